@@ -1,16 +1,25 @@
 package main
 
 import (
+	"context"
+	"crypto/rand"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 	"net/http"
 
+	"github.com/ahmednurovic/task-manager-api/internal/authz"
+	"github.com/ahmednurovic/task-manager-api/internal/blob"
 	"github.com/ahmednurovic/task-manager-api/internal/config"
 	"github.com/ahmednurovic/task-manager-api/internal/handler"
+	"github.com/ahmednurovic/task-manager-api/internal/mail"
 	"github.com/ahmednurovic/task-manager-api/internal/middleware"
+	"github.com/ahmednurovic/task-manager-api/internal/oidc"
+	"github.com/ahmednurovic/task-manager-api/internal/password"
 	"github.com/ahmednurovic/task-manager-api/internal/repository"
 	"github.com/ahmednurovic/task-manager-api/internal/service"
+	"github.com/ahmednurovic/task-manager-api/internal/signing"
 	"github.com/gin-gonic/gin"
 	"github.com/jmoiron/sqlx"
 	_ "github.com/lib/pq"
@@ -22,7 +31,7 @@ import (
 
 // @title Task Manager API
 // @version 1.0
-// @description This is a task management API with JWT authentication
+// @description This is a task management API with JWT authentication. Scripts can use personal access tokens (tm_pat_...) as bearer tokens instead. Projects and tasks live in the user's personal space unless an X-Workspace-ID header names a shared workspace the user is a member of.
 // @termsOfService http://swagger.io/terms/
 
 // @contact.name API Support
@@ -58,33 +67,209 @@ func main() {
 
 	userRepo := repository.NewUserRepository(db)
 	taskRepo := repository.NewTaskRepository(db)
-	authService := service.NewAuthService(userRepo, cfg.JWTSecret)
-	taskService := service.NewTaskService(taskRepo)
-	authMiddleware := middleware.AuthMiddleware(cfg.JWTSecret)
+	labelRepo := repository.NewLabelRepository(db)
+	projectRepo := repository.NewProjectRepository(db)
+	checklistRepo := repository.NewChecklistRepository(db)
+	dependencyRepo := repository.NewDependencyRepository(db)
+	refreshTokenRepo := repository.NewRefreshTokenRepository(db)
+	revocationRepo := repository.NewRevocationRepository(db)
+	accessTokenRepo := repository.NewPersonalAccessTokenRepository(db)
+	loginFailureRepo := repository.NewLoginFailureRepository(db)
+	userIdentityRepo := repository.NewUserIdentityRepository(db)
+	workspaceRepo := repository.NewWorkspaceRepository(db)
+	watcherRepo := repository.NewWatcherRepository(db)
+	activityRepo := repository.NewActivityRepository(db)
+	commentRepo := repository.NewCommentRepository(db)
+	attachmentRepo := repository.NewAttachmentRepository(db)
+	revocations := service.NewTokenRevocationStore(revocationRepo, cfg.RevocationCacheTTL)
+	var mailer mail.Mailer
+	switch cfg.MailDriver {
+	case "smtp":
+		mailer = mail.NewSMTPMailer(mail.SMTPConfig{
+			Host:     cfg.SMTPHost,
+			Port:     cfg.SMTPPort,
+			Username: cfg.SMTPUsername,
+			Password: cfg.SMTPPassword,
+			From:     cfg.MailFrom,
+		})
+	default:
+		mailLog := os.Stdout
+		if cfg.MailLogFile != "" {
+			mailLog, err = os.OpenFile(cfg.MailLogFile, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+			if err != nil {
+				logger.Fatal("Failed to open mail log file", zap.Error(err))
+			}
+			defer mailLog.Close()
+		}
+		mailer = mail.NewLogMailer(mailLog)
+	}
 
-	taskHandler := handler.NewTaskHandler(taskService)
+	var blobStore blob.BlobStore
+	switch cfg.BlobDriver {
+	case "s3":
+		blobStore = blob.NewS3Store(blob.S3Config{
+			Endpoint:        cfg.S3Endpoint,
+			Region:          cfg.S3Region,
+			Bucket:          cfg.S3Bucket,
+			AccessKeyID:     cfg.S3AccessKeyID,
+			SecretAccessKey: cfg.S3SecretAccessKey,
+			PathStyle:       cfg.S3PathStyle,
+		}, &http.Client{Timeout: 5 * time.Minute})
+	default:
+		if err := os.MkdirAll(cfg.BlobLocalDir, 0o700); err != nil {
+			logger.Fatal("Failed to create blob directory", zap.Error(err))
+		}
+		blobStore = blob.NewLocalStore(cfg.BlobLocalDir)
+	}
 
-	router := gin.New()
-	router.Use(gin.Recovery())
-	router.Use(middleware.ZapLogger(logger))
-	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
-	api := router.Group("/api/v1")
-	{
-		auth := api.Group("/auth")
-		{
-			auth.POST("/register", handler.Register(authService))
-			auth.POST("/login", handler.Login(authService))
+	attachmentURLSecret := []byte(cfg.AttachmentURLSecret)
+	if len(attachmentURLSecret) == 0 {
+		logger.Warn("ATTACHMENT_URL_SECRET is not set; download links will stop working on restart")
+		attachmentURLSecret = make([]byte, 32)
+		if _, err := rand.Read(attachmentURLSecret); err != nil {
+			logger.Fatal("Failed to generate attachment URL secret", zap.Error(err))
 		}
+	}
 
-		tasks := api.Group("/tasks").Use(authMiddleware)
-		{
-			tasks.POST("", taskHandler.CreateTask)
-			tasks.GET("", taskHandler.GetTasks)
-			tasks.PUT("/:id", taskHandler.UpdateTask)
-			tasks.DELETE("/:id", taskHandler.DeleteTask)
+	keys := signing.NewHMAC(cfg.JWTSecret)
+	if cfg.JWTAlgorithm != signing.HS256 {
+		keys, err = signing.LoadFiles(cfg.JWTAlgorithm, cfg.JWTSigningKeyFile, cfg.JWTVerificationKeyFiles)
+		if err != nil {
+			logger.Fatal("Failed to load JWT signing keys", zap.Error(err))
+		}
+		if cfg.JWTSecret != "" {
+			keys.AcceptHMAC(cfg.JWTSecret)
+		}
+	}
+
+	passwordHasher, err := password.New(cfg.PasswordHashAlgorithm, password.Argon2id{
+		Memory:      cfg.Argon2Memory,
+		Iterations:  cfg.Argon2Iterations,
+		Parallelism: cfg.Argon2Parallelism,
+		SaltLength:  password.DefaultArgon2id.SaltLength,
+		KeyLength:   password.DefaultArgon2id.KeyLength,
+	}, cfg.BcryptCost)
+	if err != nil {
+		logger.Fatal("Invalid password hashing settings", zap.Error(err))
+	}
+	passwordPolicy := password.Policy{MinLength: cfg.PasswordMinLength, MaxLength: cfg.PasswordMaxLength}
+	if cfg.PasswordCommonListFile != "" {
+		passwordPolicy.Common, err = password.LoadCommon(cfg.PasswordCommonListFile)
+		if err != nil {
+			logger.Fatal("Failed to load common password list", zap.Error(err))
 		}
+		logger.Info("Loaded common password list", zap.Int("passwords", len(passwordPolicy.Common)))
 	}
 
+	loginThrottle := service.NewLoginThrottle(loginFailureRepo, service.LoginThrottlePolicy{
+		MaxFailures:      cfg.LoginMaxFailures,
+		MaxFailuresPerIP: cfg.LoginMaxFailuresPerIP,
+		Window:           cfg.LoginFailureWindow,
+		LockoutDuration:  cfg.LoginLockoutDuration,
+		BackoffBase:      cfg.LoginBackoffBase,
+	}, func(event service.SecurityEvent) {
+		logger.Warn("Security event",
+			zap.String("event", event.Type),
+			zap.String("scope", event.Scope),
+			zap.String("identifier", event.Identifier),
+			zap.Int("failures", event.Failures),
+			zap.Time("until", event.Until),
+			zap.Int64("actor_id", event.ActorID),
+		)
+	})
+
+	authService := service.NewAuthService(userRepo, refreshTokenRepo, revocations, loginThrottle, mailer, keys, service.AuthPolicy{
+		AccessTokenTTL:   cfg.AccessTokenTTL,
+		RefreshTokenTTL:  cfg.RefreshTokenTTL,
+		PasswordResetTTL: cfg.PasswordResetTTL,
+		PasswordResetURL: cfg.PasswordResetURL,
+
+		EmailVerification:          service.EmailVerificationPolicy(cfg.EmailVerification),
+		EmailVerificationTTL:       cfg.EmailVerificationTTL,
+		EmailVerificationURL:       cfg.EmailVerificationURL,
+		VerificationResendInterval: cfg.VerificationResendInterval,
+		OnMailError: func(err error) {
+			logger.Error("Failed to send email", zap.Error(err))
+		},
+
+		MFAIssuer:       cfg.MFAIssuer,
+		MFAChallengeTTL: cfg.MFAChallengeTTL,
+
+		PasswordHasher: passwordHasher,
+		PasswordPolicy: passwordPolicy,
+	})
+	authorizer := authz.New(authz.DefaultPolicy, logger.Named("authz"))
+	taskService := service.NewTaskService(taskRepo, projectRepo, dependencyRepo, workspaceRepo, activityRepo, labelRepo, authorizer, service.TaskPolicy{
+		RequireSubtasksClosed: cfg.RequireSubtasksClosed,
+		MaxSubtaskDepth:       cfg.MaxSubtaskDepth,
+	})
+	labelService := service.NewLabelService(labelRepo, taskRepo, authorizer)
+	projectService := service.NewProjectService(projectRepo, authorizer)
+	checklistService := service.NewChecklistService(checklistRepo, taskRepo, authorizer)
+	watcherService := service.NewWatcherService(watcherRepo, taskRepo, authorizer)
+	commentService := service.NewCommentService(commentRepo, taskRepo, workspaceRepo, userRepo, authorizer)
+	attachmentService := service.NewAttachmentService(attachmentRepo, taskRepo, blobStore, authorizer, service.AttachmentPolicy{
+		MaxSize:      cfg.AttachmentMaxSize,
+		AllowedTypes: cfg.AttachmentAllowedTypes,
+		URLTTL:       cfg.AttachmentURLTTL,
+		URLSecret:    attachmentURLSecret,
+	})
+	accessTokenService := service.NewPersonalAccessTokenService(accessTokenRepo)
+	workspaceService := service.NewWorkspaceService(workspaceRepo, userRepo, mailer, authorizer, service.WorkspacePolicy{
+		InvitationTTL: cfg.WorkspaceInvitationTTL,
+		InvitationURL: cfg.WorkspaceInvitationURL,
+	})
+
+	providers := make(map[string]*oidc.Provider, len(cfg.OIDC))
+	providerClient := &http.Client{Timeout: 10 * time.Second}
+	for name, provider := range cfg.OIDC {
+		providers[name] = oidc.NewProvider(oidc.Config{
+			Issuer:       provider.Issuer,
+			ClientID:     provider.ClientID,
+			ClientSecret: provider.ClientSecret,
+			RedirectURL:  provider.RedirectURL,
+			Scopes:       provider.Scopes,
+		}, providerClient)
+	}
+	oidcService := service.NewOIDCService(authService, userRepo, userIdentityRepo, providers, keys)
+	authMiddleware := middleware.AuthMiddleware(keys, revocations, accessTokenService, workspaceService)
+
+	cleanupCtx, stopCleanup := context.WithCancel(context.Background())
+	defer stopCleanup()
+	go revocations.RunCleanup(cleanupCtx, cfg.RevocationCleanupInterval, func(err error) {
+		logger.Error("Failed to clean up revoked tokens", zap.Error(err))
+	})
+	go loginThrottle.RunCleanup(cleanupCtx, cfg.RevocationCleanupInterval, func(err error) {
+		logger.Error("Failed to clean up login failures", zap.Error(err))
+	})
+	go attachmentService.RunGarbageCollection(cleanupCtx, cfg.AttachmentGCInterval, func(err error) {
+		logger.Error("Failed to collect attachment garbage", zap.Error(err))
+	})
+
+	router := gin.New()
+	if err := router.SetTrustedProxies(cfg.TrustedProxies); err != nil {
+		logger.Fatal("Invalid trusted proxies", zap.Error(err))
+	}
+	router.Use(gin.Recovery())
+	router.Use(middleware.ZapLogger(logger))
+	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
+	handler.Routes{
+		Authenticate: authMiddleware,
+		Authorizer:   authorizer,
+		Auth:         authService,
+		OIDC:         oidcService,
+		Keys:         keys,
+		Tasks:        handler.NewTaskHandler(taskService),
+		Labels:       handler.NewLabelHandler(labelService),
+		Projects:     handler.NewProjectHandler(projectService),
+		Checklists:   handler.NewChecklistHandler(checklistService),
+		Watchers:     handler.NewWatcherHandler(watcherService),
+		Comments:     handler.NewCommentHandler(commentService),
+		Attachments:  handler.NewAttachmentHandler(attachmentService, cfg.AttachmentMaxSize),
+		AccessTokens: handler.NewPersonalAccessTokenHandler(accessTokenService),
+		Workspaces:   handler.NewWorkspaceHandler(workspaceService),
+	}.Register(router)
+
 	srv := &http.Server{
 		Addr:    ":" + cfg.Port,
 		Handler: router,
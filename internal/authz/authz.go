@@ -0,0 +1,128 @@
+// Package authz decides what a user may do with a resource.
+//
+// Permissions are granted to workspace roles by a Policy rather than
+// checked by hand wherever a resource is used. A user acts either in their
+// personal space, where they own everything they can see, or in one
+// workspace, with their role in it. Resources outside the space a request
+// acts in are never accessible, whatever the role.
+package authz
+
+import (
+	"github.com/ahmednurovic/task-manager-api/internal/model"
+	"go.uber.org/zap"
+)
+
+// Action is something a principal can be allowed to do. Actions are named
+// resource:verb, like personal access token scopes.
+type Action string
+
+const (
+	ReadTasks  Action = "tasks:read"
+	WriteTasks Action = "tasks:write"
+	// WatchTasks covers following and unfollowing a task, which only
+	// concerns the user themselves.
+	WatchTasks Action = "tasks:watch"
+	// ModerateComments covers editing and deleting other users' comments.
+	ModerateComments Action = "comments:moderate"
+	ReadProjects     Action = "projects:read"
+	WriteProjects    Action = "projects:write"
+	// ReadWorkspace covers a workspace's details and member list.
+	ReadWorkspace Action = "workspace:read"
+	// ManageMembers covers inviting members, changing their roles and
+	// removing them.
+	ManageMembers Action = "workspace:members"
+	// ManageWorkspace covers renaming and deleting a workspace and
+	// appointing or demoting owners.
+	ManageWorkspace Action = "workspace:manage"
+)
+
+// Policy maps each workspace role to the actions it allows.
+type Policy map[model.WorkspaceRole][]Action
+
+// DefaultPolicy gives each role everything the role below it may do, plus
+// the actions described on the role.
+var DefaultPolicy = Policy{
+	model.RoleViewer: {ReadTasks, WatchTasks, ReadProjects, ReadWorkspace},
+	model.RoleMember: {ReadTasks, WriteTasks, WatchTasks, ReadProjects, ReadWorkspace},
+	model.RoleAdmin:  {ReadTasks, WriteTasks, WatchTasks, ModerateComments, ReadProjects, WriteProjects, ReadWorkspace, ManageMembers},
+	model.RoleOwner:  {ReadTasks, WriteTasks, WatchTasks, ModerateComments, ReadProjects, WriteProjects, ReadWorkspace, ManageMembers, ManageWorkspace},
+}
+
+// Principal is the user a request acts for. WorkspaceID is the workspace
+// the request acts in and Role the user's role there; a nil WorkspaceID
+// means the user's personal space.
+type Principal struct {
+	UserID      uint
+	WorkspaceID *uint
+	Role        model.WorkspaceRole
+}
+
+// Resource is what an action is performed on. Type and ID only identify
+// it in the audit log. A nil WorkspaceID means the resource is in
+// OwnerID's personal space.
+type Resource struct {
+	Type        string
+	ID          uint
+	OwnerID     uint
+	WorkspaceID *uint
+}
+
+// Space is the space the principal acts in, for actions such as listing or
+// creating that do not concern an existing resource.
+func Space(p Principal) Resource {
+	return Resource{Type: "space", OwnerID: p.UserID, WorkspaceID: p.WorkspaceID}
+}
+
+type Authorizer struct {
+	grants map[model.WorkspaceRole]map[Action]bool
+	logger *zap.Logger
+}
+
+// New returns an Authorizer for policy that writes every denial to logger.
+func New(policy Policy, logger *zap.Logger) *Authorizer {
+	grants := make(map[model.WorkspaceRole]map[Action]bool, len(policy))
+	for role, actions := range policy {
+		grants[role] = make(map[Action]bool, len(actions))
+		for _, action := range actions {
+			grants[role][action] = true
+		}
+	}
+	return &Authorizer{grants: grants, logger: logger}
+}
+
+// Can reports whether p may perform action on r. A user is the owner of
+// their personal space.
+func (a *Authorizer) Can(p Principal, action Action, r Resource) bool {
+	role := roleFor(p, r)
+	if a.grants[role][action] {
+		return true
+	}
+
+	fields := []zap.Field{
+		zap.Uint("user_id", p.UserID),
+		zap.String("action", string(action)),
+		zap.String("resource", r.Type),
+		zap.Uint("resource_id", r.ID),
+		zap.String("role", string(role)),
+	}
+	if p.WorkspaceID != nil {
+		fields = append(fields, zap.Uint("workspace_id", *p.WorkspaceID))
+	}
+	a.logger.Warn("Authorization denied", fields...)
+	return false
+}
+
+// roleFor returns the role p holds over r, or "" if r is outside the space
+// p acts in.
+func roleFor(p Principal, r Resource) model.WorkspaceRole {
+	if p.WorkspaceID == nil {
+		if r.WorkspaceID == nil && r.OwnerID == p.UserID {
+			return model.RoleOwner
+		}
+		return ""
+	}
+	if r.WorkspaceID != nil && *r.WorkspaceID == *p.WorkspaceID {
+		return p.Role
+	}
+	return ""
+}
@@ -0,0 +1,92 @@
+package authz
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+
+	"github.com/ahmednurovic/task-manager-api/internal/model"
+)
+
+func TestDefaultPolicy(t *testing.T) {
+	allActions := []Action{ReadTasks, WriteTasks, WatchTasks, ModerateComments, ReadProjects, WriteProjects, ReadWorkspace, ManageMembers, ManageWorkspace}
+	// Each role is listed with the actions it must be allowed; every other
+	// action must be denied.
+	allowed := map[model.WorkspaceRole][]Action{
+		model.RoleViewer: {ReadTasks, WatchTasks, ReadProjects, ReadWorkspace},
+		model.RoleMember: {ReadTasks, WriteTasks, WatchTasks, ReadProjects, ReadWorkspace},
+		model.RoleAdmin:  {ReadTasks, WriteTasks, WatchTasks, ModerateComments, ReadProjects, WriteProjects, ReadWorkspace, ManageMembers},
+		model.RoleOwner:  allActions,
+		"":               nil,
+		"superuser":      nil,
+	}
+
+	authorizer := New(DefaultPolicy, zap.NewNop())
+	workspaceID := uint(3)
+	resource := Resource{Type: "task", ID: 10, OwnerID: 1, WorkspaceID: &workspaceID}
+
+	for role, actions := range allowed {
+		principal := Principal{UserID: 2, WorkspaceID: &workspaceID, Role: role}
+		for _, action := range allActions {
+			assert.Equal(t, slices.Contains(actions, action), authorizer.Can(principal, action, resource), "%q %s", role, action)
+		}
+	}
+}
+
+func TestAuthorizerSpaces(t *testing.T) {
+	authorizer := New(DefaultPolicy, zap.NewNop())
+	workspaceID, otherWorkspaceID := uint(3), uint(4)
+	personal := Principal{UserID: 2}
+	inWorkspace := Principal{UserID: 2, WorkspaceID: &workspaceID, Role: model.RoleOwner}
+
+	tests := []struct {
+		name      string
+		principal Principal
+		resource  Resource
+		want      bool
+	}{
+		{"Own Personal Resource", personal, Resource{OwnerID: 2}, true},
+		{"Someone Else's Personal Resource", personal, Resource{OwnerID: 1}, false},
+		{"Workspace Resource From Personal Space", personal, Resource{OwnerID: 2, WorkspaceID: &workspaceID}, false},
+		{"Own Personal Resource From Workspace", inWorkspace, Resource{OwnerID: 2}, false},
+		{"Resource In Active Workspace", inWorkspace, Resource{OwnerID: 1, WorkspaceID: &workspaceID}, true},
+		{"Resource In Other Workspace", inWorkspace, Resource{OwnerID: 2, WorkspaceID: &otherWorkspaceID}, false},
+		{"Personal Space", personal, Space(personal), true},
+		{"Workspace Space", inWorkspace, Space(inWorkspace), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, authorizer.Can(tt.principal, ManageWorkspace, tt.resource))
+		})
+	}
+}
+
+func TestAuthorizerLogsDenials(t *testing.T) {
+	core, logs := observer.New(zapcore.InfoLevel)
+	authorizer := New(DefaultPolicy, zap.New(core))
+	workspaceID := uint(3)
+	viewer := Principal{UserID: 2, WorkspaceID: &workspaceID, Role: model.RoleViewer}
+	task := Resource{Type: "task", ID: 10, OwnerID: 1, WorkspaceID: &workspaceID}
+
+	assert.True(t, authorizer.Can(viewer, ReadTasks, task))
+	assert.Zero(t, logs.Len(), "allowed actions are not logged")
+
+	assert.False(t, authorizer.Can(viewer, WriteTasks, task))
+	if assert.Equal(t, 1, logs.Len()) {
+		entry := logs.All()[0]
+		assert.Equal(t, zapcore.WarnLevel, entry.Level)
+		assert.Equal(t, map[string]interface{}{
+			"user_id":      uint64(2),
+			"action":       "tasks:write",
+			"resource":     "task",
+			"resource_id":  uint64(10),
+			"role":         "viewer",
+			"workspace_id": uint64(3),
+		}, entry.ContextMap())
+	}
+}
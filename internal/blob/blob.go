@@ -0,0 +1,41 @@
+// Package blob stores the bytes of uploaded files, such as task
+// attachments, behind one interface so that where they live is a matter
+// of configuration: a local directory for development and single-server
+// setups, or any S3-compatible object store.
+//
+// Keys are slash-separated paths like "attachments/3f2a...", chosen by the
+// caller. Stores keep no metadata of their own; content types and
+// checksums belong in the database next to the key.
+package blob
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+)
+
+var (
+	// ErrNotFound is returned by Open for a key with no blob.
+	ErrNotFound = errors.New("blob not found")
+	// ErrInvalidKey is returned for keys that are not clean relative
+	// paths.
+	ErrInvalidKey = errors.New("invalid blob key")
+)
+
+type BlobStore interface {
+	// Put stores size bytes read from r under key, replacing any blob
+	// already there.
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	// Open returns the blob stored under key. The caller must close it.
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete removes the blob stored under key. Deleting a key with no
+	// blob is a no-op.
+	Delete(ctx context.Context, key string) error
+}
+
+// validKey reports whether key is a clean relative path, such as
+// "attachments/3f2a", that cannot escape the store.
+func validKey(key string) bool {
+	return key != "." && fs.ValidPath(key)
+}
@@ -0,0 +1,103 @@
+package blob_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ahmednurovic/task-manager-api/internal/blob"
+	"github.com/ahmednurovic/task-manager-api/internal/blob/s3test"
+)
+
+// testStore checks the behaviour every BlobStore must share.
+func testStore(t *testing.T, store blob.BlobStore) {
+	ctx := context.Background()
+
+	t.Run("Round Trip", func(t *testing.T) {
+		require.NoError(t, store.Put(ctx, "attachments/ab/report.txt", strings.NewReader("quarterly numbers"), 17, "text/plain"))
+
+		r, err := store.Open(ctx, "attachments/ab/report.txt")
+		require.NoError(t, err)
+		data, err := io.ReadAll(r)
+		r.Close()
+		require.NoError(t, err)
+		assert.Equal(t, "quarterly numbers", string(data))
+	})
+
+	t.Run("Replace", func(t *testing.T) {
+		require.NoError(t, store.Put(ctx, "attachments/replaced", strings.NewReader("old"), 3, "text/plain"))
+		require.NoError(t, store.Put(ctx, "attachments/replaced", strings.NewReader("new"), 3, "text/plain"))
+
+		r, err := store.Open(ctx, "attachments/replaced")
+		require.NoError(t, err)
+		data, _ := io.ReadAll(r)
+		r.Close()
+		assert.Equal(t, "new", string(data))
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		require.NoError(t, store.Put(ctx, "attachments/deleted", strings.NewReader("gone"), 4, "text/plain"))
+		require.NoError(t, store.Delete(ctx, "attachments/deleted"))
+
+		_, err := store.Open(ctx, "attachments/deleted")
+		assert.ErrorIs(t, err, blob.ErrNotFound)
+		assert.NoError(t, store.Delete(ctx, "attachments/deleted"), "deleting twice is a no-op")
+	})
+
+	t.Run("Missing", func(t *testing.T) {
+		_, err := store.Open(ctx, "attachments/missing")
+		assert.ErrorIs(t, err, blob.ErrNotFound)
+	})
+
+	t.Run("Invalid Keys", func(t *testing.T) {
+		for _, key := range []string{"", ".", "/etc/passwd", "../outside", "attachments/../../outside", "attachments//double"} {
+			assert.ErrorIs(t, store.Put(ctx, key, strings.NewReader("x"), 1, "text/plain"), blob.ErrInvalidKey, key)
+			_, err := store.Open(ctx, key)
+			assert.ErrorIs(t, err, blob.ErrInvalidKey, key)
+		}
+	})
+}
+
+func TestLocalStore(t *testing.T) {
+	testStore(t, blob.NewLocalStore(t.TempDir()))
+
+	t.Run("Short Upload", func(t *testing.T) {
+		store := blob.NewLocalStore(t.TempDir())
+		err := store.Put(context.Background(), "attachments/short", strings.NewReader("abc"), 10, "text/plain")
+		assert.Error(t, err)
+
+		_, err = store.Open(context.Background(), "attachments/short")
+		assert.ErrorIs(t, err, blob.ErrNotFound, "a failed upload leaves nothing behind")
+	})
+}
+
+func TestS3Store(t *testing.T) {
+	server := s3test.NewServer(t)
+	testStore(t, blob.NewS3Store(server.Config(), http.DefaultClient))
+
+	t.Run("Content Type", func(t *testing.T) {
+		store := blob.NewS3Store(server.Config(), http.DefaultClient)
+		require.NoError(t, store.Put(context.Background(), "attachments/shot.png", strings.NewReader("png"), 3, "image/png"))
+
+		data, contentType, ok := server.Object("attachments/shot.png")
+		assert.True(t, ok)
+		assert.Equal(t, "png", string(data))
+		assert.Equal(t, "image/png", contentType)
+	})
+
+	t.Run("Wrong Secret", func(t *testing.T) {
+		cfg := server.Config()
+		cfg.SecretAccessKey = "guessed"
+		store := blob.NewS3Store(cfg, http.DefaultClient)
+
+		err := store.Put(context.Background(), "attachments/forged", strings.NewReader("x"), 1, "text/plain")
+		assert.ErrorContains(t, err, "SignatureDoesNotMatch")
+		_, _, ok := server.Object("attachments/forged")
+		assert.False(t, ok)
+	})
+}
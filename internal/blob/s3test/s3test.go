@@ -0,0 +1,170 @@
+// Package s3test runs an in-process stand-in for an S3-compatible object
+// store for tests. It serves one bucket with path-style addressing, keeps
+// objects in memory and refuses requests without a valid AWS Signature
+// Version 4.
+package s3test
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/ahmednurovic/task-manager-api/internal/blob"
+)
+
+const (
+	Bucket          = "test-bucket"
+	Region          = "us-east-1"
+	AccessKeyID     = "test-access-key"
+	SecretAccessKey = "test-secret-key"
+)
+
+type object struct {
+	data        []byte
+	contentType string
+}
+
+// Server is a fake object store.
+type Server struct {
+	*httptest.Server
+
+	mu      sync.Mutex
+	objects map[string]object
+}
+
+// NewServer starts a store that is closed when the test ends.
+func NewServer(t *testing.T) *Server {
+	t.Helper()
+
+	s := &Server{objects: make(map[string]object)}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serve))
+	t.Cleanup(s.Close)
+
+	return s
+}
+
+// Config is the configuration of a client of the store.
+func (s *Server) Config() blob.S3Config {
+	return blob.S3Config{
+		Endpoint:        s.URL,
+		Region:          Region,
+		Bucket:          Bucket,
+		AccessKeyID:     AccessKeyID,
+		SecretAccessKey: SecretAccessKey,
+		PathStyle:       true,
+	}
+}
+
+// Object returns the object stored under key and its content type.
+func (s *Server) Object(key string) (data []byte, contentType string, ok bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	obj, ok := s.objects[key]
+	return obj.data, obj.contentType, ok
+}
+
+func (s *Server) serve(w http.ResponseWriter, r *http.Request) {
+	bucket, key, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/")
+	if bucket != Bucket {
+		writeError(w, http.StatusNotFound, "NoSuchBucket")
+		return
+	}
+	if !validSignature(r) {
+		writeError(w, http.StatusForbidden, "SignatureDoesNotMatch")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch r.Method {
+	case http.MethodPut:
+		data, err := io.ReadAll(r.Body)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "IncompleteBody")
+			return
+		}
+		s.objects[key] = object{data: data, contentType: r.Header.Get("Content-Type")}
+		w.WriteHeader(http.StatusOK)
+	case http.MethodGet:
+		obj, ok := s.objects[key]
+		if !ok {
+			writeError(w, http.StatusNotFound, "NoSuchKey")
+			return
+		}
+		w.Header().Set("Content-Type", obj.contentType)
+		w.Write(obj.data)
+	case http.MethodDelete:
+		delete(s.objects, key)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "MethodNotAllowed")
+	}
+}
+
+// validSignature recomputes the request's signature from the headers it
+// claims to have signed.
+func validSignature(r *http.Request) bool {
+	fields := map[string]string{}
+	for _, field := range strings.Split(strings.TrimPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 "), ", ") {
+		name, value, _ := strings.Cut(field, "=")
+		fields[name] = value
+	}
+	accessKeyID, scope, _ := strings.Cut(fields["Credential"], "/")
+	date, _, _ := strings.Cut(scope, "/")
+	if accessKeyID != AccessKeyID || scope != date+"/"+Region+"/s3/aws4_request" {
+		return false
+	}
+
+	signedHeaders := strings.Split(fields["SignedHeaders"], ";")
+	var canonicalHeaders strings.Builder
+	for _, name := range signedHeaders {
+		value := r.Header.Get(name)
+		if name == "host" {
+			value = r.Host
+		}
+		canonicalHeaders.WriteString(name + ":" + strings.TrimSpace(value) + "\n")
+	}
+
+	query := r.URL.Query()
+	pairs := []string{}
+	for name, values := range query {
+		for _, value := range values {
+			pairs = append(pairs, name+"="+value)
+		}
+	}
+	sort.Strings(pairs)
+
+	canonicalRequest := strings.Join([]string{
+		r.Method,
+		r.URL.EscapedPath(),
+		strings.Join(pairs, "&"),
+		canonicalHeaders.String(),
+		fields["SignedHeaders"],
+		r.Header.Get("X-Amz-Content-Sha256"),
+	}, "\n")
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + r.Header.Get("X-Amz-Date") + "\n" + scope + "\n" + hex.EncodeToString(requestHash[:])
+
+	key := []byte("AWS4" + SecretAccessKey)
+	for _, part := range []string{date, Region, "s3", "aws4_request", stringToSign} {
+		mac := hmac.New(sha256.New, key)
+		mac.Write([]byte(part))
+		key = mac.Sum(nil)
+	}
+	return hmac.Equal([]byte(hex.EncodeToString(key)), []byte(fields["Signature"]))
+}
+
+func writeError(w http.ResponseWriter, status int, code string) {
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(status)
+	fmt.Fprintf(w, "<Error><Code>%s</Code></Error>", code)
+}
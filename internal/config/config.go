@@ -3,12 +3,133 @@ package config
 import (
 	"github.com/spf13/viper"
 	"fmt"
+	"strings"
+	"time"
 )
 
 type Config struct {
 	DBURL     string `mapstructure:"DB_URL"`
 	Port      string `mapstructure:"PORT"`
 	JWTSecret string `mapstructure:"JWT_SECRET"`
+
+	// JWTAlgorithm is HS256, signing with JWTSecret, or RS256 or EdDSA,
+	// signing with the private key in JWTSigningKeyFile. Tokens signed with
+	// any of JWTVerificationKeyFiles are accepted too, which is how keys are
+	// rotated. With RS256 or EdDSA, HS256 tokens signed with JWTSecret are
+	// still accepted while it is set, for switching away from HS256.
+	JWTAlgorithm            string   `mapstructure:"JWT_ALGORITHM"`
+	JWTSigningKeyFile       string   `mapstructure:"JWT_SIGNING_KEY_FILE"`
+	JWTVerificationKeyFiles []string `mapstructure:"JWT_VERIFICATION_KEY_FILES"`
+
+	// OIDCProviders names the OpenID Connect providers users can sign in
+	// with, such as "google,okta". Each is set up with OIDC_<NAME>_ISSUER,
+	// _CLIENT_ID, _CLIENT_SECRET, _REDIRECT_URL and _SCOPES, read into OIDC.
+	OIDCProviders []string                `mapstructure:"OIDC_PROVIDERS"`
+	OIDC          map[string]OIDCProvider `mapstructure:"-"`
+
+	AccessTokenTTL  time.Duration `mapstructure:"ACCESS_TOKEN_TTL"`
+	RefreshTokenTTL time.Duration `mapstructure:"REFRESH_TOKEN_TTL"`
+
+	// RevocationCacheTTL is how long this instance trusts a cached "not
+	// revoked" answer, and so how long a logout elsewhere can take to apply.
+	RevocationCacheTTL        time.Duration `mapstructure:"REVOCATION_CACHE_TTL"`
+	RevocationCleanupInterval time.Duration `mapstructure:"REVOCATION_CLEANUP_INTERVAL"`
+
+	// A failed login makes the account wait LOGIN_BACKOFF_BASE, doubling
+	// with each further failure. LOGIN_MAX_FAILURES failures for an account,
+	// or LOGIN_MAX_FAILURES_PER_IP from one address, within
+	// LOGIN_FAILURE_WINDOW lock it for LOGIN_LOCKOUT_DURATION.
+	LoginMaxFailures      int           `mapstructure:"LOGIN_MAX_FAILURES"`
+	LoginMaxFailuresPerIP int           `mapstructure:"LOGIN_MAX_FAILURES_PER_IP"`
+	LoginFailureWindow    time.Duration `mapstructure:"LOGIN_FAILURE_WINDOW"`
+	LoginLockoutDuration  time.Duration `mapstructure:"LOGIN_LOCKOUT_DURATION"`
+	LoginBackoffBase      time.Duration `mapstructure:"LOGIN_BACKOFF_BASE"`
+
+	// TrustedProxies lists the proxies whose X-Forwarded-For header is
+	// believed when working out a client's IP address. When empty, the
+	// address of the connection is used.
+	TrustedProxies []string `mapstructure:"TRUSTED_PROXIES"`
+
+	// PasswordHashAlgorithm is argon2id or bcrypt. Hashes made with the
+	// other one, or with other parameters, are replaced as users log in.
+	// ARGON2_MEMORY is in KiB.
+	PasswordHashAlgorithm string `mapstructure:"PASSWORD_HASH_ALGORITHM"`
+	Argon2Memory          uint32 `mapstructure:"ARGON2_MEMORY"`
+	Argon2Iterations      uint32 `mapstructure:"ARGON2_ITERATIONS"`
+	Argon2Parallelism     uint8  `mapstructure:"ARGON2_PARALLELISM"`
+	BcryptCost            int    `mapstructure:"BCRYPT_COST"`
+
+	// Password lengths are in characters. Passwords listed in
+	// PasswordCommonListFile, one per line, are refused.
+	PasswordMinLength      int    `mapstructure:"PASSWORD_MIN_LENGTH"`
+	PasswordMaxLength      int    `mapstructure:"PASSWORD_MAX_LENGTH"`
+	PasswordCommonListFile string `mapstructure:"PASSWORD_COMMON_LIST_FILE"`
+
+	PasswordResetTTL time.Duration `mapstructure:"PASSWORD_RESET_TTL"`
+	PasswordResetURL string        `mapstructure:"PASSWORD_RESET_URL"`
+
+	// EmailVerification is allow, read_only or block, and decides what
+	// users who have not verified their email may do.
+	EmailVerification          string        `mapstructure:"EMAIL_VERIFICATION"`
+	EmailVerificationTTL       time.Duration `mapstructure:"EMAIL_VERIFICATION_TTL"`
+	EmailVerificationURL       string        `mapstructure:"EMAIL_VERIFICATION_URL"`
+	VerificationResendInterval time.Duration `mapstructure:"VERIFICATION_RESEND_INTERVAL"`
+
+	// MFAIssuer is the name authenticator apps show for this service.
+	MFAIssuer       string        `mapstructure:"MFA_ISSUER"`
+	MFAChallengeTTL time.Duration `mapstructure:"MFA_CHALLENGE_TTL"`
+
+	// MailDriver is "smtp" to send mail, or "log" to write it to MailLogFile
+	// (standard output when empty) for development.
+	MailDriver   string `mapstructure:"MAIL_DRIVER"`
+	MailLogFile  string `mapstructure:"MAIL_LOG_FILE"`
+	MailFrom     string `mapstructure:"MAIL_FROM"`
+	SMTPHost     string `mapstructure:"SMTP_HOST"`
+	SMTPPort     int    `mapstructure:"SMTP_PORT"`
+	SMTPUsername string `mapstructure:"SMTP_USERNAME"`
+	SMTPPassword string `mapstructure:"SMTP_PASSWORD"`
+
+	// RequireSubtasksClosed stops a task from being marked done while it
+	// has open subtasks.
+	RequireSubtasksClosed bool `mapstructure:"REQUIRE_SUBTASKS_CLOSED"`
+	MaxSubtaskDepth       int  `mapstructure:"MAX_SUBTASK_DEPTH"`
+
+	WorkspaceInvitationTTL time.Duration `mapstructure:"WORKSPACE_INVITATION_TTL"`
+	WorkspaceInvitationURL string        `mapstructure:"WORKSPACE_INVITATION_URL"`
+
+	// BlobDriver is "local", keeping uploaded files under BlobLocalDir, or
+	// "s3" for an S3-compatible object store. S3PathStyle addresses the
+	// bucket as S3Endpoint/S3Bucket, which most self-hosted stores need.
+	BlobDriver        string `mapstructure:"BLOB_DRIVER"`
+	BlobLocalDir      string `mapstructure:"BLOB_LOCAL_DIR"`
+	S3Endpoint        string `mapstructure:"S3_ENDPOINT"`
+	S3Region          string `mapstructure:"S3_REGION"`
+	S3Bucket          string `mapstructure:"S3_BUCKET"`
+	S3AccessKeyID     string `mapstructure:"S3_ACCESS_KEY_ID"`
+	S3SecretAccessKey string `mapstructure:"S3_SECRET_ACCESS_KEY"`
+	S3PathStyle       bool   `mapstructure:"S3_PATH_STYLE"`
+
+	// Attachments may be at most AttachmentMaxSize bytes and must sniff as
+	// one of AttachmentAllowedTypes. Download links last AttachmentURLTTL
+	// and are signed with AttachmentURLSecret; when it is empty a random
+	// secret is used, and links only work on the instance that made them.
+	AttachmentMaxSize      int64         `mapstructure:"ATTACHMENT_MAX_SIZE"`
+	AttachmentAllowedTypes []string      `mapstructure:"ATTACHMENT_ALLOWED_TYPES"`
+	AttachmentURLTTL       time.Duration `mapstructure:"ATTACHMENT_URL_TTL"`
+	AttachmentURLSecret    string        `mapstructure:"ATTACHMENT_URL_SECRET"`
+	// AttachmentGCInterval is how often the blobs of deleted attachments
+	// and tasks are removed from storage.
+	AttachmentGCInterval time.Duration `mapstructure:"ATTACHMENT_GC_INTERVAL"`
+}
+
+// OIDCProvider is a client registration with an OpenID Connect provider.
+// RedirectURL must point at /api/v1/auth/oidc/<name>/callback.
+type OIDCProvider struct {
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string
 }
 
 func Load() (*Config, error) {
@@ -17,6 +138,46 @@ func Load() (*Config, error) {
 	viper.SetConfigType("env")    
 
 	viper.AutomaticEnv()
+	viper.SetDefault("JWT_ALGORITHM", "HS256")
+	viper.SetDefault("ACCESS_TOKEN_TTL", "15m")
+	viper.SetDefault("REFRESH_TOKEN_TTL", "720h")
+	viper.SetDefault("REVOCATION_CACHE_TTL", "30s")
+	viper.SetDefault("REVOCATION_CLEANUP_INTERVAL", "1h")
+	viper.SetDefault("LOGIN_MAX_FAILURES", 5)
+	viper.SetDefault("LOGIN_MAX_FAILURES_PER_IP", 20)
+	viper.SetDefault("LOGIN_FAILURE_WINDOW", "15m")
+	viper.SetDefault("LOGIN_LOCKOUT_DURATION", "15m")
+	viper.SetDefault("LOGIN_BACKOFF_BASE", "1s")
+	viper.SetDefault("PASSWORD_HASH_ALGORITHM", "argon2id")
+	viper.SetDefault("ARGON2_MEMORY", 65536)
+	viper.SetDefault("ARGON2_ITERATIONS", 3)
+	viper.SetDefault("ARGON2_PARALLELISM", 2)
+	viper.SetDefault("BCRYPT_COST", 10)
+	viper.SetDefault("PASSWORD_MIN_LENGTH", 8)
+	viper.SetDefault("PASSWORD_MAX_LENGTH", 128)
+	viper.SetDefault("PASSWORD_RESET_TTL", "30m")
+	viper.SetDefault("PASSWORD_RESET_URL", "http://localhost:3000/reset-password")
+	viper.SetDefault("EMAIL_VERIFICATION", "allow")
+	viper.SetDefault("EMAIL_VERIFICATION_TTL", "48h")
+	viper.SetDefault("EMAIL_VERIFICATION_URL", "http://localhost:3000/verify-email")
+	viper.SetDefault("VERIFICATION_RESEND_INTERVAL", "1m")
+	viper.SetDefault("MFA_ISSUER", "Task Manager")
+	viper.SetDefault("MFA_CHALLENGE_TTL", "5m")
+	viper.SetDefault("MAIL_DRIVER", "log")
+	viper.SetDefault("MAIL_FROM", "noreply@localhost")
+	viper.SetDefault("SMTP_PORT", 587)
+	viper.SetDefault("REQUIRE_SUBTASKS_CLOSED", false)
+	viper.SetDefault("MAX_SUBTASK_DEPTH", 5)
+	viper.SetDefault("WORKSPACE_INVITATION_TTL", "168h")
+	viper.SetDefault("WORKSPACE_INVITATION_URL", "http://localhost:3000/invitations")
+	viper.SetDefault("BLOB_DRIVER", "local")
+	viper.SetDefault("BLOB_LOCAL_DIR", "data/blobs")
+	viper.SetDefault("S3_REGION", "us-east-1")
+	viper.SetDefault("S3_PATH_STYLE", true)
+	viper.SetDefault("ATTACHMENT_MAX_SIZE", 10<<20)
+	viper.SetDefault("ATTACHMENT_ALLOWED_TYPES", "image/png,image/jpeg,image/gif,image/webp,application/pdf,text/plain")
+	viper.SetDefault("ATTACHMENT_URL_TTL", "5m")
+	viper.SetDefault("ATTACHMENT_GC_INTERVAL", "10m")
 
 	if err := viper.ReadInConfig(); err != nil {
 		return nil, fmt.Errorf("failed to read the config file: %v", err)
@@ -27,9 +188,88 @@ func Load() (*Config, error) {
 		return nil, fmt.Errorf("unable to unmarshal config into struct: %v", err)
 	}
 
-	if cfg.DBURL == "" || cfg.Port == "" || cfg.JWTSecret == "" {
+	if cfg.DBURL == "" || cfg.Port == "" {
 		return nil, fmt.Errorf("missing required environment variables")
 	}
 
+	switch cfg.JWTAlgorithm {
+	case "HS256":
+		if cfg.JWTSecret == "" {
+			return nil, fmt.Errorf("JWT_SECRET is required when JWT_ALGORITHM is HS256")
+		}
+	case "RS256", "EdDSA":
+		if cfg.JWTSigningKeyFile == "" {
+			return nil, fmt.Errorf("JWT_SIGNING_KEY_FILE is required when JWT_ALGORITHM is %s", cfg.JWTAlgorithm)
+		}
+	default:
+		return nil, fmt.Errorf("JWT_ALGORITHM must be HS256, RS256 or EdDSA")
+	}
+
+	cfg.OIDC = make(map[string]OIDCProvider, len(cfg.OIDCProviders))
+	for _, name := range cfg.OIDCProviders {
+		prefix := "OIDC_" + strings.ToUpper(name) + "_"
+		provider := OIDCProvider{
+			Issuer:       viper.GetString(prefix + "ISSUER"),
+			ClientID:     viper.GetString(prefix + "CLIENT_ID"),
+			ClientSecret: viper.GetString(prefix + "CLIENT_SECRET"),
+			RedirectURL:  viper.GetString(prefix + "REDIRECT_URL"),
+			Scopes:       strings.Fields(viper.GetString(prefix + "SCOPES")),
+		}
+		if provider.Issuer == "" || provider.ClientID == "" || provider.RedirectURL == "" {
+			return nil, fmt.Errorf("%sISSUER, %sCLIENT_ID and %sREDIRECT_URL are required", prefix, prefix, prefix)
+		}
+		if len(provider.Scopes) == 0 {
+			provider.Scopes = []string{"email", "profile"}
+		}
+		cfg.OIDC[name] = provider
+	}
+
+	switch cfg.PasswordHashAlgorithm {
+	case "argon2id", "bcrypt":
+	default:
+		return nil, fmt.Errorf("PASSWORD_HASH_ALGORITHM must be argon2id or bcrypt")
+	}
+	if cfg.Argon2Memory == 0 || cfg.Argon2Iterations == 0 || cfg.Argon2Parallelism == 0 {
+		return nil, fmt.Errorf("ARGON2_MEMORY, ARGON2_ITERATIONS and ARGON2_PARALLELISM must be positive")
+	}
+	if cfg.BcryptCost < 4 || cfg.BcryptCost > 31 {
+		return nil, fmt.Errorf("BCRYPT_COST must be between 4 and 31")
+	}
+	if cfg.PasswordMinLength < 1 || cfg.PasswordMaxLength < cfg.PasswordMinLength {
+		return nil, fmt.Errorf("PASSWORD_MIN_LENGTH must be positive and at most PASSWORD_MAX_LENGTH")
+	}
+
+	switch cfg.EmailVerification {
+	case "allow", "read_only", "block":
+	default:
+		return nil, fmt.Errorf("EMAIL_VERIFICATION must be allow, read_only or block")
+	}
+
+	switch cfg.MailDriver {
+	case "log":
+	case "smtp":
+		if cfg.SMTPHost == "" {
+			return nil, fmt.Errorf("SMTP_HOST is required when MAIL_DRIVER is smtp")
+		}
+	default:
+		return nil, fmt.Errorf("MAIL_DRIVER must be log or smtp")
+	}
+
+	switch cfg.BlobDriver {
+	case "local":
+		if cfg.BlobLocalDir == "" {
+			return nil, fmt.Errorf("BLOB_LOCAL_DIR is required when BLOB_DRIVER is local")
+		}
+	case "s3":
+		if cfg.S3Endpoint == "" || cfg.S3Bucket == "" || cfg.S3AccessKeyID == "" || cfg.S3SecretAccessKey == "" {
+			return nil, fmt.Errorf("S3_ENDPOINT, S3_BUCKET, S3_ACCESS_KEY_ID and S3_SECRET_ACCESS_KEY are required when BLOB_DRIVER is s3")
+		}
+	default:
+		return nil, fmt.Errorf("BLOB_DRIVER must be local or s3")
+	}
+	if cfg.AttachmentMaxSize <= 0 || cfg.AttachmentURLTTL <= 0 || cfg.AttachmentGCInterval <= 0 {
+		return nil, fmt.Errorf("ATTACHMENT_MAX_SIZE, ATTACHMENT_URL_TTL and ATTACHMENT_GC_INTERVAL must be positive")
+	}
+
 	return &cfg, nil
 }
@@ -0,0 +1,146 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/ahmednurovic/task-manager-api/internal/model"
+	"github.com/ahmednurovic/task-manager-api/internal/service"
+)
+
+type PersonalAccessTokenService interface {
+	CreateToken(ctx *gin.Context, token *model.PersonalAccessToken, userID int64) error
+	GetTokens(ctx *gin.Context, userID int64) ([]*model.PersonalAccessToken, error)
+	RevokeToken(ctx *gin.Context, tokenID int64, userID int64) error
+}
+
+type PersonalAccessTokenHandler struct {
+	service PersonalAccessTokenService
+}
+
+func NewPersonalAccessTokenHandler(service PersonalAccessTokenService) *PersonalAccessTokenHandler {
+	return &PersonalAccessTokenHandler{service: service}
+}
+
+type PersonalAccessTokenRequest struct {
+	Name   string   `json:"name" example:"CI"`
+	Scopes []string `json:"scopes" example:"tasks:read,tasks:write"`
+	// ExpiresAt is optional; tokens without one never expire.
+	ExpiresAt *time.Time `json:"expires_at" example:"2026-01-31T00:00:00Z"`
+}
+
+// CreateToken godoc
+// @Summary Create a personal access token
+// @Description Create a named token for scripts and CI, limited to the given scopes: tasks:read, tasks:write, labels:read, labels:write, projects:read and projects:write. Write scopes include reading. The token is only shown in this response. Personal access tokens cannot manage tokens themselves.
+// @Tags tokens
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param token body PersonalAccessTokenRequest true "Token object"
+// @Success 201 {object} model.PersonalAccessToken
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /me/tokens [post]
+func (h *PersonalAccessTokenHandler) CreateToken(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	var req PersonalAccessTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	token := model.PersonalAccessToken{Name: req.Name, Scopes: req.Scopes, ExpiresAt: req.ExpiresAt}
+	if err := h.service.CreateToken(c, &token, userID); err != nil {
+		respondAccessTokenError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, token)
+}
+
+// GetTokens godoc
+// @Summary Get the authenticated user's personal access tokens
+// @Description Get the authenticated user's tokens that have not been revoked, newest first, including expired ones. The tokens themselves are not included.
+// @Tags tokens
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} model.PersonalAccessToken
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /me/tokens [get]
+func (h *PersonalAccessTokenHandler) GetTokens(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	tokens, err := h.service.GetTokens(c, userID)
+	if err != nil {
+		respondAccessTokenError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, tokens)
+}
+
+// RevokeToken godoc
+// @Summary Revoke a personal access token
+// @Description Revoke one of the authenticated user's tokens. It stops working immediately.
+// @Tags tokens
+// @Security BearerAuth
+// @Param id path int true "Token ID"
+// @Success 204
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /me/tokens/{id} [delete]
+func (h *PersonalAccessTokenHandler) RevokeToken(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	tokenID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid token id"})
+		return
+	}
+
+	if err := h.service.RevokeToken(c, tokenID, userID); err != nil {
+		respondAccessTokenError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func respondAccessTokenError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrAccessTokenNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrAccessTokenNameRequired),
+		errors.Is(err, service.ErrAccessTokenNameTooLong),
+		errors.Is(err, service.ErrAccessTokenExpired),
+		errors.Is(err, service.ErrScopesRequired),
+		errors.Is(err, service.ErrInvalidScope):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
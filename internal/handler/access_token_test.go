@@ -0,0 +1,114 @@
+package handler_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/ahmednurovic/task-manager-api/internal/handler"
+	"github.com/ahmednurovic/task-manager-api/internal/model"
+	"github.com/ahmednurovic/task-manager-api/internal/service"
+)
+
+type MockPersonalAccessTokenService struct {
+	mock.Mock
+}
+
+func (m *MockPersonalAccessTokenService) CreateToken(ctx *gin.Context, token *model.PersonalAccessToken, userID int64) error {
+	args := m.Called(ctx, token, userID)
+	return args.Error(0)
+}
+
+func (m *MockPersonalAccessTokenService) GetTokens(ctx *gin.Context, userID int64) ([]*model.PersonalAccessToken, error) {
+	args := m.Called(ctx, userID)
+	tokens, _ := args.Get(0).([]*model.PersonalAccessToken)
+	return tokens, args.Error(1)
+}
+
+func (m *MockPersonalAccessTokenService) RevokeToken(ctx *gin.Context, tokenID int64, userID int64) error {
+	args := m.Called(ctx, tokenID, userID)
+	return args.Error(0)
+}
+
+func setupAccessTokenRouter(tokenService handler.PersonalAccessTokenService, userID uint) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	tokenHandler := handler.NewPersonalAccessTokenHandler(tokenService)
+	tokens := router.Group("/me/tokens", fakeAuth(userID))
+	tokens.POST("", tokenHandler.CreateToken)
+	tokens.GET("", tokenHandler.GetTokens)
+	tokens.DELETE("/:id", tokenHandler.RevokeToken)
+	return router
+}
+
+func TestCreateTokenHandler(t *testing.T) {
+	t.Run("Token Shown Once", func(t *testing.T) {
+		mockTokenService := new(MockPersonalAccessTokenService)
+		mockTokenService.On("CreateToken", mock.Anything, mock.MatchedBy(func(token *model.PersonalAccessToken) bool {
+			return token.Name == "CI" && len(token.Scopes) == 1 && token.Scopes[0] == "tasks:read" && token.ExpiresAt == nil
+		}), int64(1)).
+			Run(func(args mock.Arguments) {
+				token := args.Get(1).(*model.PersonalAccessToken)
+				token.ID = 3
+				token.Token = "tm_pat_secret"
+				token.TokenHash = "hash"
+			}).
+			Return(nil)
+
+		router := setupAccessTokenRouter(mockTokenService, 1)
+		w := performRequest(router, "POST", "/me/tokens", `{"name":"CI","scopes":["tasks:read"]}`)
+
+		assert.Equal(t, http.StatusCreated, w.Code)
+		assert.Contains(t, w.Body.String(), `"token":"tm_pat_secret"`)
+		assert.NotContains(t, w.Body.String(), "hash")
+	})
+
+	t.Run("Invalid Scope", func(t *testing.T) {
+		mockTokenService := new(MockPersonalAccessTokenService)
+		mockTokenService.On("CreateToken", mock.Anything, mock.Anything, int64(1)).Return(service.ErrInvalidScope)
+
+		router := setupAccessTokenRouter(mockTokenService, 1)
+		w := performRequest(router, "POST", "/me/tokens", `{"name":"CI","scopes":["admin"]}`)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
+
+func TestGetTokensHandler(t *testing.T) {
+	mockTokenService := new(MockPersonalAccessTokenService)
+	mockTokenService.On("GetTokens", mock.Anything, int64(1)).
+		Return([]*model.PersonalAccessToken{{ID: 3, Name: "CI", TokenHash: "hash", Scopes: []string{"tasks:read"}}}, nil)
+
+	router := setupAccessTokenRouter(mockTokenService, 1)
+	w := performRequest(router, "GET", "/me/tokens", "")
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"name":"CI"`)
+	assert.NotContains(t, w.Body.String(), "hash")
+	assert.NotContains(t, w.Body.String(), `"token"`)
+}
+
+func TestRevokeTokenHandler(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		mockTokenService := new(MockPersonalAccessTokenService)
+		mockTokenService.On("RevokeToken", mock.Anything, int64(3), int64(1)).Return(nil)
+
+		router := setupAccessTokenRouter(mockTokenService, 1)
+		w := performRequest(router, "DELETE", "/me/tokens/3", "")
+
+		assert.Equal(t, http.StatusNoContent, w.Code)
+	})
+
+	t.Run("Not Found", func(t *testing.T) {
+		mockTokenService := new(MockPersonalAccessTokenService)
+		mockTokenService.On("RevokeToken", mock.Anything, int64(4), int64(1)).Return(service.ErrAccessTokenNotFound)
+
+		router := setupAccessTokenRouter(mockTokenService, 1)
+		w := performRequest(router, "DELETE", "/me/tokens/4", "")
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
@@ -0,0 +1,49 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/ahmednurovic/task-manager-api/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+// UnlockAccount godoc
+// @Summary Unlock an account
+// @Description Lift a lockout caused by failed logins before it runs out. Succeeds whether or not the account is locked. Administrators only.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param input body UnlockAccountRequest true "Unlock input"
+// @Success 204
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /admin/accounts/unlock [post]
+func UnlockAccount(authService service.AuthServicer) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		adminID, ok := currentUserID(c)
+		if !ok {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+			return
+		}
+
+		var req UnlockAccountRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		if err := authService.UnlockAccount(c.Request.Context(), adminID, req.Email); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to unlock account"})
+			return
+		}
+
+		c.Status(http.StatusNoContent)
+	}
+}
+
+type UnlockAccountRequest struct {
+	Email string `json:"email" binding:"required,email" example:"user@example.com"`
+}
@@ -0,0 +1,40 @@
+package handler_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/ahmednurovic/task-manager-api/internal/handler"
+)
+
+func TestUnlockAccountHandler(t *testing.T) {
+	newRouter := func(mockAuthService *MockAuthService) *gin.Engine {
+		gin.SetMode(gin.TestMode)
+		router := gin.New()
+		router.POST("/admin/accounts/unlock", fakeAuth(1), handler.UnlockAccount(mockAuthService))
+		return router
+	}
+
+	t.Run("Unlocks", func(t *testing.T) {
+		mockAuthService := new(MockAuthService)
+		mockAuthService.On("UnlockAccount", mock.Anything, int64(1), "user@example.com").Return(nil)
+
+		w := performRequest(newRouter(mockAuthService), "POST", "/admin/accounts/unlock", `{"email":"user@example.com"}`)
+
+		assert.Equal(t, http.StatusNoContent, w.Code)
+		mockAuthService.AssertExpectations(t)
+	})
+
+	t.Run("Invalid Email", func(t *testing.T) {
+		mockAuthService := new(MockAuthService)
+
+		w := performRequest(newRouter(mockAuthService), "POST", "/admin/accounts/unlock", `{"email":"not-an-email"}`)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		mockAuthService.AssertNotCalled(t, "UnlockAccount", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
@@ -0,0 +1,126 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AssigneeRequest names the user to assign a task to.
+type AssigneeRequest struct {
+	UserID uint `json:"user_id" binding:"required" example:"7"`
+}
+
+// AssignTask godoc
+// @Summary Assign a task
+// @Description Make a user responsible for a task. In a workspace the assignee must be a member of it; a personal task can only be assigned to its owner. The change is recorded in the task's activity.
+// @Tags assignees
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Task ID"
+// @Param assignee body AssigneeRequest true "Assignee"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /tasks/{id}/assignee [put]
+func (h *TaskHandler) AssignTask(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	taskID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid task id"})
+		return
+	}
+
+	var req AssigneeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.service.AssignTask(c, taskID, int64(req.UserID), userID); err != nil {
+		respondTaskError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Task assigned successfully"})
+}
+
+// UnassignTask godoc
+// @Summary Unassign a task
+// @Description Leave a task without an assignee. The change is recorded in the task's activity.
+// @Tags assignees
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Task ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /tasks/{id}/assignee [delete]
+func (h *TaskHandler) UnassignTask(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	taskID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid task id"})
+		return
+	}
+
+	if err := h.service.UnassignTask(c, taskID, userID); err != nil {
+		respondTaskError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Task unassigned successfully"})
+}
+
+// GetActivity godoc
+// @Summary Get a task's activity
+// @Description Get the history of a task, oldest first
+// @Tags assignees
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Task ID"
+// @Success 200 {array} model.TaskActivity
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /tasks/{id}/activity [get]
+func (h *TaskHandler) GetActivity(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	taskID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid task id"})
+		return
+	}
+
+	activity, err := h.service.GetActivity(c, taskID, userID)
+	if err != nil {
+		respondTaskError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, activity)
+}
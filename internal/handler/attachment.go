@@ -0,0 +1,273 @@
+package handler
+
+import (
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/ahmednurovic/task-manager-api/internal/model"
+	"github.com/ahmednurovic/task-manager-api/internal/service"
+)
+
+type AttachmentService interface {
+	GetAttachments(ctx *gin.Context, taskID int64, userID int64) ([]*model.Attachment, error)
+	UploadAttachment(ctx *gin.Context, taskID int64, filename string, size int64, r io.Reader, userID int64) (*model.Attachment, error)
+	DeleteAttachment(ctx *gin.Context, taskID int64, attachmentID int64, userID int64) error
+	GetAttachmentURL(ctx *gin.Context, taskID int64, attachmentID int64, userID int64) (*model.AttachmentURL, error)
+	OpenAttachment(ctx *gin.Context, attachmentID int64, expires string, signature string) (*model.Attachment, io.ReadCloser, error)
+}
+
+// multipartOverhead is room in an upload's body for the multipart framing
+// around the file.
+const multipartOverhead = 64 << 10
+
+type AttachmentHandler struct {
+	service       AttachmentService
+	maxUploadSize int64
+}
+
+// NewAttachmentHandler returns a handler that stops reading upload bodies
+// once they are clearly larger than maxUploadSize bytes.
+func NewAttachmentHandler(service AttachmentService, maxUploadSize int64) *AttachmentHandler {
+	return &AttachmentHandler{service: service, maxUploadSize: maxUploadSize}
+}
+
+// GetAttachments godoc
+// @Summary List a task's attachments
+// @Description List the files attached to a task
+// @Tags attachments
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Task ID"
+// @Success 200 {array} model.Attachment
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /tasks/{id}/attachments [get]
+func (h *AttachmentHandler) GetAttachments(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	taskID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid task id"})
+		return
+	}
+
+	attachments, err := h.service.GetAttachments(c, taskID, userID)
+	if err != nil {
+		respondAttachmentError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, attachments)
+}
+
+// UploadAttachment godoc
+// @Summary Attach a file to a task
+// @Description Upload a file as the file field of a multipart form. The file's type is sniffed from its contents and must be one of ATTACHMENT_ALLOWED_TYPES; it may be at most ATTACHMENT_MAX_SIZE bytes. The response includes the SHA-256 checksum of the stored file.
+// @Tags attachments
+// @Accept mpfd
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Task ID"
+// @Param file formData file true "File to attach"
+// @Success 201 {object} model.Attachment
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 413 {object} ErrorResponse
+// @Failure 415 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /tasks/{id}/attachments [post]
+func (h *AttachmentHandler) UploadAttachment(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	taskID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid task id"})
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, h.maxUploadSize+multipartOverhead)
+	header, err := c.FormFile("file")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			respondAttachmentError(c, service.ErrAttachmentTooLarge)
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "file is required"})
+		return
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	defer file.Close()
+
+	attachment, err := h.service.UploadAttachment(c, taskID, header.Filename, header.Size, file, userID)
+	if err != nil {
+		respondAttachmentError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, attachment)
+}
+
+// DeleteAttachment godoc
+// @Summary Delete an attachment
+// @Description Remove a file from a task. Its contents are deleted from storage shortly afterwards.
+// @Tags attachments
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Task ID"
+// @Param attachment_id path int true "Attachment ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /tasks/{id}/attachments/{attachment_id} [delete]
+func (h *AttachmentHandler) DeleteAttachment(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	taskID, attachmentID, ok := attachmentParams(c)
+	if !ok {
+		return
+	}
+
+	if err := h.service.DeleteAttachment(c, taskID, attachmentID, userID); err != nil {
+		respondAttachmentError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Attachment deleted successfully"})
+}
+
+// GetAttachmentURL godoc
+// @Summary Get a download link for an attachment
+// @Description Get a signed link that downloads an attachment without an access token until it expires, after ATTACHMENT_URL_TTL
+// @Tags attachments
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Task ID"
+// @Param attachment_id path int true "Attachment ID"
+// @Success 200 {object} model.AttachmentURL
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /tasks/{id}/attachments/{attachment_id}/url [get]
+func (h *AttachmentHandler) GetAttachmentURL(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	taskID, attachmentID, ok := attachmentParams(c)
+	if !ok {
+		return
+	}
+
+	url, err := h.service.GetAttachmentURL(c, taskID, attachmentID, userID)
+	if err != nil {
+		respondAttachmentError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, url)
+}
+
+// DownloadAttachment godoc
+// @Summary Download an attachment
+// @Description Download an attachment through a signed link from the download link endpoint. No access token is needed.
+// @Tags attachments
+// @Produce octet-stream
+// @Param id path int true "Attachment ID"
+// @Param expires query int true "Expiry, as a Unix time"
+// @Param signature query string true "Link signature"
+// @Success 200 {file} file
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /attachments/{id}/content [get]
+func (h *AttachmentHandler) DownloadAttachment(c *gin.Context) {
+	attachmentID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid attachment id"})
+		return
+	}
+
+	attachment, contents, err := h.service.OpenAttachment(c, attachmentID, c.Query("expires"), c.Query("signature"))
+	if err != nil {
+		respondAttachmentError(c, err)
+		return
+	}
+	defer contents.Close()
+
+	c.DataFromReader(http.StatusOK, attachment.Size, attachment.ContentType, contents, map[string]string{
+		"Content-Disposition":    mime.FormatMediaType("attachment", map[string]string{"filename": attachment.Filename}),
+		"X-Content-Type-Options": "nosniff",
+		"Cache-Control":          "private, no-store",
+	})
+}
+
+// attachmentParams parses the task and attachment IDs from the path,
+// writing a 400 response if either is malformed.
+func attachmentParams(c *gin.Context) (int64, int64, bool) {
+	taskID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid task id"})
+		return 0, 0, false
+	}
+
+	attachmentID, err := strconv.ParseInt(c.Param("attachment_id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid attachment id"})
+		return 0, 0, false
+	}
+
+	return taskID, attachmentID, true
+}
+
+func respondAttachmentError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrAttachmentNotFound),
+		errors.Is(err, service.ErrTaskNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrAttachmentEmpty):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrAttachmentTooLarge):
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrAttachmentTypeNotAllowed):
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrInvalidAttachmentURL),
+		errors.Is(err, service.ErrInsufficientRole):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
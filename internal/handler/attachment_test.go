@@ -0,0 +1,200 @@
+package handler_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ahmednurovic/task-manager-api/internal/handler"
+	"github.com/ahmednurovic/task-manager-api/internal/model"
+	"github.com/ahmednurovic/task-manager-api/internal/service"
+)
+
+type MockAttachmentService struct {
+	mock.Mock
+}
+
+func (m *MockAttachmentService) GetAttachments(ctx *gin.Context, taskID int64, userID int64) ([]*model.Attachment, error) {
+	args := m.Called(ctx, taskID, userID)
+	attachments, _ := args.Get(0).([]*model.Attachment)
+	return attachments, args.Error(1)
+}
+
+func (m *MockAttachmentService) UploadAttachment(ctx *gin.Context, taskID int64, filename string, size int64, r io.Reader, userID int64) (*model.Attachment, error) {
+	args := m.Called(ctx, taskID, filename, size, r, userID)
+	attachment, _ := args.Get(0).(*model.Attachment)
+	return attachment, args.Error(1)
+}
+
+func (m *MockAttachmentService) DeleteAttachment(ctx *gin.Context, taskID int64, attachmentID int64, userID int64) error {
+	args := m.Called(ctx, taskID, attachmentID, userID)
+	return args.Error(0)
+}
+
+func (m *MockAttachmentService) GetAttachmentURL(ctx *gin.Context, taskID int64, attachmentID int64, userID int64) (*model.AttachmentURL, error) {
+	args := m.Called(ctx, taskID, attachmentID, userID)
+	url, _ := args.Get(0).(*model.AttachmentURL)
+	return url, args.Error(1)
+}
+
+func (m *MockAttachmentService) OpenAttachment(ctx *gin.Context, attachmentID int64, expires string, signature string) (*model.Attachment, io.ReadCloser, error) {
+	args := m.Called(ctx, attachmentID, expires, signature)
+	attachment, _ := args.Get(0).(*model.Attachment)
+	contents, _ := args.Get(1).(io.ReadCloser)
+	return attachment, contents, args.Error(2)
+}
+
+func newAttachmentRouter(attachmentService handler.AttachmentService, userID uint, maxUploadSize int64) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+
+	attachmentHandler := handler.NewAttachmentHandler(attachmentService, maxUploadSize)
+	tasks := router.Group("/tasks").Use(fakeAuth(userID))
+	tasks.GET("/:id/attachments", attachmentHandler.GetAttachments)
+	tasks.POST("/:id/attachments", attachmentHandler.UploadAttachment)
+	tasks.DELETE("/:id/attachments/:attachment_id", attachmentHandler.DeleteAttachment)
+	tasks.GET("/:id/attachments/:attachment_id/url", attachmentHandler.GetAttachmentURL)
+	router.GET("/attachments/:id/content", attachmentHandler.DownloadAttachment)
+
+	return router
+}
+
+// performUpload posts a multipart form with content under the given field.
+func performUpload(t *testing.T, router *gin.Engine, path, field, filename, content string) *httptest.ResponseRecorder {
+	t.Helper()
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, err := form.CreateFormFile(field, filename)
+	require.NoError(t, err)
+	_, err = part.Write([]byte(content))
+	require.NoError(t, err)
+	require.NoError(t, form.Close())
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, path, &body)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestAttachmentHandlers(t *testing.T) {
+	t.Run("Upload", func(t *testing.T) {
+		mockAttachmentService := new(MockAttachmentService)
+		mockAttachmentService.On("UploadAttachment", mock.Anything, int64(10), "notes.txt", int64(5), mock.MatchedBy(func(r io.Reader) bool {
+			data, err := io.ReadAll(r)
+			return err == nil && string(data) == "hello"
+		}), int64(1)).Return(&model.Attachment{ID: 30, TaskID: 10, Filename: "notes.txt", Size: 5}, nil)
+
+		router := newAttachmentRouter(mockAttachmentService, 1, 1024)
+		w := performUpload(t, router, "/tasks/10/attachments", "file", "notes.txt", "hello")
+
+		assert.Equal(t, http.StatusCreated, w.Code)
+		assert.Contains(t, w.Body.String(), `"filename":"notes.txt"`)
+		assert.NotContains(t, w.Body.String(), "storage_key")
+		mockAttachmentService.AssertExpectations(t)
+	})
+
+	t.Run("Upload Without File", func(t *testing.T) {
+		router := newAttachmentRouter(new(MockAttachmentService), 1, 1024)
+		w := performUpload(t, router, "/tasks/10/attachments", "document", "notes.txt", "hello")
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "file is required")
+	})
+
+	t.Run("Upload Body Too Large", func(t *testing.T) {
+		mockAttachmentService := new(MockAttachmentService)
+		router := newAttachmentRouter(mockAttachmentService, 1, 1024)
+		w := performUpload(t, router, "/tasks/10/attachments", "file", "big.txt", strings.Repeat("a", 128<<10))
+
+		assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+		mockAttachmentService.AssertNotCalled(t, "UploadAttachment", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Upload Errors", func(t *testing.T) {
+		tests := []struct {
+			err  error
+			code int
+		}{
+			{service.ErrAttachmentTooLarge, http.StatusRequestEntityTooLarge},
+			{service.ErrAttachmentTypeNotAllowed, http.StatusUnsupportedMediaType},
+			{service.ErrInsufficientRole, http.StatusForbidden},
+			{service.ErrTaskNotFound, http.StatusNotFound},
+		}
+		for _, tt := range tests {
+			mockAttachmentService := new(MockAttachmentService)
+			mockAttachmentService.On("UploadAttachment", mock.Anything, int64(10), "notes.txt", int64(5), mock.Anything, int64(1)).
+				Return(nil, tt.err)
+
+			router := newAttachmentRouter(mockAttachmentService, 1, 1024)
+			w := performUpload(t, router, "/tasks/10/attachments", "file", "notes.txt", "hello")
+
+			assert.Equal(t, tt.code, w.Code, tt.err.Error())
+		}
+	})
+
+	t.Run("Download Link", func(t *testing.T) {
+		expiresAt := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+		mockAttachmentService := new(MockAttachmentService)
+		mockAttachmentService.On("GetAttachmentURL", mock.Anything, int64(10), int64(30), int64(1)).
+			Return(&model.AttachmentURL{URL: "/api/v1/attachments/30/content?expires=1&signature=abc", ExpiresAt: expiresAt}, nil)
+
+		router := newAttachmentRouter(mockAttachmentService, 1, 1024)
+		w := performRequest(router, "GET", "/tasks/10/attachments/30/url", "")
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var link model.AttachmentURL
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &link))
+		assert.Equal(t, "/api/v1/attachments/30/content?expires=1&signature=abc", link.URL)
+		assert.Equal(t, expiresAt, link.ExpiresAt)
+	})
+
+	t.Run("Download", func(t *testing.T) {
+		mockAttachmentService := new(MockAttachmentService)
+		mockAttachmentService.On("OpenAttachment", mock.Anything, int64(30), "1900000000", "abc").
+			Return(&model.Attachment{ID: 30, Filename: "quarterly report.pdf", ContentType: "application/pdf", Size: 5}, io.NopCloser(strings.NewReader("%PDF-")), nil)
+
+		// No user: the signature is the only credential.
+		router := newAttachmentRouter(mockAttachmentService, 0, 1024)
+		w := performRequest(router, "GET", "/attachments/30/content?expires=1900000000&signature=abc", "")
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "%PDF-", w.Body.String())
+		assert.Equal(t, "application/pdf", w.Header().Get("Content-Type"))
+		assert.Equal(t, `attachment; filename="quarterly report.pdf"`, w.Header().Get("Content-Disposition"))
+		assert.Equal(t, "nosniff", w.Header().Get("X-Content-Type-Options"))
+		assert.Equal(t, "private, no-store", w.Header().Get("Cache-Control"))
+	})
+
+	t.Run("Download With Invalid Link", func(t *testing.T) {
+		mockAttachmentService := new(MockAttachmentService)
+		mockAttachmentService.On("OpenAttachment", mock.Anything, int64(30), "1", "forged").
+			Return(nil, nil, service.ErrInvalidAttachmentURL)
+
+		router := newAttachmentRouter(mockAttachmentService, 0, 1024)
+		w := performRequest(router, "GET", "/attachments/30/content?expires=1&signature=forged", "")
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+
+	t.Run("Delete Missing", func(t *testing.T) {
+		mockAttachmentService := new(MockAttachmentService)
+		mockAttachmentService.On("DeleteAttachment", mock.Anything, int64(10), int64(31), int64(1)).Return(service.ErrAttachmentNotFound)
+
+		router := newAttachmentRouter(mockAttachmentService, 1, 1024)
+		w := performRequest(router, "DELETE", "/tasks/10/attachments/31", "")
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
@@ -1,22 +1,21 @@
 package handler
 
 import (
+	"errors"
+	"io"
+	"math"
 	"net/http"
+	"strconv"
 	"strings"
+	"time"
 
-	"github.com/ahmednurovic/task-manager-api/internal/model"
 	"github.com/ahmednurovic/task-manager-api/internal/service"
 	"github.com/gin-gonic/gin"
 )
 
-type AuthService interface {
-	Register(ctx *gin.Context, email, password string) (*model.User, error)
-	Login(ctx *gin.Context, email, password string) (string, error)
-}
-
 // Register godoc
 // @Summary Register a new user
-// @Description Register a new user with email and password
+// @Description Register a new user with email and password. The password must meet the server's password policy: a minimum and maximum length, and not one of a list of common passwords.
 // @Tags auth
 // @Accept json
 // @Produce json
@@ -29,7 +28,7 @@ func Register(authService service.AuthServicer) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var req struct {
 			Email    string `json:"email" binding:"required,email"`
-			Password string `json:"password" binding:"required,min=8"`
+			Password string `json:"password" binding:"required"`
 		}
 
 		if err := c.ShouldBindJSON(&req); err != nil {
@@ -44,7 +43,11 @@ func Register(authService service.AuthServicer) gin.HandlerFunc {
 
 		user, err := authService.Register(c.Request.Context(), req.Email, req.Password)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			if isPasswordPolicyError(err) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			} else {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			}
 			return
 		}
 
@@ -54,7 +57,7 @@ func Register(authService service.AuthServicer) gin.HandlerFunc {
 
 // Login godoc
 // @Summary Login a user
-// @Description Login with email and password
+// @Description Login with email and password. Returns a short-lived access token and a single-use refresh token. Depending on server policy, users who have not verified their email get a 403 with code email_not_verified, or a read-only access token. Users with two-factor authentication on get mfa_required and an mfa_token instead of tokens, to complete the login at /auth/mfa/verify. After a failed login the account must wait before trying again, for longer after each failure, and repeated failures lock the account or the client's IP address for a while; until then the response is a 429 with Retry-After.
 // @Tags auth
 // @Accept json
 // @Produce json
@@ -62,6 +65,8 @@ func Register(authService service.AuthServicer) gin.HandlerFunc {
 // @Success 200 {object} TokenResponse
 // @Failure 400 {object} ErrorResponse
 // @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 429 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
 // @Router /auth/login [post]
 func Login(authService service.AuthServicer) gin.HandlerFunc {
@@ -76,21 +81,282 @@ func Login(authService service.AuthServicer) gin.HandlerFunc {
 			return
 		}
 
-		token, err := authService.Login(c.Request.Context(), req.Email, req.Password)
+		tokens, err := authService.Login(c.Request.Context(), req.Email, req.Password, c.ClientIP())
+		if err != nil {
+			var retryErr *service.RetryAfterError
+			if errors.As(err, &retryErr) {
+				respondRetryAfter(c, retryErr)
+			} else if errors.Is(err, service.ErrEmailNotVerified) {
+				respondEmailNotVerified(c, err)
+			} else if errors.Is(err, service.ErrInvalidCredentials) {
+				c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			} else {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to log in"})
+			}
+			return
+		}
+
+		c.JSON(http.StatusOK, tokens)
+	}
+}
+
+// Refresh godoc
+// @Summary Refresh an access token
+// @Description Exchange a refresh token for a new access token and a new refresh token. Each refresh token can be used once; reusing one revokes every refresh token issued since the login it came from.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param input body RefreshRequest true "Refresh input"
+// @Success 200 {object} TokenResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /auth/refresh [post]
+func Refresh(authService service.AuthServicer) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req RefreshRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		tokens, err := authService.Refresh(c.Request.Context(), req.RefreshToken)
+		if err != nil {
+			if errors.Is(err, service.ErrInvalidRefreshToken) || errors.Is(err, service.ErrRefreshTokenReused) {
+				c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			} else if errors.Is(err, service.ErrEmailNotVerified) {
+				respondEmailNotVerified(c, err)
+			} else {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			}
+			return
+		}
+
+		c.JSON(http.StatusOK, tokens)
+	}
+}
+
+// Logout godoc
+// @Summary Log out
+// @Description Revoke the access token used for this request. If a refresh token is given, every refresh token from the same login is revoked too.
+// @Tags auth
+// @Accept json
+// @Security BearerAuth
+// @Param input body LogoutRequest false "Logout input"
+// @Success 204
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /auth/logout [post]
+func Logout(authService service.AuthServicer) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, ok := currentUserID(c)
+		if !ok {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+			return
+		}
+
+		// The body is optional.
+		var req LogoutRequest
+		if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		tokenID, expiresAt := currentToken(c)
+		if err := authService.Logout(c.Request.Context(), userID, tokenID, expiresAt, req.RefreshToken); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.Status(http.StatusNoContent)
+	}
+}
+
+// LogoutAll godoc
+// @Summary Log out everywhere
+// @Description Revoke every access token, refresh token and personal access token issued to the authenticated user so far
+// @Tags auth
+// @Security BearerAuth
+// @Success 204
+// @Failure 401 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /auth/logout-all [post]
+func LogoutAll(authService service.AuthServicer) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, ok := currentUserID(c)
+		if !ok {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+			return
+		}
+
+		if err := authService.LogoutAll(c.Request.Context(), userID); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.Status(http.StatusNoContent)
+	}
+}
+
+// ForgotPassword godoc
+// @Summary Request a password reset
+// @Description Email a single-use password reset link to the account with this email. The response is the same whether or not the account exists.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param input body ForgotPasswordRequest true "Forgot password input"
+// @Success 202 {object} MessageResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /auth/password/forgot [post]
+func ForgotPassword(authService service.AuthServicer) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req ForgotPasswordRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		if err := authService.ForgotPassword(c.Request.Context(), req.Email); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to send password reset email"})
+			return
+		}
+
+		c.JSON(http.StatusAccepted, gin.H{
+			"message": "if an account exists for this email, a password reset link has been sent",
+		})
+	}
+}
+
+// ResetPassword godoc
+// @Summary Reset a password
+// @Description Set a new password with a token from a password reset email. The token works once, and all of the user's sessions are signed out.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param input body ResetPasswordRequest true "Reset password input"
+// @Success 204
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /auth/password/reset [post]
+func ResetPassword(authService service.AuthServicer) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req ResetPasswordRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		err := authService.ResetPassword(c.Request.Context(), req.Token, req.Password)
+		if err != nil {
+			if errors.Is(err, service.ErrInvalidResetToken) || isPasswordPolicyError(err) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			} else {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			}
+			return
+		}
+
+		c.Status(http.StatusNoContent)
+	}
+}
+
+// VerifyEmail godoc
+// @Summary Verify an email address
+// @Description Confirm an email address with the token from a verification email. Each token works once.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param input body VerifyEmailRequest true "Verify input"
+// @Success 204
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /auth/verify [post]
+func VerifyEmail(authService service.AuthServicer) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req VerifyEmailRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		err := authService.VerifyEmail(c.Request.Context(), req.Token)
 		if err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			if errors.Is(err, service.ErrInvalidVerificationToken) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			} else {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			}
 			return
 		}
 
-		c.JSON(http.StatusOK, gin.H{
-			"token": token,
+		c.Status(http.StatusNoContent)
+	}
+}
+
+// ResendVerification godoc
+// @Summary Resend the verification email
+// @Description Send a new verification link to the account with this email, if it exists and is not verified yet. The response does not reveal which is the case. Limited to one request per email per interval.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param input body ResendVerificationRequest true "Resend input"
+// @Success 202 {object} MessageResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 429 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /auth/verify/resend [post]
+func ResendVerification(authService service.AuthServicer) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req ResendVerificationRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		err := authService.ResendVerification(c.Request.Context(), req.Email)
+		if err != nil {
+			var retryErr *service.RetryAfterError
+			if errors.As(err, &retryErr) {
+				respondRetryAfter(c, retryErr)
+			} else {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to send verification email"})
+			}
+			return
+		}
+
+		c.JSON(http.StatusAccepted, gin.H{
+			"message": "if an unverified account exists for this email, a verification link has been sent",
 		})
 	}
 }
 
+// respondEmailNotVerified sends the 403 for users the verification policy
+// keeps out, with a code clients can tell apart from other failures.
+func respondEmailNotVerified(c *gin.Context, err error) {
+	c.JSON(http.StatusForbidden, gin.H{"error": err.Error(), "code": "email_not_verified"})
+}
+
+// respondRetryAfter sends a 429 with a Retry-After header in whole seconds.
+func respondRetryAfter(c *gin.Context, err *service.RetryAfterError) {
+	seconds := int(math.Ceil(err.RetryAfter.Seconds()))
+	c.Header("Retry-After", strconv.Itoa(seconds))
+	c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+}
+
+// isPasswordPolicyError reports whether err says a new password may not be
+// used.
+func isPasswordPolicyError(err error) bool {
+	return errors.Is(err, service.ErrPasswordTooShort) ||
+		errors.Is(err, service.ErrPasswordTooLong) ||
+		errors.Is(err, service.ErrPasswordTooCommon)
+}
+
 type RegisterRequest struct {
 	Email    string `json:"email" binding:"required,email" example:"user@example.com"`
-	Password string `json:"password" binding:"required,min=8" example:"password123"`
+	Password string `json:"password" binding:"required" example:"password123"`
 }
 
 type LoginRequest struct {
@@ -98,10 +364,46 @@ type LoginRequest struct {
 	Password string `json:"password" binding:"required" example:"password123"`
 }
 
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required" example:"Vq3xK9mZ2pL7wR4tY8uN1bC6dF0hJ5sA3eG9iO2kM7q"`
+}
+
+type LogoutRequest struct {
+	RefreshToken string `json:"refresh_token" example:"Vq3xK9mZ2pL7wR4tY8uN1bC6dF0hJ5sA3eG9iO2kM7q"`
+}
+
+type ForgotPasswordRequest struct {
+	Email string `json:"email" binding:"required,email" example:"user@example.com"`
+}
+
+type ResetPasswordRequest struct {
+	Token    string `json:"token" binding:"required" example:"Vq3xK9mZ2pL7wR4tY8uN1bC6dF0hJ5sA3eG9iO2kM7q"`
+	Password string `json:"password" binding:"required" example:"new-password123"`
+}
+
+type VerifyEmailRequest struct {
+	Token string `json:"token" binding:"required" example:"Vq3xK9mZ2pL7wR4tY8uN1bC6dF0hJ5sA3eG9iO2kM7q"`
+}
+
+type ResendVerificationRequest struct {
+	Email string `json:"email" binding:"required,email" example:"user@example.com"`
+}
+
+type MessageResponse struct {
+	Message string `json:"message" example:"if an account exists for this email, a password reset link has been sent"`
+}
+
 type TokenResponse struct {
-	Token string `json:"token" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."`
+	AccessToken  string    `json:"access_token,omitempty" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."`
+	RefreshToken string    `json:"refresh_token,omitempty" example:"Vq3xK9mZ2pL7wR4tY8uN1bC6dF0hJ5sA3eG9iO2kM7q"`
+	ExpiresAt    time.Time `json:"expires_at" example:"2025-01-31T17:15:00Z"`
+	ReadOnly     bool      `json:"read_only,omitempty" example:"false"`
+	MFARequired  bool      `json:"mfa_required,omitempty" example:"false"`
+	MFAToken     string    `json:"mfa_token,omitempty" example:""`
 }
 
 type ErrorResponse struct {
 	Error string `json:"error" example:"error message"`
+	// Code is set for errors clients are expected to handle specially.
+	Code string `json:"code,omitempty" example:"email_not_verified"`
 }
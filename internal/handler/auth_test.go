@@ -2,10 +2,12 @@ package handler_test
 
 import (
 	"context"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
@@ -25,9 +27,85 @@ func (m *MockAuthService) Register(ctx context.Context, email string, password s
 	return args.Get(0).(*model.User), args.Error(1)
 }
 
-func (m *MockAuthService) Login(ctx context.Context, email string, password string) (string, error) {
-	args := m.Called(ctx, email, password)
-	return args.String(0), args.Error(1)
+func (m *MockAuthService) Login(ctx context.Context, email, password, clientIP string) (*model.TokenPair, error) {
+	args := m.Called(ctx, email, password, clientIP)
+	tokens, _ := args.Get(0).(*model.TokenPair)
+	return tokens, args.Error(1)
+}
+
+func (m *MockAuthService) Logout(ctx context.Context, userID int64, tokenID string, expiresAt time.Time, refreshToken string) error {
+	args := m.Called(ctx, userID, tokenID, expiresAt, refreshToken)
+	return args.Error(0)
+}
+
+func (m *MockAuthService) LogoutAll(ctx context.Context, userID int64) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
+func (m *MockAuthService) ForgotPassword(ctx context.Context, email string) error {
+	args := m.Called(ctx, email)
+	return args.Error(0)
+}
+
+func (m *MockAuthService) ResetPassword(ctx context.Context, token string, password string) error {
+	args := m.Called(ctx, token, password)
+	return args.Error(0)
+}
+
+func (m *MockAuthService) VerifyEmail(ctx context.Context, token string) error {
+	args := m.Called(ctx, token)
+	return args.Error(0)
+}
+
+func (m *MockAuthService) ResendVerification(ctx context.Context, email string) error {
+	args := m.Called(ctx, email)
+	return args.Error(0)
+}
+
+func (m *MockAuthService) EnrollMFA(ctx context.Context, userID int64) (*model.MFAEnrollment, error) {
+	args := m.Called(ctx, userID)
+	enrollment, _ := args.Get(0).(*model.MFAEnrollment)
+	return enrollment, args.Error(1)
+}
+
+func (m *MockAuthService) ConfirmMFA(ctx context.Context, userID int64, code string) ([]string, error) {
+	args := m.Called(ctx, userID, code)
+	codes, _ := args.Get(0).([]string)
+	return codes, args.Error(1)
+}
+
+func (m *MockAuthService) DisableMFA(ctx context.Context, userID int64, password, code, clientIP string) error {
+	args := m.Called(ctx, userID, password, code, clientIP)
+	return args.Error(0)
+}
+
+func (m *MockAuthService) VerifyMFA(ctx context.Context, mfaToken, code, clientIP string) (*model.TokenPair, error) {
+	args := m.Called(ctx, mfaToken, code, clientIP)
+	tokens, _ := args.Get(0).(*model.TokenPair)
+	return tokens, args.Error(1)
+}
+
+func (m *MockAuthService) SignIn(ctx context.Context, user *model.User) (*model.TokenPair, error) {
+	args := m.Called(ctx, user)
+	tokens, _ := args.Get(0).(*model.TokenPair)
+	return tokens, args.Error(1)
+}
+
+func (m *MockAuthService) UnlockAccount(ctx context.Context, adminID int64, email string) error {
+	args := m.Called(ctx, adminID, email)
+	return args.Error(0)
+}
+
+func (m *MockAuthService) IsAdmin(ctx context.Context, userID uint) (bool, error) {
+	args := m.Called(ctx, userID)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockAuthService) Refresh(ctx context.Context, refreshToken string) (*model.TokenPair, error) {
+	args := m.Called(ctx, refreshToken)
+	tokens, _ := args.Get(0).(*model.TokenPair)
+	return tokens, args.Error(1)
 }
 
 func TestRegisterHandler(t *testing.T) {
@@ -73,6 +151,26 @@ func TestRegisterHandler(t *testing.T) {
 		assert.Equal(t, http.StatusBadRequest, w.Code)
 		assert.Contains(t, w.Body.String(), "invalid email")
 	})
+
+	t.Run("Common Password", func(t *testing.T) {
+		gin.SetMode(gin.TestMode)
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+
+		body := `{"email":"test@example.com","password":"password123"}`
+		req := httptest.NewRequest("POST", "/register", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		c.Request = req
+
+		mockAuthService := new(MockAuthService)
+		mockAuthService.On("Register", mock.Anything, "test@example.com", "password123").
+			Return((*model.User)(nil), fmt.Errorf("%w: it appears in lists of breached passwords", service.ErrPasswordTooCommon))
+
+		handler.Register(mockAuthService)(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "breached")
+	})
 }
 
 func TestLoginHandler(t *testing.T) {
@@ -87,15 +185,19 @@ func TestLoginHandler(t *testing.T) {
 		c.Request = req
 
 		mockAuthService := new(MockAuthService)
-		mockAuthService.On("Login", mock.Anything, "test@example.com", "password123").
-			Return("valid.token", nil)
+		mockAuthService.On("Login", mock.Anything, "test@example.com", "password123", mock.Anything).
+			Return(&model.TokenPair{
+				AccessToken:  "valid.token",
+				RefreshToken: "refresh-token",
+				ExpiresAt:    time.Date(2025, 1, 31, 17, 15, 0, 0, time.UTC),
+			}, nil)
 
 		// Convert mock to service.AuthServicer interface type
 		var authService service.AuthServicer = mockAuthService
 		handler.Login(authService)(c)
 
 		assert.Equal(t, http.StatusOK, w.Code)
-		assert.JSONEq(t, `{"token":"valid.token"}`, w.Body.String())
+		assert.JSONEq(t, `{"access_token":"valid.token","refresh_token":"refresh-token","expires_at":"2025-01-31T17:15:00Z"}`, w.Body.String())
 		mockAuthService.AssertExpectations(t)
 	})
 
@@ -110,8 +212,8 @@ func TestLoginHandler(t *testing.T) {
 		c.Request = req
 
 		mockAuthService := new(MockAuthService)
-		mockAuthService.On("Login", mock.Anything, "test@example.com", "wrong").
-			Return("", service.ErrInvalidCredentials)
+		mockAuthService.On("Login", mock.Anything, "test@example.com", "wrong", mock.Anything).
+			Return(nil, service.ErrInvalidCredentials)
 
 		var authService service.AuthServicer = mockAuthService
 		handler.Login(authService)(c)
@@ -120,4 +222,403 @@ func TestLoginHandler(t *testing.T) {
 		assert.Contains(t, w.Body.String(), "invalid credentials")
 		mockAuthService.AssertExpectations(t)
 	})
+
+	t.Run("Email Not Verified", func(t *testing.T) {
+		gin.SetMode(gin.TestMode)
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+
+		body := `{"email":"test@example.com","password":"password123"}`
+		req := httptest.NewRequest("POST", "/login", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		c.Request = req
+
+		mockAuthService := new(MockAuthService)
+		mockAuthService.On("Login", mock.Anything, "test@example.com", "password123", mock.Anything).
+			Return(nil, service.ErrEmailNotVerified)
+
+		handler.Login(mockAuthService)(c)
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.JSONEq(t, `{"error":"email address is not verified","code":"email_not_verified"}`, w.Body.String())
+	})
+
+	t.Run("Locked Out", func(t *testing.T) {
+		gin.SetMode(gin.TestMode)
+		router := gin.New()
+		mockAuthService := new(MockAuthService)
+		router.POST("/login", handler.Login(mockAuthService))
+		mockAuthService.On("Login", mock.Anything, "test@example.com", "password123", "192.0.2.1").
+			Return(nil, &service.RetryAfterError{Err: service.ErrTooManyLoginAttempts, RetryAfter: 14*time.Minute + 500*time.Millisecond})
+
+		w := performRequest(router, "POST", "/login", `{"email":"test@example.com","password":"password123"}`)
+
+		assert.Equal(t, http.StatusTooManyRequests, w.Code)
+		assert.Equal(t, "841", w.Header().Get("Retry-After"))
+		assert.JSONEq(t, `{"error":"too many failed login attempts; try again later"}`, w.Body.String())
+	})
+}
+
+func TestRefreshHandler(t *testing.T) {
+	performRefresh := func(authService service.AuthServicer, body string) *httptest.ResponseRecorder {
+		gin.SetMode(gin.TestMode)
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+
+		req := httptest.NewRequest("POST", "/refresh", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		c.Request = req
+
+		handler.Refresh(authService)(c)
+		return w
+	}
+
+	t.Run("Successful Refresh", func(t *testing.T) {
+		mockAuthService := new(MockAuthService)
+		mockAuthService.On("Refresh", mock.Anything, "old-refresh-token").
+			Return(&model.TokenPair{
+				AccessToken:  "new.token",
+				RefreshToken: "new-refresh-token",
+				ExpiresAt:    time.Date(2025, 1, 31, 17, 15, 0, 0, time.UTC),
+			}, nil)
+
+		w := performRefresh(mockAuthService, `{"refresh_token":"old-refresh-token"}`)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `{"access_token":"new.token","refresh_token":"new-refresh-token","expires_at":"2025-01-31T17:15:00Z"}`, w.Body.String())
+		mockAuthService.AssertExpectations(t)
+	})
+
+	t.Run("Rejected Tokens", func(t *testing.T) {
+		for _, err := range []error{service.ErrInvalidRefreshToken, service.ErrRefreshTokenReused} {
+			mockAuthService := new(MockAuthService)
+			mockAuthService.On("Refresh", mock.Anything, "old-refresh-token").Return(nil, err)
+
+			w := performRefresh(mockAuthService, `{"refresh_token":"old-refresh-token"}`)
+
+			assert.Equal(t, http.StatusUnauthorized, w.Code)
+			assert.Contains(t, w.Body.String(), err.Error())
+		}
+	})
+
+	t.Run("Missing Token", func(t *testing.T) {
+		mockAuthService := new(MockAuthService)
+
+		w := performRefresh(mockAuthService, `{}`)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		mockAuthService.AssertNotCalled(t, "Refresh", mock.Anything, mock.Anything)
+	})
+}
+
+func TestLogoutHandlers(t *testing.T) {
+	expiresAt := time.Date(2025, 1, 31, 17, 15, 0, 0, time.UTC)
+	newRouter := func(authService service.AuthServicer, userID uint) *gin.Engine {
+		gin.SetMode(gin.TestMode)
+		router := gin.New()
+		router.Use(func(c *gin.Context) {
+			if userID != 0 {
+				c.Set("userID", userID)
+				c.Set("tokenID", "token-id")
+				c.Set("tokenExpiresAt", expiresAt)
+			}
+		})
+		router.POST("/logout", handler.Logout(authService))
+		router.POST("/logout-all", handler.LogoutAll(authService))
+		return router
+	}
+
+	t.Run("Logout", func(t *testing.T) {
+		mockAuthService := new(MockAuthService)
+		mockAuthService.On("Logout", mock.Anything, int64(1), "token-id", expiresAt, "").Return(nil)
+
+		w := performRequest(newRouter(mockAuthService, 1), "POST", "/logout", "")
+
+		assert.Equal(t, http.StatusNoContent, w.Code)
+		mockAuthService.AssertExpectations(t)
+	})
+
+	t.Run("Logout With Refresh Token", func(t *testing.T) {
+		mockAuthService := new(MockAuthService)
+		mockAuthService.On("Logout", mock.Anything, int64(1), "token-id", expiresAt, "refresh-token").Return(nil)
+
+		w := performRequest(newRouter(mockAuthService, 1), "POST", "/logout", `{"refresh_token":"refresh-token"}`)
+
+		assert.Equal(t, http.StatusNoContent, w.Code)
+		mockAuthService.AssertExpectations(t)
+	})
+
+	t.Run("Logout Unauthenticated", func(t *testing.T) {
+		mockAuthService := new(MockAuthService)
+
+		w := performRequest(newRouter(mockAuthService, 0), "POST", "/logout", "")
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		mockAuthService.AssertNotCalled(t, "Logout", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Logout All", func(t *testing.T) {
+		mockAuthService := new(MockAuthService)
+		mockAuthService.On("LogoutAll", mock.Anything, int64(1)).Return(nil)
+
+		w := performRequest(newRouter(mockAuthService, 1), "POST", "/logout-all", "")
+
+		assert.Equal(t, http.StatusNoContent, w.Code)
+		mockAuthService.AssertExpectations(t)
+	})
+}
+
+func TestPasswordResetHandlers(t *testing.T) {
+	newRouter := func(authService service.AuthServicer) *gin.Engine {
+		gin.SetMode(gin.TestMode)
+		router := gin.New()
+		router.POST("/password/forgot", handler.ForgotPassword(authService))
+		router.POST("/password/reset", handler.ResetPassword(authService))
+		return router
+	}
+
+	t.Run("Forgot Password", func(t *testing.T) {
+		mockAuthService := new(MockAuthService)
+		mockAuthService.On("ForgotPassword", mock.Anything, "user@example.com").Return(nil)
+
+		w := performRequest(newRouter(mockAuthService), "POST", "/password/forgot", `{"email":"user@example.com"}`)
+
+		assert.Equal(t, http.StatusAccepted, w.Code)
+		mockAuthService.AssertExpectations(t)
+	})
+
+	t.Run("Forgot Password Invalid Email", func(t *testing.T) {
+		mockAuthService := new(MockAuthService)
+
+		w := performRequest(newRouter(mockAuthService), "POST", "/password/forgot", `{"email":"not-an-email"}`)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		mockAuthService.AssertNotCalled(t, "ForgotPassword", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Reset Password", func(t *testing.T) {
+		mockAuthService := new(MockAuthService)
+		mockAuthService.On("ResetPassword", mock.Anything, "reset-token", "new-password").Return(nil)
+
+		w := performRequest(newRouter(mockAuthService), "POST", "/password/reset", `{"token":"reset-token","password":"new-password"}`)
+
+		assert.Equal(t, http.StatusNoContent, w.Code)
+		mockAuthService.AssertExpectations(t)
+	})
+
+	t.Run("Reset Password Invalid Token", func(t *testing.T) {
+		mockAuthService := new(MockAuthService)
+		mockAuthService.On("ResetPassword", mock.Anything, "used-token", "new-password").Return(service.ErrInvalidResetToken)
+
+		w := performRequest(newRouter(mockAuthService), "POST", "/password/reset", `{"token":"used-token","password":"new-password"}`)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), service.ErrInvalidResetToken.Error())
+	})
+
+	t.Run("Reset Password Too Short", func(t *testing.T) {
+		mockAuthService := new(MockAuthService)
+		mockAuthService.On("ResetPassword", mock.Anything, "reset-token", "short").
+			Return(fmt.Errorf("%w: use at least 8 characters", service.ErrPasswordTooShort))
+
+		w := performRequest(newRouter(mockAuthService), "POST", "/password/reset", `{"token":"reset-token","password":"short"}`)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "use at least 8 characters")
+	})
+}
+
+func TestEmailVerificationHandlers(t *testing.T) {
+	newRouter := func(authService service.AuthServicer) *gin.Engine {
+		gin.SetMode(gin.TestMode)
+		router := gin.New()
+		router.POST("/verify", handler.VerifyEmail(authService))
+		router.POST("/verify/resend", handler.ResendVerification(authService))
+		return router
+	}
+
+	t.Run("Verify", func(t *testing.T) {
+		mockAuthService := new(MockAuthService)
+		mockAuthService.On("VerifyEmail", mock.Anything, "verify-token").Return(nil)
+
+		w := performRequest(newRouter(mockAuthService), "POST", "/verify", `{"token":"verify-token"}`)
+
+		assert.Equal(t, http.StatusNoContent, w.Code)
+		mockAuthService.AssertExpectations(t)
+	})
+
+	t.Run("Verify Invalid Token", func(t *testing.T) {
+		mockAuthService := new(MockAuthService)
+		mockAuthService.On("VerifyEmail", mock.Anything, "used-token").Return(service.ErrInvalidVerificationToken)
+
+		w := performRequest(newRouter(mockAuthService), "POST", "/verify", `{"token":"used-token"}`)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("Resend", func(t *testing.T) {
+		mockAuthService := new(MockAuthService)
+		mockAuthService.On("ResendVerification", mock.Anything, "user@example.com").Return(nil)
+
+		w := performRequest(newRouter(mockAuthService), "POST", "/verify/resend", `{"email":"user@example.com"}`)
+
+		assert.Equal(t, http.StatusAccepted, w.Code)
+		mockAuthService.AssertExpectations(t)
+	})
+
+	t.Run("Resend Rate Limited", func(t *testing.T) {
+		mockAuthService := new(MockAuthService)
+		mockAuthService.On("ResendVerification", mock.Anything, "user@example.com").
+			Return(&service.RetryAfterError{Err: service.ErrTooManyRequests, RetryAfter: 41500 * time.Millisecond})
+
+		w := performRequest(newRouter(mockAuthService), "POST", "/verify/resend", `{"email":"user@example.com"}`)
+
+		assert.Equal(t, http.StatusTooManyRequests, w.Code)
+		assert.Equal(t, "42", w.Header().Get("Retry-After"))
+	})
+}
+
+func TestMFAHandlers(t *testing.T) {
+	newRouter := func(authService service.AuthServicer) *gin.Engine {
+		gin.SetMode(gin.TestMode)
+		router := gin.New()
+		router.POST("/mfa/verify", handler.VerifyMFA(authService))
+		authenticated := router.Group("/mfa", fakeAuth(1))
+		authenticated.POST("/enroll", handler.EnrollMFA(authService))
+		authenticated.POST("/confirm", handler.ConfirmMFA(authService))
+		authenticated.POST("/disable", handler.DisableMFA(authService))
+		return router
+	}
+
+	t.Run("Login Requires MFA", func(t *testing.T) {
+		gin.SetMode(gin.TestMode)
+		router := gin.New()
+		mockAuthService := new(MockAuthService)
+		router.POST("/login", handler.Login(mockAuthService))
+		expiresAt := time.Date(2025, 1, 31, 17, 5, 0, 0, time.UTC)
+		mockAuthService.On("Login", mock.Anything, "user@example.com", "password123", mock.Anything).
+			Return(&model.TokenPair{MFARequired: true, MFAToken: "mfa-token", ExpiresAt: expiresAt}, nil)
+
+		w := performRequest(router, "POST", "/login", `{"email":"user@example.com","password":"password123"}`)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `{"mfa_required":true,"mfa_token":"mfa-token","expires_at":"2025-01-31T17:05:00Z"}`, w.Body.String())
+	})
+
+	t.Run("Verify", func(t *testing.T) {
+		mockAuthService := new(MockAuthService)
+		mockAuthService.On("VerifyMFA", mock.Anything, "mfa-token", "123456", "192.0.2.1").
+			Return(&model.TokenPair{AccessToken: "access", RefreshToken: "refresh"}, nil)
+
+		w := performRequest(newRouter(mockAuthService), "POST", "/mfa/verify", `{"mfa_token":"mfa-token","code":"123456"}`)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"access_token":"access"`)
+	})
+
+	t.Run("Verify Wrong Code", func(t *testing.T) {
+		mockAuthService := new(MockAuthService)
+		mockAuthService.On("VerifyMFA", mock.Anything, "mfa-token", "000000", "192.0.2.1").Return(nil, service.ErrInvalidMFACode)
+
+		w := performRequest(newRouter(mockAuthService), "POST", "/mfa/verify", `{"mfa_token":"mfa-token","code":"000000"}`)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+
+	t.Run("Verify Locked Out", func(t *testing.T) {
+		mockAuthService := new(MockAuthService)
+		mockAuthService.On("VerifyMFA", mock.Anything, "mfa-token", "000000", "192.0.2.1").
+			Return(nil, &service.RetryAfterError{Err: service.ErrTooManyLoginAttempts, RetryAfter: 15 * time.Minute})
+
+		w := performRequest(newRouter(mockAuthService), "POST", "/mfa/verify", `{"mfa_token":"mfa-token","code":"000000"}`)
+
+		assert.Equal(t, http.StatusTooManyRequests, w.Code)
+		assert.Equal(t, "900", w.Header().Get("Retry-After"))
+	})
+
+	t.Run("Enroll", func(t *testing.T) {
+		mockAuthService := new(MockAuthService)
+		mockAuthService.On("EnrollMFA", mock.Anything, int64(1)).
+			Return(&model.MFAEnrollment{Secret: "SECRET", URI: "otpauth://totp/user@example.com?secret=SECRET"}, nil)
+
+		w := performRequest(newRouter(mockAuthService), "POST", "/mfa/enroll", "")
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `{"secret":"SECRET","otpauth_uri":"otpauth://totp/user@example.com?secret=SECRET"}`, w.Body.String())
+	})
+
+	t.Run("Enroll Already Enabled", func(t *testing.T) {
+		mockAuthService := new(MockAuthService)
+		mockAuthService.On("EnrollMFA", mock.Anything, int64(1)).Return(nil, service.ErrMFAAlreadyEnabled)
+
+		w := performRequest(newRouter(mockAuthService), "POST", "/mfa/enroll", "")
+
+		assert.Equal(t, http.StatusConflict, w.Code)
+	})
+
+	t.Run("Confirm", func(t *testing.T) {
+		mockAuthService := new(MockAuthService)
+		mockAuthService.On("ConfirmMFA", mock.Anything, int64(1), "123456").Return([]string{"k3vq-9xzt"}, nil)
+
+		w := performRequest(newRouter(mockAuthService), "POST", "/mfa/confirm", `{"code":"123456"}`)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `{"recovery_codes":["k3vq-9xzt"]}`, w.Body.String())
+	})
+
+	t.Run("Confirm Wrong Code", func(t *testing.T) {
+		mockAuthService := new(MockAuthService)
+		mockAuthService.On("ConfirmMFA", mock.Anything, int64(1), "000000").Return(nil, service.ErrInvalidMFACode)
+
+		w := performRequest(newRouter(mockAuthService), "POST", "/mfa/confirm", `{"code":"000000"}`)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("Disable", func(t *testing.T) {
+		mockAuthService := new(MockAuthService)
+		mockAuthService.On("DisableMFA", mock.Anything, int64(1), "password123", "", "192.0.2.1").Return(nil)
+
+		w := performRequest(newRouter(mockAuthService), "POST", "/mfa/disable", `{"password":"password123"}`)
+
+		assert.Equal(t, http.StatusNoContent, w.Code)
+	})
+
+	t.Run("Disable Wrong Password", func(t *testing.T) {
+		mockAuthService := new(MockAuthService)
+		mockAuthService.On("DisableMFA", mock.Anything, int64(1), "wrong", "", "192.0.2.1").Return(service.ErrInvalidCredentials)
+
+		w := performRequest(newRouter(mockAuthService), "POST", "/mfa/disable", `{"password":"wrong"}`)
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+
+	t.Run("Disable With Code", func(t *testing.T) {
+		mockAuthService := new(MockAuthService)
+		mockAuthService.On("DisableMFA", mock.Anything, int64(1), "", "123456", "192.0.2.1").Return(nil)
+
+		w := performRequest(newRouter(mockAuthService), "POST", "/mfa/disable", `{"code":"123456"}`)
+
+		assert.Equal(t, http.StatusNoContent, w.Code)
+	})
+
+	t.Run("Disable Locked Out", func(t *testing.T) {
+		mockAuthService := new(MockAuthService)
+		mockAuthService.On("DisableMFA", mock.Anything, int64(1), "wrong", "", "192.0.2.1").
+			Return(&service.RetryAfterError{Err: service.ErrTooManyLoginAttempts, RetryAfter: 15 * time.Minute})
+
+		w := performRequest(newRouter(mockAuthService), "POST", "/mfa/disable", `{"password":"wrong"}`)
+
+		assert.Equal(t, http.StatusTooManyRequests, w.Code)
+		assert.Equal(t, "900", w.Header().Get("Retry-After"))
+	})
+
+	t.Run("Disable Without Password", func(t *testing.T) {
+		mockAuthService := new(MockAuthService)
+
+		w := performRequest(newRouter(mockAuthService), "POST", "/mfa/disable", `{}`)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		mockAuthService.AssertNotCalled(t, "DisableMFA", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
 }
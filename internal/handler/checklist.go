@@ -0,0 +1,234 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/ahmednurovic/task-manager-api/internal/model"
+	"github.com/ahmednurovic/task-manager-api/internal/service"
+)
+
+type ChecklistService interface {
+	GetChecklist(ctx *gin.Context, taskID int64, userID int64) ([]*model.ChecklistItem, error)
+	CreateChecklistItem(ctx *gin.Context, item *model.ChecklistItem, position *int, userID int64) error
+	UpdateChecklistItem(ctx *gin.Context, item *model.ChecklistItem, position *int, userID int64) error
+	DeleteChecklistItem(ctx *gin.Context, taskID int64, itemID int64, userID int64) error
+}
+
+type ChecklistHandler struct {
+	service ChecklistService
+}
+
+func NewChecklistHandler(service ChecklistService) *ChecklistHandler {
+	return &ChecklistHandler{service: service}
+}
+
+// ChecklistItemRequest is the client-writable part of a checklist item.
+// Omitting the position appends a new item at the end and keeps an existing
+// item's place.
+type ChecklistItemRequest struct {
+	Title    string `json:"title" example:"Proofread the summary"`
+	Done     bool   `json:"done" example:"false"`
+	Position *int   `json:"position" example:"0"`
+}
+
+func (r ChecklistItemRequest) toItem(taskID int64) model.ChecklistItem {
+	return model.ChecklistItem{
+		TaskID: uint(taskID),
+		Title:  r.Title,
+		Done:   r.Done,
+	}
+}
+
+// GetChecklist godoc
+// @Summary Get a task's checklist
+// @Description Get the checklist items of a task owned by the authenticated user, in position order
+// @Tags checklists
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Task ID"
+// @Success 200 {array} model.ChecklistItem
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /tasks/{id}/checklist [get]
+func (h *ChecklistHandler) GetChecklist(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	taskID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid task id"})
+		return
+	}
+
+	items, err := h.service.GetChecklist(c, taskID, userID)
+	if err != nil {
+		respondChecklistError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, items)
+}
+
+// CreateChecklistItem godoc
+// @Summary Add a checklist item
+// @Description Add a checklist item to a task owned by the authenticated user
+// @Tags checklists
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Task ID"
+// @Param item body ChecklistItemRequest true "Checklist item object"
+// @Success 201 {object} model.ChecklistItem
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /tasks/{id}/checklist [post]
+func (h *ChecklistHandler) CreateChecklistItem(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	taskID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid task id"})
+		return
+	}
+
+	var req ChecklistItemRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	item := req.toItem(taskID)
+	if err := h.service.CreateChecklistItem(c, &item, req.Position, userID); err != nil {
+		respondChecklistError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, item)
+}
+
+// UpdateChecklistItem godoc
+// @Summary Update a checklist item
+// @Description Update a checklist item of a task owned by the authenticated user
+// @Tags checklists
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Task ID"
+// @Param item_id path int true "Checklist item ID"
+// @Param item body ChecklistItemRequest true "Updated checklist item object"
+// @Success 200 {object} model.ChecklistItem
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /tasks/{id}/checklist/{item_id} [put]
+func (h *ChecklistHandler) UpdateChecklistItem(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	taskID, itemID, ok := checklistItemParams(c)
+	if !ok {
+		return
+	}
+
+	var req ChecklistItemRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	item := req.toItem(taskID)
+	item.ID = uint(itemID)
+	if err := h.service.UpdateChecklistItem(c, &item, req.Position, userID); err != nil {
+		respondChecklistError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, item)
+}
+
+// DeleteChecklistItem godoc
+// @Summary Delete a checklist item
+// @Description Delete a checklist item of a task owned by the authenticated user
+// @Tags checklists
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Task ID"
+// @Param item_id path int true "Checklist item ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /tasks/{id}/checklist/{item_id} [delete]
+func (h *ChecklistHandler) DeleteChecklistItem(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	taskID, itemID, ok := checklistItemParams(c)
+	if !ok {
+		return
+	}
+
+	if err := h.service.DeleteChecklistItem(c, taskID, itemID, userID); err != nil {
+		respondChecklistError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Checklist item deleted successfully"})
+}
+
+// checklistItemParams parses the task and item IDs from the path, writing a
+// 400 response if either is malformed.
+func checklistItemParams(c *gin.Context) (int64, int64, bool) {
+	taskID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid task id"})
+		return 0, 0, false
+	}
+
+	itemID, err := strconv.ParseInt(c.Param("item_id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid checklist item id"})
+		return 0, 0, false
+	}
+
+	return taskID, itemID, true
+}
+
+func respondChecklistError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrChecklistItemNotFound),
+		errors.Is(err, service.ErrTaskNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrChecklistTitleRequired),
+		errors.Is(err, service.ErrChecklistTitleTooLong),
+		errors.Is(err, service.ErrInvalidPosition):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrInsufficientRole):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,271 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/ahmednurovic/task-manager-api/internal/model"
+	"github.com/ahmednurovic/task-manager-api/internal/service"
+)
+
+type CommentService interface {
+	GetComments(ctx *gin.Context, taskID int64, cursor string, limit int, userID int64) (*model.CommentPage, error)
+	CreateComment(ctx *gin.Context, comment *model.Comment, userID int64) error
+	UpdateComment(ctx *gin.Context, comment *model.Comment, userID int64) error
+	DeleteComment(ctx *gin.Context, taskID int64, commentID int64, userID int64) error
+	GetCommentHistory(ctx *gin.Context, taskID int64, commentID int64, userID int64) ([]*model.CommentRevision, error)
+}
+
+type CommentHandler struct {
+	service CommentService
+}
+
+func NewCommentHandler(service CommentService) *CommentHandler {
+	return &CommentHandler{service: service}
+}
+
+// CommentRequest is the client-writable part of a comment.
+type CommentRequest struct {
+	Body string `json:"body" example:"Looks good, @ana can you double-check the totals?"`
+}
+
+// GetComments godoc
+// @Summary List a task's comments
+// @Description List the comments on a task, oldest first, one page at a time. Deleted comments are left out.
+// @Tags comments
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Task ID"
+// @Param cursor query string false "next_cursor from the previous page"
+// @Param limit query int false "Page size (1-100, default 50)"
+// @Success 200 {object} model.CommentPage
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /tasks/{id}/comments [get]
+func (h *CommentHandler) GetComments(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	taskID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid task id"})
+		return
+	}
+
+	var limit int
+	if value := c.Query("limit"); value != "" {
+		if limit, err = strconv.Atoi(value); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid limit"})
+			return
+		}
+	}
+
+	page, err := h.service.GetComments(c, taskID, c.Query("cursor"), limit, userID)
+	if err != nil {
+		respondCommentError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, page)
+}
+
+// CreateComment godoc
+// @Summary Comment on a task
+// @Description Add a markdown comment to a task. @email and @username mentions of users who can see the task are resolved and stored; a username is the part of an email address before the @.
+// @Tags comments
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Task ID"
+// @Param comment body CommentRequest true "Comment"
+// @Success 201 {object} model.Comment
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /tasks/{id}/comments [post]
+func (h *CommentHandler) CreateComment(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	taskID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid task id"})
+		return
+	}
+
+	var req CommentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	comment := model.Comment{TaskID: uint(taskID), Body: req.Body}
+	if err := h.service.CreateComment(c, &comment, userID); err != nil {
+		respondCommentError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, comment)
+}
+
+// UpdateComment godoc
+// @Summary Edit a comment
+// @Description Replace the body of a comment. Only its author and workspace admins can edit it. The previous body is kept in the comment's history.
+// @Tags comments
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Task ID"
+// @Param comment_id path int true "Comment ID"
+// @Param comment body CommentRequest true "Updated comment"
+// @Success 200 {object} model.Comment
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /tasks/{id}/comments/{comment_id} [put]
+func (h *CommentHandler) UpdateComment(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	taskID, commentID, ok := commentParams(c)
+	if !ok {
+		return
+	}
+
+	var req CommentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	comment := model.Comment{ID: uint(commentID), TaskID: uint(taskID), Body: req.Body}
+	if err := h.service.UpdateComment(c, &comment, userID); err != nil {
+		respondCommentError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, comment)
+}
+
+// DeleteComment godoc
+// @Summary Delete a comment
+// @Description Delete a comment. Only its author and workspace admins can delete it.
+// @Tags comments
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Task ID"
+// @Param comment_id path int true "Comment ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /tasks/{id}/comments/{comment_id} [delete]
+func (h *CommentHandler) DeleteComment(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	taskID, commentID, ok := commentParams(c)
+	if !ok {
+		return
+	}
+
+	if err := h.service.DeleteComment(c, taskID, commentID, userID); err != nil {
+		respondCommentError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Comment deleted successfully"})
+}
+
+// GetCommentHistory godoc
+// @Summary Get a comment's edit history
+// @Description List the bodies a comment had before each of its edits, oldest first
+// @Tags comments
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Task ID"
+// @Param comment_id path int true "Comment ID"
+// @Success 200 {array} model.CommentRevision
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /tasks/{id}/comments/{comment_id}/history [get]
+func (h *CommentHandler) GetCommentHistory(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	taskID, commentID, ok := commentParams(c)
+	if !ok {
+		return
+	}
+
+	revisions, err := h.service.GetCommentHistory(c, taskID, commentID, userID)
+	if err != nil {
+		respondCommentError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, revisions)
+}
+
+// commentParams parses the task and comment IDs from the path, writing a
+// 400 response if either is malformed.
+func commentParams(c *gin.Context) (int64, int64, bool) {
+	taskID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid task id"})
+		return 0, 0, false
+	}
+
+	commentID, err := strconv.ParseInt(c.Param("comment_id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid comment id"})
+		return 0, 0, false
+	}
+
+	return taskID, commentID, true
+}
+
+func respondCommentError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrCommentNotFound),
+		errors.Is(err, service.ErrTaskNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrCommentBodyRequired),
+		errors.Is(err, service.ErrCommentBodyTooLong),
+		errors.Is(err, service.ErrInvalidFilter):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrNotCommentAuthor),
+		errors.Is(err, service.ErrInsufficientRole):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,140 @@
+package handler_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/ahmednurovic/task-manager-api/internal/handler"
+	"github.com/ahmednurovic/task-manager-api/internal/model"
+	"github.com/ahmednurovic/task-manager-api/internal/service"
+)
+
+type MockCommentService struct {
+	mock.Mock
+}
+
+func (m *MockCommentService) GetComments(ctx *gin.Context, taskID int64, cursor string, limit int, userID int64) (*model.CommentPage, error) {
+	args := m.Called(ctx, taskID, cursor, limit, userID)
+	page, _ := args.Get(0).(*model.CommentPage)
+	return page, args.Error(1)
+}
+
+func (m *MockCommentService) CreateComment(ctx *gin.Context, comment *model.Comment, userID int64) error {
+	args := m.Called(ctx, comment, userID)
+	return args.Error(0)
+}
+
+func (m *MockCommentService) UpdateComment(ctx *gin.Context, comment *model.Comment, userID int64) error {
+	args := m.Called(ctx, comment, userID)
+	return args.Error(0)
+}
+
+func (m *MockCommentService) DeleteComment(ctx *gin.Context, taskID int64, commentID int64, userID int64) error {
+	args := m.Called(ctx, taskID, commentID, userID)
+	return args.Error(0)
+}
+
+func (m *MockCommentService) GetCommentHistory(ctx *gin.Context, taskID int64, commentID int64, userID int64) ([]*model.CommentRevision, error) {
+	args := m.Called(ctx, taskID, commentID, userID)
+	revisions, _ := args.Get(0).([]*model.CommentRevision)
+	return revisions, args.Error(1)
+}
+
+func newCommentRouter(commentService handler.CommentService, userID uint) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+
+	tasks := router.Group("/tasks").Use(fakeAuth(userID))
+	commentHandler := handler.NewCommentHandler(commentService)
+	tasks.GET("/:id/comments", commentHandler.GetComments)
+	tasks.POST("/:id/comments", commentHandler.CreateComment)
+	tasks.PUT("/:id/comments/:comment_id", commentHandler.UpdateComment)
+	tasks.DELETE("/:id/comments/:comment_id", commentHandler.DeleteComment)
+	tasks.GET("/:id/comments/:comment_id/history", commentHandler.GetCommentHistory)
+
+	return router
+}
+
+func TestCommentHandlers(t *testing.T) {
+	t.Run("List", func(t *testing.T) {
+		mockCommentService := new(MockCommentService)
+		mockCommentService.On("GetComments", mock.Anything, int64(10), "abc", 20, int64(1)).
+			Return(&model.CommentPage{Items: []*model.Comment{}, NextCursor: "def"}, nil)
+
+		router := newCommentRouter(mockCommentService, 1)
+		w := performRequest(router, "GET", "/tasks/10/comments?cursor=abc&limit=20", "")
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `{"items":[],"next_cursor":"def"}`, w.Body.String())
+	})
+
+	t.Run("Invalid Limit", func(t *testing.T) {
+		mockCommentService := new(MockCommentService)
+
+		router := newCommentRouter(mockCommentService, 1)
+		w := performRequest(router, "GET", "/tasks/10/comments?limit=many", "")
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		mockCommentService.AssertNotCalled(t, "GetComments", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Create", func(t *testing.T) {
+		mockCommentService := new(MockCommentService)
+		mockCommentService.On("CreateComment", mock.Anything, mock.MatchedBy(func(comment *model.Comment) bool {
+			return comment.TaskID == 10 && comment.Body == "Hi @ana"
+		}), int64(1)).Return(nil)
+
+		router := newCommentRouter(mockCommentService, 1)
+		w := performRequest(router, "POST", "/tasks/10/comments", `{"body":"Hi @ana"}`)
+
+		assert.Equal(t, http.StatusCreated, w.Code)
+		mockCommentService.AssertExpectations(t)
+	})
+
+	t.Run("Empty Body", func(t *testing.T) {
+		mockCommentService := new(MockCommentService)
+		mockCommentService.On("CreateComment", mock.Anything, mock.Anything, int64(1)).Return(service.ErrCommentBodyRequired)
+
+		router := newCommentRouter(mockCommentService, 1)
+		w := performRequest(router, "POST", "/tasks/10/comments", `{"body":""}`)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("Edit Someone Else's", func(t *testing.T) {
+		mockCommentService := new(MockCommentService)
+		mockCommentService.On("UpdateComment", mock.Anything, mock.MatchedBy(func(comment *model.Comment) bool {
+			return comment.ID == 20 && comment.TaskID == 10
+		}), int64(1)).Return(service.ErrNotCommentAuthor)
+
+		router := newCommentRouter(mockCommentService, 1)
+		w := performRequest(router, "PUT", "/tasks/10/comments/20", `{"body":"Edited"}`)
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.JSONEq(t, `{"error":"only the author or a workspace admin can change a comment"}`, w.Body.String())
+	})
+
+	t.Run("Delete Missing", func(t *testing.T) {
+		mockCommentService := new(MockCommentService)
+		mockCommentService.On("DeleteComment", mock.Anything, int64(10), int64(20), int64(1)).Return(service.ErrCommentNotFound)
+
+		router := newCommentRouter(mockCommentService, 1)
+		w := performRequest(router, "DELETE", "/tasks/10/comments/20", "")
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("Invalid Comment ID", func(t *testing.T) {
+		mockCommentService := new(MockCommentService)
+
+		router := newCommentRouter(mockCommentService, 1)
+		w := performRequest(router, "GET", "/tasks/10/comments/abc/history", "")
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		mockCommentService.AssertNotCalled(t, "GetCommentHistory", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+}
@@ -0,0 +1,30 @@
+package handler
+
+import (
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// currentUserID returns the ID of the user authenticated by AuthMiddleware.
+func currentUserID(c *gin.Context) (int64, bool) {
+	value, exists := c.Get("userID")
+	if !exists {
+		return 0, false
+	}
+
+	userID, ok := value.(uint)
+	if !ok || userID == 0 {
+		return 0, false
+	}
+
+	return int64(userID), true
+}
+
+// currentToken returns the jti and expiry of the access token the request
+// was authenticated with.
+func currentToken(c *gin.Context) (string, time.Time) {
+	tokenID := c.GetString("tokenID")
+	expiresAt := c.GetTime("tokenExpiresAt")
+	return tokenID, expiresAt
+}
@@ -0,0 +1,142 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetDependencies godoc
+// @Summary List a task's dependencies
+// @Description List the tasks that block a task owned by the authenticated user, and the tasks it blocks
+// @Tags dependencies
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Task ID"
+// @Success 200 {object} model.TaskDependencies
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /tasks/{id}/dependencies [get]
+func (h *TaskHandler) GetDependencies(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	taskID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid task id"})
+		return
+	}
+
+	dependencies, err := h.service.GetDependencies(c, taskID, userID)
+	if err != nil {
+		respondTaskError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, dependencies)
+}
+
+// AddDependency godoc
+// @Summary Add a dependency
+// @Description Mark a task as blocked by another task. Both must be owned by the authenticated user, and the dependency must not create a cycle. Adding an existing dependency is a no-op.
+// @Tags dependencies
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Task ID"
+// @Param blocker_id path int true "ID of the blocking task"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /tasks/{id}/dependencies/{blocker_id} [put]
+func (h *TaskHandler) AddDependency(c *gin.Context) {
+	h.changeDependency(c, h.service.AddDependency, "Dependency added successfully")
+}
+
+// RemoveDependency godoc
+// @Summary Remove a dependency
+// @Description Stop a task owned by the authenticated user from being blocked by another task. Removing a missing dependency is a no-op.
+// @Tags dependencies
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Task ID"
+// @Param blocker_id path int true "ID of the blocking task"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /tasks/{id}/dependencies/{blocker_id} [delete]
+func (h *TaskHandler) RemoveDependency(c *gin.Context) {
+	h.changeDependency(c, h.service.RemoveDependency, "Dependency removed successfully")
+}
+
+func (h *TaskHandler) changeDependency(c *gin.Context, change func(*gin.Context, int64, int64, int64) error, message string) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	taskID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid task id"})
+		return
+	}
+
+	blockerID, err := strconv.ParseInt(c.Param("blocker_id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid blocker id"})
+		return
+	}
+
+	if err := change(c, taskID, blockerID, userID); err != nil {
+		respondTaskError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": message})
+}
+
+// GetDependencyGraph godoc
+// @Summary Get a task's dependency graph
+// @Description Get a task owned by the authenticated user together with every task it transitively depends on, as nodes and blocked-by edges
+// @Tags dependencies
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Task ID"
+// @Success 200 {object} model.TaskGraph
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /tasks/{id}/graph [get]
+func (h *TaskHandler) GetDependencyGraph(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	taskID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid task id"})
+		return
+	}
+
+	graph, err := h.service.GetDependencyGraph(c, taskID, userID)
+	if err != nil {
+		respondTaskError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, graph)
+}
@@ -0,0 +1,24 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/ahmednurovic/task-manager-api/internal/signing"
+	"github.com/gin-gonic/gin"
+)
+
+// jwksMaxAge is how long clients may cache the key set. A new signing key
+// must be published at least this long before it starts signing tokens.
+const jwksMaxAge = "max-age=300"
+
+// JWKS serves the public keys access tokens are verified with, so other
+// services can check tokens without sharing a secret. It is served outside
+// /api/v1, at the conventional /.well-known/jwks.json, and is empty when
+// tokens are signed with HS256.
+func JWKS(keys *signing.KeySet) gin.HandlerFunc {
+	jwks := keys.JWKS()
+	return func(c *gin.Context) {
+		c.Header("Cache-Control", "public, "+jwksMaxAge)
+		c.JSON(http.StatusOK, jwks)
+	}
+}
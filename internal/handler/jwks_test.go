@@ -0,0 +1,54 @@
+package handler_test
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ahmednurovic/task-manager-api/internal/handler"
+	"github.com/ahmednurovic/task-manager-api/internal/signing"
+)
+
+func TestJWKSHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("Publishes Public Keys", func(t *testing.T) {
+		_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+		assert.NoError(t, err)
+		der, err := x509.MarshalPKCS8PrivateKey(privateKey)
+		assert.NoError(t, err)
+		keys, err := signing.New(signing.EdDSA, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}))
+		assert.NoError(t, err)
+
+		router := gin.New()
+		router.GET("/.well-known/jwks.json", handler.JWKS(keys))
+		w := performRequest(router, "GET", "/.well-known/jwks.json", "")
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "public, max-age=300", w.Header().Get("Cache-Control"))
+
+		var jwks signing.JWKS
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &jwks))
+		assert.Equal(t, keys.JWKS(), jwks)
+		assert.Len(t, jwks.Keys, 1)
+		assert.Equal(t, "OKP", jwks.Keys[0].KeyType)
+		assert.Equal(t, "sig", jwks.Keys[0].Use)
+		assert.NotContains(t, w.Body.String(), `"d"`)
+	})
+
+	t.Run("Empty For HMAC", func(t *testing.T) {
+		router := gin.New()
+		router.GET("/.well-known/jwks.json", handler.JWKS(signing.NewHMAC("test-secret")))
+		w := performRequest(router, "GET", "/.well-known/jwks.json", "")
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `{"keys":[]}`, w.Body.String())
+	})
+}
@@ -0,0 +1,255 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/ahmednurovic/task-manager-api/internal/model"
+	"github.com/ahmednurovic/task-manager-api/internal/service"
+)
+
+type LabelService interface {
+	CreateLabel(ctx *gin.Context, label *model.Label, userID int64) error
+	GetLabels(ctx *gin.Context, userID int64) ([]*model.Label, error)
+	UpdateLabel(ctx *gin.Context, label *model.Label, userID int64) error
+	DeleteLabel(ctx *gin.Context, labelID int64, userID int64) error
+	AttachLabel(ctx *gin.Context, taskID int64, labelID int64, userID int64) error
+	DetachLabel(ctx *gin.Context, taskID int64, labelID int64, userID int64) error
+}
+
+type LabelHandler struct {
+	service LabelService
+}
+
+func NewLabelHandler(service LabelService) *LabelHandler {
+	return &LabelHandler{service: service}
+}
+
+type LabelRequest struct {
+	Name  string `json:"name" example:"bug"`
+	Color string `json:"color" example:"#e53935"`
+}
+
+// CreateLabel godoc
+// @Summary Create a label
+// @Description Create a label owned by the authenticated user
+// @Tags labels
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param label body LabelRequest true "Label object"
+// @Success 201 {object} model.Label
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /labels [post]
+func (h *LabelHandler) CreateLabel(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	var req LabelRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	label := model.Label{Name: req.Name, Color: req.Color}
+	if err := h.service.CreateLabel(c, &label, userID); err != nil {
+		respondLabelError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, label)
+}
+
+// GetLabels godoc
+// @Summary Get the authenticated user's labels
+// @Description Get all labels owned by the authenticated user, ordered by name
+// @Tags labels
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} model.Label
+// @Failure 401 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /labels [get]
+func (h *LabelHandler) GetLabels(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	labels, err := h.service.GetLabels(c, userID)
+	if err != nil {
+		respondLabelError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, labels)
+}
+
+// UpdateLabel godoc
+// @Summary Update a label
+// @Description Rename or recolour a label owned by the authenticated user
+// @Tags labels
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Label ID"
+// @Param label body LabelRequest true "Updated label object"
+// @Success 200 {object} model.Label
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /labels/{id} [put]
+func (h *LabelHandler) UpdateLabel(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	labelID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid label id"})
+		return
+	}
+
+	var req LabelRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	label := model.Label{ID: uint(labelID), Name: req.Name, Color: req.Color}
+	if err := h.service.UpdateLabel(c, &label, userID); err != nil {
+		respondLabelError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, label)
+}
+
+// DeleteLabel godoc
+// @Summary Delete a label
+// @Description Delete a label owned by the authenticated user and remove it from all tasks
+// @Tags labels
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Label ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /labels/{id} [delete]
+func (h *LabelHandler) DeleteLabel(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	labelID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid label id"})
+		return
+	}
+
+	if err := h.service.DeleteLabel(c, labelID, userID); err != nil {
+		respondLabelError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Label deleted successfully"})
+}
+
+// AttachLabel godoc
+// @Summary Attach a label to a task
+// @Description Attach one of the authenticated user's labels to one of their tasks
+// @Tags labels
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Task ID"
+// @Param label_id path int true "Label ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /tasks/{id}/labels/{label_id} [put]
+func (h *LabelHandler) AttachLabel(c *gin.Context) {
+	h.changeTaskLabel(c, h.service.AttachLabel, "Label attached successfully")
+}
+
+// DetachLabel godoc
+// @Summary Detach a label from a task
+// @Description Remove one of the authenticated user's labels from one of their tasks
+// @Tags labels
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Task ID"
+// @Param label_id path int true "Label ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /tasks/{id}/labels/{label_id} [delete]
+func (h *LabelHandler) DetachLabel(c *gin.Context) {
+	h.changeTaskLabel(c, h.service.DetachLabel, "Label detached successfully")
+}
+
+func (h *LabelHandler) changeTaskLabel(c *gin.Context, change func(*gin.Context, int64, int64, int64) error, message string) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	taskID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid task id"})
+		return
+	}
+
+	labelID, err := strconv.ParseInt(c.Param("label_id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid label id"})
+		return
+	}
+
+	if err := change(c, taskID, labelID, userID); err != nil {
+		respondLabelError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": message})
+}
+
+func respondLabelError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrLabelNotFound),
+		errors.Is(err, service.ErrTaskNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrLabelNameRequired),
+		errors.Is(err, service.ErrLabelNameTooLong),
+		errors.Is(err, service.ErrInvalidLabelColor):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrLabelExists):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrInsufficientRole):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,200 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/ahmednurovic/task-manager-api/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+// VerifyMFA godoc
+// @Summary Complete a two-factor login
+// @Description Exchange the MFA token from a login, together with a code from an authenticator app or an unused recovery code, for an access token and a refresh token. Each code works once, and an MFA token stops working after five wrong codes. Wrong codes count as failed logins, so repeated ones make the account or the client's IP address wait, or lock them, like wrong passwords; until then the response is a 429 with Retry-After.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param input body VerifyMFARequest true "Verify input"
+// @Success 200 {object} TokenResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 429 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /auth/mfa/verify [post]
+func VerifyMFA(authService service.AuthServicer) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req VerifyMFARequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		tokens, err := authService.VerifyMFA(c.Request.Context(), req.MFAToken, req.Code, c.ClientIP())
+		if err != nil {
+			var retryErr *service.RetryAfterError
+			if errors.As(err, &retryErr) {
+				respondRetryAfter(c, retryErr)
+			} else if errors.Is(err, service.ErrInvalidMFAToken) || errors.Is(err, service.ErrInvalidMFACode) {
+				c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			} else if errors.Is(err, service.ErrEmailNotVerified) {
+				respondEmailNotVerified(c, err)
+			} else {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			}
+			return
+		}
+
+		c.JSON(http.StatusOK, tokens)
+	}
+}
+
+// EnrollMFA godoc
+// @Summary Start two-factor enrolment
+// @Description Generate a new TOTP secret for the authenticated user and return it with an otpauth:// URI for a QR code. Two-factor authentication is turned on once a code is confirmed; starting again before then replaces the secret.
+// @Tags auth
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} model.MFAEnrollment
+// @Failure 401 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /auth/mfa/enroll [post]
+func EnrollMFA(authService service.AuthServicer) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, ok := currentUserID(c)
+		if !ok {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+			return
+		}
+
+		enrollment, err := authService.EnrollMFA(c.Request.Context(), userID)
+		if err != nil {
+			respondMFAError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, enrollment)
+	}
+}
+
+// ConfirmMFA godoc
+// @Summary Confirm two-factor enrolment
+// @Description Turn two-factor authentication on with a code from the authenticator app. The response holds single-use recovery codes, which are not shown again.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param input body ConfirmMFARequest true "Confirm input"
+// @Success 200 {object} RecoveryCodesResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /auth/mfa/confirm [post]
+func ConfirmMFA(authService service.AuthServicer) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, ok := currentUserID(c)
+		if !ok {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+			return
+		}
+
+		var req ConfirmMFARequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		codes, err := authService.ConfirmMFA(c.Request.Context(), userID, req.Code)
+		if err != nil {
+			respondMFAError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, RecoveryCodesResponse{RecoveryCodes: codes})
+	}
+}
+
+// DisableMFA godoc
+// @Summary Turn two-factor authentication off
+// @Description Turn two-factor authentication off, or cancel an unconfirmed enrolment. Requires the current password or, for users without one such as those created by single sign-on, a code from the authenticator app or an unused recovery code. Wrong passwords and codes count as failed logins, so repeated ones make the account or the client's IP address wait, or lock them; until then the response is a 429 with Retry-After.
+// @Tags auth
+// @Accept json
+// @Security BearerAuth
+// @Param input body DisableMFARequest true "Disable input"
+// @Success 204
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 429 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /auth/mfa/disable [post]
+func DisableMFA(authService service.AuthServicer) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, ok := currentUserID(c)
+		if !ok {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+			return
+		}
+
+		var req DisableMFARequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		if req.Password == "" && req.Code == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "password or code is required"})
+			return
+		}
+
+		if err := authService.DisableMFA(c.Request.Context(), userID, req.Password, req.Code, c.ClientIP()); err != nil {
+			respondMFAError(c, err)
+			return
+		}
+
+		c.Status(http.StatusNoContent)
+	}
+}
+
+func respondMFAError(c *gin.Context, err error) {
+	var retryErr *service.RetryAfterError
+	switch {
+	case errors.As(err, &retryErr):
+		respondRetryAfter(c, retryErr)
+	case errors.Is(err, service.ErrUnauthorized):
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrInvalidMFACode):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	// A wrong password is a 403, not a 401: the access token is fine.
+	case errors.Is(err, service.ErrInvalidCredentials):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrMFAAlreadyEnabled), errors.Is(err, service.ErrMFANotEnrolled), errors.Is(err, service.ErrMFANotEnabled):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+type VerifyMFARequest struct {
+	MFAToken string `json:"mfa_token" binding:"required" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."`
+	// Code is a six-digit code from the authenticator app, or a recovery code.
+	Code string `json:"code" binding:"required" example:"123456"`
+}
+
+type ConfirmMFARequest struct {
+	Code string `json:"code" binding:"required" example:"123456"`
+}
+
+// DisableMFARequest needs Password, or Code for users without a password.
+type DisableMFARequest struct {
+	Password string `json:"password" example:"password123"`
+	// Code is a six-digit code from the authenticator app, or a recovery code.
+	Code string `json:"code" example:"123456"`
+}
+
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes" example:"k3vq-9xzt,m2pa-7rde"`
+}
@@ -0,0 +1,112 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"path"
+
+	"github.com/ahmednurovic/task-manager-api/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+// oidcStateCookie carries the state token from StartOIDC to OIDCCallback.
+// It is scoped to the provider's routes and sent on the provider's
+// top-level redirect back, which SameSite=Lax allows.
+const oidcStateCookie = "oidc_state"
+
+// StartOIDC godoc
+// @Summary Start a login with an identity provider
+// @Description Redirect the browser to the OpenID Connect provider to sign in. The provider sends the user back to the callback, which finishes the login.
+// @Tags auth
+// @Param provider path string true "Provider name"
+// @Success 302
+// @Failure 404 {object} ErrorResponse
+// @Failure 502 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /auth/oidc/{provider}/start [get]
+func StartOIDC(oidcService service.OIDCServicer) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		provider := c.Param("provider")
+		authURL, stateToken, err := oidcService.StartOIDC(c.Request.Context(), provider)
+		if err != nil {
+			respondOIDCError(c, err)
+			return
+		}
+
+		setOIDCStateCookie(c, stateToken, int(service.OIDCStateTTL.Seconds()))
+		c.Redirect(http.StatusFound, authURL)
+	}
+}
+
+// OIDCCallback godoc
+// @Summary Finish a login with an identity provider
+// @Description The provider redirects here after the user signs in. A user is found by their provider account, or else by an email the provider has verified, and is created if there is none. The response is the same as for a password login, including mfa_required for users with two-factor authentication on. An existing account whose email is not verified yet cannot be linked, to keep whoever registered it from sharing it.
+// @Tags auth
+// @Produce json
+// @Param provider path string true "Provider name"
+// @Param code query string true "Authorization code"
+// @Param state query string true "State"
+// @Success 200 {object} TokenResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 502 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /auth/oidc/{provider}/callback [get]
+func OIDCCallback(oidcService service.OIDCServicer) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		stateToken, _ := c.Cookie(oidcStateCookie)
+		setOIDCStateCookie(c, "", -1)
+
+		// The provider reports failures, such as the user declining, with
+		// an error parameter instead of a code.
+		if providerErr := c.Query("error"); providerErr != "" {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": service.ErrOIDCLoginFailed.Error() + ": " + providerErr})
+			return
+		}
+
+		code, state := c.Query("code"), c.Query("state")
+		if code == "" || state == "" || stateToken == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": service.ErrInvalidOIDCState.Error()})
+			return
+		}
+
+		tokens, err := oidcService.FinishOIDC(c.Request.Context(), c.Param("provider"), code, state, stateToken)
+		if err != nil {
+			respondOIDCError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, tokens)
+	}
+}
+
+func setOIDCStateCookie(c *gin.Context, value string, maxAge int) {
+	secure := c.Request.TLS != nil || c.GetHeader("X-Forwarded-Proto") == "https"
+	c.SetSameSite(http.SameSiteLaxMode)
+	// Both routes live under /auth/oidc/{provider}, the cookie's path.
+	c.SetCookie(oidcStateCookie, value, maxAge, path.Dir(c.Request.URL.Path), "", secure, true)
+}
+
+func respondOIDCError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrUnknownProvider):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrInvalidOIDCState):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrOIDCLoginFailed):
+		c.JSON(http.StatusUnauthorized, gin.H{"error": service.ErrOIDCLoginFailed.Error()})
+	case errors.Is(err, service.ErrOIDCEmailNotVerified):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrEmailNotVerified):
+		respondEmailNotVerified(c, err)
+	case errors.Is(err, service.ErrOIDCAccountNotVerified):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error(), "code": "email_not_verified"})
+	case errors.Is(err, service.ErrProviderUnavailable):
+		c.JSON(http.StatusBadGateway, gin.H{"error": service.ErrProviderUnavailable.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
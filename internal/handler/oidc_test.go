@@ -0,0 +1,151 @@
+package handler_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/ahmednurovic/task-manager-api/internal/handler"
+	"github.com/ahmednurovic/task-manager-api/internal/model"
+	"github.com/ahmednurovic/task-manager-api/internal/service"
+)
+
+type MockOIDCService struct {
+	mock.Mock
+}
+
+func (m *MockOIDCService) StartOIDC(ctx context.Context, provider string) (string, string, error) {
+	args := m.Called(ctx, provider)
+	return args.String(0), args.String(1), args.Error(2)
+}
+
+func (m *MockOIDCService) FinishOIDC(ctx context.Context, provider, code, state, stateToken string) (*model.TokenPair, error) {
+	args := m.Called(ctx, provider, code, state, stateToken)
+	tokens, _ := args.Get(0).(*model.TokenPair)
+	return tokens, args.Error(1)
+}
+
+func setupOIDCRouter(oidcService service.OIDCServicer) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/api/v1/auth/oidc/:provider/start", handler.StartOIDC(oidcService))
+	router.GET("/api/v1/auth/oidc/:provider/callback", handler.OIDCCallback(oidcService))
+	return router
+}
+
+func callback(router *gin.Engine, query, stateToken string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/api/v1/auth/oidc/google/callback?"+query, nil)
+	if stateToken != "" {
+		req.AddCookie(&http.Cookie{Name: "oidc_state", Value: stateToken})
+	}
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestStartOIDCHandler(t *testing.T) {
+	t.Run("Redirects To Provider", func(t *testing.T) {
+		mockOIDCService := new(MockOIDCService)
+		mockOIDCService.On("StartOIDC", mock.Anything, "google").Return("https://idp.example.com/authorize?state=abc", "state-token", nil)
+
+		w := performRequest(setupOIDCRouter(mockOIDCService), "GET", "/api/v1/auth/oidc/google/start", "")
+
+		assert.Equal(t, http.StatusFound, w.Code)
+		assert.Equal(t, "https://idp.example.com/authorize?state=abc", w.Header().Get("Location"))
+
+		cookies := w.Result().Cookies()
+		assert.Len(t, cookies, 1)
+		assert.Equal(t, "oidc_state", cookies[0].Name)
+		assert.Equal(t, "state-token", cookies[0].Value)
+		assert.Equal(t, "/api/v1/auth/oidc/google", cookies[0].Path)
+		assert.Equal(t, 600, cookies[0].MaxAge)
+		assert.True(t, cookies[0].HttpOnly)
+		assert.Equal(t, http.SameSiteLaxMode, cookies[0].SameSite)
+	})
+
+	t.Run("Unknown Provider", func(t *testing.T) {
+		mockOIDCService := new(MockOIDCService)
+		mockOIDCService.On("StartOIDC", mock.Anything, "other").Return("", "", service.ErrUnknownProvider)
+
+		w := performRequest(setupOIDCRouter(mockOIDCService), "GET", "/api/v1/auth/oidc/other/start", "")
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("Provider Unavailable", func(t *testing.T) {
+		mockOIDCService := new(MockOIDCService)
+		mockOIDCService.On("StartOIDC", mock.Anything, "google").Return("", "", service.ErrProviderUnavailable)
+
+		w := performRequest(setupOIDCRouter(mockOIDCService), "GET", "/api/v1/auth/oidc/google/start", "")
+
+		assert.Equal(t, http.StatusBadGateway, w.Code)
+	})
+}
+
+func TestOIDCCallbackHandler(t *testing.T) {
+	t.Run("Issues Tokens", func(t *testing.T) {
+		mockOIDCService := new(MockOIDCService)
+		mockOIDCService.On("FinishOIDC", mock.Anything, "google", "the-code", "the-state", "state-token").
+			Return(&model.TokenPair{AccessToken: "access", RefreshToken: "refresh"}, nil)
+
+		w := callback(setupOIDCRouter(mockOIDCService), "code=the-code&state=the-state", "state-token")
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"access_token":"access"`)
+
+		// The state cookie is cleared.
+		cookies := w.Result().Cookies()
+		assert.Len(t, cookies, 1)
+		assert.Equal(t, "oidc_state", cookies[0].Name)
+		assert.Empty(t, cookies[0].Value)
+		assert.Negative(t, cookies[0].MaxAge)
+	})
+
+	t.Run("Missing State Cookie", func(t *testing.T) {
+		mockOIDCService := new(MockOIDCService)
+
+		w := callback(setupOIDCRouter(mockOIDCService), "code=the-code&state=the-state", "")
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		mockOIDCService.AssertNotCalled(t, "FinishOIDC", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Provider Error", func(t *testing.T) {
+		mockOIDCService := new(MockOIDCService)
+
+		w := callback(setupOIDCRouter(mockOIDCService), "error=access_denied&state=the-state", "state-token")
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		assert.Contains(t, w.Body.String(), "access_denied")
+		mockOIDCService.AssertNotCalled(t, "FinishOIDC", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	errorTests := []struct {
+		name       string
+		err        error
+		wantStatus int
+	}{
+		{"Invalid State", service.ErrInvalidOIDCState, http.StatusBadRequest},
+		{"Login Failed", service.ErrOIDCLoginFailed, http.StatusUnauthorized},
+		{"Email Not Verified By Provider", service.ErrOIDCEmailNotVerified, http.StatusForbidden},
+		{"Blocked By Verification Policy", service.ErrEmailNotVerified, http.StatusForbidden},
+		{"Unverified Account", service.ErrOIDCAccountNotVerified, http.StatusConflict},
+		{"Provider Unavailable", service.ErrProviderUnavailable, http.StatusBadGateway},
+	}
+
+	for _, tt := range errorTests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockOIDCService := new(MockOIDCService)
+			mockOIDCService.On("FinishOIDC", mock.Anything, "google", "the-code", "the-state", "state-token").Return(nil, tt.err)
+
+			w := callback(setupOIDCRouter(mockOIDCService), "code=the-code&state=the-state", "state-token")
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+		})
+	}
+}
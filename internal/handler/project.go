@@ -0,0 +1,249 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/ahmednurovic/task-manager-api/internal/model"
+	"github.com/ahmednurovic/task-manager-api/internal/service"
+)
+
+type ProjectService interface {
+	CreateProject(ctx *gin.Context, project *model.Project, position *int, userID int64) error
+	GetProjects(ctx *gin.Context, userID int64, includeArchived bool) (*model.ProjectList, error)
+	GetProject(ctx *gin.Context, projectID int64, userID int64) (*model.Project, error)
+	UpdateProject(ctx *gin.Context, project *model.Project, position *int, userID int64) error
+	DeleteProject(ctx *gin.Context, projectID int64, userID int64) error
+}
+
+type ProjectHandler struct {
+	service ProjectService
+}
+
+func NewProjectHandler(service ProjectService) *ProjectHandler {
+	return &ProjectHandler{service: service}
+}
+
+// ProjectRequest is the client-writable part of a project. Omitting the
+// position appends a new project at the end and keeps an existing
+// project's place.
+type ProjectRequest struct {
+	Name        string `json:"name" example:"Website relaunch"`
+	Description string `json:"description" example:"Everything for the **Q3** launch"`
+	Color       string `json:"color" example:"#1e88e5"`
+	Archived    bool   `json:"archived" example:"false"`
+	Position    *int   `json:"position" example:"0"`
+}
+
+func (r ProjectRequest) toProject() model.Project {
+	return model.Project{
+		Name:        r.Name,
+		Description: r.Description,
+		Color:       r.Color,
+		Archived:    r.Archived,
+	}
+}
+
+// CreateProject godoc
+// @Summary Create a project
+// @Description Create a project owned by the authenticated user
+// @Tags projects
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param project body ProjectRequest true "Project object"
+// @Success 201 {object} model.Project
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /projects [post]
+func (h *ProjectHandler) CreateProject(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	var req ProjectRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	project := req.toProject()
+	if err := h.service.CreateProject(c, &project, req.Position, userID); err != nil {
+		respondProjectError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, project)
+}
+
+// GetProjects godoc
+// @Summary Get the authenticated user's projects
+// @Description Get the authenticated user's projects in position order, with open and done task counts. Tasks without a project are counted in the inbox.
+// @Tags projects
+// @Produce json
+// @Security BearerAuth
+// @Param archived query bool false "Include archived projects"
+// @Success 200 {object} model.ProjectList
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /projects [get]
+func (h *ProjectHandler) GetProjects(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	includeArchived := false
+	if value := c.Query("archived"); value != "" {
+		var err error
+		if includeArchived, err = strconv.ParseBool(value); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid archived"})
+			return
+		}
+	}
+
+	projects, err := h.service.GetProjects(c, userID, includeArchived)
+	if err != nil {
+		respondProjectError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, projects)
+}
+
+// GetProject godoc
+// @Summary Get a project
+// @Description Get a project owned by the authenticated user, with open and done task counts
+// @Tags projects
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Project ID"
+// @Success 200 {object} model.Project
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /projects/{id} [get]
+func (h *ProjectHandler) GetProject(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	projectID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid project id"})
+		return
+	}
+
+	project, err := h.service.GetProject(c, projectID, userID)
+	if err != nil {
+		respondProjectError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, project)
+}
+
+// UpdateProject godoc
+// @Summary Update a project
+// @Description Update a project owned by the authenticated user
+// @Tags projects
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Project ID"
+// @Param project body ProjectRequest true "Updated project object"
+// @Success 200 {object} model.Project
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /projects/{id} [put]
+func (h *ProjectHandler) UpdateProject(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	projectID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid project id"})
+		return
+	}
+
+	var req ProjectRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	project := req.toProject()
+	project.ID = uint(projectID)
+	if err := h.service.UpdateProject(c, &project, req.Position, userID); err != nil {
+		respondProjectError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, project)
+}
+
+// DeleteProject godoc
+// @Summary Delete a project
+// @Description Delete a project owned by the authenticated user. Its tasks move to the inbox.
+// @Tags projects
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Project ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /projects/{id} [delete]
+func (h *ProjectHandler) DeleteProject(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	projectID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid project id"})
+		return
+	}
+
+	if err := h.service.DeleteProject(c, projectID, userID); err != nil {
+		respondProjectError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Project deleted successfully"})
+}
+
+func respondProjectError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrProjectNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrProjectNameRequired),
+		errors.Is(err, service.ErrProjectNameTooLong),
+		errors.Is(err, service.ErrInvalidProjectColor),
+		errors.Is(err, service.ErrInvalidPosition):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrInsufficientRole):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
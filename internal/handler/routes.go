@@ -0,0 +1,161 @@
+package handler
+
+import (
+	"github.com/ahmednurovic/task-manager-api/internal/authz"
+	"github.com/ahmednurovic/task-manager-api/internal/middleware"
+	"github.com/ahmednurovic/task-manager-api/internal/service"
+	"github.com/ahmednurovic/task-manager-api/internal/signing"
+	"github.com/gin-gonic/gin"
+)
+
+// Routes is what the API's routes are served by.
+type Routes struct {
+	// Authenticate is middleware.AuthMiddleware. It resolves the user and
+	// the space a request acts in, which every later check relies on.
+	Authenticate gin.HandlerFunc
+	Authorizer   *authz.Authorizer
+
+	Auth service.AuthServicer
+	OIDC service.OIDCServicer
+	Keys *signing.KeySet
+
+	Tasks        *TaskHandler
+	Labels       *LabelHandler
+	Projects     *ProjectHandler
+	Checklists   *ChecklistHandler
+	Watchers     *WatcherHandler
+	Comments     *CommentHandler
+	Attachments  *AttachmentHandler
+	AccessTokens *PersonalAccessTokenHandler
+	Workspaces   *WorkspaceHandler
+}
+
+// Register adds the JWKS endpoint and every route under /api/v1 to router,
+// each behind the checks it needs.
+func (r Routes) Register(router gin.IRouter) {
+	requireWritable := middleware.RequireWritable()
+	requireSession := middleware.RequireSession()
+
+	router.GET("/.well-known/jwks.json", JWKS(r.Keys))
+	api := router.Group("/api/v1")
+	{
+		auth := api.Group("/auth")
+		{
+			auth.POST("/register", Register(r.Auth))
+			auth.POST("/login", Login(r.Auth))
+			auth.POST("/refresh", Refresh(r.Auth))
+			auth.POST("/logout", r.Authenticate, Logout(r.Auth))
+			auth.POST("/logout-all", r.Authenticate, requireSession, LogoutAll(r.Auth))
+			auth.POST("/password/forgot", ForgotPassword(r.Auth))
+			auth.POST("/password/reset", ResetPassword(r.Auth))
+			auth.POST("/verify", VerifyEmail(r.Auth))
+			auth.POST("/verify/resend", ResendVerification(r.Auth))
+			auth.GET("/oidc/:provider/start", StartOIDC(r.OIDC))
+			auth.GET("/oidc/:provider/callback", OIDCCallback(r.OIDC))
+			auth.POST("/mfa/verify", VerifyMFA(r.Auth))
+			auth.POST("/mfa/enroll", r.Authenticate, requireSession, EnrollMFA(r.Auth))
+			auth.POST("/mfa/confirm", r.Authenticate, requireSession, ConfirmMFA(r.Auth))
+			auth.POST("/mfa/disable", r.Authenticate, requireSession, DisableMFA(r.Auth))
+		}
+
+		tasks := api.Group("/tasks").Use(r.Authenticate, requireWritable, middleware.RequireScope("tasks"), middleware.Authorize(r.Authorizer, "tasks"))
+		{
+			tasks.POST("", r.Tasks.CreateTask)
+			tasks.GET("", r.Tasks.GetTasks)
+			tasks.GET("/search", r.Tasks.SearchTasks)
+			tasks.GET("/:id", r.Tasks.GetTask)
+			tasks.PUT("/:id", r.Tasks.UpdateTask)
+			tasks.DELETE("/:id", r.Tasks.DeleteTask)
+			tasks.GET("/:id/transitions", r.Tasks.GetTransitions)
+			tasks.PUT("/:id/labels/:label_id", r.Labels.AttachLabel)
+			tasks.DELETE("/:id/labels/:label_id", r.Labels.DetachLabel)
+			tasks.GET("/:id/checklist", r.Checklists.GetChecklist)
+			tasks.POST("/:id/checklist", r.Checklists.CreateChecklistItem)
+			tasks.PUT("/:id/checklist/:item_id", r.Checklists.UpdateChecklistItem)
+			tasks.DELETE("/:id/checklist/:item_id", r.Checklists.DeleteChecklistItem)
+			tasks.GET("/:id/dependencies", r.Tasks.GetDependencies)
+			tasks.PUT("/:id/dependencies/:blocker_id", r.Tasks.AddDependency)
+			tasks.DELETE("/:id/dependencies/:blocker_id", r.Tasks.RemoveDependency)
+			tasks.GET("/:id/graph", r.Tasks.GetDependencyGraph)
+			tasks.PUT("/:id/assignee", r.Tasks.AssignTask)
+			tasks.DELETE("/:id/assignee", r.Tasks.UnassignTask)
+			tasks.GET("/:id/activity", r.Tasks.GetActivity)
+			tasks.GET("/:id/watchers", r.Watchers.GetWatchers)
+			tasks.GET("/:id/comments", r.Comments.GetComments)
+			tasks.POST("/:id/comments", r.Comments.CreateComment)
+			tasks.PUT("/:id/comments/:comment_id", r.Comments.UpdateComment)
+			tasks.DELETE("/:id/comments/:comment_id", r.Comments.DeleteComment)
+			tasks.GET("/:id/comments/:comment_id/history", r.Comments.GetCommentHistory)
+			tasks.GET("/:id/attachments", r.Attachments.GetAttachments)
+			tasks.POST("/:id/attachments", r.Attachments.UploadAttachment)
+			tasks.DELETE("/:id/attachments/:attachment_id", r.Attachments.DeleteAttachment)
+			tasks.GET("/:id/attachments/:attachment_id/url", r.Attachments.GetAttachmentURL)
+		}
+
+		// Download links carry their own signature, so they work without
+		// a token, e.g. in an <img> tag or a browser tab.
+		api.GET("/attachments/:id/content", r.Attachments.DownloadAttachment)
+
+		// Watching only concerns the user, so every workspace role may do it.
+		watching := api.Group("/tasks").Use(r.Authenticate, requireWritable, middleware.RequireScope("tasks"),
+			middleware.AuthorizeAction(r.Authorizer, authz.WatchTasks))
+		{
+			watching.PUT("/:id/watch", r.Watchers.WatchTask)
+			watching.DELETE("/:id/watch", r.Watchers.UnwatchTask)
+		}
+
+		// Labels are always the user's own, whatever space they act in.
+		labels := api.Group("/labels").Use(r.Authenticate, requireWritable, middleware.RequireScope("labels"))
+		{
+			labels.POST("", r.Labels.CreateLabel)
+			labels.GET("", r.Labels.GetLabels)
+			labels.PUT("/:id", r.Labels.UpdateLabel)
+			labels.DELETE("/:id", r.Labels.DeleteLabel)
+		}
+
+		projects := api.Group("/projects").Use(r.Authenticate, requireWritable, middleware.RequireScope("projects"), middleware.Authorize(r.Authorizer, "projects"))
+		{
+			projects.POST("", r.Projects.CreateProject)
+			projects.GET("", r.Projects.GetProjects)
+			projects.GET("/:id", r.Projects.GetProject)
+			projects.PUT("/:id", r.Projects.UpdateProject)
+			projects.DELETE("/:id", r.Projects.DeleteProject)
+			// Listing a project's tasks reads tasks, so it needs their
+			// scope as well.
+			projects.GET("/:id/tasks", middleware.RequireScope("tasks"), r.Tasks.GetProjectTasks)
+		}
+
+		// The workspace a route names need not be the one the request acts
+		// in, so WorkspaceService checks the user's role in it.
+		workspaces := api.Group("/workspaces").Use(r.Authenticate, requireSession, requireWritable)
+		{
+			workspaces.POST("", r.Workspaces.CreateWorkspace)
+			workspaces.GET("", r.Workspaces.GetWorkspaces)
+			workspaces.GET("/:id", r.Workspaces.GetWorkspace)
+			workspaces.PUT("/:id", r.Workspaces.UpdateWorkspace)
+			workspaces.DELETE("/:id", r.Workspaces.DeleteWorkspace)
+			workspaces.GET("/:id/members", r.Workspaces.GetMembers)
+			workspaces.PUT("/:id/members/:user_id", r.Workspaces.UpdateMemberRole)
+			workspaces.DELETE("/:id/members/:user_id", r.Workspaces.RemoveMember)
+			workspaces.POST("/:id/invitations", r.Workspaces.InviteMember)
+		}
+
+		invitations := api.Group("/invitations")
+		{
+			invitations.POST("/accept", r.Authenticate, requireSession, requireWritable, r.Workspaces.AcceptInvitation)
+			invitations.POST("/decline", r.Workspaces.DeclineInvitation)
+		}
+
+		me := api.Group("/me").Use(r.Authenticate, requireSession, requireWritable)
+		{
+			me.POST("/tokens", r.AccessTokens.CreateToken)
+			me.GET("/tokens", r.AccessTokens.GetTokens)
+			me.DELETE("/tokens/:id", r.AccessTokens.RevokeToken)
+		}
+
+		admin := api.Group("/admin").Use(r.Authenticate, requireSession, middleware.RequireAdmin(r.Auth))
+		{
+			admin.POST("/accounts/unlock", UnlockAccount(r.Auth))
+		}
+	}
+}
@@ -0,0 +1,294 @@
+package handler_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+
+	"github.com/ahmednurovic/task-manager-api/internal/authz"
+	"github.com/ahmednurovic/task-manager-api/internal/handler"
+	"github.com/ahmednurovic/task-manager-api/internal/model"
+	"github.com/ahmednurovic/task-manager-api/internal/signing"
+)
+
+// Access to a route, besides the workspace roles.
+const (
+	// public routes need no token.
+	public model.WorkspaceRole = "public"
+	// signedIn routes let every signed-in user through; the services check
+	// what they may do.
+	signedIn model.WorkspaceRole = "signed in"
+	// siteAdmin routes are only for administrators.
+	siteAdmin model.WorkspaceRole = "site admin"
+)
+
+// routeAccess is who may reach each route registered by handler.Routes.
+// For workspace roles it is the lowest role that may, in the workspace the
+// request acts in.
+var routeAccess = map[string]model.WorkspaceRole{
+	"GET /.well-known/jwks.json":                           public,
+	"POST /api/v1/auth/register":                           public,
+	"POST /api/v1/auth/login":                              public,
+	"POST /api/v1/auth/refresh":                            public,
+	"POST /api/v1/auth/logout":                             signedIn,
+	"POST /api/v1/auth/logout-all":                         signedIn,
+	"POST /api/v1/auth/password/forgot":                    public,
+	"POST /api/v1/auth/password/reset":                     public,
+	"POST /api/v1/auth/verify":                             public,
+	"POST /api/v1/auth/verify/resend":                      public,
+	"GET /api/v1/auth/oidc/:provider/start":                public,
+	"GET /api/v1/auth/oidc/:provider/callback":             public,
+	"POST /api/v1/auth/mfa/verify":                         public,
+	"POST /api/v1/auth/mfa/enroll":                         signedIn,
+	"POST /api/v1/auth/mfa/confirm":                        signedIn,
+	"POST /api/v1/auth/mfa/disable":                        signedIn,
+	"POST /api/v1/tasks":                                   model.RoleMember,
+	"GET /api/v1/tasks":                                    model.RoleViewer,
+	"GET /api/v1/tasks/search":                             model.RoleViewer,
+	"GET /api/v1/tasks/:id":                                model.RoleViewer,
+	"PUT /api/v1/tasks/:id":                                model.RoleMember,
+	"DELETE /api/v1/tasks/:id":                             model.RoleMember,
+	"GET /api/v1/tasks/:id/transitions":                    model.RoleViewer,
+	"PUT /api/v1/tasks/:id/labels/:label_id":               model.RoleMember,
+	"DELETE /api/v1/tasks/:id/labels/:label_id":            model.RoleMember,
+	"GET /api/v1/tasks/:id/checklist":                      model.RoleViewer,
+	"POST /api/v1/tasks/:id/checklist":                     model.RoleMember,
+	"PUT /api/v1/tasks/:id/checklist/:item_id":             model.RoleMember,
+	"DELETE /api/v1/tasks/:id/checklist/:item_id":          model.RoleMember,
+	"GET /api/v1/tasks/:id/dependencies":                   model.RoleViewer,
+	"PUT /api/v1/tasks/:id/dependencies/:blocker_id":       model.RoleMember,
+	"DELETE /api/v1/tasks/:id/dependencies/:blocker_id":    model.RoleMember,
+	"GET /api/v1/tasks/:id/graph":                          model.RoleViewer,
+	"PUT /api/v1/tasks/:id/assignee":                       model.RoleMember,
+	"DELETE /api/v1/tasks/:id/assignee":                    model.RoleMember,
+	"GET /api/v1/tasks/:id/activity":                       model.RoleViewer,
+	"GET /api/v1/tasks/:id/watchers":                       model.RoleViewer,
+	"PUT /api/v1/tasks/:id/watch":                          model.RoleViewer,
+	"DELETE /api/v1/tasks/:id/watch":                       model.RoleViewer,
+	"GET /api/v1/tasks/:id/comments":                       model.RoleViewer,
+	"POST /api/v1/tasks/:id/comments":                      model.RoleMember,
+	"PUT /api/v1/tasks/:id/comments/:comment_id":           model.RoleMember,
+	"DELETE /api/v1/tasks/:id/comments/:comment_id":        model.RoleMember,
+	"GET /api/v1/tasks/:id/comments/:comment_id/history":   model.RoleViewer,
+	"GET /api/v1/tasks/:id/attachments":                    model.RoleViewer,
+	"POST /api/v1/tasks/:id/attachments":                   model.RoleMember,
+	"DELETE /api/v1/tasks/:id/attachments/:attachment_id":  model.RoleMember,
+	"GET /api/v1/tasks/:id/attachments/:attachment_id/url": model.RoleViewer,
+	"GET /api/v1/attachments/:id/content":                  public,
+	"POST /api/v1/labels":                                  signedIn,
+	"GET /api/v1/labels":                                   signedIn,
+	"PUT /api/v1/labels/:id":                               signedIn,
+	"DELETE /api/v1/labels/:id":                            signedIn,
+	"POST /api/v1/projects":                                model.RoleAdmin,
+	"GET /api/v1/projects":                                 model.RoleViewer,
+	"GET /api/v1/projects/:id":                             model.RoleViewer,
+	"PUT /api/v1/projects/:id":                             model.RoleAdmin,
+	"DELETE /api/v1/projects/:id":                          model.RoleAdmin,
+	"GET /api/v1/projects/:id/tasks":                       model.RoleViewer,
+	// WorkspaceService checks the role in the workspace the path names;
+	// see TestWorkspaceServiceRouteRoles.
+	"POST /api/v1/workspaces":                        signedIn,
+	"GET /api/v1/workspaces":                         signedIn,
+	"GET /api/v1/workspaces/:id":                     signedIn,
+	"PUT /api/v1/workspaces/:id":                     signedIn,
+	"DELETE /api/v1/workspaces/:id":                  signedIn,
+	"GET /api/v1/workspaces/:id/members":             signedIn,
+	"PUT /api/v1/workspaces/:id/members/:user_id":    signedIn,
+	"DELETE /api/v1/workspaces/:id/members/:user_id": signedIn,
+	"POST /api/v1/workspaces/:id/invitations":        signedIn,
+	"POST /api/v1/invitations/accept":                signedIn,
+	"POST /api/v1/invitations/decline":               public,
+	"POST /api/v1/me/tokens":                         signedIn,
+	"GET /api/v1/me/tokens":                          signedIn,
+	"DELETE /api/v1/me/tokens/:id":                   signedIn,
+	"POST /api/v1/admin/accounts/unlock":             siteAdmin,
+}
+
+// principal is who a request in TestAuthorizeRoutes is signed in as.
+type principal struct {
+	name string
+	// role is the role in workspace 3, or empty for the personal space.
+	role  model.WorkspaceRole
+	admin bool
+}
+
+func (p principal) mayReach(access model.WorkspaceRole) bool {
+	switch access {
+	case public, signedIn:
+		return true
+	case siteAdmin:
+		return p.admin
+	}
+	return p.role == "" || p.role.AtLeast(access)
+}
+
+// refusalWriter discards everything written to it, noting whether the
+// first status was 403.
+type refusalWriter struct {
+	gin.ResponseWriter
+	wrote   bool
+	refused bool
+}
+
+func (w *refusalWriter) WriteHeader(code int) {
+	if !w.wrote {
+		w.wrote = true
+		w.refused = code == http.StatusForbidden
+	}
+}
+
+func (w *refusalWriter) WriteHeaderNow() {}
+
+func (w *refusalWriter) Write(data []byte) (int, error) {
+	w.WriteHeader(http.StatusOK)
+	return len(data), nil
+}
+
+func (w *refusalWriter) WriteString(s string) (int, error) {
+	w.WriteHeader(http.StatusOK)
+	return len(s), nil
+}
+
+// reachedOrRefused answers 403 if the checks in front of a handler refused
+// the request and 200 otherwise. The handlers have no services behind them,
+// so whatever they do once reached, panicking included, is discarded.
+func reachedOrRefused(c *gin.Context) {
+	writer := &refusalWriter{ResponseWriter: c.Writer}
+	c.Writer = writer
+	defer func() {
+		_ = recover()
+		c.Writer = writer.ResponseWriter
+		if writer.refused {
+			c.Status(http.StatusForbidden)
+		} else {
+			c.Status(http.StatusOK)
+		}
+		c.Writer.WriteHeaderNow()
+	}()
+
+	c.Next()
+}
+
+// TestAuthorizeRoutes walks every route the API registers and checks who
+// the middleware in front of it lets through: the personal space, each
+// workspace role and an administrator.
+func TestAuthorizeRoutes(t *testing.T) {
+	principals := []principal{
+		{name: "personal"},
+		{name: "viewer", role: model.RoleViewer},
+		{name: "member", role: model.RoleMember},
+		{name: "admin", role: model.RoleAdmin},
+		{name: "owner", role: model.RoleOwner},
+		{name: "site admin", admin: true},
+	}
+
+	gin.SetMode(gin.TestMode)
+	for _, p := range principals {
+		admins := new(MockAuthService)
+		admins.On("IsAdmin", mock.Anything, uint(2)).Return(p.admin, nil)
+
+		router := gin.New()
+		router.Use(reachedOrRefused)
+		handler.Routes{
+			Authenticate: func(c *gin.Context) {
+				c.Set("userID", uint(2))
+				if p.role != "" {
+					c.Set("workspaceID", uint(3))
+					c.Set("workspaceRole", p.role)
+				}
+				c.Next()
+			},
+			Authorizer:   authz.New(authz.DefaultPolicy, zap.NewNop()),
+			Auth:         admins,
+			Keys:         signing.NewHMAC("test-secret"),
+			Tasks:        handler.NewTaskHandler(nil),
+			Labels:       handler.NewLabelHandler(nil),
+			Projects:     handler.NewProjectHandler(nil),
+			Checklists:   handler.NewChecklistHandler(nil),
+			Watchers:     handler.NewWatcherHandler(nil),
+			Comments:     handler.NewCommentHandler(nil),
+			Attachments:  handler.NewAttachmentHandler(nil, 1024),
+			AccessTokens: handler.NewPersonalAccessTokenHandler(nil),
+			Workspaces:   handler.NewWorkspaceHandler(nil),
+		}.Register(router)
+
+		registered := map[string]bool{}
+		for _, route := range router.Routes() {
+			key := route.Method + " " + route.Path
+			registered[key] = true
+			access, ok := routeAccess[key]
+			if !ok {
+				t.Errorf("%s is not in routeAccess; add who may reach it", key)
+				continue
+			}
+
+			t.Run(key+" As "+p.name, func(t *testing.T) {
+				w := httptest.NewRecorder()
+				router.ServeHTTP(w, httptest.NewRequest(route.Method, route.Path, nil))
+
+				want := http.StatusForbidden
+				if p.mayReach(access) {
+					want = http.StatusOK
+				}
+				assert.Equal(t, want, w.Code)
+			})
+		}
+
+		for key := range routeAccess {
+			if !registered[key] {
+				t.Errorf("%s is in routeAccess but not registered", key)
+			}
+		}
+	}
+}
+
+// TestProjectTasksScope checks that listing a project's tasks with a
+// personal access token needs a tasks scope, not only a projects one.
+func TestProjectTasksScope(t *testing.T) {
+	tests := []struct {
+		name   string
+		scopes []string
+		want   int
+	}{
+		{"Projects Only", []string{"projects:read"}, http.StatusForbidden},
+		{"Tasks Only", []string{"tasks:read"}, http.StatusForbidden},
+		{"Projects And Tasks", []string{"projects:read", "tasks:read"}, http.StatusOK},
+	}
+
+	gin.SetMode(gin.TestMode)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+			router.Use(reachedOrRefused)
+			handler.Routes{
+				Authenticate: func(c *gin.Context) {
+					c.Set("userID", uint(2))
+					c.Set("scopes", tt.scopes)
+					c.Next()
+				},
+				Authorizer:   authz.New(authz.DefaultPolicy, zap.NewNop()),
+				Auth:         new(MockAuthService),
+				Keys:         signing.NewHMAC("test-secret"),
+				Tasks:        handler.NewTaskHandler(nil),
+				Labels:       handler.NewLabelHandler(nil),
+				Projects:     handler.NewProjectHandler(nil),
+				Checklists:   handler.NewChecklistHandler(nil),
+				Watchers:     handler.NewWatcherHandler(nil),
+				Comments:     handler.NewCommentHandler(nil),
+				Attachments:  handler.NewAttachmentHandler(nil, 1024),
+				AccessTokens: handler.NewPersonalAccessTokenHandler(nil),
+				Workspaces:   handler.NewWorkspaceHandler(nil),
+			}.Register(router)
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/projects/inbox/tasks", nil))
+
+			assert.Equal(t, tt.want, w.Code)
+		})
+	}
+}
@@ -1,19 +1,33 @@
 package handler
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 
 	"github.com/ahmednurovic/task-manager-api/internal/model"
+	"github.com/ahmednurovic/task-manager-api/internal/service"
 )
 
 type TaskService interface {
-	CreateTask(ctx *gin.Context, task *model.Task) error
-	GetTasks(ctx *gin.Context, userID int64) ([]*model.Task, error)
-	UpdateTask(ctx *gin.Context, task *model.Task) error
+	CreateTask(ctx *gin.Context, task *model.Task, userID int64) error
+	GetTasks(ctx *gin.Context, userID int64, filter model.TaskFilter) (*model.TaskPage, error)
+	GetTask(ctx *gin.Context, taskID int64, userID int64) (*model.Task, error)
+	SearchTasks(ctx *gin.Context, userID int64, query string, limit int) (*model.TaskSearchResults, error)
+	UpdateTask(ctx *gin.Context, task *model.Task, userID int64) error
 	DeleteTask(ctx *gin.Context, taskID int64, userID int64) error
+	GetTransitions(ctx *gin.Context, taskID int64, userID int64) (*model.TaskTransitions, error)
+	GetDependencies(ctx *gin.Context, taskID int64, userID int64) (*model.TaskDependencies, error)
+	AddDependency(ctx *gin.Context, taskID int64, blockerID int64, userID int64) error
+	RemoveDependency(ctx *gin.Context, taskID int64, blockerID int64, userID int64) error
+	GetDependencyGraph(ctx *gin.Context, taskID int64, userID int64) (*model.TaskGraph, error)
+	AssignTask(ctx *gin.Context, taskID int64, assigneeID int64, userID int64) error
+	UnassignTask(ctx *gin.Context, taskID int64, userID int64) error
+	GetActivity(ctx *gin.Context, taskID int64, userID int64) ([]*model.TaskActivity, error)
 }
 
 type TaskHandler struct {
@@ -24,27 +38,70 @@ func NewTaskHandler(service TaskService) *TaskHandler {
 	return &TaskHandler{service: service}
 }
 
+// TaskRequest is the client-writable part of a task. The owner is always
+// taken from the authenticated user.
+type TaskRequest struct {
+	ProjectID   *uint              `json:"project_id" example:"3"`
+	ParentID    *uint              `json:"parent_id" example:"12"`
+	Title       string             `json:"title" example:"Write report"`
+	Description string             `json:"description" example:"Summarise **Q3** results"`
+	Status      model.TaskStatus   `json:"status" example:"pending" enums:"pending,in_progress,blocked,done,cancelled"`
+	Priority    model.TaskPriority `json:"priority" example:"medium" enums:"low,medium,high,urgent"`
+	DueAt       *time.Time         `json:"due_at" example:"2025-01-31T17:00:00Z"`
+	// RecurrenceRule is an RFC 5545 RRULE supporting FREQ, INTERVAL (up to
+	// 1000), BYDAY, BYMONTHDAY, COUNT and UNTIL. Recurring tasks need a due
+	// date, and series end after the year 9999.
+	RecurrenceRule string               `json:"recurrence_rule" example:"FREQ=WEEKLY;BYDAY=MO"`
+	RecurrenceTZ   string               `json:"recurrence_tz" example:"Europe/Berlin"`
+	RecurrenceMode model.RecurrenceMode `json:"recurrence_mode" example:"schedule" enums:"schedule,completion"`
+}
+
+func (r TaskRequest) toTask() model.Task {
+	return model.Task{
+		ProjectID:   r.ProjectID,
+		ParentID:    r.ParentID,
+		Title:       r.Title,
+		Description: r.Description,
+		Status:      r.Status,
+		Priority:    r.Priority,
+		DueAt:       r.DueAt,
+
+		RecurrenceRule: r.RecurrenceRule,
+		RecurrenceTZ:   r.RecurrenceTZ,
+		RecurrenceMode: r.RecurrenceMode,
+	}
+}
+
 // CreateTask godoc
 // @Summary Create a new task
-// @Description Create a new task for the given user
+// @Description Create a new task owned by the authenticated user. A task with a recurrence rule repeats from its due date, in the given time zone (default UTC).
 // @Tags tasks
 // @Accept json
 // @Produce json
-// @Param task body model.Task true "Task object"
+// @Security BearerAuth
+// @Param task body TaskRequest true "Task object"
 // @Success 201 {object} model.Task
-// @Failure 400 {object} map[string]interface{}
-// @Failure 500 {object} map[string]interface{}
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
 // @Router /tasks [post]
 func (h *TaskHandler) CreateTask(c *gin.Context) {
-	var task model.Task
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
 
-	if err := c.ShouldBindJSON(&task); err != nil {
+	var req TaskRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	if err := h.service.CreateTask(c, &task); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	task := req.toTask()
+
+	if err := h.service.CreateTask(c, &task, userID); err != nil {
+		respondTaskError(c, err)
 		return
 	}
 
@@ -52,93 +109,425 @@ func (h *TaskHandler) CreateTask(c *gin.Context) {
 }
 
 // GetTasks godoc
-// @Summary Get tasks by user ID
-// @Description Get a list of tasks for the given user
+// @Summary Get the authenticated user's tasks
+// @Description Get a page of tasks owned by the authenticated user. Pass next_cursor from the previous page as cursor, with the same sort and order, to get the next page.
 // @Tags tasks
 // @Accept json
 // @Produce json
-// @Param user_id query int true "User ID"
-// @Success 200 {array} model.Task
-// @Failure 400 {object} map[string]interface{}
-// @Failure 500 {object} map[string]interface{}
+// @Security BearerAuth
+// @Param project_id query string false "Project ID, or inbox for tasks without a project"
+// @Param parent_id query int false "Only direct subtasks of this task"
+// @Param assigned_to_me query bool false "Only tasks assigned to the authenticated user"
+// @Param created_by_me query bool false "Only tasks created by the authenticated user"
+// @Param status query []string false "Statuses to include" collectionFormat(csv)
+// @Param priority query []string false "Priorities to include" collectionFormat(csv)
+// @Param due_after query string false "Only tasks due at or after this time (RFC 3339)"
+// @Param due_before query string false "Only tasks due before this time (RFC 3339)"
+// @Param title query string false "Case-insensitive title substring"
+// @Param labels query []int false "IDs of the user's own labels to filter by" collectionFormat(csv)
+// @Param label_match query string false "Whether tasks need any or all of the labels (default any)" Enums(any, all)
+// @Param sort query string false "Sort field" Enums(created_at, updated_at, due_at, priority, title)
+// @Param order query string false "Sort order" Enums(asc, desc)
+// @Param cursor query string false "Opaque cursor from a previous page"
+// @Param limit query int false "Page size (1-200, default 50)"
+// @Success 200 {object} model.TaskPage
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
 // @Router /tasks [get]
 func (h *TaskHandler) GetTasks(c *gin.Context) {
-	userIDStr := c.Query("user_id")
-	userID, err := strconv.ParseInt(userIDStr, 10, 64)
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	filter, err := parseTaskFilter(c)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user_id"})
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	tasks, err := h.service.GetTasks(c, userID)
+	page, err := h.service.GetTasks(c, userID, filter)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondTaskError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, page)
+}
+
+// GetProjectTasks godoc
+// @Summary Get the tasks in a project
+// @Description Get a page of tasks in one of the authenticated user's projects, or in the inbox of tasks without a project. Accepts the same filter, sort and pagination parameters as GET /tasks. Personal access tokens need both the projects:read and tasks:read scopes.
+// @Tags projects
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Project ID, or inbox"
+// @Param cursor query string false "Opaque cursor from a previous page"
+// @Param limit query int false "Page size (1-200, default 50)"
+// @Success 200 {object} model.TaskPage
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /projects/{id}/tasks [get]
+func (h *TaskHandler) GetProjectTasks(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	filter, err := parseTaskFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	filter.ProjectID, filter.InboxOnly, err = parseProjectRef(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid project id"})
+		return
+	}
+
+	page, err := h.service.GetTasks(c, userID, filter)
+	if err != nil {
+		respondTaskError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, page)
+}
+
+// parseTaskFilter reads the GET /tasks query string. List parameters may be
+// repeated or comma separated.
+func parseTaskFilter(c *gin.Context) (model.TaskFilter, error) {
+	filter := model.TaskFilter{
+		Title:      c.Query("title"),
+		LabelMatch: model.LabelMatch(c.Query("label_match")),
+		Sort:       model.TaskSortField(c.Query("sort")),
+		Order:      model.SortOrder(c.Query("order")),
+		Cursor:     c.Query("cursor"),
+	}
+
+	if value := c.Query("project_id"); value != "" {
+		var err error
+		filter.ProjectID, filter.InboxOnly, err = parseProjectRef(value)
+		if err != nil {
+			return filter, errors.New("invalid project_id")
+		}
+	}
+
+	if value := c.Query("parent_id"); value != "" {
+		parentID, err := strconv.ParseUint(value, 10, 32)
+		if err != nil || parentID == 0 {
+			return filter, errors.New("invalid parent_id")
+		}
+		id := uint(parentID)
+		filter.ParentID = &id
+	}
+
+	if value := c.Query("assigned_to_me"); value != "" {
+		assignedToMe, err := strconv.ParseBool(value)
+		if err != nil {
+			return filter, errors.New("invalid assigned_to_me")
+		}
+		filter.AssignedToMe = assignedToMe
+	}
+	if value := c.Query("created_by_me"); value != "" {
+		createdByMe, err := strconv.ParseBool(value)
+		if err != nil {
+			return filter, errors.New("invalid created_by_me")
+		}
+		filter.CreatedByMe = createdByMe
+	}
+
+	for _, status := range queryList(c, "status") {
+		filter.Statuses = append(filter.Statuses, model.TaskStatus(status))
+	}
+	for _, priority := range queryList(c, "priority") {
+		filter.Priorities = append(filter.Priorities, model.TaskPriority(priority))
+	}
+	for _, value := range queryList(c, "labels") {
+		labelID, err := strconv.ParseUint(value, 10, 32)
+		if err != nil || labelID == 0 {
+			return filter, errors.New("invalid labels")
+		}
+		filter.LabelIDs = append(filter.LabelIDs, uint(labelID))
+	}
+
+	if value := c.Query("due_after"); value != "" {
+		dueAfter, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return filter, errors.New("invalid due_after")
+		}
+		filter.DueAfter = &dueAfter
+	}
+	if value := c.Query("due_before"); value != "" {
+		dueBefore, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return filter, errors.New("invalid due_before")
+		}
+		filter.DueBefore = &dueBefore
+	}
+
+	if value := c.Query("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil {
+			return filter, errors.New("invalid limit")
+		}
+		filter.Limit = limit
+	}
+
+	return filter, nil
+}
+
+// parseProjectRef parses a project ID, or "inbox" for tasks without a
+// project.
+func parseProjectRef(value string) (*uint, bool, error) {
+	if value == "inbox" {
+		return nil, true, nil
+	}
+
+	projectID, err := strconv.ParseUint(value, 10, 32)
+	if err != nil || projectID == 0 {
+		return nil, false, errors.New("invalid project id")
+	}
+
+	id := uint(projectID)
+	return &id, false, nil
+}
+
+func queryList(c *gin.Context, key string) []string {
+	var values []string
+	for _, param := range c.QueryArray(key) {
+		for _, value := range strings.Split(param, ",") {
+			if value = strings.TrimSpace(value); value != "" {
+				values = append(values, value)
+			}
+		}
+	}
+	return values
+}
+
+// GetTask godoc
+// @Summary Get a task
+// @Description Get a task owned by the authenticated user, with the progress of its direct subtasks and checklist
+// @Tags tasks
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Task ID"
+// @Success 200 {object} model.Task
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /tasks/{id} [get]
+func (h *TaskHandler) GetTask(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	taskID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid task id"})
+		return
+	}
+
+	task, err := h.service.GetTask(c, taskID, userID)
+	if err != nil {
+		respondTaskError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, tasks)
+	c.JSON(http.StatusOK, task)
+}
+
+// SearchTasks godoc
+// @Summary Search the authenticated user's tasks
+// @Description Full-text search over task titles and descriptions, best matches first. Words match as prefixes; "quoted phrases", OR and -excluded terms are supported.
+// @Tags tasks
+// @Produce json
+// @Security BearerAuth
+// @Param q query string true "Search query"
+// @Param limit query int false "Maximum number of results (1-100, default 20)"
+// @Success 200 {object} model.TaskSearchResults
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /tasks/search [get]
+func (h *TaskHandler) SearchTasks(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	var limit int
+	if value := c.Query("limit"); value != "" {
+		var err error
+		if limit, err = strconv.Atoi(value); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid limit"})
+			return
+		}
+	}
+
+	results, err := h.service.SearchTasks(c, userID, c.Query("q"), limit)
+	if err != nil {
+		respondTaskError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, results)
 }
 
 // UpdateTask godoc
 // @Summary Update a task
-// @Description Update an existing task
+// @Description Update an existing task owned by the authenticated user. A task cannot move to in_progress or done while any task blocking it is open. Cancelling a task also cancels its open subtasks. Completing a recurring task creates its next occurrence, due by the original schedule or counted from the completion date depending on recurrence_mode.
 // @Tags tasks
 // @Accept json
 // @Produce json
+// @Security BearerAuth
 // @Param id path int true "Task ID"
-// @Param task body model.Task true "Updated task object"
-// @Success 200 {object} map[string]interface{}
-// @Failure 400 {object} map[string]interface{}
-// @Failure 500 {object} map[string]interface{}
+// @Param task body TaskRequest true "Updated task object"
+// @Success 200 {object} model.Task
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
 // @Router /tasks/{id} [put]
 func (h *TaskHandler) UpdateTask(c *gin.Context) {
-	var task model.Task
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
 
-	if err := c.ShouldBindJSON(&task); err != nil {
+	taskID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid task id"})
+		return
+	}
+
+	var req TaskRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	if err := h.service.UpdateTask(c, &task); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	task := req.toTask()
+	task.ID = uint(taskID)
+
+	if err := h.service.UpdateTask(c, &task, userID); err != nil {
+		respondTaskError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Task updated successfully"})
+	c.JSON(http.StatusOK, task)
 }
 
 // DeleteTask godoc
 // @Summary Delete a task
-// @Description Delete a task by its ID
+// @Description Delete a task owned by the authenticated user, together with its subtasks
 // @Tags tasks
 // @Accept json
 // @Produce json
+// @Security BearerAuth
 // @Param id path int true "Task ID"
-// @Param user_id query int true "User ID"
 // @Success 200 {object} map[string]interface{}
-// @Failure 400 {object} map[string]interface{}
-// @Failure 500 {object} map[string]interface{}
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
 // @Router /tasks/{id} [delete]
 func (h *TaskHandler) DeleteTask(c *gin.Context) {
-	taskIDStr := c.Param("id")
-	userIDStr := c.Query("user_id")
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
 
-	taskID, err := strconv.ParseInt(taskIDStr, 10, 64)
+	taskID, err := strconv.ParseInt(c.Param("id"), 10, 64)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid task id"})
 		return
 	}
 
-	userID, err := strconv.ParseInt(userIDStr, 10, 64)
+	if err := h.service.DeleteTask(c, taskID, userID); err != nil {
+		respondTaskError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Task deleted successfully"})
+}
+
+// GetTransitions godoc
+// @Summary List allowed status transitions
+// @Description List the statuses a task owned by the authenticated user can move to from its current status
+// @Tags tasks
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Task ID"
+// @Success 200 {object} model.TaskTransitions
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /tasks/{id}/transitions [get]
+func (h *TaskHandler) GetTransitions(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	taskID, err := strconv.ParseInt(c.Param("id"), 10, 64)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid task id"})
 		return
 	}
 
-	if err := h.service.DeleteTask(c, taskID, userID); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	transitions, err := h.service.GetTransitions(c, taskID, userID)
+	if err != nil {
+		respondTaskError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Task deleted successfully"})
+	c.JSON(http.StatusOK, transitions)
+}
+
+// respondTaskError maps task service errors to HTTP responses. Tasks owned
+// by someone else surface as ErrTaskNotFound, so they get the same 404 as
+// tasks that do not exist.
+func respondTaskError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrTaskNotFound),
+		errors.Is(err, service.ErrProjectNotFound),
+		errors.Is(err, service.ErrParentTaskNotFound),
+		errors.Is(err, service.ErrBlockerNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrTitleRequired),
+		errors.Is(err, service.ErrInvalidPriority),
+		errors.Is(err, service.ErrInvalidStatus),
+		errors.Is(err, service.ErrInvalidFilter),
+		errors.Is(err, service.ErrSearchQueryRequired),
+		errors.Is(err, service.ErrSubtaskCycle),
+		errors.Is(err, service.ErrSubtaskTooDeep),
+		errors.Is(err, service.ErrInvalidRecurrence),
+		errors.Is(err, service.ErrInvalidAssignee):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrInvalidTransition),
+		errors.Is(err, service.ErrOpenSubtasks),
+		errors.Is(err, service.ErrDependencyCycle),
+		errors.Is(err, service.ErrBlockedByOpenTasks):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrInsufficientRole):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
 }
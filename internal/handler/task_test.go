@@ -0,0 +1,711 @@
+package handler_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/ahmednurovic/task-manager-api/internal/handler"
+	"github.com/ahmednurovic/task-manager-api/internal/model"
+	"github.com/ahmednurovic/task-manager-api/internal/service"
+)
+
+type MockTaskService struct {
+	mock.Mock
+}
+
+func (m *MockTaskService) CreateTask(ctx *gin.Context, task *model.Task, userID int64) error {
+	args := m.Called(ctx, task, userID)
+	return args.Error(0)
+}
+
+func (m *MockTaskService) GetTasks(ctx *gin.Context, userID int64, filter model.TaskFilter) (*model.TaskPage, error) {
+	args := m.Called(ctx, userID, filter)
+	page, _ := args.Get(0).(*model.TaskPage)
+	return page, args.Error(1)
+}
+
+func (m *MockTaskService) GetTask(ctx *gin.Context, taskID int64, userID int64) (*model.Task, error) {
+	args := m.Called(ctx, taskID, userID)
+	task, _ := args.Get(0).(*model.Task)
+	return task, args.Error(1)
+}
+
+func (m *MockTaskService) SearchTasks(ctx *gin.Context, userID int64, query string, limit int) (*model.TaskSearchResults, error) {
+	args := m.Called(ctx, userID, query, limit)
+	results, _ := args.Get(0).(*model.TaskSearchResults)
+	return results, args.Error(1)
+}
+
+func (m *MockTaskService) UpdateTask(ctx *gin.Context, task *model.Task, userID int64) error {
+	args := m.Called(ctx, task, userID)
+	return args.Error(0)
+}
+
+func (m *MockTaskService) DeleteTask(ctx *gin.Context, taskID int64, userID int64) error {
+	args := m.Called(ctx, taskID, userID)
+	return args.Error(0)
+}
+
+func (m *MockTaskService) GetTransitions(ctx *gin.Context, taskID int64, userID int64) (*model.TaskTransitions, error) {
+	args := m.Called(ctx, taskID, userID)
+	transitions, _ := args.Get(0).(*model.TaskTransitions)
+	return transitions, args.Error(1)
+}
+
+func (m *MockTaskService) GetDependencies(ctx *gin.Context, taskID int64, userID int64) (*model.TaskDependencies, error) {
+	args := m.Called(ctx, taskID, userID)
+	dependencies, _ := args.Get(0).(*model.TaskDependencies)
+	return dependencies, args.Error(1)
+}
+
+func (m *MockTaskService) AddDependency(ctx *gin.Context, taskID int64, blockerID int64, userID int64) error {
+	args := m.Called(ctx, taskID, blockerID, userID)
+	return args.Error(0)
+}
+
+func (m *MockTaskService) RemoveDependency(ctx *gin.Context, taskID int64, blockerID int64, userID int64) error {
+	args := m.Called(ctx, taskID, blockerID, userID)
+	return args.Error(0)
+}
+
+func (m *MockTaskService) GetDependencyGraph(ctx *gin.Context, taskID int64, userID int64) (*model.TaskGraph, error) {
+	args := m.Called(ctx, taskID, userID)
+	graph, _ := args.Get(0).(*model.TaskGraph)
+	return graph, args.Error(1)
+}
+
+func (m *MockTaskService) AssignTask(ctx *gin.Context, taskID int64, assigneeID int64, userID int64) error {
+	args := m.Called(ctx, taskID, assigneeID, userID)
+	return args.Error(0)
+}
+
+func (m *MockTaskService) UnassignTask(ctx *gin.Context, taskID int64, userID int64) error {
+	args := m.Called(ctx, taskID, userID)
+	return args.Error(0)
+}
+
+func (m *MockTaskService) GetActivity(ctx *gin.Context, taskID int64, userID int64) ([]*model.TaskActivity, error) {
+	args := m.Called(ctx, taskID, userID)
+	activity, _ := args.Get(0).([]*model.TaskActivity)
+	return activity, args.Error(1)
+}
+
+// fakeAuth stands in for AuthMiddleware and authenticates every request as
+// userID. A zero userID leaves the request unauthenticated.
+func fakeAuth(userID uint) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if userID != 0 {
+			c.Set("userID", userID)
+		}
+		c.Next()
+	}
+}
+
+func newTaskRouter(taskService handler.TaskService, userID uint) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+
+	tasks := router.Group("/tasks").Use(fakeAuth(userID))
+	taskHandler := handler.NewTaskHandler(taskService)
+	tasks.POST("", taskHandler.CreateTask)
+	tasks.GET("", taskHandler.GetTasks)
+	tasks.GET("/search", taskHandler.SearchTasks)
+	tasks.GET("/:id", taskHandler.GetTask)
+	tasks.PUT("/:id", taskHandler.UpdateTask)
+	tasks.DELETE("/:id", taskHandler.DeleteTask)
+	tasks.GET("/:id/transitions", taskHandler.GetTransitions)
+	tasks.GET("/:id/dependencies", taskHandler.GetDependencies)
+	tasks.PUT("/:id/dependencies/:blocker_id", taskHandler.AddDependency)
+	tasks.DELETE("/:id/dependencies/:blocker_id", taskHandler.RemoveDependency)
+	tasks.GET("/:id/graph", taskHandler.GetDependencyGraph)
+	tasks.PUT("/:id/assignee", taskHandler.AssignTask)
+	tasks.DELETE("/:id/assignee", taskHandler.UnassignTask)
+	tasks.GET("/:id/activity", taskHandler.GetActivity)
+
+	projects := router.Group("/projects").Use(fakeAuth(userID))
+	projects.GET("/:id/tasks", taskHandler.GetProjectTasks)
+
+	return router
+}
+
+func performRequest(router *gin.Engine, method, path, body string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestCreateTaskHandler(t *testing.T) {
+	t.Run("Owner Comes From Token", func(t *testing.T) {
+		mockTaskService := new(MockTaskService)
+		mockTaskService.On("CreateTask", mock.Anything, mock.MatchedBy(func(task *model.Task) bool {
+			return task.Title == "Write report" && task.Priority == model.PriorityHigh && task.UserID == 0
+		}), int64(1)).
+			Run(func(args mock.Arguments) {
+				task := args.Get(1).(*model.Task)
+				task.ID = 10
+				task.UserID = 1
+			}).
+			Return(nil)
+
+		router := newTaskRouter(mockTaskService, 1)
+		w := performRequest(router, "POST", "/tasks", `{"title":"Write report","status":"pending","priority":"high","user_id":2}`)
+
+		assert.Equal(t, http.StatusCreated, w.Code)
+		var task model.Task
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &task))
+		assert.Equal(t, uint(10), task.ID)
+		assert.Equal(t, uint(1), task.UserID)
+		assert.Equal(t, "Write report", task.Title)
+		mockTaskService.AssertExpectations(t)
+	})
+
+	t.Run("Recurrence", func(t *testing.T) {
+		mockTaskService := new(MockTaskService)
+		mockTaskService.On("CreateTask", mock.Anything, mock.MatchedBy(func(task *model.Task) bool {
+			return task.RecurrenceRule == "FREQ=WEEKLY;BYDAY=MO" && task.RecurrenceTZ == "Europe/Berlin" &&
+				task.RecurrenceMode == model.RecurFromCompletion
+		}), int64(1)).
+			Return(nil)
+
+		router := newTaskRouter(mockTaskService, 1)
+		w := performRequest(router, "POST", "/tasks", `{"title":"Standup notes","due_at":"2025-01-06T09:00:00+01:00",`+
+			`"recurrence_rule":"FREQ=WEEKLY;BYDAY=MO","recurrence_tz":"Europe/Berlin","recurrence_mode":"completion"}`)
+
+		assert.Equal(t, http.StatusCreated, w.Code)
+		mockTaskService.AssertExpectations(t)
+	})
+
+	t.Run("Invalid Recurrence", func(t *testing.T) {
+		mockTaskService := new(MockTaskService)
+		mockTaskService.On("CreateTask", mock.Anything, mock.Anything, int64(1)).
+			Return(fmt.Errorf("%w: FREQ is required", service.ErrInvalidRecurrence))
+
+		router := newTaskRouter(mockTaskService, 1)
+		w := performRequest(router, "POST", "/tasks", `{"title":"Standup notes","recurrence_rule":"INTERVAL=2"}`)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		mockTaskService.AssertExpectations(t)
+	})
+
+	t.Run("Unauthenticated", func(t *testing.T) {
+		mockTaskService := new(MockTaskService)
+
+		router := newTaskRouter(mockTaskService, 0)
+		w := performRequest(router, "POST", "/tasks", `{"title":"Write report"}`)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		mockTaskService.AssertNotCalled(t, "CreateTask", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestGetTasksHandler(t *testing.T) {
+	t.Run("Query User ID Is Ignored", func(t *testing.T) {
+		mockTaskService := new(MockTaskService)
+		mockTaskService.On("GetTasks", mock.Anything, int64(1), model.TaskFilter{}).
+			Return(&model.TaskPage{Items: []*model.Task{{ID: 10, UserID: 1, Title: "Mine", Status: model.StatusPending}}}, nil)
+
+		router := newTaskRouter(mockTaskService, 1)
+		w := performRequest(router, "GET", "/tasks?user_id=2", "")
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var page model.TaskPage
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &page))
+		assert.Len(t, page.Items, 1)
+		assert.Equal(t, uint(1), page.Items[0].UserID)
+		mockTaskService.AssertExpectations(t)
+	})
+
+	t.Run("Filters", func(t *testing.T) {
+		dueAfter := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+		dueBefore := time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)
+		mockTaskService := new(MockTaskService)
+		mockTaskService.On("GetTasks", mock.Anything, int64(1), model.TaskFilter{
+			Statuses:   []model.TaskStatus{model.StatusPending, model.StatusBlocked, model.StatusDone},
+			Priorities: []model.TaskPriority{model.PriorityHigh},
+			DueAfter:   &dueAfter,
+			DueBefore:  &dueBefore,
+			Title:      "report",
+			LabelIDs:   []uint{3, 4},
+			LabelMatch: model.LabelMatchAll,
+			Sort:       model.SortByDueAt,
+			Order:      model.SortAsc,
+			Cursor:     "abc",
+			Limit:      20,
+		}).
+			Return(&model.TaskPage{Items: []*model.Task{}, NextCursor: "def"}, nil)
+
+		router := newTaskRouter(mockTaskService, 1)
+		w := performRequest(router, "GET", "/tasks?status=pending,blocked&status=done&priority=high"+
+			"&due_after=2025-01-01T00:00:00Z&due_before=2025-02-01T00:00:00Z"+
+			"&title=report&labels=3,4&label_match=all&sort=due_at&order=asc&cursor=abc&limit=20", "")
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `{"items":[],"next_cursor":"def"}`, w.Body.String())
+		mockTaskService.AssertExpectations(t)
+	})
+
+	t.Run("Assigned To Me And Created By Me", func(t *testing.T) {
+		mockTaskService := new(MockTaskService)
+		mockTaskService.On("GetTasks", mock.Anything, int64(1), model.TaskFilter{AssignedToMe: true, CreatedByMe: true}).
+			Return(&model.TaskPage{Items: []*model.Task{}}, nil)
+
+		router := newTaskRouter(mockTaskService, 1)
+		w := performRequest(router, "GET", "/tasks?assigned_to_me=true&created_by_me=1", "")
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		mockTaskService.AssertExpectations(t)
+	})
+
+	t.Run("Invalid Assigned To Me", func(t *testing.T) {
+		mockTaskService := new(MockTaskService)
+
+		router := newTaskRouter(mockTaskService, 1)
+		w := performRequest(router, "GET", "/tasks?assigned_to_me=maybe", "")
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		mockTaskService.AssertNotCalled(t, "GetTasks", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Invalid Filter", func(t *testing.T) {
+		mockTaskService := new(MockTaskService)
+		mockTaskService.On("GetTasks", mock.Anything, int64(1), mock.Anything).
+			Return(nil, service.ErrInvalidFilter)
+
+		router := newTaskRouter(mockTaskService, 1)
+		w := performRequest(router, "GET", "/tasks?sort=password", "")
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("Invalid Label ID", func(t *testing.T) {
+		mockTaskService := new(MockTaskService)
+
+		router := newTaskRouter(mockTaskService, 1)
+		w := performRequest(router, "GET", "/tasks?labels=bug", "")
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		mockTaskService.AssertNotCalled(t, "GetTasks", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Subtasks Of Parent", func(t *testing.T) {
+		parentID := uint(5)
+		mockTaskService := new(MockTaskService)
+		mockTaskService.On("GetTasks", mock.Anything, int64(1), model.TaskFilter{ParentID: &parentID}).
+			Return(&model.TaskPage{Items: []*model.Task{}}, nil)
+
+		router := newTaskRouter(mockTaskService, 1)
+		w := performRequest(router, "GET", "/tasks?parent_id=5", "")
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		mockTaskService.AssertExpectations(t)
+	})
+
+	t.Run("Invalid Due Date", func(t *testing.T) {
+		mockTaskService := new(MockTaskService)
+
+		router := newTaskRouter(mockTaskService, 1)
+		w := performRequest(router, "GET", "/tasks?due_after=tomorrow", "")
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		mockTaskService.AssertNotCalled(t, "GetTasks", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Unauthenticated", func(t *testing.T) {
+		mockTaskService := new(MockTaskService)
+
+		router := newTaskRouter(mockTaskService, 0)
+		w := performRequest(router, "GET", "/tasks?user_id=1", "")
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		mockTaskService.AssertNotCalled(t, "GetTasks", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestGetProjectTasksHandler(t *testing.T) {
+	t.Run("Project", func(t *testing.T) {
+		projectID := uint(3)
+		mockTaskService := new(MockTaskService)
+		mockTaskService.On("GetTasks", mock.Anything, int64(1), model.TaskFilter{
+			ProjectID: &projectID,
+			Statuses:  []model.TaskStatus{model.StatusDone},
+		}).
+			Return(&model.TaskPage{Items: []*model.Task{}}, nil)
+
+		router := newTaskRouter(mockTaskService, 1)
+		w := performRequest(router, "GET", "/projects/3/tasks?status=done&project_id=4", "")
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		mockTaskService.AssertExpectations(t)
+	})
+
+	t.Run("Inbox", func(t *testing.T) {
+		mockTaskService := new(MockTaskService)
+		mockTaskService.On("GetTasks", mock.Anything, int64(1), model.TaskFilter{InboxOnly: true}).
+			Return(&model.TaskPage{Items: []*model.Task{}}, nil)
+
+		router := newTaskRouter(mockTaskService, 1)
+		w := performRequest(router, "GET", "/projects/inbox/tasks", "")
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		mockTaskService.AssertExpectations(t)
+	})
+
+	t.Run("Other User's Project", func(t *testing.T) {
+		mockTaskService := new(MockTaskService)
+		mockTaskService.On("GetTasks", mock.Anything, int64(2), mock.Anything).
+			Return(nil, service.ErrProjectNotFound)
+
+		router := newTaskRouter(mockTaskService, 2)
+		w := performRequest(router, "GET", "/projects/3/tasks", "")
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
+
+func TestSearchTasksHandler(t *testing.T) {
+	t.Run("Scoped To Caller", func(t *testing.T) {
+		mockTaskService := new(MockTaskService)
+		mockTaskService.On("SearchTasks", mock.Anything, int64(1), `report -draft`, 5).
+			Return(&model.TaskSearchResults{Items: []*model.TaskSearchResult{{
+				Task:           &model.Task{ID: 10, UserID: 1, Title: "Quarterly report"},
+				Rank:           0.5,
+				TitleHighlight: "Quarterly <mark>report</mark>",
+			}}}, nil)
+
+		router := newTaskRouter(mockTaskService, 1)
+		w := performRequest(router, "GET", "/tasks/search?q=report+-draft&limit=5&user_id=2", "")
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var results model.TaskSearchResults
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &results))
+		assert.Len(t, results.Items, 1)
+		assert.Equal(t, "Quarterly <mark>report</mark>", results.Items[0].TitleHighlight)
+		mockTaskService.AssertExpectations(t)
+	})
+
+	t.Run("Missing Query", func(t *testing.T) {
+		mockTaskService := new(MockTaskService)
+		mockTaskService.On("SearchTasks", mock.Anything, int64(1), "", 0).
+			Return(nil, service.ErrSearchQueryRequired)
+
+		router := newTaskRouter(mockTaskService, 1)
+		w := performRequest(router, "GET", "/tasks/search", "")
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
+
+func TestUpdateTaskHandler(t *testing.T) {
+	t.Run("Own Task", func(t *testing.T) {
+		mockTaskService := new(MockTaskService)
+		mockTaskService.On("UpdateTask", mock.Anything, mock.MatchedBy(func(task *model.Task) bool {
+			return task.ID == 10 && task.Title == "Renamed" && task.UserID == 0
+		}), int64(1)).
+			Return(nil)
+
+		router := newTaskRouter(mockTaskService, 1)
+		w := performRequest(router, "PUT", "/tasks/10", `{"title":"Renamed","status":"done","user_id":2}`)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		mockTaskService.AssertExpectations(t)
+	})
+
+	t.Run("Other User's Task", func(t *testing.T) {
+		mockTaskService := new(MockTaskService)
+		mockTaskService.On("UpdateTask", mock.Anything, mock.Anything, int64(2)).
+			Return(service.ErrTaskNotFound)
+
+		router := newTaskRouter(mockTaskService, 2)
+		w := performRequest(router, "PUT", "/tasks/10", `{"title":"Hijacked"}`)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.JSONEq(t, `{"error":"task not found"}`, w.Body.String())
+		mockTaskService.AssertExpectations(t)
+	})
+
+	t.Run("Invalid Transition", func(t *testing.T) {
+		mockTaskService := new(MockTaskService)
+		mockTaskService.On("UpdateTask", mock.Anything, mock.Anything, int64(1)).
+			Return(service.ErrInvalidTransition)
+
+		router := newTaskRouter(mockTaskService, 1)
+		w := performRequest(router, "PUT", "/tasks/10", `{"title":"Renamed","status":"blocked"}`)
+
+		assert.Equal(t, http.StatusConflict, w.Code)
+		mockTaskService.AssertExpectations(t)
+	})
+
+	t.Run("Subtask Rules", func(t *testing.T) {
+		tests := []struct {
+			err  error
+			code int
+		}{
+			{service.ErrParentTaskNotFound, http.StatusNotFound},
+			{service.ErrSubtaskCycle, http.StatusBadRequest},
+			{service.ErrSubtaskTooDeep, http.StatusBadRequest},
+			{service.ErrOpenSubtasks, http.StatusConflict},
+		}
+
+		for _, tt := range tests {
+			mockTaskService := new(MockTaskService)
+			mockTaskService.On("UpdateTask", mock.Anything, mock.MatchedBy(func(task *model.Task) bool {
+				return task.ParentID != nil && *task.ParentID == 5
+			}), int64(1)).
+				Return(tt.err)
+
+			router := newTaskRouter(mockTaskService, 1)
+			w := performRequest(router, "PUT", "/tasks/10", `{"title":"Renamed","parent_id":5}`)
+
+			assert.Equal(t, tt.code, w.Code, tt.err.Error())
+			mockTaskService.AssertExpectations(t)
+		}
+	})
+
+	t.Run("Invalid Task ID", func(t *testing.T) {
+		mockTaskService := new(MockTaskService)
+
+		router := newTaskRouter(mockTaskService, 1)
+		w := performRequest(router, "PUT", "/tasks/abc", `{"title":"Renamed"}`)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
+
+func TestGetTaskHandler(t *testing.T) {
+	t.Run("Own Task With Progress", func(t *testing.T) {
+		mockTaskService := new(MockTaskService)
+		mockTaskService.On("GetTask", mock.Anything, int64(10), int64(1)).
+			Return(&model.Task{
+				ID:       10,
+				UserID:   1,
+				Title:    "Launch",
+				Progress: model.TaskProgress{SubtasksDone: 1, SubtasksTotal: 2, ChecklistDone: 3, ChecklistTotal: 5},
+			}, nil)
+
+		router := newTaskRouter(mockTaskService, 1)
+		w := performRequest(router, "GET", "/tasks/10", "")
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var body struct {
+			Progress model.TaskProgress `json:"progress"`
+		}
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		assert.Equal(t, model.TaskProgress{SubtasksDone: 1, SubtasksTotal: 2, ChecklistDone: 3, ChecklistTotal: 5}, body.Progress)
+	})
+
+	t.Run("Other User's Task", func(t *testing.T) {
+		mockTaskService := new(MockTaskService)
+		mockTaskService.On("GetTask", mock.Anything, int64(10), int64(2)).
+			Return(nil, service.ErrTaskNotFound)
+
+		router := newTaskRouter(mockTaskService, 2)
+		w := performRequest(router, "GET", "/tasks/10", "")
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		mockTaskService.AssertExpectations(t)
+	})
+}
+
+func TestDeleteTaskHandler(t *testing.T) {
+	t.Run("Own Task", func(t *testing.T) {
+		mockTaskService := new(MockTaskService)
+		mockTaskService.On("DeleteTask", mock.Anything, int64(10), int64(1)).Return(nil)
+
+		router := newTaskRouter(mockTaskService, 1)
+		w := performRequest(router, "DELETE", "/tasks/10?user_id=2", "")
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		mockTaskService.AssertExpectations(t)
+	})
+
+	t.Run("Other User's Task", func(t *testing.T) {
+		mockTaskService := new(MockTaskService)
+		mockTaskService.On("DeleteTask", mock.Anything, int64(10), int64(2)).
+			Return(service.ErrTaskNotFound)
+
+		router := newTaskRouter(mockTaskService, 2)
+		w := performRequest(router, "DELETE", "/tasks/10?user_id=1", "")
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.JSONEq(t, `{"error":"task not found"}`, w.Body.String())
+		mockTaskService.AssertExpectations(t)
+	})
+}
+
+func TestGetTransitionsHandler(t *testing.T) {
+	t.Run("Own Task", func(t *testing.T) {
+		mockTaskService := new(MockTaskService)
+		mockTaskService.On("GetTransitions", mock.Anything, int64(10), int64(1)).
+			Return(&model.TaskTransitions{
+				TaskID:  10,
+				Status:  model.StatusCancelled,
+				Allowed: []model.TaskStatus{model.StatusPending},
+			}, nil)
+
+		router := newTaskRouter(mockTaskService, 1)
+		w := performRequest(router, "GET", "/tasks/10/transitions", "")
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `{"task_id":10,"status":"cancelled","allowed":["pending"]}`, w.Body.String())
+		mockTaskService.AssertExpectations(t)
+	})
+
+	t.Run("Other User's Task", func(t *testing.T) {
+		mockTaskService := new(MockTaskService)
+		mockTaskService.On("GetTransitions", mock.Anything, int64(10), int64(2)).
+			Return(nil, service.ErrTaskNotFound)
+
+		router := newTaskRouter(mockTaskService, 2)
+		w := performRequest(router, "GET", "/tasks/10/transitions", "")
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		mockTaskService.AssertExpectations(t)
+	})
+}
+
+func TestDependencyHandlers(t *testing.T) {
+	t.Run("Add Dependency", func(t *testing.T) {
+		mockTaskService := new(MockTaskService)
+		mockTaskService.On("AddDependency", mock.Anything, int64(10), int64(11), int64(1)).Return(nil)
+
+		router := newTaskRouter(mockTaskService, 1)
+		w := performRequest(router, "PUT", "/tasks/10/dependencies/11", "")
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		mockTaskService.AssertExpectations(t)
+	})
+
+	t.Run("Cycle", func(t *testing.T) {
+		mockTaskService := new(MockTaskService)
+		mockTaskService.On("AddDependency", mock.Anything, int64(10), int64(11), int64(1)).
+			Return(service.ErrDependencyCycle)
+
+		router := newTaskRouter(mockTaskService, 1)
+		w := performRequest(router, "PUT", "/tasks/10/dependencies/11", "")
+
+		assert.Equal(t, http.StatusConflict, w.Code)
+		assert.JSONEq(t, `{"error":"dependency would create a cycle"}`, w.Body.String())
+	})
+
+	t.Run("Other User's Blocker", func(t *testing.T) {
+		mockTaskService := new(MockTaskService)
+		mockTaskService.On("AddDependency", mock.Anything, int64(10), int64(11), int64(1)).
+			Return(service.ErrBlockerNotFound)
+
+		router := newTaskRouter(mockTaskService, 1)
+		w := performRequest(router, "PUT", "/tasks/10/dependencies/11", "")
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("Invalid Blocker ID", func(t *testing.T) {
+		mockTaskService := new(MockTaskService)
+
+		router := newTaskRouter(mockTaskService, 1)
+		w := performRequest(router, "DELETE", "/tasks/10/dependencies/abc", "")
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		mockTaskService.AssertNotCalled(t, "RemoveDependency", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Start While Blocked", func(t *testing.T) {
+		mockTaskService := new(MockTaskService)
+		mockTaskService.On("UpdateTask", mock.Anything, mock.Anything, int64(1)).
+			Return(service.ErrBlockedByOpenTasks)
+
+		router := newTaskRouter(mockTaskService, 1)
+		w := performRequest(router, "PUT", "/tasks/10", `{"title":"Task","status":"in_progress"}`)
+
+		assert.Equal(t, http.StatusConflict, w.Code)
+	})
+
+	t.Run("Graph", func(t *testing.T) {
+		mockTaskService := new(MockTaskService)
+		mockTaskService.On("GetDependencyGraph", mock.Anything, int64(10), int64(1)).
+			Return(&model.TaskGraph{
+				RootID: 10,
+				Nodes:  []model.TaskRef{{ID: 10, Title: "Ship", Status: model.StatusPending}, {ID: 11, Title: "Build", Status: model.StatusDone}},
+				Edges:  []model.DependencyEdge{{TaskID: 10, BlockerID: 11}},
+			}, nil)
+
+		router := newTaskRouter(mockTaskService, 1)
+		w := performRequest(router, "GET", "/tasks/10/graph", "")
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `{
+			"root_id": 10,
+			"nodes": [{"id":10,"title":"Ship","status":"pending"},{"id":11,"title":"Build","status":"done"}],
+			"edges": [{"task_id":10,"blocker_id":11}]
+		}`, w.Body.String())
+	})
+}
+
+func TestAssigneeHandlers(t *testing.T) {
+	t.Run("Assign", func(t *testing.T) {
+		mockTaskService := new(MockTaskService)
+		mockTaskService.On("AssignTask", mock.Anything, int64(10), int64(5), int64(1)).Return(nil)
+
+		router := newTaskRouter(mockTaskService, 1)
+		w := performRequest(router, "PUT", "/tasks/10/assignee", `{"user_id":5}`)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		mockTaskService.AssertExpectations(t)
+	})
+
+	t.Run("Not A Member", func(t *testing.T) {
+		mockTaskService := new(MockTaskService)
+		mockTaskService.On("AssignTask", mock.Anything, int64(10), int64(9), int64(1)).
+			Return(service.ErrInvalidAssignee)
+
+		router := newTaskRouter(mockTaskService, 1)
+		w := performRequest(router, "PUT", "/tasks/10/assignee", `{"user_id":9}`)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.JSONEq(t, `{"error":"assignee must be a member of the task's workspace"}`, w.Body.String())
+	})
+
+	t.Run("Missing User ID", func(t *testing.T) {
+		mockTaskService := new(MockTaskService)
+
+		router := newTaskRouter(mockTaskService, 1)
+		w := performRequest(router, "PUT", "/tasks/10/assignee", `{}`)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		mockTaskService.AssertNotCalled(t, "AssignTask", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Unassign", func(t *testing.T) {
+		mockTaskService := new(MockTaskService)
+		mockTaskService.On("UnassignTask", mock.Anything, int64(10), int64(1)).Return(nil)
+
+		router := newTaskRouter(mockTaskService, 1)
+		w := performRequest(router, "DELETE", "/tasks/10/assignee", "")
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		mockTaskService.AssertExpectations(t)
+	})
+
+	t.Run("Activity", func(t *testing.T) {
+		userID := uint(5)
+		mockTaskService := new(MockTaskService)
+		mockTaskService.On("GetActivity", mock.Anything, int64(10), int64(1)).
+			Return([]*model.TaskActivity{{ID: 1, TaskID: 10, ActorID: &userID, Action: model.ActivityAssigned, SubjectID: &userID}}, nil)
+
+		router := newTaskRouter(mockTaskService, 1)
+		w := performRequest(router, "GET", "/tasks/10/activity", "")
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"action":"assigned"`)
+	})
+}
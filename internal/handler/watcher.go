@@ -0,0 +1,114 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/ahmednurovic/task-manager-api/internal/model"
+)
+
+type WatcherService interface {
+	GetWatchers(ctx *gin.Context, taskID int64, userID int64) ([]*model.TaskWatcher, error)
+	WatchTask(ctx *gin.Context, taskID int64, userID int64) error
+	UnwatchTask(ctx *gin.Context, taskID int64, userID int64) error
+}
+
+type WatcherHandler struct {
+	service WatcherService
+}
+
+func NewWatcherHandler(service WatcherService) *WatcherHandler {
+	return &WatcherHandler{service: service}
+}
+
+// GetWatchers godoc
+// @Summary List a task's watchers
+// @Description List the users following a task
+// @Tags watchers
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Task ID"
+// @Success 200 {array} model.TaskWatcher
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /tasks/{id}/watchers [get]
+func (h *WatcherHandler) GetWatchers(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	taskID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid task id"})
+		return
+	}
+
+	watchers, err := h.service.GetWatchers(c, taskID, userID)
+	if err != nil {
+		respondTaskError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, watchers)
+}
+
+// WatchTask godoc
+// @Summary Watch a task
+// @Description Follow a task as the authenticated user. Any workspace role may watch the tasks it can see. Watching a task twice is a no-op.
+// @Tags watchers
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Task ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /tasks/{id}/watch [put]
+func (h *WatcherHandler) WatchTask(c *gin.Context) {
+	h.changeWatch(c, h.service.WatchTask, "Task watched successfully")
+}
+
+// UnwatchTask godoc
+// @Summary Unwatch a task
+// @Description Stop following a task as the authenticated user
+// @Tags watchers
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Task ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /tasks/{id}/watch [delete]
+func (h *WatcherHandler) UnwatchTask(c *gin.Context) {
+	h.changeWatch(c, h.service.UnwatchTask, "Task unwatched successfully")
+}
+
+func (h *WatcherHandler) changeWatch(c *gin.Context, change func(*gin.Context, int64, int64) error, message string) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	taskID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid task id"})
+		return
+	}
+
+	if err := change(c, taskID, userID); err != nil {
+		respondTaskError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": message})
+}
@@ -0,0 +1,486 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/ahmednurovic/task-manager-api/internal/model"
+	"github.com/ahmednurovic/task-manager-api/internal/service"
+)
+
+type WorkspaceService interface {
+	CreateWorkspace(ctx *gin.Context, workspace *model.Workspace, userID int64) error
+	GetWorkspaces(ctx *gin.Context, userID int64) ([]*model.Workspace, error)
+	GetWorkspace(ctx *gin.Context, workspaceID int64, userID int64) (*model.Workspace, error)
+	UpdateWorkspace(ctx *gin.Context, workspace *model.Workspace, userID int64) error
+	DeleteWorkspace(ctx *gin.Context, workspaceID int64, userID int64) error
+	GetMembers(ctx *gin.Context, workspaceID int64, userID int64) ([]*model.WorkspaceMember, error)
+	UpdateMemberRole(ctx *gin.Context, workspaceID int64, memberID int64, role model.WorkspaceRole, userID int64) error
+	RemoveMember(ctx *gin.Context, workspaceID int64, memberID int64, userID int64) error
+	InviteMember(ctx *gin.Context, invitation *model.WorkspaceInvitation, userID int64) error
+	AcceptInvitation(ctx *gin.Context, token string, userID int64) (*model.Workspace, error)
+	DeclineInvitation(ctx *gin.Context, token string) error
+}
+
+type WorkspaceHandler struct {
+	service WorkspaceService
+}
+
+func NewWorkspaceHandler(service WorkspaceService) *WorkspaceHandler {
+	return &WorkspaceHandler{service: service}
+}
+
+type WorkspaceRequest struct {
+	Name string `json:"name" example:"Platform team"`
+}
+
+type MemberRoleRequest struct {
+	Role model.WorkspaceRole `json:"role" example:"member"`
+}
+
+type InvitationRequest struct {
+	Email string              `json:"email" example:"colleague@example.com"`
+	Role  model.WorkspaceRole `json:"role" example:"member"`
+}
+
+type InvitationTokenRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+// CreateWorkspace godoc
+// @Summary Create a workspace
+// @Description Create a shared workspace with the authenticated user as its owner
+// @Tags workspaces
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param workspace body WorkspaceRequest true "Workspace object"
+// @Success 201 {object} model.Workspace
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /workspaces [post]
+func (h *WorkspaceHandler) CreateWorkspace(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	var req WorkspaceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	workspace := model.Workspace{Name: req.Name}
+	if err := h.service.CreateWorkspace(c, &workspace, userID); err != nil {
+		respondWorkspaceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, workspace)
+}
+
+// GetWorkspaces godoc
+// @Summary Get the authenticated user's workspaces
+// @Description Get the workspaces the authenticated user is a member of, with their role in each
+// @Tags workspaces
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} model.Workspace
+// @Failure 401 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /workspaces [get]
+func (h *WorkspaceHandler) GetWorkspaces(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	workspaces, err := h.service.GetWorkspaces(c, userID)
+	if err != nil {
+		respondWorkspaceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, workspaces)
+}
+
+// GetWorkspace godoc
+// @Summary Get a workspace
+// @Description Get a workspace the authenticated user is a member of
+// @Tags workspaces
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Workspace ID"
+// @Success 200 {object} model.Workspace
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /workspaces/{id} [get]
+func (h *WorkspaceHandler) GetWorkspace(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	workspaceID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid workspace id"})
+		return
+	}
+
+	workspace, err := h.service.GetWorkspace(c, workspaceID, userID)
+	if err != nil {
+		respondWorkspaceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, workspace)
+}
+
+// UpdateWorkspace godoc
+// @Summary Rename a workspace
+// @Description Rename a workspace. Only owners may do so.
+// @Tags workspaces
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Workspace ID"
+// @Param workspace body WorkspaceRequest true "Updated workspace object"
+// @Success 200 {object} model.Workspace
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /workspaces/{id} [put]
+func (h *WorkspaceHandler) UpdateWorkspace(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	workspaceID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid workspace id"})
+		return
+	}
+
+	var req WorkspaceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	workspace := model.Workspace{ID: uint(workspaceID), Name: req.Name}
+	if err := h.service.UpdateWorkspace(c, &workspace, userID); err != nil {
+		respondWorkspaceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, workspace)
+}
+
+// DeleteWorkspace godoc
+// @Summary Delete a workspace
+// @Description Delete a workspace together with its projects and tasks. Only owners may do so.
+// @Tags workspaces
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Workspace ID"
+// @Success 204
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /workspaces/{id} [delete]
+func (h *WorkspaceHandler) DeleteWorkspace(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	workspaceID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid workspace id"})
+		return
+	}
+
+	if err := h.service.DeleteWorkspace(c, workspaceID, userID); err != nil {
+		respondWorkspaceError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// GetMembers godoc
+// @Summary Get the members of a workspace
+// @Description Get the members of a workspace the authenticated user belongs to, with their roles
+// @Tags workspaces
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Workspace ID"
+// @Success 200 {array} model.WorkspaceMember
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /workspaces/{id}/members [get]
+func (h *WorkspaceHandler) GetMembers(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	workspaceID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid workspace id"})
+		return
+	}
+
+	members, err := h.service.GetMembers(c, workspaceID, userID)
+	if err != nil {
+		respondWorkspaceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, members)
+}
+
+// UpdateMemberRole godoc
+// @Summary Change a member's role
+// @Description Change a member's role to owner, admin, member or viewer. Admins manage everyone below owner; only owners may appoint or demote owners. The last owner cannot be demoted.
+// @Tags workspaces
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Workspace ID"
+// @Param user_id path int true "Member user ID"
+// @Param role body MemberRoleRequest true "New role"
+// @Success 204
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /workspaces/{id}/members/{user_id} [put]
+func (h *WorkspaceHandler) UpdateMemberRole(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	workspaceID, memberID, ok := workspaceMemberParams(c)
+	if !ok {
+		return
+	}
+
+	var req MemberRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.service.UpdateMemberRole(c, workspaceID, memberID, req.Role, userID); err != nil {
+		respondWorkspaceError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// RemoveMember godoc
+// @Summary Remove a member from a workspace
+// @Description Remove a member from a workspace. Any member may remove themselves to leave; removing others takes an admin, and removing an owner takes an owner. The last owner cannot leave.
+// @Tags workspaces
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Workspace ID"
+// @Param user_id path int true "Member user ID"
+// @Success 204
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /workspaces/{id}/members/{user_id} [delete]
+func (h *WorkspaceHandler) RemoveMember(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	workspaceID, memberID, ok := workspaceMemberParams(c)
+	if !ok {
+		return
+	}
+
+	if err := h.service.RemoveMember(c, workspaceID, memberID, userID); err != nil {
+		respondWorkspaceError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// InviteMember godoc
+// @Summary Invite someone to a workspace
+// @Description Email an invitation to join the workspace with the given role. The email holds a link with a token to accept or decline. Inviting takes an admin, and inviting an owner takes an owner.
+// @Tags workspaces
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Workspace ID"
+// @Param invitation body InvitationRequest true "Invitation object"
+// @Success 201 {object} model.WorkspaceInvitation
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /workspaces/{id}/invitations [post]
+func (h *WorkspaceHandler) InviteMember(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	workspaceID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid workspace id"})
+		return
+	}
+
+	var req InvitationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	invitation := model.WorkspaceInvitation{WorkspaceID: uint(workspaceID), Email: req.Email, Role: req.Role}
+	if err := h.service.InviteMember(c, &invitation, userID); err != nil {
+		respondWorkspaceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, invitation)
+}
+
+// AcceptInvitation godoc
+// @Summary Accept a workspace invitation
+// @Description Join the workspace of an invitation sent to the authenticated user's email address
+// @Tags workspaces
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body InvitationTokenRequest true "Invitation token from the email"
+// @Success 200 {object} model.Workspace
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /invitations/accept [post]
+func (h *WorkspaceHandler) AcceptInvitation(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	var req InvitationTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	workspace, err := h.service.AcceptInvitation(c, req.Token, userID)
+	if err != nil {
+		respondWorkspaceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, workspace)
+}
+
+// DeclineInvitation godoc
+// @Summary Decline a workspace invitation
+// @Description Turn down an invitation. The token from the email is enough; no login is needed.
+// @Tags workspaces
+// @Accept json
+// @Produce json
+// @Param request body InvitationTokenRequest true "Invitation token from the email"
+// @Success 204
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /invitations/decline [post]
+func (h *WorkspaceHandler) DeclineInvitation(c *gin.Context) {
+	var req InvitationTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.service.DeclineInvitation(c, req.Token); err != nil {
+		respondWorkspaceError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// workspaceMemberParams parses the workspace and member IDs of a member
+// route, responding with 400 if either is invalid.
+func workspaceMemberParams(c *gin.Context) (int64, int64, bool) {
+	workspaceID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid workspace id"})
+		return 0, 0, false
+	}
+
+	memberID, err := strconv.ParseInt(c.Param("user_id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+		return 0, 0, false
+	}
+
+	return workspaceID, memberID, true
+}
+
+func respondWorkspaceError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrWorkspaceNotFound),
+		errors.Is(err, service.ErrMemberNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrWorkspaceNameRequired),
+		errors.Is(err, service.ErrWorkspaceNameTooLong),
+		errors.Is(err, service.ErrInvalidWorkspaceRole),
+		errors.Is(err, service.ErrInvitationEmailRequired),
+		errors.Is(err, service.ErrInvalidInvitation):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrInsufficientRole),
+		errors.Is(err, service.ErrInvitationEmailMismatch):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrLastOwner):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
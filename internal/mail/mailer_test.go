@@ -0,0 +1,117 @@
+package mail
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"net/textproto"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLogMailer(t *testing.T) {
+	var buf bytes.Buffer
+	mailer := NewLogMailer(&buf)
+
+	err := mailer.Send(context.Background(), Message{To: "user@example.com", Subject: "Hello", Body: "Line one\nLine two\n"})
+
+	assert.NoError(t, err)
+	assert.Contains(t, buf.String(), "To: user@example.com\nSubject: Hello\n\nLine one\nLine two\n")
+}
+
+// fakeSMTPServer accepts one connection, plays the server side of a plain
+// SMTP session and records the envelope and message it receives.
+type fakeSMTPServer struct {
+	listener net.Listener
+	from     string
+	to       []string
+	data     string
+	done     chan struct{}
+}
+
+func newFakeSMTPServer(t *testing.T) *fakeSMTPServer {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server := &fakeSMTPServer{listener: listener, done: make(chan struct{})}
+	go server.serve()
+	t.Cleanup(func() { listener.Close() })
+	return server
+}
+
+func (s *fakeSMTPServer) serve() {
+	defer close(s.done)
+
+	conn, err := s.listener.Accept()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	text := textproto.NewConn(conn)
+	text.PrintfLine("220 localhost ESMTP")
+	for {
+		line, err := text.ReadLine()
+		if err != nil {
+			return
+		}
+
+		command := strings.ToUpper(line)
+		switch {
+		case strings.HasPrefix(command, "EHLO"), strings.HasPrefix(command, "HELO"):
+			text.PrintfLine("250 localhost")
+		case strings.HasPrefix(command, "MAIL FROM:"):
+			s.from = strings.Trim(line[len("MAIL FROM:"):], "<> ")
+			text.PrintfLine("250 OK")
+		case strings.HasPrefix(command, "RCPT TO:"):
+			s.to = append(s.to, strings.Trim(line[len("RCPT TO:"):], "<> "))
+			text.PrintfLine("250 OK")
+		case command == "DATA":
+			text.PrintfLine("354 Go ahead")
+			data, err := text.ReadDotBytes()
+			if err != nil {
+				return
+			}
+			s.data = string(data)
+			text.PrintfLine("250 OK")
+		case command == "QUIT":
+			text.PrintfLine("221 Bye")
+			return
+		default:
+			text.PrintfLine("502 Not implemented")
+		}
+	}
+}
+
+func TestSMTPMailer(t *testing.T) {
+	t.Run("Sends Message", func(t *testing.T) {
+		server := newFakeSMTPServer(t)
+		addr := server.listener.Addr().(*net.TCPAddr)
+
+		mailer := NewSMTPMailer(SMTPConfig{Host: "127.0.0.1", Port: addr.Port, From: "noreply@example.com"})
+		err := mailer.Send(context.Background(), Message{To: "user@example.com", Subject: "Hello", Body: "Line one\nLine two"})
+		assert.NoError(t, err)
+		<-server.done
+
+		assert.Equal(t, "noreply@example.com", server.from)
+		assert.Equal(t, []string{"user@example.com"}, server.to)
+		assert.Contains(t, server.data, "From: noreply@example.com\n")
+		assert.Contains(t, server.data, "To: user@example.com\n")
+		assert.Contains(t, server.data, "Subject: Hello\n")
+		assert.Contains(t, server.data, "\n\nLine one\nLine two\n")
+	})
+
+	t.Run("Header Injection", func(t *testing.T) {
+		mailer := NewSMTPMailer(SMTPConfig{Host: "127.0.0.1", Port: 1, From: "noreply@example.com"})
+
+		err := mailer.Send(context.Background(), Message{To: "user@example.com\r\nBcc: victim@example.com", Subject: "Hello"})
+		assert.Error(t, err)
+
+		err = mailer.Send(context.Background(), Message{To: "user@example.com", Subject: "Hello\nBcc: victim@example.com"})
+		assert.Error(t, err)
+	})
+}
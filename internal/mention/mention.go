@@ -0,0 +1,67 @@
+// Package mention finds @mentions in markdown text.
+//
+// A mention is an @ followed by either an email address (@ana@example.com)
+// or a username (@ana). An @ that follows a letter, digit or dot is part of
+// an email address written out in the text, and one that follows a slash
+// is part of a link, so neither is a mention. Mentions inside
+// inline code and fenced code blocks are ignored, since they are usually
+// quoted code rather than people.
+package mention
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	mentionPattern = regexp.MustCompile(`(?:^|[^\w.@/])@([\w.+-]+@[\w-]+(?:\.[\w-]+)+|\w[\w.-]*)`)
+	inlineCode     = regexp.MustCompile("`[^`\n]*`")
+)
+
+// Parse returns the distinct mentions in a markdown body, lowercased and
+// without the leading @, in the order they first appear.
+func Parse(body string) []string {
+	mentions := []string{}
+	seen := map[string]bool{}
+
+	for _, line := range proseLines(body) {
+		line = inlineCode.ReplaceAllString(line, " ")
+		for _, match := range mentionPattern.FindAllStringSubmatch(line, -1) {
+			// Sentence punctuation is not part of a name.
+			name := strings.ToLower(strings.TrimRight(match[1], ".-"))
+			if name != "" && !seen[name] {
+				seen[name] = true
+				mentions = append(mentions, name)
+			}
+		}
+	}
+
+	return mentions
+}
+
+// IsEmail reports whether a mention returned by Parse names an email
+// address rather than a username.
+func IsEmail(mention string) bool {
+	return strings.Contains(mention, "@")
+}
+
+// proseLines returns the lines of body outside fenced code blocks.
+func proseLines(body string) []string {
+	var lines []string
+	fence := ""
+	for _, line := range strings.Split(body, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if fence != "" {
+			if strings.HasPrefix(trimmed, fence) {
+				fence = ""
+			}
+			continue
+		}
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			fence = trimmed[:3]
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
@@ -0,0 +1,38 @@
+package mention
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want []string
+	}{
+		{"None", "Looks good to me", []string{}},
+		{"Username", "@ana can you review?", []string{"ana"}},
+		{"Email", "Thanks @Ana@Example.com!", []string{"ana@example.com"}},
+		{"Several In Order", "@bo and @ana, then @bo again", []string{"bo", "ana"}},
+		{"Trailing Punctuation", "Ask @ana.", []string{"ana"}},
+		{"Dotted Username", "cc @ana.lopez-ruiz", []string{"ana.lopez-ruiz"}},
+		{"Written Out Email", "Mail ana@example.com instead", []string{}},
+		{"In Markdown", "**@ana** see [the doc](https://example.com/@bo)", []string{"ana"}},
+		{"Inline Code", "Run `git blame @head` and ping @ana", []string{"ana"}},
+		{"Fenced Code", "```\n@decorator\n```\n@ana", []string{"ana"}},
+		{"Unclosed Fence", "~~~\n@ana", []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, Parse(tt.body))
+		})
+	}
+}
+
+func TestIsEmail(t *testing.T) {
+	assert.True(t, IsEmail("ana@example.com"))
+	assert.False(t, IsEmail("ana"))
+}
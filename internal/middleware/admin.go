@@ -0,0 +1,35 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Admins reports whether a user is an administrator.
+type Admins interface {
+	IsAdmin(ctx context.Context, userID uint) (bool, error)
+}
+
+// RequireAdmin lets only administrators through. It must run after
+// AuthMiddleware. The user is looked up on every request, so taking away
+// admin rights applies at once.
+func RequireAdmin(admins Admins) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, _ := c.Get("userID")
+		id, _ := userID.(uint)
+
+		isAdmin, err := admins.IsAdmin(c.Request.Context(), id)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "failed to check permissions"})
+			return
+		}
+		if !isAdmin {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "administrator access required"})
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,53 @@
+package middleware_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ahmednurovic/task-manager-api/internal/middleware"
+)
+
+type admins map[uint]bool
+
+func (a admins) IsAdmin(ctx context.Context, userID uint) (bool, error) {
+	if userID == 99 {
+		return false, errors.New("database is down")
+	}
+	return a[userID], nil
+}
+
+func TestRequireAdmin(t *testing.T) {
+	tests := []struct {
+		name       string
+		userID     uint
+		wantStatus int
+	}{
+		{"Administrator", 1, http.StatusOK},
+		{"Regular User", 2, http.StatusForbidden},
+		{"Lookup Fails", 99, http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gin.SetMode(gin.TestMode)
+			router := gin.New()
+			router.GET("/admin", func(c *gin.Context) {
+				c.Set("userID", tt.userID)
+				c.Next()
+			}, middleware.RequireAdmin(admins{1: true}), func(c *gin.Context) {
+				c.Status(http.StatusOK)
+			})
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest("GET", "/admin", nil))
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+		})
+	}
+}
@@ -1,14 +1,51 @@
 package middleware
 
 import (
+	"context"
+	"database/sql"
+	"errors"
 	"net/http"
+	"strconv"
 	"strings"
 
+	"github.com/ahmednurovic/task-manager-api/internal/model"
+	"github.com/ahmednurovic/task-manager-api/internal/signing"
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
 )
 
-func AuthMiddleware(jwtSecret string) gin.HandlerFunc {
+// TokenRevocations reports access tokens revoked before they expire, either
+// one at a time by jti or all of a user's tokens by bumping their version.
+type TokenRevocations interface {
+	IsRevoked(ctx context.Context, jti string) (bool, error)
+	TokenVersion(ctx context.Context, userID uint) (int, error)
+}
+
+// PersonalAccessTokens looks up personal access tokens. Authenticate
+// returns nil if the token is unknown, revoked or expired.
+type PersonalAccessTokens interface {
+	Authenticate(ctx context.Context, token string) (*model.PersonalAccessToken, error)
+}
+
+// WorkspaceMemberships looks up users' roles in workspaces. MemberRole
+// returns "" if the user is not a member.
+type WorkspaceMemberships interface {
+	MemberRole(ctx context.Context, workspaceID uint, userID uint) (model.WorkspaceRole, error)
+}
+
+// WorkspaceHeader switches a request from the user's personal space to a
+// workspace they are a member of.
+const WorkspaceHeader = "X-Workspace-ID"
+
+// AuthMiddleware accepts either a JWT access token or a personal access
+// token. Requests made with a personal access token carry its scopes; see
+// HasScope. Both kinds of token are rejected once the user's token version
+// has moved past the one they were issued with.
+//
+// A request that names a workspace in WorkspaceHeader acts in that
+// workspace with the user's role in it, and is refused if the user is not
+// a member.
+func AuthMiddleware(keys *signing.KeySet, revocations TokenRevocations, accessTokens PersonalAccessTokens, workspaces WorkspaceMemberships) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
@@ -22,12 +59,41 @@ func AuthMiddleware(jwtSecret string) gin.HandlerFunc {
 			return
 		}
 
-		token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, jwt.ErrSignatureInvalid
+		if strings.HasPrefix(tokenString, model.PersonalAccessTokenPrefix) {
+			accessToken, err := accessTokens.Authenticate(c.Request.Context(), tokenString)
+			if err != nil {
+				c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "failed to check token"})
+				return
+			}
+			if accessToken == nil {
+				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
+				return
+			}
+
+			currentVersion, err := revocations.TokenVersion(c.Request.Context(), accessToken.UserID)
+			if errors.Is(err, sql.ErrNoRows) {
+				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
+				return
+			}
+			if err != nil {
+				c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "failed to check token"})
+				return
+			}
+			if accessToken.TokenVersion < currentVersion {
+				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "token has been revoked"})
+				return
+			}
+
+			c.Set("userID", accessToken.UserID)
+			c.Set("scopes", []string(accessToken.Scopes))
+			if !resolveWorkspace(c, workspaces, accessToken.UserID) {
+				return
 			}
-			return []byte(jwtSecret), nil
-		})
+			c.Next()
+			return
+		}
+
+		token, err := keys.Parse(tokenString, jwt.MapClaims{})
 
 		if err != nil {
 			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid token: " + err.Error()})
@@ -62,7 +128,77 @@ func AuthMiddleware(jwtSecret string) gin.HandlerFunc {
 			return
 		}
 
+		jti, _ := claims["jti"].(string)
+		if jti == "" {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "missing jti in token"})
+			return
+		}
+
+		tokenVersion, _ := claims["ver"].(float64)
+
+		ctx := c.Request.Context()
+		revoked, err := revocations.IsRevoked(ctx, jti)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "failed to check token"})
+			return
+		}
+		currentVersion, err := revocations.TokenVersion(ctx, userID)
+		if errors.Is(err, sql.ErrNoRows) {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
+			return
+		}
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "failed to check token"})
+			return
+		}
+		if revoked || int(tokenVersion) < currentVersion {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "token has been revoked"})
+			return
+		}
+
+		expiresAt, _ := claims.GetExpirationTime()
+
+		readOnly, _ := claims["ro"].(bool)
+
 		c.Set("userID", userID)
+		c.Set("tokenID", jti)
+		c.Set("readOnly", readOnly)
+		if expiresAt != nil {
+			c.Set("tokenExpiresAt", expiresAt.Time)
+		}
+		if !resolveWorkspace(c, workspaces, userID) {
+			return
+		}
 		c.Next()
 	}
+}
+
+// resolveWorkspace sets the workspace named in WorkspaceHeader and the
+// user's role in it on the context. It aborts the request and returns
+// false if the header is invalid or the user is not a member.
+func resolveWorkspace(c *gin.Context, workspaces WorkspaceMemberships, userID uint) bool {
+	header := c.GetHeader(WorkspaceHeader)
+	if header == "" {
+		return true
+	}
+
+	workspaceID, err := strconv.ParseUint(header, 10, 64)
+	if err != nil || workspaceID == 0 {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "invalid " + WorkspaceHeader + " header"})
+		return false
+	}
+
+	role, err := workspaces.MemberRole(c.Request.Context(), uint(workspaceID), userID)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "failed to check workspace membership"})
+		return false
+	}
+	if role == "" {
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "not a member of this workspace"})
+		return false
+	}
+
+	c.Set("workspaceID", uint(workspaceID))
+	c.Set("workspaceRole", role)
+	return true
 }
\ No newline at end of file
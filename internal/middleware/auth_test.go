@@ -0,0 +1,220 @@
+package middleware_test
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ahmednurovic/task-manager-api/internal/middleware"
+	"github.com/ahmednurovic/task-manager-api/internal/model"
+	"github.com/ahmednurovic/task-manager-api/internal/signing"
+)
+
+type noRevocations struct{}
+
+func (noRevocations) IsRevoked(ctx context.Context, jti string) (bool, error) {
+	return false, nil
+}
+
+func (noRevocations) TokenVersion(ctx context.Context, userID uint) (int, error) {
+	return 0, nil
+}
+
+// tokenVersions revokes nothing by jti and reports each user's token
+// version, which is zero for users not in the map.
+type tokenVersions map[uint]int
+
+func (tokenVersions) IsRevoked(ctx context.Context, jti string) (bool, error) {
+	return false, nil
+}
+
+func (v tokenVersions) TokenVersion(ctx context.Context, userID uint) (int, error) {
+	return v[userID], nil
+}
+
+type accessTokens map[string]*model.PersonalAccessToken
+
+func (t accessTokens) Authenticate(ctx context.Context, token string) (*model.PersonalAccessToken, error) {
+	return t[token], nil
+}
+
+// memberships maps workspace IDs to the roles of their members.
+type memberships map[uint]map[uint]model.WorkspaceRole
+
+func (m memberships) MemberRole(ctx context.Context, workspaceID uint, userID uint) (model.WorkspaceRole, error) {
+	return m[workspaceID][userID], nil
+}
+
+func TestAuthMiddlewarePersonalAccessTokens(t *testing.T) {
+	tokens := accessTokens{
+		"tm_pat_reader": {ID: 1, UserID: 7, Scopes: []string{"tasks:read"}},
+		"tm_pat_writer": {ID: 2, UserID: 7, Scopes: []string{"tasks:write"}},
+	}
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	auth := middleware.AuthMiddleware(signing.NewHMAC("test-secret"), noRevocations{}, tokens, memberships{})
+	ok := func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"user_id": c.MustGet("userID")})
+	}
+	tasks := router.Group("/tasks", auth, middleware.RequireScope("tasks"))
+	tasks.GET("", ok)
+	tasks.POST("", ok)
+	router.GET("/labels", auth, middleware.RequireScope("labels"), ok)
+	router.GET("/me/tokens", auth, middleware.RequireSession(), ok)
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		token      string
+		wantStatus int
+	}{
+		{"Read With Read Scope", "GET", "/tasks", "tm_pat_reader", http.StatusOK},
+		{"Write With Read Scope", "POST", "/tasks", "tm_pat_reader", http.StatusForbidden},
+		{"Write With Write Scope", "POST", "/tasks", "tm_pat_writer", http.StatusOK},
+		{"Write Scope Includes Read", "GET", "/tasks", "tm_pat_writer", http.StatusOK},
+		{"Other Resource", "GET", "/labels", "tm_pat_writer", http.StatusForbidden},
+		{"Session Only", "GET", "/me/tokens", "tm_pat_writer", http.StatusForbidden},
+		{"Unknown Token", "GET", "/tasks", "tm_pat_unknown", http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			req.Header.Set("Authorization", "Bearer "+tt.token)
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+			if tt.wantStatus == http.StatusOK {
+				assert.JSONEq(t, `{"user_id":7}`, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestAuthMiddlewarePersonalAccessTokenVersion(t *testing.T) {
+	// User 7 has signed out everywhere, or reset their password, since
+	// making their first token.
+	tokens := accessTokens{
+		"tm_pat_before": {ID: 1, UserID: 7, Scopes: []string{"tasks:read"}, TokenVersion: 0},
+		"tm_pat_after":  {ID: 2, UserID: 7, Scopes: []string{"tasks:read"}, TokenVersion: 1},
+	}
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	auth := middleware.AuthMiddleware(signing.NewHMAC("test-secret"), tokenVersions{7: 1}, tokens, memberships{})
+	router.GET("/tasks", auth, func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"user_id": c.MustGet("userID")})
+	})
+
+	tests := []struct {
+		name       string
+		token      string
+		wantStatus int
+	}{
+		{"Made Before Sign Out", "tm_pat_before", http.StatusUnauthorized},
+		{"Made After Sign Out", "tm_pat_after", http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", "/tasks", nil)
+			req.Header.Set("Authorization", "Bearer "+tt.token)
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+		})
+	}
+}
+
+func TestAuthMiddlewareAsymmetricKeys(t *testing.T) {
+	_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	assert.NoError(t, err)
+	der, err := x509.MarshalPKCS8PrivateKey(privateKey)
+	assert.NoError(t, err)
+	keys, err := signing.New(signing.EdDSA, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}))
+	assert.NoError(t, err)
+
+	claims := jwt.MapClaims{"user_id": 7, "jti": "token", "exp": jwt.NewNumericDate(time.Now().Add(time.Hour))}
+	signed, err := keys.Sign(claims)
+	assert.NoError(t, err)
+	forged, err := signing.NewHMAC("test-secret").Sign(claims)
+	assert.NoError(t, err)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/tasks", middleware.AuthMiddleware(keys, noRevocations{}, accessTokens{}, memberships{}), func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"user_id": c.MustGet("userID")})
+	})
+
+	tests := []struct {
+		name       string
+		token      string
+		wantStatus int
+	}{
+		{"Signed With Key", signed, http.StatusOK},
+		{"HS256 Token", forged, http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", "/tasks", nil)
+			req.Header.Set("Authorization", "Bearer "+tt.token)
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+		})
+	}
+}
+
+func TestAuthMiddlewareWorkspace(t *testing.T) {
+	tokens := accessTokens{"tm_pat_member": {ID: 1, UserID: 7, Scopes: []string{"tasks:write"}}}
+	workspaces := memberships{3: {7: model.RoleViewer}}
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/tasks", middleware.AuthMiddleware(signing.NewHMAC("test-secret"), noRevocations{}, tokens, workspaces), func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"workspace_id": c.Value("workspaceID"), "role": c.Value("workspaceRole")})
+	})
+
+	tests := []struct {
+		name       string
+		header     string
+		wantStatus int
+		wantBody   string
+	}{
+		{"Personal Space", "", http.StatusOK, `{"workspace_id":null,"role":null}`},
+		{"Member", "3", http.StatusOK, `{"workspace_id":3,"role":"viewer"}`},
+		{"Not A Member", "4", http.StatusForbidden, `{"error":"not a member of this workspace"}`},
+		{"Invalid", "abc", http.StatusBadRequest, `{"error":"invalid X-Workspace-ID header"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", "/tasks", nil)
+			req.Header.Set("Authorization", "Bearer tm_pat_member")
+			if tt.header != "" {
+				req.Header.Set(middleware.WorkspaceHeader, tt.header)
+			}
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+			assert.JSONEq(t, tt.wantBody, w.Body.String())
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/ahmednurovic/task-manager-api/internal/authz"
+	"github.com/ahmednurovic/task-manager-api/internal/model"
+	"github.com/gin-gonic/gin"
+)
+
+// Principal returns who the request acts for and in which space, as
+// resolved by AuthMiddleware.
+func Principal(c *gin.Context) authz.Principal {
+	userID, _ := c.Get("userID")
+	principal := authz.Principal{}
+	principal.UserID, _ = userID.(uint)
+
+	if value, exists := c.Get("workspaceID"); exists {
+		workspaceID, _ := value.(uint)
+		principal.WorkspaceID = &workspaceID
+		role, _ := c.Get("workspaceRole")
+		principal.Role, _ = role.(model.WorkspaceRole)
+	}
+	return principal
+}
+
+// Authorize refuses requests whose role in the space they act in does not
+// allow resource:read, for reads, or resource:write, for anything else. It
+// must run after AuthMiddleware. Services still check each resource they
+// load, since the route alone does not say which space it is in.
+func Authorize(authorizer *authz.Authorizer, resource string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		action := authz.Action(resource + ":write")
+		switch c.Request.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			action = authz.Action(resource + ":read")
+		}
+
+		authorize(c, authorizer, action)
+	}
+}
+
+// AuthorizeAction is Authorize for routes that need one particular action
+// whatever their method.
+func AuthorizeAction(authorizer *authz.Authorizer, action authz.Action) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		authorize(c, authorizer, action)
+	}
+}
+
+func authorize(c *gin.Context, authorizer *authz.Authorizer, action authz.Action) {
+	principal := Principal(c)
+	if !authorizer.Can(principal, action, authz.Space(principal)) {
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+			"error": "your workspace role does not allow this",
+			"code":  "insufficient_role",
+		})
+		return
+	}
+
+	c.Next()
+}
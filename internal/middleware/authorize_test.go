@@ -0,0 +1,60 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+
+	"github.com/ahmednurovic/task-manager-api/internal/authz"
+	"github.com/ahmednurovic/task-manager-api/internal/middleware"
+	"github.com/ahmednurovic/task-manager-api/internal/model"
+)
+
+// TestAuthorizeAction checks that watching a task is open to every role,
+// viewers included, even though it is not a GET.
+func TestAuthorizeAction(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	authorizer := authz.New(authz.DefaultPolicy, zap.NewNop())
+
+	for _, method := range []string{"PUT", "DELETE"} {
+		for _, role := range []model.WorkspaceRole{model.RoleViewer, model.RoleMember, ""} {
+			t.Run(method+" As "+string(role), func(t *testing.T) {
+				router := gin.New()
+				router.Handle(method, "/tasks/:id/watch", func(c *gin.Context) {
+					c.Set("userID", uint(2))
+					c.Set("workspaceID", uint(3))
+					c.Set("workspaceRole", role)
+					c.Next()
+				}, middleware.AuthorizeAction(authorizer, authz.WatchTasks), func(c *gin.Context) {
+					c.Status(http.StatusOK)
+				})
+
+				w := httptest.NewRecorder()
+				router.ServeHTTP(w, httptest.NewRequest(method, "/tasks/10/watch", nil))
+
+				want := http.StatusOK
+				if role == "" {
+					want = http.StatusForbidden
+				}
+				assert.Equal(t, want, w.Code)
+			})
+		}
+	}
+}
+
+func TestPrincipal(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Set("userID", uint(2))
+	assert.Equal(t, authz.Principal{UserID: 2}, middleware.Principal(c))
+
+	workspaceID := uint(3)
+	c.Set("workspaceID", workspaceID)
+	c.Set("workspaceRole", model.RoleMember)
+	assert.Equal(t, authz.Principal{UserID: 2, WorkspaceID: &workspaceID, Role: model.RoleMember}, middleware.Principal(c))
+}
@@ -0,0 +1,30 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequireWritable refuses anything but reads from read-only tokens, which
+// are issued to users who have not verified their email address yet. It
+// must run after AuthMiddleware.
+func RequireWritable() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		switch c.Request.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			c.Next()
+			return
+		}
+
+		if c.GetBool("readOnly") {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+				"error": "verify your email address to make changes",
+				"code":  "email_not_verified",
+			})
+			return
+		}
+
+		c.Next()
+	}
+}
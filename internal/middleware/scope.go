@@ -0,0 +1,69 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// HasScope reports whether the request may use scope. Requests made with a
+// JWT may do anything; requests made with a personal access token may only
+// do what its scopes allow. A write scope includes the matching read scope.
+func HasScope(c *gin.Context, scope string) bool {
+	value, exists := c.Get("scopes")
+	if !exists {
+		return true
+	}
+
+	scopes, _ := value.([]string)
+	for _, granted := range scopes {
+		if granted == scope {
+			return true
+		}
+		if resource, ok := strings.CutSuffix(scope, ":read"); ok && granted == resource+":write" {
+			return true
+		}
+	}
+	return false
+}
+
+// RequireScope refuses requests whose personal access token lacks
+// resource:read, for reads, or resource:write, for anything else. It must
+// run after AuthMiddleware.
+func RequireScope(resource string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		scope := resource + ":write"
+		switch c.Request.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			scope = resource + ":read"
+		}
+
+		if !HasScope(c, scope) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+				"error": "token is missing the " + scope + " scope",
+				"code":  "insufficient_scope",
+			})
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// RequireSession refuses personal access tokens, for endpoints such as
+// token management that only a signed-in user should reach. It must run
+// after AuthMiddleware.
+func RequireSession() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, exists := c.Get("scopes"); exists {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+				"error": "personal access tokens cannot be used here",
+				"code":  "session_required",
+			})
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,48 @@
+package model
+
+import (
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// PersonalAccessTokenPrefix starts every personal access token, so they can
+// be told apart from JWTs and found by secret scanners.
+const PersonalAccessTokenPrefix = "tm_pat_"
+
+// Scopes a personal access token can be given. Each write scope includes
+// the matching read scope.
+const (
+	ScopeTasksRead     = "tasks:read"
+	ScopeTasksWrite    = "tasks:write"
+	ScopeLabelsRead    = "labels:read"
+	ScopeLabelsWrite   = "labels:write"
+	ScopeProjectsRead  = "projects:read"
+	ScopeProjectsWrite = "projects:write"
+)
+
+var Scopes = []string{
+	ScopeTasksRead, ScopeTasksWrite,
+	ScopeLabelsRead, ScopeLabelsWrite,
+	ScopeProjectsRead, ScopeProjectsWrite,
+}
+
+// PersonalAccessToken is a long-lived token for scripts and CI. Only the
+// SHA-256 hash of the token is kept.
+type PersonalAccessToken struct {
+	ID         uint           `json:"id" db:"id"`
+	UserID     uint           `json:"user_id" db:"user_id"`
+	Name       string         `json:"name" db:"name" example:"CI"`
+	TokenHash  string         `json:"-" db:"token_hash"`
+	Scopes     pq.StringArray `json:"scopes" db:"scopes" swaggertype:"array,string" example:"tasks:read,tasks:write"`
+	ExpiresAt  *time.Time     `json:"expires_at" db:"expires_at"`
+	LastUsedAt *time.Time     `json:"last_used_at" db:"last_used_at"`
+	CreatedAt  time.Time      `json:"created_at" db:"created_at"`
+	RevokedAt  *time.Time     `json:"-" db:"revoked_at"`
+	// TokenVersion is the user's token version when the token was made.
+	// The token stops working once the user's version moves past it.
+	TokenVersion int `json:"-" db:"token_version"`
+	// Token is only set in the response to creating the token. It cannot
+	// be seen again.
+	Token string `json:"token,omitempty" db:"-" example:"tm_pat_Vq3xK9mZ2pL7wR4tY8uN1bC6dF0hJ5sA3eG9iO2kM7q"`
+}
@@ -0,0 +1,32 @@
+package model
+
+import "time"
+
+type ActivityAction string
+
+const (
+	// ActivityAssigned records that SubjectID was made the assignee.
+	ActivityAssigned ActivityAction = "assigned"
+	// ActivityUnassigned records that SubjectID stopped being the assignee.
+	ActivityUnassigned ActivityAction = "unassigned"
+)
+
+// TaskActivity is one entry in a task's history. ActorID is the user who
+// made the change and SubjectID the user it concerns; either is nil once
+// that user has been deleted.
+type TaskActivity struct {
+	ID        uint           `json:"id" db:"id"`
+	TaskID    uint           `json:"task_id" db:"task_id"`
+	ActorID   *uint          `json:"actor_id" db:"actor_id"`
+	Action    ActivityAction `json:"action" db:"action"`
+	SubjectID *uint          `json:"subject_id" db:"subject_id"`
+	CreatedAt time.Time      `json:"created_at" db:"created_at"`
+}
+
+// TaskWatcher is a user following a task.
+type TaskWatcher struct {
+	TaskID    uint      `json:"task_id" db:"task_id"`
+	UserID    uint      `json:"user_id" db:"user_id"`
+	Email     string    `json:"email" db:"email"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
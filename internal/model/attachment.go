@@ -0,0 +1,25 @@
+package model
+
+import "time"
+
+// Attachment is a file attached to a task. ContentType is sniffed from the
+// file's contents rather than taken from the upload, and SHA256 is the hex
+// checksum of the stored bytes.
+type Attachment struct {
+	ID          uint      `json:"id" db:"id"`
+	TaskID      uint      `json:"task_id" db:"task_id"`
+	UploaderID  *uint     `json:"uploader_id" db:"uploader_id"`
+	Filename    string    `json:"filename" db:"filename"`
+	ContentType string    `json:"content_type" db:"content_type"`
+	Size        int64     `json:"size" db:"size"`
+	SHA256      string    `json:"sha256" db:"sha256"`
+	StorageKey  string    `json:"-" db:"storage_key"`
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+}
+
+// AttachmentURL is a signed link that downloads an attachment without
+// further authentication until ExpiresAt.
+type AttachmentURL struct {
+	URL       string    `json:"url"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
@@ -0,0 +1,13 @@
+package model
+
+import "time"
+
+type ChecklistItem struct {
+	ID        uint      `json:"id" db:"id"`
+	TaskID    uint      `json:"task_id" db:"task_id"`
+	Title     string    `json:"title" db:"title"`
+	Done      bool      `json:"done" db:"done"`
+	Position  int       `json:"position" db:"position"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+}
@@ -0,0 +1,40 @@
+package model
+
+import "time"
+
+// Comment is a markdown comment on a task. AuthorID is nil once the author
+// has been deleted. Deleted comments are kept, with their history, but are
+// no longer listed or counted.
+type Comment struct {
+	ID        uint       `json:"id" db:"id"`
+	TaskID    uint       `json:"task_id" db:"task_id"`
+	AuthorID  *uint      `json:"author_id" db:"author_id"`
+	Body      string     `json:"body" db:"body"`
+	Mentions  []Mention  `json:"mentions" db:"-"`
+	Edited    bool       `json:"edited" db:"edited"`
+	CreatedAt time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at" db:"updated_at"`
+	EditedAt  *time.Time `json:"edited_at" db:"edited_at"`
+}
+
+// Mention is a user @mentioned in a comment.
+type Mention struct {
+	UserID uint   `json:"user_id" db:"user_id"`
+	Email  string `json:"email" db:"email"`
+}
+
+// CommentRevision is a body a comment had before one of its edits.
+type CommentRevision struct {
+	ID        uint      `json:"id" db:"id"`
+	CommentID uint      `json:"comment_id" db:"comment_id"`
+	Body      string    `json:"body" db:"body"`
+	EditorID  *uint     `json:"editor_id" db:"editor_id"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+// CommentPage is one page of a task's comments, oldest first. NextCursor
+// is empty on the last page.
+type CommentPage struct {
+	Items      []*Comment `json:"items"`
+	NextCursor string     `json:"next_cursor"`
+}
@@ -0,0 +1,30 @@
+package model
+
+// TaskRef is the short form of a task used in dependency listings.
+type TaskRef struct {
+	ID     uint       `json:"id" db:"id"`
+	Title  string     `json:"title" db:"title"`
+	Status TaskStatus `json:"status" db:"status"`
+}
+
+// TaskDependencies lists the tasks that block a task and the tasks it
+// blocks in turn.
+type TaskDependencies struct {
+	TaskID    uint      `json:"task_id"`
+	BlockedBy []TaskRef `json:"blocked_by"`
+	Blocks    []TaskRef `json:"blocks"`
+}
+
+// DependencyEdge says that TaskID is blocked by BlockerID.
+type DependencyEdge struct {
+	TaskID    uint `json:"task_id" db:"task_id"`
+	BlockerID uint `json:"blocker_id" db:"blocker_id"`
+}
+
+// TaskGraph is the task together with everything it transitively depends
+// on. Nodes include the root task itself.
+type TaskGraph struct {
+	RootID uint             `json:"root_id"`
+	Nodes  []TaskRef        `json:"nodes"`
+	Edges  []DependencyEdge `json:"edges"`
+}
@@ -0,0 +1,20 @@
+package model
+
+import "time"
+
+type Label struct {
+	ID        uint      `json:"id" db:"id"`
+	UserID    uint      `json:"user_id" db:"user_id"`
+	Name      string    `json:"name" db:"name"`
+	Color     string    `json:"color" db:"color"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+// LabelMatch decides whether a task must carry any or all of the labels in
+// a TaskFilter.
+type LabelMatch string
+
+const (
+	LabelMatchAny LabelMatch = "any"
+	LabelMatchAll LabelMatch = "all"
+)
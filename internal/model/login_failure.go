@@ -0,0 +1,19 @@
+package model
+
+import "time"
+
+// Scopes failed logins are counted in.
+const (
+	LoginScopeAccount = "account"
+	LoginScopeIP      = "ip"
+)
+
+// LoginFailure counts the failed logins for one account or IP address
+// since WindowStartedAt.
+type LoginFailure struct {
+	Scope           string     `db:"scope"`
+	Identifier      string     `db:"identifier"`
+	Failures        int        `db:"failures"`
+	WindowStartedAt time.Time  `db:"window_started_at"`
+	LockedUntil     *time.Time `db:"locked_until"`
+}
@@ -0,0 +1,31 @@
+package model
+
+import "time"
+
+// ProjectCounts summarises the tasks in a project. Open tasks are those that
+// are neither done nor cancelled.
+type ProjectCounts struct {
+	OpenCount int `json:"open_count" db:"open_count"`
+	DoneCount int `json:"done_count" db:"done_count"`
+}
+
+type Project struct {
+	ID          uint      `json:"id" db:"id"`
+	UserID      uint      `json:"user_id" db:"user_id"`
+	WorkspaceID *uint     `json:"workspace_id" db:"workspace_id"`
+	Name        string    `json:"name" db:"name"`
+	Description string    `json:"description" db:"description"`
+	Color       string    `json:"color" db:"color"`
+	Archived    bool      `json:"archived" db:"archived"`
+	Position    int       `json:"position" db:"position"`
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at" db:"updated_at"`
+	ProjectCounts
+}
+
+// ProjectList is the projects of a user or workspace together with the
+// implicit inbox that holds tasks without a project.
+type ProjectList struct {
+	Inbox ProjectCounts `json:"inbox"`
+	Items []*Project    `json:"items"`
+}
@@ -1,8 +1,205 @@
 package model
 
+import "time"
+
+type TaskStatus string
+
+const (
+	StatusPending    TaskStatus = "pending"
+	StatusInProgress TaskStatus = "in_progress"
+	StatusBlocked    TaskStatus = "blocked"
+	StatusDone       TaskStatus = "done"
+	StatusCancelled  TaskStatus = "cancelled"
+)
+
+// taskTransitions lists, for every status, the statuses a task may move to
+// next. Moving to the current status is always allowed and not listed here.
+var taskTransitions = map[TaskStatus][]TaskStatus{
+	StatusPending:    {StatusInProgress, StatusBlocked, StatusDone, StatusCancelled},
+	StatusInProgress: {StatusPending, StatusBlocked, StatusDone, StatusCancelled},
+	StatusBlocked:    {StatusPending, StatusInProgress, StatusCancelled},
+	StatusDone:       {StatusPending, StatusInProgress},
+	StatusCancelled:  {StatusPending},
+}
+
+func (s TaskStatus) Valid() bool {
+	_, ok := taskTransitions[s]
+	return ok
+}
+
+// AllowedTransitions returns the statuses a task in status s may move to.
+func (s TaskStatus) AllowedTransitions() []TaskStatus {
+	allowed := make([]TaskStatus, len(taskTransitions[s]))
+	copy(allowed, taskTransitions[s])
+	return allowed
+}
+
+func (s TaskStatus) CanTransitionTo(next TaskStatus) bool {
+	if s == next {
+		return s.Valid()
+	}
+	for _, status := range taskTransitions[s] {
+		if status == next {
+			return true
+		}
+	}
+	return false
+}
+
+// Open reports whether work on a task with this status is still
+// outstanding.
+func (s TaskStatus) Open() bool {
+	return s != StatusDone && s != StatusCancelled
+}
+
+type TaskPriority string
+
+const (
+	PriorityLow    TaskPriority = "low"
+	PriorityMedium TaskPriority = "medium"
+	PriorityHigh   TaskPriority = "high"
+	PriorityUrgent TaskPriority = "urgent"
+)
+
+func (p TaskPriority) Valid() bool {
+	switch p {
+	case PriorityLow, PriorityMedium, PriorityHigh, PriorityUrgent:
+		return true
+	}
+	return false
+}
+
+// RecurrenceMode picks what the next occurrence of a recurring task is
+// scheduled from.
+type RecurrenceMode string
+
+const (
+	// RecurFromSchedule keeps to the original series: the next occurrence
+	// is the first one after the completed task's due date.
+	RecurFromSchedule RecurrenceMode = "schedule"
+	// RecurFromCompletion restarts the series on the day the task was
+	// completed.
+	RecurFromCompletion RecurrenceMode = "completion"
+)
+
+func (m RecurrenceMode) Valid() bool {
+	return m == RecurFromSchedule || m == RecurFromCompletion
+}
+
 type Task struct {
-	ID     uint   `json:"id" db:"id"`
-	UserID uint   `json:"user_id" db:"user_id"`
-	Title  string `json:"title" db:"title"`
-	Status string `json:"status" db:"status"`
-}
\ No newline at end of file
+	ID          uint         `json:"id" db:"id"`
+	UserID      uint         `json:"user_id" db:"user_id"`
+	WorkspaceID *uint        `json:"workspace_id" db:"workspace_id"`
+	AssigneeID  *uint        `json:"assignee_id" db:"assignee_id"`
+	ProjectID   *uint        `json:"project_id" db:"project_id"`
+	ParentID    *uint        `json:"parent_id" db:"parent_id"`
+	Title       string       `json:"title" db:"title"`
+	Description string       `json:"description" db:"description"`
+	Status      TaskStatus   `json:"status" db:"status"`
+	Priority    TaskPriority `json:"priority" db:"priority"`
+	DueAt       *time.Time   `json:"due_at" db:"due_at"`
+	CreatedAt   time.Time    `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time    `json:"updated_at" db:"updated_at"`
+	CompletedAt *time.Time   `json:"completed_at" db:"completed_at"`
+	Labels      []Label      `json:"labels" db:"-"`
+	Progress    TaskProgress `json:"progress" db:"-"`
+	// CommentCount leaves out deleted comments.
+	CommentCount int `json:"comment_count" db:"-"`
+
+	// RecurrenceRule is an RFC 5545 RRULE evaluated in RecurrenceTZ. It is
+	// empty for one-off tasks.
+	RecurrenceRule   string         `json:"recurrence_rule" db:"recurrence_rule"`
+	RecurrenceTZ     string         `json:"recurrence_tz" db:"recurrence_tz"`
+	RecurrenceMode   RecurrenceMode `json:"recurrence_mode" db:"recurrence_mode"`
+	RecurrenceStart  *time.Time     `json:"recurrence_start" db:"recurrence_start"`
+	Occurrence       int            `json:"occurrence" db:"occurrence"`
+	NextOccurrenceID *uint          `json:"next_occurrence_id" db:"next_occurrence_id"`
+}
+
+// TaskProgress counts the completed direct subtasks and checklist items of a
+// task. Cancelled subtasks are left out of both counts.
+type TaskProgress struct {
+	SubtasksDone   int `json:"subtasks_done"`
+	SubtasksTotal  int `json:"subtasks_total"`
+	ChecklistDone  int `json:"checklist_done"`
+	ChecklistTotal int `json:"checklist_total"`
+}
+
+// TaskTransitions describes where a task can move from its current status.
+type TaskTransitions struct {
+	TaskID  uint         `json:"task_id"`
+	Status  TaskStatus   `json:"status"`
+	Allowed []TaskStatus `json:"allowed"`
+}
+
+type TaskSortField string
+
+const (
+	SortByCreatedAt TaskSortField = "created_at"
+	SortByUpdatedAt TaskSortField = "updated_at"
+	SortByDueAt     TaskSortField = "due_at"
+	SortByPriority  TaskSortField = "priority"
+	SortByTitle     TaskSortField = "title"
+)
+
+func (f TaskSortField) Valid() bool {
+	switch f {
+	case SortByCreatedAt, SortByUpdatedAt, SortByDueAt, SortByPriority, SortByTitle:
+		return true
+	}
+	return false
+}
+
+type SortOrder string
+
+const (
+	SortAsc  SortOrder = "asc"
+	SortDesc SortOrder = "desc"
+)
+
+// TaskFilter selects and orders a page of tasks. Cursor is the opaque
+// NextCursor of a previous page requested with the same sort and order.
+// InboxOnly selects tasks without a project and excludes ProjectID.
+// ParentID selects the direct subtasks of a task. WorkspaceID lists a
+// workspace's tasks instead of the user's personal ones. AssignedToMe and
+// CreatedByMe keep only tasks assigned to or created by the listing user.
+type TaskFilter struct {
+	ProjectID  *uint
+	InboxOnly  bool
+	ParentID   *uint
+	Statuses   []TaskStatus
+	Priorities []TaskPriority
+	DueAfter   *time.Time
+	DueBefore  *time.Time
+	Title      string
+	LabelIDs   []uint
+	LabelMatch LabelMatch
+	Sort       TaskSortField
+	Order      SortOrder
+	Cursor     string
+	Limit      int
+
+	WorkspaceID  *uint
+	AssignedToMe bool
+	CreatedByMe  bool
+}
+
+// TaskPage is one page of a task listing. NextCursor is empty on the last
+// page.
+type TaskPage struct {
+	Items      []*Task `json:"items"`
+	NextCursor string  `json:"next_cursor"`
+}
+
+// TaskSearchResult is a task matched by full-text search. TitleHighlight
+// and Snippet wrap matched words in <mark> tags.
+type TaskSearchResult struct {
+	Task           *Task   `json:"task"`
+	Rank           float64 `json:"rank"`
+	TitleHighlight string  `json:"title_highlight"`
+	Snippet        string  `json:"snippet"`
+}
+
+type TaskSearchResults struct {
+	Items []*TaskSearchResult `json:"items"`
+}
@@ -0,0 +1,33 @@
+package model
+
+import "time"
+
+// RefreshToken is the stored form of an opaque refresh token. Only the
+// SHA-256 hash of the token is kept.
+type RefreshToken struct {
+	ID        uint       `db:"id"`
+	UserID    uint       `db:"user_id"`
+	FamilyID  string     `db:"family_id"`
+	TokenHash string     `db:"token_hash"`
+	ExpiresAt time.Time  `db:"expires_at"`
+	CreatedAt time.Time  `db:"created_at"`
+	UsedAt    *time.Time `db:"used_at"`
+	RevokedAt *time.Time `db:"revoked_at"`
+}
+
+// TokenPair is what a successful login or refresh hands back to the client.
+type TokenPair struct {
+	AccessToken  string `json:"access_token,omitempty"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	// ExpiresAt is when the access token expires, or the MFA token when
+	// MFARequired is set.
+	ExpiresAt time.Time `json:"expires_at"`
+	// ReadOnly is set when the access token only allows reads because the
+	// user's email address is not verified yet.
+	ReadOnly bool `json:"read_only,omitempty"`
+	// MFARequired is set instead of the tokens when the user has two-factor
+	// authentication on. MFAToken is then exchanged for the tokens together
+	// with a code.
+	MFARequired bool   `json:"mfa_required,omitempty"`
+	MFAToken    string `json:"mfa_token,omitempty"`
+}
@@ -1,7 +1,23 @@
 package model
 
+import "time"
+
 type User struct {
-	ID       uint   `json:"id" db:"id"`
-	Email    string `json:"email" db:"email"`
-	Password string `json:"-" db:"password"`
-}
\ No newline at end of file
+	ID              uint       `json:"id" db:"id"`
+	Email           string     `json:"email" db:"email"`
+	Password        string     `json:"-" db:"password"`
+	EmailVerifiedAt *time.Time `json:"email_verified_at,omitempty" db:"email_verified_at"`
+	// MFASecret is the base32 TOTP secret, set from the start of enrolment.
+	// Two-factor authentication is only required once MFAEnabledAt is set.
+	MFASecret    *string    `json:"-" db:"mfa_secret"`
+	MFAEnabledAt *time.Time `json:"mfa_enabled_at,omitempty" db:"mfa_enabled_at"`
+	// IsAdmin is set directly in the database by an operator.
+	IsAdmin bool `json:"is_admin,omitempty" db:"is_admin"`
+}
+
+// MFAEnrollment is what a user needs to add their account to an
+// authenticator app.
+type MFAEnrollment struct {
+	Secret string `json:"secret" example:"JBSWY3DPEHPK3PXPJBSWY3DPEHPK3PXP"`
+	URI    string `json:"otpauth_uri" example:"otpauth://totp/Task%20Manager:user@example.com?issuer=Task%20Manager&secret=JBSWY3DPEHPK3PXPJBSWY3DPEHPK3PXP"`
+}
@@ -0,0 +1,15 @@
+package model
+
+import "time"
+
+// UserIdentity links a user to an account at an OpenID Connect provider,
+// which is identified by the provider's subject, never by email.
+type UserIdentity struct {
+	ID       uint   `json:"id" db:"id"`
+	UserID   uint   `json:"user_id" db:"user_id"`
+	Provider string `json:"provider" db:"provider"`
+	Subject  string `json:"subject" db:"subject"`
+	// Email is the address the provider gave when the identity was linked.
+	Email     string    `json:"email" db:"email"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
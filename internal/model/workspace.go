@@ -0,0 +1,78 @@
+package model
+
+import "time"
+
+// WorkspaceRole is what a member may do in a workspace. Each role can do
+// everything the roles below it can.
+type WorkspaceRole string
+
+const (
+	// RoleViewer can read the workspace's projects and tasks.
+	RoleViewer WorkspaceRole = "viewer"
+	// RoleMember can also create, change and delete tasks.
+	RoleMember WorkspaceRole = "member"
+	// RoleAdmin can also manage projects, invite members and change their
+	// roles.
+	RoleAdmin WorkspaceRole = "admin"
+	// RoleOwner can also rename or delete the workspace and appoint other
+	// owners.
+	RoleOwner WorkspaceRole = "owner"
+)
+
+var workspaceRoleRanks = map[WorkspaceRole]int{
+	RoleViewer: 1,
+	RoleMember: 2,
+	RoleAdmin:  3,
+	RoleOwner:  4,
+}
+
+func (r WorkspaceRole) Valid() bool {
+	_, ok := workspaceRoleRanks[r]
+	return ok
+}
+
+// AtLeast reports whether r grants everything other does. An unknown role
+// grants nothing.
+func (r WorkspaceRole) AtLeast(other WorkspaceRole) bool {
+	rank, ok := workspaceRoleRanks[r]
+	return ok && rank >= workspaceRoleRanks[other]
+}
+
+// Workspace is shared by its members. Role is the role of the user it was
+// loaded for.
+type Workspace struct {
+	ID        uint          `json:"id" db:"id"`
+	Name      string        `json:"name" db:"name"`
+	Role      WorkspaceRole `json:"role" db:"role"`
+	CreatedAt time.Time     `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time     `json:"updated_at" db:"updated_at"`
+}
+
+type WorkspaceMember struct {
+	WorkspaceID uint          `json:"workspace_id" db:"workspace_id"`
+	UserID      uint          `json:"user_id" db:"user_id"`
+	Email       string        `json:"email" db:"email"`
+	Role        WorkspaceRole `json:"role" db:"role"`
+	JoinedAt    time.Time     `json:"joined_at" db:"joined_at"`
+}
+
+// WorkspaceInvitation asks the owner of Email to join a workspace. Only
+// the SHA-256 hash of its token is stored; the token itself is emailed.
+type WorkspaceInvitation struct {
+	ID          uint          `json:"id" db:"id"`
+	WorkspaceID uint          `json:"workspace_id" db:"workspace_id"`
+	Email       string        `json:"email" db:"email"`
+	Role        WorkspaceRole `json:"role" db:"role"`
+	TokenHash   string        `json:"-" db:"token_hash"`
+	InvitedBy   uint          `json:"invited_by" db:"invited_by"`
+	ExpiresAt   time.Time     `json:"expires_at" db:"expires_at"`
+	AcceptedAt  *time.Time    `json:"accepted_at" db:"accepted_at"`
+	DeclinedAt  *time.Time    `json:"declined_at" db:"declined_at"`
+	CreatedAt   time.Time     `json:"created_at" db:"created_at"`
+}
+
+// Pending reports whether the invitation can still be accepted or
+// declined.
+func (i *WorkspaceInvitation) Pending(now time.Time) bool {
+	return i.AcceptedAt == nil && i.DeclinedAt == nil && now.Before(i.ExpiresAt)
+}
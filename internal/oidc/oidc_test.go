@@ -0,0 +1,133 @@
+package oidc_test
+
+import (
+	"context"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ahmednurovic/task-manager-api/internal/oidc"
+	"github.com/ahmednurovic/task-manager-api/internal/oidc/oidctest"
+)
+
+// login runs the authorization code flow against idp and returns the
+// result of the exchange.
+func login(t *testing.T, idp *oidctest.Server, provider *oidc.Provider) (*oidc.Claims, error) {
+	verifier, err := oidc.NewVerifier()
+	assert.NoError(t, err)
+
+	authURL, err := provider.AuthCodeURL(context.Background(), "state-value", "nonce-value", verifier)
+	assert.NoError(t, err)
+	code, state := idp.Authorize(t, authURL)
+	assert.Equal(t, "state-value", state)
+
+	return provider.Exchange(context.Background(), code, verifier, "nonce-value")
+}
+
+func TestChallenge(t *testing.T) {
+	// RFC 7636 appendix B.
+	assert.Equal(t, "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM", oidc.Challenge("dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"))
+}
+
+func TestAuthCodeURL(t *testing.T) {
+	idp := oidctest.NewServer(t)
+	provider := oidc.NewProvider(idp.Config(), nil)
+
+	authURL, err := provider.AuthCodeURL(context.Background(), "state-value", "nonce-value", "verifier")
+	assert.NoError(t, err)
+
+	parsed, err := url.Parse(authURL)
+	assert.NoError(t, err)
+	assert.Equal(t, idp.URL+"/authorize", parsed.Scheme+"://"+parsed.Host+parsed.Path)
+	assert.Equal(t, url.Values{
+		"response_type":         {"code"},
+		"client_id":             {oidctest.ClientID},
+		"redirect_uri":          {oidctest.RedirectURL},
+		"scope":                 {"openid email"},
+		"state":                 {"state-value"},
+		"nonce":                 {"nonce-value"},
+		"code_challenge":        {oidc.Challenge("verifier")},
+		"code_challenge_method": {"S256"},
+	}, parsed.Query())
+}
+
+func TestExchange(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		idp := oidctest.NewServer(t)
+		idp.SetIdentity(oidctest.Identity{Subject: "abc", Email: "sso@example.com", EmailVerified: true})
+
+		claims, err := login(t, idp, oidc.NewProvider(idp.Config(), nil))
+
+		assert.NoError(t, err)
+		assert.Equal(t, "abc", claims.Subject)
+		assert.Equal(t, "sso@example.com", claims.Email)
+		assert.True(t, claims.EmailVerified)
+	})
+
+	t.Run("Wrong Verifier", func(t *testing.T) {
+		idp := oidctest.NewServer(t)
+		provider := oidc.NewProvider(idp.Config(), nil)
+
+		authURL, err := provider.AuthCodeURL(context.Background(), "state", "nonce", "verifier")
+		assert.NoError(t, err)
+		code, _ := idp.Authorize(t, authURL)
+
+		_, err = provider.Exchange(context.Background(), code, "other-verifier", "nonce")
+		assert.ErrorIs(t, err, oidc.ErrExchange)
+	})
+
+	t.Run("Wrong Nonce", func(t *testing.T) {
+		idp := oidctest.NewServer(t)
+		provider := oidc.NewProvider(idp.Config(), nil)
+
+		authURL, err := provider.AuthCodeURL(context.Background(), "state", "nonce", "verifier")
+		assert.NoError(t, err)
+		code, _ := idp.Authorize(t, authURL)
+
+		_, err = provider.Exchange(context.Background(), code, "verifier", "other-nonce")
+		assert.ErrorIs(t, err, oidc.ErrInvalidIDToken)
+	})
+
+	t.Run("Wrong Client Secret", func(t *testing.T) {
+		idp := oidctest.NewServer(t)
+		config := idp.Config()
+		config.ClientSecret = "wrong"
+
+		_, err := login(t, idp, oidc.NewProvider(config, nil))
+		assert.ErrorIs(t, err, oidc.ErrExchange)
+	})
+
+	t.Run("Wrong Audience", func(t *testing.T) {
+		idp := oidctest.NewServer(t)
+		idp.Audience = "another-client"
+
+		_, err := login(t, idp, oidc.NewProvider(idp.Config(), nil))
+		assert.ErrorIs(t, err, oidc.ErrInvalidIDToken)
+	})
+
+	t.Run("Expired", func(t *testing.T) {
+		idp := oidctest.NewServer(t)
+		idp.TokenTTL = -time.Hour
+
+		_, err := login(t, idp, oidc.NewProvider(idp.Config(), nil))
+		assert.ErrorIs(t, err, oidc.ErrInvalidIDToken)
+	})
+
+	t.Run("Signed By Another Provider", func(t *testing.T) {
+		idp := oidctest.NewServer(t)
+		idp.Forger = oidctest.NewServer(t).Keys
+
+		_, err := login(t, idp, oidc.NewProvider(idp.Config(), nil))
+		assert.ErrorIs(t, err, oidc.ErrInvalidIDToken)
+	})
+
+	t.Run("Issuer Mismatch", func(t *testing.T) {
+		idp := oidctest.NewServer(t)
+		idp.Issuer = "https://impostor.example.com"
+
+		_, err := oidc.NewProvider(idp.Config(), nil).AuthCodeURL(context.Background(), "state", "nonce", "verifier")
+		assert.ErrorContains(t, err, "does not match")
+	})
+}
@@ -0,0 +1,241 @@
+// Package oidctest runs an in-process OpenID Connect provider for tests.
+// It signs users in without asking anything: each authorization request is
+// answered straight away for the identity set with SetIdentity.
+package oidctest
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/subtle"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ahmednurovic/task-manager-api/internal/oidc"
+	"github.com/ahmednurovic/task-manager-api/internal/signing"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	ClientID     = "test-client"
+	ClientSecret = "test-client-secret"
+	RedirectURL  = "http://app.test/api/v1/auth/oidc/test/callback"
+)
+
+// Identity is the user the provider signs in.
+type Identity struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+}
+
+type grant struct {
+	identity    Identity
+	redirectURL string
+	challenge   string
+	nonce       string
+}
+
+// Server is a fake provider. Its fields may be changed between requests to
+// make it misbehave.
+type Server struct {
+	*httptest.Server
+	// Keys are published at the jwks_uri and sign ID tokens.
+	Keys *signing.KeySet
+	// Forger, when set, signs ID tokens instead of Keys.
+	Forger *signing.KeySet
+	// Issuer is what the provider claims to be, in its metadata and in ID
+	// tokens. It defaults to the server's URL.
+	Issuer string
+	// Audience defaults to ClientID.
+	Audience string
+	// TokenTTL defaults to five minutes; a negative TTL issues expired
+	// tokens.
+	TokenTTL time.Duration
+
+	mu       sync.Mutex
+	identity Identity
+	grants   map[string]grant
+}
+
+// NewServer starts a provider, signing with a fresh Ed25519 key, that is
+// closed when the test ends.
+func NewServer(t *testing.T) *Server {
+	t.Helper()
+
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys, err := signing.New(signing.EdDSA, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := &Server{
+		Keys:     keys,
+		Audience: ClientID,
+		TokenTTL: 5 * time.Minute,
+		identity: Identity{Subject: "user-1", Email: "user@example.com", EmailVerified: true},
+		grants:   make(map[string]grant),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /.well-known/openid-configuration", s.metadata)
+	mux.HandleFunc("GET /authorize", s.authorize)
+	mux.HandleFunc("POST /token", s.token)
+	mux.HandleFunc("GET /jwks", s.jwks)
+	s.Server = httptest.NewServer(mux)
+	s.Issuer = s.URL
+	t.Cleanup(s.Close)
+
+	return s
+}
+
+// Config is the configuration of a client registered with the provider.
+func (s *Server) Config() oidc.Config {
+	return oidc.Config{
+		Issuer:       s.URL,
+		ClientID:     ClientID,
+		ClientSecret: ClientSecret,
+		RedirectURL:  RedirectURL,
+		Scopes:       []string{"email"},
+	}
+}
+
+// SetIdentity sets who the next authorization requests sign in.
+func (s *Server) SetIdentity(identity Identity) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.identity = identity
+}
+
+// Authorize follows authURL as a browser would and returns the code and
+// state the provider redirects back with.
+func (s *Server) Authorize(t *testing.T, authURL string) (code, state string) {
+	t.Helper()
+
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+	resp, err := client.Get(authURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusFound {
+		t.Fatalf("authorize returned %s", resp.Status)
+	}
+
+	location, err := url.Parse(resp.Header.Get("Location"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return location.Query().Get("code"), location.Query().Get("state")
+}
+
+func (s *Server) metadata(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{
+		"issuer":                 s.Issuer,
+		"authorization_endpoint": s.URL + "/authorize",
+		"token_endpoint":         s.URL + "/token",
+		"jwks_uri":               s.URL + "/jwks",
+	})
+}
+
+func (s *Server) authorize(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	if query.Get("client_id") != ClientID || query.Get("redirect_uri") != RedirectURL ||
+		query.Get("response_type") != "code" || query.Get("code_challenge_method") != "S256" ||
+		query.Get("code_challenge") == "" {
+		http.Error(w, "invalid authorization request", http.StatusBadRequest)
+		return
+	}
+
+	code, err := oidc.NewVerifier()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	s.mu.Lock()
+	s.grants[code] = grant{
+		identity:    s.identity,
+		redirectURL: query.Get("redirect_uri"),
+		challenge:   query.Get("code_challenge"),
+		nonce:       query.Get("nonce"),
+	}
+	s.mu.Unlock()
+
+	redirect := url.Values{"code": {code}, "state": {query.Get("state")}}
+	http.Redirect(w, r, RedirectURL+"?"+redirect.Encode(), http.StatusFound)
+}
+
+func (s *Server) token(w http.ResponseWriter, r *http.Request) {
+	clientID, clientSecret, _ := r.BasicAuth()
+	if clientID != ClientID || subtle.ConstantTimeCompare([]byte(clientSecret), []byte(ClientSecret)) != 1 {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid_client"})
+		return
+	}
+
+	code := r.PostFormValue("code")
+	s.mu.Lock()
+	g, ok := s.grants[code]
+	delete(s.grants, code)
+	s.mu.Unlock()
+
+	if !ok || r.PostFormValue("grant_type") != "authorization_code" ||
+		r.PostFormValue("redirect_uri") != g.redirectURL ||
+		oidc.Challenge(r.PostFormValue("code_verifier")) != g.challenge {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+		return
+	}
+
+	keys := s.Keys
+	if s.Forger != nil {
+		keys = s.Forger
+	}
+
+	now := time.Now()
+	idToken, err := keys.Sign(jwt.MapClaims{
+		"iss":            s.Issuer,
+		"aud":            s.Audience,
+		"sub":            g.identity.Subject,
+		"iat":            now.Unix(),
+		"exp":            now.Add(s.TokenTTL).Unix(),
+		"nonce":          g.nonce,
+		"email":          g.identity.Email,
+		"email_verified": g.identity.EmailVerified,
+	})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"access_token": "provider-access-token",
+		"token_type":   "Bearer",
+		"expires_in":   300,
+		"id_token":     idToken,
+	})
+}
+
+func (s *Server) jwks(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.Keys.JWKS())
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
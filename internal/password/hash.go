@@ -0,0 +1,222 @@
+// Package password hashes passwords for storage and decides which
+// passwords are acceptable.
+//
+// Hashes are stored as strings that name their algorithm and parameters,
+// in the PHC string format for argon2id
+// ($argon2id$v=19$m=65536,t=3,p=2$salt$hash) and in bcrypt's own
+// $2a$cost$... format, which predates PHC and which every bcrypt library
+// reads. A stored hash can therefore always be checked, even after the
+// configured algorithm or parameters have changed, and be recognised as
+// out of date.
+package password
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var (
+	// ErrMismatch is returned by Verify when the password is wrong.
+	ErrMismatch = errors.New("password does not match")
+	// ErrUnknownHash is returned by Verify for a hash it cannot read.
+	ErrUnknownHash = errors.New("unknown password hash format")
+)
+
+// Hasher hashes passwords and checks them against stored hashes.
+type Hasher interface {
+	// Hash returns the hash to store for a password.
+	Hash(password string) (string, error)
+	// Verify returns nil if password matches hash, ErrMismatch if it
+	// does not, and ErrUnknownHash if the hash is not one this Hasher
+	// reads.
+	Verify(password, hash string) error
+	// NeedsRehash reports whether the hash was made with another algorithm
+	// or other parameters than Hash now uses, and should be replaced.
+	NeedsRehash(hash string) bool
+}
+
+// Argon2id hashes with argon2id, the variant RFC 9106 recommends for
+// passwords.
+type Argon2id struct {
+	// Memory is in KiB.
+	Memory      uint32
+	Iterations  uint32
+	Parallelism uint8
+	SaltLength  uint32
+	KeyLength   uint32
+}
+
+// DefaultArgon2id are the parameters of RFC 9106's second recommended
+// option, with less parallelism: 64 MiB and three passes take in the
+// order of 50ms on a typical server core.
+var DefaultArgon2id = Argon2id{
+	Memory:      64 * 1024,
+	Iterations:  3,
+	Parallelism: 2,
+	SaltLength:  16,
+	KeyLength:   32,
+}
+
+const argon2idPrefix = "$argon2id$"
+
+func (a Argon2id) Hash(password string) (string, error) {
+	salt := make([]byte, a.SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+
+	key := argon2.IDKey([]byte(password), salt, a.Iterations, a.Memory, a.Parallelism, a.KeyLength)
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version, a.Memory, a.Iterations, a.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	), nil
+}
+
+func (a Argon2id) Verify(password, hash string) error {
+	params, salt, key, err := parseArgon2id(hash)
+	if err != nil {
+		return err
+	}
+
+	other := argon2.IDKey([]byte(password), salt, params.Iterations, params.Memory, params.Parallelism, uint32(len(key)))
+	if subtle.ConstantTimeCompare(key, other) != 1 {
+		return ErrMismatch
+	}
+	return nil
+}
+
+func (a Argon2id) NeedsRehash(hash string) bool {
+	params, _, _, err := parseArgon2id(hash)
+	return err != nil || params != a
+}
+
+// parseArgon2id reads a PHC string. The parameters it returns include the
+// lengths of the salt and key.
+func parseArgon2id(hash string) (Argon2id, []byte, []byte, error) {
+	var params Argon2id
+	if !strings.HasPrefix(hash, argon2idPrefix) {
+		return params, nil, nil, ErrUnknownHash
+	}
+
+	// "", "argon2id", "v=19", "m=...,t=...,p=...", salt, key
+	fields := strings.Split(hash, "$")
+	if len(fields) != 6 {
+		return params, nil, nil, ErrUnknownHash
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(fields[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return params, nil, nil, ErrUnknownHash
+	}
+	if _, err := fmt.Sscanf(fields[3], "m=%d,t=%d,p=%d", &params.Memory, &params.Iterations, &params.Parallelism); err != nil {
+		return params, nil, nil, ErrUnknownHash
+	}
+	if params.Memory == 0 || params.Iterations == 0 || params.Parallelism == 0 {
+		return params, nil, nil, ErrUnknownHash
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(fields[4])
+	if err != nil {
+		return params, nil, nil, ErrUnknownHash
+	}
+	key, err := base64.RawStdEncoding.DecodeString(fields[5])
+	if err != nil || len(key) == 0 {
+		return params, nil, nil, ErrUnknownHash
+	}
+
+	params.SaltLength = uint32(len(salt))
+	params.KeyLength = uint32(len(key))
+	return params, salt, key, nil
+}
+
+// Bcrypt hashes with bcrypt. Passwords longer than 72 bytes cannot be
+// hashed with it; Hash returns ErrTooLong for them.
+type Bcrypt struct {
+	Cost int
+}
+
+// DefaultBcrypt is the cost bcrypt itself defaults to.
+var DefaultBcrypt = Bcrypt{Cost: bcrypt.DefaultCost}
+
+const bcryptMaxLength = 72
+
+func (b Bcrypt) Hash(password string) (string, error) {
+	if len(password) > bcryptMaxLength {
+		return "", fmt.Errorf("%w: bcrypt takes at most %d bytes", ErrTooLong, bcryptMaxLength)
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), b.Cost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+func (b Bcrypt) Verify(password, hash string) error {
+	if _, err := bcrypt.Cost([]byte(hash)); err != nil {
+		return ErrUnknownHash
+	}
+
+	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+	if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+		return ErrMismatch
+	}
+	return err
+}
+
+func (b Bcrypt) NeedsRehash(hash string) bool {
+	cost, err := bcrypt.Cost([]byte(hash))
+	return err != nil || cost != b.Cost
+}
+
+// Chain hashes with current, and verifies hashes made by current or any of
+// legacy. Every hash current did not make needs rehashing, so that hashes
+// move to current as users log in.
+func Chain(current Hasher, legacy ...Hasher) Hasher {
+	return &chain{current: current, legacy: legacy}
+}
+
+type chain struct {
+	current Hasher
+	legacy  []Hasher
+}
+
+func (c *chain) Hash(password string) (string, error) {
+	return c.current.Hash(password)
+}
+
+func (c *chain) Verify(password, hash string) error {
+	err := c.current.Verify(password, hash)
+	for _, hasher := range c.legacy {
+		if !errors.Is(err, ErrUnknownHash) {
+			break
+		}
+		err = hasher.Verify(password, hash)
+	}
+	return err
+}
+
+func (c *chain) NeedsRehash(hash string) bool {
+	return c.current.NeedsRehash(hash)
+}
+
+// New returns the Hasher for an algorithm, "argon2id" or "bcrypt", which
+// also verifies hashes made with the other one.
+func New(algorithm string, argon Argon2id, bcryptCost int) (Hasher, error) {
+	switch algorithm {
+	case "argon2id":
+		return Chain(argon, Bcrypt{Cost: bcryptCost}), nil
+	case "bcrypt":
+		return Chain(Bcrypt{Cost: bcryptCost}, argon), nil
+	default:
+		return nil, fmt.Errorf("unknown password hash algorithm %q", algorithm)
+	}
+}
@@ -0,0 +1,110 @@
+package password
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// testArgon2id is cheap enough to run many times in tests.
+var testArgon2id = Argon2id{Memory: 64, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32}
+
+func TestArgon2id(t *testing.T) {
+	hash, err := testArgon2id.Hash("correct horse")
+	require.NoError(t, err)
+
+	assert.True(t, strings.HasPrefix(hash, "$argon2id$v=19$m=64,t=1,p=1$"), hash)
+	assert.NoError(t, testArgon2id.Verify("correct horse", hash))
+	assert.ErrorIs(t, testArgon2id.Verify("battery staple", hash), ErrMismatch)
+	assert.False(t, testArgon2id.NeedsRehash(hash))
+
+	other, err := testArgon2id.Hash("correct horse")
+	require.NoError(t, err)
+	assert.NotEqual(t, hash, other, "salts must differ")
+}
+
+func TestArgon2idKnownHash(t *testing.T) {
+	// A test vector of golang.org/x/crypto/argon2, from the reference
+	// implementation: password "password", salt "somesalt", t=1, m=64, p=1.
+	hash := "$argon2id$v=19$m=64,t=1,p=1$c29tZXNhbHQ$ZVrRXqxlLcWfcXCnMyv0m4Rpvh/bnCi7"
+
+	assert.NoError(t, Argon2id{}.Verify("password", hash))
+	assert.ErrorIs(t, Argon2id{}.Verify("Password", hash), ErrMismatch)
+}
+
+func TestArgon2idNeedsRehash(t *testing.T) {
+	hash, err := testArgon2id.Hash("correct horse")
+	require.NoError(t, err)
+
+	stronger := testArgon2id
+	stronger.Iterations = 2
+	assert.True(t, stronger.NeedsRehash(hash))
+
+	longerKey := testArgon2id
+	longerKey.KeyLength = 64
+	assert.True(t, longerKey.NeedsRehash(hash))
+
+	assert.True(t, testArgon2id.NeedsRehash("$2a$10$abcdefghijklmnopqrstuu"))
+}
+
+func TestArgon2idMalformed(t *testing.T) {
+	for _, hash := range []string{
+		"",
+		"$argon2i$v=19$m=64,t=1,p=1$c29tZXNhbHQ$c29tZWtleQ",
+		"$argon2id$v=16$m=64,t=1,p=1$c29tZXNhbHQ$c29tZWtleQ",
+		"$argon2id$v=19$m=0,t=1,p=1$c29tZXNhbHQ$c29tZWtleQ",
+		"$argon2id$v=19$m=64,t=1$c29tZXNhbHQ$c29tZWtleQ",
+		"$argon2id$v=19$m=64,t=1,p=1$c29tZXNhbHQ",
+		"$argon2id$v=19$m=64,t=1,p=1$!!!$c29tZWtleQ",
+	} {
+		assert.ErrorIs(t, testArgon2id.Verify("password", hash), ErrUnknownHash, hash)
+	}
+}
+
+func TestBcrypt(t *testing.T) {
+	hasher := Bcrypt{Cost: bcrypt.MinCost}
+	hash, err := hasher.Hash("correct horse")
+	require.NoError(t, err)
+
+	assert.NoError(t, hasher.Verify("correct horse", hash))
+	assert.ErrorIs(t, hasher.Verify("battery staple", hash), ErrMismatch)
+	assert.False(t, hasher.NeedsRehash(hash))
+	assert.True(t, Bcrypt{Cost: bcrypt.MinCost + 1}.NeedsRehash(hash))
+	assert.ErrorIs(t, hasher.Verify("correct horse", "$argon2id$v=19$m=64,t=1,p=1$c29tZXNhbHQ$c29tZWtleQ"), ErrUnknownHash)
+
+	_, err = hasher.Hash(strings.Repeat("a", 73))
+	assert.ErrorIs(t, err, ErrTooLong)
+}
+
+func TestChain(t *testing.T) {
+	legacy := Bcrypt{Cost: bcrypt.MinCost}
+	hasher := Chain(testArgon2id, legacy)
+
+	oldHash, err := legacy.Hash("correct horse")
+	require.NoError(t, err)
+	assert.NoError(t, hasher.Verify("correct horse", oldHash))
+	assert.ErrorIs(t, hasher.Verify("battery staple", oldHash), ErrMismatch)
+	assert.True(t, hasher.NeedsRehash(oldHash))
+
+	newHash, err := hasher.Hash("correct horse")
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(newHash, argon2idPrefix))
+	assert.NoError(t, hasher.Verify("correct horse", newHash))
+	assert.False(t, hasher.NeedsRehash(newHash))
+
+	assert.ErrorIs(t, hasher.Verify("correct horse", "plaintext"), ErrUnknownHash)
+}
+
+func TestNew(t *testing.T) {
+	hasher, err := New("bcrypt", testArgon2id, bcrypt.MinCost)
+	require.NoError(t, err)
+	hash, err := hasher.Hash("correct horse")
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(hash, "$2a$"))
+
+	_, err = New("md5", testArgon2id, bcrypt.MinCost)
+	assert.Error(t, err)
+}
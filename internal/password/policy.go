@@ -0,0 +1,81 @@
+package password
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"unicode/utf8"
+)
+
+var (
+	ErrTooShort  = errors.New("password is too short")
+	ErrTooLong   = errors.New("password is too long")
+	ErrTooCommon = errors.New("password is too common")
+)
+
+const (
+	DefaultMinLength = 8
+	// DefaultMaxLength stops multi-megabyte passwords from tying up the
+	// hasher, while leaving room for any passphrase.
+	DefaultMaxLength = 128
+)
+
+// Policy decides which passwords users may choose. Lengths are counted in
+// characters, not bytes. Zero lengths fall back to the defaults.
+type Policy struct {
+	MinLength int
+	MaxLength int
+	// Common are passwords that are refused however long they are, such
+	// as those that turn up in breaches. They are matched ignoring case.
+	Common map[string]struct{}
+}
+
+// Check returns an error wrapping ErrTooShort, ErrTooLong or ErrTooCommon
+// if the password may not be used.
+func (p Policy) Check(password string) error {
+	minLength, maxLength := p.MinLength, p.MaxLength
+	if minLength <= 0 {
+		minLength = DefaultMinLength
+	}
+	if maxLength <= 0 {
+		maxLength = DefaultMaxLength
+	}
+
+	length := utf8.RuneCountInString(password)
+	if length < minLength {
+		return fmt.Errorf("%w: use at least %d characters", ErrTooShort, minLength)
+	}
+	if length > maxLength {
+		return fmt.Errorf("%w: use at most %d characters", ErrTooLong, maxLength)
+	}
+	if _, ok := p.Common[strings.ToLower(password)]; ok {
+		return fmt.Errorf("%w: it appears in lists of breached passwords", ErrTooCommon)
+	}
+	return nil
+}
+
+// LoadCommon reads a list of common passwords for Policy.Common, one per
+// line. Blank lines are skipped.
+func LoadCommon(path string) (map[string]struct{}, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	common := make(map[string]struct{})
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if line == "" {
+			continue
+		}
+		common[strings.ToLower(line)] = struct{}{}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading %s: %w", path, err)
+	}
+	return common, nil
+}
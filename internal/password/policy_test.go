@@ -0,0 +1,61 @@
+package password
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPolicyCheck(t *testing.T) {
+	policy := Policy{MinLength: 10, MaxLength: 20, Common: map[string]struct{}{"password1234": {}}}
+
+	tests := []struct {
+		name     string
+		password string
+		wantErr  error
+	}{
+		{"Acceptable", "correct horse", nil},
+		{"Too Short", "short", ErrTooShort},
+		{"Counts Characters Not Bytes", "ŝŝŝŝŝŝŝŝŝŝ", nil},
+		{"Too Long", strings.Repeat("a", 21), ErrTooLong},
+		{"Common", "password1234", ErrTooCommon},
+		{"Common Ignoring Case", "PassWord1234", ErrTooCommon},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := policy.Check(tt.password)
+			if tt.wantErr == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestPolicyDefaults(t *testing.T) {
+	err := Policy{}.Check("seven77")
+	assert.ErrorIs(t, err, ErrTooShort)
+	assert.EqualError(t, err, "password is too short: use at least 8 characters")
+
+	assert.NoError(t, Policy{}.Check("eight888"))
+	assert.ErrorIs(t, Policy{}.Check(strings.Repeat("a", 129)), ErrTooLong)
+}
+
+func TestLoadCommon(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "common.txt")
+	require.NoError(t, os.WriteFile(path, []byte("123456\r\nQwerty\n\npassword\n"), 0o600))
+
+	common, err := LoadCommon(path)
+
+	require.NoError(t, err)
+	assert.Equal(t, map[string]struct{}{"123456": {}, "qwerty": {}, "password": {}}, common)
+
+	_, err = LoadCommon(filepath.Join(t.TempDir(), "missing.txt"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,241 @@
+package recurrence
+
+import (
+	"sort"
+	"time"
+)
+
+// maxPeriods bounds the search in Next, so a rule that can never match
+// again (BYMONTHDAY=31 every twelve months from February, say) gives up
+// instead of looping forever.
+const maxPeriods = 100000
+
+// maxYear is the last year Next returns occurrences in. Later ones are of
+// no use to anyone, and from year 294277 on Postgres cannot store them.
+const maxYear = 9999
+
+// Next returns the first occurrence after the given instant of the series
+// that starts at start and repeats by the rule in loc. ok is false once
+// the series has ended by COUNT or UNTIL, or would go on past the year
+// 9999.
+//
+// start always counts as the first occurrence. Later occurrences keep its
+// wall-clock time in loc across daylight saving changes: a time that falls
+// in a gap is read with the offset from before the gap, and a time that
+// occurs twice resolves to the first of the two.
+func (r *Rule) Next(start, after time.Time, loc *time.Location) (time.Time, bool) {
+	start = start.In(loc)
+	if start.After(after) {
+		return start, true
+	}
+
+	hour, minute, second := start.Clock()
+	startDate := civilDate(start)
+	interval := r.Interval
+	if interval < 1 {
+		interval = 1
+	}
+
+	emitted := 1
+	for period := 0; period < maxPeriods; period++ {
+		for _, date := range r.periodDates(startDate, period*interval) {
+			if !date.After(startDate) {
+				continue
+			}
+			if date.Year() > maxYear {
+				return time.Time{}, false
+			}
+
+			occurrence := localTime(date, hour, minute, second, start.Nanosecond(), loc)
+			if r.pastUntil(date, occurrence) {
+				return time.Time{}, false
+			}
+
+			emitted++
+			if r.Count > 0 && emitted > r.Count {
+				return time.Time{}, false
+			}
+
+			if occurrence.After(after) {
+				return occurrence, true
+			}
+		}
+	}
+
+	return time.Time{}, false
+}
+
+// periodDates returns the candidate dates, in order, of the period offset
+// periods after the one containing startDate. Dates are civil dates at
+// midnight UTC, so calendar arithmetic is free of daylight saving shifts.
+func (r *Rule) periodDates(startDate time.Time, offset int) []time.Time {
+	var dates []time.Time
+
+	switch r.Freq {
+	case Daily:
+		date := startDate.AddDate(0, 0, offset)
+		if r.matchesWeekday(date) && r.matchesMonthDay(date) {
+			dates = append(dates, date)
+		}
+
+	case Weekly:
+		weekStart := startDate.AddDate(0, 0, 7*offset-mondayIndex(startDate.Weekday()))
+		if len(r.ByDay) == 0 {
+			dates = append(dates, weekStart.AddDate(0, 0, mondayIndex(startDate.Weekday())))
+		}
+		for _, day := range r.ByDay {
+			date := weekStart.AddDate(0, 0, mondayIndex(day.Day))
+			if r.matchesMonthDay(date) {
+				dates = append(dates, date)
+			}
+		}
+
+	case Monthly:
+		first := time.Date(startDate.Year(), startDate.Month()+time.Month(offset), 1, 0, 0, 0, 0, time.UTC)
+		days := daysInMonth(first)
+
+		switch {
+		case len(r.ByMonthDay) > 0:
+			for _, monthDay := range r.ByMonthDay {
+				if day, ok := resolveMonthDay(monthDay, days); ok {
+					date := first.AddDate(0, 0, day-1)
+					if r.matchesWeekday(date) {
+						dates = append(dates, date)
+					}
+				}
+			}
+		case len(r.ByDay) > 0:
+			for day := 1; day <= days; day++ {
+				date := first.AddDate(0, 0, day-1)
+				if r.matchesWeekday(date) {
+					dates = append(dates, date)
+				}
+			}
+		case startDate.Day() <= days:
+			dates = append(dates, first.AddDate(0, 0, startDate.Day()-1))
+		}
+
+	case Yearly:
+		date := time.Date(startDate.Year()+offset, startDate.Month(), startDate.Day(), 0, 0, 0, 0, time.UTC)
+		// Skip years where the day does not exist, such as 29 February.
+		if date.Month() == startDate.Month() {
+			dates = append(dates, date)
+		}
+	}
+
+	sort.Slice(dates, func(i, j int) bool { return dates[i].Before(dates[j]) })
+	return uniqueDates(dates)
+}
+
+// matchesWeekday applies BYDAY to a date. Numbered entries count within
+// the date's month.
+func (r *Rule) matchesWeekday(date time.Time) bool {
+	if len(r.ByDay) == 0 {
+		return true
+	}
+
+	fromStart := (date.Day()-1)/7 + 1
+	fromEnd := -((daysInMonth(date)-date.Day())/7 + 1)
+	for _, day := range r.ByDay {
+		if day.Day != date.Weekday() {
+			continue
+		}
+		if day.N == 0 || day.N == fromStart || day.N == fromEnd {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Rule) matchesMonthDay(date time.Time) bool {
+	if len(r.ByMonthDay) == 0 {
+		return true
+	}
+
+	days := daysInMonth(date)
+	for _, monthDay := range r.ByMonthDay {
+		if day, ok := resolveMonthDay(monthDay, days); ok && day == date.Day() {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Rule) pastUntil(date, occurrence time.Time) bool {
+	if r.Until.IsZero() {
+		return false
+	}
+	if r.UntilDate {
+		return date.After(r.Until)
+	}
+	return occurrence.After(r.Until)
+}
+
+// resolveMonthDay turns a BYMONTHDAY value into a day of a month with the
+// given number of days. Negative values count from the end of the month.
+func resolveMonthDay(monthDay, days int) (int, bool) {
+	if monthDay < 0 {
+		monthDay = days + monthDay + 1
+	}
+	return monthDay, monthDay >= 1 && monthDay <= days
+}
+
+func daysInMonth(date time.Time) int {
+	return time.Date(date.Year(), date.Month()+1, 0, 0, 0, 0, 0, time.UTC).Day()
+}
+
+func civilDate(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+func uniqueDates(dates []time.Time) []time.Time {
+	unique := dates[:0]
+	for i, date := range dates {
+		if i == 0 || !date.Equal(dates[i-1]) {
+			unique = append(unique, date)
+		}
+	}
+	return unique
+}
+
+// AtWallClock returns the wall-clock time of clock on the calendar date of
+// date, both read in loc. It resolves daylight saving gaps and overlaps the
+// same way Next does.
+func AtWallClock(date, clock time.Time, loc *time.Location) time.Time {
+	clock = clock.In(loc)
+	hour, minute, second := clock.Clock()
+	return localTime(civilDate(date.In(loc)), hour, minute, second, clock.Nanosecond(), loc)
+}
+
+// localTime places a wall-clock time on a civil date in loc following RFC
+// 5545: a time skipped by a daylight saving gap uses the offset in force
+// before the gap, and a repeated time resolves to its first occurrence.
+// time.Date leaves both cases unspecified.
+func localTime(date time.Time, hour, minute, second, nanosecond int, loc *time.Location) time.Time {
+	wall := time.Date(date.Year(), date.Month(), date.Day(), hour, minute, second, nanosecond, time.UTC)
+
+	_, offsetBefore := wall.Add(-24 * time.Hour).In(loc).Zone()
+	_, offsetAfter := wall.Add(24 * time.Hour).In(loc).Zone()
+	before := wall.Add(-time.Duration(offsetBefore) * time.Second).In(loc)
+	after := wall.Add(-time.Duration(offsetAfter) * time.Second).In(loc)
+
+	beforeFits := sameWallClock(before, wall)
+	afterFits := sameWallClock(after, wall)
+	switch {
+	case beforeFits && afterFits && after.Before(before):
+		return after
+	case beforeFits:
+		return before
+	case afterFits:
+		return after
+	default:
+		return before
+	}
+}
+
+func sameWallClock(t, wall time.Time) bool {
+	year, month, day := t.Date()
+	hour, minute, second := t.Clock()
+	return year == wall.Year() && month == wall.Month() && day == wall.Day() &&
+		hour == wall.Hour() && minute == wall.Minute() && second == wall.Second()
+}
@@ -0,0 +1,404 @@
+package recurrence
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func mustLoadLocation(t *testing.T, name string) *time.Location {
+	t.Helper()
+	loc, err := time.LoadLocation(name)
+	require.NoError(t, err)
+	return loc
+}
+
+// occurrences follows the series with Next and formats up to n occurrences
+// after start in loc.
+func occurrences(t *testing.T, value string, start time.Time, loc *time.Location, n int) []string {
+	t.Helper()
+	rule, err := Parse(value)
+	require.NoError(t, err)
+
+	var got []string
+	current := start
+	for len(got) < n {
+		next, ok := rule.Next(start, current, loc)
+		if !ok {
+			break
+		}
+		require.True(t, next.After(current), "occurrence %s does not advance past %s", next, current)
+		got = append(got, next.In(loc).Format(time.RFC3339))
+		current = next
+	}
+	return got
+}
+
+func TestNext(t *testing.T) {
+	utc := time.UTC
+	losAngeles := mustLoadLocation(t, "America/Los_Angeles")
+
+	tests := []struct {
+		name  string
+		rule  string
+		start time.Time
+		loc   *time.Location
+		want  []string
+		// ends is set for series that finish after the wanted occurrences.
+		ends bool
+	}{
+		{
+			name:  "Daily",
+			rule:  "FREQ=DAILY",
+			start: time.Date(2025, 1, 30, 9, 0, 0, 0, utc),
+			loc:   utc,
+			want:  []string{"2025-01-31T09:00:00Z", "2025-02-01T09:00:00Z", "2025-02-02T09:00:00Z"},
+		},
+		{
+			name:  "Daily Interval",
+			rule:  "FREQ=DAILY;INTERVAL=3",
+			start: time.Date(2024, 2, 26, 9, 0, 0, 0, utc),
+			loc:   utc,
+			want:  []string{"2024-02-29T09:00:00Z", "2024-03-03T09:00:00Z", "2024-03-06T09:00:00Z"},
+		},
+		{
+			name:  "Daily On Weekdays",
+			rule:  "FREQ=DAILY;BYDAY=MO,WE,FR",
+			start: time.Date(2025, 1, 1, 9, 0, 0, 0, utc),
+			loc:   utc,
+			want:  []string{"2025-01-03T09:00:00Z", "2025-01-06T09:00:00Z", "2025-01-08T09:00:00Z"},
+		},
+		{
+			name:  "Daily On Month Day",
+			rule:  "FREQ=DAILY;BYMONTHDAY=1",
+			start: time.Date(2025, 1, 1, 9, 0, 0, 0, utc),
+			loc:   utc,
+			want:  []string{"2025-02-01T09:00:00Z", "2025-03-01T09:00:00Z"},
+		},
+		{
+			name:  "Weekly",
+			rule:  "FREQ=WEEKLY",
+			start: time.Date(2025, 1, 1, 9, 0, 0, 0, utc),
+			loc:   utc,
+			want:  []string{"2025-01-08T09:00:00Z", "2025-01-15T09:00:00Z", "2025-01-22T09:00:00Z"},
+		},
+		{
+			name:  "Fortnightly On Two Days",
+			rule:  "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,TH",
+			start: time.Date(2025, 1, 1, 9, 0, 0, 0, utc),
+			loc:   utc,
+			want:  []string{"2025-01-02T09:00:00Z", "2025-01-13T09:00:00Z", "2025-01-16T09:00:00Z", "2025-01-27T09:00:00Z"},
+		},
+		{
+			name:  "Weekly On Sunday Ends The Week",
+			rule:  "FREQ=WEEKLY;BYDAY=SU",
+			start: time.Date(2025, 1, 6, 9, 0, 0, 0, utc),
+			loc:   utc,
+			want:  []string{"2025-01-12T09:00:00Z", "2025-01-19T09:00:00Z"},
+		},
+		{
+			name:  "Monthly Skips Short Months",
+			rule:  "FREQ=MONTHLY",
+			start: time.Date(2025, 1, 31, 9, 0, 0, 0, utc),
+			loc:   utc,
+			want:  []string{"2025-03-31T09:00:00Z", "2025-05-31T09:00:00Z", "2025-07-31T09:00:00Z"},
+		},
+		{
+			name:  "Monthly Last Day",
+			rule:  "FREQ=MONTHLY;BYMONTHDAY=-1",
+			start: time.Date(2024, 1, 31, 9, 0, 0, 0, utc),
+			loc:   utc,
+			want:  []string{"2024-02-29T09:00:00Z", "2024-03-31T09:00:00Z", "2024-04-30T09:00:00Z"},
+		},
+		{
+			name:  "Monthly On Two Days",
+			rule:  "FREQ=MONTHLY;BYMONTHDAY=15,1",
+			start: time.Date(2025, 1, 10, 9, 0, 0, 0, utc),
+			loc:   utc,
+			want:  []string{"2025-01-15T09:00:00Z", "2025-02-01T09:00:00Z", "2025-02-15T09:00:00Z"},
+		},
+		{
+			name:  "Monthly First Monday",
+			rule:  "FREQ=MONTHLY;BYDAY=1MO",
+			start: time.Date(2025, 1, 6, 9, 0, 0, 0, utc),
+			loc:   utc,
+			want:  []string{"2025-02-03T09:00:00Z", "2025-03-03T09:00:00Z", "2025-04-07T09:00:00Z"},
+		},
+		{
+			name:  "Monthly Last Friday",
+			rule:  "FREQ=MONTHLY;BYDAY=-1FR",
+			start: time.Date(2025, 1, 31, 9, 0, 0, 0, utc),
+			loc:   utc,
+			want:  []string{"2025-02-28T09:00:00Z", "2025-03-28T09:00:00Z", "2025-04-25T09:00:00Z"},
+		},
+		{
+			name:  "Monthly Every Tuesday",
+			rule:  "FREQ=MONTHLY;BYDAY=TU",
+			start: time.Date(2025, 2, 4, 9, 0, 0, 0, utc),
+			loc:   utc,
+			want:  []string{"2025-02-11T09:00:00Z", "2025-02-18T09:00:00Z", "2025-02-25T09:00:00Z", "2025-03-04T09:00:00Z"},
+		},
+		{
+			name:  "Friday The Thirteenth",
+			rule:  "FREQ=MONTHLY;BYMONTHDAY=13;BYDAY=FR",
+			start: time.Date(2024, 12, 13, 9, 0, 0, 0, utc),
+			loc:   utc,
+			want:  []string{"2025-06-13T09:00:00Z", "2026-02-13T09:00:00Z", "2026-03-13T09:00:00Z"},
+		},
+		{
+			name:  "Bimonthly",
+			rule:  "FREQ=MONTHLY;INTERVAL=2;BYMONTHDAY=31",
+			start: time.Date(2025, 1, 31, 9, 0, 0, 0, utc),
+			loc:   utc,
+			want:  []string{"2025-03-31T09:00:00Z", "2025-05-31T09:00:00Z", "2025-07-31T09:00:00Z"},
+		},
+		{
+			name:  "Never Matches Again",
+			rule:  "FREQ=MONTHLY;INTERVAL=12;BYMONTHDAY=31",
+			start: time.Date(2025, 2, 28, 9, 0, 0, 0, utc),
+			loc:   utc,
+			want:  nil,
+			ends:  true,
+		},
+		{
+			name:  "Yearly Leap Day",
+			rule:  "FREQ=YEARLY",
+			start: time.Date(2024, 2, 29, 9, 0, 0, 0, utc),
+			loc:   utc,
+			want:  []string{"2028-02-29T09:00:00Z", "2032-02-29T09:00:00Z"},
+		},
+		{
+			name:  "Every Other Year",
+			rule:  "FREQ=YEARLY;INTERVAL=2",
+			start: time.Date(2025, 3, 15, 9, 0, 0, 0, utc),
+			loc:   utc,
+			want:  []string{"2027-03-15T09:00:00Z", "2029-03-15T09:00:00Z"},
+		},
+		{
+			name:  "Count Includes Start",
+			rule:  "FREQ=DAILY;COUNT=3",
+			start: time.Date(2025, 1, 1, 9, 0, 0, 0, utc),
+			loc:   utc,
+			want:  []string{"2025-01-02T09:00:00Z", "2025-01-03T09:00:00Z"},
+			ends:  true,
+		},
+		{
+			name:  "Count Of One",
+			rule:  "FREQ=WEEKLY;COUNT=1",
+			start: time.Date(2025, 1, 1, 9, 0, 0, 0, utc),
+			loc:   utc,
+			want:  nil,
+			ends:  true,
+		},
+		{
+			name:  "Until Is Inclusive",
+			rule:  "FREQ=DAILY;UNTIL=20250103T090000Z",
+			start: time.Date(2025, 1, 1, 9, 0, 0, 0, utc),
+			loc:   utc,
+			want:  []string{"2025-01-02T09:00:00Z", "2025-01-03T09:00:00Z"},
+			ends:  true,
+		},
+		{
+			name:  "Until Just Before",
+			rule:  "FREQ=DAILY;UNTIL=20250103T085959Z",
+			start: time.Date(2025, 1, 1, 9, 0, 0, 0, utc),
+			loc:   utc,
+			want:  []string{"2025-01-02T09:00:00Z"},
+			ends:  true,
+		},
+		{
+			name:  "Until Date In Local Time",
+			rule:  "FREQ=DAILY;UNTIL=20250103",
+			start: time.Date(2025, 1, 1, 23, 0, 0, 0, losAngeles),
+			loc:   losAngeles,
+			want:  []string{"2025-01-02T23:00:00-08:00", "2025-01-03T23:00:00-08:00"},
+			ends:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n := len(tt.want)
+			if tt.ends {
+				n++
+			}
+			assert.Equal(t, tt.want, occurrences(t, tt.rule, tt.start, tt.loc, n))
+		})
+	}
+}
+
+func TestNextDaylightSaving(t *testing.T) {
+	newYork := mustLoadLocation(t, "America/New_York")
+	berlin := mustLoadLocation(t, "Europe/Berlin")
+	sydney := mustLoadLocation(t, "Australia/Sydney")
+
+	tests := []struct {
+		name  string
+		rule  string
+		start time.Time
+		loc   *time.Location
+		want  []string
+	}{
+		{
+			name:  "Keeps Wall Clock Into Summer Time",
+			rule:  "FREQ=DAILY",
+			start: time.Date(2025, 3, 8, 9, 0, 0, 0, newYork),
+			loc:   newYork,
+			want:  []string{"2025-03-09T09:00:00-04:00", "2025-03-10T09:00:00-04:00"},
+		},
+		{
+			name:  "Keeps Wall Clock Into Winter Time",
+			rule:  "FREQ=DAILY",
+			start: time.Date(2025, 11, 1, 9, 0, 0, 0, newYork),
+			loc:   newYork,
+			want:  []string{"2025-11-02T09:00:00-05:00", "2025-11-03T09:00:00-05:00"},
+		},
+		{
+			name:  "Start Given In UTC",
+			rule:  "FREQ=DAILY",
+			start: time.Date(2025, 3, 8, 14, 0, 0, 0, time.UTC),
+			loc:   newYork,
+			want:  []string{"2025-03-09T09:00:00-04:00"},
+		},
+		{
+			name:  "Skipped Time Uses Offset Before Gap",
+			rule:  "FREQ=DAILY",
+			start: time.Date(2025, 3, 8, 2, 30, 0, 0, newYork),
+			loc:   newYork,
+			want:  []string{"2025-03-09T03:30:00-04:00", "2025-03-10T02:30:00-04:00"},
+		},
+		{
+			name:  "Repeated Time Uses First Occurrence",
+			rule:  "FREQ=DAILY",
+			start: time.Date(2025, 11, 1, 1, 30, 0, 0, newYork),
+			loc:   newYork,
+			want:  []string{"2025-11-02T01:30:00-04:00", "2025-11-03T01:30:00-05:00"},
+		},
+		{
+			name:  "Skipped Time In Europe",
+			rule:  "FREQ=WEEKLY",
+			start: time.Date(2025, 3, 23, 2, 30, 0, 0, berlin),
+			loc:   berlin,
+			want:  []string{"2025-03-30T03:30:00+02:00", "2025-04-06T02:30:00+02:00"},
+		},
+		{
+			name:  "Repeated Time In Europe",
+			rule:  "FREQ=DAILY",
+			start: time.Date(2025, 10, 25, 2, 30, 0, 0, berlin),
+			loc:   berlin,
+			want:  []string{"2025-10-26T02:30:00+02:00", "2025-10-27T02:30:00+01:00"},
+		},
+		{
+			name:  "Skipped Time In Southern Hemisphere",
+			rule:  "FREQ=DAILY",
+			start: time.Date(2025, 10, 4, 2, 30, 0, 0, sydney),
+			loc:   sydney,
+			want:  []string{"2025-10-05T03:30:00+11:00", "2025-10-06T02:30:00+11:00"},
+		},
+		{
+			name:  "Repeated Time In Southern Hemisphere",
+			rule:  "FREQ=DAILY",
+			start: time.Date(2025, 4, 5, 2, 30, 0, 0, sydney),
+			loc:   sydney,
+			want:  []string{"2025-04-06T02:30:00+11:00", "2025-04-07T02:30:00+10:00"},
+		},
+		{
+			name:  "Monthly Across Change",
+			rule:  "FREQ=MONTHLY",
+			start: time.Date(2025, 10, 15, 9, 0, 0, 0, newYork),
+			loc:   newYork,
+			want:  []string{"2025-11-15T09:00:00-05:00", "2025-12-15T09:00:00-05:00"},
+		},
+		{
+			name:  "Until On Change Day",
+			rule:  "FREQ=DAILY;UNTIL=20250309T130000Z",
+			start: time.Date(2025, 3, 7, 9, 0, 0, 0, newYork),
+			loc:   newYork,
+			want:  []string{"2025-03-08T09:00:00-05:00", "2025-03-09T09:00:00-04:00"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, occurrences(t, tt.rule, tt.start, tt.loc, len(tt.want)))
+		})
+	}
+}
+
+func TestNextAfter(t *testing.T) {
+	rule, err := Parse("FREQ=DAILY")
+	require.NoError(t, err)
+	start := time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name  string
+		after time.Time
+		want  time.Time
+	}{
+		{"Before Start", time.Date(2024, 12, 31, 0, 0, 0, 0, time.UTC), start},
+		{"At Start", start, time.Date(2025, 1, 2, 9, 0, 0, 0, time.UTC)},
+		{"Between Occurrences", time.Date(2025, 1, 10, 12, 0, 0, 0, time.UTC), time.Date(2025, 1, 11, 9, 0, 0, 0, time.UTC)},
+		{"At Occurrence", time.Date(2025, 1, 10, 9, 0, 0, 0, time.UTC), time.Date(2025, 1, 11, 9, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			next, ok := rule.Next(start, tt.after, time.UTC)
+
+			assert.True(t, ok)
+			assert.True(t, tt.want.Equal(next), "got %s", next)
+		})
+	}
+}
+
+func TestNextHorizon(t *testing.T) {
+	tests := []struct {
+		name   string
+		rule   string
+		start  time.Time
+		want   time.Time
+		wantOK bool
+	}{
+		{"Yearly Before Horizon", "FREQ=YEARLY;INTERVAL=1000", time.Date(8000, 3, 1, 9, 0, 0, 0, time.UTC), time.Date(9000, 3, 1, 9, 0, 0, 0, time.UTC), true},
+		{"Yearly Past Horizon", "FREQ=YEARLY;INTERVAL=1000", time.Date(9500, 3, 1, 9, 0, 0, 0, time.UTC), time.Time{}, false},
+		{"Last Day Of Horizon", "FREQ=DAILY", time.Date(9999, 12, 30, 9, 0, 0, 0, time.UTC), time.Date(9999, 12, 31, 9, 0, 0, 0, time.UTC), true},
+		{"Daily Past Horizon", "FREQ=DAILY", time.Date(9999, 12, 31, 9, 0, 0, 0, time.UTC), time.Time{}, false},
+		{"Monthly Past Horizon", "FREQ=MONTHLY;INTERVAL=1000", time.Date(9950, 1, 15, 9, 0, 0, 0, time.UTC), time.Time{}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule, err := Parse(tt.rule)
+			require.NoError(t, err)
+
+			next, ok := rule.Next(tt.start, tt.start, time.UTC)
+
+			assert.Equal(t, tt.wantOK, ok)
+			assert.True(t, tt.want.Equal(next), "got %s", next)
+		})
+	}
+}
+
+func TestAtWallClock(t *testing.T) {
+	newYork := mustLoadLocation(t, "America/New_York")
+	clock := time.Date(2025, 1, 10, 2, 30, 0, 0, newYork)
+
+	tests := []struct {
+		name string
+		date time.Time
+		want string
+	}{
+		{"Same Offset", time.Date(2025, 2, 1, 20, 0, 0, 0, newYork), "2025-02-01T02:30:00-05:00"},
+		{"Date Read In Location", time.Date(2025, 2, 2, 3, 0, 0, 0, time.UTC), "2025-02-01T02:30:00-05:00"},
+		{"Summer Time", time.Date(2025, 6, 1, 12, 0, 0, 0, newYork), "2025-06-01T02:30:00-04:00"},
+		{"Skipped Time", time.Date(2025, 3, 9, 12, 0, 0, 0, newYork), "2025-03-09T03:30:00-04:00"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, AtWallClock(tt.date, clock, newYork).Format(time.RFC3339))
+		})
+	}
+}
@@ -0,0 +1,282 @@
+// Package recurrence parses and evaluates the subset of RFC 5545 recurrence
+// rules that recurring tasks support: FREQ, INTERVAL, BYDAY, BYMONTHDAY,
+// COUNT and UNTIL.
+package recurrence
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	// Embed the time zone database so recurrence time zones resolve even in
+	// images without /usr/share/zoneinfo.
+	_ "time/tzdata"
+)
+
+// ErrInvalidRule is wrapped by every error Parse returns.
+var ErrInvalidRule = errors.New("invalid recurrence rule")
+
+// MaxInterval is the largest INTERVAL Parse accepts. Larger ones have no
+// use and would soon schedule occurrences past the end of the calendar.
+const MaxInterval = 1000
+
+type Frequency string
+
+const (
+	Daily   Frequency = "DAILY"
+	Weekly  Frequency = "WEEKLY"
+	Monthly Frequency = "MONTHLY"
+	Yearly  Frequency = "YEARLY"
+)
+
+// WeekdayNum is one BYDAY entry. N selects the Nth such weekday of the
+// month, counting from the end when negative; zero selects all of them.
+type WeekdayNum struct {
+	Day time.Weekday
+	N   int
+}
+
+// Rule is a parsed recurrence rule. At most one of Count and Until is set.
+type Rule struct {
+	Freq       Frequency
+	Interval   int
+	ByDay      []WeekdayNum
+	ByMonthDay []int
+	Count      int
+	// Until is the last instant an occurrence may start at. When UntilDate
+	// is set it was given as a DATE, and occurrences on that calendar day in
+	// the rule's time zone are included.
+	Until     time.Time
+	UntilDate bool
+}
+
+var weekdayCodes = map[string]time.Weekday{
+	"SU": time.Sunday,
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+}
+
+var weekdayNames = [...]string{"SU", "MO", "TU", "WE", "TH", "FR", "SA"}
+
+const (
+	untilDateTimeLayout = "20060102T150405Z"
+	untilDateLayout     = "20060102"
+)
+
+// Parse reads an RRULE value such as "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,TH".
+// A leading "RRULE:" is accepted. Names and values are case-insensitive.
+func Parse(value string) (*Rule, error) {
+	value = strings.TrimSpace(value)
+	if len(value) >= 6 && strings.EqualFold(value[:6], "RRULE:") {
+		value = value[6:]
+	}
+	if value == "" {
+		return nil, fmt.Errorf("%w: rule is empty", ErrInvalidRule)
+	}
+
+	rule := &Rule{Interval: 1}
+	seen := make(map[string]bool)
+	for _, part := range strings.Split(value, ";") {
+		name, partValue, ok := strings.Cut(part, "=")
+		name = strings.ToUpper(strings.TrimSpace(name))
+		partValue = strings.ToUpper(strings.TrimSpace(partValue))
+		if !ok || name == "" || partValue == "" {
+			return nil, fmt.Errorf("%w: malformed part %q", ErrInvalidRule, part)
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("%w: %s given more than once", ErrInvalidRule, name)
+		}
+		seen[name] = true
+
+		var err error
+		switch name {
+		case "FREQ":
+			err = rule.parseFreq(partValue)
+		case "INTERVAL":
+			rule.Interval, err = parsePositive(name, partValue)
+			if err == nil && rule.Interval > MaxInterval {
+				err = fmt.Errorf("%w: INTERVAL must be at most %d", ErrInvalidRule, MaxInterval)
+			}
+		case "COUNT":
+			rule.Count, err = parsePositive(name, partValue)
+		case "UNTIL":
+			err = rule.parseUntil(partValue)
+		case "BYDAY":
+			err = rule.parseByDay(partValue)
+		case "BYMONTHDAY":
+			err = rule.parseByMonthDay(partValue)
+		case "WKST":
+			if partValue != "MO" {
+				err = fmt.Errorf("%w: only WKST=MO is supported", ErrInvalidRule)
+			}
+		default:
+			err = fmt.Errorf("%w: %s is not supported", ErrInvalidRule, name)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := rule.validate(); err != nil {
+		return nil, err
+	}
+	return rule, nil
+}
+
+func (r *Rule) parseFreq(value string) error {
+	switch freq := Frequency(value); freq {
+	case Daily, Weekly, Monthly, Yearly:
+		r.Freq = freq
+		return nil
+	}
+	return fmt.Errorf("%w: FREQ must be DAILY, WEEKLY, MONTHLY or YEARLY", ErrInvalidRule)
+}
+
+func (r *Rule) parseUntil(value string) error {
+	if until, err := time.Parse(untilDateTimeLayout, value); err == nil {
+		r.Until = until
+		return nil
+	}
+	if until, err := time.Parse(untilDateLayout, value); err == nil {
+		r.Until = until
+		r.UntilDate = true
+		return nil
+	}
+	return fmt.Errorf("%w: UNTIL must look like 20250131 or 20250131T170000Z", ErrInvalidRule)
+}
+
+func (r *Rule) parseByDay(value string) error {
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if len(item) < 2 {
+			return fmt.Errorf("%w: invalid BYDAY entry %q", ErrInvalidRule, item)
+		}
+
+		day, ok := weekdayCodes[item[len(item)-2:]]
+		if !ok {
+			return fmt.Errorf("%w: invalid BYDAY entry %q", ErrInvalidRule, item)
+		}
+
+		n := 0
+		if prefix := item[:len(item)-2]; prefix != "" {
+			var err error
+			n, err = strconv.Atoi(prefix)
+			if err != nil || n == 0 || n < -5 || n > 5 {
+				return fmt.Errorf("%w: invalid BYDAY entry %q", ErrInvalidRule, item)
+			}
+		}
+
+		r.ByDay = append(r.ByDay, WeekdayNum{Day: day, N: n})
+	}
+	return nil
+}
+
+func (r *Rule) parseByMonthDay(value string) error {
+	for _, item := range strings.Split(value, ",") {
+		day, err := strconv.Atoi(strings.TrimSpace(item))
+		if err != nil || day == 0 || day < -31 || day > 31 {
+			return fmt.Errorf("%w: invalid BYMONTHDAY entry %q", ErrInvalidRule, item)
+		}
+		r.ByMonthDay = append(r.ByMonthDay, day)
+	}
+	return nil
+}
+
+func parsePositive(name, value string) (int, error) {
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 1 {
+		return 0, fmt.Errorf("%w: %s must be a positive integer", ErrInvalidRule, name)
+	}
+	return n, nil
+}
+
+func (r *Rule) validate() error {
+	if r.Freq == "" {
+		return fmt.Errorf("%w: FREQ is required", ErrInvalidRule)
+	}
+	if r.Count > 0 && !r.Until.IsZero() {
+		return fmt.Errorf("%w: COUNT and UNTIL cannot both be given", ErrInvalidRule)
+	}
+	if r.Freq == Yearly && (len(r.ByDay) > 0 || len(r.ByMonthDay) > 0) {
+		return fmt.Errorf("%w: BYDAY and BYMONTHDAY are not supported with FREQ=YEARLY", ErrInvalidRule)
+	}
+	if r.Freq != Monthly {
+		for _, day := range r.ByDay {
+			if day.N != 0 {
+				return fmt.Errorf("%w: numbered BYDAY entries need FREQ=MONTHLY", ErrInvalidRule)
+			}
+		}
+	}
+	return nil
+}
+
+// String formats the rule in a canonical form, so equal rules compare
+// equal as strings.
+func (r *Rule) String() string {
+	parts := []string{"FREQ=" + string(r.Freq)}
+	if r.Interval > 1 {
+		parts = append(parts, "INTERVAL="+strconv.Itoa(r.Interval))
+	}
+
+	if len(r.ByDay) > 0 {
+		days := append([]WeekdayNum(nil), r.ByDay...)
+		sort.SliceStable(days, func(i, j int) bool {
+			if days[i].N != days[j].N {
+				return days[i].N < days[j].N
+			}
+			return mondayIndex(days[i].Day) < mondayIndex(days[j].Day)
+		})
+
+		codes := make([]string, 0, len(days))
+		for i, day := range days {
+			if i > 0 && day == days[i-1] {
+				continue
+			}
+			code := weekdayNames[day.Day]
+			if day.N != 0 {
+				code = strconv.Itoa(day.N) + code
+			}
+			codes = append(codes, code)
+		}
+		parts = append(parts, "BYDAY="+strings.Join(codes, ","))
+	}
+
+	if len(r.ByMonthDay) > 0 {
+		days := append([]int(nil), r.ByMonthDay...)
+		sort.Ints(days)
+
+		values := make([]string, 0, len(days))
+		for i, day := range days {
+			if i > 0 && day == days[i-1] {
+				continue
+			}
+			values = append(values, strconv.Itoa(day))
+		}
+		parts = append(parts, "BYMONTHDAY="+strings.Join(values, ","))
+	}
+
+	if r.Count > 0 {
+		parts = append(parts, "COUNT="+strconv.Itoa(r.Count))
+	}
+	if !r.Until.IsZero() {
+		if r.UntilDate {
+			parts = append(parts, "UNTIL="+r.Until.Format(untilDateLayout))
+		} else {
+			parts = append(parts, "UNTIL="+r.Until.UTC().Format(untilDateTimeLayout))
+		}
+	}
+
+	return strings.Join(parts, ";")
+}
+
+// mondayIndex numbers weekdays from Monday, the only supported week start.
+func mondayIndex(day time.Weekday) int {
+	return (int(day) + 6) % 7
+}
@@ -0,0 +1,149 @@
+package recurrence
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  Rule
+	}{
+		{
+			name:  "Daily",
+			input: "FREQ=DAILY",
+			want:  Rule{Freq: Daily, Interval: 1},
+		},
+		{
+			name:  "Prefix And Lower Case",
+			input: "rrule:freq=weekly;interval=2;byday=mo,th",
+			want: Rule{Freq: Weekly, Interval: 2, ByDay: []WeekdayNum{
+				{Day: time.Monday}, {Day: time.Thursday},
+			}},
+		},
+		{
+			name:  "Numbered Weekdays",
+			input: "FREQ=MONTHLY;BYDAY=1MO,-1FR,+2WE",
+			want: Rule{Freq: Monthly, Interval: 1, ByDay: []WeekdayNum{
+				{Day: time.Monday, N: 1}, {Day: time.Friday, N: -1}, {Day: time.Wednesday, N: 2},
+			}},
+		},
+		{
+			name:  "Month Days",
+			input: "FREQ=MONTHLY;BYMONTHDAY=1,15,-1",
+			want:  Rule{Freq: Monthly, Interval: 1, ByMonthDay: []int{1, 15, -1}},
+		},
+		{
+			name:  "Count",
+			input: "FREQ=YEARLY;COUNT=3",
+			want:  Rule{Freq: Yearly, Interval: 1, Count: 3},
+		},
+		{
+			name:  "Until Date Time",
+			input: "FREQ=DAILY;UNTIL=20250131T170000Z",
+			want:  Rule{Freq: Daily, Interval: 1, Until: time.Date(2025, 1, 31, 17, 0, 0, 0, time.UTC)},
+		},
+		{
+			name:  "Until Date",
+			input: "FREQ=DAILY;UNTIL=20250131",
+			want:  Rule{Freq: Daily, Interval: 1, Until: time.Date(2025, 1, 31, 0, 0, 0, 0, time.UTC), UntilDate: true},
+		},
+		{
+			name:  "Week Start Monday",
+			input: "FREQ=WEEKLY;WKST=MO",
+			want:  Rule{Freq: Weekly, Interval: 1},
+		},
+		{
+			name:  "Largest Interval",
+			input: "FREQ=YEARLY;INTERVAL=1000",
+			want:  Rule{Freq: Yearly, Interval: 1000},
+		},
+		{
+			name:  "Whitespace",
+			input: "  FREQ = DAILY ; INTERVAL = 3 ",
+			want:  Rule{Freq: Daily, Interval: 3},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule, err := Parse(tt.input)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, *rule)
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{"Empty", ""},
+		{"Prefix Only", "RRULE:"},
+		{"Missing Freq", "INTERVAL=2"},
+		{"Unknown Freq", "FREQ=HOURLY"},
+		{"Malformed Part", "FREQ=DAILY;INTERVAL"},
+		{"Empty Value", "FREQ=DAILY;COUNT="},
+		{"Trailing Separator", "FREQ=DAILY;"},
+		{"Duplicate Part", "FREQ=DAILY;FREQ=WEEKLY"},
+		{"Zero Interval", "FREQ=DAILY;INTERVAL=0"},
+		{"Interval Too Large", "FREQ=DAILY;INTERVAL=1001"},
+		{"Interval Overflows", "FREQ=YEARLY;INTERVAL=9223372036854775807"},
+		{"Negative Count", "FREQ=DAILY;COUNT=-1"},
+		{"Count And Until", "FREQ=DAILY;COUNT=2;UNTIL=20250131"},
+		{"Local Until", "FREQ=DAILY;UNTIL=20250131T170000"},
+		{"Unknown Weekday", "FREQ=WEEKLY;BYDAY=XX"},
+		{"Short Weekday", "FREQ=WEEKLY;BYDAY=M"},
+		{"Zero Ordinal", "FREQ=MONTHLY;BYDAY=0MO"},
+		{"Ordinal Too Large", "FREQ=MONTHLY;BYDAY=6MO"},
+		{"Ordinal With Weekly", "FREQ=WEEKLY;BYDAY=1MO"},
+		{"Zero Month Day", "FREQ=MONTHLY;BYMONTHDAY=0"},
+		{"Month Day Too Large", "FREQ=MONTHLY;BYMONTHDAY=32"},
+		{"Month Day Too Small", "FREQ=MONTHLY;BYMONTHDAY=-32"},
+		{"Yearly By Day", "FREQ=YEARLY;BYDAY=MO"},
+		{"Unsupported Part", "FREQ=YEARLY;BYMONTH=3"},
+		{"Unsupported Week Start", "FREQ=WEEKLY;WKST=SU"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(tt.input)
+
+			assert.True(t, errors.Is(err, ErrInvalidRule), "got %v", err)
+		})
+	}
+}
+
+func TestRuleString(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"FREQ=DAILY;INTERVAL=1", "FREQ=DAILY"},
+		{"freq=weekly;byday=th,mo,th;interval=2", "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,TH"},
+		{"FREQ=MONTHLY;BYDAY=1MO,-1FR", "FREQ=MONTHLY;BYDAY=-1FR,1MO"},
+		{"FREQ=MONTHLY;BYMONTHDAY=15,-1,1,15", "FREQ=MONTHLY;BYMONTHDAY=-1,1,15"},
+		{"FREQ=DAILY;COUNT=5", "FREQ=DAILY;COUNT=5"},
+		{"FREQ=DAILY;UNTIL=20250131", "FREQ=DAILY;UNTIL=20250131"},
+		{"FREQ=DAILY;UNTIL=20250131T170000Z", "FREQ=DAILY;UNTIL=20250131T170000Z"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			rule, err := Parse(tt.input)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, rule.String())
+
+			reparsed, err := Parse(rule.String())
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, reparsed.String())
+		})
+	}
+}
@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/ahmednurovic/task-manager-api/internal/model"
+	"github.com/jmoiron/sqlx"
+)
+
+type PersonalAccessTokenRepository interface {
+	Create(ctx context.Context, token *model.PersonalAccessToken) error
+	GetByHash(ctx context.Context, tokenHash string) (*model.PersonalAccessToken, error)
+	ListForUser(ctx context.Context, userID int64) ([]*model.PersonalAccessToken, error)
+	Revoke(ctx context.Context, tokenID int64, userID int64) error
+	TouchLastUsed(ctx context.Context, tokenID int64, usedAt time.Time) error
+}
+
+const personalAccessTokenColumns = `id, user_id, name, token_hash, scopes, expires_at, last_used_at, created_at, revoked_at, token_version`
+
+type PersonalAccessTokenRepositoryImpl struct {
+	db *sqlx.DB
+}
+
+func NewPersonalAccessTokenRepository(db *sqlx.DB) *PersonalAccessTokenRepositoryImpl {
+	return &PersonalAccessTokenRepositoryImpl{db: db}
+}
+
+// Create stamps the token with the user's current token version.
+func (r *PersonalAccessTokenRepositoryImpl) Create(ctx context.Context, token *model.PersonalAccessToken) error {
+	query := `INSERT INTO personal_access_tokens (user_id, name, token_hash, scopes, expires_at, token_version)
+		SELECT id, $2, $3, $4, $5, token_version FROM users WHERE id = $1
+		RETURNING id, created_at, token_version`
+	return r.db.QueryRowContext(ctx, query, token.UserID, token.Name, token.TokenHash, token.Scopes, token.ExpiresAt).
+		Scan(&token.ID, &token.CreatedAt, &token.TokenVersion)
+}
+
+func (r *PersonalAccessTokenRepositoryImpl) GetByHash(ctx context.Context, tokenHash string) (*model.PersonalAccessToken, error) {
+	var token model.PersonalAccessToken
+	query := `SELECT ` + personalAccessTokenColumns + ` FROM personal_access_tokens WHERE token_hash = $1`
+	if err := r.db.GetContext(ctx, &token, query, tokenHash); err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+// ListForUser returns the user's tokens that have not been revoked, newest
+// first. Expired tokens are included.
+func (r *PersonalAccessTokenRepositoryImpl) ListForUser(ctx context.Context, userID int64) ([]*model.PersonalAccessToken, error) {
+	tokens := []*model.PersonalAccessToken{}
+	query := `SELECT ` + personalAccessTokenColumns + ` FROM personal_access_tokens
+		WHERE user_id = $1 AND revoked_at IS NULL
+		ORDER BY created_at DESC, id DESC`
+	if err := r.db.SelectContext(ctx, &tokens, query, userID); err != nil {
+		return nil, err
+	}
+	return tokens, nil
+}
+
+// Revoke returns sql.ErrNoRows if the user has no such token, or it has
+// been revoked already.
+func (r *PersonalAccessTokenRepositoryImpl) Revoke(ctx context.Context, tokenID int64, userID int64) error {
+	query := `UPDATE personal_access_tokens SET revoked_at = NOW()
+		WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL`
+	result, err := r.db.ExecContext(ctx, query, tokenID, userID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+func (r *PersonalAccessTokenRepositoryImpl) TouchLastUsed(ctx context.Context, tokenID int64, usedAt time.Time) error {
+	query := `UPDATE personal_access_tokens SET last_used_at = $2 WHERE id = $1`
+	_, err := r.db.ExecContext(ctx, query, tokenID, usedAt)
+	return err
+}
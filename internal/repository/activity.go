@@ -0,0 +1,36 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/ahmednurovic/task-manager-api/internal/model"
+	"github.com/jmoiron/sqlx"
+)
+
+// ActivityRepository reads task activity. Entries are written by the
+// repositories that make the changes they record, in the same statement.
+type ActivityRepository interface {
+	ListForTask(ctx context.Context, taskID int64) ([]*model.TaskActivity, error)
+}
+
+type ActivityRepositoryImpl struct {
+	db *sqlx.DB
+}
+
+func NewActivityRepository(db *sqlx.DB) *ActivityRepositoryImpl {
+	return &ActivityRepositoryImpl{db: db}
+}
+
+// ListForTask returns the task's activity, oldest first.
+func (r *ActivityRepositoryImpl) ListForTask(ctx context.Context, taskID int64) ([]*model.TaskActivity, error) {
+	activity := []*model.TaskActivity{}
+	query := `SELECT id, task_id, actor_id, action, subject_id, created_at
+		FROM task_activity
+		WHERE task_id = $1
+		ORDER BY created_at, id`
+	err := r.db.SelectContext(ctx, &activity, query, taskID)
+	if err != nil {
+		return nil, err
+	}
+	return activity, nil
+}
@@ -0,0 +1,95 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/ahmednurovic/task-manager-api/internal/model"
+	"github.com/jmoiron/sqlx"
+)
+
+type AttachmentRepository interface {
+	Create(ctx context.Context, attachment *model.Attachment) error
+	ListForTask(ctx context.Context, taskID int64) ([]*model.Attachment, error)
+	GetByID(ctx context.Context, attachmentID int64) (*model.Attachment, error)
+	Detach(ctx context.Context, attachmentID int64, taskID int64) error
+	ListOrphaned(ctx context.Context, limit int) ([]*model.Attachment, error)
+	Purge(ctx context.Context, attachmentID int64) error
+}
+
+const attachmentColumns = `id, COALESCE(task_id, 0) AS task_id, uploader_id, filename, content_type, size, sha256, storage_key, created_at`
+
+type AttachmentRepositoryImpl struct {
+	db *sqlx.DB
+}
+
+func NewAttachmentRepository(db *sqlx.DB) *AttachmentRepositoryImpl {
+	return &AttachmentRepositoryImpl{db: db}
+}
+
+func (r *AttachmentRepositoryImpl) Create(ctx context.Context, attachment *model.Attachment) error {
+	query := `INSERT INTO task_attachments (task_id, uploader_id, filename, content_type, size, sha256, storage_key)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id, created_at`
+	return r.db.QueryRowContext(ctx, query,
+		attachment.TaskID, attachment.UploaderID, attachment.Filename, attachment.ContentType, attachment.Size,
+		attachment.SHA256, attachment.StorageKey,
+	).Scan(&attachment.ID, &attachment.CreatedAt)
+}
+
+func (r *AttachmentRepositoryImpl) ListForTask(ctx context.Context, taskID int64) ([]*model.Attachment, error) {
+	attachments := []*model.Attachment{}
+	query := `SELECT ` + attachmentColumns + ` FROM task_attachments WHERE task_id = $1 ORDER BY id`
+	if err := r.db.SelectContext(ctx, &attachments, query, taskID); err != nil {
+		return nil, err
+	}
+	return attachments, nil
+}
+
+// GetByID returns an attachment that is still attached to a task.
+func (r *AttachmentRepositoryImpl) GetByID(ctx context.Context, attachmentID int64) (*model.Attachment, error) {
+	var attachment model.Attachment
+	query := `SELECT ` + attachmentColumns + ` FROM task_attachments WHERE id = $1 AND task_id IS NOT NULL`
+	if err := r.db.GetContext(ctx, &attachment, query, attachmentID); err != nil {
+		return nil, err
+	}
+	return &attachment, nil
+}
+
+// Detach removes an attachment from its task, leaving the row and its
+// blob for ListOrphaned to find.
+func (r *AttachmentRepositoryImpl) Detach(ctx context.Context, attachmentID int64, taskID int64) error {
+	query := `UPDATE task_attachments SET task_id = NULL WHERE id = $1 AND task_id = $2`
+	result, err := r.db.ExecContext(ctx, query, attachmentID, taskID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// ListOrphaned returns up to limit attachments whose task, or which
+// themselves, have been deleted.
+func (r *AttachmentRepositoryImpl) ListOrphaned(ctx context.Context, limit int) ([]*model.Attachment, error) {
+	attachments := []*model.Attachment{}
+	query := `SELECT ` + attachmentColumns + ` FROM task_attachments WHERE task_id IS NULL ORDER BY id LIMIT $1`
+	if err := r.db.SelectContext(ctx, &attachments, query, limit); err != nil {
+		return nil, err
+	}
+	return attachments, nil
+}
+
+// Purge deletes the row of an orphaned attachment once its blob is gone.
+func (r *AttachmentRepositoryImpl) Purge(ctx context.Context, attachmentID int64) error {
+	query := `DELETE FROM task_attachments WHERE id = $1 AND task_id IS NULL`
+	_, err := r.db.ExecContext(ctx, query, attachmentID)
+	return err
+}
@@ -0,0 +1,87 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/ahmednurovic/task-manager-api/internal/model"
+	"github.com/jmoiron/sqlx"
+)
+
+type ChecklistRepository interface {
+	Create(ctx context.Context, item *model.ChecklistItem) error
+	ListForTask(ctx context.Context, taskID int64) ([]*model.ChecklistItem, error)
+	GetByID(ctx context.Context, itemID int64) (*model.ChecklistItem, error)
+	Update(ctx context.Context, item *model.ChecklistItem) error
+	Delete(ctx context.Context, itemID int64, taskID int64) error
+}
+
+const checklistColumns = `id, task_id, title, done, position, created_at, updated_at`
+
+type ChecklistRepositoryImpl struct {
+	db *sqlx.DB
+}
+
+func NewChecklistRepository(db *sqlx.DB) *ChecklistRepositoryImpl {
+	return &ChecklistRepositoryImpl{db: db}
+}
+
+// Create inserts a checklist item. A negative position appends it after the
+// task's other items.
+func (r *ChecklistRepositoryImpl) Create(ctx context.Context, item *model.ChecklistItem) error {
+	query := `INSERT INTO task_checklist_items (task_id, title, done, position)
+		VALUES ($1, $2, $3,
+			CASE WHEN $4 >= 0 THEN $4 ELSE (SELECT COALESCE(MAX(position) + 1, 0) FROM task_checklist_items WHERE task_id = $1) END)
+		RETURNING id, position, created_at, updated_at`
+	return r.db.QueryRowContext(ctx, query,
+		item.TaskID, item.Title, item.Done, item.Position,
+	).Scan(&item.ID, &item.Position, &item.CreatedAt, &item.UpdatedAt)
+}
+
+func (r *ChecklistRepositoryImpl) ListForTask(ctx context.Context, taskID int64) ([]*model.ChecklistItem, error) {
+	items := []*model.ChecklistItem{}
+	query := `SELECT ` + checklistColumns + ` FROM task_checklist_items WHERE task_id = $1 ORDER BY position, id`
+	err := r.db.SelectContext(ctx, &items, query, taskID)
+	if err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+func (r *ChecklistRepositoryImpl) GetByID(ctx context.Context, itemID int64) (*model.ChecklistItem, error) {
+	var item model.ChecklistItem
+	query := `SELECT ` + checklistColumns + ` FROM task_checklist_items WHERE id = $1`
+	err := r.db.GetContext(ctx, &item, query, itemID)
+	if err != nil {
+		return nil, err
+	}
+	return &item, nil
+}
+
+func (r *ChecklistRepositoryImpl) Update(ctx context.Context, item *model.ChecklistItem) error {
+	query := `UPDATE task_checklist_items
+		SET title = $1, done = $2, position = $3, updated_at = NOW()
+		WHERE id = $4 AND task_id = $5
+		RETURNING updated_at`
+	return r.db.QueryRowContext(ctx, query,
+		item.Title, item.Done, item.Position, item.ID, item.TaskID,
+	).Scan(&item.UpdatedAt)
+}
+
+func (r *ChecklistRepositoryImpl) Delete(ctx context.Context, itemID int64, taskID int64) error {
+	query := `DELETE FROM task_checklist_items WHERE id = $1 AND task_id = $2`
+	result, err := r.db.ExecContext(ctx, query, itemID, taskID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
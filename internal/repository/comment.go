@@ -0,0 +1,242 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"strconv"
+
+	"github.com/ahmednurovic/task-manager-api/internal/model"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+type CommentRepository interface {
+	Create(ctx context.Context, comment *model.Comment) error
+	List(ctx context.Context, taskID int64, cursor string, limit int) (*model.CommentPage, error)
+	GetByID(ctx context.Context, commentID int64) (*model.Comment, error)
+	Update(ctx context.Context, comment *model.Comment, editorID int64) error
+	Delete(ctx context.Context, commentID int64, taskID int64, deletedBy int64) error
+	ListRevisions(ctx context.Context, commentID int64) ([]*model.CommentRevision, error)
+}
+
+const commentColumns = `id, task_id, author_id, body, edited_at IS NOT NULL AS edited, created_at, updated_at, edited_at`
+
+type CommentRepositoryImpl struct {
+	db *sqlx.DB
+}
+
+func NewCommentRepository(db *sqlx.DB) *CommentRepositoryImpl {
+	return &CommentRepositoryImpl{db: db}
+}
+
+// Create inserts a comment together with its mentions.
+func (r *CommentRepositoryImpl) Create(ctx context.Context, comment *model.Comment) error {
+	query := `WITH inserted AS (
+			INSERT INTO task_comments (task_id, author_id, body)
+			VALUES ($1, $2, $3)
+			RETURNING id, created_at, updated_at
+		), mentioned AS (
+			INSERT INTO task_comment_mentions (comment_id, user_id)
+			SELECT inserted.id, unnest($4::int[]) FROM inserted
+		)
+		SELECT id, created_at, updated_at FROM inserted`
+	return r.db.QueryRowContext(ctx, query,
+		comment.TaskID, comment.AuthorID, comment.Body, pq.Array(mentionIDs(comment.Mentions)),
+	).Scan(&comment.ID, &comment.CreatedAt, &comment.UpdatedAt)
+}
+
+// List returns one page of a task's comments, oldest first. Cursor is the
+// NextCursor of the previous page, or empty for the first.
+func (r *CommentRepositoryImpl) List(ctx context.Context, taskID int64, cursor string, limit int) (*model.CommentPage, error) {
+	afterID := int64(0)
+	if cursor != "" {
+		raw, err := base64.RawURLEncoding.DecodeString(cursor)
+		if err != nil {
+			return nil, ErrInvalidCursor
+		}
+		if afterID, err = strconv.ParseInt(string(raw), 10, 64); err != nil {
+			return nil, ErrInvalidCursor
+		}
+	}
+
+	comments := []*model.Comment{}
+	query := `SELECT ` + commentColumns + ` FROM task_comments
+		WHERE task_id = $1 AND deleted_at IS NULL AND id > $2
+		ORDER BY id
+		LIMIT $3`
+	if err := r.db.SelectContext(ctx, &comments, query, taskID, afterID, limit+1); err != nil {
+		return nil, err
+	}
+
+	page := &model.CommentPage{Items: comments}
+	if len(comments) > limit {
+		page.Items = comments[:limit]
+		last := strconv.FormatUint(uint64(page.Items[limit-1].ID), 10)
+		page.NextCursor = base64.RawURLEncoding.EncodeToString([]byte(last))
+	}
+
+	if err := loadCommentMentions(ctx, r.db, page.Items); err != nil {
+		return nil, err
+	}
+	return page, nil
+}
+
+// GetByID returns a comment that has not been deleted.
+func (r *CommentRepositoryImpl) GetByID(ctx context.Context, commentID int64) (*model.Comment, error) {
+	var comment model.Comment
+	query := `SELECT ` + commentColumns + ` FROM task_comments WHERE id = $1 AND deleted_at IS NULL`
+	if err := r.db.GetContext(ctx, &comment, query, commentID); err != nil {
+		return nil, err
+	}
+	if err := loadCommentMentions(ctx, r.db, []*model.Comment{&comment}); err != nil {
+		return nil, err
+	}
+	return &comment, nil
+}
+
+// Update replaces the body and mentions of a comment, keeping the body it
+// had before as a revision by editorID, and marks the comment edited.
+func (r *CommentRepositoryImpl) Update(ctx context.Context, comment *model.Comment, editorID int64) error {
+	query := `WITH previous AS (
+			SELECT body FROM task_comments
+			WHERE id = $1 AND task_id = $2 AND deleted_at IS NULL
+			FOR UPDATE
+		), revision AS (
+			INSERT INTO task_comment_revisions (comment_id, body, editor_id)
+			SELECT $1, body, $4 FROM previous
+		), unmentioned AS (
+			DELETE FROM task_comment_mentions
+			WHERE comment_id = $1 AND user_id <> ALL($5::int[]) AND EXISTS (SELECT 1 FROM previous)
+		), mentioned AS (
+			INSERT INTO task_comment_mentions (comment_id, user_id)
+			SELECT $1, unnest($5::int[]) FROM previous
+			ON CONFLICT DO NOTHING
+		)
+		UPDATE task_comments
+		SET body = $3, edited_at = NOW(), updated_at = NOW()
+		WHERE id = $1 AND task_id = $2 AND deleted_at IS NULL
+		RETURNING updated_at, edited_at`
+	err := r.db.QueryRowContext(ctx, query,
+		comment.ID, comment.TaskID, comment.Body, editorID, pq.Array(mentionIDs(comment.Mentions)),
+	).Scan(&comment.UpdatedAt, &comment.EditedAt)
+	if err != nil {
+		return err
+	}
+
+	comment.Edited = true
+	return nil
+}
+
+// Delete soft-deletes a comment, recording who deleted it.
+func (r *CommentRepositoryImpl) Delete(ctx context.Context, commentID int64, taskID int64, deletedBy int64) error {
+	query := `UPDATE task_comments SET deleted_at = NOW(), deleted_by = $3
+		WHERE id = $1 AND task_id = $2 AND deleted_at IS NULL`
+	result, err := r.db.ExecContext(ctx, query, commentID, taskID, deletedBy)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// ListRevisions returns the earlier bodies of a comment, oldest first.
+func (r *CommentRepositoryImpl) ListRevisions(ctx context.Context, commentID int64) ([]*model.CommentRevision, error) {
+	revisions := []*model.CommentRevision{}
+	query := `SELECT id, comment_id, body, editor_id, created_at
+		FROM task_comment_revisions
+		WHERE comment_id = $1
+		ORDER BY id`
+	if err := r.db.SelectContext(ctx, &revisions, query, commentID); err != nil {
+		return nil, err
+	}
+	return revisions, nil
+}
+
+func mentionIDs(mentions []model.Mention) []int64 {
+	ids := make([]int64, len(mentions))
+	for i, mention := range mentions {
+		ids[i] = int64(mention.UserID)
+	}
+	return ids
+}
+
+// loadCommentMentions fills in the mentions of every comment with a single
+// query.
+func loadCommentMentions(ctx context.Context, db *sqlx.DB, comments []*model.Comment) error {
+	if len(comments) == 0 {
+		return nil
+	}
+
+	commentIDs := make([]int64, len(comments))
+	byID := make(map[uint]*model.Comment, len(comments))
+	for i, comment := range comments {
+		comment.Mentions = []model.Mention{}
+		commentIDs[i] = int64(comment.ID)
+		byID[comment.ID] = comment
+	}
+
+	var rows []struct {
+		CommentID uint `db:"comment_id"`
+		model.Mention
+	}
+	query := `SELECT m.comment_id, u.id AS user_id, u.email
+		FROM task_comment_mentions m
+		JOIN users u ON u.id = m.user_id
+		WHERE m.comment_id = ANY($1)
+		ORDER BY u.email`
+	if err := db.SelectContext(ctx, &rows, query, pq.Array(commentIDs)); err != nil {
+		return err
+	}
+
+	for _, row := range rows {
+		if comment, ok := byID[row.CommentID]; ok {
+			comment.Mentions = append(comment.Mentions, row.Mention)
+		}
+	}
+
+	return nil
+}
+
+// loadTaskCommentCounts fills in how many comments every task has.
+func loadTaskCommentCounts(ctx context.Context, db dbtx, tasks []*model.Task) error {
+	if len(tasks) == 0 {
+		return nil
+	}
+
+	taskIDs := make([]int64, len(tasks))
+	byID := make(map[uint]*model.Task, len(tasks))
+	for i, task := range tasks {
+		task.CommentCount = 0
+		taskIDs[i] = int64(task.ID)
+		byID[task.ID] = task
+	}
+
+	var rows []struct {
+		TaskID uint `db:"task_id"`
+		Count  int  `db:"count"`
+	}
+	query := `SELECT task_id, COUNT(*) AS count
+		FROM task_comments
+		WHERE task_id = ANY($1) AND deleted_at IS NULL
+		GROUP BY task_id`
+	if err := db.SelectContext(ctx, &rows, query, pq.Array(taskIDs)); err != nil {
+		return err
+	}
+
+	for _, row := range rows {
+		if task, ok := byID[row.TaskID]; ok {
+			task.CommentCount = row.Count
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,129 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/ahmednurovic/task-manager-api/internal/model"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+type DependencyRepository interface {
+	Add(ctx context.Context, taskID int64, blockerID int64) error
+	Remove(ctx context.Context, taskID int64, blockerID int64) error
+	ListBlockers(ctx context.Context, taskID int64) ([]model.TaskRef, error)
+	ListBlocked(ctx context.Context, taskID int64) ([]model.TaskRef, error)
+	DependsOn(ctx context.Context, taskID int64, otherID int64) (bool, error)
+	CountOpenBlockers(ctx context.Context, taskID int64) (int, error)
+	Graph(ctx context.Context, taskID int64) ([]model.TaskRef, []model.DependencyEdge, error)
+}
+
+// upstreamCTE walks the blocked-by edges from $1, yielding every edge on
+// the way. UNION drops repeated edges, so the walk ends even if the data
+// somehow contains a cycle.
+const upstreamCTE = `WITH RECURSIVE upstream (task_id, blocker_id) AS (
+		SELECT task_id, blocker_id FROM task_dependencies WHERE task_id = $1
+		UNION
+		SELECT d.task_id, d.blocker_id
+		FROM task_dependencies d
+		JOIN upstream u ON d.task_id = u.blocker_id
+	)`
+
+type DependencyRepositoryImpl struct {
+	db *sqlx.DB
+}
+
+func NewDependencyRepository(db *sqlx.DB) *DependencyRepositoryImpl {
+	return &DependencyRepositoryImpl{db: db}
+}
+
+// Add records that the task is blocked by blockerID. Adding an existing
+// dependency is a no-op.
+func (r *DependencyRepositoryImpl) Add(ctx context.Context, taskID int64, blockerID int64) error {
+	query := `INSERT INTO task_dependencies (task_id, blocker_id) VALUES ($1, $2) ON CONFLICT DO NOTHING`
+	_, err := r.db.ExecContext(ctx, query, taskID, blockerID)
+	return err
+}
+
+// Remove deletes a dependency. Removing one that does not exist is a no-op.
+func (r *DependencyRepositoryImpl) Remove(ctx context.Context, taskID int64, blockerID int64) error {
+	query := `DELETE FROM task_dependencies WHERE task_id = $1 AND blocker_id = $2`
+	_, err := r.db.ExecContext(ctx, query, taskID, blockerID)
+	return err
+}
+
+// ListBlockers returns the tasks that directly block the task.
+func (r *DependencyRepositoryImpl) ListBlockers(ctx context.Context, taskID int64) ([]model.TaskRef, error) {
+	blockers := []model.TaskRef{}
+	query := `SELECT t.id, t.title, t.status
+		FROM task_dependencies d
+		JOIN tasks t ON t.id = d.blocker_id
+		WHERE d.task_id = $1
+		ORDER BY t.id`
+	err := r.db.SelectContext(ctx, &blockers, query, taskID)
+	if err != nil {
+		return nil, err
+	}
+	return blockers, nil
+}
+
+// ListBlocked returns the tasks that the task directly blocks.
+func (r *DependencyRepositoryImpl) ListBlocked(ctx context.Context, taskID int64) ([]model.TaskRef, error) {
+	blocked := []model.TaskRef{}
+	query := `SELECT t.id, t.title, t.status
+		FROM task_dependencies d
+		JOIN tasks t ON t.id = d.task_id
+		WHERE d.blocker_id = $1
+		ORDER BY t.id`
+	err := r.db.SelectContext(ctx, &blocked, query, taskID)
+	if err != nil {
+		return nil, err
+	}
+	return blocked, nil
+}
+
+// DependsOn reports whether the task is blocked by otherID, directly or
+// through other tasks.
+func (r *DependencyRepositoryImpl) DependsOn(ctx context.Context, taskID int64, otherID int64) (bool, error) {
+	var exists bool
+	query := upstreamCTE + `
+		SELECT EXISTS (SELECT 1 FROM upstream WHERE blocker_id = $2)`
+	err := r.db.GetContext(ctx, &exists, query, taskID, otherID)
+	return exists, err
+}
+
+// CountOpenBlockers counts the direct blockers of the task that are neither
+// done nor cancelled.
+func (r *DependencyRepositoryImpl) CountOpenBlockers(ctx context.Context, taskID int64) (int, error) {
+	var count int
+	query := `SELECT COUNT(*)
+		FROM task_dependencies d
+		JOIN tasks t ON t.id = d.blocker_id
+		WHERE d.task_id = $1 AND t.status NOT IN ('done', 'cancelled')`
+	err := r.db.GetContext(ctx, &count, query, taskID)
+	return count, err
+}
+
+// Graph returns every task the task transitively depends on, itself
+// included, and the edges between them.
+func (r *DependencyRepositoryImpl) Graph(ctx context.Context, taskID int64) ([]model.TaskRef, []model.DependencyEdge, error) {
+	edges := []model.DependencyEdge{}
+	edgeQuery := upstreamCTE + `
+		SELECT task_id, blocker_id FROM upstream ORDER BY task_id, blocker_id`
+	if err := r.db.SelectContext(ctx, &edges, edgeQuery, taskID); err != nil {
+		return nil, nil, err
+	}
+
+	nodeIDs := []int64{taskID}
+	for _, edge := range edges {
+		nodeIDs = append(nodeIDs, int64(edge.BlockerID))
+	}
+
+	nodes := []model.TaskRef{}
+	nodeQuery := `SELECT id, title, status FROM tasks WHERE id = ANY($1) ORDER BY id`
+	if err := r.db.SelectContext(ctx, &nodes, nodeQuery, pq.Array(nodeIDs)); err != nil {
+		return nil, nil, err
+	}
+
+	return nodes, edges, nil
+}
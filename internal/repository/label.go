@@ -0,0 +1,152 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/ahmednurovic/task-manager-api/internal/model"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+var ErrDuplicateLabel = errors.New("duplicate label name")
+
+type LabelRepository interface {
+	Create(ctx context.Context, label *model.Label) error
+	GetByID(ctx context.Context, labelID int64) (*model.Label, error)
+	ListForUser(ctx context.Context, userID int64) ([]*model.Label, error)
+	Update(ctx context.Context, label *model.Label) error
+	Delete(ctx context.Context, labelID int64, userID int64) error
+	AttachToTask(ctx context.Context, taskID int64, labelID int64) error
+	DetachFromTask(ctx context.Context, taskID int64, labelID int64) error
+}
+
+type LabelRepositoryImpl struct {
+	db *sqlx.DB
+}
+
+func NewLabelRepository(db *sqlx.DB) *LabelRepositoryImpl {
+	return &LabelRepositoryImpl{db: db}
+}
+
+func (r *LabelRepositoryImpl) Create(ctx context.Context, label *model.Label) error {
+	query := `INSERT INTO labels (user_id, name, color) VALUES ($1, $2, $3) RETURNING id, created_at`
+	err := r.db.QueryRowContext(ctx, query, label.UserID, label.Name, label.Color).Scan(&label.ID, &label.CreatedAt)
+	return translateLabelError(err)
+}
+
+func (r *LabelRepositoryImpl) GetByID(ctx context.Context, labelID int64) (*model.Label, error) {
+	var label model.Label
+	query := `SELECT id, user_id, name, color, created_at FROM labels WHERE id = $1`
+	err := r.db.GetContext(ctx, &label, query, labelID)
+	if err != nil {
+		return nil, err
+	}
+	return &label, nil
+}
+
+func (r *LabelRepositoryImpl) ListForUser(ctx context.Context, userID int64) ([]*model.Label, error) {
+	labels := []*model.Label{}
+	query := `SELECT id, user_id, name, color, created_at FROM labels WHERE user_id = $1 ORDER BY lower(name), id`
+	err := r.db.SelectContext(ctx, &labels, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	return labels, nil
+}
+
+func (r *LabelRepositoryImpl) Update(ctx context.Context, label *model.Label) error {
+	query := `UPDATE labels SET name = $1, color = $2 WHERE id = $3 AND user_id = $4`
+	result, err := r.db.ExecContext(ctx, query, label.Name, label.Color, label.ID, label.UserID)
+	if err != nil {
+		return translateLabelError(err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+func (r *LabelRepositoryImpl) Delete(ctx context.Context, labelID int64, userID int64) error {
+	query := `DELETE FROM labels WHERE id = $1 AND user_id = $2`
+	result, err := r.db.ExecContext(ctx, query, labelID, userID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// AttachToTask labels a task. Attaching a label twice is a no-op.
+func (r *LabelRepositoryImpl) AttachToTask(ctx context.Context, taskID int64, labelID int64) error {
+	query := `INSERT INTO task_labels (task_id, label_id) VALUES ($1, $2) ON CONFLICT DO NOTHING`
+	_, err := r.db.ExecContext(ctx, query, taskID, labelID)
+	return err
+}
+
+// DetachFromTask removes a label from a task. Detaching a label the task
+// does not carry is a no-op.
+func (r *LabelRepositoryImpl) DetachFromTask(ctx context.Context, taskID int64, labelID int64) error {
+	query := `DELETE FROM task_labels WHERE task_id = $1 AND label_id = $2`
+	_, err := r.db.ExecContext(ctx, query, taskID, labelID)
+	return err
+}
+
+// loadTaskLabels fills in the labels of every task with a single query.
+func loadTaskLabels(ctx context.Context, db dbtx, tasks []*model.Task) error {
+	if len(tasks) == 0 {
+		return nil
+	}
+
+	taskIDs := make([]int64, len(tasks))
+	byID := make(map[uint]*model.Task, len(tasks))
+	for i, task := range tasks {
+		task.Labels = []model.Label{}
+		taskIDs[i] = int64(task.ID)
+		byID[task.ID] = task
+	}
+
+	var rows []struct {
+		TaskID uint `db:"task_id"`
+		model.Label
+	}
+	query := `SELECT tl.task_id, l.id, l.user_id, l.name, l.color, l.created_at
+		FROM task_labels tl
+		JOIN labels l ON l.id = tl.label_id
+		WHERE tl.task_id = ANY($1)
+		ORDER BY lower(l.name), l.id`
+	if err := db.SelectContext(ctx, &rows, query, pq.Array(taskIDs)); err != nil {
+		return err
+	}
+
+	for _, row := range rows {
+		if task, ok := byID[row.TaskID]; ok {
+			task.Labels = append(task.Labels, row.Label)
+		}
+	}
+
+	return nil
+}
+
+func translateLabelError(err error) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+		return ErrDuplicateLabel
+	}
+	return err
+}
@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/ahmednurovic/task-manager-api/internal/model"
+	"github.com/jmoiron/sqlx"
+)
+
+// LoginFailureRepository counts failed logins per account and per IP
+// address, and records lockouts.
+type LoginFailureRepository interface {
+	Get(ctx context.Context, scope, identifier string) (*model.LoginFailure, error)
+	RecordFailure(ctx context.Context, scope, identifier string, now, windowStart time.Time) (*model.LoginFailure, error)
+	Lock(ctx context.Context, scope, identifier string, until time.Time) error
+	Clear(ctx context.Context, scope, identifier string) error
+	DeleteExpired(ctx context.Context, now, windowStart time.Time) (int64, error)
+}
+
+const loginFailureColumns = `scope, identifier, failures, window_started_at, locked_until`
+
+type LoginFailureRepositoryImpl struct {
+	db *sqlx.DB
+}
+
+func NewLoginFailureRepository(db *sqlx.DB) *LoginFailureRepositoryImpl {
+	return &LoginFailureRepositoryImpl{db: db}
+}
+
+// Get returns sql.ErrNoRows if there have been no recent failures.
+func (r *LoginFailureRepositoryImpl) Get(ctx context.Context, scope, identifier string) (*model.LoginFailure, error) {
+	var failure model.LoginFailure
+	query := `SELECT ` + loginFailureColumns + ` FROM login_failures WHERE scope = $1 AND identifier = $2`
+	if err := r.db.GetContext(ctx, &failure, query, scope, identifier); err != nil {
+		return nil, err
+	}
+	return &failure, nil
+}
+
+// RecordFailure counts a failure and returns the updated count. A window
+// that started before windowStart is over, and counting starts again.
+func (r *LoginFailureRepositoryImpl) RecordFailure(ctx context.Context, scope, identifier string, now, windowStart time.Time) (*model.LoginFailure, error) {
+	var failure model.LoginFailure
+	query := `INSERT INTO login_failures (scope, identifier, failures, window_started_at)
+		VALUES ($1, $2, 1, $3)
+		ON CONFLICT (scope, identifier) DO UPDATE SET
+			failures = CASE WHEN login_failures.window_started_at < $4 THEN 1 ELSE login_failures.failures + 1 END,
+			window_started_at = CASE WHEN login_failures.window_started_at < $4 THEN $3 ELSE login_failures.window_started_at END
+		RETURNING ` + loginFailureColumns
+	if err := r.db.GetContext(ctx, &failure, query, scope, identifier, now, windowStart); err != nil {
+		return nil, err
+	}
+	return &failure, nil
+}
+
+// Lock refuses logins until the given time. A later lock already in place
+// is kept.
+func (r *LoginFailureRepositoryImpl) Lock(ctx context.Context, scope, identifier string, until time.Time) error {
+	query := `UPDATE login_failures SET locked_until = GREATEST(locked_until, $3)
+		WHERE scope = $1 AND identifier = $2`
+	_, err := r.db.ExecContext(ctx, query, scope, identifier, until)
+	return err
+}
+
+// Clear forgets the failures and any lock.
+func (r *LoginFailureRepositoryImpl) Clear(ctx context.Context, scope, identifier string) error {
+	query := `DELETE FROM login_failures WHERE scope = $1 AND identifier = $2`
+	_, err := r.db.ExecContext(ctx, query, scope, identifier)
+	return err
+}
+
+// DeleteExpired deletes counts whose window is over and whose lock, if
+// any, has run out.
+func (r *LoginFailureRepositoryImpl) DeleteExpired(ctx context.Context, now, windowStart time.Time) (int64, error) {
+	query := `DELETE FROM login_failures
+		WHERE window_started_at < $2 AND (locked_until IS NULL OR locked_until <= $1)`
+	result, err := r.db.ExecContext(ctx, query, now, windowStart)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
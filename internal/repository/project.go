@@ -0,0 +1,114 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/ahmednurovic/task-manager-api/internal/model"
+	"github.com/jmoiron/sqlx"
+)
+
+type ProjectRepository interface {
+	Create(ctx context.Context, project *model.Project) error
+	GetByID(ctx context.Context, projectID int64) (*model.Project, error)
+	List(ctx context.Context, userID int64, workspaceID *uint, includeArchived bool) ([]*model.Project, error)
+	InboxCounts(ctx context.Context, userID int64, workspaceID *uint) (model.ProjectCounts, error)
+	Update(ctx context.Context, project *model.Project) error
+	Delete(ctx context.Context, projectID int64, userID int64) error
+}
+
+// projectCountColumns aggregates the tasks LEFT JOINed as t.
+const projectCountColumns = `COUNT(t.id) FILTER (WHERE t.status IN ('pending', 'in_progress', 'blocked')) AS open_count,
+	COUNT(t.id) FILTER (WHERE t.status = 'done') AS done_count`
+
+const projectColumns = `p.id, p.user_id, p.workspace_id, p.name, p.description, p.color, p.archived, p.position, p.created_at, p.updated_at`
+
+type ProjectRepositoryImpl struct {
+	db *sqlx.DB
+}
+
+func NewProjectRepository(db *sqlx.DB) *ProjectRepositoryImpl {
+	return &ProjectRepositoryImpl{db: db}
+}
+
+// Create inserts a project. A negative position appends it after the other
+// projects in the user's personal space or in its workspace.
+func (r *ProjectRepositoryImpl) Create(ctx context.Context, project *model.Project) error {
+	args := queryArgs{project.UserID, project.WorkspaceID, project.Name, project.Description, project.Color, project.Archived, project.Position}
+	space := spaceCondition("p", &args, int64(project.UserID), project.WorkspaceID)
+	query := `INSERT INTO projects (user_id, workspace_id, name, description, color, archived, position)
+		VALUES ($1, $2, $3, $4, $5, $6,
+			CASE WHEN $7 >= 0 THEN $7 ELSE (SELECT COALESCE(MAX(p.position) + 1, 0) FROM projects p WHERE ` + space + `) END)
+		RETURNING id, position, created_at, updated_at`
+	return r.db.QueryRowContext(ctx, query, args...).Scan(&project.ID, &project.Position, &project.CreatedAt, &project.UpdatedAt)
+}
+
+func (r *ProjectRepositoryImpl) GetByID(ctx context.Context, projectID int64) (*model.Project, error) {
+	var project model.Project
+	query := `SELECT ` + projectColumns + `, ` + projectCountColumns + `
+		FROM projects p
+		LEFT JOIN tasks t ON t.project_id = p.id
+		WHERE p.id = $1
+		GROUP BY p.id`
+	err := r.db.GetContext(ctx, &project, query, projectID)
+	if err != nil {
+		return nil, err
+	}
+	return &project, nil
+}
+
+// List returns the user's personal projects, or the projects in the
+// workspace if workspaceID is set.
+func (r *ProjectRepositoryImpl) List(ctx context.Context, userID int64, workspaceID *uint, includeArchived bool) ([]*model.Project, error) {
+	projects := []*model.Project{}
+	args := queryArgs{includeArchived}
+	query := `SELECT ` + projectColumns + `, ` + projectCountColumns + `
+		FROM projects p
+		LEFT JOIN tasks t ON t.project_id = p.id
+		WHERE ` + spaceCondition("p", &args, userID, workspaceID) + ` AND ($1 OR NOT p.archived)
+		GROUP BY p.id
+		ORDER BY p.position, p.id`
+	err := r.db.SelectContext(ctx, &projects, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	return projects, nil
+}
+
+func (r *ProjectRepositoryImpl) InboxCounts(ctx context.Context, userID int64, workspaceID *uint) (model.ProjectCounts, error) {
+	var counts model.ProjectCounts
+	var args queryArgs
+	query := `SELECT ` + projectCountColumns + `
+		FROM tasks t
+		WHERE ` + spaceCondition("t", &args, userID, workspaceID) + ` AND t.project_id IS NULL`
+	err := r.db.GetContext(ctx, &counts, query, args...)
+	return counts, err
+}
+
+func (r *ProjectRepositoryImpl) Update(ctx context.Context, project *model.Project) error {
+	query := `UPDATE projects
+		SET name = $1, description = $2, color = $3, archived = $4, position = $5, updated_at = NOW()
+		WHERE id = $6 AND user_id = $7
+		RETURNING updated_at`
+	return r.db.QueryRowContext(ctx, query,
+		project.Name, project.Description, project.Color, project.Archived, project.Position, project.ID, project.UserID,
+	).Scan(&project.UpdatedAt)
+}
+
+func (r *ProjectRepositoryImpl) Delete(ctx context.Context, projectID int64, userID int64) error {
+	query := `DELETE FROM projects WHERE id = $1 AND user_id = $2`
+	result, err := r.db.ExecContext(ctx, query, projectID, userID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
@@ -0,0 +1,71 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/ahmednurovic/task-manager-api/internal/model"
+	"github.com/jmoiron/sqlx"
+)
+
+type RefreshTokenRepository interface {
+	Create(ctx context.Context, token *model.RefreshToken) error
+	GetByHash(ctx context.Context, tokenHash string) (*model.RefreshToken, error)
+	Rotate(ctx context.Context, usedID int64, next *model.RefreshToken) error
+	RevokeFamily(ctx context.Context, familyID string) error
+	RevokeForUser(ctx context.Context, userID uint) error
+}
+
+const refreshTokenColumns = `id, user_id, family_id, token_hash, expires_at, created_at, used_at, revoked_at`
+
+type RefreshTokenRepositoryImpl struct {
+	db dbtx
+}
+
+func NewRefreshTokenRepository(db *sqlx.DB) *RefreshTokenRepositoryImpl {
+	return &RefreshTokenRepositoryImpl{db: db}
+}
+
+func (r *RefreshTokenRepositoryImpl) Create(ctx context.Context, token *model.RefreshToken) error {
+	query := `INSERT INTO refresh_tokens (user_id, family_id, token_hash, expires_at)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at`
+	return r.db.QueryRowContext(ctx, query, token.UserID, token.FamilyID, token.TokenHash, token.ExpiresAt).
+		Scan(&token.ID, &token.CreatedAt)
+}
+
+func (r *RefreshTokenRepositoryImpl) GetByHash(ctx context.Context, tokenHash string) (*model.RefreshToken, error) {
+	var token model.RefreshToken
+	query := `SELECT ` + refreshTokenColumns + ` FROM refresh_tokens WHERE token_hash = $1`
+	if err := r.db.GetContext(ctx, &token, query, tokenHash); err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+// Rotate marks a token used and stores its replacement in one statement.
+// It returns sql.ErrNoRows, and stores nothing, if the token has already
+// been used or revoked, so two concurrent refreshes cannot both succeed.
+func (r *RefreshTokenRepositoryImpl) Rotate(ctx context.Context, usedID int64, next *model.RefreshToken) error {
+	query := `WITH used AS (
+			UPDATE refresh_tokens SET used_at = NOW()
+			WHERE id = $1 AND used_at IS NULL AND revoked_at IS NULL
+			RETURNING id
+		)
+		INSERT INTO refresh_tokens (user_id, family_id, token_hash, expires_at)
+		SELECT $2, $3, $4, $5 FROM used
+		RETURNING id, created_at`
+	return r.db.QueryRowContext(ctx, query, usedID, next.UserID, next.FamilyID, next.TokenHash, next.ExpiresAt).
+		Scan(&next.ID, &next.CreatedAt)
+}
+
+func (r *RefreshTokenRepositoryImpl) RevokeFamily(ctx context.Context, familyID string) error {
+	query := `UPDATE refresh_tokens SET revoked_at = NOW() WHERE family_id = $1 AND revoked_at IS NULL`
+	_, err := r.db.ExecContext(ctx, query, familyID)
+	return err
+}
+
+func (r *RefreshTokenRepositoryImpl) RevokeForUser(ctx context.Context, userID uint) error {
+	query := `UPDATE refresh_tokens SET revoked_at = NOW() WHERE user_id = $1 AND revoked_at IS NULL`
+	_, err := r.db.ExecContext(ctx, query, userID)
+	return err
+}
@@ -0,0 +1,64 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// RevocationRepository stores revoked access tokens and the per-user token
+// version that invalidates all of a user's earlier tokens at once.
+type RevocationRepository interface {
+	Revoke(ctx context.Context, jti string, userID uint, expiresAt time.Time) error
+	IsRevoked(ctx context.Context, jti string) (bool, error)
+	DeleteExpired(ctx context.Context) (int64, error)
+	GetTokenVersion(ctx context.Context, userID uint) (int, error)
+	IncrementTokenVersion(ctx context.Context, userID uint) (int, error)
+}
+
+type RevocationRepositoryImpl struct {
+	db dbtx
+}
+
+func NewRevocationRepository(db *sqlx.DB) *RevocationRepositoryImpl {
+	return &RevocationRepositoryImpl{db: db}
+}
+
+func (r *RevocationRepositoryImpl) Revoke(ctx context.Context, jti string, userID uint, expiresAt time.Time) error {
+	query := `INSERT INTO revoked_tokens (jti, user_id, expires_at) VALUES ($1, $2, $3)
+		ON CONFLICT (jti) DO NOTHING`
+	_, err := r.db.ExecContext(ctx, query, jti, userID, expiresAt)
+	return err
+}
+
+func (r *RevocationRepositoryImpl) IsRevoked(ctx context.Context, jti string) (bool, error) {
+	var revoked bool
+	query := `SELECT EXISTS (SELECT 1 FROM revoked_tokens WHERE jti = $1)`
+	err := r.db.GetContext(ctx, &revoked, query, jti)
+	return revoked, err
+}
+
+func (r *RevocationRepositoryImpl) DeleteExpired(ctx context.Context) (int64, error) {
+	query := `DELETE FROM revoked_tokens WHERE expires_at <= NOW()`
+	result, err := r.db.ExecContext(ctx, query)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// GetTokenVersion returns sql.ErrNoRows if the user does not exist.
+func (r *RevocationRepositoryImpl) GetTokenVersion(ctx context.Context, userID uint) (int, error) {
+	var version int
+	query := `SELECT token_version FROM users WHERE id = $1`
+	err := r.db.GetContext(ctx, &version, query, userID)
+	return version, err
+}
+
+func (r *RevocationRepositoryImpl) IncrementTokenVersion(ctx context.Context, userID uint) (int, error) {
+	var version int
+	query := `UPDATE users SET token_version = token_version + 1 WHERE id = $1 RETURNING token_version`
+	err := r.db.GetContext(ctx, &version, query, userID)
+	return version, err
+}
@@ -3,55 +3,226 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"strings"
 
 	"github.com/ahmednurovic/task-manager-api/internal/model"
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 )
 
 type TaskRepository interface {
 	Create(ctx context.Context, task *model.Task) error
-	GetAllForUser(ctx context.Context, userID int64) ([]*model.Task, error)
+	List(ctx context.Context, userID int64, filter model.TaskFilter) (*model.TaskPage, error)
+	Search(ctx context.Context, userID int64, workspaceID *uint, query string, limit int) ([]*model.TaskSearchResult, error)
 	GetByID(ctx context.Context, taskID int64) (*model.Task, error)
 	Update(ctx context.Context, task *model.Task) error
 	Delete(ctx context.Context, taskID int64, userID int64) error
+	GetAncestorIDs(ctx context.Context, taskID int64) ([]uint, error)
+	SubtreeHeight(ctx context.Context, taskID int64) (int, error)
+	CountOpenDescendants(ctx context.Context, taskID int64) (int, error)
+	CancelOpenDescendants(ctx context.Context, taskID int64) error
+	CopyLabelsAndChecklist(ctx context.Context, fromTaskID int64, toTaskID int64) error
+	SetAssignee(ctx context.Context, taskID int64, assigneeID *uint, actorID int64) error
+	InTx(ctx context.Context, fn func(TaskRepository) error) error
 }
 
+const taskColumns = `id, user_id, workspace_id, assignee_id, project_id, parent_id, title, description, status, priority, due_at, created_at, updated_at, completed_at,
+	recurrence_rule, recurrence_tz, recurrence_mode, recurrence_start, occurrence, next_occurrence_id`
+
+const qualifiedTaskColumns = `t.id, t.user_id, t.workspace_id, t.assignee_id, t.project_id, t.parent_id, t.title, t.description, t.status, t.priority, t.due_at, t.created_at, t.updated_at, t.completed_at,
+	t.recurrence_rule, t.recurrence_tz, t.recurrence_mode, t.recurrence_start, t.occurrence, t.next_occurrence_id`
+
 type TaskRepositoryImpl struct {
-	db *sqlx.DB
+	db dbtx
 }
 
 func NewTaskRepository(db *sqlx.DB) *TaskRepositoryImpl {
 	return &TaskRepositoryImpl{db: db}
 }
 
+// InTx runs fn with a repository whose statements all belong to one
+// transaction, committed if fn returns nil and rolled back otherwise. Inside
+// a transaction already, fn simply joins it.
+func (r *TaskRepositoryImpl) InTx(ctx context.Context, fn func(TaskRepository) error) error {
+	db, ok := r.db.(*sqlx.DB)
+	if !ok {
+		return fn(r)
+	}
+
+	tx, err := db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if err := fn(&TaskRepositoryImpl{db: tx}); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
 func (r *TaskRepositoryImpl) Create(ctx context.Context, task *model.Task) error {
-	query := `INSERT INTO tasks (user_id, title, status) VALUES ($1, $2, $3) RETURNING id`
-	return r.db.QueryRowContext(ctx, query, task.UserID, task.Title, task.Status).Scan(&task.ID)
+	query := `INSERT INTO tasks (user_id, workspace_id, assignee_id, project_id, parent_id, title, description, status, priority, due_at,
+			completed_at, recurrence_rule, recurrence_tz, recurrence_mode, recurrence_start, occurrence)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16)
+		RETURNING id, created_at, updated_at`
+	return r.db.QueryRowContext(ctx, query,
+		task.UserID, task.WorkspaceID, task.AssigneeID, task.ProjectID, task.ParentID, task.Title, task.Description, task.Status, task.Priority, task.DueAt, task.CompletedAt,
+		task.RecurrenceRule, task.RecurrenceTZ, task.RecurrenceMode, task.RecurrenceStart, task.Occurrence,
+	).Scan(&task.ID, &task.CreatedAt, &task.UpdatedAt)
 }
 
-func (r *TaskRepositoryImpl) GetAllForUser(ctx context.Context, userID int64) ([]*model.Task, error) {
-	var tasks []*model.Task
-	query := `SELECT id, user_id, title, status FROM tasks WHERE user_id = $1`
-	err := r.db.SelectContext(ctx, &tasks, query, userID)
-	if err != nil {
+// List returns one page of the user's personal tasks, or of the tasks in
+// filter.WorkspaceID, using keyset pagination over (sort key, id). The
+// filter must already be validated; Limit must be set.
+func (r *TaskRepositoryImpl) List(ctx context.Context, userID int64, filter model.TaskFilter) (*model.TaskPage, error) {
+	column, ok := taskSortColumns[filter.Sort]
+	if !ok {
+		column = taskSortColumns[model.SortByCreatedAt]
+	}
+
+	var args queryArgs
+	conditions := []string{spaceCondition("t", &args, userID, filter.WorkspaceID)}
+
+	switch {
+	case filter.InboxOnly:
+		conditions = append(conditions, "t.project_id IS NULL")
+	case filter.ProjectID != nil:
+		conditions = append(conditions, "t.project_id = "+args.add(*filter.ProjectID))
+	}
+	if filter.ParentID != nil {
+		conditions = append(conditions, "t.parent_id = "+args.add(*filter.ParentID))
+	}
+	if filter.AssignedToMe {
+		conditions = append(conditions, "t.assignee_id = "+args.add(userID))
+	}
+	if filter.CreatedByMe {
+		conditions = append(conditions, "t.user_id = "+args.add(userID))
+	}
+
+	if len(filter.Statuses) > 0 {
+		statuses := make([]string, len(filter.Statuses))
+		for i, status := range filter.Statuses {
+			statuses[i] = string(status)
+		}
+		conditions = append(conditions, "t.status = ANY("+args.add(pq.Array(statuses))+")")
+	}
+	if len(filter.Priorities) > 0 {
+		priorities := make([]string, len(filter.Priorities))
+		for i, priority := range filter.Priorities {
+			priorities[i] = string(priority)
+		}
+		conditions = append(conditions, "t.priority = ANY("+args.add(pq.Array(priorities))+")")
+	}
+	if filter.DueAfter != nil {
+		conditions = append(conditions, "t.due_at >= "+args.add(*filter.DueAfter))
+	}
+	if filter.DueBefore != nil {
+		conditions = append(conditions, "t.due_at < "+args.add(*filter.DueBefore))
+	}
+	if filter.Title != "" {
+		conditions = append(conditions, "t.title ILIKE '%' || "+args.add(escapeLike(filter.Title))+" || '%'")
+	}
+	if len(filter.LabelIDs) > 0 {
+		labelIDs := uniqueIDs(filter.LabelIDs)
+		labelsArg := args.add(pq.Array(labelIDs))
+		if filter.LabelMatch == model.LabelMatchAll {
+			conditions = append(conditions, "(SELECT COUNT(*) FROM task_labels tl WHERE tl.task_id = t.id AND tl.label_id = ANY("+
+				labelsArg+")) = "+args.add(len(labelIDs)))
+		} else {
+			conditions = append(conditions, "EXISTS (SELECT 1 FROM task_labels tl WHERE tl.task_id = t.id AND tl.label_id = ANY("+
+				labelsArg+"))")
+		}
+	}
+
+	comparison, direction := ">", "ASC"
+	if filter.Order == model.SortDesc {
+		comparison, direction = "<", "DESC"
+	}
+
+	if filter.Cursor != "" {
+		cursor, err := decodeTaskCursor(filter.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		if cursor.Sort != filter.Sort || cursor.Order != filter.Order {
+			return nil, ErrInvalidCursor
+		}
+		conditions = append(conditions, "("+column.expr+", t.id) "+comparison+
+			" ("+args.add(cursor.Key)+"::"+column.castType+", "+args.add(cursor.ID)+")")
+	}
+
+	query := `SELECT ` + qualifiedTaskColumns + `, (` + column.expr + `)::text AS sort_key
+		FROM tasks t
+		WHERE ` + strings.Join(conditions, " AND ") + `
+		ORDER BY ` + column.expr + ` ` + direction + `, t.id ` + direction + `
+		LIMIT ` + args.add(filter.Limit+1)
+
+	var rows []struct {
+		model.Task
+		SortKey string `db:"sort_key"`
+	}
+	if err := r.db.SelectContext(ctx, &rows, query, args...); err != nil {
 		return nil, err
 	}
-	return tasks, nil
+
+	page := &model.TaskPage{Items: make([]*model.Task, 0, len(rows))}
+	for i := range rows {
+		if i == filter.Limit {
+			last := rows[i-1]
+			page.NextCursor = encodeTaskCursor(taskCursor{
+				Sort:  filter.Sort,
+				Order: filter.Order,
+				Key:   last.SortKey,
+				ID:    last.ID,
+			})
+			break
+		}
+		task := rows[i].Task
+		page.Items = append(page.Items, &task)
+	}
+
+	if err := r.loadRelations(ctx, page.Items); err != nil {
+		return nil, err
+	}
+
+	return page, nil
 }
 
 func (r *TaskRepositoryImpl) GetByID(ctx context.Context, taskID int64) (*model.Task, error) {
 	var task model.Task
-	query := `SELECT id, user_id, title, status FROM tasks WHERE id = $1`
+	query := `SELECT ` + taskColumns + ` FROM tasks WHERE id = $1`
 	err := r.db.GetContext(ctx, &task, query, taskID)
 	if err != nil {
 		return nil, err
 	}
+	if err := r.loadRelations(ctx, []*model.Task{&task}); err != nil {
+		return nil, err
+	}
 	return &task, nil
 }
 
 func (r *TaskRepositoryImpl) Update(ctx context.Context, task *model.Task) error {
-	query := `UPDATE tasks SET title = $1, status = $2 WHERE id = $3 AND user_id = $4`
-	result, err := r.db.ExecContext(ctx, query, task.Title, task.Status, task.ID, task.UserID)
+	query := `UPDATE tasks
+		SET project_id = $1, parent_id = $2, title = $3, description = $4, status = $5, priority = $6, due_at = $7,
+			completed_at = $8, recurrence_rule = $9, recurrence_tz = $10, recurrence_mode = $11, recurrence_start = $12,
+			occurrence = $13, next_occurrence_id = $14, updated_at = NOW()
+		WHERE id = $15 AND user_id = $16
+		RETURNING updated_at`
+	err := r.db.QueryRowContext(ctx, query,
+		task.ProjectID, task.ParentID, task.Title, task.Description, task.Status, task.Priority, task.DueAt, task.CompletedAt,
+		task.RecurrenceRule, task.RecurrenceTZ, task.RecurrenceMode, task.RecurrenceStart, task.Occurrence, task.NextOccurrenceID,
+		task.ID, task.UserID,
+	).Scan(&task.UpdatedAt)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r *TaskRepositoryImpl) Delete(ctx context.Context, taskID int64, userID int64) error {
+	query := `DELETE FROM tasks WHERE id = $1 AND user_id = $2`
+	result, err := r.db.ExecContext(ctx, query, taskID, userID)
 	if err != nil {
 		return err
 	}
@@ -67,20 +238,171 @@ func (r *TaskRepositoryImpl) Update(ctx context.Context, task *model.Task) error
 	return nil
 }
 
-func (r *TaskRepositoryImpl) Delete(ctx context.Context, taskID int64, userID int64) error {
-	query := `DELETE FROM tasks WHERE id = $1 AND user_id = $2`
-	result, err := r.db.ExecContext(ctx, query, taskID, userID)
+// GetAncestorIDs returns the IDs of the task's parent, grandparent and so
+// on up to the root.
+func (r *TaskRepositoryImpl) GetAncestorIDs(ctx context.Context, taskID int64) ([]uint, error) {
+	ancestorIDs := []uint{}
+	query := `WITH RECURSIVE ancestors (id, parent_id, depth) AS (
+			SELECT id, parent_id, 0 FROM tasks WHERE id = $1
+			UNION ALL
+			SELECT t.id, t.parent_id, a.depth + 1
+			FROM tasks t
+			JOIN ancestors a ON t.id = a.parent_id
+			WHERE a.depth < 100
+		)
+		SELECT id FROM ancestors WHERE depth > 0 ORDER BY depth`
+	err := r.db.SelectContext(ctx, &ancestorIDs, query, taskID)
 	if err != nil {
+		return nil, err
+	}
+	return ancestorIDs, nil
+}
+
+// SubtreeHeight returns the number of levels in the subtree rooted at the
+// task: 1 for a task without subtasks.
+func (r *TaskRepositoryImpl) SubtreeHeight(ctx context.Context, taskID int64) (int, error) {
+	var height int
+	query := `WITH RECURSIVE subtree (id, depth) AS (
+			SELECT id, 1 FROM tasks WHERE id = $1
+			UNION ALL
+			SELECT t.id, s.depth + 1
+			FROM tasks t
+			JOIN subtree s ON t.parent_id = s.id
+			WHERE s.depth < 100
+		)
+		SELECT COALESCE(MAX(depth), 0) FROM subtree`
+	err := r.db.GetContext(ctx, &height, query, taskID)
+	return height, err
+}
+
+const descendantsCTE = `WITH RECURSIVE descendants (id, depth) AS (
+		SELECT id, 1 FROM tasks WHERE parent_id = $1
+		UNION ALL
+		SELECT t.id, d.depth + 1
+		FROM tasks t
+		JOIN descendants d ON t.parent_id = d.id
+		WHERE d.depth < 100
+	)`
+
+// CountOpenDescendants counts the subtasks at any depth below the task that
+// are neither done nor cancelled.
+func (r *TaskRepositoryImpl) CountOpenDescendants(ctx context.Context, taskID int64) (int, error) {
+	var count int
+	query := descendantsCTE + `
+		SELECT COUNT(*) FROM tasks
+		WHERE id IN (SELECT id FROM descendants) AND status NOT IN ('done', 'cancelled')`
+	err := r.db.GetContext(ctx, &count, query, taskID)
+	return count, err
+}
+
+// CancelOpenDescendants cancels every open subtask at any depth below the
+// task.
+func (r *TaskRepositoryImpl) CancelOpenDescendants(ctx context.Context, taskID int64) error {
+	query := descendantsCTE + `
+		UPDATE tasks SET status = 'cancelled', completed_at = NULL, updated_at = NOW()
+		WHERE id IN (SELECT id FROM descendants) AND status NOT IN ('done', 'cancelled')`
+	_, err := r.db.ExecContext(ctx, query, taskID)
+	return err
+}
+
+// CopyLabelsAndChecklist gives a task the labels and checklist items of
+// another, with every copied item unticked.
+func (r *TaskRepositoryImpl) CopyLabelsAndChecklist(ctx context.Context, fromTaskID int64, toTaskID int64) error {
+	labelQuery := `INSERT INTO task_labels (task_id, label_id)
+		SELECT $2, label_id FROM task_labels WHERE task_id = $1
+		ON CONFLICT DO NOTHING`
+	if _, err := r.db.ExecContext(ctx, labelQuery, fromTaskID, toTaskID); err != nil {
 		return err
 	}
 
-	rowsAffected, err := result.RowsAffected()
-	if err != nil {
+	checklistQuery := `INSERT INTO task_checklist_items (task_id, title, done, position)
+		SELECT $2, title, FALSE, position FROM task_checklist_items WHERE task_id = $1`
+	_, err := r.db.ExecContext(ctx, checklistQuery, fromTaskID, toTaskID)
+	return err
+}
+
+// loadRelations fills in the labels, progress and comment count of every
+// task.
+func (r *TaskRepositoryImpl) loadRelations(ctx context.Context, tasks []*model.Task) error {
+	if err := loadTaskLabels(ctx, r.db, tasks); err != nil {
 		return err
 	}
-	if rowsAffected == 0 {
-		return sql.ErrNoRows
+	if err := loadTaskProgress(ctx, r.db, tasks); err != nil {
+		return err
+	}
+	return loadTaskCommentCounts(ctx, r.db, tasks)
+}
+
+// loadTaskProgress fills in subtask and checklist progress for every task.
+func loadTaskProgress(ctx context.Context, db dbtx, tasks []*model.Task) error {
+	if len(tasks) == 0 {
+		return nil
+	}
+
+	taskIDs := make([]int64, len(tasks))
+	byID := make(map[uint]*model.Task, len(tasks))
+	for i, task := range tasks {
+		task.Progress = model.TaskProgress{}
+		taskIDs[i] = int64(task.ID)
+		byID[task.ID] = task
+	}
+
+	var rows []struct {
+		TaskID uint `db:"task_id"`
+		Done   int  `db:"done"`
+		Total  int  `db:"total"`
+	}
+	subtaskQuery := `SELECT parent_id AS task_id,
+			COUNT(*) FILTER (WHERE status = 'done') AS done,
+			COUNT(*) FILTER (WHERE status <> 'cancelled') AS total
+		FROM tasks
+		WHERE parent_id = ANY($1)
+		GROUP BY parent_id`
+	if err := db.SelectContext(ctx, &rows, subtaskQuery, pq.Array(taskIDs)); err != nil {
+		return err
+	}
+	for _, row := range rows {
+		if task, ok := byID[row.TaskID]; ok {
+			task.Progress.SubtasksDone = row.Done
+			task.Progress.SubtasksTotal = row.Total
+		}
+	}
+
+	rows = rows[:0]
+	checklistQuery := `SELECT task_id, COUNT(*) FILTER (WHERE done) AS done, COUNT(*) AS total
+		FROM task_checklist_items
+		WHERE task_id = ANY($1)
+		GROUP BY task_id`
+	if err := db.SelectContext(ctx, &rows, checklistQuery, pq.Array(taskIDs)); err != nil {
+		return err
+	}
+	for _, row := range rows {
+		if task, ok := byID[row.TaskID]; ok {
+			task.Progress.ChecklistDone = row.Done
+			task.Progress.ChecklistTotal = row.Total
+		}
 	}
 
 	return nil
 }
+
+// SetAssignee assigns the task to assigneeID, or unassigns it if
+// assigneeID is nil, and records the change in the task's activity as
+// actorID. Setting the current assignee again changes nothing and records
+// nothing.
+func (r *TaskRepositoryImpl) SetAssignee(ctx context.Context, taskID int64, assigneeID *uint, actorID int64) error {
+	query := `WITH previous AS (
+			SELECT assignee_id FROM tasks WHERE id = $1 FOR UPDATE
+		), updated AS (
+			UPDATE tasks SET assignee_id = $2, updated_at = NOW()
+			WHERE id = $1 AND assignee_id IS DISTINCT FROM $2::int
+			RETURNING id
+		), unassigned AS (
+			INSERT INTO task_activity (task_id, actor_id, action, subject_id)
+			SELECT $1, $3, 'unassigned', p.assignee_id FROM previous p, updated WHERE p.assignee_id IS NOT NULL
+		)
+		INSERT INTO task_activity (task_id, actor_id, action, subject_id)
+		SELECT $1, $3, 'assigned', $2 FROM updated WHERE $2::int IS NOT NULL`
+	_, err := r.db.ExecContext(ctx, query, taskID, assigneeID, actorID)
+	return err
+}
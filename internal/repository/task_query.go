@@ -0,0 +1,93 @@
+package repository
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/ahmednurovic/task-manager-api/internal/model"
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// sortColumn is the SQL expression behind a sortable field and the type its
+// text form is cast back to when comparing against a cursor.
+type sortColumn struct {
+	expr     string
+	castType string
+}
+
+var taskSortColumns = map[model.TaskSortField]sortColumn{
+	model.SortByCreatedAt: {expr: "t.created_at", castType: "timestamptz"},
+	model.SortByUpdatedAt: {expr: "t.updated_at", castType: "timestamptz"},
+	// Tasks without a due date sort after every dated task.
+	model.SortByDueAt: {expr: "COALESCE(t.due_at, 'infinity'::timestamptz)", castType: "timestamptz"},
+	model.SortByPriority: {
+		expr:     "CASE t.priority WHEN 'low' THEN 1 WHEN 'medium' THEN 2 WHEN 'high' THEN 3 WHEN 'urgent' THEN 4 END",
+		castType: "int",
+	},
+	model.SortByTitle: {expr: "t.title", castType: "text"},
+}
+
+// taskCursor is the decoded form of TaskPage.NextCursor: the sort key and ID
+// of the last task on the previous page.
+type taskCursor struct {
+	Sort  model.TaskSortField `json:"s"`
+	Order model.SortOrder     `json:"o"`
+	Key   string              `json:"k"`
+	ID    uint                `json:"i"`
+}
+
+func encodeTaskCursor(cursor taskCursor) string {
+	raw, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+func decodeTaskCursor(encoded string) (taskCursor, error) {
+	var cursor taskCursor
+	raw, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return cursor, ErrInvalidCursor
+	}
+	if err := json.Unmarshal(raw, &cursor); err != nil {
+		return cursor, ErrInvalidCursor
+	}
+	return cursor, nil
+}
+
+// queryArgs collects positional arguments while a query is being built.
+type queryArgs []interface{}
+
+func (a *queryArgs) add(value interface{}) string {
+	*a = append(*a, value)
+	return "$" + strconv.Itoa(len(*a))
+}
+
+// spaceCondition restricts the rows of the table aliased as alias to a
+// workspace, or to the user's personal space when workspaceID is nil.
+func spaceCondition(alias string, args *queryArgs, userID int64, workspaceID *uint) string {
+	if workspaceID != nil {
+		return alias + ".workspace_id = " + args.add(*workspaceID)
+	}
+	return alias + ".user_id = " + args.add(userID) + " AND " + alias + ".workspace_id IS NULL"
+}
+
+// escapeLike escapes the LIKE wildcards in s so it matches literally.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+// uniqueIDs converts ids for use with pq.Array, dropping duplicates.
+func uniqueIDs(ids []uint) []int64 {
+	seen := make(map[uint]bool, len(ids))
+	unique := make([]int64, 0, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, int64(id))
+		}
+	}
+	return unique
+}
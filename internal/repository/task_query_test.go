@@ -0,0 +1,53 @@
+package repository
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ahmednurovic/task-manager-api/internal/model"
+)
+
+func TestTaskCursor(t *testing.T) {
+	t.Run("Round Trip", func(t *testing.T) {
+		cursor := taskCursor{
+			Sort:  model.SortByDueAt,
+			Order: model.SortAsc,
+			Key:   "2025-01-31 17:00:00+00",
+			ID:    42,
+		}
+
+		decoded, err := decodeTaskCursor(encodeTaskCursor(cursor))
+
+		assert.NoError(t, err)
+		assert.Equal(t, cursor, decoded)
+	})
+
+	t.Run("Garbage", func(t *testing.T) {
+		_, err := decodeTaskCursor("not a cursor!")
+		assert.ErrorIs(t, err, ErrInvalidCursor)
+	})
+}
+
+func TestSpaceCondition(t *testing.T) {
+	t.Run("Personal", func(t *testing.T) {
+		args := queryArgs{"first"}
+		condition := spaceCondition("t", &args, 7, nil)
+
+		assert.Equal(t, "t.user_id = $2 AND t.workspace_id IS NULL", condition)
+		assert.Equal(t, queryArgs{"first", int64(7)}, args)
+	})
+
+	t.Run("Workspace", func(t *testing.T) {
+		var args queryArgs
+		workspaceID := uint(3)
+		condition := spaceCondition("p", &args, 7, &workspaceID)
+
+		assert.Equal(t, "p.workspace_id = $1", condition)
+		assert.Equal(t, queryArgs{uint(3)}, args)
+	})
+}
+
+func TestEscapeLike(t *testing.T) {
+	assert.Equal(t, `100\% done\_ok\\`, escapeLike(`100% done_ok\`))
+}
@@ -0,0 +1,175 @@
+package repository
+
+import (
+	"context"
+	"strings"
+	"unicode"
+
+	"github.com/ahmednurovic/task-manager-api/internal/model"
+)
+
+const searchHighlightOptions = `StartSel=<mark>, StopSel=</mark>`
+
+// Search ranks the user's personal tasks, or the tasks in the workspace if
+// workspaceID is set, against a websearch-style query. See buildTSQuery for
+// the supported syntax.
+func (r *TaskRepositoryImpl) Search(ctx context.Context, userID int64, workspaceID *uint, query string, limit int) ([]*model.TaskSearchResult, error) {
+	tsQuery := buildTSQuery(query)
+	if tsQuery == "" {
+		return []*model.TaskSearchResult{}, nil
+	}
+
+	args := queryArgs{tsQuery, limit}
+	space := spaceCondition("t", &args, userID, workspaceID)
+
+	// Headlines are expensive, so they are only built for the page of
+	// matches that is actually returned.
+	sqlQuery := `WITH q AS (SELECT to_tsquery('english', $1) AS query),
+		matches AS (
+			SELECT t.id, ts_rank_cd(t.search_vector, q.query) AS rank
+			FROM tasks t, q
+			WHERE ` + space + ` AND t.search_vector @@ q.query
+			ORDER BY rank DESC, t.id DESC
+			LIMIT $2
+		)
+		SELECT ` + qualifiedTaskColumns + `, m.rank,
+			ts_headline('english', t.title, q.query, '` + searchHighlightOptions + `, HighlightAll=true') AS title_highlight,
+			ts_headline('english', t.description, q.query, '` + searchHighlightOptions + `, MaxFragments=2, MaxWords=30, MinWords=10') AS snippet
+		FROM matches m
+		JOIN tasks t ON t.id = m.id
+		CROSS JOIN q
+		ORDER BY m.rank DESC, t.id DESC`
+
+	var rows []struct {
+		model.Task
+		Rank           float64 `db:"rank"`
+		TitleHighlight string  `db:"title_highlight"`
+		Snippet        string  `db:"snippet"`
+	}
+	if err := r.db.SelectContext(ctx, &rows, sqlQuery, args...); err != nil {
+		return nil, err
+	}
+
+	results := make([]*model.TaskSearchResult, 0, len(rows))
+	tasks := make([]*model.Task, 0, len(rows))
+	for i := range rows {
+		task := rows[i].Task
+		tasks = append(tasks, &task)
+		results = append(results, &model.TaskSearchResult{
+			Task:           &task,
+			Rank:           rows[i].Rank,
+			TitleHighlight: rows[i].TitleHighlight,
+			Snippet:        rows[i].Snippet,
+		})
+	}
+
+	if err := r.loadRelations(ctx, tasks); err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+// buildTSQuery translates a websearch-style query into to_tsquery syntax.
+// Bare words match as prefixes, "quoted phrases" match adjacent words
+// exactly, OR between two terms makes either match, and a leading minus
+// excludes a term. Every other character only separates words, so user
+// input can never produce an invalid tsquery.
+func buildTSQuery(input string) string {
+	var (
+		out       strings.Builder
+		pendingOr bool
+	)
+
+	appendTerm := func(term string, negate bool) {
+		if term == "" {
+			return
+		}
+		if out.Len() > 0 {
+			if pendingOr {
+				out.WriteString(" | ")
+			} else {
+				out.WriteString(" & ")
+			}
+		}
+		if negate {
+			out.WriteString("!")
+		}
+		out.WriteString(term)
+		pendingOr = false
+	}
+
+	runes := []rune(input)
+	for i := 0; i < len(runes); {
+		if unicode.IsSpace(runes[i]) {
+			i++
+			continue
+		}
+
+		negate := false
+		if runes[i] == '-' {
+			negate = true
+			i++
+			if i >= len(runes) || unicode.IsSpace(runes[i]) {
+				continue
+			}
+		}
+
+		if runes[i] == '"' {
+			end := i + 1
+			for end < len(runes) && runes[end] != '"' {
+				end++
+			}
+			appendTerm(phraseTerm(searchWords(string(runes[i+1:end])), false), negate)
+			i = end + 1
+			continue
+		}
+
+		end := i
+		for end < len(runes) && !unicode.IsSpace(runes[end]) && runes[end] != '"' {
+			end++
+		}
+		word := string(runes[i:end])
+		i = end
+
+		if !negate && strings.EqualFold(word, "or") {
+			if out.Len() > 0 {
+				pendingOr = true
+			}
+			continue
+		}
+
+		appendTerm(phraseTerm(searchWords(word), true), negate)
+	}
+
+	return out.String()
+}
+
+// searchWords splits s into runs of letters and digits.
+func searchWords(s string) []string {
+	return strings.FieldsFunc(s, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// phraseTerm joins words with the followed-by operator. With prefix set,
+// the last word matches as a prefix.
+func phraseTerm(words []string, prefix bool) string {
+	if len(words) == 0 {
+		return ""
+	}
+
+	lexemes := make([]string, len(words))
+	for i, word := range words {
+		lexemes[i] = strings.ToLower(word)
+	}
+	if prefix {
+		lexemes[len(lexemes)-1] += ":*"
+	}
+
+	term := strings.Join(lexemes, " <-> ")
+	if len(lexemes) > 1 {
+		term = "(" + term + ")"
+	}
+	return term
+}
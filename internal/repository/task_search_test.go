@@ -0,0 +1,35 @@
+package repository
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuildTSQuery(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"", ""},
+		{"report", "report:*"},
+		{"quarterly report", "quarterly:* & report:*"},
+		{`"quarterly report"`, "(quarterly <-> report)"},
+		{"report OR summary", "report:* | summary:*"},
+		{"report or summary", "report:* | summary:*"},
+		{"report -draft", "report:* & !draft:*"},
+		{`report -"first draft"`, "report:* & !(first <-> draft)"},
+		{"e-mail", "(e <-> mail:*)"},
+		{"OR report OR", "report:*"},
+		{"report - draft", "report:* & draft:*"},
+		{`it's a 'quote' & | ! :* (bad)`, "(it <-> s:*) & a:* & quote:* & bad:*"},
+		{`"unterminated phrase`, "(unterminated <-> phrase)"},
+		{"Über Straße", "über:* & straße:*"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			assert.Equal(t, tt.want, buildTSQuery(tt.input))
+		})
+	}
+}
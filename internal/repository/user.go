@@ -4,30 +4,274 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"time"
 
 	"github.com/ahmednurovic/task-manager-api/internal/model"
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 )
 
-type UserRepository struct {
-	db *sqlx.DB
+type UserRepository interface {
+	CreateUser(ctx context.Context, user *model.User) error
+	GetUserByEmail(ctx context.Context, email string) (*model.User, error)
+	GetUserByID(ctx context.Context, userID uint) (*model.User, error)
+	UpdatePassword(ctx context.Context, userID uint, passwordHash string) error
+	RehashPassword(ctx context.Context, userID uint, oldHash, newHash string) error
+	CreatePasswordReset(ctx context.Context, userID uint, tokenHash string, expiresAt time.Time) error
+	ConsumePasswordReset(ctx context.Context, tokenHash string) (uint, error)
+	CreateEmailVerification(ctx context.Context, userID uint, tokenHash string, expiresAt time.Time) error
+	ConsumeEmailVerification(ctx context.Context, tokenHash string) (uint, error)
+	SetMFASecret(ctx context.Context, userID uint, secret string) error
+	EnableMFA(ctx context.Context, userID uint, counter uint64, recoveryCodeHashes []string) error
+	UseMFACounter(ctx context.Context, userID uint, counter uint64) error
+	UseRecoveryCode(ctx context.Context, userID uint, codeHash string) error
+	DisableMFA(ctx context.Context, userID uint) error
+	InTx(ctx context.Context, fn func(UserTx) error) error
 }
 
-func NewUserRepository(db *sqlx.DB) *UserRepository {
-	return &UserRepository{db: db}
+// UserTx holds repositories whose statements all belong to one transaction,
+// for changes to a user that must happen together with revoking their
+// tokens.
+type UserTx struct {
+	Users         UserRepository
+	Revocations   RevocationRepository
+	RefreshTokens RefreshTokenRepository
 }
 
-func (r *UserRepository) CreateUser(ctx context.Context, user *model.User) error {
+const userColumns = `id, email, password, email_verified_at, mfa_secret, mfa_enabled_at, is_admin`
+
+type UserRepositoryImpl struct {
+	db dbtx
+}
+
+func NewUserRepository(db *sqlx.DB) *UserRepositoryImpl {
+	return &UserRepositoryImpl{db: db}
+}
+
+// InTx runs fn with repositories whose statements all belong to one
+// transaction, committed if fn returns nil and rolled back otherwise. Inside
+// a transaction already, fn simply joins it.
+func (r *UserRepositoryImpl) InTx(ctx context.Context, fn func(UserTx) error) error {
+	db, ok := r.db.(*sqlx.DB)
+	if !ok {
+		return fn(newUserTx(r.db))
+	}
+
+	tx, err := db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if err := fn(newUserTx(tx)); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+func newUserTx(db dbtx) UserTx {
+	return UserTx{
+		Users:         &UserRepositoryImpl{db: db},
+		Revocations:   &RevocationRepositoryImpl{db: db},
+		RefreshTokens: &RefreshTokenRepositoryImpl{db: db},
+	}
+}
+
+func (r *UserRepositoryImpl) CreateUser(ctx context.Context, user *model.User) error {
 	query := `INSERT INTO users (email, password) VALUES ($1, $2) RETURNING id`
 	return r.db.QueryRowContext(ctx, query, user.Email, user.Password).Scan(&user.ID)
 }
 
-func (r *UserRepository) GetUserByEmail(ctx context.Context, email string) (*model.User, error) {
+func (r *UserRepositoryImpl) GetUserByEmail(ctx context.Context, email string) (*model.User, error) {
 	var user model.User
-	query := `SELECT id, email, password FROM users WHERE email = $1`
+	query := `SELECT ` + userColumns + ` FROM users WHERE email = $1`
 	err := r.db.GetContext(ctx, &user, query, email)
 	if errors.Is(err, sql.ErrNoRows) {
 		return nil, nil
 	}
 	return &user, err
-}
\ No newline at end of file
+}
+
+// GetUserByID returns nil, like GetUserByEmail, if there is no such user.
+func (r *UserRepositoryImpl) GetUserByID(ctx context.Context, userID uint) (*model.User, error) {
+	var user model.User
+	query := `SELECT ` + userColumns + ` FROM users WHERE id = $1`
+	err := r.db.GetContext(ctx, &user, query, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	return &user, err
+}
+
+func (r *UserRepositoryImpl) UpdatePassword(ctx context.Context, userID uint, passwordHash string) error {
+	query := `UPDATE users SET password = $1 WHERE id = $2`
+	result, err := r.db.ExecContext(ctx, query, passwordHash, userID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// RehashPassword replaces a password hash with a new hash of the same
+// password. It does nothing if the hash has changed since it was read, so
+// that it never undoes a password change.
+func (r *UserRepositoryImpl) RehashPassword(ctx context.Context, userID uint, oldHash, newHash string) error {
+	query := `UPDATE users SET password = $3 WHERE id = $1 AND password = $2`
+	_, err := r.db.ExecContext(ctx, query, userID, oldHash, newHash)
+	return err
+}
+
+func (r *UserRepositoryImpl) CreatePasswordReset(ctx context.Context, userID uint, tokenHash string, expiresAt time.Time) error {
+	query := `INSERT INTO password_reset_tokens (user_id, token_hash, expires_at) VALUES ($1, $2, $3)`
+	_, err := r.db.ExecContext(ctx, query, userID, tokenHash, expiresAt)
+	return err
+}
+
+// ConsumePasswordReset marks an unused, unexpired reset token used and
+// returns the user it belongs to. The user's other outstanding reset tokens
+// are used up as well. It returns sql.ErrNoRows if the token is unknown,
+// used or expired.
+func (r *UserRepositoryImpl) ConsumePasswordReset(ctx context.Context, tokenHash string) (uint, error) {
+	var userID uint
+	query := `WITH consumed AS (
+			UPDATE password_reset_tokens SET used_at = NOW()
+			WHERE token_hash = $1 AND used_at IS NULL AND expires_at > NOW()
+			RETURNING user_id
+		), others AS (
+			UPDATE password_reset_tokens SET used_at = NOW()
+			WHERE user_id IN (SELECT user_id FROM consumed) AND token_hash <> $1 AND used_at IS NULL
+		)
+		SELECT user_id FROM consumed`
+	err := r.db.GetContext(ctx, &userID, query, tokenHash)
+	return userID, err
+}
+
+func (r *UserRepositoryImpl) CreateEmailVerification(ctx context.Context, userID uint, tokenHash string, expiresAt time.Time) error {
+	query := `INSERT INTO email_verification_tokens (user_id, token_hash, expires_at) VALUES ($1, $2, $3)`
+	_, err := r.db.ExecContext(ctx, query, userID, tokenHash, expiresAt)
+	return err
+}
+
+// ConsumeEmailVerification marks an unused, unexpired verification token
+// used, marks its user's email verified, and returns the user's ID. It
+// returns sql.ErrNoRows if the token is unknown, used or expired.
+func (r *UserRepositoryImpl) ConsumeEmailVerification(ctx context.Context, tokenHash string) (uint, error) {
+	var userID uint
+	query := `WITH consumed AS (
+			UPDATE email_verification_tokens SET used_at = NOW()
+			WHERE token_hash = $1 AND used_at IS NULL AND expires_at > NOW()
+			RETURNING user_id
+		)
+		UPDATE users SET email_verified_at = COALESCE(email_verified_at, NOW())
+		WHERE id IN (SELECT user_id FROM consumed)
+		RETURNING id`
+	err := r.db.GetContext(ctx, &userID, query, tokenHash)
+	return userID, err
+}
+
+// SetMFASecret starts enrolment with a new secret, replacing the secret of
+// any enrolment that was never confirmed. It returns sql.ErrNoRows if the
+// user does not exist or already has two-factor authentication on.
+func (r *UserRepositoryImpl) SetMFASecret(ctx context.Context, userID uint, secret string) error {
+	query := `UPDATE users SET mfa_secret = $2, mfa_last_counter = NULL
+		WHERE id = $1 AND mfa_enabled_at IS NULL`
+	result, err := r.db.ExecContext(ctx, query, userID, secret)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// EnableMFA turns two-factor authentication on for a user part way through
+// enrolment, records the time step of the code that confirmed it, and
+// replaces the user's recovery codes. It returns sql.ErrNoRows if the user
+// has no secret or already has two-factor authentication on.
+func (r *UserRepositoryImpl) EnableMFA(ctx context.Context, userID uint, counter uint64, recoveryCodeHashes []string) error {
+	var id uint
+	query := `WITH enabled AS (
+			UPDATE users SET mfa_enabled_at = NOW(), mfa_last_counter = $2
+			WHERE id = $1 AND mfa_secret IS NOT NULL AND mfa_enabled_at IS NULL
+			RETURNING id
+		), cleared AS (
+			DELETE FROM mfa_recovery_codes WHERE user_id IN (SELECT id FROM enabled)
+		), inserted AS (
+			INSERT INTO mfa_recovery_codes (user_id, code_hash)
+			SELECT enabled.id, code_hash FROM enabled, unnest($3::text[]) AS code_hash
+		)
+		SELECT id FROM enabled`
+	return r.db.GetContext(ctx, &id, query, userID, int64(counter), pq.Array(recoveryCodeHashes))
+}
+
+// UseMFACounter records that a code for the given time step was accepted.
+// It returns sql.ErrNoRows if a code for that step or a later one has been
+// accepted already, which makes each code work only once.
+func (r *UserRepositoryImpl) UseMFACounter(ctx context.Context, userID uint, counter uint64) error {
+	query := `UPDATE users SET mfa_last_counter = $2
+		WHERE id = $1 AND mfa_enabled_at IS NOT NULL
+			AND (mfa_last_counter IS NULL OR mfa_last_counter < $2)`
+	result, err := r.db.ExecContext(ctx, query, userID, int64(counter))
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// UseRecoveryCode marks one of the user's unused recovery codes used. It
+// returns sql.ErrNoRows if there is no such code.
+func (r *UserRepositoryImpl) UseRecoveryCode(ctx context.Context, userID uint, codeHash string) error {
+	query := `UPDATE mfa_recovery_codes SET used_at = NOW()
+		WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL`
+	result, err := r.db.ExecContext(ctx, query, userID, codeHash)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// DisableMFA turns two-factor authentication off, forgetting the secret
+// and deleting the recovery codes.
+func (r *UserRepositoryImpl) DisableMFA(ctx context.Context, userID uint) error {
+	var id uint
+	query := `WITH cleared AS (
+			DELETE FROM mfa_recovery_codes WHERE user_id = $1
+		)
+		UPDATE users SET mfa_secret = NULL, mfa_enabled_at = NULL, mfa_last_counter = NULL
+		WHERE id = $1
+		RETURNING id`
+	return r.db.GetContext(ctx, &id, query, userID)
+}
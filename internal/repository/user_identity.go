@@ -0,0 +1,63 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/ahmednurovic/task-manager-api/internal/model"
+	"github.com/jmoiron/sqlx"
+)
+
+type UserIdentityRepository interface {
+	Get(ctx context.Context, provider, subject string) (*model.UserIdentity, error)
+	Create(ctx context.Context, identity *model.UserIdentity) error
+	CreateWithUser(ctx context.Context, user *model.User, identity *model.UserIdentity) error
+}
+
+const userIdentityColumns = `id, user_id, provider, subject, email, created_at`
+
+type UserIdentityRepositoryImpl struct {
+	db *sqlx.DB
+}
+
+func NewUserIdentityRepository(db *sqlx.DB) *UserIdentityRepositoryImpl {
+	return &UserIdentityRepositoryImpl{db: db}
+}
+
+// Get returns sql.ErrNoRows if no user is linked to the identity.
+func (r *UserIdentityRepositoryImpl) Get(ctx context.Context, provider, subject string) (*model.UserIdentity, error) {
+	var identity model.UserIdentity
+	query := `SELECT ` + userIdentityColumns + ` FROM user_identities WHERE provider = $1 AND subject = $2`
+	if err := r.db.GetContext(ctx, &identity, query, provider, subject); err != nil {
+		return nil, err
+	}
+	return &identity, nil
+}
+
+func (r *UserIdentityRepositoryImpl) Create(ctx context.Context, identity *model.UserIdentity) error {
+	query := `INSERT INTO user_identities (user_id, provider, subject, email)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at`
+	return r.db.QueryRowContext(ctx, query, identity.UserID, identity.Provider, identity.Subject, identity.Email).
+		Scan(&identity.ID, &identity.CreatedAt)
+}
+
+// CreateWithUser creates the user and links the identity to them in one
+// statement, so there is never a user who cannot sign in.
+func (r *UserIdentityRepositoryImpl) CreateWithUser(ctx context.Context, user *model.User, identity *model.UserIdentity) error {
+	query := `WITH new_user AS (
+			INSERT INTO users (email, password, email_verified_at)
+			VALUES ($1, $2, $3)
+			RETURNING id
+		)
+		INSERT INTO user_identities (user_id, provider, subject, email)
+		SELECT id, $4, $5, $6 FROM new_user
+		RETURNING user_id, id, created_at`
+	err := r.db.QueryRowContext(ctx, query, user.Email, user.Password, user.EmailVerifiedAt,
+		identity.Provider, identity.Subject, identity.Email).
+		Scan(&user.ID, &identity.ID, &identity.CreatedAt)
+	if err != nil {
+		return err
+	}
+	identity.UserID = user.ID
+	return nil
+}
@@ -0,0 +1,49 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/ahmednurovic/task-manager-api/internal/model"
+	"github.com/jmoiron/sqlx"
+)
+
+type WatcherRepository interface {
+	Add(ctx context.Context, taskID int64, userID int64) error
+	Remove(ctx context.Context, taskID int64, userID int64) error
+	ListForTask(ctx context.Context, taskID int64) ([]*model.TaskWatcher, error)
+}
+
+type WatcherRepositoryImpl struct {
+	db *sqlx.DB
+}
+
+func NewWatcherRepository(db *sqlx.DB) *WatcherRepositoryImpl {
+	return &WatcherRepositoryImpl{db: db}
+}
+
+// Add makes the user watch the task. Watching a task twice is a no-op.
+func (r *WatcherRepositoryImpl) Add(ctx context.Context, taskID int64, userID int64) error {
+	query := `INSERT INTO task_watchers (task_id, user_id) VALUES ($1, $2) ON CONFLICT DO NOTHING`
+	_, err := r.db.ExecContext(ctx, query, taskID, userID)
+	return err
+}
+
+func (r *WatcherRepositoryImpl) Remove(ctx context.Context, taskID int64, userID int64) error {
+	query := `DELETE FROM task_watchers WHERE task_id = $1 AND user_id = $2`
+	_, err := r.db.ExecContext(ctx, query, taskID, userID)
+	return err
+}
+
+func (r *WatcherRepositoryImpl) ListForTask(ctx context.Context, taskID int64) ([]*model.TaskWatcher, error) {
+	watchers := []*model.TaskWatcher{}
+	query := `SELECT w.task_id, w.user_id, u.email, w.created_at
+		FROM task_watchers w
+		JOIN users u ON u.id = w.user_id
+		WHERE w.task_id = $1
+		ORDER BY w.created_at, w.user_id`
+	err := r.db.SelectContext(ctx, &watchers, query, taskID)
+	if err != nil {
+		return nil, err
+	}
+	return watchers, nil
+}
@@ -0,0 +1,235 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/ahmednurovic/task-manager-api/internal/model"
+	"github.com/jmoiron/sqlx"
+)
+
+type WorkspaceRepository interface {
+	Create(ctx context.Context, workspace *model.Workspace, ownerID int64) error
+	GetForMember(ctx context.Context, workspaceID int64, userID int64) (*model.Workspace, error)
+	ListForUser(ctx context.Context, userID int64) ([]*model.Workspace, error)
+	Update(ctx context.Context, workspace *model.Workspace) error
+	Delete(ctx context.Context, workspaceID int64) error
+	MemberRole(ctx context.Context, workspaceID int64, userID int64) (model.WorkspaceRole, error)
+	ListMembers(ctx context.Context, workspaceID int64) ([]*model.WorkspaceMember, error)
+	UpdateMemberRole(ctx context.Context, workspaceID int64, userID int64, role model.WorkspaceRole) error
+	RemoveMember(ctx context.Context, workspaceID int64, userID int64) error
+	CreateInvitation(ctx context.Context, invitation *model.WorkspaceInvitation) error
+	GetInvitationByHash(ctx context.Context, tokenHash string) (*model.WorkspaceInvitation, error)
+	AcceptInvitation(ctx context.Context, invitationID int64, userID int64) error
+	DeclineInvitation(ctx context.Context, invitationID int64) error
+}
+
+const workspaceColumns = `w.id, w.name, m.role, w.created_at, w.updated_at`
+
+const invitationColumns = `id, workspace_id, email, role, token_hash, COALESCE(invited_by, 0) AS invited_by, expires_at, accepted_at,
+	declined_at, created_at`
+
+// lastOwnerGuard is true unless the member $1/$2 is the only owner left,
+// so that no update or delete can leave a workspace without an owner.
+const lastOwnerGuard = `(role <> 'owner'
+		OR (SELECT COUNT(*) FROM workspace_members WHERE workspace_id = $1 AND role = 'owner') > 1)`
+
+type WorkspaceRepositoryImpl struct {
+	db *sqlx.DB
+}
+
+func NewWorkspaceRepository(db *sqlx.DB) *WorkspaceRepositoryImpl {
+	return &WorkspaceRepositoryImpl{db: db}
+}
+
+// Create inserts a workspace with the user as its owner.
+func (r *WorkspaceRepositoryImpl) Create(ctx context.Context, workspace *model.Workspace, ownerID int64) error {
+	query := `WITH w AS (
+			INSERT INTO workspaces (name) VALUES ($1)
+			RETURNING id, created_at, updated_at
+		), m AS (
+			INSERT INTO workspace_members (workspace_id, user_id, role)
+			SELECT id, $2, 'owner' FROM w
+		)
+		SELECT id, created_at, updated_at FROM w`
+	workspace.Role = model.RoleOwner
+	return r.db.QueryRowContext(ctx, query, workspace.Name, ownerID).
+		Scan(&workspace.ID, &workspace.CreatedAt, &workspace.UpdatedAt)
+}
+
+// GetForMember returns a workspace with the user's role in it, or
+// sql.ErrNoRows if the user is not a member.
+func (r *WorkspaceRepositoryImpl) GetForMember(ctx context.Context, workspaceID int64, userID int64) (*model.Workspace, error) {
+	var workspace model.Workspace
+	query := `SELECT ` + workspaceColumns + `
+		FROM workspaces w
+		JOIN workspace_members m ON m.workspace_id = w.id
+		WHERE w.id = $1 AND m.user_id = $2`
+	err := r.db.GetContext(ctx, &workspace, query, workspaceID, userID)
+	if err != nil {
+		return nil, err
+	}
+	return &workspace, nil
+}
+
+func (r *WorkspaceRepositoryImpl) ListForUser(ctx context.Context, userID int64) ([]*model.Workspace, error) {
+	workspaces := []*model.Workspace{}
+	query := `SELECT ` + workspaceColumns + `
+		FROM workspaces w
+		JOIN workspace_members m ON m.workspace_id = w.id
+		WHERE m.user_id = $1
+		ORDER BY w.name, w.id`
+	err := r.db.SelectContext(ctx, &workspaces, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	return workspaces, nil
+}
+
+func (r *WorkspaceRepositoryImpl) Update(ctx context.Context, workspace *model.Workspace) error {
+	query := `UPDATE workspaces SET name = $1, updated_at = NOW() WHERE id = $2 RETURNING updated_at`
+	return r.db.QueryRowContext(ctx, query, workspace.Name, workspace.ID).Scan(&workspace.UpdatedAt)
+}
+
+// Delete removes a workspace together with its projects, tasks, members
+// and invitations.
+func (r *WorkspaceRepositoryImpl) Delete(ctx context.Context, workspaceID int64) error {
+	query := `DELETE FROM workspaces WHERE id = $1`
+	result, err := r.db.ExecContext(ctx, query, workspaceID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// MemberRole returns the user's role in a workspace, or sql.ErrNoRows if
+// the user is not a member.
+func (r *WorkspaceRepositoryImpl) MemberRole(ctx context.Context, workspaceID int64, userID int64) (model.WorkspaceRole, error) {
+	var role model.WorkspaceRole
+	query := `SELECT role FROM workspace_members WHERE workspace_id = $1 AND user_id = $2`
+	err := r.db.GetContext(ctx, &role, query, workspaceID, userID)
+	return role, err
+}
+
+func (r *WorkspaceRepositoryImpl) ListMembers(ctx context.Context, workspaceID int64) ([]*model.WorkspaceMember, error) {
+	members := []*model.WorkspaceMember{}
+	query := `SELECT m.workspace_id, m.user_id, u.email, m.role, m.joined_at
+		FROM workspace_members m
+		JOIN users u ON u.id = m.user_id
+		WHERE m.workspace_id = $1
+		ORDER BY m.joined_at, m.user_id`
+	err := r.db.SelectContext(ctx, &members, query, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	return members, nil
+}
+
+// UpdateMemberRole changes a member's role. It returns sql.ErrNoRows if the
+// user is not a member, or is the last owner and would stop being one.
+func (r *WorkspaceRepositoryImpl) UpdateMemberRole(ctx context.Context, workspaceID int64, userID int64, role model.WorkspaceRole) error {
+	query := `UPDATE workspace_members SET role = $3
+		WHERE workspace_id = $1 AND user_id = $2 AND ($3 = 'owner' OR ` + lastOwnerGuard + `)`
+	result, err := r.db.ExecContext(ctx, query, workspaceID, userID, role)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// RemoveMember removes a user from a workspace. It returns sql.ErrNoRows
+// if the user is not a member or is the last owner.
+func (r *WorkspaceRepositoryImpl) RemoveMember(ctx context.Context, workspaceID int64, userID int64) error {
+	query := `DELETE FROM workspace_members WHERE workspace_id = $1 AND user_id = $2 AND ` + lastOwnerGuard
+	result, err := r.db.ExecContext(ctx, query, workspaceID, userID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+func (r *WorkspaceRepositoryImpl) CreateInvitation(ctx context.Context, invitation *model.WorkspaceInvitation) error {
+	query := `INSERT INTO workspace_invitations (workspace_id, email, role, token_hash, invited_by, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at`
+	return r.db.QueryRowContext(ctx, query,
+		invitation.WorkspaceID, invitation.Email, invitation.Role, invitation.TokenHash, invitation.InvitedBy, invitation.ExpiresAt,
+	).Scan(&invitation.ID, &invitation.CreatedAt)
+}
+
+func (r *WorkspaceRepositoryImpl) GetInvitationByHash(ctx context.Context, tokenHash string) (*model.WorkspaceInvitation, error) {
+	var invitation model.WorkspaceInvitation
+	query := `SELECT ` + invitationColumns + ` FROM workspace_invitations WHERE token_hash = $1`
+	err := r.db.GetContext(ctx, &invitation, query, tokenHash)
+	if err != nil {
+		return nil, err
+	}
+	return &invitation, nil
+}
+
+// AcceptInvitation marks a pending invitation accepted and adds the user
+// to the workspace with the invited role. A user who is already a member
+// keeps their current role. It returns sql.ErrNoRows if the invitation is
+// no longer pending.
+func (r *WorkspaceRepositoryImpl) AcceptInvitation(ctx context.Context, invitationID int64, userID int64) error {
+	query := `WITH accepted AS (
+			UPDATE workspace_invitations SET accepted_at = NOW()
+			WHERE id = $1 AND accepted_at IS NULL AND declined_at IS NULL AND expires_at > NOW()
+			RETURNING workspace_id, role
+		), joined AS (
+			INSERT INTO workspace_members (workspace_id, user_id, role)
+			SELECT workspace_id, $2, role FROM accepted
+			ON CONFLICT (workspace_id, user_id) DO NOTHING
+		)
+		SELECT workspace_id FROM accepted`
+	var workspaceID int64
+	return r.db.GetContext(ctx, &workspaceID, query, invitationID, userID)
+}
+
+// DeclineInvitation marks a pending invitation declined. It returns
+// sql.ErrNoRows if the invitation is no longer pending.
+func (r *WorkspaceRepositoryImpl) DeclineInvitation(ctx context.Context, invitationID int64) error {
+	query := `UPDATE workspace_invitations SET declined_at = NOW()
+		WHERE id = $1 AND accepted_at IS NULL AND declined_at IS NULL AND expires_at > NOW()`
+	result, err := r.db.ExecContext(ctx, query, invitationID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
@@ -0,0 +1,138 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ahmednurovic/task-manager-api/internal/model"
+	"github.com/ahmednurovic/task-manager-api/internal/repository"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	maxAccessTokenNameLength = 100
+	// lastUsedResolution is how stale last_used_at may get before it is
+	// written again, so a busy script does not write a row per request.
+	lastUsedResolution = time.Minute
+)
+
+type PersonalAccessTokenService struct {
+	tokenRepo repository.PersonalAccessTokenRepository
+}
+
+func NewPersonalAccessTokenService(tokenRepo repository.PersonalAccessTokenRepository) *PersonalAccessTokenService {
+	return &PersonalAccessTokenService{tokenRepo: tokenRepo}
+}
+
+// CreateToken issues a new token to the user. The token itself is set on
+// token.Token, and is the only time it can be seen.
+func (s *PersonalAccessTokenService) CreateToken(ctx *gin.Context, token *model.PersonalAccessToken, userID int64) error {
+	if err := normalizeAccessToken(token); err != nil {
+		return err
+	}
+
+	secret, err := randomToken(32, base64.RawURLEncoding.EncodeToString)
+	if err != nil {
+		return ErrTokenGeneration
+	}
+
+	token.ID = 0
+	token.UserID = uint(userID)
+	token.Token = model.PersonalAccessTokenPrefix + secret
+	token.TokenHash = hashToken(token.Token)
+	token.LastUsedAt = nil
+	token.RevokedAt = nil
+
+	return s.tokenRepo.Create(ctx, token)
+}
+
+func (s *PersonalAccessTokenService) GetTokens(ctx *gin.Context, userID int64) ([]*model.PersonalAccessToken, error) {
+	return s.tokenRepo.ListForUser(ctx, userID)
+}
+
+func (s *PersonalAccessTokenService) RevokeToken(ctx *gin.Context, tokenID int64, userID int64) error {
+	if err := s.tokenRepo.Revoke(ctx, tokenID, userID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrAccessTokenNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+// Authenticate returns the token a request presented, or nil if it is
+// unknown, revoked or expired. It also records that the token was used.
+func (s *PersonalAccessTokenService) Authenticate(ctx context.Context, token string) (*model.PersonalAccessToken, error) {
+	if !strings.HasPrefix(token, model.PersonalAccessTokenPrefix) {
+		return nil, nil
+	}
+
+	stored, err := s.tokenRepo.GetByHash(ctx, hashToken(token))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if stored.RevokedAt != nil || (stored.ExpiresAt != nil && !now.Before(*stored.ExpiresAt)) {
+		return nil, nil
+	}
+
+	if stored.LastUsedAt == nil || now.Sub(*stored.LastUsedAt) >= lastUsedResolution {
+		if err := s.tokenRepo.TouchLastUsed(ctx, int64(stored.ID), now.UTC()); err != nil {
+			return nil, err
+		}
+		stored.LastUsedAt = &now
+	}
+
+	return stored, nil
+}
+
+func normalizeAccessToken(token *model.PersonalAccessToken) error {
+	token.Name = strings.TrimSpace(token.Name)
+	if token.Name == "" {
+		return ErrAccessTokenNameRequired
+	}
+	if len([]rune(token.Name)) > maxAccessTokenNameLength {
+		return ErrAccessTokenNameTooLong
+	}
+
+	if token.ExpiresAt != nil && !token.ExpiresAt.After(time.Now()) {
+		return ErrAccessTokenExpired
+	}
+
+	if len(token.Scopes) == 0 {
+		return ErrScopesRequired
+	}
+	scopes := make(map[string]bool, len(token.Scopes))
+	for _, scope := range token.Scopes {
+		scope = strings.ToLower(strings.TrimSpace(scope))
+		if !validScope(scope) {
+			return ErrInvalidScope
+		}
+		scopes[scope] = true
+	}
+
+	token.Scopes = token.Scopes[:0]
+	for scope := range scopes {
+		token.Scopes = append(token.Scopes, scope)
+	}
+	sort.Strings(token.Scopes)
+	return nil
+}
+
+func validScope(scope string) bool {
+	for _, known := range model.Scopes {
+		if scope == known {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,153 @@
+package service_test
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/ahmednurovic/task-manager-api/internal/model"
+	"github.com/ahmednurovic/task-manager-api/internal/service"
+)
+
+type MockPersonalAccessTokenRepository struct {
+	mock.Mock
+}
+
+func (m *MockPersonalAccessTokenRepository) Create(ctx context.Context, token *model.PersonalAccessToken) error {
+	args := m.Called(ctx, token)
+	return args.Error(0)
+}
+
+func (m *MockPersonalAccessTokenRepository) GetByHash(ctx context.Context, tokenHash string) (*model.PersonalAccessToken, error) {
+	args := m.Called(ctx, tokenHash)
+	token, _ := args.Get(0).(*model.PersonalAccessToken)
+	return token, args.Error(1)
+}
+
+func (m *MockPersonalAccessTokenRepository) ListForUser(ctx context.Context, userID int64) ([]*model.PersonalAccessToken, error) {
+	args := m.Called(ctx, userID)
+	tokens, _ := args.Get(0).([]*model.PersonalAccessToken)
+	return tokens, args.Error(1)
+}
+
+func (m *MockPersonalAccessTokenRepository) Revoke(ctx context.Context, tokenID int64, userID int64) error {
+	args := m.Called(ctx, tokenID, userID)
+	return args.Error(0)
+}
+
+func (m *MockPersonalAccessTokenRepository) TouchLastUsed(ctx context.Context, tokenID int64, usedAt time.Time) error {
+	args := m.Called(ctx, tokenID, usedAt)
+	return args.Error(0)
+}
+
+func TestPersonalAccessTokenServiceCreateToken(t *testing.T) {
+	t.Run("Normalises Input", func(t *testing.T) {
+		mockTokenRepo := new(MockPersonalAccessTokenRepository)
+		mockTokenRepo.On("Create", mock.Anything, mock.MatchedBy(func(token *model.PersonalAccessToken) bool {
+			return token.UserID == 1 && token.Name == "CI" &&
+				assert.ObjectsAreEqual([]string{"labels:read", "tasks:write"}, []string(token.Scopes))
+		})).Return(nil)
+
+		tokenService := service.NewPersonalAccessTokenService(mockTokenRepo)
+		token := &model.PersonalAccessToken{UserID: 2, Name: " CI ", Scopes: []string{"tasks:write", " Labels:Read", "tasks:write"}}
+		err := tokenService.CreateToken(newTestContext(), token, 1)
+
+		assert.NoError(t, err)
+		assert.True(t, strings.HasPrefix(token.Token, "tm_pat_"))
+		assert.Equal(t, sha256Hex(token.Token), token.TokenHash)
+		mockTokenRepo.AssertExpectations(t)
+	})
+
+	past := time.Now().Add(-time.Hour)
+	tests := []struct {
+		name    string
+		token   model.PersonalAccessToken
+		wantErr error
+	}{
+		{"Name Required", model.PersonalAccessToken{Name: " ", Scopes: []string{"tasks:read"}}, service.ErrAccessTokenNameRequired},
+		{"Name Too Long", model.PersonalAccessToken{Name: strings.Repeat("a", 101), Scopes: []string{"tasks:read"}}, service.ErrAccessTokenNameTooLong},
+		{"Scopes Required", model.PersonalAccessToken{Name: "CI"}, service.ErrScopesRequired},
+		{"Unknown Scope", model.PersonalAccessToken{Name: "CI", Scopes: []string{"admin"}}, service.ErrInvalidScope},
+		{"Expiry In The Past", model.PersonalAccessToken{Name: "CI", Scopes: []string{"tasks:read"}, ExpiresAt: &past}, service.ErrAccessTokenExpired},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockTokenRepo := new(MockPersonalAccessTokenRepository)
+
+			tokenService := service.NewPersonalAccessTokenService(mockTokenRepo)
+			err := tokenService.CreateToken(newTestContext(), &tt.token, 1)
+
+			assert.ErrorIs(t, err, tt.wantErr)
+			mockTokenRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestPersonalAccessTokenServiceRevokeToken(t *testing.T) {
+	mockTokenRepo := new(MockPersonalAccessTokenRepository)
+	mockTokenRepo.On("Revoke", mock.Anything, int64(5), int64(1)).Return(nil)
+	mockTokenRepo.On("Revoke", mock.Anything, int64(6), int64(1)).Return(sql.ErrNoRows)
+
+	tokenService := service.NewPersonalAccessTokenService(mockTokenRepo)
+
+	assert.NoError(t, tokenService.RevokeToken(newTestContext(), 5, 1))
+	assert.ErrorIs(t, tokenService.RevokeToken(newTestContext(), 6, 1), service.ErrAccessTokenNotFound)
+}
+
+func TestPersonalAccessTokenServiceAuthenticate(t *testing.T) {
+	const token = "tm_pat_secret"
+	past := time.Now().Add(-time.Hour)
+	future := time.Now().Add(time.Hour)
+	recent := time.Now().Add(-10 * time.Second)
+
+	tests := []struct {
+		name      string
+		stored    *model.PersonalAccessToken
+		storedErr error
+		wantValid bool
+		wantTouch bool
+	}{
+		{"Valid", &model.PersonalAccessToken{ID: 5, UserID: 1}, nil, true, true},
+		{"Valid Until Expiry", &model.PersonalAccessToken{ID: 5, UserID: 1, ExpiresAt: &future}, nil, true, true},
+		{"Recently Used", &model.PersonalAccessToken{ID: 5, UserID: 1, LastUsedAt: &recent}, nil, true, false},
+		{"Unknown", nil, sql.ErrNoRows, false, false},
+		{"Revoked", &model.PersonalAccessToken{ID: 5, UserID: 1, RevokedAt: &past}, nil, false, false},
+		{"Expired", &model.PersonalAccessToken{ID: 5, UserID: 1, ExpiresAt: &past}, nil, false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockTokenRepo := new(MockPersonalAccessTokenRepository)
+			mockTokenRepo.On("GetByHash", mock.Anything, sha256Hex(token)).Return(tt.stored, tt.storedErr)
+			mockTokenRepo.On("TouchLastUsed", mock.Anything, int64(5), mock.Anything).Return(nil).Maybe()
+
+			tokenService := service.NewPersonalAccessTokenService(mockTokenRepo)
+			authenticated, err := tokenService.Authenticate(context.Background(), token)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantValid, authenticated != nil)
+			if tt.wantTouch {
+				mockTokenRepo.AssertCalled(t, "TouchLastUsed", mock.Anything, int64(5), mock.Anything)
+			} else {
+				mockTokenRepo.AssertNotCalled(t, "TouchLastUsed", mock.Anything, mock.Anything, mock.Anything)
+			}
+		})
+	}
+
+	t.Run("Not A Personal Access Token", func(t *testing.T) {
+		mockTokenRepo := new(MockPersonalAccessTokenRepository)
+
+		tokenService := service.NewPersonalAccessTokenService(mockTokenRepo)
+		authenticated, err := tokenService.Authenticate(context.Background(), "eyJhbGciOiJIUzI1NiJ9")
+
+		assert.NoError(t, err)
+		assert.Nil(t, authenticated)
+		mockTokenRepo.AssertNotCalled(t, "GetByHash", mock.Anything, mock.Anything)
+	})
+}
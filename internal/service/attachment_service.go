@@ -0,0 +1,291 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ahmednurovic/task-manager-api/internal/authz"
+	"github.com/ahmednurovic/task-manager-api/internal/blob"
+	"github.com/ahmednurovic/task-manager-api/internal/model"
+	"github.com/ahmednurovic/task-manager-api/internal/repository"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	maxAttachmentNameLength = 255
+	// sniffLength is how much of a file http.DetectContentType looks at.
+	sniffLength = 512
+	// garbageBatchSize is how many orphaned blobs CollectGarbage deletes
+	// per query.
+	garbageBatchSize = 100
+)
+
+// AttachmentPolicy holds the limits on uploads and the settings for
+// download links.
+type AttachmentPolicy struct {
+	// MaxSize is the largest upload accepted, in bytes.
+	MaxSize int64
+	// AllowedTypes lists the media types, such as image/png, that uploads
+	// may have. Types are sniffed from the file's contents.
+	AllowedTypes []string
+	// URLTTL is how long a download link works for.
+	URLTTL time.Duration
+	// URLSecret signs download links.
+	URLSecret []byte
+}
+
+type AttachmentService struct {
+	attachmentRepo repository.AttachmentRepository
+	taskRepo       repository.TaskRepository
+	store          blob.BlobStore
+	authorizer     *authz.Authorizer
+	policy         AttachmentPolicy
+}
+
+func NewAttachmentService(
+	attachmentRepo repository.AttachmentRepository,
+	taskRepo repository.TaskRepository,
+	store blob.BlobStore,
+	authorizer *authz.Authorizer,
+	policy AttachmentPolicy,
+) *AttachmentService {
+	return &AttachmentService{
+		attachmentRepo: attachmentRepo,
+		taskRepo:       taskRepo,
+		store:          store,
+		authorizer:     authorizer,
+		policy:         policy,
+	}
+}
+
+func (s *AttachmentService) GetAttachments(ctx *gin.Context, taskID int64, userID int64) ([]*model.Attachment, error) {
+	if _, err := getTask(ctx, s.taskRepo, s.authorizer, taskID, userID, authz.ReadTasks); err != nil {
+		return nil, err
+	}
+
+	return s.attachmentRepo.ListForTask(ctx, taskID)
+}
+
+// UploadAttachment stores size bytes read from r as an attachment of a
+// task the user may change. The file's type is sniffed from its first
+// bytes and must be allowed by the policy; the name the client gave it is
+// kept without any directories.
+func (s *AttachmentService) UploadAttachment(ctx *gin.Context, taskID int64, filename string, size int64, r io.Reader, userID int64) (*model.Attachment, error) {
+	if size <= 0 {
+		return nil, ErrAttachmentEmpty
+	}
+	if size > s.policy.MaxSize {
+		return nil, fmt.Errorf("%w: the limit is %d bytes", ErrAttachmentTooLarge, s.policy.MaxSize)
+	}
+
+	if _, err := getTask(ctx, s.taskRepo, s.authorizer, taskID, userID, authz.WriteTasks); err != nil {
+		return nil, err
+	}
+
+	head := make([]byte, min(size, sniffLength))
+	if _, err := io.ReadFull(r, head); err != nil {
+		return nil, err
+	}
+	contentType, _, err := mime.ParseMediaType(http.DetectContentType(head))
+	if err != nil || !slices.Contains(s.policy.AllowedTypes, contentType) {
+		return nil, fmt.Errorf("%w: %s", ErrAttachmentTypeNotAllowed, contentType)
+	}
+
+	key, err := newStorageKey()
+	if err != nil {
+		return nil, err
+	}
+
+	checksum := sha256.New()
+	body := io.TeeReader(io.MultiReader(bytes.NewReader(head), io.LimitReader(r, size-int64(len(head)))), checksum)
+	if err := s.store.Put(ctx, key, body, size, contentType); err != nil {
+		return nil, err
+	}
+
+	uploaderID := uint(userID)
+	attachment := &model.Attachment{
+		TaskID:      uint(taskID),
+		UploaderID:  &uploaderID,
+		Filename:    cleanAttachmentName(filename),
+		ContentType: contentType,
+		Size:        size,
+		SHA256:      hex.EncodeToString(checksum.Sum(nil)),
+		StorageKey:  key,
+	}
+	if err := s.attachmentRepo.Create(ctx, attachment); err != nil {
+		// Nothing refers to the blob yet, so the garbage collector would
+		// never find it.
+		_ = s.store.Delete(ctx, key)
+		return nil, err
+	}
+
+	return attachment, nil
+}
+
+// DeleteAttachment removes an attachment from its task. Its blob is
+// deleted later by CollectGarbage.
+func (s *AttachmentService) DeleteAttachment(ctx *gin.Context, taskID int64, attachmentID int64, userID int64) error {
+	if _, err := getTask(ctx, s.taskRepo, s.authorizer, taskID, userID, authz.WriteTasks); err != nil {
+		return err
+	}
+
+	if err := s.attachmentRepo.Detach(ctx, attachmentID, taskID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrAttachmentNotFound
+		}
+		return err
+	}
+
+	return nil
+}
+
+// GetAttachmentURL returns a link that downloads an attachment of a task
+// the user can see, valid for the policy's URLTTL.
+func (s *AttachmentService) GetAttachmentURL(ctx *gin.Context, taskID int64, attachmentID int64, userID int64) (*model.AttachmentURL, error) {
+	if _, err := getTask(ctx, s.taskRepo, s.authorizer, taskID, userID, authz.ReadTasks); err != nil {
+		return nil, err
+	}
+
+	attachment, err := s.getAttachment(ctx, attachmentID)
+	if err != nil {
+		return nil, err
+	}
+	if attachment.TaskID != uint(taskID) {
+		return nil, ErrAttachmentNotFound
+	}
+
+	expiresAt := time.Now().Add(s.policy.URLTTL).Truncate(time.Second)
+	expires := strconv.FormatInt(expiresAt.Unix(), 10)
+	return &model.AttachmentURL{
+		URL: fmt.Sprintf("/api/v1/attachments/%d/content?expires=%s&signature=%s",
+			attachment.ID, expires, s.signAttachmentURL(attachment.ID, expires)),
+		ExpiresAt: expiresAt.UTC(),
+	}, nil
+}
+
+// OpenAttachment checks a download link made by GetAttachmentURL and
+// returns the attachment with its contents, which the caller must close.
+func (s *AttachmentService) OpenAttachment(ctx *gin.Context, attachmentID int64, expires string, signature string) (*model.Attachment, io.ReadCloser, error) {
+	expiresAt, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || time.Now().Unix() > expiresAt {
+		return nil, nil, ErrInvalidAttachmentURL
+	}
+	if !hmac.Equal([]byte(signature), []byte(s.signAttachmentURL(uint(attachmentID), expires))) {
+		return nil, nil, ErrInvalidAttachmentURL
+	}
+
+	attachment, err := s.getAttachment(ctx, attachmentID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	contents, err := s.store.Open(ctx, attachment.StorageKey)
+	if err != nil {
+		if errors.Is(err, blob.ErrNotFound) {
+			return nil, nil, ErrAttachmentNotFound
+		}
+		return nil, nil, err
+	}
+
+	return attachment, contents, nil
+}
+
+// CollectGarbage deletes the blobs of attachments that were deleted or
+// whose task was, then forgets the attachments.
+func (s *AttachmentService) CollectGarbage(ctx context.Context) error {
+	for {
+		orphans, err := s.attachmentRepo.ListOrphaned(ctx, garbageBatchSize)
+		if err != nil {
+			return err
+		}
+
+		for _, orphan := range orphans {
+			if err := s.store.Delete(ctx, orphan.StorageKey); err != nil {
+				return err
+			}
+			if err := s.attachmentRepo.Purge(ctx, int64(orphan.ID)); err != nil {
+				return err
+			}
+		}
+
+		if len(orphans) < garbageBatchSize {
+			return nil
+		}
+	}
+}
+
+// RunGarbageCollection calls CollectGarbage every interval until ctx is
+// done. Errors are passed to onError, which may be nil.
+func (s *AttachmentService) RunGarbageCollection(ctx context.Context, interval time.Duration, onError func(error)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.CollectGarbage(ctx); err != nil && onError != nil {
+				onError(err)
+			}
+		}
+	}
+}
+
+func (s *AttachmentService) getAttachment(ctx *gin.Context, attachmentID int64) (*model.Attachment, error) {
+	attachment, err := s.attachmentRepo.GetByID(ctx, attachmentID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrAttachmentNotFound
+		}
+		return nil, err
+	}
+	return attachment, nil
+}
+
+// signAttachmentURL returns the hex HMAC-SHA256 of an attachment ID and
+// the Unix time its link expires at.
+func (s *AttachmentService) signAttachmentURL(attachmentID uint, expires string) string {
+	mac := hmac.New(sha256.New, s.policy.URLSecret)
+	fmt.Fprintf(mac, "%d:%s", attachmentID, expires)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// newStorageKey returns a random key for a new blob, so that uploads never
+// overwrite each other.
+func newStorageKey() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	name := hex.EncodeToString(b)
+	return "attachments/" + name[:2] + "/" + name, nil
+}
+
+// cleanAttachmentName drops any directories from an uploaded file's name,
+// whichever separator the client used, and shortens it to fit.
+func cleanAttachmentName(filename string) string {
+	name := strings.TrimSpace(path.Base(strings.ReplaceAll(filename, `\`, "/")))
+	if name == "" || name == "." || name == "/" {
+		return "attachment"
+	}
+	if runes := []rune(name); len(runes) > maxAttachmentNameLength {
+		name = string(runes[:maxAttachmentNameLength])
+	}
+	return name
+}
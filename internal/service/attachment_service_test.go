@@ -0,0 +1,286 @@
+package service_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"io"
+	"io/fs"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ahmednurovic/task-manager-api/internal/blob"
+	"github.com/ahmednurovic/task-manager-api/internal/model"
+	"github.com/ahmednurovic/task-manager-api/internal/service"
+)
+
+type MockAttachmentRepository struct {
+	mock.Mock
+}
+
+func (m *MockAttachmentRepository) Create(ctx context.Context, attachment *model.Attachment) error {
+	args := m.Called(ctx, attachment)
+	return args.Error(0)
+}
+
+func (m *MockAttachmentRepository) ListForTask(ctx context.Context, taskID int64) ([]*model.Attachment, error) {
+	args := m.Called(ctx, taskID)
+	attachments, _ := args.Get(0).([]*model.Attachment)
+	return attachments, args.Error(1)
+}
+
+func (m *MockAttachmentRepository) GetByID(ctx context.Context, attachmentID int64) (*model.Attachment, error) {
+	args := m.Called(ctx, attachmentID)
+	attachment, _ := args.Get(0).(*model.Attachment)
+	return attachment, args.Error(1)
+}
+
+func (m *MockAttachmentRepository) Detach(ctx context.Context, attachmentID int64, taskID int64) error {
+	args := m.Called(ctx, attachmentID, taskID)
+	return args.Error(0)
+}
+
+func (m *MockAttachmentRepository) ListOrphaned(ctx context.Context, limit int) ([]*model.Attachment, error) {
+	args := m.Called(ctx, limit)
+	attachments, _ := args.Get(0).([]*model.Attachment)
+	return attachments, args.Error(1)
+}
+
+func (m *MockAttachmentRepository) Purge(ctx context.Context, attachmentID int64) error {
+	args := m.Called(ctx, attachmentID)
+	return args.Error(0)
+}
+
+const pngHeader = "\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR"
+
+var testAttachmentPolicy = service.AttachmentPolicy{
+	MaxSize:      1024,
+	AllowedTypes: []string{"image/png", "text/plain"},
+	URLTTL:       time.Minute,
+	URLSecret:    []byte("attachment-secret"),
+}
+
+// storedFiles returns the paths of the blobs under a local store's root.
+func storedFiles(t *testing.T, root string) []string {
+	t.Helper()
+	var files []string
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err == nil && !d.IsDir() {
+			files = append(files, path)
+		}
+		return err
+	})
+	require.NoError(t, err)
+	return files
+}
+
+func TestAttachmentServiceUploadAttachment(t *testing.T) {
+	t.Run("Stores Blob With Checksum", func(t *testing.T) {
+		mockTaskRepo := new(MockTaskRepository)
+		sharedTask(mockTaskRepo)
+		var stored *model.Attachment
+		mockAttachmentRepo := new(MockAttachmentRepository)
+		mockAttachmentRepo.On("Create", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+			stored = args.Get(1).(*model.Attachment)
+		}).Return(nil)
+		root := t.TempDir()
+		store := blob.NewLocalStore(root)
+
+		attachmentService := service.NewAttachmentService(mockAttachmentRepo, mockTaskRepo, store, testAuthorizer, testAttachmentPolicy)
+		attachment, err := attachmentService.UploadAttachment(workspaceContext(3, model.RoleMember), 10,
+			`C:\Users\bo\diagram.png`, int64(len(pngHeader)), strings.NewReader(pngHeader), 2)
+
+		require.NoError(t, err)
+		sum := sha256.Sum256([]byte(pngHeader))
+		assert.Same(t, stored, attachment)
+		assert.Equal(t, uint(10), attachment.TaskID)
+		assert.Equal(t, uint(2), *attachment.UploaderID)
+		assert.Equal(t, "diagram.png", attachment.Filename)
+		assert.Equal(t, "image/png", attachment.ContentType)
+		assert.Equal(t, int64(len(pngHeader)), attachment.Size)
+		assert.Equal(t, hex.EncodeToString(sum[:]), attachment.SHA256)
+
+		contents, err := store.Open(context.Background(), attachment.StorageKey)
+		require.NoError(t, err)
+		defer contents.Close()
+		data, err := io.ReadAll(contents)
+		require.NoError(t, err)
+		assert.Equal(t, pngHeader, string(data))
+	})
+
+	t.Run("Type Is Sniffed", func(t *testing.T) {
+		mockTaskRepo := new(MockTaskRepository)
+		sharedTask(mockTaskRepo)
+		mockAttachmentRepo := new(MockAttachmentRepository)
+		root := t.TempDir()
+
+		attachmentService := service.NewAttachmentService(mockAttachmentRepo, mockTaskRepo, blob.NewLocalStore(root), testAuthorizer, testAttachmentPolicy)
+		body := "<html><script>alert(1)</script></html>"
+		_, err := attachmentService.UploadAttachment(workspaceContext(3, model.RoleMember), 10,
+			"notes.txt", int64(len(body)), strings.NewReader(body), 2)
+
+		assert.ErrorIs(t, err, service.ErrAttachmentTypeNotAllowed)
+		assert.Empty(t, storedFiles(t, root))
+		mockAttachmentRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Size Limits", func(t *testing.T) {
+		attachmentService := service.NewAttachmentService(new(MockAttachmentRepository), new(MockTaskRepository), nil, testAuthorizer, testAttachmentPolicy)
+
+		_, err := attachmentService.UploadAttachment(newTestContext(), 10, "big.txt", 1025, strings.NewReader(""), 1)
+		assert.ErrorIs(t, err, service.ErrAttachmentTooLarge)
+
+		_, err = attachmentService.UploadAttachment(newTestContext(), 10, "empty.txt", 0, strings.NewReader(""), 1)
+		assert.ErrorIs(t, err, service.ErrAttachmentEmpty)
+	})
+
+	t.Run("Viewer Denied", func(t *testing.T) {
+		mockTaskRepo := new(MockTaskRepository)
+		sharedTask(mockTaskRepo)
+		root := t.TempDir()
+
+		attachmentService := service.NewAttachmentService(new(MockAttachmentRepository), mockTaskRepo, blob.NewLocalStore(root), testAuthorizer, testAttachmentPolicy)
+		_, err := attachmentService.UploadAttachment(workspaceContext(3, model.RoleViewer), 10,
+			"notes.txt", 5, strings.NewReader("hello"), 2)
+
+		assert.ErrorIs(t, err, service.ErrInsufficientRole)
+		assert.Empty(t, storedFiles(t, root))
+	})
+
+	t.Run("Blob Removed When Metadata Fails", func(t *testing.T) {
+		mockTaskRepo := new(MockTaskRepository)
+		sharedTask(mockTaskRepo)
+		mockAttachmentRepo := new(MockAttachmentRepository)
+		mockAttachmentRepo.On("Create", mock.Anything, mock.Anything).Return(errors.New("connection reset"))
+		root := t.TempDir()
+
+		attachmentService := service.NewAttachmentService(mockAttachmentRepo, mockTaskRepo, blob.NewLocalStore(root), testAuthorizer, testAttachmentPolicy)
+		_, err := attachmentService.UploadAttachment(workspaceContext(3, model.RoleMember), 10,
+			"notes.txt", 5, strings.NewReader("hello"), 2)
+
+		assert.EqualError(t, err, "connection reset")
+		assert.Empty(t, storedFiles(t, root))
+	})
+}
+
+func TestAttachmentServiceDeleteAttachment(t *testing.T) {
+	mockTaskRepo := new(MockTaskRepository)
+	sharedTask(mockTaskRepo)
+	mockAttachmentRepo := new(MockAttachmentRepository)
+	mockAttachmentRepo.On("Detach", mock.Anything, int64(30), int64(10)).Return(nil)
+	mockAttachmentRepo.On("Detach", mock.Anything, int64(31), int64(10)).Return(sql.ErrNoRows)
+
+	attachmentService := service.NewAttachmentService(mockAttachmentRepo, mockTaskRepo, nil, testAuthorizer, testAttachmentPolicy)
+
+	assert.NoError(t, attachmentService.DeleteAttachment(workspaceContext(3, model.RoleMember), 10, 30, 2))
+	assert.ErrorIs(t, attachmentService.DeleteAttachment(workspaceContext(3, model.RoleMember), 10, 31, 2), service.ErrAttachmentNotFound)
+}
+
+func TestAttachmentServiceDownloadLinks(t *testing.T) {
+	setup := func(t *testing.T, policy service.AttachmentPolicy) *service.AttachmentService {
+		mockTaskRepo := new(MockTaskRepository)
+		sharedTask(mockTaskRepo)
+		store := blob.NewLocalStore(t.TempDir())
+		require.NoError(t, store.Put(context.Background(), "attachments/ab/abc", strings.NewReader("hello"), 5, "text/plain"))
+		mockAttachmentRepo := new(MockAttachmentRepository)
+		mockAttachmentRepo.On("GetByID", mock.Anything, int64(30)).
+			Return(&model.Attachment{ID: 30, TaskID: 10, Filename: "notes.txt", StorageKey: "attachments/ab/abc"}, nil)
+		mockAttachmentRepo.On("GetByID", mock.Anything, int64(31)).
+			Return(&model.Attachment{ID: 31, TaskID: 11, Filename: "secret.txt", StorageKey: "attachments/cd/cde"}, nil)
+		return service.NewAttachmentService(mockAttachmentRepo, mockTaskRepo, store, testAuthorizer, policy)
+	}
+	query := func(t *testing.T, link *model.AttachmentURL) url.Values {
+		t.Helper()
+		parsed, err := url.Parse(link.URL)
+		require.NoError(t, err)
+		assert.Equal(t, "/api/v1/attachments/30/content", parsed.Path)
+		return parsed.Query()
+	}
+
+	t.Run("Round Trip", func(t *testing.T) {
+		attachmentService := setup(t, testAttachmentPolicy)
+		link, err := attachmentService.GetAttachmentURL(workspaceContext(3, model.RoleViewer), 10, 30, 2)
+		require.NoError(t, err)
+		assert.WithinDuration(t, time.Now().Add(time.Minute), link.ExpiresAt, 2*time.Second)
+
+		params := query(t, link)
+		attachment, contents, err := attachmentService.OpenAttachment(newTestContext(), 30, params.Get("expires"), params.Get("signature"))
+		require.NoError(t, err)
+		defer contents.Close()
+		data, err := io.ReadAll(contents)
+		require.NoError(t, err)
+		assert.Equal(t, "notes.txt", attachment.Filename)
+		assert.Equal(t, "hello", string(data))
+	})
+
+	t.Run("Link Is Bound To Attachment And Expiry", func(t *testing.T) {
+		attachmentService := setup(t, testAttachmentPolicy)
+		link, err := attachmentService.GetAttachmentURL(workspaceContext(3, model.RoleViewer), 10, 30, 2)
+		require.NoError(t, err)
+		params := query(t, link)
+		expires, err := strconv.ParseInt(params.Get("expires"), 10, 64)
+		require.NoError(t, err)
+
+		_, _, err = attachmentService.OpenAttachment(newTestContext(), 31, params.Get("expires"), params.Get("signature"))
+		assert.ErrorIs(t, err, service.ErrInvalidAttachmentURL)
+		_, _, err = attachmentService.OpenAttachment(newTestContext(), 30, strconv.FormatInt(expires+3600, 10), params.Get("signature"))
+		assert.ErrorIs(t, err, service.ErrInvalidAttachmentURL)
+		_, _, err = attachmentService.OpenAttachment(newTestContext(), 30, params.Get("expires"), "")
+		assert.ErrorIs(t, err, service.ErrInvalidAttachmentURL)
+	})
+
+	t.Run("Expired Link", func(t *testing.T) {
+		policy := testAttachmentPolicy
+		policy.URLTTL = -time.Minute
+		attachmentService := setup(t, policy)
+		link, err := attachmentService.GetAttachmentURL(workspaceContext(3, model.RoleViewer), 10, 30, 2)
+		require.NoError(t, err)
+
+		params := query(t, link)
+		_, _, err = attachmentService.OpenAttachment(newTestContext(), 30, params.Get("expires"), params.Get("signature"))
+		assert.ErrorIs(t, err, service.ErrInvalidAttachmentURL)
+	})
+
+	t.Run("Attachment Of Another Task", func(t *testing.T) {
+		attachmentService := setup(t, testAttachmentPolicy)
+
+		_, err := attachmentService.GetAttachmentURL(workspaceContext(3, model.RoleViewer), 10, 31, 2)
+		assert.ErrorIs(t, err, service.ErrAttachmentNotFound)
+	})
+}
+
+func TestAttachmentServiceCollectGarbage(t *testing.T) {
+	store := blob.NewLocalStore(t.TempDir())
+	ctx := context.Background()
+	require.NoError(t, store.Put(ctx, "attachments/aa/one", strings.NewReader("one"), 3, "text/plain"))
+	require.NoError(t, store.Put(ctx, "attachments/bb/two", strings.NewReader("two"), 3, "text/plain"))
+
+	mockAttachmentRepo := new(MockAttachmentRepository)
+	mockAttachmentRepo.On("ListOrphaned", mock.Anything, 100).Return([]*model.Attachment{
+		{ID: 1, StorageKey: "attachments/aa/one"},
+		// Its blob is already gone, e.g. after an interrupted earlier run.
+		{ID: 2, StorageKey: "attachments/cc/three"},
+	}, nil)
+	mockAttachmentRepo.On("Purge", mock.Anything, int64(1)).Return(nil)
+	mockAttachmentRepo.On("Purge", mock.Anything, int64(2)).Return(nil)
+
+	attachmentService := service.NewAttachmentService(mockAttachmentRepo, nil, store, testAuthorizer, testAttachmentPolicy)
+	require.NoError(t, attachmentService.CollectGarbage(ctx))
+
+	mockAttachmentRepo.AssertExpectations(t)
+	_, err := store.Open(ctx, "attachments/aa/one")
+	assert.ErrorIs(t, err, blob.ErrNotFound)
+	contents, err := store.Open(ctx, "attachments/bb/two")
+	require.NoError(t, err)
+	contents.Close()
+}
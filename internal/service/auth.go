@@ -2,34 +2,193 @@ package service
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
 	"errors"
-
+	"sync"
 	"time"
 
+	"github.com/ahmednurovic/task-manager-api/internal/mail"
 	"github.com/ahmednurovic/task-manager-api/internal/model"
+	"github.com/ahmednurovic/task-manager-api/internal/password"
 	"github.com/ahmednurovic/task-manager-api/internal/repository"
+	"github.com/ahmednurovic/task-manager-api/internal/signing"
 	"github.com/golang-jwt/jwt/v5"
-	"golang.org/x/crypto/bcrypt"
 )
 
+const (
+	DefaultAccessTokenTTL   = 15 * time.Minute
+	DefaultRefreshTokenTTL  = 30 * 24 * time.Hour
+	DefaultPasswordResetTTL = 30 * time.Minute
+	// Verification links are often opened hours later, from another device.
+	DefaultEmailVerificationTTL       = 48 * time.Hour
+	DefaultVerificationResendInterval = time.Minute
+	DefaultMFAChallengeTTL            = 5 * time.Minute
+	DefaultMFAIssuer                  = "Task Manager"
+)
+
+// EmailVerificationPolicy decides what users who have not verified their
+// email address yet may do.
+type EmailVerificationPolicy string
+
+const (
+	// VerificationAllow lets unverified users do everything.
+	VerificationAllow EmailVerificationPolicy = "allow"
+	// VerificationReadOnly lets unverified users log in, but their tokens
+	// only work for reads.
+	VerificationReadOnly EmailVerificationPolicy = "read_only"
+	// VerificationBlock stops unverified users from logging in.
+	VerificationBlock EmailVerificationPolicy = "block"
+)
+
+func (p EmailVerificationPolicy) Valid() bool {
+	switch p {
+	case VerificationAllow, VerificationReadOnly, VerificationBlock:
+		return true
+	}
+	return false
+}
+
+// AuthPolicy sets how long issued tokens stay valid, where emails link to
+// and how unverified users are treated. Zero values fall back to the
+// defaults; the default verification policy is VerificationAllow.
+type AuthPolicy struct {
+	AccessTokenTTL   time.Duration
+	RefreshTokenTTL  time.Duration
+	PasswordResetTTL time.Duration
+	// PasswordResetURL is the page that completes a reset. The token is
+	// added to it as the token query parameter.
+	PasswordResetURL string
+
+	EmailVerification          EmailVerificationPolicy
+	EmailVerificationTTL       time.Duration
+	VerificationResendInterval time.Duration
+	// EmailVerificationURL is the page that completes verification, with
+	// the token added the same way as for PasswordResetURL.
+	EmailVerificationURL string
+	// OnMailError, which may be nil, is passed errors sending the
+	// verification email at registration and password reset emails. They
+	// are not returned: the user can ask for the email again, and a failure
+	// only for existing accounts would tell which emails have one.
+	OnMailError func(error)
+
+	// MFAIssuer names the service in authenticator apps.
+	MFAIssuer string
+	// MFAChallengeTTL is how long a user with two-factor authentication
+	// has, after getting their password right, to enter a code.
+	MFAChallengeTTL time.Duration
+
+	// PasswordHasher hashes new passwords and checks stored ones. Stored
+	// hashes it would not make itself are replaced when their user logs
+	// in. The default hashes with argon2id and still checks bcrypt.
+	PasswordHasher password.Hasher
+	// PasswordPolicy applies whenever a user chooses a password.
+	PasswordPolicy password.Policy
+}
+
 type AuthServicer interface {
 	Register(ctx context.Context, email, password string) (*model.User, error)
-	Login(ctx context.Context, email, password string) (string, error)
+	Login(ctx context.Context, email, password, clientIP string) (*model.TokenPair, error)
+	Refresh(ctx context.Context, refreshToken string) (*model.TokenPair, error)
+	Logout(ctx context.Context, userID int64, tokenID string, expiresAt time.Time, refreshToken string) error
+	LogoutAll(ctx context.Context, userID int64) error
+	ForgotPassword(ctx context.Context, email string) error
+	ResetPassword(ctx context.Context, token, password string) error
+	VerifyEmail(ctx context.Context, token string) error
+	ResendVerification(ctx context.Context, email string) error
+	EnrollMFA(ctx context.Context, userID int64) (*model.MFAEnrollment, error)
+	ConfirmMFA(ctx context.Context, userID int64, code string) ([]string, error)
+	DisableMFA(ctx context.Context, userID int64, password, code, clientIP string) error
+	VerifyMFA(ctx context.Context, mfaToken, code, clientIP string) (*model.TokenPair, error)
+	SignIn(ctx context.Context, user *model.User) (*model.TokenPair, error)
+	UnlockAccount(ctx context.Context, adminID int64, email string) error
+	IsAdmin(ctx context.Context, userID uint) (bool, error)
 }
 
 type AuthService struct {
-	userRepo  *repository.UserRepository
-	jwtSecret string
+	userRepo         repository.UserRepository
+	refreshTokenRepo repository.RefreshTokenRepository
+	revocations      *TokenRevocationStore
+	throttle         *LoginThrottle
+	mailer           mail.Mailer
+	keys             *signing.KeySet
+	policy           AuthPolicy
+	resendLimiter    *intervalLimiter
+	// mfaKey signs MFA tokens. It is not an access token key, so an MFA
+	// token can never pass for an access token.
+	mfaKey      []byte
+	mfaAttempts *attemptCounter
+	// dummyHash is checked against when there is no user, so that Login
+	// takes as long as it does for a wrong password.
+	dummyHash func() string
 }
 
-func NewAuthService(userRepo *repository.UserRepository, jwtSecret string) AuthServicer {
+func NewAuthService(
+	userRepo repository.UserRepository,
+	refreshTokenRepo repository.RefreshTokenRepository,
+	revocations *TokenRevocationStore,
+	throttle *LoginThrottle,
+	mailer mail.Mailer,
+	keys *signing.KeySet,
+	policy AuthPolicy,
+) AuthServicer {
+	if policy.AccessTokenTTL <= 0 {
+		policy.AccessTokenTTL = DefaultAccessTokenTTL
+	}
+	if policy.RefreshTokenTTL <= 0 {
+		policy.RefreshTokenTTL = DefaultRefreshTokenTTL
+	}
+	if policy.PasswordResetTTL <= 0 {
+		policy.PasswordResetTTL = DefaultPasswordResetTTL
+	}
+	if policy.EmailVerification == "" {
+		policy.EmailVerification = VerificationAllow
+	}
+	if policy.EmailVerificationTTL <= 0 {
+		policy.EmailVerificationTTL = DefaultEmailVerificationTTL
+	}
+	if policy.VerificationResendInterval <= 0 {
+		policy.VerificationResendInterval = DefaultVerificationResendInterval
+	}
+	if policy.MFAIssuer == "" {
+		policy.MFAIssuer = DefaultMFAIssuer
+	}
+	if policy.MFAChallengeTTL <= 0 {
+		policy.MFAChallengeTTL = DefaultMFAChallengeTTL
+	}
+	if policy.PasswordHasher == nil {
+		policy.PasswordHasher = password.Chain(password.DefaultArgon2id, password.DefaultBcrypt)
+	}
+
 	return &AuthService{
-		userRepo:  userRepo,
-		jwtSecret: jwtSecret,
+		userRepo:         userRepo,
+		refreshTokenRepo: refreshTokenRepo,
+		revocations:      revocations,
+		throttle:         throttle,
+		mailer:           mailer,
+		keys:             keys,
+		policy:           policy,
+		resendLimiter:    newIntervalLimiter(policy.VerificationResendInterval),
+		mfaKey:           keys.Derive("mfa-token"),
+		mfaAttempts:      newAttemptCounter(mfaChallengeAttempts),
+		dummyHash: sync.OnceValue(func() string {
+			hash, err := policy.PasswordHasher.Hash(rand.Text())
+			if err != nil {
+				panic(err)
+			}
+			return hash
+		}),
 	}
 }
 
 func (s *AuthService) Register(ctx context.Context, email, password string) (*model.User, error) {
+	if err := s.policy.PasswordPolicy.Check(password); err != nil {
+		return nil, err
+	}
+
 	existingUser, err := s.userRepo.GetUserByEmail(ctx, email)
 	if err != nil {
 		return nil, err
@@ -38,45 +197,330 @@ func (s *AuthService) Register(ctx context.Context, email, password string) (*mo
 		return nil, errors.New("user already exists")
 	}
 
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	hashedPassword, err := s.policy.PasswordHasher.Hash(password)
 	if err != nil {
 		return nil, err
 	}
 
 	user := &model.User{
 		Email:    email,
-		Password: string(hashedPassword),
+		Password: hashedPassword,
 	}
 
 	if err := s.userRepo.CreateUser(ctx, user); err != nil {
 		return nil, err
 	}
 
+	// The account exists now, so failing here would only make a retry
+	// fail with "user already exists". The user can resend the email.
+	s.resendLimiter.Allow(email)
+	if err := s.sendVerification(ctx, user); err != nil && s.policy.OnMailError != nil {
+		s.policy.OnMailError(err)
+	}
+
 	return user, nil
 }
 
-func (s *AuthService) Login(ctx context.Context, email, password string) (string, error) {
+// Login checks the user's password and starts a new refresh token family.
+// Users who have not verified their email get ErrEmailNotVerified or a
+// read-only token, depending on the verification policy. Users with
+// two-factor authentication on get an MFA token instead, for VerifyMFA.
+//
+// Failed logins are counted against the email and clientIP; while either
+// is locked out, Login returns a RetryAfterError without checking the
+// password. For users with two-factor authentication on, wrong codes given
+// to VerifyMFA count as failed logins too. An unknown email is treated
+// like a wrong password, down to the time taken.
+//
+// A password hash made with an outdated algorithm or outdated parameters
+// is replaced once the password has been checked.
+func (s *AuthService) Login(ctx context.Context, email, password, clientIP string) (*model.TokenPair, error) {
+	if err := s.throttle.Check(ctx, email, clientIP); err != nil {
+		return nil, err
+	}
+
 	user, err := s.userRepo.GetUserByEmail(ctx, email)
 	if err != nil {
-		return "", ErrInvalidCredentials
+		return nil, ErrInvalidCredentials
+	}
+
+	// Users without a password, such as those created by single sign-on,
+	// are checked against the dummy hash too.
+	hash := s.dummyHash()
+	if user != nil && user.Password != "" {
+		hash = user.Password
+	}
+	err = s.policy.PasswordHasher.Verify(password, hash)
+	if err != nil || user == nil || user.Password == "" {
+		if err := s.throttle.Failure(ctx, email, clientIP); err != nil {
+			return nil, err
+		}
+		return nil, ErrInvalidCredentials
+	}
+
+	// With two-factor authentication on, the password alone is not a
+	// successful login. Failures are forgotten once VerifyMFA accepts a
+	// code, so logging in again buys no more guesses at the code.
+	if user.MFAEnabledAt == nil {
+		if err := s.throttle.Success(ctx, email); err != nil {
+			return nil, err
+		}
+	}
+	s.rehash(ctx, user, password)
+
+	return s.SignIn(ctx, user)
+}
+
+// SignIn issues tokens to a user who has been authenticated some other way
+// than with a password, such as by an identity provider. The verification
+// policy and two-factor authentication apply just as they do to Login.
+func (s *AuthService) SignIn(ctx context.Context, user *model.User) (*model.TokenPair, error) {
+	if s.blocked(user) {
+		return nil, ErrEmailNotVerified
+	}
+
+	if user.MFAEnabledAt != nil {
+		mfaToken, expiresAt, err := s.newMFAToken(user)
+		if err != nil {
+			return nil, ErrTokenGeneration
+		}
+		return &model.TokenPair{MFARequired: true, MFAToken: mfaToken, ExpiresAt: expiresAt}, nil
+	}
+
+	return s.startSession(ctx, user)
+}
+
+// UnlockAccount lifts a lockout of the account with the given email before
+// it runs out. It succeeds whether or not the account is locked, or exists.
+func (s *AuthService) UnlockAccount(ctx context.Context, adminID int64, email string) error {
+	return s.throttle.Unlock(ctx, email, adminID)
+}
+
+// IsAdmin reports whether the user is an administrator.
+func (s *AuthService) IsAdmin(ctx context.Context, userID uint) (bool, error) {
+	user, err := s.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+	return user != nil && user.IsAdmin, nil
+}
+
+// rehash replaces the user's password hash if it is out of date. The
+// login goes ahead even if this fails; it is tried again next time.
+func (s *AuthService) rehash(ctx context.Context, user *model.User, password string) {
+	if !s.policy.PasswordHasher.NeedsRehash(user.Password) {
+		return
+	}
+
+	hash, err := s.policy.PasswordHasher.Hash(password)
+	if err != nil {
+		return
+	}
+	if err := s.userRepo.RehashPassword(ctx, user.ID, user.Password, hash); err == nil {
+		user.Password = hash
+	}
+}
+
+// startSession issues tokens to a user who has just logged in, starting a
+// new refresh token family.
+func (s *AuthService) startSession(ctx context.Context, user *model.User) (*model.TokenPair, error) {
+	familyID, err := randomToken(16, hex.EncodeToString)
+	if err != nil {
+		return nil, ErrTokenGeneration
+	}
+
+	refreshToken, stored, err := s.newRefreshToken(user.ID, familyID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.refreshTokenRepo.Create(ctx, stored); err != nil {
+		return nil, err
+	}
+
+	return s.tokenPair(ctx, user, refreshToken)
+}
+
+// Refresh exchanges a refresh token for a new access token and a new
+// refresh token. Each refresh token works once: presenting one that has
+// already been used revokes every token descended from the same login, on
+// the assumption that it has been stolen.
+func (s *AuthService) Refresh(ctx context.Context, refreshToken string) (*model.TokenPair, error) {
+	stored, err := s.refreshTokenRepo.GetByHash(ctx, hashToken(refreshToken))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrInvalidRefreshToken
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if stored.UsedAt != nil {
+		return nil, s.revokeFamily(ctx, stored.FamilyID)
+	}
+	if stored.RevokedAt != nil || !time.Now().Before(stored.ExpiresAt) {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	// The user is loaded again so a newly verified email takes effect.
+	user, err := s.userRepo.GetUserByID(ctx, stored.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrInvalidRefreshToken
+	}
+	if s.blocked(user) {
+		return nil, ErrEmailNotVerified
+	}
+
+	nextToken, next, err := s.newRefreshToken(stored.UserID, stored.FamilyID)
+	if err != nil {
+		return nil, err
 	}
 
-	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)); err != nil {
-		return "", ErrInvalidCredentials
+	// Rotate finds nothing to rotate when a concurrent refresh used the
+	// token first, which is reuse as well.
+	err = s.refreshTokenRepo.Rotate(ctx, int64(stored.ID), next)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, s.revokeFamily(ctx, stored.FamilyID)
 	}
+	if err != nil {
+		return nil, err
+	}
+
+	return s.tokenPair(ctx, user, nextToken)
+}
 
-	token, err := CreateToken(user.ID, s.jwtSecret)
+// Logout revokes the access token the user is signed in with and, when one
+// is given, every refresh token from the same login as refreshToken.
+func (s *AuthService) Logout(ctx context.Context, userID int64, tokenID string, expiresAt time.Time, refreshToken string) error {
+	if tokenID != "" {
+		if err := s.revocations.Revoke(ctx, tokenID, uint(userID), expiresAt); err != nil {
+			return err
+		}
+	}
+
+	if refreshToken == "" {
+		return nil
+	}
+
+	stored, err := s.refreshTokenRepo.GetByHash(ctx, hashToken(refreshToken))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
 	if err != nil {
-		return "", ErrTokenGeneration
+		return err
 	}
 
-	return token, nil
+	// Someone else's refresh token is ignored rather than revoked.
+	if int64(stored.UserID) != userID {
+		return nil
+	}
+	return s.refreshTokenRepo.RevokeFamily(ctx, stored.FamilyID)
+}
+
+// LogoutAll signs the user out everywhere: every access token issued so
+// far is rejected and every refresh token is revoked.
+func (s *AuthService) LogoutAll(ctx context.Context, userID int64) error {
+	if _, err := s.revocations.BumpTokenVersion(ctx, uint(userID)); err != nil {
+		return err
+	}
+	return s.refreshTokenRepo.RevokeForUser(ctx, uint(userID))
+}
+
+func (s *AuthService) revokeFamily(ctx context.Context, familyID string) error {
+	if err := s.refreshTokenRepo.RevokeFamily(ctx, familyID); err != nil {
+		return err
+	}
+	return ErrRefreshTokenReused
+}
+
+// newRefreshToken returns a new opaque refresh token together with the
+// hashed form to store.
+func (s *AuthService) newRefreshToken(userID uint, familyID string) (string, *model.RefreshToken, error) {
+	token, err := randomToken(32, base64.RawURLEncoding.EncodeToString)
+	if err != nil {
+		return "", nil, ErrTokenGeneration
+	}
+
+	return token, &model.RefreshToken{
+		UserID:    userID,
+		FamilyID:  familyID,
+		TokenHash: hashToken(token),
+		ExpiresAt: time.Now().Add(s.policy.RefreshTokenTTL).UTC(),
+	}, nil
+}
+
+func (s *AuthService) tokenPair(ctx context.Context, user *model.User, refreshToken string) (*model.TokenPair, error) {
+	// Read the version from the database, not the cache, so a new token is
+	// never issued with a version that another instance has already bumped.
+	version, err := s.revocations.loadTokenVersion(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	claims := AccessClaims{
+		UserID:       user.ID,
+		TokenVersion: version,
+		ReadOnly:     user.EmailVerifiedAt == nil && s.policy.EmailVerification == VerificationReadOnly,
+	}
+	expiresAt := time.Now().Add(s.policy.AccessTokenTTL).UTC().Truncate(time.Second)
+	accessToken, err := CreateToken(claims, s.keys, expiresAt)
+	if err != nil {
+		return nil, ErrTokenGeneration
+	}
+
+	return &model.TokenPair{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		ExpiresAt:    expiresAt,
+		ReadOnly:     claims.ReadOnly,
+	}, nil
+}
+
+// blocked reports whether the verification policy keeps the user out.
+func (s *AuthService) blocked(user *model.User) bool {
+	return user.EmailVerifiedAt == nil && s.policy.EmailVerification == VerificationBlock
+}
+
+// AccessClaims are the application claims of an access token.
+type AccessClaims struct {
+	UserID       uint
+	TokenVersion int
+	// ReadOnly tokens are refused for anything but reads.
+	ReadOnly bool
+}
+
+// CreateToken signs an access token. Every token gets a unique jti so it
+// can be revoked on its own, and carries the user's token version so all
+// of them can be revoked at once.
+func CreateToken(claims AccessClaims, keys *signing.KeySet, expiresAt time.Time) (string, error) {
+	jti, err := randomToken(16, hex.EncodeToString)
+	if err != nil {
+		return "", err
+	}
+
+	mapClaims := jwt.MapClaims{
+		"user_id": claims.UserID,
+		"ver":     claims.TokenVersion,
+		"jti":     jti,
+		"exp":     jwt.NewNumericDate(expiresAt),
+	}
+	if claims.ReadOnly {
+		mapClaims["ro"] = true
+	}
+
+	return keys.Sign(mapClaims)
+}
+
+func randomToken(size int, encode func([]byte) string) (string, error) {
+	buf := make([]byte, size)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return encode(buf), nil
 }
 
-func CreateToken(userID uint, secret string) (string, error) {
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"user_id": userID,
-		"exp":     jwt.NewNumericDate(time.Now().Add(24 * time.Hour)),
-	})
-	return token.SignedString([]byte(secret))
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
 }
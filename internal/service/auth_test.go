@@ -0,0 +1,373 @@
+package service_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/ahmednurovic/task-manager-api/internal/model"
+	"github.com/ahmednurovic/task-manager-api/internal/password"
+	"github.com/ahmednurovic/task-manager-api/internal/repository"
+	"github.com/ahmednurovic/task-manager-api/internal/service"
+	"github.com/ahmednurovic/task-manager-api/internal/signing"
+)
+
+type MockUserRepository struct {
+	mock.Mock
+	// Tx holds the repositories InTx passes to fn besides the mock itself.
+	Tx repository.UserTx
+}
+
+func (m *MockUserRepository) CreateUser(ctx context.Context, user *model.User) error {
+	args := m.Called(ctx, user)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) GetUserByEmail(ctx context.Context, email string) (*model.User, error) {
+	args := m.Called(ctx, email)
+	user, _ := args.Get(0).(*model.User)
+	return user, args.Error(1)
+}
+
+func (m *MockUserRepository) GetUserByID(ctx context.Context, userID uint) (*model.User, error) {
+	args := m.Called(ctx, userID)
+	user, _ := args.Get(0).(*model.User)
+	return user, args.Error(1)
+}
+
+func (m *MockUserRepository) UpdatePassword(ctx context.Context, userID uint, passwordHash string) error {
+	args := m.Called(ctx, userID, passwordHash)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) RehashPassword(ctx context.Context, userID uint, oldHash, newHash string) error {
+	args := m.Called(ctx, userID, oldHash, newHash)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) CreatePasswordReset(ctx context.Context, userID uint, tokenHash string, expiresAt time.Time) error {
+	args := m.Called(ctx, userID, tokenHash, expiresAt)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) ConsumePasswordReset(ctx context.Context, tokenHash string) (uint, error) {
+	args := m.Called(ctx, tokenHash)
+	return args.Get(0).(uint), args.Error(1)
+}
+
+func (m *MockUserRepository) CreateEmailVerification(ctx context.Context, userID uint, tokenHash string, expiresAt time.Time) error {
+	args := m.Called(ctx, userID, tokenHash, expiresAt)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) ConsumeEmailVerification(ctx context.Context, tokenHash string) (uint, error) {
+	args := m.Called(ctx, tokenHash)
+	return args.Get(0).(uint), args.Error(1)
+}
+
+func (m *MockUserRepository) SetMFASecret(ctx context.Context, userID uint, secret string) error {
+	args := m.Called(ctx, userID, secret)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) EnableMFA(ctx context.Context, userID uint, counter uint64, recoveryCodeHashes []string) error {
+	args := m.Called(ctx, userID, counter, recoveryCodeHashes)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) UseMFACounter(ctx context.Context, userID uint, counter uint64) error {
+	args := m.Called(ctx, userID, counter)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) UseRecoveryCode(ctx context.Context, userID uint, codeHash string) error {
+	args := m.Called(ctx, userID, codeHash)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) DisableMFA(ctx context.Context, userID uint) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
+// InTx runs fn against the mock itself and Tx; tests assert on the calls
+// made inside.
+func (m *MockUserRepository) InTx(ctx context.Context, fn func(repository.UserTx) error) error {
+	tx := m.Tx
+	tx.Users = m
+	return fn(tx)
+}
+
+// verifiedUserRepo finds user 1, with a verified email, by ID.
+func verifiedUserRepo() *MockUserRepository {
+	verifiedAt := time.Now().Add(-time.Hour)
+	mockUserRepo := new(MockUserRepository)
+	mockUserRepo.On("GetUserByID", mock.Anything, uint(1)).
+		Return(&model.User{ID: 1, Email: "user@example.com", EmailVerifiedAt: &verifiedAt}, nil).Maybe()
+	return mockUserRepo
+}
+
+type MockRefreshTokenRepository struct {
+	mock.Mock
+}
+
+func (m *MockRefreshTokenRepository) Create(ctx context.Context, token *model.RefreshToken) error {
+	args := m.Called(ctx, token)
+	return args.Error(0)
+}
+
+func (m *MockRefreshTokenRepository) GetByHash(ctx context.Context, tokenHash string) (*model.RefreshToken, error) {
+	args := m.Called(ctx, tokenHash)
+	token, _ := args.Get(0).(*model.RefreshToken)
+	return token, args.Error(1)
+}
+
+func (m *MockRefreshTokenRepository) Rotate(ctx context.Context, usedID int64, next *model.RefreshToken) error {
+	args := m.Called(ctx, usedID, next)
+	return args.Error(0)
+}
+
+func (m *MockRefreshTokenRepository) RevokeFamily(ctx context.Context, familyID string) error {
+	args := m.Called(ctx, familyID)
+	return args.Error(0)
+}
+
+func (m *MockRefreshTokenRepository) RevokeForUser(ctx context.Context, userID uint) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
+// testKeys signs tokens with HS256 and the secret "test-secret".
+var testKeys = signing.NewHMAC("test-secret")
+
+// testPasswords is a cheap hasher, under which the bcrypt hashes the tests
+// use as fixtures are up to date.
+var testPasswords = password.Bcrypt{Cost: bcrypt.MinCost}
+
+// unrevokedStore is a revocation store where every user is on token
+// version 0 and nothing has been revoked.
+func unrevokedStore() *service.TokenRevocationStore {
+	mockRevocationRepo := new(MockRevocationRepository)
+	mockRevocationRepo.On("GetTokenVersion", mock.Anything, mock.Anything).Return(0, nil).Maybe()
+	mockRevocationRepo.On("IsRevoked", mock.Anything, mock.Anything).Return(false, nil).Maybe()
+	return service.NewTokenRevocationStore(mockRevocationRepo, time.Minute)
+}
+
+func sha256Hex(value string) string {
+	sum := sha256.Sum256([]byte(value))
+	return hex.EncodeToString(sum[:])
+}
+
+func TestAuthServiceRefresh(t *testing.T) {
+	const secret = "test-secret"
+	policy := service.AuthPolicy{AccessTokenTTL: 5 * time.Minute, RefreshTokenTTL: time.Hour}
+	storedToken := func() *model.RefreshToken {
+		return &model.RefreshToken{
+			ID: 7, UserID: 1, FamilyID: "family", TokenHash: sha256Hex("old-token"),
+			ExpiresAt: time.Now().Add(time.Hour),
+		}
+	}
+
+	t.Run("Rotates Token", func(t *testing.T) {
+		mockRepo := new(MockRefreshTokenRepository)
+		mockRepo.On("GetByHash", mock.Anything, sha256Hex("old-token")).Return(storedToken(), nil)
+		var rotated *model.RefreshToken
+		mockRepo.On("Rotate", mock.Anything, int64(7), mock.MatchedBy(func(next *model.RefreshToken) bool {
+			rotated = next
+			return next.UserID == 1 && next.FamilyID == "family"
+		})).Return(nil)
+
+		authService := service.NewAuthService(verifiedUserRepo(), mockRepo, unrevokedStore(), nil, nil, signing.NewHMAC(secret), policy)
+		tokens, err := authService.Refresh(context.Background(), "old-token")
+
+		assert.NoError(t, err)
+		mockRepo.AssertExpectations(t)
+		assert.NotEqual(t, "old-token", tokens.RefreshToken)
+		assert.Equal(t, sha256Hex(tokens.RefreshToken), rotated.TokenHash)
+		assert.WithinDuration(t, time.Now().Add(time.Hour), rotated.ExpiresAt, time.Minute)
+		assert.WithinDuration(t, time.Now().Add(5*time.Minute), tokens.ExpiresAt, time.Minute)
+
+		claims := jwt.MapClaims{}
+		_, err = jwt.ParseWithClaims(tokens.AccessToken, claims, func(*jwt.Token) (interface{}, error) {
+			return []byte(secret), nil
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, float64(1), claims["user_id"])
+		assert.Equal(t, float64(0), claims["ver"])
+		assert.NotEmpty(t, claims["jti"])
+	})
+
+	t.Run("Unknown Token", func(t *testing.T) {
+		mockRepo := new(MockRefreshTokenRepository)
+		mockRepo.On("GetByHash", mock.Anything, mock.Anything).Return(nil, sql.ErrNoRows)
+
+		authService := service.NewAuthService(verifiedUserRepo(), mockRepo, unrevokedStore(), nil, nil, signing.NewHMAC(secret), policy)
+		_, err := authService.Refresh(context.Background(), "made-up")
+
+		assert.ErrorIs(t, err, service.ErrInvalidRefreshToken)
+	})
+
+	t.Run("Expired Token", func(t *testing.T) {
+		expired := storedToken()
+		expired.ExpiresAt = time.Now().Add(-time.Second)
+		mockRepo := new(MockRefreshTokenRepository)
+		mockRepo.On("GetByHash", mock.Anything, mock.Anything).Return(expired, nil)
+
+		authService := service.NewAuthService(verifiedUserRepo(), mockRepo, unrevokedStore(), nil, nil, signing.NewHMAC(secret), policy)
+		_, err := authService.Refresh(context.Background(), "old-token")
+
+		assert.ErrorIs(t, err, service.ErrInvalidRefreshToken)
+		mockRepo.AssertNotCalled(t, "Rotate", mock.Anything, mock.Anything, mock.Anything)
+		mockRepo.AssertNotCalled(t, "RevokeFamily", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Revoked Token", func(t *testing.T) {
+		revokedAt := time.Now().Add(-time.Minute)
+		revoked := storedToken()
+		revoked.RevokedAt = &revokedAt
+		mockRepo := new(MockRefreshTokenRepository)
+		mockRepo.On("GetByHash", mock.Anything, mock.Anything).Return(revoked, nil)
+
+		authService := service.NewAuthService(verifiedUserRepo(), mockRepo, unrevokedStore(), nil, nil, signing.NewHMAC(secret), policy)
+		_, err := authService.Refresh(context.Background(), "old-token")
+
+		assert.ErrorIs(t, err, service.ErrInvalidRefreshToken)
+		mockRepo.AssertNotCalled(t, "Rotate", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Reused Token Revokes Family", func(t *testing.T) {
+		usedAt := time.Now().Add(-time.Minute)
+		used := storedToken()
+		used.UsedAt = &usedAt
+		mockRepo := new(MockRefreshTokenRepository)
+		mockRepo.On("GetByHash", mock.Anything, mock.Anything).Return(used, nil)
+		mockRepo.On("RevokeFamily", mock.Anything, "family").Return(nil)
+
+		authService := service.NewAuthService(verifiedUserRepo(), mockRepo, unrevokedStore(), nil, nil, signing.NewHMAC(secret), policy)
+		tokens, err := authService.Refresh(context.Background(), "old-token")
+
+		assert.ErrorIs(t, err, service.ErrRefreshTokenReused)
+		assert.Nil(t, tokens)
+		mockRepo.AssertExpectations(t)
+		mockRepo.AssertNotCalled(t, "Rotate", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Concurrent Reuse Revokes Family", func(t *testing.T) {
+		mockRepo := new(MockRefreshTokenRepository)
+		mockRepo.On("GetByHash", mock.Anything, mock.Anything).Return(storedToken(), nil)
+		mockRepo.On("Rotate", mock.Anything, int64(7), mock.Anything).Return(sql.ErrNoRows)
+		mockRepo.On("RevokeFamily", mock.Anything, "family").Return(nil)
+
+		authService := service.NewAuthService(verifiedUserRepo(), mockRepo, unrevokedStore(), nil, nil, signing.NewHMAC(secret), policy)
+		_, err := authService.Refresh(context.Background(), "old-token")
+
+		assert.ErrorIs(t, err, service.ErrRefreshTokenReused)
+		mockRepo.AssertExpectations(t)
+	})
+}
+
+func TestCreateToken(t *testing.T) {
+	expiresAt := time.Now().Add(time.Hour)
+	first, err := service.CreateToken(service.AccessClaims{UserID: 1, TokenVersion: 3}, testKeys, expiresAt)
+	assert.NoError(t, err)
+	second, err := service.CreateToken(service.AccessClaims{UserID: 1, TokenVersion: 3}, testKeys, expiresAt)
+	assert.NoError(t, err)
+
+	parse := func(token string) jwt.MapClaims {
+		claims := jwt.MapClaims{}
+		_, err := jwt.ParseWithClaims(token, claims, func(*jwt.Token) (interface{}, error) {
+			return []byte("test-secret"), nil
+		})
+		assert.NoError(t, err)
+		return claims
+	}
+
+	firstClaims, secondClaims := parse(first), parse(second)
+	assert.Equal(t, float64(3), firstClaims["ver"])
+	assert.NotEmpty(t, firstClaims["jti"])
+	assert.NotEqual(t, firstClaims["jti"], secondClaims["jti"])
+	assert.NotContains(t, firstClaims, "ro")
+
+	readOnly, err := service.CreateToken(service.AccessClaims{UserID: 1, ReadOnly: true}, testKeys, expiresAt)
+	assert.NoError(t, err)
+	assert.Equal(t, true, parse(readOnly)["ro"])
+}
+
+func TestAuthServiceLogout(t *testing.T) {
+	expiresAt := time.Now().Add(10 * time.Minute)
+
+	t.Run("Revokes Access Token", func(t *testing.T) {
+		mockRevocationRepo := new(MockRevocationRepository)
+		mockRevocationRepo.On("Revoke", mock.Anything, "token-id", uint(1), expiresAt).Return(nil)
+		store := service.NewTokenRevocationStore(mockRevocationRepo, time.Minute)
+
+		authService := service.NewAuthService(nil, new(MockRefreshTokenRepository), store, nil, nil, testKeys, service.AuthPolicy{})
+		err := authService.Logout(context.Background(), 1, "token-id", expiresAt, "")
+
+		assert.NoError(t, err)
+		mockRevocationRepo.AssertExpectations(t)
+
+		revoked, err := store.IsRevoked(context.Background(), "token-id")
+		assert.NoError(t, err)
+		assert.True(t, revoked)
+		mockRevocationRepo.AssertNotCalled(t, "IsRevoked", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Revokes Refresh Token Family", func(t *testing.T) {
+		mockRevocationRepo := new(MockRevocationRepository)
+		mockRevocationRepo.On("Revoke", mock.Anything, "token-id", uint(1), expiresAt).Return(nil)
+		mockRepo := new(MockRefreshTokenRepository)
+		mockRepo.On("GetByHash", mock.Anything, sha256Hex("refresh-token")).
+			Return(&model.RefreshToken{ID: 7, UserID: 1, FamilyID: "family"}, nil)
+		mockRepo.On("RevokeFamily", mock.Anything, "family").Return(nil)
+
+		store := service.NewTokenRevocationStore(mockRevocationRepo, time.Minute)
+		authService := service.NewAuthService(nil, mockRepo, store, nil, nil, testKeys, service.AuthPolicy{})
+		err := authService.Logout(context.Background(), 1, "token-id", expiresAt, "refresh-token")
+
+		assert.NoError(t, err)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Other User's Refresh Token", func(t *testing.T) {
+		mockRevocationRepo := new(MockRevocationRepository)
+		mockRevocationRepo.On("Revoke", mock.Anything, "token-id", uint(2), expiresAt).Return(nil)
+		mockRepo := new(MockRefreshTokenRepository)
+		mockRepo.On("GetByHash", mock.Anything, mock.Anything).
+			Return(&model.RefreshToken{ID: 7, UserID: 1, FamilyID: "family"}, nil)
+
+		store := service.NewTokenRevocationStore(mockRevocationRepo, time.Minute)
+		authService := service.NewAuthService(nil, mockRepo, store, nil, nil, testKeys, service.AuthPolicy{})
+		err := authService.Logout(context.Background(), 2, "token-id", expiresAt, "refresh-token")
+
+		assert.NoError(t, err)
+		mockRepo.AssertNotCalled(t, "RevokeFamily", mock.Anything, mock.Anything)
+	})
+}
+
+func TestAuthServiceLogoutAll(t *testing.T) {
+	mockRevocationRepo := new(MockRevocationRepository)
+	mockRevocationRepo.On("IncrementTokenVersion", mock.Anything, uint(1)).Return(4, nil)
+	mockRepo := new(MockRefreshTokenRepository)
+	mockRepo.On("RevokeForUser", mock.Anything, uint(1)).Return(nil)
+
+	store := service.NewTokenRevocationStore(mockRevocationRepo, time.Minute)
+	authService := service.NewAuthService(nil, mockRepo, store, nil, nil, testKeys, service.AuthPolicy{})
+	err := authService.LogoutAll(context.Background(), 1)
+
+	assert.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+
+	version, err := store.TokenVersion(context.Background(), 1)
+	assert.NoError(t, err)
+	assert.Equal(t, 4, version)
+	mockRevocationRepo.AssertNotCalled(t, "GetTokenVersion", mock.Anything, mock.Anything)
+}
@@ -0,0 +1,137 @@
+package service
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+
+	"github.com/ahmednurovic/task-manager-api/internal/authz"
+	"github.com/ahmednurovic/task-manager-api/internal/model"
+	"github.com/ahmednurovic/task-manager-api/internal/repository"
+	"github.com/gin-gonic/gin"
+)
+
+const maxChecklistTitleLength = 255
+
+type ChecklistService struct {
+	checklistRepo repository.ChecklistRepository
+	taskRepo      repository.TaskRepository
+	authorizer    *authz.Authorizer
+}
+
+func NewChecklistService(checklistRepo repository.ChecklistRepository, taskRepo repository.TaskRepository, authorizer *authz.Authorizer) *ChecklistService {
+	return &ChecklistService{checklistRepo: checklistRepo, taskRepo: taskRepo, authorizer: authorizer}
+}
+
+func (s *ChecklistService) GetChecklist(ctx *gin.Context, taskID int64, userID int64) ([]*model.ChecklistItem, error) {
+	if _, err := getTask(ctx, s.taskRepo, s.authorizer, taskID, userID, authz.ReadTasks); err != nil {
+		return nil, err
+	}
+
+	return s.checklistRepo.ListForTask(ctx, taskID)
+}
+
+// CreateChecklistItem adds an item to a task the user may change. A nil
+// position appends the item after the task's existing items.
+func (s *ChecklistService) CreateChecklistItem(ctx *gin.Context, item *model.ChecklistItem, position *int, userID int64) error {
+	if err := normalizeChecklistItem(item); err != nil {
+		return err
+	}
+
+	item.Position = -1
+	if position != nil {
+		if *position < 0 {
+			return ErrInvalidPosition
+		}
+		item.Position = *position
+	}
+
+	if _, err := getTask(ctx, s.taskRepo, s.authorizer, int64(item.TaskID), userID, authz.WriteTasks); err != nil {
+		return err
+	}
+
+	item.ID = 0
+	return s.checklistRepo.Create(ctx, item)
+}
+
+// UpdateChecklistItem replaces the title and done flag of an item. A nil
+// position keeps the current one.
+func (s *ChecklistService) UpdateChecklistItem(ctx *gin.Context, item *model.ChecklistItem, position *int, userID int64) error {
+	if err := normalizeChecklistItem(item); err != nil {
+		return err
+	}
+
+	existingItem, err := s.getOwnedItem(ctx, int64(item.TaskID), int64(item.ID), userID)
+	if err != nil {
+		return err
+	}
+
+	if position != nil {
+		if *position < 0 {
+			return ErrInvalidPosition
+		}
+		existingItem.Position = *position
+	}
+
+	existingItem.Title = item.Title
+	existingItem.Done = item.Done
+
+	if err := s.checklistRepo.Update(ctx, existingItem); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrChecklistItemNotFound
+		}
+		return err
+	}
+
+	*item = *existingItem
+	return nil
+}
+
+func (s *ChecklistService) DeleteChecklistItem(ctx *gin.Context, taskID int64, itemID int64, userID int64) error {
+	if _, err := getTask(ctx, s.taskRepo, s.authorizer, taskID, userID, authz.WriteTasks); err != nil {
+		return err
+	}
+
+	if err := s.checklistRepo.Delete(ctx, itemID, taskID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrChecklistItemNotFound
+		}
+		return err
+	}
+
+	return nil
+}
+
+// getOwnedItem loads a checklist item of a task the user may change. Items
+// of other tasks are reported as not found.
+func (s *ChecklistService) getOwnedItem(ctx *gin.Context, taskID int64, itemID int64, userID int64) (*model.ChecklistItem, error) {
+	if _, err := getTask(ctx, s.taskRepo, s.authorizer, taskID, userID, authz.WriteTasks); err != nil {
+		return nil, err
+	}
+
+	item, err := s.checklistRepo.GetByID(ctx, itemID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrChecklistItemNotFound
+		}
+		return nil, err
+	}
+
+	if item.TaskID != uint(taskID) {
+		return nil, ErrChecklistItemNotFound
+	}
+
+	return item, nil
+}
+
+func normalizeChecklistItem(item *model.ChecklistItem) error {
+	item.Title = strings.TrimSpace(item.Title)
+	if item.Title == "" {
+		return ErrChecklistTitleRequired
+	}
+	if len([]rune(item.Title)) > maxChecklistTitleLength {
+		return ErrChecklistTitleTooLong
+	}
+
+	return nil
+}
@@ -0,0 +1,135 @@
+package service_test
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/ahmednurovic/task-manager-api/internal/model"
+	"github.com/ahmednurovic/task-manager-api/internal/service"
+)
+
+type MockChecklistRepository struct {
+	mock.Mock
+}
+
+func (m *MockChecklistRepository) Create(ctx context.Context, item *model.ChecklistItem) error {
+	args := m.Called(ctx, item)
+	return args.Error(0)
+}
+
+func (m *MockChecklistRepository) ListForTask(ctx context.Context, taskID int64) ([]*model.ChecklistItem, error) {
+	args := m.Called(ctx, taskID)
+	items, _ := args.Get(0).([]*model.ChecklistItem)
+	return items, args.Error(1)
+}
+
+func (m *MockChecklistRepository) GetByID(ctx context.Context, itemID int64) (*model.ChecklistItem, error) {
+	args := m.Called(ctx, itemID)
+	item, _ := args.Get(0).(*model.ChecklistItem)
+	return item, args.Error(1)
+}
+
+func (m *MockChecklistRepository) Update(ctx context.Context, item *model.ChecklistItem) error {
+	args := m.Called(ctx, item)
+	return args.Error(0)
+}
+
+func (m *MockChecklistRepository) Delete(ctx context.Context, itemID int64, taskID int64) error {
+	args := m.Called(ctx, itemID, taskID)
+	return args.Error(0)
+}
+
+func TestChecklistServiceCreateChecklistItem(t *testing.T) {
+	t.Run("Appends By Default", func(t *testing.T) {
+		mockTaskRepo := new(MockTaskRepository)
+		mockTaskRepo.On("GetByID", mock.Anything, int64(10)).Return(&model.Task{ID: 10, UserID: 1}, nil)
+		mockChecklistRepo := new(MockChecklistRepository)
+		mockChecklistRepo.On("Create", mock.Anything, mock.MatchedBy(func(item *model.ChecklistItem) bool {
+			return item.TaskID == 10 && item.Title == "Proofread" && item.Position == -1
+		})).Return(nil)
+
+		checklistService := service.NewChecklistService(mockChecklistRepo, mockTaskRepo, testAuthorizer)
+		err := checklistService.CreateChecklistItem(newTestContext(), &model.ChecklistItem{TaskID: 10, Title: " Proofread "}, nil, 1)
+
+		assert.NoError(t, err)
+		mockChecklistRepo.AssertExpectations(t)
+	})
+
+	t.Run("Other User's Task", func(t *testing.T) {
+		mockTaskRepo := new(MockTaskRepository)
+		mockTaskRepo.On("GetByID", mock.Anything, int64(10)).Return(&model.Task{ID: 10, UserID: 2}, nil)
+		mockChecklistRepo := new(MockChecklistRepository)
+
+		checklistService := service.NewChecklistService(mockChecklistRepo, mockTaskRepo, testAuthorizer)
+		err := checklistService.CreateChecklistItem(newTestContext(), &model.ChecklistItem{TaskID: 10, Title: "Proofread"}, nil, 1)
+
+		assert.ErrorIs(t, err, service.ErrTaskNotFound)
+		mockChecklistRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Invalid Input", func(t *testing.T) {
+		position := -1
+		checklistService := service.NewChecklistService(new(MockChecklistRepository), new(MockTaskRepository), testAuthorizer)
+
+		err := checklistService.CreateChecklistItem(newTestContext(), &model.ChecklistItem{TaskID: 10, Title: " "}, nil, 1)
+		assert.ErrorIs(t, err, service.ErrChecklistTitleRequired)
+
+		err = checklistService.CreateChecklistItem(newTestContext(), &model.ChecklistItem{TaskID: 10, Title: strings.Repeat("a", 256)}, nil, 1)
+		assert.ErrorIs(t, err, service.ErrChecklistTitleTooLong)
+
+		err = checklistService.CreateChecklistItem(newTestContext(), &model.ChecklistItem{TaskID: 10, Title: "Proofread"}, &position, 1)
+		assert.ErrorIs(t, err, service.ErrInvalidPosition)
+	})
+}
+
+func TestChecklistServiceUpdateChecklistItem(t *testing.T) {
+	t.Run("Tick Item", func(t *testing.T) {
+		mockTaskRepo := new(MockTaskRepository)
+		mockTaskRepo.On("GetByID", mock.Anything, int64(10)).Return(&model.Task{ID: 10, UserID: 1}, nil)
+		mockChecklistRepo := new(MockChecklistRepository)
+		mockChecklistRepo.On("GetByID", mock.Anything, int64(3)).
+			Return(&model.ChecklistItem{ID: 3, TaskID: 10, Title: "Proofread", Position: 2}, nil)
+		mockChecklistRepo.On("Update", mock.Anything, mock.MatchedBy(func(item *model.ChecklistItem) bool {
+			return item.ID == 3 && item.Done && item.Position == 2
+		})).Return(nil)
+
+		checklistService := service.NewChecklistService(mockChecklistRepo, mockTaskRepo, testAuthorizer)
+		item := &model.ChecklistItem{ID: 3, TaskID: 10, Title: "Proofread", Done: true}
+		err := checklistService.UpdateChecklistItem(newTestContext(), item, nil, 1)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 2, item.Position)
+		mockChecklistRepo.AssertExpectations(t)
+	})
+
+	t.Run("Item Of Another Task", func(t *testing.T) {
+		mockTaskRepo := new(MockTaskRepository)
+		mockTaskRepo.On("GetByID", mock.Anything, int64(10)).Return(&model.Task{ID: 10, UserID: 1}, nil)
+		mockChecklistRepo := new(MockChecklistRepository)
+		mockChecklistRepo.On("GetByID", mock.Anything, int64(3)).
+			Return(&model.ChecklistItem{ID: 3, TaskID: 11, Title: "Proofread"}, nil)
+
+		checklistService := service.NewChecklistService(mockChecklistRepo, mockTaskRepo, testAuthorizer)
+		err := checklistService.UpdateChecklistItem(newTestContext(), &model.ChecklistItem{ID: 3, TaskID: 10, Title: "Proofread"}, nil, 1)
+
+		assert.ErrorIs(t, err, service.ErrChecklistItemNotFound)
+		mockChecklistRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+	})
+}
+
+func TestChecklistServiceDeleteChecklistItem(t *testing.T) {
+	mockTaskRepo := new(MockTaskRepository)
+	mockTaskRepo.On("GetByID", mock.Anything, int64(10)).Return(&model.Task{ID: 10, UserID: 1}, nil)
+	mockChecklistRepo := new(MockChecklistRepository)
+	mockChecklistRepo.On("Delete", mock.Anything, int64(3), int64(10)).Return(sql.ErrNoRows)
+
+	checklistService := service.NewChecklistService(mockChecklistRepo, mockTaskRepo, testAuthorizer)
+	err := checklistService.DeleteChecklistItem(newTestContext(), 10, 3, 1)
+
+	assert.ErrorIs(t, err, service.ErrChecklistItemNotFound)
+}
@@ -0,0 +1,20 @@
+package service
+
+import (
+	"regexp"
+	"strings"
+)
+
+const defaultColor = "#9e9e9e"
+
+var colorPattern = regexp.MustCompile(`^#[0-9a-f]{6}$`)
+
+// normalizeColor lower-cases a #rrggbb colour and substitutes the default
+// for an empty one. It reports false if the colour is malformed.
+func normalizeColor(color string) (string, bool) {
+	color = strings.ToLower(strings.TrimSpace(color))
+	if color == "" {
+		return defaultColor, true
+	}
+	return color, colorPattern.MatchString(color)
+}
@@ -0,0 +1,263 @@
+package service
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/ahmednurovic/task-manager-api/internal/authz"
+	"github.com/ahmednurovic/task-manager-api/internal/mention"
+	"github.com/ahmednurovic/task-manager-api/internal/model"
+	"github.com/ahmednurovic/task-manager-api/internal/repository"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	maxCommentBodyLength   = 10000
+	defaultCommentPageSize = 50
+	maxCommentPageSize     = 100
+)
+
+type CommentService struct {
+	commentRepo   repository.CommentRepository
+	taskRepo      repository.TaskRepository
+	workspaceRepo repository.WorkspaceRepository
+	userRepo      repository.UserRepository
+	authorizer    *authz.Authorizer
+}
+
+func NewCommentService(
+	commentRepo repository.CommentRepository,
+	taskRepo repository.TaskRepository,
+	workspaceRepo repository.WorkspaceRepository,
+	userRepo repository.UserRepository,
+	authorizer *authz.Authorizer,
+) *CommentService {
+	return &CommentService{
+		commentRepo:   commentRepo,
+		taskRepo:      taskRepo,
+		workspaceRepo: workspaceRepo,
+		userRepo:      userRepo,
+		authorizer:    authorizer,
+	}
+}
+
+// GetComments returns one page of a task's comments, oldest first. A zero
+// limit uses the default page size.
+func (s *CommentService) GetComments(ctx *gin.Context, taskID int64, cursor string, limit int, userID int64) (*model.CommentPage, error) {
+	if limit == 0 {
+		limit = defaultCommentPageSize
+	}
+	if limit < 1 || limit > maxCommentPageSize {
+		return nil, fmt.Errorf("%w: limit must be between 1 and %d", ErrInvalidFilter, maxCommentPageSize)
+	}
+
+	if _, err := getTask(ctx, s.taskRepo, s.authorizer, taskID, userID, authz.ReadTasks); err != nil {
+		return nil, err
+	}
+
+	page, err := s.commentRepo.List(ctx, taskID, cursor, limit)
+	if err != nil {
+		if errors.Is(err, repository.ErrInvalidCursor) {
+			return nil, fmt.Errorf("%w: invalid cursor", ErrInvalidFilter)
+		}
+		return nil, err
+	}
+	return page, nil
+}
+
+// CreateComment adds a comment by the user to a task they may change. The
+// users it @mentions are resolved among those who can see the task.
+func (s *CommentService) CreateComment(ctx *gin.Context, comment *model.Comment, userID int64) error {
+	if err := normalizeComment(comment); err != nil {
+		return err
+	}
+
+	task, err := getTask(ctx, s.taskRepo, s.authorizer, int64(comment.TaskID), userID, authz.WriteTasks)
+	if err != nil {
+		return err
+	}
+
+	if comment.Mentions, err = s.resolveMentions(ctx, task, comment.Body); err != nil {
+		return err
+	}
+
+	authorID := uint(userID)
+	comment.ID = 0
+	comment.AuthorID = &authorID
+	comment.Edited = false
+	comment.EditedAt = nil
+	return s.commentRepo.Create(ctx, comment)
+}
+
+// UpdateComment replaces the body of a comment, keeping the old body in
+// the comment's history. Submitting the current body changes nothing.
+func (s *CommentService) UpdateComment(ctx *gin.Context, comment *model.Comment, userID int64) error {
+	if err := normalizeComment(comment); err != nil {
+		return err
+	}
+
+	task, existingComment, err := s.getEditableComment(ctx, int64(comment.TaskID), int64(comment.ID), userID)
+	if err != nil {
+		return err
+	}
+
+	if existingComment.Body != comment.Body {
+		existingComment.Body = comment.Body
+		if existingComment.Mentions, err = s.resolveMentions(ctx, task, comment.Body); err != nil {
+			return err
+		}
+
+		if err := s.commentRepo.Update(ctx, existingComment, userID); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return ErrCommentNotFound
+			}
+			return err
+		}
+	}
+
+	*comment = *existingComment
+	return nil
+}
+
+// DeleteComment hides a comment from the task. The comment and its history
+// are kept.
+func (s *CommentService) DeleteComment(ctx *gin.Context, taskID int64, commentID int64, userID int64) error {
+	if _, _, err := s.getEditableComment(ctx, taskID, commentID, userID); err != nil {
+		return err
+	}
+
+	if err := s.commentRepo.Delete(ctx, commentID, taskID, userID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrCommentNotFound
+		}
+		return err
+	}
+
+	return nil
+}
+
+// GetCommentHistory returns the bodies a comment had before each of its
+// edits, oldest first.
+func (s *CommentService) GetCommentHistory(ctx *gin.Context, taskID int64, commentID int64, userID int64) ([]*model.CommentRevision, error) {
+	if _, err := getTask(ctx, s.taskRepo, s.authorizer, taskID, userID, authz.ReadTasks); err != nil {
+		return nil, err
+	}
+
+	if _, err := s.getComment(ctx, taskID, commentID); err != nil {
+		return nil, err
+	}
+
+	return s.commentRepo.ListRevisions(ctx, commentID)
+}
+
+// getEditableComment loads a comment the user may edit or delete: their
+// own, on a task they may still change, or anyone's if they moderate the
+// task's comments.
+func (s *CommentService) getEditableComment(ctx *gin.Context, taskID int64, commentID int64, userID int64) (*model.Task, *model.Comment, error) {
+	task, err := getTask(ctx, s.taskRepo, s.authorizer, taskID, userID, authz.ReadTasks)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	comment, err := s.getComment(ctx, taskID, commentID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	resource := authz.Resource{Type: "comment", ID: comment.ID, OwnerID: task.UserID, WorkspaceID: task.WorkspaceID}
+	p := principal(ctx, userID)
+	if comment.AuthorID != nil && *comment.AuthorID == uint(userID) {
+		if !s.authorizer.Can(p, authz.WriteTasks, resource) {
+			return nil, nil, ErrInsufficientRole
+		}
+	} else if !s.authorizer.Can(p, authz.ModerateComments, resource) {
+		return nil, nil, ErrNotCommentAuthor
+	}
+
+	return task, comment, nil
+}
+
+// getComment loads a comment that has not been deleted. Comments on other
+// tasks are reported as not found.
+func (s *CommentService) getComment(ctx *gin.Context, taskID int64, commentID int64) (*model.Comment, error) {
+	comment, err := s.commentRepo.GetByID(ctx, commentID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrCommentNotFound
+		}
+		return nil, err
+	}
+
+	if comment.TaskID != uint(taskID) {
+		return nil, ErrCommentNotFound
+	}
+
+	return comment, nil
+}
+
+// resolveMentions turns the @mentions in body into users who can see the
+// task: the members of its workspace, or the owner of a personal task. A
+// username is the part of a user's email address before the @, and only
+// resolves if exactly one of those users has it. Mentions that do not
+// resolve are left as plain text.
+func (s *CommentService) resolveMentions(ctx *gin.Context, task *model.Task, body string) ([]model.Mention, error) {
+	mentions := []model.Mention{}
+	names := mention.Parse(body)
+	if len(names) == 0 {
+		return mentions, nil
+	}
+
+	var candidates []model.Mention
+	if task.WorkspaceID != nil {
+		members, err := s.workspaceRepo.ListMembers(ctx, int64(*task.WorkspaceID))
+		if err != nil {
+			return nil, err
+		}
+		for _, member := range members {
+			candidates = append(candidates, model.Mention{UserID: member.UserID, Email: member.Email})
+		}
+	} else {
+		owner, err := s.userRepo.GetUserByID(ctx, task.UserID)
+		if err != nil {
+			return nil, err
+		}
+		// A personal task whose owner is gone has nobody to mention.
+		if owner != nil {
+			candidates = append(candidates, model.Mention{UserID: owner.ID, Email: owner.Email})
+		}
+	}
+
+	seen := map[uint]bool{}
+	for _, name := range names {
+		var matches []model.Mention
+		for _, candidate := range candidates {
+			email := strings.ToLower(candidate.Email)
+			if mention.IsEmail(name) && email == name {
+				matches = append(matches, candidate)
+			} else if !mention.IsEmail(name) && strings.SplitN(email, "@", 2)[0] == name {
+				matches = append(matches, candidate)
+			}
+		}
+
+		if len(matches) == 1 && !seen[matches[0].UserID] {
+			seen[matches[0].UserID] = true
+			mentions = append(mentions, matches[0])
+		}
+	}
+
+	return mentions, nil
+}
+
+func normalizeComment(comment *model.Comment) error {
+	comment.Body = strings.TrimSpace(comment.Body)
+	if comment.Body == "" {
+		return ErrCommentBodyRequired
+	}
+	if len([]rune(comment.Body)) > maxCommentBodyLength {
+		return ErrCommentBodyTooLong
+	}
+
+	return nil
+}
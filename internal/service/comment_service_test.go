@@ -0,0 +1,292 @@
+package service_test
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/ahmednurovic/task-manager-api/internal/model"
+	"github.com/ahmednurovic/task-manager-api/internal/repository"
+	"github.com/ahmednurovic/task-manager-api/internal/service"
+)
+
+type MockCommentRepository struct {
+	mock.Mock
+}
+
+func (m *MockCommentRepository) Create(ctx context.Context, comment *model.Comment) error {
+	args := m.Called(ctx, comment)
+	return args.Error(0)
+}
+
+func (m *MockCommentRepository) List(ctx context.Context, taskID int64, cursor string, limit int) (*model.CommentPage, error) {
+	args := m.Called(ctx, taskID, cursor, limit)
+	page, _ := args.Get(0).(*model.CommentPage)
+	return page, args.Error(1)
+}
+
+func (m *MockCommentRepository) GetByID(ctx context.Context, commentID int64) (*model.Comment, error) {
+	args := m.Called(ctx, commentID)
+	comment, _ := args.Get(0).(*model.Comment)
+	return comment, args.Error(1)
+}
+
+func (m *MockCommentRepository) Update(ctx context.Context, comment *model.Comment, editorID int64) error {
+	args := m.Called(ctx, comment, editorID)
+	return args.Error(0)
+}
+
+func (m *MockCommentRepository) Delete(ctx context.Context, commentID int64, taskID int64, deletedBy int64) error {
+	args := m.Called(ctx, commentID, taskID, deletedBy)
+	return args.Error(0)
+}
+
+func (m *MockCommentRepository) ListRevisions(ctx context.Context, commentID int64) ([]*model.CommentRevision, error) {
+	args := m.Called(ctx, commentID)
+	revisions, _ := args.Get(0).([]*model.CommentRevision)
+	return revisions, args.Error(1)
+}
+
+// sharedTask stubs task 10, created by user 1 in workspace 3.
+func sharedTask(mockTaskRepo *MockTaskRepository) {
+	workspaceID := uint(3)
+	mockTaskRepo.On("GetByID", mock.Anything, int64(10)).Return(&model.Task{ID: 10, UserID: 1, WorkspaceID: &workspaceID}, nil)
+}
+
+// commentBy stubs comment 20 on task 10, written by authorID.
+func commentBy(mockCommentRepo *MockCommentRepository, authorID uint) {
+	mockCommentRepo.On("GetByID", mock.Anything, int64(20)).
+		Return(&model.Comment{ID: 20, TaskID: 10, AuthorID: &authorID, Body: "First draft", Mentions: []model.Mention{}}, nil)
+}
+
+func TestCommentServiceCreateComment(t *testing.T) {
+	t.Run("Resolves Mentions", func(t *testing.T) {
+		mockTaskRepo := new(MockTaskRepository)
+		sharedTask(mockTaskRepo)
+		mockWorkspaceRepo := new(MockWorkspaceRepository)
+		mockWorkspaceRepo.On("ListMembers", mock.Anything, int64(3)).Return([]*model.WorkspaceMember{
+			{WorkspaceID: 3, UserID: 1, Email: "ana@example.com"},
+			{WorkspaceID: 3, UserID: 2, Email: "Bo@example.com"},
+			{WorkspaceID: 3, UserID: 4, Email: "sam@example.com"},
+			{WorkspaceID: 3, UserID: 5, Email: "sam@example.org"},
+		}, nil)
+		mockCommentRepo := new(MockCommentRepository)
+		mockCommentRepo.On("Create", mock.Anything, mock.MatchedBy(func(comment *model.Comment) bool {
+			return comment.TaskID == 10 && *comment.AuthorID == 2 && comment.Body == "@bo@example.com and @ana, not @sam or @zoe" &&
+				assert.ObjectsAreEqual([]model.Mention{{UserID: 2, Email: "Bo@example.com"}, {UserID: 1, Email: "ana@example.com"}}, comment.Mentions)
+		})).Return(nil)
+
+		commentService := service.NewCommentService(mockCommentRepo, mockTaskRepo, mockWorkspaceRepo, nil, testAuthorizer)
+		comment := &model.Comment{TaskID: 10, Body: " @bo@example.com and @ana, not @sam or @zoe "}
+		err := commentService.CreateComment(workspaceContext(3, model.RoleMember), comment, 2)
+
+		assert.NoError(t, err)
+		mockCommentRepo.AssertExpectations(t)
+	})
+
+	t.Run("Personal Task Mentions Its Owner Only", func(t *testing.T) {
+		mockTaskRepo := new(MockTaskRepository)
+		mockTaskRepo.On("GetByID", mock.Anything, int64(10)).Return(&model.Task{ID: 10, UserID: 1}, nil)
+		mockUserRepo := new(MockUserRepository)
+		mockUserRepo.On("GetUserByID", mock.Anything, uint(1)).Return(&model.User{ID: 1, Email: "ana@example.com"}, nil)
+		mockCommentRepo := new(MockCommentRepository)
+		mockCommentRepo.On("Create", mock.Anything, mock.MatchedBy(func(comment *model.Comment) bool {
+			return assert.ObjectsAreEqual([]model.Mention{{UserID: 1, Email: "ana@example.com"}}, comment.Mentions)
+		})).Return(nil)
+
+		commentService := service.NewCommentService(mockCommentRepo, mockTaskRepo, nil, mockUserRepo, testAuthorizer)
+		err := commentService.CreateComment(newTestContext(), &model.Comment{TaskID: 10, Body: "Note to @ana, not @bo"}, 1)
+
+		assert.NoError(t, err)
+		mockCommentRepo.AssertExpectations(t)
+	})
+
+	t.Run("Personal Task Owner Gone", func(t *testing.T) {
+		mockTaskRepo := new(MockTaskRepository)
+		mockTaskRepo.On("GetByID", mock.Anything, int64(10)).Return(&model.Task{ID: 10, UserID: 1}, nil)
+		mockUserRepo := new(MockUserRepository)
+		mockUserRepo.On("GetUserByID", mock.Anything, uint(1)).Return(nil, nil)
+		mockCommentRepo := new(MockCommentRepository)
+		mockCommentRepo.On("Create", mock.Anything, mock.MatchedBy(func(comment *model.Comment) bool {
+			return len(comment.Mentions) == 0
+		})).Return(nil)
+
+		commentService := service.NewCommentService(mockCommentRepo, mockTaskRepo, nil, mockUserRepo, testAuthorizer)
+		err := commentService.CreateComment(newTestContext(), &model.Comment{TaskID: 10, Body: "Note to @ana"}, 1)
+
+		assert.NoError(t, err)
+		mockCommentRepo.AssertExpectations(t)
+	})
+
+	t.Run("Viewer Cannot Comment", func(t *testing.T) {
+		mockTaskRepo := new(MockTaskRepository)
+		sharedTask(mockTaskRepo)
+		mockCommentRepo := new(MockCommentRepository)
+
+		commentService := service.NewCommentService(mockCommentRepo, mockTaskRepo, nil, nil, testAuthorizer)
+		err := commentService.CreateComment(workspaceContext(3, model.RoleViewer), &model.Comment{TaskID: 10, Body: "Hi"}, 2)
+
+		assert.ErrorIs(t, err, service.ErrInsufficientRole)
+		mockCommentRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Body Validation", func(t *testing.T) {
+		commentService := service.NewCommentService(new(MockCommentRepository), new(MockTaskRepository), nil, nil, testAuthorizer)
+
+		err := commentService.CreateComment(newTestContext(), &model.Comment{TaskID: 10, Body: "  "}, 1)
+		assert.ErrorIs(t, err, service.ErrCommentBodyRequired)
+
+		err = commentService.CreateComment(newTestContext(), &model.Comment{TaskID: 10, Body: strings.Repeat("a", 10001)}, 1)
+		assert.ErrorIs(t, err, service.ErrCommentBodyTooLong)
+	})
+}
+
+func TestCommentServiceGetComments(t *testing.T) {
+	t.Run("Default Page Size", func(t *testing.T) {
+		mockTaskRepo := new(MockTaskRepository)
+		mockTaskRepo.On("GetByID", mock.Anything, int64(10)).Return(&model.Task{ID: 10, UserID: 1}, nil)
+		mockCommentRepo := new(MockCommentRepository)
+		mockCommentRepo.On("List", mock.Anything, int64(10), "", 50).
+			Return(&model.CommentPage{Items: []*model.Comment{{ID: 20, TaskID: 10}}, NextCursor: "abc"}, nil)
+
+		commentService := service.NewCommentService(mockCommentRepo, mockTaskRepo, nil, nil, testAuthorizer)
+		page, err := commentService.GetComments(newTestContext(), 10, "", 0, 1)
+
+		assert.NoError(t, err)
+		assert.Len(t, page.Items, 1)
+		assert.Equal(t, "abc", page.NextCursor)
+	})
+
+	t.Run("Invalid Cursor", func(t *testing.T) {
+		mockTaskRepo := new(MockTaskRepository)
+		mockTaskRepo.On("GetByID", mock.Anything, int64(10)).Return(&model.Task{ID: 10, UserID: 1}, nil)
+		mockCommentRepo := new(MockCommentRepository)
+		mockCommentRepo.On("List", mock.Anything, int64(10), "bogus", 50).Return(nil, repository.ErrInvalidCursor)
+
+		commentService := service.NewCommentService(mockCommentRepo, mockTaskRepo, nil, nil, testAuthorizer)
+		_, err := commentService.GetComments(newTestContext(), 10, "bogus", 0, 1)
+
+		assert.ErrorIs(t, err, service.ErrInvalidFilter)
+	})
+
+	t.Run("Limit Too Large", func(t *testing.T) {
+		commentService := service.NewCommentService(new(MockCommentRepository), new(MockTaskRepository), nil, nil, testAuthorizer)
+		_, err := commentService.GetComments(newTestContext(), 10, "", 101, 1)
+
+		assert.ErrorIs(t, err, service.ErrInvalidFilter)
+	})
+}
+
+func TestCommentServiceUpdateComment(t *testing.T) {
+	tests := []struct {
+		name     string
+		role     model.WorkspaceRole
+		authorID uint
+		wantErr  error
+	}{
+		{"Author", model.RoleMember, 2, nil},
+		{"Admin Edits Someone Else's", model.RoleAdmin, 1, nil},
+		{"Member Edits Someone Else's", model.RoleMember, 1, service.ErrNotCommentAuthor},
+		{"Author Demoted To Viewer", model.RoleViewer, 2, service.ErrInsufficientRole},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockTaskRepo := new(MockTaskRepository)
+			sharedTask(mockTaskRepo)
+			mockWorkspaceRepo := new(MockWorkspaceRepository)
+			mockWorkspaceRepo.On("ListMembers", mock.Anything, int64(3)).
+				Return([]*model.WorkspaceMember{{WorkspaceID: 3, UserID: 1, Email: "ana@example.com"}}, nil)
+			mockCommentRepo := new(MockCommentRepository)
+			commentBy(mockCommentRepo, tt.authorID)
+			mockCommentRepo.On("Update", mock.Anything, mock.MatchedBy(func(comment *model.Comment) bool {
+				return comment.ID == 20 && comment.Body == "Second draft for @ana" && len(comment.Mentions) == 1
+			}), int64(2)).Return(nil)
+
+			commentService := service.NewCommentService(mockCommentRepo, mockTaskRepo, mockWorkspaceRepo, nil, testAuthorizer)
+			comment := &model.Comment{ID: 20, TaskID: 10, Body: "Second draft for @ana"}
+			err := commentService.UpdateComment(workspaceContext(3, tt.role), comment, 2)
+
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				mockCommentRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything, mock.Anything)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, "Second draft for @ana", comment.Body)
+			mockCommentRepo.AssertExpectations(t)
+		})
+	}
+
+	t.Run("Unchanged Body", func(t *testing.T) {
+		mockTaskRepo := new(MockTaskRepository)
+		mockTaskRepo.On("GetByID", mock.Anything, int64(10)).Return(&model.Task{ID: 10, UserID: 2}, nil)
+		mockCommentRepo := new(MockCommentRepository)
+		commentBy(mockCommentRepo, 2)
+
+		commentService := service.NewCommentService(mockCommentRepo, mockTaskRepo, nil, nil, testAuthorizer)
+		err := commentService.UpdateComment(newTestContext(), &model.Comment{ID: 20, TaskID: 10, Body: "First draft"}, 2)
+
+		assert.NoError(t, err)
+		mockCommentRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Comment On Another Task", func(t *testing.T) {
+		mockTaskRepo := new(MockTaskRepository)
+		mockTaskRepo.On("GetByID", mock.Anything, int64(11)).Return(&model.Task{ID: 11, UserID: 2}, nil)
+		mockCommentRepo := new(MockCommentRepository)
+		commentBy(mockCommentRepo, 2)
+
+		commentService := service.NewCommentService(mockCommentRepo, mockTaskRepo, nil, nil, testAuthorizer)
+		err := commentService.UpdateComment(newTestContext(), &model.Comment{ID: 20, TaskID: 11, Body: "Moved"}, 2)
+
+		assert.ErrorIs(t, err, service.ErrCommentNotFound)
+	})
+}
+
+func TestCommentServiceDeleteComment(t *testing.T) {
+	t.Run("Admin", func(t *testing.T) {
+		mockTaskRepo := new(MockTaskRepository)
+		sharedTask(mockTaskRepo)
+		mockCommentRepo := new(MockCommentRepository)
+		commentBy(mockCommentRepo, 1)
+		mockCommentRepo.On("Delete", mock.Anything, int64(20), int64(10), int64(2)).Return(nil)
+
+		commentService := service.NewCommentService(mockCommentRepo, mockTaskRepo, nil, nil, testAuthorizer)
+		err := commentService.DeleteComment(workspaceContext(3, model.RoleAdmin), 10, 20, 2)
+
+		assert.NoError(t, err)
+		mockCommentRepo.AssertExpectations(t)
+	})
+
+	t.Run("Already Deleted", func(t *testing.T) {
+		mockTaskRepo := new(MockTaskRepository)
+		sharedTask(mockTaskRepo)
+		mockCommentRepo := new(MockCommentRepository)
+		mockCommentRepo.On("GetByID", mock.Anything, int64(20)).Return(nil, sql.ErrNoRows)
+
+		commentService := service.NewCommentService(mockCommentRepo, mockTaskRepo, nil, nil, testAuthorizer)
+		err := commentService.DeleteComment(workspaceContext(3, model.RoleAdmin), 10, 20, 2)
+
+		assert.ErrorIs(t, err, service.ErrCommentNotFound)
+	})
+}
+
+func TestCommentServiceGetCommentHistory(t *testing.T) {
+	mockTaskRepo := new(MockTaskRepository)
+	sharedTask(mockTaskRepo)
+	mockCommentRepo := new(MockCommentRepository)
+	commentBy(mockCommentRepo, 1)
+	mockCommentRepo.On("ListRevisions", mock.Anything, int64(20)).
+		Return([]*model.CommentRevision{{ID: 1, CommentID: 20, Body: "Typo"}}, nil)
+
+	commentService := service.NewCommentService(mockCommentRepo, mockTaskRepo, nil, nil, testAuthorizer)
+	revisions, err := commentService.GetCommentHistory(workspaceContext(3, model.RoleViewer), 10, 20, 2)
+
+	assert.NoError(t, err)
+	assert.Len(t, revisions, 1)
+}
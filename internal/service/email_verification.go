@@ -0,0 +1,111 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ahmednurovic/task-manager-api/internal/mail"
+	"github.com/ahmednurovic/task-manager-api/internal/model"
+)
+
+// VerifyEmail marks the email of the user a verification token was sent to
+// as verified. Each token works once.
+func (s *AuthService) VerifyEmail(ctx context.Context, token string) error {
+	_, err := s.userRepo.ConsumeEmailVerification(ctx, hashToken(token))
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrInvalidVerificationToken
+	}
+	return err
+}
+
+// ResendVerification sends a new verification email to the user with the
+// given email. Like ForgotPassword it returns nil when there is no such
+// user, and also when the email is already verified. Requests for the same
+// email are limited to one per resend interval, whether or not the user
+// exists, so the limit gives nothing away either.
+func (s *AuthService) ResendVerification(ctx context.Context, email string) error {
+	if wait := s.resendLimiter.Allow(email); wait > 0 {
+		return &RetryAfterError{Err: ErrTooManyRequests, RetryAfter: wait}
+	}
+
+	user, err := s.userRepo.GetUserByEmail(ctx, email)
+	if err != nil {
+		return err
+	}
+	if user == nil || user.EmailVerifiedAt != nil {
+		return nil
+	}
+
+	return s.sendVerification(ctx, user)
+}
+
+func (s *AuthService) sendVerification(ctx context.Context, user *model.User) error {
+	token, err := randomToken(32, base64.RawURLEncoding.EncodeToString)
+	if err != nil {
+		return ErrTokenGeneration
+	}
+
+	expiresAt := time.Now().Add(s.policy.EmailVerificationTTL).UTC()
+	if err := s.userRepo.CreateEmailVerification(ctx, user.ID, hashToken(token), expiresAt); err != nil {
+		return err
+	}
+
+	link, err := tokenLink(s.policy.EmailVerificationURL, token)
+	if err != nil {
+		return err
+	}
+
+	return s.mailer.Send(ctx, mail.Message{
+		To:      user.Email,
+		Subject: "Verify your email address",
+		Body: fmt.Sprintf("Welcome! Please confirm this is your email address by opening this link within %s:\n\n%s\n\n"+
+			"If you didn't create an account, you can ignore this email.\n",
+			s.policy.EmailVerificationTTL, link),
+	})
+}
+
+// intervalLimiter allows one event per key per interval. It keeps its state
+// in memory, so each instance of the API limits on its own.
+type intervalLimiter struct {
+	interval time.Duration
+
+	mu   sync.Mutex
+	last map[string]time.Time
+}
+
+func newIntervalLimiter(interval time.Duration) *intervalLimiter {
+	return &intervalLimiter{interval: interval, last: make(map[string]time.Time)}
+}
+
+// Allow records an event for key and returns zero, or returns how long to
+// wait if the last allowed event was too recent. Keys are compared without
+// regard to case or surrounding space.
+func (l *intervalLimiter) Allow(key string) time.Duration {
+	key = strings.ToLower(strings.TrimSpace(key))
+	now := time.Now()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if last, ok := l.last[key]; ok {
+		if wait := l.interval - now.Sub(last); wait > 0 {
+			return wait
+		}
+	}
+
+	// Forget keys whose interval has passed, so the map stays small.
+	for k, last := range l.last {
+		if now.Sub(last) >= l.interval {
+			delete(l.last, k)
+		}
+	}
+
+	l.last[key] = now
+	return 0
+}
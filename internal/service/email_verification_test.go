@@ -0,0 +1,241 @@
+package service_test
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"net/url"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/ahmednurovic/task-manager-api/internal/mail"
+	"github.com/ahmednurovic/task-manager-api/internal/model"
+	"github.com/ahmednurovic/task-manager-api/internal/service"
+)
+
+var verifyLinkPattern = regexp.MustCompile(`https://app\.example\.com/verify\?\S+`)
+
+func TestAuthServiceRegisterSendsVerification(t *testing.T) {
+	var storedHash string
+	mockUserRepo := new(MockUserRepository)
+	mockUserRepo.On("GetUserByEmail", mock.Anything, "user@example.com").Return(nil, nil)
+	mockUserRepo.On("CreateUser", mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) {
+			args.Get(1).(*model.User).ID = 1
+		}).
+		Return(nil)
+	mockUserRepo.On("CreateEmailVerification", mock.Anything, uint(1), mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) {
+			storedHash = args.String(2)
+		}).
+		Return(nil)
+
+	var outbox bytes.Buffer
+	policy := service.AuthPolicy{EmailVerificationURL: "https://app.example.com/verify"}
+	authService := service.NewAuthService(mockUserRepo, nil, nil, nil, mail.NewLogMailer(&outbox), testKeys, policy)
+	user, err := authService.Register(context.Background(), "user@example.com", "password123")
+
+	assert.NoError(t, err)
+	assert.Nil(t, user.EmailVerifiedAt)
+	mockUserRepo.AssertExpectations(t)
+
+	link, err := url.Parse(verifyLinkPattern.FindString(outbox.String()))
+	assert.NoError(t, err)
+	assert.Equal(t, sha256Hex(link.Query().Get("token")), storedHash)
+}
+
+// failingMailer fails every send, like an unreachable SMTP server.
+type failingMailer struct{}
+
+func (failingMailer) Send(ctx context.Context, msg mail.Message) error {
+	return errors.New("dial tcp: connection refused")
+}
+
+func TestAuthServiceRegisterMailFailure(t *testing.T) {
+	mockUserRepo := new(MockUserRepository)
+	mockUserRepo.On("GetUserByEmail", mock.Anything, "user@example.com").Return(nil, nil)
+	mockUserRepo.On("CreateUser", mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) {
+			args.Get(1).(*model.User).ID = 1
+		}).
+		Return(nil)
+	mockUserRepo.On("CreateEmailVerification", mock.Anything, uint(1), mock.Anything, mock.Anything).Return(nil)
+
+	var mailErrs []error
+	policy := service.AuthPolicy{
+		EmailVerificationURL: "https://app.example.com/verify",
+		OnMailError:          func(err error) { mailErrs = append(mailErrs, err) },
+	}
+	authService := service.NewAuthService(mockUserRepo, nil, nil, nil, failingMailer{}, testKeys, policy)
+	user, err := authService.Register(context.Background(), "user@example.com", "password123")
+
+	// The account exists, so registration succeeds and the email can be
+	// resent; the failure is only reported.
+	assert.NoError(t, err)
+	assert.Equal(t, uint(1), user.ID)
+	assert.Len(t, mailErrs, 1)
+	mockUserRepo.AssertExpectations(t)
+}
+
+func TestAuthServiceLoginVerificationPolicy(t *testing.T) {
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.MinCost)
+	assert.NoError(t, err)
+	verifiedAt := time.Now().Add(-time.Hour)
+
+	tests := []struct {
+		name         string
+		policy       service.EmailVerificationPolicy
+		verified     bool
+		wantErr      error
+		wantReadOnly bool
+	}{
+		{"Allow Unverified", service.VerificationAllow, false, nil, false},
+		{"Read Only Unverified", service.VerificationReadOnly, false, nil, true},
+		{"Read Only Verified", service.VerificationReadOnly, true, nil, false},
+		{"Block Unverified", service.VerificationBlock, false, service.ErrEmailNotVerified, false},
+		{"Block Verified", service.VerificationBlock, true, nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user := &model.User{ID: 1, Email: "user@example.com", Password: string(hashedPassword)}
+			if tt.verified {
+				user.EmailVerifiedAt = &verifiedAt
+			}
+			mockUserRepo := new(MockUserRepository)
+			mockUserRepo.On("GetUserByEmail", mock.Anything, "user@example.com").Return(user, nil)
+			mockRefreshRepo := new(MockRefreshTokenRepository)
+			mockRefreshRepo.On("Create", mock.Anything, mock.Anything).Return(nil).Maybe()
+
+			authService := service.NewAuthService(mockUserRepo, mockRefreshRepo, unrevokedStore(), nil, nil, testKeys,
+				service.AuthPolicy{EmailVerification: tt.policy, PasswordHasher: testPasswords})
+			tokens, err := authService.Login(context.Background(), "user@example.com", "password123", "192.0.2.1")
+
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				mockRefreshRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantReadOnly, tokens.ReadOnly)
+
+			claims := jwt.MapClaims{}
+			_, err = jwt.ParseWithClaims(tokens.AccessToken, claims, func(*jwt.Token) (interface{}, error) {
+				return []byte("test-secret"), nil
+			})
+			assert.NoError(t, err)
+			readOnly, _ := claims["ro"].(bool)
+			assert.Equal(t, tt.wantReadOnly, readOnly)
+		})
+	}
+
+	t.Run("Wrong Password Before Policy", func(t *testing.T) {
+		mockUserRepo := new(MockUserRepository)
+		mockUserRepo.On("GetUserByEmail", mock.Anything, "user@example.com").
+			Return(&model.User{ID: 1, Email: "user@example.com", Password: string(hashedPassword)}, nil)
+
+		authService := service.NewAuthService(mockUserRepo, nil, nil, nil, nil, testKeys,
+			service.AuthPolicy{EmailVerification: service.VerificationBlock})
+		_, err := authService.Login(context.Background(), "user@example.com", "wrong-password", "192.0.2.1")
+
+		assert.ErrorIs(t, err, service.ErrInvalidCredentials)
+	})
+}
+
+func TestAuthServiceRefreshUnverified(t *testing.T) {
+	mockUserRepo := new(MockUserRepository)
+	mockUserRepo.On("GetUserByID", mock.Anything, uint(1)).Return(&model.User{ID: 1}, nil)
+	mockRefreshRepo := new(MockRefreshTokenRepository)
+	mockRefreshRepo.On("GetByHash", mock.Anything, mock.Anything).
+		Return(&model.RefreshToken{ID: 7, UserID: 1, FamilyID: "family", ExpiresAt: time.Now().Add(time.Hour)}, nil)
+
+	authService := service.NewAuthService(mockUserRepo, mockRefreshRepo, unrevokedStore(), nil, nil, testKeys,
+		service.AuthPolicy{EmailVerification: service.VerificationBlock})
+	_, err := authService.Refresh(context.Background(), "old-token")
+
+	assert.ErrorIs(t, err, service.ErrEmailNotVerified)
+	mockRefreshRepo.AssertNotCalled(t, "Rotate", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestAuthServiceVerifyEmail(t *testing.T) {
+	t.Run("Valid Token", func(t *testing.T) {
+		mockUserRepo := new(MockUserRepository)
+		mockUserRepo.On("ConsumeEmailVerification", mock.Anything, sha256Hex("verify-token")).Return(uint(1), nil)
+
+		authService := service.NewAuthService(mockUserRepo, nil, nil, nil, nil, testKeys, service.AuthPolicy{})
+		err := authService.VerifyEmail(context.Background(), "verify-token")
+
+		assert.NoError(t, err)
+		mockUserRepo.AssertExpectations(t)
+	})
+
+	t.Run("Invalid Token", func(t *testing.T) {
+		mockUserRepo := new(MockUserRepository)
+		mockUserRepo.On("ConsumeEmailVerification", mock.Anything, mock.Anything).Return(uint(0), sql.ErrNoRows)
+
+		authService := service.NewAuthService(mockUserRepo, nil, nil, nil, nil, testKeys, service.AuthPolicy{})
+		err := authService.VerifyEmail(context.Background(), "used-token")
+
+		assert.ErrorIs(t, err, service.ErrInvalidVerificationToken)
+	})
+}
+
+func TestAuthServiceResendVerification(t *testing.T) {
+	policy := service.AuthPolicy{EmailVerificationURL: "https://app.example.com/verify", VerificationResendInterval: time.Hour}
+
+	t.Run("Unverified User", func(t *testing.T) {
+		mockUserRepo := new(MockUserRepository)
+		mockUserRepo.On("GetUserByEmail", mock.Anything, "user@example.com").
+			Return(&model.User{ID: 1, Email: "user@example.com"}, nil)
+		mockUserRepo.On("CreateEmailVerification", mock.Anything, uint(1), mock.Anything, mock.Anything).Return(nil)
+
+		var outbox bytes.Buffer
+		authService := service.NewAuthService(mockUserRepo, nil, nil, nil, mail.NewLogMailer(&outbox), testKeys, policy)
+		err := authService.ResendVerification(context.Background(), "user@example.com")
+
+		assert.NoError(t, err)
+		assert.Regexp(t, verifyLinkPattern, outbox.String())
+	})
+
+	t.Run("Verified Or Unknown User", func(t *testing.T) {
+		verifiedAt := time.Now()
+		mockUserRepo := new(MockUserRepository)
+		mockUserRepo.On("GetUserByEmail", mock.Anything, "verified@example.com").
+			Return(&model.User{ID: 1, Email: "verified@example.com", EmailVerifiedAt: &verifiedAt}, nil)
+		mockUserRepo.On("GetUserByEmail", mock.Anything, "nobody@example.com").Return(nil, nil)
+
+		var outbox bytes.Buffer
+		authService := service.NewAuthService(mockUserRepo, nil, nil, nil, mail.NewLogMailer(&outbox), testKeys, policy)
+
+		assert.NoError(t, authService.ResendVerification(context.Background(), "verified@example.com"))
+		assert.NoError(t, authService.ResendVerification(context.Background(), "nobody@example.com"))
+		assert.Empty(t, outbox.String())
+		mockUserRepo.AssertNotCalled(t, "CreateEmailVerification", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Rate Limited Per Email", func(t *testing.T) {
+		mockUserRepo := new(MockUserRepository)
+		mockUserRepo.On("GetUserByEmail", mock.Anything, mock.Anything).Return(nil, nil)
+
+		authService := service.NewAuthService(mockUserRepo, nil, nil, nil, nil, testKeys, policy)
+		assert.NoError(t, authService.ResendVerification(context.Background(), "nobody@example.com"))
+
+		err := authService.ResendVerification(context.Background(), " Nobody@Example.com")
+		assert.ErrorIs(t, err, service.ErrTooManyRequests)
+		var retryErr *service.RetryAfterError
+		if assert.True(t, errors.As(err, &retryErr)) {
+			assert.InDelta(t, time.Hour.Seconds(), retryErr.RetryAfter.Seconds(), 5)
+		}
+
+		assert.NoError(t, authService.ResendVerification(context.Background(), "other@example.com"))
+		mockUserRepo.AssertNumberOfCalls(t, "GetUserByEmail", 2)
+	})
+}
@@ -1,11 +1,106 @@
 package service
 
-import "errors"
+import (
+	"errors"
+	"time"
+
+	"github.com/ahmednurovic/task-manager-api/internal/password"
+	"github.com/ahmednurovic/task-manager-api/internal/recurrence"
+)
 
 var (
-	ErrUserExists         = errors.New("user already exists")
-	ErrInvalidCredentials = errors.New("invalid credentials")
-	ErrTokenGeneration    = errors.New("failed to generate token")
-	ErrTaskNotFound       = errors.New("task not found")
-	ErrUnauthorized       = errors.New("unauthorized access")
+	ErrUserExists               = errors.New("user already exists")
+	ErrInvalidCredentials       = errors.New("invalid credentials")
+	ErrTokenGeneration          = errors.New("failed to generate token")
+	ErrInvalidRefreshToken      = errors.New("invalid refresh token")
+	ErrRefreshTokenReused       = errors.New("refresh token has already been used")
+	ErrInvalidResetToken        = errors.New("invalid or expired reset token")
+	ErrPasswordTooShort         = password.ErrTooShort
+	ErrPasswordTooLong          = password.ErrTooLong
+	ErrPasswordTooCommon        = password.ErrTooCommon
+	ErrEmailNotVerified         = errors.New("email address is not verified")
+	ErrInvalidVerificationToken = errors.New("invalid or expired verification token")
+	ErrTooManyRequests          = errors.New("too many requests")
+	ErrTooManyLoginAttempts     = errors.New("too many failed login attempts; try again later")
+	ErrInvalidMFAToken          = errors.New("invalid or expired MFA token")
+	ErrInvalidMFACode           = errors.New("invalid two-factor code")
+	ErrMFAAlreadyEnabled        = errors.New("two-factor authentication is already enabled")
+	ErrMFANotEnrolled           = errors.New("two-factor enrolment has not been started")
+	ErrMFANotEnabled            = errors.New("two-factor authentication is not enabled")
+	ErrUnknownProvider          = errors.New("unknown identity provider")
+	ErrInvalidOIDCState         = errors.New("invalid or expired login state")
+	ErrOIDCLoginFailed          = errors.New("identity provider login failed")
+	ErrProviderUnavailable      = errors.New("identity provider is unavailable")
+	ErrOIDCEmailNotVerified     = errors.New("identity provider has not verified the email address")
+	ErrOIDCAccountNotVerified   = errors.New("an account with this email exists but is not verified; verify it before signing in with an identity provider")
+	ErrAccessTokenNotFound      = errors.New("access token not found")
+	ErrAccessTokenNameRequired  = errors.New("access token name is required")
+	ErrAccessTokenNameTooLong   = errors.New("access token name must be at most 100 characters")
+	ErrAccessTokenExpired       = errors.New("access token expiry must be in the future")
+	ErrScopesRequired           = errors.New("at least one scope is required")
+	ErrInvalidScope             = errors.New("scope must be one of tasks:read, tasks:write, labels:read, labels:write, projects:read, projects:write")
+	ErrTaskNotFound             = errors.New("task not found")
+	ErrUnauthorized             = errors.New("unauthorized access")
+	ErrTitleRequired            = errors.New("title is required")
+	ErrInvalidPriority          = errors.New("priority must be one of low, medium, high, urgent")
+	ErrInvalidStatus            = errors.New("status must be one of pending, in_progress, blocked, done, cancelled")
+	ErrInvalidTransition        = errors.New("status transition not allowed")
+	ErrInvalidFilter            = errors.New("invalid filter")
+	ErrSearchQueryRequired      = errors.New("search query is required")
+	ErrLabelNotFound            = errors.New("label not found")
+	ErrLabelExists              = errors.New("label with this name already exists")
+	ErrLabelNameRequired        = errors.New("label name is required")
+	ErrLabelNameTooLong         = errors.New("label name must be at most 50 characters")
+	ErrInvalidLabelColor        = errors.New("label color must be a hex color like #1e88e5")
+	ErrProjectNotFound          = errors.New("project not found")
+	ErrProjectNameRequired      = errors.New("project name is required")
+	ErrProjectNameTooLong       = errors.New("project name must be at most 100 characters")
+	ErrInvalidProjectColor      = errors.New("project color must be a hex color like #1e88e5")
+	ErrInvalidPosition          = errors.New("position must not be negative")
+	ErrParentTaskNotFound       = errors.New("parent task not found")
+	ErrSubtaskCycle             = errors.New("a task cannot be placed under itself or one of its subtasks")
+	ErrSubtaskTooDeep           = errors.New("subtasks are nested too deeply")
+	ErrOpenSubtasks             = errors.New("task has open subtasks")
+	ErrChecklistItemNotFound    = errors.New("checklist item not found")
+	ErrChecklistTitleRequired   = errors.New("checklist item title is required")
+	ErrChecklistTitleTooLong    = errors.New("checklist item title must be at most 255 characters")
+	ErrBlockerNotFound          = errors.New("blocking task not found")
+	ErrDependencyCycle          = errors.New("dependency would create a cycle")
+	ErrBlockedByOpenTasks       = errors.New("task is blocked by open tasks")
+	ErrInvalidRecurrence        = recurrence.ErrInvalidRule
+	ErrWorkspaceNotFound        = errors.New("workspace not found")
+	ErrWorkspaceNameRequired    = errors.New("workspace name is required")
+	ErrWorkspaceNameTooLong     = errors.New("workspace name must be at most 100 characters")
+	ErrInvalidWorkspaceRole     = errors.New("role must be one of owner, admin, member, viewer")
+	ErrInsufficientRole         = errors.New("your workspace role does not allow this")
+	ErrMemberNotFound           = errors.New("workspace member not found")
+	ErrLastOwner                = errors.New("a workspace must keep at least one owner")
+	ErrInvitationEmailRequired  = errors.New("invitation email is required")
+	ErrInvalidInvitation        = errors.New("invalid or expired invitation")
+	ErrInvitationEmailMismatch  = errors.New("this invitation was sent to a different email address")
+	ErrInvalidAssignee          = errors.New("assignee must be a member of the task's workspace")
+	ErrCommentNotFound          = errors.New("comment not found")
+	ErrCommentBodyRequired      = errors.New("comment body is required")
+	ErrCommentBodyTooLong       = errors.New("comment body must be at most 10000 characters")
+	ErrNotCommentAuthor         = errors.New("only the author or a workspace admin can change a comment")
+	ErrAttachmentNotFound       = errors.New("attachment not found")
+	ErrAttachmentEmpty          = errors.New("attachment is empty")
+	ErrAttachmentTooLarge       = errors.New("attachment is too large")
+	ErrAttachmentTypeNotAllowed = errors.New("attachment type is not allowed")
+	ErrInvalidAttachmentURL     = errors.New("invalid or expired download link")
 )
+
+// RetryAfterError is returned when a request is refused for now but may be
+// tried again once RetryAfter has passed.
+type RetryAfterError struct {
+	Err        error
+	RetryAfter time.Duration
+}
+
+func (e *RetryAfterError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *RetryAfterError) Unwrap() error {
+	return e.Err
+}
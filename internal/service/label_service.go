@@ -0,0 +1,148 @@
+package service
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+
+	"github.com/ahmednurovic/task-manager-api/internal/authz"
+	"github.com/ahmednurovic/task-manager-api/internal/model"
+	"github.com/ahmednurovic/task-manager-api/internal/repository"
+	"github.com/gin-gonic/gin"
+)
+
+const maxLabelNameLength = 50
+
+type LabelService struct {
+	labelRepo  repository.LabelRepository
+	taskRepo   repository.TaskRepository
+	authorizer *authz.Authorizer
+}
+
+func NewLabelService(labelRepo repository.LabelRepository, taskRepo repository.TaskRepository, authorizer *authz.Authorizer) *LabelService {
+	return &LabelService{labelRepo: labelRepo, taskRepo: taskRepo, authorizer: authorizer}
+}
+
+func (s *LabelService) CreateLabel(ctx *gin.Context, label *model.Label, userID int64) error {
+	if err := normalizeLabel(label); err != nil {
+		return err
+	}
+
+	label.ID = 0
+	label.UserID = uint(userID)
+
+	if err := s.labelRepo.Create(ctx, label); err != nil {
+		if errors.Is(err, repository.ErrDuplicateLabel) {
+			return ErrLabelExists
+		}
+		return err
+	}
+
+	return nil
+}
+
+func (s *LabelService) GetLabels(ctx *gin.Context, userID int64) ([]*model.Label, error) {
+	return s.labelRepo.ListForUser(ctx, userID)
+}
+
+func (s *LabelService) UpdateLabel(ctx *gin.Context, label *model.Label, userID int64) error {
+	if err := normalizeLabel(label); err != nil {
+		return err
+	}
+
+	existingLabel, err := s.getOwnedLabel(ctx, int64(label.ID), userID)
+	if err != nil {
+		return err
+	}
+
+	existingLabel.Name = label.Name
+	existingLabel.Color = label.Color
+
+	if err := s.labelRepo.Update(ctx, existingLabel); err != nil {
+		switch {
+		case errors.Is(err, repository.ErrDuplicateLabel):
+			return ErrLabelExists
+		case errors.Is(err, sql.ErrNoRows):
+			return ErrLabelNotFound
+		}
+		return err
+	}
+
+	*label = *existingLabel
+	return nil
+}
+
+func (s *LabelService) DeleteLabel(ctx *gin.Context, labelID int64, userID int64) error {
+	if err := s.labelRepo.Delete(ctx, labelID, userID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrLabelNotFound
+		}
+		return err
+	}
+
+	return nil
+}
+
+// AttachLabel labels a task the user may change with one of their labels.
+func (s *LabelService) AttachLabel(ctx *gin.Context, taskID int64, labelID int64, userID int64) error {
+	if err := s.checkTaskAndLabel(ctx, taskID, labelID, userID); err != nil {
+		return err
+	}
+
+	return s.labelRepo.AttachToTask(ctx, taskID, labelID)
+}
+
+func (s *LabelService) DetachLabel(ctx *gin.Context, taskID int64, labelID int64, userID int64) error {
+	if err := s.checkTaskAndLabel(ctx, taskID, labelID, userID); err != nil {
+		return err
+	}
+
+	return s.labelRepo.DetachFromTask(ctx, taskID, labelID)
+}
+
+// checkTaskAndLabel verifies that the user may change the task and owns the
+// label. Labels stay personal inside workspaces.
+func (s *LabelService) checkTaskAndLabel(ctx *gin.Context, taskID int64, labelID int64, userID int64) error {
+	if _, err := getTask(ctx, s.taskRepo, s.authorizer, taskID, userID, authz.WriteTasks); err != nil {
+		return err
+	}
+
+	_, err := s.getOwnedLabel(ctx, labelID, userID)
+	return err
+}
+
+// getOwnedLabel loads a label and hides labels owned by other users behind
+// ErrLabelNotFound.
+func (s *LabelService) getOwnedLabel(ctx *gin.Context, labelID int64, userID int64) (*model.Label, error) {
+	label, err := s.labelRepo.GetByID(ctx, labelID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrLabelNotFound
+		}
+		return nil, err
+	}
+
+	if label.UserID != uint(userID) {
+		return nil, ErrLabelNotFound
+	}
+
+	return label, nil
+}
+
+func normalizeLabel(label *model.Label) error {
+	label.Name = strings.TrimSpace(label.Name)
+	if label.Name == "" {
+		return ErrLabelNameRequired
+	}
+	if len([]rune(label.Name)) > maxLabelNameLength {
+		return ErrLabelNameTooLong
+	}
+
+	color, ok := normalizeColor(label.Color)
+	if !ok {
+		return ErrInvalidLabelColor
+	}
+	label.Color = color
+
+	return nil
+}
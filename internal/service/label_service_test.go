@@ -0,0 +1,139 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/ahmednurovic/task-manager-api/internal/model"
+	"github.com/ahmednurovic/task-manager-api/internal/repository"
+	"github.com/ahmednurovic/task-manager-api/internal/service"
+)
+
+type MockLabelRepository struct {
+	mock.Mock
+}
+
+func (m *MockLabelRepository) Create(ctx context.Context, label *model.Label) error {
+	args := m.Called(ctx, label)
+	return args.Error(0)
+}
+
+func (m *MockLabelRepository) GetByID(ctx context.Context, labelID int64) (*model.Label, error) {
+	args := m.Called(ctx, labelID)
+	label, _ := args.Get(0).(*model.Label)
+	return label, args.Error(1)
+}
+
+func (m *MockLabelRepository) ListForUser(ctx context.Context, userID int64) ([]*model.Label, error) {
+	args := m.Called(ctx, userID)
+	labels, _ := args.Get(0).([]*model.Label)
+	return labels, args.Error(1)
+}
+
+func (m *MockLabelRepository) Update(ctx context.Context, label *model.Label) error {
+	args := m.Called(ctx, label)
+	return args.Error(0)
+}
+
+func (m *MockLabelRepository) Delete(ctx context.Context, labelID int64, userID int64) error {
+	args := m.Called(ctx, labelID, userID)
+	return args.Error(0)
+}
+
+func (m *MockLabelRepository) AttachToTask(ctx context.Context, taskID int64, labelID int64) error {
+	args := m.Called(ctx, taskID, labelID)
+	return args.Error(0)
+}
+
+func (m *MockLabelRepository) DetachFromTask(ctx context.Context, taskID int64, labelID int64) error {
+	args := m.Called(ctx, taskID, labelID)
+	return args.Error(0)
+}
+
+func TestLabelServiceCreateLabel(t *testing.T) {
+	t.Run("Normalises Input", func(t *testing.T) {
+		mockLabelRepo := new(MockLabelRepository)
+		mockLabelRepo.On("Create", mock.Anything, mock.MatchedBy(func(label *model.Label) bool {
+			return label.UserID == 1 && label.Name == "bug" && label.Color == "#e53935"
+		})).Return(nil)
+
+		labelService := service.NewLabelService(mockLabelRepo, new(MockTaskRepository), testAuthorizer)
+		err := labelService.CreateLabel(newTestContext(), &model.Label{UserID: 2, Name: " bug ", Color: "#E53935"}, 1)
+
+		assert.NoError(t, err)
+		mockLabelRepo.AssertExpectations(t)
+	})
+
+	t.Run("Default Color", func(t *testing.T) {
+		mockLabelRepo := new(MockLabelRepository)
+		mockLabelRepo.On("Create", mock.Anything, mock.Anything).Return(nil)
+
+		labelService := service.NewLabelService(mockLabelRepo, new(MockTaskRepository), testAuthorizer)
+		label := &model.Label{Name: "bug"}
+		err := labelService.CreateLabel(newTestContext(), label, 1)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "#9e9e9e", label.Color)
+	})
+
+	t.Run("Invalid Color", func(t *testing.T) {
+		labelService := service.NewLabelService(new(MockLabelRepository), new(MockTaskRepository), testAuthorizer)
+		err := labelService.CreateLabel(newTestContext(), &model.Label{Name: "bug", Color: "red"}, 1)
+
+		assert.ErrorIs(t, err, service.ErrInvalidLabelColor)
+	})
+
+	t.Run("Duplicate Name", func(t *testing.T) {
+		mockLabelRepo := new(MockLabelRepository)
+		mockLabelRepo.On("Create", mock.Anything, mock.Anything).Return(repository.ErrDuplicateLabel)
+
+		labelService := service.NewLabelService(mockLabelRepo, new(MockTaskRepository), testAuthorizer)
+		err := labelService.CreateLabel(newTestContext(), &model.Label{Name: "bug"}, 1)
+
+		assert.ErrorIs(t, err, service.ErrLabelExists)
+	})
+}
+
+func TestLabelServiceAttachLabel(t *testing.T) {
+	t.Run("Own Task And Label", func(t *testing.T) {
+		mockTaskRepo := new(MockTaskRepository)
+		mockTaskRepo.On("GetByID", mock.Anything, int64(10)).Return(&model.Task{ID: 10, UserID: 1}, nil)
+		mockLabelRepo := new(MockLabelRepository)
+		mockLabelRepo.On("GetByID", mock.Anything, int64(3)).Return(&model.Label{ID: 3, UserID: 1}, nil)
+		mockLabelRepo.On("AttachToTask", mock.Anything, int64(10), int64(3)).Return(nil)
+
+		labelService := service.NewLabelService(mockLabelRepo, mockTaskRepo, testAuthorizer)
+		err := labelService.AttachLabel(newTestContext(), 10, 3, 1)
+
+		assert.NoError(t, err)
+		mockLabelRepo.AssertExpectations(t)
+	})
+
+	t.Run("Other User's Task", func(t *testing.T) {
+		mockTaskRepo := new(MockTaskRepository)
+		mockTaskRepo.On("GetByID", mock.Anything, int64(10)).Return(&model.Task{ID: 10, UserID: 2}, nil)
+		mockLabelRepo := new(MockLabelRepository)
+
+		labelService := service.NewLabelService(mockLabelRepo, mockTaskRepo, testAuthorizer)
+		err := labelService.AttachLabel(newTestContext(), 10, 3, 1)
+
+		assert.ErrorIs(t, err, service.ErrTaskNotFound)
+		mockLabelRepo.AssertNotCalled(t, "AttachToTask", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Other User's Label", func(t *testing.T) {
+		mockTaskRepo := new(MockTaskRepository)
+		mockTaskRepo.On("GetByID", mock.Anything, int64(10)).Return(&model.Task{ID: 10, UserID: 1}, nil)
+		mockLabelRepo := new(MockLabelRepository)
+		mockLabelRepo.On("GetByID", mock.Anything, int64(3)).Return(&model.Label{ID: 3, UserID: 2}, nil)
+
+		labelService := service.NewLabelService(mockLabelRepo, mockTaskRepo, testAuthorizer)
+		err := labelService.AttachLabel(newTestContext(), 10, 3, 1)
+
+		assert.ErrorIs(t, err, service.ErrLabelNotFound)
+		mockLabelRepo.AssertNotCalled(t, "AttachToTask", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
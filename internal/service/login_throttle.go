@@ -0,0 +1,244 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/ahmednurovic/task-manager-api/internal/model"
+	"github.com/ahmednurovic/task-manager-api/internal/repository"
+)
+
+const (
+	DefaultLoginMaxFailures      = 5
+	DefaultLoginMaxFailuresPerIP = 20
+	DefaultLoginFailureWindow    = 15 * time.Minute
+	DefaultLoginLockoutDuration  = 15 * time.Minute
+	DefaultLoginBackoffBase      = time.Second
+)
+
+// Security event types.
+const (
+	EventLoginLockout = "login_lockout"
+	EventLoginUnlock  = "login_unlock"
+)
+
+// SecurityEvent is something an operator may want to know about, such as
+// an account being locked after repeated failed logins.
+type SecurityEvent struct {
+	Type string
+	// Scope is model.LoginScopeAccount or model.LoginScopeIP, and
+	// Identifier the email or IP address.
+	Scope      string
+	Identifier string
+	Failures   int
+	Until      time.Time
+	// ActorID is the administrator behind an unlock.
+	ActorID int64
+}
+
+// LoginThrottlePolicy sets how failed logins are limited. Zero values fall
+// back to the defaults.
+type LoginThrottlePolicy struct {
+	// MaxFailures is how many failed logins an account may have within
+	// Window before it is locked for LockoutDuration.
+	MaxFailures int
+	// MaxFailuresPerIP is the same limit for a client IP address. It is
+	// higher, since many users may share an address.
+	MaxFailuresPerIP int
+	Window           time.Duration
+	LockoutDuration  time.Duration
+	// BackoffBase is how long an account must wait after its first failed
+	// login. Each further failure doubles the wait, up to LockoutDuration.
+	BackoffBase time.Duration
+}
+
+// LoginThrottle limits password guessing. Failures are counted per account,
+// by email whether or not there is such an account, so the limits say
+// nothing about which accounts exist. Counts live in Postgres and so are
+// shared by every instance.
+//
+// A nil LoginThrottle lets every attempt through.
+type LoginThrottle struct {
+	repo    repository.LoginFailureRepository
+	policy  LoginThrottlePolicy
+	onEvent func(SecurityEvent)
+}
+
+// NewLoginThrottle returns a throttle that reports lockouts and unlocks to
+// onEvent, which may be nil.
+func NewLoginThrottle(repo repository.LoginFailureRepository, policy LoginThrottlePolicy, onEvent func(SecurityEvent)) *LoginThrottle {
+	if policy.MaxFailures <= 0 {
+		policy.MaxFailures = DefaultLoginMaxFailures
+	}
+	if policy.MaxFailuresPerIP <= 0 {
+		policy.MaxFailuresPerIP = DefaultLoginMaxFailuresPerIP
+	}
+	if policy.Window <= 0 {
+		policy.Window = DefaultLoginFailureWindow
+	}
+	if policy.LockoutDuration <= 0 {
+		policy.LockoutDuration = DefaultLoginLockoutDuration
+	}
+	if policy.BackoffBase <= 0 {
+		policy.BackoffBase = DefaultLoginBackoffBase
+	}
+
+	return &LoginThrottle{repo: repo, policy: policy, onEvent: onEvent}
+}
+
+// Check returns a RetryAfterError wrapping ErrTooManyLoginAttempts while
+// the account or the IP address may not try again.
+func (t *LoginThrottle) Check(ctx context.Context, email, ip string) error {
+	if t == nil {
+		return nil
+	}
+
+	now := time.Now()
+	var wait time.Duration
+	for _, key := range t.keys(email, ip) {
+		failure, err := t.repo.Get(ctx, key.scope, key.identifier)
+		if errors.Is(err, sql.ErrNoRows) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if failure.LockedUntil != nil && failure.LockedUntil.Sub(now) > wait {
+			wait = failure.LockedUntil.Sub(now)
+		}
+	}
+
+	if wait > 0 {
+		return &RetryAfterError{Err: ErrTooManyLoginAttempts, RetryAfter: wait}
+	}
+	return nil
+}
+
+// Failure counts a failed login against the account and the IP address.
+// The account must then back off before trying again, and either is locked
+// once it reaches its limit.
+func (t *LoginThrottle) Failure(ctx context.Context, email, ip string) error {
+	if t == nil {
+		return nil
+	}
+
+	now := time.Now()
+	for _, key := range t.keys(email, ip) {
+		failure, err := t.repo.RecordFailure(ctx, key.scope, key.identifier, now, now.Add(-t.policy.Window))
+		if err != nil {
+			return err
+		}
+
+		var wait time.Duration
+		switch {
+		case failure.Failures >= key.limit:
+			wait = t.policy.LockoutDuration
+		case key.scope == model.LoginScopeAccount:
+			wait = t.backoff(failure.Failures)
+		default:
+			continue
+		}
+
+		until := now.Add(wait)
+		if err := t.repo.Lock(ctx, key.scope, key.identifier, until); err != nil {
+			return err
+		}
+		// Only the failure that reaches the limit reports it, not those
+		// that arrive while the lock is being set.
+		if failure.Failures == key.limit {
+			t.report(SecurityEvent{
+				Type:       EventLoginLockout,
+				Scope:      key.scope,
+				Identifier: key.identifier,
+				Failures:   failure.Failures,
+				Until:      until,
+			})
+		}
+	}
+	return nil
+}
+
+// Success forgets the account's failures. Those of the IP address are
+// kept: one good password must not buy more guesses at other accounts.
+func (t *LoginThrottle) Success(ctx context.Context, email string) error {
+	if t == nil {
+		return nil
+	}
+	return t.repo.Clear(ctx, model.LoginScopeAccount, normalizeEmail(email))
+}
+
+// Unlock lifts a lockout of the account before it runs out.
+func (t *LoginThrottle) Unlock(ctx context.Context, email string, actorID int64) error {
+	if t == nil {
+		return nil
+	}
+
+	identifier := normalizeEmail(email)
+	if err := t.repo.Clear(ctx, model.LoginScopeAccount, identifier); err != nil {
+		return err
+	}
+	t.report(SecurityEvent{Type: EventLoginUnlock, Scope: model.LoginScopeAccount, Identifier: identifier, ActorID: actorID})
+	return nil
+}
+
+// Cleanup deletes counts that can no longer lock anything.
+func (t *LoginThrottle) Cleanup(ctx context.Context) error {
+	now := time.Now()
+	_, err := t.repo.DeleteExpired(ctx, now, now.Add(-t.policy.Window))
+	return err
+}
+
+// RunCleanup calls Cleanup every interval until ctx is done. Errors are
+// passed to onError, which may be nil.
+func (t *LoginThrottle) RunCleanup(ctx context.Context, interval time.Duration, onError func(error)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := t.Cleanup(ctx); err != nil && onError != nil {
+				onError(err)
+			}
+		}
+	}
+}
+
+// backoff is the wait after the given number of failures: BackoffBase
+// after the first, doubling with each one after that.
+func (t *LoginThrottle) backoff(failures int) time.Duration {
+	wait := t.policy.BackoffBase
+	for i := 1; i < failures && wait < t.policy.LockoutDuration; i++ {
+		wait *= 2
+	}
+	return min(wait, t.policy.LockoutDuration)
+}
+
+type throttleKey struct {
+	scope      string
+	identifier string
+	limit      int
+}
+
+func (t *LoginThrottle) keys(email, ip string) []throttleKey {
+	keys := []throttleKey{{model.LoginScopeAccount, normalizeEmail(email), t.policy.MaxFailures}}
+	if ip != "" {
+		keys = append(keys, throttleKey{model.LoginScopeIP, ip, t.policy.MaxFailuresPerIP})
+	}
+	return keys
+}
+
+func (t *LoginThrottle) report(event SecurityEvent) {
+	if t.onEvent != nil {
+		t.onEvent(event)
+	}
+}
+
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
@@ -0,0 +1,301 @@
+package service_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/ahmednurovic/task-manager-api/internal/model"
+	"github.com/ahmednurovic/task-manager-api/internal/service"
+)
+
+type MockLoginFailureRepository struct {
+	mock.Mock
+}
+
+func (m *MockLoginFailureRepository) Get(ctx context.Context, scope, identifier string) (*model.LoginFailure, error) {
+	args := m.Called(ctx, scope, identifier)
+	failure, _ := args.Get(0).(*model.LoginFailure)
+	return failure, args.Error(1)
+}
+
+func (m *MockLoginFailureRepository) RecordFailure(ctx context.Context, scope, identifier string, now, windowStart time.Time) (*model.LoginFailure, error) {
+	args := m.Called(ctx, scope, identifier, now, windowStart)
+	failure, _ := args.Get(0).(*model.LoginFailure)
+	return failure, args.Error(1)
+}
+
+func (m *MockLoginFailureRepository) Lock(ctx context.Context, scope, identifier string, until time.Time) error {
+	args := m.Called(ctx, scope, identifier, until)
+	return args.Error(0)
+}
+
+func (m *MockLoginFailureRepository) Clear(ctx context.Context, scope, identifier string) error {
+	args := m.Called(ctx, scope, identifier)
+	return args.Error(0)
+}
+
+func (m *MockLoginFailureRepository) DeleteExpired(ctx context.Context, now, windowStart time.Time) (int64, error) {
+	args := m.Called(ctx, now, windowStart)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+// lockedFor matches a lock that runs out about d from now.
+func lockedFor(d time.Duration) interface{} {
+	return mock.MatchedBy(func(until time.Time) bool {
+		return until.Sub(time.Now()).Round(time.Second) == d
+	})
+}
+
+var throttlePolicy = service.LoginThrottlePolicy{
+	MaxFailures:      3,
+	MaxFailuresPerIP: 10,
+	Window:           15 * time.Minute,
+	LockoutDuration:  15 * time.Minute,
+	BackoffBase:      time.Second,
+}
+
+func TestLoginThrottleCheck(t *testing.T) {
+	t.Run("No Failures", func(t *testing.T) {
+		mockRepo := new(MockLoginFailureRepository)
+		mockRepo.On("Get", mock.Anything, mock.Anything, mock.Anything).Return(nil, sql.ErrNoRows)
+
+		err := service.NewLoginThrottle(mockRepo, throttlePolicy, nil).Check(context.Background(), "user@example.com", "192.0.2.1")
+
+		assert.NoError(t, err)
+	})
+
+	t.Run("Longest Lock Wins", func(t *testing.T) {
+		accountUntil := time.Now().Add(time.Minute)
+		ipUntil := time.Now().Add(10 * time.Minute)
+		mockRepo := new(MockLoginFailureRepository)
+		mockRepo.On("Get", mock.Anything, model.LoginScopeAccount, "user@example.com").Return(&model.LoginFailure{LockedUntil: &accountUntil}, nil)
+		mockRepo.On("Get", mock.Anything, model.LoginScopeIP, "192.0.2.1").Return(&model.LoginFailure{LockedUntil: &ipUntil}, nil)
+
+		err := service.NewLoginThrottle(mockRepo, throttlePolicy, nil).Check(context.Background(), " User@Example.com", "192.0.2.1")
+
+		var retryErr *service.RetryAfterError
+		assert.True(t, errors.As(err, &retryErr))
+		assert.ErrorIs(t, err, service.ErrTooManyLoginAttempts)
+		assert.InDelta(t, (10 * time.Minute).Seconds(), retryErr.RetryAfter.Seconds(), 1)
+	})
+
+	t.Run("Expired Lock", func(t *testing.T) {
+		until := time.Now().Add(-time.Second)
+		mockRepo := new(MockLoginFailureRepository)
+		mockRepo.On("Get", mock.Anything, mock.Anything, mock.Anything).Return(&model.LoginFailure{Failures: 2, LockedUntil: &until}, nil)
+
+		err := service.NewLoginThrottle(mockRepo, throttlePolicy, nil).Check(context.Background(), "user@example.com", "192.0.2.1")
+
+		assert.NoError(t, err)
+	})
+}
+
+func TestLoginThrottleFailure(t *testing.T) {
+	tests := []struct {
+		name        string
+		failures    int
+		wantLock    time.Duration
+		wantLockout bool
+	}{
+		{"First Failure", 1, time.Second, false},
+		{"Second Failure Doubles", 2, 2 * time.Second, false},
+		{"Limit Locks Out", 3, 15 * time.Minute, true},
+		{"Beyond Limit", 4, 15 * time.Minute, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var events []service.SecurityEvent
+			mockRepo := new(MockLoginFailureRepository)
+			mockRepo.On("RecordFailure", mock.Anything, model.LoginScopeAccount, "user@example.com", mock.Anything, mock.Anything).
+				Return(&model.LoginFailure{Failures: tt.failures}, nil)
+			mockRepo.On("RecordFailure", mock.Anything, model.LoginScopeIP, "192.0.2.1", mock.Anything, mock.Anything).
+				Return(&model.LoginFailure{Failures: tt.failures}, nil)
+			mockRepo.On("Lock", mock.Anything, model.LoginScopeAccount, "user@example.com", lockedFor(tt.wantLock)).Return(nil)
+
+			throttle := service.NewLoginThrottle(mockRepo, throttlePolicy, func(event service.SecurityEvent) {
+				events = append(events, event)
+			})
+			err := throttle.Failure(context.Background(), "User@example.com", "192.0.2.1")
+
+			assert.NoError(t, err)
+			mockRepo.AssertExpectations(t)
+			// The IP address is below its limit, so it does not back off.
+			mockRepo.AssertNotCalled(t, "Lock", mock.Anything, model.LoginScopeIP, mock.Anything, mock.Anything)
+			if tt.wantLockout {
+				assert.Len(t, events, 1)
+				assert.Equal(t, service.EventLoginLockout, events[0].Type)
+				assert.Equal(t, model.LoginScopeAccount, events[0].Scope)
+				assert.Equal(t, "user@example.com", events[0].Identifier)
+				assert.Equal(t, 3, events[0].Failures)
+			} else {
+				assert.Empty(t, events)
+			}
+		})
+	}
+
+	t.Run("IP Limit", func(t *testing.T) {
+		var events []service.SecurityEvent
+		mockRepo := new(MockLoginFailureRepository)
+		mockRepo.On("RecordFailure", mock.Anything, model.LoginScopeAccount, mock.Anything, mock.Anything, mock.Anything).
+			Return(&model.LoginFailure{Failures: 1}, nil)
+		mockRepo.On("RecordFailure", mock.Anything, model.LoginScopeIP, "192.0.2.1", mock.Anything, mock.Anything).
+			Return(&model.LoginFailure{Failures: 10}, nil)
+		mockRepo.On("Lock", mock.Anything, model.LoginScopeAccount, mock.Anything, lockedFor(time.Second)).Return(nil)
+		mockRepo.On("Lock", mock.Anything, model.LoginScopeIP, "192.0.2.1", lockedFor(15*time.Minute)).Return(nil)
+
+		throttle := service.NewLoginThrottle(mockRepo, throttlePolicy, func(event service.SecurityEvent) {
+			events = append(events, event)
+		})
+		err := throttle.Failure(context.Background(), "other@example.com", "192.0.2.1")
+
+		assert.NoError(t, err)
+		mockRepo.AssertExpectations(t)
+		assert.Len(t, events, 1)
+		assert.Equal(t, model.LoginScopeIP, events[0].Scope)
+	})
+
+	t.Run("Backoff Is Capped", func(t *testing.T) {
+		policy := throttlePolicy
+		policy.MaxFailures = 50
+		mockRepo := new(MockLoginFailureRepository)
+		mockRepo.On("RecordFailure", mock.Anything, model.LoginScopeAccount, mock.Anything, mock.Anything, mock.Anything).
+			Return(&model.LoginFailure{Failures: 40}, nil)
+		mockRepo.On("Lock", mock.Anything, model.LoginScopeAccount, mock.Anything, lockedFor(15*time.Minute)).Return(nil)
+
+		err := service.NewLoginThrottle(mockRepo, policy, nil).Failure(context.Background(), "user@example.com", "")
+
+		assert.NoError(t, err)
+		mockRepo.AssertExpectations(t)
+	})
+}
+
+func TestAuthServiceLoginThrottling(t *testing.T) {
+	passwordUser := func(t *testing.T) *model.User {
+		hashedPassword, err := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.MinCost)
+		assert.NoError(t, err)
+		return &model.User{ID: 1, Email: "user@example.com", Password: string(hashedPassword)}
+	}
+	newService := func(userRepo *MockUserRepository, failureRepo *MockLoginFailureRepository) service.AuthServicer {
+		mockRefreshRepo := new(MockRefreshTokenRepository)
+		mockRefreshRepo.On("Create", mock.Anything, mock.Anything).Return(nil).Maybe()
+		throttle := service.NewLoginThrottle(failureRepo, throttlePolicy, nil)
+		return service.NewAuthService(userRepo, mockRefreshRepo, unrevokedStore(), throttle, nil, testKeys, service.AuthPolicy{PasswordHasher: testPasswords})
+	}
+	recordsFailure := func(failureRepo *MockLoginFailureRepository) {
+		failureRepo.On("RecordFailure", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+			Return(&model.LoginFailure{Failures: 1}, nil)
+		failureRepo.On("Lock", mock.Anything, model.LoginScopeAccount, "user@example.com", mock.Anything).Return(nil)
+	}
+
+	t.Run("Success Clears Account Failures", func(t *testing.T) {
+		mockUserRepo := new(MockUserRepository)
+		mockUserRepo.On("GetUserByEmail", mock.Anything, "user@example.com").Return(passwordUser(t), nil)
+		mockFailureRepo := new(MockLoginFailureRepository)
+		mockFailureRepo.On("Get", mock.Anything, mock.Anything, mock.Anything).Return(nil, sql.ErrNoRows)
+		mockFailureRepo.On("Clear", mock.Anything, model.LoginScopeAccount, "user@example.com").Return(nil)
+
+		tokens, err := newService(mockUserRepo, mockFailureRepo).Login(context.Background(), "user@example.com", "password123", "192.0.2.1")
+
+		assert.NoError(t, err)
+		assert.NotEmpty(t, tokens.AccessToken)
+		mockFailureRepo.AssertExpectations(t)
+		mockFailureRepo.AssertNotCalled(t, "Clear", mock.Anything, model.LoginScopeIP, mock.Anything)
+	})
+
+	t.Run("Wrong Password", func(t *testing.T) {
+		mockUserRepo := new(MockUserRepository)
+		mockUserRepo.On("GetUserByEmail", mock.Anything, "user@example.com").Return(passwordUser(t), nil)
+		mockFailureRepo := new(MockLoginFailureRepository)
+		mockFailureRepo.On("Get", mock.Anything, mock.Anything, mock.Anything).Return(nil, sql.ErrNoRows)
+		recordsFailure(mockFailureRepo)
+
+		_, err := newService(mockUserRepo, mockFailureRepo).Login(context.Background(), "user@example.com", "wrong", "192.0.2.1")
+
+		assert.ErrorIs(t, err, service.ErrInvalidCredentials)
+		mockFailureRepo.AssertNumberOfCalls(t, "RecordFailure", 2)
+	})
+
+	t.Run("Unknown User Is Treated Like A Wrong Password", func(t *testing.T) {
+		mockUserRepo := new(MockUserRepository)
+		mockUserRepo.On("GetUserByEmail", mock.Anything, "user@example.com").Return(nil, nil)
+		mockFailureRepo := new(MockLoginFailureRepository)
+		mockFailureRepo.On("Get", mock.Anything, mock.Anything, mock.Anything).Return(nil, sql.ErrNoRows)
+		recordsFailure(mockFailureRepo)
+
+		_, err := newService(mockUserRepo, mockFailureRepo).Login(context.Background(), "user@example.com", "password123", "192.0.2.1")
+
+		assert.ErrorIs(t, err, service.ErrInvalidCredentials)
+		mockFailureRepo.AssertNumberOfCalls(t, "RecordFailure", 2)
+	})
+
+	t.Run("User Without Password", func(t *testing.T) {
+		mockUserRepo := new(MockUserRepository)
+		mockUserRepo.On("GetUserByEmail", mock.Anything, "user@example.com").Return(&model.User{ID: 1, Email: "user@example.com"}, nil)
+		mockFailureRepo := new(MockLoginFailureRepository)
+		mockFailureRepo.On("Get", mock.Anything, mock.Anything, mock.Anything).Return(nil, sql.ErrNoRows)
+		recordsFailure(mockFailureRepo)
+
+		_, err := newService(mockUserRepo, mockFailureRepo).Login(context.Background(), "user@example.com", "", "192.0.2.1")
+
+		assert.ErrorIs(t, err, service.ErrInvalidCredentials)
+	})
+
+	t.Run("Locked Account Is Not Checked", func(t *testing.T) {
+		until := time.Now().Add(10 * time.Minute)
+		mockUserRepo := new(MockUserRepository)
+		mockFailureRepo := new(MockLoginFailureRepository)
+		mockFailureRepo.On("Get", mock.Anything, model.LoginScopeAccount, "user@example.com").Return(&model.LoginFailure{Failures: 3, LockedUntil: &until}, nil)
+		mockFailureRepo.On("Get", mock.Anything, model.LoginScopeIP, "192.0.2.1").Return(nil, sql.ErrNoRows)
+
+		_, err := newService(mockUserRepo, mockFailureRepo).Login(context.Background(), "user@example.com", "password123", "192.0.2.1")
+
+		var retryErr *service.RetryAfterError
+		assert.True(t, errors.As(err, &retryErr))
+		assert.ErrorIs(t, err, service.ErrTooManyLoginAttempts)
+		mockUserRepo.AssertNotCalled(t, "GetUserByEmail", mock.Anything, mock.Anything)
+		mockFailureRepo.AssertNotCalled(t, "RecordFailure", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestAuthServiceUnlockAccount(t *testing.T) {
+	var events []service.SecurityEvent
+	mockFailureRepo := new(MockLoginFailureRepository)
+	mockFailureRepo.On("Clear", mock.Anything, model.LoginScopeAccount, "user@example.com").Return(nil)
+	throttle := service.NewLoginThrottle(mockFailureRepo, throttlePolicy, func(event service.SecurityEvent) {
+		events = append(events, event)
+	})
+	authService := service.NewAuthService(new(MockUserRepository), nil, nil, throttle, nil, testKeys, service.AuthPolicy{})
+
+	err := authService.UnlockAccount(context.Background(), 42, "User@Example.com")
+
+	assert.NoError(t, err)
+	mockFailureRepo.AssertExpectations(t)
+	assert.Equal(t, []service.SecurityEvent{{
+		Type:       service.EventLoginUnlock,
+		Scope:      model.LoginScopeAccount,
+		Identifier: "user@example.com",
+		ActorID:    42,
+	}}, events)
+}
+
+func TestAuthServiceIsAdmin(t *testing.T) {
+	mockUserRepo := new(MockUserRepository)
+	mockUserRepo.On("GetUserByID", mock.Anything, uint(1)).Return(&model.User{ID: 1, IsAdmin: true}, nil)
+	mockUserRepo.On("GetUserByID", mock.Anything, uint(2)).Return(&model.User{ID: 2}, nil)
+	mockUserRepo.On("GetUserByID", mock.Anything, uint(3)).Return(nil, nil)
+	authService := service.NewAuthService(mockUserRepo, nil, nil, nil, nil, testKeys, service.AuthPolicy{})
+
+	for userID, want := range map[uint]bool{1: true, 2: false, 3: false} {
+		isAdmin, err := authService.IsAdmin(context.Background(), userID)
+		assert.NoError(t, err)
+		assert.Equal(t, want, isAdmin, "user %d", userID)
+	}
+}
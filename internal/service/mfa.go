@@ -0,0 +1,397 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/base32"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ahmednurovic/task-manager-api/internal/model"
+	"github.com/ahmednurovic/task-manager-api/internal/totp"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	// mfaSkew is how many 30-second steps a code may be off by, to allow
+	// for the clock on the user's phone being slightly wrong.
+	mfaSkew = 1
+	// mfaChallengeAttempts is how many wrong codes an MFA token may be
+	// tried with before the user has to log in again.
+	mfaChallengeAttempts = 5
+	recoveryCodeCount    = 10
+	mfaTokenType         = "mfa"
+)
+
+// EnrollMFA starts enrolment in two-factor authentication with a new
+// secret. It is only turned on once ConfirmMFA sees a code made with it;
+// calling EnrollMFA again before then replaces the secret.
+func (s *AuthService) EnrollMFA(ctx context.Context, userID int64) (*model.MFAEnrollment, error) {
+	user, err := s.userRepo.GetUserByID(ctx, uint(userID))
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrUnauthorized
+	}
+	if user.MFAEnabledAt != nil {
+		return nil, ErrMFAAlreadyEnabled
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, ErrTokenGeneration
+	}
+
+	encoded := totp.EncodeSecret(secret)
+	err = s.userRepo.SetMFASecret(ctx, user.ID, encoded)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrMFAAlreadyEnabled
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.MFAEnrollment{
+		Secret: encoded,
+		URI:    totp.URI(s.policy.MFAIssuer, user.Email, secret, totp.Defaults),
+	}, nil
+}
+
+// ConfirmMFA turns two-factor authentication on once the user shows a code
+// from their authenticator app, and returns their recovery codes. This is
+// the only time the recovery codes can be seen; only their hashes are kept.
+func (s *AuthService) ConfirmMFA(ctx context.Context, userID int64, code string) ([]string, error) {
+	user, err := s.userRepo.GetUserByID(ctx, uint(userID))
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrUnauthorized
+	}
+	if user.MFAEnabledAt != nil {
+		return nil, ErrMFAAlreadyEnabled
+	}
+	if user.MFASecret == nil {
+		return nil, ErrMFANotEnrolled
+	}
+
+	counter, err := validateTOTP(*user.MFASecret, normalizeMFACode(code))
+	if err != nil {
+		return nil, err
+	}
+
+	codes := make([]string, recoveryCodeCount)
+	hashes := make([]string, recoveryCodeCount)
+	for i := range codes {
+		codes[i], err = newRecoveryCode()
+		if err != nil {
+			return nil, ErrTokenGeneration
+		}
+		hashes[i] = hashToken(codes[i])
+	}
+
+	err = s.userRepo.EnableMFA(ctx, user.ID, counter, hashes)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrMFANotEnrolled
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return codes, nil
+}
+
+// DisableMFA turns two-factor authentication off, or cancels an
+// enrolment that was never confirmed. It needs the user's password, so a
+// stolen access token is not enough to do it. Users without a password,
+// such as those created by single sign-on, give a code from their
+// authenticator app or a recovery code instead.
+//
+// Wrong passwords and codes are counted by the login throttle against the
+// user's email and clientIP, like those given to Login and VerifyMFA.
+func (s *AuthService) DisableMFA(ctx context.Context, userID int64, password, code, clientIP string) error {
+	user, err := s.userRepo.GetUserByID(ctx, uint(userID))
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return ErrUnauthorized
+	}
+	if err := s.throttle.Check(ctx, user.Email, clientIP); err != nil {
+		return err
+	}
+
+	if err := s.checkDisableMFA(ctx, user, password, code); err != nil {
+		if errors.Is(err, ErrInvalidCredentials) || errors.Is(err, ErrInvalidMFACode) {
+			if err := s.throttle.Failure(ctx, user.Email, clientIP); err != nil {
+				return err
+			}
+		}
+		return err
+	}
+
+	return s.userRepo.DisableMFA(ctx, user.ID)
+}
+
+// checkDisableMFA checks that the user may turn two-factor authentication
+// off: with their password, or, without one, with a code.
+func (s *AuthService) checkDisableMFA(ctx context.Context, user *model.User, password, code string) error {
+	if user.Password != "" {
+		if err := s.policy.PasswordHasher.Verify(password, user.Password); err != nil {
+			return ErrInvalidCredentials
+		}
+		if user.MFASecret == nil {
+			return ErrMFANotEnabled
+		}
+		return nil
+	}
+
+	if user.MFASecret == nil {
+		return ErrMFANotEnabled
+	}
+	if normalizeMFACode(code) == "" {
+		return ErrInvalidMFACode
+	}
+	// An enrolment that was never confirmed has no recovery codes, and
+	// nothing to record the code against.
+	if user.MFAEnabledAt == nil {
+		_, err := validateTOTP(*user.MFASecret, normalizeMFACode(code))
+		return err
+	}
+	return s.useMFACode(ctx, user, code)
+}
+
+// VerifyMFA completes a login for a user with two-factor authentication
+// on. It takes the MFA token Login returned, and either a code from the
+// user's authenticator app or one of their recovery codes. Each code works
+// once.
+//
+// Wrong codes are counted by the login throttle against the user's email
+// and clientIP, like wrong passwords, and the account's failures are only
+// forgotten once a code is accepted.
+func (s *AuthService) VerifyMFA(ctx context.Context, mfaToken, code, clientIP string) (*model.TokenPair, error) {
+	challenge, err := s.parseMFAToken(mfaToken)
+	if err != nil {
+		return nil, ErrInvalidMFAToken
+	}
+	if s.mfaAttempts.Exhausted(challenge.ID) {
+		return nil, ErrInvalidMFAToken
+	}
+
+	user, err := s.userRepo.GetUserByID(ctx, challenge.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil || user.MFAEnabledAt == nil || user.MFASecret == nil {
+		return nil, ErrInvalidMFAToken
+	}
+	if s.blocked(user) {
+		return nil, ErrEmailNotVerified
+	}
+	if err := s.throttle.Check(ctx, user.Email, clientIP); err != nil {
+		return nil, err
+	}
+
+	if err := s.useMFACode(ctx, user, code); err != nil {
+		if errors.Is(err, ErrInvalidMFACode) {
+			s.mfaAttempts.Fail(challenge.ID, challenge.ExpiresAt)
+			if err := s.throttle.Failure(ctx, user.Email, clientIP); err != nil {
+				return nil, err
+			}
+		}
+		return nil, err
+	}
+	s.mfaAttempts.Close(challenge.ID, challenge.ExpiresAt)
+	if err := s.throttle.Success(ctx, user.Email); err != nil {
+		return nil, err
+	}
+
+	return s.startSession(ctx, user)
+}
+
+// useMFACode accepts a TOTP code or a recovery code, and records that it
+// has been used.
+func (s *AuthService) useMFACode(ctx context.Context, user *model.User, code string) error {
+	code = normalizeMFACode(code)
+	if !isTOTPCode(code) {
+		err := s.userRepo.UseRecoveryCode(ctx, user.ID, hashToken(normalizeRecoveryCode(code)))
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrInvalidMFACode
+		}
+		return err
+	}
+
+	counter, err := validateTOTP(*user.MFASecret, code)
+	if err != nil {
+		return err
+	}
+
+	// A code for a step at or before the last accepted one has been seen
+	// already, or is older than a code that has.
+	err = s.userRepo.UseMFACounter(ctx, user.ID, counter)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrInvalidMFACode
+	}
+	return err
+}
+
+func validateTOTP(encodedSecret, code string) (uint64, error) {
+	secret, err := totp.DecodeSecret(encodedSecret)
+	if err != nil {
+		return 0, err
+	}
+
+	counter, ok := totp.Validate(secret, code, time.Now(), mfaSkew, totp.Defaults)
+	if !ok {
+		return 0, ErrInvalidMFACode
+	}
+	return counter, nil
+}
+
+// normalizeMFACode drops the spaces some apps show in the middle of codes.
+func normalizeMFACode(code string) string {
+	return strings.ReplaceAll(strings.TrimSpace(code), " ", "")
+}
+
+func isTOTPCode(code string) bool {
+	if len(code) != totp.Defaults.Digits {
+		return false
+	}
+	for _, r := range code {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+var recoveryCodeEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// newRecoveryCode returns a random code like "k3vq-9xzt": 40 bits, which
+// is plenty for a code that works once and only after the password.
+func newRecoveryCode() (string, error) {
+	code, err := randomToken(5, recoveryCodeEncoding.EncodeToString)
+	if err != nil {
+		return "", err
+	}
+	code = strings.ToLower(code)
+	return code[:4] + "-" + code[4:], nil
+}
+
+// normalizeRecoveryCode accepts a recovery code typed in any case, with or
+// without its dash.
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(strings.ReplaceAll(code, "-", ""))
+	if len(code) == 8 {
+		code = code[:4] + "-" + code[4:]
+	}
+	return code
+}
+
+// mfaChallenge is what an MFA token proves: that the user got their
+// password right at Login, recently.
+type mfaChallenge struct {
+	ID        string
+	UserID    uint
+	ExpiresAt time.Time
+}
+
+// newMFAToken issues the token Login returns in place of real tokens to a
+// user with two-factor authentication on.
+func (s *AuthService) newMFAToken(user *model.User) (string, time.Time, error) {
+	jti, err := randomToken(16, hex.EncodeToString)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	expiresAt := time.Now().Add(s.policy.MFAChallengeTTL).UTC().Truncate(time.Second)
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"typ":     mfaTokenType,
+		"user_id": user.ID,
+		"jti":     jti,
+		"exp":     jwt.NewNumericDate(expiresAt),
+	})
+	signed, err := token.SignedString(s.mfaKey)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	return signed, expiresAt, nil
+}
+
+func (s *AuthService) parseMFAToken(tokenString string) (*mfaChallenge, error) {
+	claims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		return s.mfaKey, nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}), jwt.WithExpirationRequired())
+	if err != nil {
+		return nil, err
+	}
+
+	tokenType, _ := claims["typ"].(string)
+	jti, _ := claims["jti"].(string)
+	userID, _ := claims["user_id"].(float64)
+	expiresAt, err := claims.GetExpirationTime()
+	if err != nil || tokenType != mfaTokenType || jti == "" || userID <= 0 {
+		return nil, ErrInvalidMFAToken
+	}
+
+	return &mfaChallenge{ID: jti, UserID: uint(userID), ExpiresAt: expiresAt.Time}, nil
+}
+
+// attemptCounter counts failures per key until the key expires. Like
+// intervalLimiter it keeps its state in memory, per instance.
+type attemptCounter struct {
+	limit int
+
+	mu       sync.Mutex
+	attempts map[string]attempts
+}
+
+type attempts struct {
+	count     int
+	expiresAt time.Time
+}
+
+func newAttemptCounter(limit int) *attemptCounter {
+	return &attemptCounter{limit: limit, attempts: make(map[string]attempts)}
+}
+
+// Exhausted reports whether key has failed limit times.
+func (a *attemptCounter) Exhausted(key string) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	return a.attempts[key].count >= a.limit
+}
+
+// Fail records a failure for key, which is remembered until expiresAt.
+func (a *attemptCounter) Fail(key string, expiresAt time.Time) {
+	a.add(key, expiresAt, 1)
+}
+
+// Close uses up the remaining attempts for key, so it cannot be used again.
+func (a *attemptCounter) Close(key string, expiresAt time.Time) {
+	a.add(key, expiresAt, a.limit)
+}
+
+func (a *attemptCounter) add(key string, expiresAt time.Time, n int) {
+	now := time.Now()
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	for k, entry := range a.attempts {
+		if !now.Before(entry.expiresAt) {
+			delete(a.attempts, k)
+		}
+	}
+
+	entry := a.attempts[key]
+	entry.count += n
+	entry.expiresAt = expiresAt
+	a.attempts[key] = entry
+}
@@ -0,0 +1,405 @@
+package service_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/ahmednurovic/task-manager-api/internal/model"
+	"github.com/ahmednurovic/task-manager-api/internal/service"
+	"github.com/ahmednurovic/task-manager-api/internal/totp"
+)
+
+const mfaSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func currentCode(t *testing.T) string {
+	secret, err := totp.DecodeSecret(mfaSecret)
+	assert.NoError(t, err)
+	return totp.Generate(secret, time.Now(), totp.Defaults)
+}
+
+// mfaUser is user 1 with a verified email, the password "password123" and
+// two-factor authentication on.
+func mfaUser(t *testing.T) *model.User {
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.MinCost)
+	assert.NoError(t, err)
+
+	secret := mfaSecret
+	now := time.Now().Add(-time.Hour)
+	return &model.User{
+		ID:              1,
+		Email:           "user@example.com",
+		Password:        string(hashedPassword),
+		EmailVerifiedAt: &now,
+		MFASecret:       &secret,
+		MFAEnabledAt:    &now,
+	}
+}
+
+func TestAuthServiceEnrollMFA(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		var storedSecret string
+		mockUserRepo := new(MockUserRepository)
+		mockUserRepo.On("GetUserByID", mock.Anything, uint(1)).Return(&model.User{ID: 1, Email: "user@example.com"}, nil)
+		mockUserRepo.On("SetMFASecret", mock.Anything, uint(1), mock.Anything).
+			Run(func(args mock.Arguments) {
+				storedSecret = args.String(2)
+			}).
+			Return(nil)
+
+		authService := service.NewAuthService(mockUserRepo, nil, nil, nil, nil, testKeys, service.AuthPolicy{MFAIssuer: "Acme"})
+		enrollment, err := authService.EnrollMFA(context.Background(), 1)
+
+		assert.NoError(t, err)
+		assert.Equal(t, storedSecret, enrollment.Secret)
+		assert.Equal(t, "otpauth://totp/Acme:user@example.com?issuer=Acme&secret="+enrollment.Secret, enrollment.URI)
+	})
+
+	t.Run("Already Enabled", func(t *testing.T) {
+		mockUserRepo := new(MockUserRepository)
+		mockUserRepo.On("GetUserByID", mock.Anything, uint(1)).Return(mfaUser(t), nil)
+
+		authService := service.NewAuthService(mockUserRepo, nil, nil, nil, nil, testKeys, service.AuthPolicy{})
+		_, err := authService.EnrollMFA(context.Background(), 1)
+
+		assert.ErrorIs(t, err, service.ErrMFAAlreadyEnabled)
+		mockUserRepo.AssertNotCalled(t, "SetMFASecret", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestAuthServiceConfirmMFA(t *testing.T) {
+	enrolling := func() *model.User {
+		secret := mfaSecret
+		return &model.User{ID: 1, Email: "user@example.com", MFASecret: &secret}
+	}
+
+	t.Run("Valid Code", func(t *testing.T) {
+		var storedHashes []string
+		mockUserRepo := new(MockUserRepository)
+		mockUserRepo.On("GetUserByID", mock.Anything, uint(1)).Return(enrolling(), nil)
+		mockUserRepo.On("EnableMFA", mock.Anything, uint(1), mock.Anything, mock.Anything).
+			Run(func(args mock.Arguments) {
+				storedHashes = args.Get(3).([]string)
+			}).
+			Return(nil)
+
+		authService := service.NewAuthService(mockUserRepo, nil, nil, nil, nil, testKeys, service.AuthPolicy{})
+		codes, err := authService.ConfirmMFA(context.Background(), 1, currentCode(t))
+
+		assert.NoError(t, err)
+		assert.Len(t, codes, 10)
+		assert.Len(t, storedHashes, 10)
+		for i, code := range codes {
+			assert.Regexp(t, `^[a-z2-7]{4}-[a-z2-7]{4}$`, code)
+			assert.Equal(t, sha256Hex(code), storedHashes[i])
+		}
+	})
+
+	t.Run("Invalid Code", func(t *testing.T) {
+		mockUserRepo := new(MockUserRepository)
+		mockUserRepo.On("GetUserByID", mock.Anything, uint(1)).Return(enrolling(), nil)
+
+		authService := service.NewAuthService(mockUserRepo, nil, nil, nil, nil, testKeys, service.AuthPolicy{})
+		_, err := authService.ConfirmMFA(context.Background(), 1, "000000")
+
+		assert.ErrorIs(t, err, service.ErrInvalidMFACode)
+		mockUserRepo.AssertNotCalled(t, "EnableMFA", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Not Enrolled", func(t *testing.T) {
+		mockUserRepo := new(MockUserRepository)
+		mockUserRepo.On("GetUserByID", mock.Anything, uint(1)).Return(&model.User{ID: 1}, nil)
+
+		authService := service.NewAuthService(mockUserRepo, nil, nil, nil, nil, testKeys, service.AuthPolicy{})
+		_, err := authService.ConfirmMFA(context.Background(), 1, "123456")
+
+		assert.ErrorIs(t, err, service.ErrMFANotEnrolled)
+	})
+}
+
+func TestAuthServiceDisableMFA(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		mockUserRepo := new(MockUserRepository)
+		mockUserRepo.On("GetUserByID", mock.Anything, uint(1)).Return(mfaUser(t), nil)
+		mockUserRepo.On("DisableMFA", mock.Anything, uint(1)).Return(nil)
+
+		authService := service.NewAuthService(mockUserRepo, nil, nil, nil, nil, testKeys, service.AuthPolicy{})
+		err := authService.DisableMFA(context.Background(), 1, "password123", "", "192.0.2.1")
+
+		assert.NoError(t, err)
+		mockUserRepo.AssertExpectations(t)
+	})
+
+	t.Run("Wrong Password", func(t *testing.T) {
+		mockUserRepo := new(MockUserRepository)
+		mockUserRepo.On("GetUserByID", mock.Anything, uint(1)).Return(mfaUser(t), nil)
+
+		authService := service.NewAuthService(mockUserRepo, nil, nil, nil, nil, testKeys, service.AuthPolicy{})
+		err := authService.DisableMFA(context.Background(), 1, "wrong-password", "", "192.0.2.1")
+
+		assert.ErrorIs(t, err, service.ErrInvalidCredentials)
+		mockUserRepo.AssertNotCalled(t, "DisableMFA", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Not Enabled", func(t *testing.T) {
+		user := mfaUser(t)
+		user.MFASecret, user.MFAEnabledAt = nil, nil
+		mockUserRepo := new(MockUserRepository)
+		mockUserRepo.On("GetUserByID", mock.Anything, uint(1)).Return(user, nil)
+
+		authService := service.NewAuthService(mockUserRepo, nil, nil, nil, nil, testKeys, service.AuthPolicy{})
+		err := authService.DisableMFA(context.Background(), 1, "password123", "", "192.0.2.1")
+
+		assert.ErrorIs(t, err, service.ErrMFANotEnabled)
+	})
+
+	t.Run("Without Password", func(t *testing.T) {
+		user := mfaUser(t)
+		user.Password = ""
+		mockUserRepo := new(MockUserRepository)
+		mockUserRepo.On("GetUserByID", mock.Anything, uint(1)).Return(user, nil)
+		mockUserRepo.On("UseMFACounter", mock.Anything, uint(1), mock.Anything).Return(nil)
+		mockUserRepo.On("DisableMFA", mock.Anything, uint(1)).Return(nil)
+
+		authService := service.NewAuthService(mockUserRepo, nil, nil, nil, nil, testKeys, service.AuthPolicy{})
+		err := authService.DisableMFA(context.Background(), 1, "", currentCode(t), "192.0.2.1")
+
+		assert.NoError(t, err)
+		mockUserRepo.AssertExpectations(t)
+	})
+
+	t.Run("Without Password Wrong Code", func(t *testing.T) {
+		user := mfaUser(t)
+		user.Password = ""
+		mockUserRepo := new(MockUserRepository)
+		mockUserRepo.On("GetUserByID", mock.Anything, uint(1)).Return(user, nil)
+
+		authService := service.NewAuthService(mockUserRepo, nil, nil, nil, nil, testKeys, service.AuthPolicy{})
+		err := authService.DisableMFA(context.Background(), 1, "", "000000", "192.0.2.1")
+
+		assert.ErrorIs(t, err, service.ErrInvalidMFACode)
+		mockUserRepo.AssertNotCalled(t, "DisableMFA", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Without Password Unconfirmed", func(t *testing.T) {
+		secret := mfaSecret
+		mockUserRepo := new(MockUserRepository)
+		mockUserRepo.On("GetUserByID", mock.Anything, uint(1)).Return(&model.User{ID: 1, Email: "user@example.com", MFASecret: &secret}, nil)
+		mockUserRepo.On("DisableMFA", mock.Anything, uint(1)).Return(nil)
+
+		authService := service.NewAuthService(mockUserRepo, nil, nil, nil, nil, testKeys, service.AuthPolicy{})
+		err := authService.DisableMFA(context.Background(), 1, "", currentCode(t), "192.0.2.1")
+
+		assert.NoError(t, err)
+		mockUserRepo.AssertNotCalled(t, "UseMFACounter", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestAuthServiceDisableMFAThrottling(t *testing.T) {
+	mockUserRepo := new(MockUserRepository)
+	mockUserRepo.On("GetUserByID", mock.Anything, uint(1)).Return(mfaUser(t), nil)
+
+	throttle := service.NewLoginThrottle(memoryLoginFailures{}, service.LoginThrottlePolicy{
+		MaxFailures:     3,
+		BackoffBase:     time.Nanosecond,
+		LockoutDuration: 15 * time.Minute,
+	}, nil)
+	authService := service.NewAuthService(mockUserRepo, nil, nil, throttle, nil, testKeys, service.AuthPolicy{PasswordHasher: testPasswords})
+
+	for i := 0; i < 3; i++ {
+		err := authService.DisableMFA(context.Background(), 1, "wrong-password", "", "192.0.2.1")
+		assert.ErrorIs(t, err, service.ErrInvalidCredentials, "attempt %d", i+1)
+		time.Sleep(time.Millisecond)
+	}
+
+	// The right password is refused too while the account is locked.
+	err := authService.DisableMFA(context.Background(), 1, "password123", "", "192.0.2.1")
+	var retryErr *service.RetryAfterError
+	assert.ErrorAs(t, err, &retryErr)
+	assert.ErrorIs(t, err, service.ErrTooManyLoginAttempts)
+	mockUserRepo.AssertNotCalled(t, "DisableMFA", mock.Anything, mock.Anything)
+}
+
+func TestAuthServiceLoginWithMFA(t *testing.T) {
+	newService := func(t *testing.T) (service.AuthServicer, *MockUserRepository, *MockRefreshTokenRepository) {
+		user := mfaUser(t)
+		mockUserRepo := new(MockUserRepository)
+		mockUserRepo.On("GetUserByEmail", mock.Anything, "user@example.com").Return(user, nil)
+		mockUserRepo.On("GetUserByID", mock.Anything, uint(1)).Return(user, nil)
+		mockRefreshRepo := new(MockRefreshTokenRepository)
+		mockRefreshRepo.On("Create", mock.Anything, mock.Anything).Return(nil).Maybe()
+
+		authService := service.NewAuthService(mockUserRepo, mockRefreshRepo, unrevokedStore(), nil, nil, testKeys, service.AuthPolicy{PasswordHasher: testPasswords})
+		return authService, mockUserRepo, mockRefreshRepo
+	}
+
+	login := func(t *testing.T, authService service.AuthServicer) string {
+		challenge, err := authService.Login(context.Background(), "user@example.com", "password123", "192.0.2.1")
+		assert.NoError(t, err)
+		assert.True(t, challenge.MFARequired)
+		assert.Empty(t, challenge.AccessToken)
+		assert.Empty(t, challenge.RefreshToken)
+		assert.WithinDuration(t, time.Now().Add(service.DefaultMFAChallengeTTL), challenge.ExpiresAt, 2*time.Second)
+		return challenge.MFAToken
+	}
+
+	t.Run("Authenticator Code", func(t *testing.T) {
+		authService, mockUserRepo, mockRefreshRepo := newService(t)
+		mockUserRepo.On("UseMFACounter", mock.Anything, uint(1), mock.Anything).Return(nil)
+
+		mfaToken := login(t, authService)
+		mockRefreshRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+
+		tokens, err := authService.VerifyMFA(context.Background(), mfaToken, currentCode(t), "192.0.2.1")
+
+		assert.NoError(t, err)
+		assert.NotEmpty(t, tokens.AccessToken)
+		assert.NotEmpty(t, tokens.RefreshToken)
+		assert.False(t, tokens.MFARequired)
+		mockRefreshRepo.AssertNumberOfCalls(t, "Create", 1)
+
+		// The same MFA token cannot start a second session.
+		_, err = authService.VerifyMFA(context.Background(), mfaToken, currentCode(t), "192.0.2.1")
+		assert.ErrorIs(t, err, service.ErrInvalidMFAToken)
+	})
+
+	t.Run("Reused Code", func(t *testing.T) {
+		authService, mockUserRepo, _ := newService(t)
+		mockUserRepo.On("UseMFACounter", mock.Anything, uint(1), mock.Anything).Return(sql.ErrNoRows)
+
+		_, err := authService.VerifyMFA(context.Background(), login(t, authService), currentCode(t), "192.0.2.1")
+
+		assert.ErrorIs(t, err, service.ErrInvalidMFACode)
+	})
+
+	t.Run("Recovery Code", func(t *testing.T) {
+		authService, mockUserRepo, _ := newService(t)
+		mockUserRepo.On("UseRecoveryCode", mock.Anything, uint(1), sha256Hex("k3vq-9xzt")).Return(nil)
+
+		tokens, err := authService.VerifyMFA(context.Background(), login(t, authService), " K3VQ9XZT ", "192.0.2.1")
+
+		assert.NoError(t, err)
+		assert.NotEmpty(t, tokens.AccessToken)
+	})
+
+	t.Run("Used Recovery Code", func(t *testing.T) {
+		authService, mockUserRepo, _ := newService(t)
+		mockUserRepo.On("UseRecoveryCode", mock.Anything, uint(1), mock.Anything).Return(sql.ErrNoRows)
+
+		_, err := authService.VerifyMFA(context.Background(), login(t, authService), "k3vq-9xzt", "192.0.2.1")
+
+		assert.ErrorIs(t, err, service.ErrInvalidMFACode)
+	})
+
+	t.Run("Too Many Wrong Codes", func(t *testing.T) {
+		authService, _, _ := newService(t)
+		mfaToken := login(t, authService)
+
+		for i := 0; i < 5; i++ {
+			_, err := authService.VerifyMFA(context.Background(), mfaToken, "000000", "192.0.2.1")
+			assert.ErrorIs(t, err, service.ErrInvalidMFACode)
+		}
+
+		_, err := authService.VerifyMFA(context.Background(), mfaToken, currentCode(t), "192.0.2.1")
+		assert.ErrorIs(t, err, service.ErrInvalidMFAToken)
+	})
+
+	t.Run("Access Token Is Not An MFA Token", func(t *testing.T) {
+		authService, _, _ := newService(t)
+		accessToken, err := service.CreateToken(service.AccessClaims{UserID: 1}, testKeys, time.Now().Add(time.Hour))
+		assert.NoError(t, err)
+
+		_, err = authService.VerifyMFA(context.Background(), accessToken, currentCode(t), "192.0.2.1")
+		assert.ErrorIs(t, err, service.ErrInvalidMFAToken)
+	})
+
+	t.Run("MFA Token Is Not An Access Token", func(t *testing.T) {
+		authService, _, _ := newService(t)
+		mfaToken := login(t, authService)
+
+		_, err := jwt.Parse(mfaToken, func(*jwt.Token) (interface{}, error) {
+			return []byte("test-secret"), nil
+		})
+		assert.ErrorIs(t, err, jwt.ErrTokenSignatureInvalid)
+	})
+}
+
+// memoryLoginFailures is a LoginFailureRepository kept in a map, so that
+// the throttle can be exercised across many logins.
+type memoryLoginFailures map[string]*model.LoginFailure
+
+func (m memoryLoginFailures) Get(ctx context.Context, scope, identifier string) (*model.LoginFailure, error) {
+	failure, ok := m[scope+":"+identifier]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return failure, nil
+}
+
+func (m memoryLoginFailures) RecordFailure(ctx context.Context, scope, identifier string, now, windowStart time.Time) (*model.LoginFailure, error) {
+	failure, ok := m[scope+":"+identifier]
+	if !ok || failure.WindowStartedAt.Before(windowStart) {
+		failure = &model.LoginFailure{Scope: scope, Identifier: identifier, WindowStartedAt: now}
+		m[scope+":"+identifier] = failure
+	}
+	failure.Failures++
+	return failure, nil
+}
+
+func (m memoryLoginFailures) Lock(ctx context.Context, scope, identifier string, until time.Time) error {
+	m[scope+":"+identifier].LockedUntil = &until
+	return nil
+}
+
+func (m memoryLoginFailures) Clear(ctx context.Context, scope, identifier string) error {
+	delete(m, scope+":"+identifier)
+	return nil
+}
+
+func (m memoryLoginFailures) DeleteExpired(ctx context.Context, now, windowStart time.Time) (int64, error) {
+	return 0, nil
+}
+
+func TestAuthServiceMFAThrottling(t *testing.T) {
+	user := mfaUser(t)
+	mockUserRepo := new(MockUserRepository)
+	mockUserRepo.On("GetUserByEmail", mock.Anything, "user@example.com").Return(user, nil)
+	mockUserRepo.On("GetUserByID", mock.Anything, uint(1)).Return(user, nil)
+
+	// Backoff runs out at once, so only the lockout can stop the loop.
+	throttle := service.NewLoginThrottle(memoryLoginFailures{}, service.LoginThrottlePolicy{
+		MaxFailures:     3,
+		BackoffBase:     time.Nanosecond,
+		LockoutDuration: 15 * time.Minute,
+	}, nil)
+	authService := service.NewAuthService(mockUserRepo, nil, unrevokedStore(), throttle, nil, testKeys, service.AuthPolicy{PasswordHasher: testPasswords})
+
+	// Each login gets a fresh MFA token, but the right password no longer
+	// clears the wrong codes given with the tokens before it.
+	var mfaToken string
+	for i := 0; i < 3; i++ {
+		challenge, err := authService.Login(context.Background(), "user@example.com", "password123", "192.0.2.1")
+		if !assert.NoError(t, err, "login %d", i+1) {
+			return
+		}
+		mfaToken = challenge.MFAToken
+
+		_, err = authService.VerifyMFA(context.Background(), mfaToken, "000000", "192.0.2.1")
+		assert.ErrorIs(t, err, service.ErrInvalidMFACode)
+		time.Sleep(time.Millisecond)
+	}
+
+	_, err := authService.Login(context.Background(), "user@example.com", "password123", "192.0.2.1")
+	assert.ErrorIs(t, err, service.ErrTooManyLoginAttempts)
+
+	// The right code is refused too while the account is locked.
+	_, err = authService.VerifyMFA(context.Background(), mfaToken, currentCode(t), "192.0.2.1")
+	assert.ErrorIs(t, err, service.ErrTooManyLoginAttempts)
+	mockUserRepo.AssertNotCalled(t, "UseMFACounter", mock.Anything, mock.Anything, mock.Anything)
+}
@@ -0,0 +1,206 @@
+package service
+
+import (
+	"context"
+	"crypto/subtle"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ahmednurovic/task-manager-api/internal/model"
+	"github.com/ahmednurovic/task-manager-api/internal/oidc"
+	"github.com/ahmednurovic/task-manager-api/internal/repository"
+	"github.com/ahmednurovic/task-manager-api/internal/signing"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	// OIDCStateTTL is how long a user has to sign in at the provider.
+	OIDCStateTTL   = 10 * time.Minute
+	oidcStateType  = "oidc_state"
+	oidcStateScope = "oidc-state"
+)
+
+type OIDCServicer interface {
+	StartOIDC(ctx context.Context, provider string) (authURL, stateToken string, err error)
+	FinishOIDC(ctx context.Context, provider, code, state, stateToken string) (*model.TokenPair, error)
+}
+
+// OIDCService signs users in with OpenID Connect providers. A user is found
+// by the provider's subject, or else by verified email, and is created if
+// there is no such user yet. Tokens are then issued by the AuthService.
+type OIDCService struct {
+	auth         AuthServicer
+	userRepo     repository.UserRepository
+	identityRepo repository.UserIdentityRepository
+	providers    map[string]*oidc.Provider
+	// stateKey signs state tokens, which, like MFA tokens, must never pass
+	// for access tokens.
+	stateKey []byte
+}
+
+func NewOIDCService(
+	auth AuthServicer,
+	userRepo repository.UserRepository,
+	identityRepo repository.UserIdentityRepository,
+	providers map[string]*oidc.Provider,
+	keys *signing.KeySet,
+) *OIDCService {
+	return &OIDCService{
+		auth:         auth,
+		userRepo:     userRepo,
+		identityRepo: identityRepo,
+		providers:    providers,
+		stateKey:     keys.Derive(oidcStateScope),
+	}
+}
+
+// oidcFlow is what the callback needs to know about the login it belongs
+// to. It travels in a signed state token that the handler keeps in a
+// cookie, so the PKCE verifier never passes through the provider.
+type oidcFlow struct {
+	Provider string
+	State    string
+	Nonce    string
+	Verifier string
+}
+
+// StartOIDC returns the provider URL to send the user to, and a state token
+// to give back to FinishOIDC along with the provider's response.
+func (s *OIDCService) StartOIDC(ctx context.Context, provider string) (string, string, error) {
+	p, ok := s.providers[provider]
+	if !ok {
+		return "", "", ErrUnknownProvider
+	}
+
+	flow := oidcFlow{Provider: provider}
+	for _, value := range []*string{&flow.State, &flow.Nonce, &flow.Verifier} {
+		random, err := oidc.NewVerifier()
+		if err != nil {
+			return "", "", ErrTokenGeneration
+		}
+		*value = random
+	}
+
+	authURL, err := p.AuthCodeURL(ctx, flow.State, flow.Nonce, flow.Verifier)
+	if err != nil {
+		return "", "", fmt.Errorf("%w: %v", ErrProviderUnavailable, err)
+	}
+
+	stateToken, err := s.newStateToken(flow)
+	if err != nil {
+		return "", "", ErrTokenGeneration
+	}
+	return authURL, stateToken, nil
+}
+
+// FinishOIDC redeems the code the provider sent the user back with and
+// signs them in.
+func (s *OIDCService) FinishOIDC(ctx context.Context, provider, code, state, stateToken string) (*model.TokenPair, error) {
+	p, ok := s.providers[provider]
+	if !ok {
+		return nil, ErrUnknownProvider
+	}
+
+	flow, err := s.parseStateToken(stateToken)
+	if err != nil || flow.Provider != provider || subtle.ConstantTimeCompare([]byte(flow.State), []byte(state)) != 1 {
+		return nil, ErrInvalidOIDCState
+	}
+
+	claims, err := p.Exchange(ctx, code, flow.Verifier, flow.Nonce)
+	if errors.Is(err, oidc.ErrExchange) || errors.Is(err, oidc.ErrInvalidIDToken) {
+		return nil, fmt.Errorf("%w: %v", ErrOIDCLoginFailed, err)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrProviderUnavailable, err)
+	}
+
+	user, err := s.findOrCreateUser(ctx, provider, claims)
+	if err != nil {
+		return nil, err
+	}
+	return s.auth.SignIn(ctx, user)
+}
+
+func (s *OIDCService) findOrCreateUser(ctx context.Context, provider string, claims *oidc.Claims) (*model.User, error) {
+	identity, err := s.identityRepo.Get(ctx, provider, claims.Subject)
+	if err == nil {
+		user, err := s.userRepo.GetUserByID(ctx, identity.UserID)
+		if err != nil {
+			return nil, err
+		}
+		if user == nil {
+			return nil, ErrOIDCLoginFailed
+		}
+		return user, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+
+	// A new identity is matched by email, which only proves anything if
+	// the provider has checked it.
+	if claims.Email == "" || !claims.EmailVerified {
+		return nil, ErrOIDCEmailNotVerified
+	}
+	identity = &model.UserIdentity{Provider: provider, Subject: claims.Subject, Email: claims.Email}
+
+	user, err := s.userRepo.GetUserByEmail(ctx, claims.Email)
+	if err != nil {
+		return nil, err
+	}
+	if user != nil {
+		// Anyone can register an address they do not own. Linking to such
+		// an account would share it with whoever knows its password.
+		if user.EmailVerifiedAt == nil {
+			return nil, ErrOIDCAccountNotVerified
+		}
+		identity.UserID = user.ID
+		if err := s.identityRepo.Create(ctx, identity); err != nil {
+			return nil, err
+		}
+		return user, nil
+	}
+
+	// New users have no password. They can set one with a password reset.
+	verifiedAt := time.Now().UTC()
+	user = &model.User{Email: claims.Email, EmailVerifiedAt: &verifiedAt}
+	if err := s.identityRepo.CreateWithUser(ctx, user, identity); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+func (s *OIDCService) newStateToken(flow oidcFlow) (string, error) {
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"typ":   oidcStateType,
+		"prv":   flow.Provider,
+		"state": flow.State,
+		"nonce": flow.Nonce,
+		"pkce":  flow.Verifier,
+		"exp":   jwt.NewNumericDate(time.Now().Add(OIDCStateTTL)),
+	})
+	return token.SignedString(s.stateKey)
+}
+
+func (s *OIDCService) parseStateToken(tokenString string) (*oidcFlow, error) {
+	claims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		return s.stateKey, nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}), jwt.WithExpirationRequired())
+	if err != nil {
+		return nil, err
+	}
+
+	tokenType, _ := claims["typ"].(string)
+	flow := &oidcFlow{}
+	flow.Provider, _ = claims["prv"].(string)
+	flow.State, _ = claims["state"].(string)
+	flow.Nonce, _ = claims["nonce"].(string)
+	flow.Verifier, _ = claims["pkce"].(string)
+	if tokenType != oidcStateType || flow.State == "" || flow.Nonce == "" || flow.Verifier == "" {
+		return nil, ErrInvalidOIDCState
+	}
+	return flow, nil
+}
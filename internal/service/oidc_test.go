@@ -0,0 +1,230 @@
+package service_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/ahmednurovic/task-manager-api/internal/model"
+	"github.com/ahmednurovic/task-manager-api/internal/oidc"
+	"github.com/ahmednurovic/task-manager-api/internal/oidc/oidctest"
+	"github.com/ahmednurovic/task-manager-api/internal/service"
+)
+
+type MockUserIdentityRepository struct {
+	mock.Mock
+}
+
+func (m *MockUserIdentityRepository) Get(ctx context.Context, provider, subject string) (*model.UserIdentity, error) {
+	args := m.Called(ctx, provider, subject)
+	identity, _ := args.Get(0).(*model.UserIdentity)
+	return identity, args.Error(1)
+}
+
+func (m *MockUserIdentityRepository) Create(ctx context.Context, identity *model.UserIdentity) error {
+	args := m.Called(ctx, identity)
+	return args.Error(0)
+}
+
+func (m *MockUserIdentityRepository) CreateWithUser(ctx context.Context, user *model.User, identity *model.UserIdentity) error {
+	args := m.Called(ctx, user, identity)
+	return args.Error(0)
+}
+
+type oidcFixture struct {
+	idp          *oidctest.Server
+	userRepo     *MockUserRepository
+	identityRepo *MockUserIdentityRepository
+	refreshRepo  *MockRefreshTokenRepository
+	service      *service.OIDCService
+}
+
+// newOIDCFixture sets up an OIDCService with one provider, "test", backed
+// by a fake identity provider.
+func newOIDCFixture(t *testing.T) *oidcFixture {
+	f := &oidcFixture{
+		idp:          oidctest.NewServer(t),
+		userRepo:     new(MockUserRepository),
+		identityRepo: new(MockUserIdentityRepository),
+		refreshRepo:  new(MockRefreshTokenRepository),
+	}
+	f.refreshRepo.On("Create", mock.Anything, mock.Anything).Return(nil).Maybe()
+
+	authService := service.NewAuthService(f.userRepo, f.refreshRepo, unrevokedStore(), nil, nil, testKeys, service.AuthPolicy{})
+	providers := map[string]*oidc.Provider{"test": oidc.NewProvider(f.idp.Config(), nil)}
+	f.service = service.NewOIDCService(authService, f.userRepo, f.identityRepo, providers, testKeys)
+	return f
+}
+
+// login signs in at the fake provider and finishes the login.
+func (f *oidcFixture) login(t *testing.T) (*model.TokenPair, error) {
+	authURL, stateToken, err := f.service.StartOIDC(context.Background(), "test")
+	assert.NoError(t, err)
+
+	code, state := f.idp.Authorize(t, authURL)
+	return f.service.FinishOIDC(context.Background(), "test", code, state, stateToken)
+}
+
+func verifiedUser(id uint, email string) *model.User {
+	verifiedAt := time.Now().Add(-time.Hour)
+	return &model.User{ID: id, Email: email, EmailVerifiedAt: &verifiedAt}
+}
+
+func TestOIDCServiceFinish(t *testing.T) {
+	t.Run("Known Identity", func(t *testing.T) {
+		f := newOIDCFixture(t)
+		f.idp.SetIdentity(oidctest.Identity{Subject: "sub-1", Email: "new-address@example.com", EmailVerified: true})
+		f.identityRepo.On("Get", mock.Anything, "test", "sub-1").Return(&model.UserIdentity{UserID: 3, Provider: "test", Subject: "sub-1"}, nil)
+		f.userRepo.On("GetUserByID", mock.Anything, uint(3)).Return(verifiedUser(3, "user@example.com"), nil)
+
+		tokens, err := f.login(t)
+
+		assert.NoError(t, err)
+		assert.NotEmpty(t, tokens.AccessToken)
+		assert.NotEmpty(t, tokens.RefreshToken)
+		f.userRepo.AssertNotCalled(t, "GetUserByEmail", mock.Anything, mock.Anything)
+		f.identityRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Links Existing User By Verified Email", func(t *testing.T) {
+		f := newOIDCFixture(t)
+		f.idp.SetIdentity(oidctest.Identity{Subject: "sub-1", Email: "user@example.com", EmailVerified: true})
+		f.identityRepo.On("Get", mock.Anything, "test", "sub-1").Return(nil, sql.ErrNoRows)
+		f.userRepo.On("GetUserByEmail", mock.Anything, "user@example.com").Return(verifiedUser(3, "user@example.com"), nil)
+		f.identityRepo.On("Create", mock.Anything, mock.MatchedBy(func(identity *model.UserIdentity) bool {
+			return identity.UserID == 3 && identity.Provider == "test" && identity.Subject == "sub-1" && identity.Email == "user@example.com"
+		})).Return(nil)
+
+		tokens, err := f.login(t)
+
+		assert.NoError(t, err)
+		assert.NotEmpty(t, tokens.AccessToken)
+		f.identityRepo.AssertExpectations(t)
+	})
+
+	t.Run("Creates New User", func(t *testing.T) {
+		f := newOIDCFixture(t)
+		f.idp.SetIdentity(oidctest.Identity{Subject: "sub-1", Email: "new@example.com", EmailVerified: true})
+		f.identityRepo.On("Get", mock.Anything, "test", "sub-1").Return(nil, sql.ErrNoRows)
+		f.userRepo.On("GetUserByEmail", mock.Anything, "new@example.com").Return(nil, nil)
+		var created *model.User
+		f.identityRepo.On("CreateWithUser", mock.Anything, mock.Anything, mock.MatchedBy(func(identity *model.UserIdentity) bool {
+			return identity.Provider == "test" && identity.Subject == "sub-1"
+		})).
+			Run(func(args mock.Arguments) {
+				created = args.Get(1).(*model.User)
+				created.ID = 9
+			}).
+			Return(nil)
+
+		tokens, err := f.login(t)
+
+		assert.NoError(t, err)
+		assert.NotEmpty(t, tokens.AccessToken)
+		assert.False(t, tokens.ReadOnly)
+		assert.Equal(t, "new@example.com", created.Email)
+		assert.Empty(t, created.Password)
+		assert.NotNil(t, created.EmailVerifiedAt)
+	})
+
+	t.Run("Unverified Existing Account", func(t *testing.T) {
+		f := newOIDCFixture(t)
+		f.identityRepo.On("Get", mock.Anything, "test", mock.Anything).Return(nil, sql.ErrNoRows)
+		f.userRepo.On("GetUserByEmail", mock.Anything, "user@example.com").Return(&model.User{ID: 3, Email: "user@example.com"}, nil)
+
+		_, err := f.login(t)
+
+		assert.ErrorIs(t, err, service.ErrOIDCAccountNotVerified)
+		f.identityRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Email Not Verified By Provider", func(t *testing.T) {
+		f := newOIDCFixture(t)
+		f.idp.SetIdentity(oidctest.Identity{Subject: "sub-1", Email: "user@example.com", EmailVerified: false})
+		f.identityRepo.On("Get", mock.Anything, "test", "sub-1").Return(nil, sql.ErrNoRows)
+
+		_, err := f.login(t)
+
+		assert.ErrorIs(t, err, service.ErrOIDCEmailNotVerified)
+		f.userRepo.AssertNotCalled(t, "GetUserByEmail", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Two-Factor User Gets MFA Challenge", func(t *testing.T) {
+		f := newOIDCFixture(t)
+		f.identityRepo.On("Get", mock.Anything, "test", mock.Anything).Return(&model.UserIdentity{UserID: 1}, nil)
+		f.userRepo.On("GetUserByID", mock.Anything, uint(1)).Return(mfaUser(t), nil)
+
+		tokens, err := f.login(t)
+
+		assert.NoError(t, err)
+		assert.True(t, tokens.MFARequired)
+		assert.NotEmpty(t, tokens.MFAToken)
+		assert.Empty(t, tokens.AccessToken)
+		f.refreshRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+
+	t.Run("State Mismatch", func(t *testing.T) {
+		f := newOIDCFixture(t)
+		authURL, stateToken, err := f.service.StartOIDC(context.Background(), "test")
+		assert.NoError(t, err)
+		code, _ := f.idp.Authorize(t, authURL)
+
+		_, err = f.service.FinishOIDC(context.Background(), "test", code, "forged-state", stateToken)
+
+		assert.ErrorIs(t, err, service.ErrInvalidOIDCState)
+	})
+
+	t.Run("State Token From Another Login", func(t *testing.T) {
+		f := newOIDCFixture(t)
+		authURL, _, err := f.service.StartOIDC(context.Background(), "test")
+		assert.NoError(t, err)
+		_, otherStateToken, err := f.service.StartOIDC(context.Background(), "test")
+		assert.NoError(t, err)
+		code, state := f.idp.Authorize(t, authURL)
+
+		_, err = f.service.FinishOIDC(context.Background(), "test", code, state, otherStateToken)
+
+		assert.ErrorIs(t, err, service.ErrInvalidOIDCState)
+	})
+
+	t.Run("Access Token Is Not A State Token", func(t *testing.T) {
+		f := newOIDCFixture(t)
+		accessToken, err := service.CreateToken(service.AccessClaims{UserID: 1}, testKeys, time.Now().Add(time.Hour))
+		assert.NoError(t, err)
+
+		_, err = f.service.FinishOIDC(context.Background(), "test", "code", "state", accessToken)
+
+		assert.ErrorIs(t, err, service.ErrInvalidOIDCState)
+	})
+
+	t.Run("Rejected By Provider", func(t *testing.T) {
+		f := newOIDCFixture(t)
+		f.idp.Audience = "another-client"
+
+		_, err := f.login(t)
+
+		assert.ErrorIs(t, err, service.ErrOIDCLoginFailed)
+	})
+
+	t.Run("Unknown Provider", func(t *testing.T) {
+		f := newOIDCFixture(t)
+
+		_, _, err := f.service.StartOIDC(context.Background(), "other")
+		assert.ErrorIs(t, err, service.ErrUnknownProvider)
+		_, err = f.service.FinishOIDC(context.Background(), "other", "code", "state", "token")
+		assert.ErrorIs(t, err, service.ErrUnknownProvider)
+	})
+
+	t.Run("Provider Unavailable", func(t *testing.T) {
+		f := newOIDCFixture(t)
+		f.idp.Close()
+
+		_, _, err := f.service.StartOIDC(context.Background(), "test")
+
+		assert.ErrorIs(t, err, service.ErrProviderUnavailable)
+	})
+}
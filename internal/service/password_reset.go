@@ -0,0 +1,121 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/ahmednurovic/task-manager-api/internal/mail"
+	"github.com/ahmednurovic/task-manager-api/internal/model"
+	"github.com/ahmednurovic/task-manager-api/internal/repository"
+)
+
+// ForgotPassword emails a password reset link to the user with the given
+// email. It returns nil whether or not such a user exists, so callers
+// cannot use it to find out which emails have accounts. The link is made
+// and sent in the background, so that the response takes as long either
+// way; failures are passed to OnMailError.
+func (s *AuthService) ForgotPassword(ctx context.Context, email string) error {
+	user, err := s.userRepo.GetUserByEmail(ctx, email)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return nil
+	}
+
+	ctx = context.WithoutCancel(ctx)
+	go func() {
+		if err := s.sendPasswordReset(ctx, user); err != nil && s.policy.OnMailError != nil {
+			s.policy.OnMailError(err)
+		}
+	}()
+	return nil
+}
+
+func (s *AuthService) sendPasswordReset(ctx context.Context, user *model.User) error {
+	token, err := randomToken(32, base64.RawURLEncoding.EncodeToString)
+	if err != nil {
+		return ErrTokenGeneration
+	}
+
+	expiresAt := time.Now().Add(s.policy.PasswordResetTTL).UTC()
+	if err := s.userRepo.CreatePasswordReset(ctx, user.ID, hashToken(token), expiresAt); err != nil {
+		return err
+	}
+
+	link, err := tokenLink(s.policy.PasswordResetURL, token)
+	if err != nil {
+		return err
+	}
+
+	return s.mailer.Send(ctx, mail.Message{
+		To:      user.Email,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf("Someone asked to reset the password for your account.\n\n"+
+			"To choose a new password, open this link within %s:\n\n%s\n\n"+
+			"If this wasn't you, you can ignore this email.\n",
+			s.policy.PasswordResetTTL, link),
+	})
+}
+
+// ResetPassword sets a new password using a token from ForgotPassword. The
+// token works once, and every session the user had is signed out. Using up
+// the token, changing the password and signing out happen together or not
+// at all.
+func (s *AuthService) ResetPassword(ctx context.Context, token, password string) error {
+	if err := s.policy.PasswordPolicy.Check(password); err != nil {
+		return err
+	}
+
+	hashedPassword, err := s.policy.PasswordHasher.Hash(password)
+	if err != nil {
+		return err
+	}
+
+	var userID uint
+	var version int
+	err = s.userRepo.InTx(ctx, func(tx repository.UserTx) error {
+		var err error
+		userID, err = tx.Users.ConsumePasswordReset(ctx, hashToken(token))
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrInvalidResetToken
+		}
+		if err != nil {
+			return err
+		}
+
+		if err := tx.Users.UpdatePassword(ctx, userID, hashedPassword); err != nil {
+			return err
+		}
+
+		version, err = tx.Revocations.IncrementTokenVersion(ctx, userID)
+		if err != nil {
+			return err
+		}
+		return tx.RefreshTokens.RevokeForUser(ctx, userID)
+	})
+	if err != nil {
+		return err
+	}
+
+	s.revocations.cacheTokenVersion(userID, version)
+	return nil
+}
+
+// tokenLink adds a token to a link as the token query parameter.
+func tokenLink(base, token string) (string, error) {
+	link, err := url.Parse(base)
+	if err != nil {
+		return "", fmt.Errorf("invalid link URL: %w", err)
+	}
+
+	query := link.Query()
+	query.Set("token", token)
+	link.RawQuery = query.Encode()
+	return link.String(), nil
+}
@@ -0,0 +1,193 @@
+package service_test
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/ahmednurovic/task-manager-api/internal/mail"
+	"github.com/ahmednurovic/task-manager-api/internal/model"
+	"github.com/ahmednurovic/task-manager-api/internal/password"
+	"github.com/ahmednurovic/task-manager-api/internal/repository"
+	"github.com/ahmednurovic/task-manager-api/internal/service"
+)
+
+var resetLinkPattern = regexp.MustCompile(`https://app\.example\.com/reset\?\S+`)
+
+// outboxMailer passes every message it is given to the channel, so tests
+// can wait for emails sent in the background.
+type outboxMailer chan mail.Message
+
+func (m outboxMailer) Send(ctx context.Context, msg mail.Message) error {
+	m <- msg
+	return nil
+}
+
+// receive waits for a value sent in the background.
+func receive[T any](t *testing.T, ch <-chan T) T {
+	t.Helper()
+	select {
+	case v := <-ch:
+		return v
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for background work")
+		var zero T
+		return zero
+	}
+}
+
+func TestAuthServiceForgotPassword(t *testing.T) {
+	policy := service.AuthPolicy{PasswordResetTTL: 20 * time.Minute, PasswordResetURL: "https://app.example.com/reset?lang=en"}
+
+	t.Run("Known Email", func(t *testing.T) {
+		var storedHash string
+		mockUserRepo := new(MockUserRepository)
+		mockUserRepo.On("GetUserByEmail", mock.Anything, "user@example.com").
+			Return(&model.User{ID: 1, Email: "user@example.com"}, nil)
+		mockUserRepo.On("CreatePasswordReset", mock.Anything, uint(1), mock.Anything, mock.MatchedBy(func(expiresAt time.Time) bool {
+			return expiresAt.Sub(time.Now()) > 19*time.Minute && expiresAt.Sub(time.Now()) <= 20*time.Minute
+		})).
+			Run(func(args mock.Arguments) {
+				storedHash = args.String(2)
+			}).
+			Return(nil)
+
+		outbox := make(outboxMailer, 1)
+		authService := service.NewAuthService(mockUserRepo, nil, nil, nil, outbox, testKeys, policy)
+		err := authService.ForgotPassword(context.Background(), "user@example.com")
+
+		assert.NoError(t, err)
+		msg := receive(t, outbox)
+		mockUserRepo.AssertExpectations(t)
+		assert.Equal(t, "user@example.com", msg.To)
+
+		link, err := url.Parse(resetLinkPattern.FindString(msg.Body))
+		assert.NoError(t, err)
+		assert.Equal(t, "en", link.Query().Get("lang"))
+		token := link.Query().Get("token")
+		assert.NotEmpty(t, token)
+		assert.Equal(t, sha256Hex(token), storedHash)
+	})
+
+	t.Run("Unknown Email", func(t *testing.T) {
+		mockUserRepo := new(MockUserRepository)
+		mockUserRepo.On("GetUserByEmail", mock.Anything, "nobody@example.com").Return(nil, nil)
+
+		var outbox bytes.Buffer
+		authService := service.NewAuthService(mockUserRepo, nil, nil, nil, mail.NewLogMailer(&outbox), testKeys, policy)
+		err := authService.ForgotPassword(context.Background(), "nobody@example.com")
+
+		assert.NoError(t, err)
+		assert.Empty(t, outbox.String())
+		mockUserRepo.AssertNotCalled(t, "CreatePasswordReset", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Mail Failure", func(t *testing.T) {
+		mockUserRepo := new(MockUserRepository)
+		mockUserRepo.On("GetUserByEmail", mock.Anything, "user@example.com").
+			Return(&model.User{ID: 1, Email: "user@example.com"}, nil)
+		mockUserRepo.On("CreatePasswordReset", mock.Anything, uint(1), mock.Anything, mock.Anything).Return(nil)
+
+		mailErrs := make(chan error, 1)
+		policy := policy
+		policy.OnMailError = func(err error) { mailErrs <- err }
+		authService := service.NewAuthService(mockUserRepo, nil, nil, nil, failingMailer{}, testKeys, policy)
+		err := authService.ForgotPassword(context.Background(), "user@example.com")
+
+		// The caller sees what it would for an unknown email; the failure
+		// is only reported.
+		assert.NoError(t, err)
+		assert.Error(t, receive(t, mailErrs))
+	})
+}
+
+func TestAuthServiceResetPassword(t *testing.T) {
+	t.Run("Valid Token", func(t *testing.T) {
+		mockRevocationRepo := new(MockRevocationRepository)
+		mockRevocationRepo.On("IncrementTokenVersion", mock.Anything, uint(1)).Return(1, nil)
+		mockRefreshRepo := new(MockRefreshTokenRepository)
+		mockRefreshRepo.On("RevokeForUser", mock.Anything, uint(1)).Return(nil)
+		mockUserRepo := &MockUserRepository{Tx: repository.UserTx{Revocations: mockRevocationRepo, RefreshTokens: mockRefreshRepo}}
+		mockUserRepo.On("ConsumePasswordReset", mock.Anything, sha256Hex("reset-token")).Return(uint(1), nil)
+		mockUserRepo.On("UpdatePassword", mock.Anything, uint(1), mock.MatchedBy(func(hash string) bool {
+			return strings.HasPrefix(hash, "$argon2id$") && password.DefaultArgon2id.Verify("new-password", hash) == nil
+		})).Return(nil)
+
+		store := service.NewTokenRevocationStore(mockRevocationRepo, time.Minute)
+		authService := service.NewAuthService(mockUserRepo, nil, store, nil, nil, testKeys, service.AuthPolicy{})
+		err := authService.ResetPassword(context.Background(), "reset-token", "new-password")
+
+		assert.NoError(t, err)
+		mockUserRepo.AssertExpectations(t)
+		mockRevocationRepo.AssertExpectations(t)
+		mockRefreshRepo.AssertExpectations(t)
+
+		// The new version is seen at once, without asking the database.
+		version, err := store.TokenVersion(context.Background(), 1)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, version)
+		mockRevocationRepo.AssertNotCalled(t, "GetTokenVersion", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Sign Out Fails", func(t *testing.T) {
+		mockRevocationRepo := new(MockRevocationRepository)
+		mockRevocationRepo.On("IncrementTokenVersion", mock.Anything, uint(1)).Return(1, nil)
+		mockRefreshRepo := new(MockRefreshTokenRepository)
+		mockRefreshRepo.On("RevokeForUser", mock.Anything, uint(1)).Return(sql.ErrConnDone)
+		mockUserRepo := &MockUserRepository{Tx: repository.UserTx{Revocations: mockRevocationRepo, RefreshTokens: mockRefreshRepo}}
+		mockUserRepo.On("ConsumePasswordReset", mock.Anything, sha256Hex("reset-token")).Return(uint(1), nil)
+		mockUserRepo.On("UpdatePassword", mock.Anything, uint(1), mock.Anything).Return(nil)
+		mockRevocationRepo.On("GetTokenVersion", mock.Anything, uint(1)).Return(0, nil)
+
+		store := service.NewTokenRevocationStore(mockRevocationRepo, time.Minute)
+		authService := service.NewAuthService(mockUserRepo, nil, store, nil, nil, testKeys, service.AuthPolicy{})
+		err := authService.ResetPassword(context.Background(), "reset-token", "new-password")
+
+		// The transaction is rolled back, so the version that was bumped
+		// inside it is not cached.
+		assert.ErrorIs(t, err, sql.ErrConnDone)
+		version, err := store.TokenVersion(context.Background(), 1)
+		assert.NoError(t, err)
+		assert.Equal(t, 0, version)
+	})
+
+	t.Run("Invalid Token", func(t *testing.T) {
+		mockUserRepo := new(MockUserRepository)
+		mockUserRepo.On("ConsumePasswordReset", mock.Anything, mock.Anything).Return(uint(0), sql.ErrNoRows)
+
+		authService := service.NewAuthService(mockUserRepo, nil, nil, nil, nil, testKeys, service.AuthPolicy{})
+		err := authService.ResetPassword(context.Background(), "used-token", "new-password")
+
+		assert.ErrorIs(t, err, service.ErrInvalidResetToken)
+		mockUserRepo.AssertNotCalled(t, "UpdatePassword", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Short Password", func(t *testing.T) {
+		mockUserRepo := new(MockUserRepository)
+
+		authService := service.NewAuthService(mockUserRepo, nil, nil, nil, nil, testKeys, service.AuthPolicy{})
+		err := authService.ResetPassword(context.Background(), "reset-token", "short")
+
+		assert.ErrorIs(t, err, service.ErrPasswordTooShort)
+		mockUserRepo.AssertNotCalled(t, "ConsumePasswordReset", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Common Password", func(t *testing.T) {
+		mockUserRepo := new(MockUserRepository)
+		policy := service.AuthPolicy{PasswordPolicy: password.Policy{Common: map[string]struct{}{"password123": {}}}}
+
+		authService := service.NewAuthService(mockUserRepo, nil, nil, nil, nil, testKeys, policy)
+		err := authService.ResetPassword(context.Background(), "reset-token", "Password123")
+
+		assert.ErrorIs(t, err, service.ErrPasswordTooCommon)
+		mockUserRepo.AssertNotCalled(t, "ConsumePasswordReset", mock.Anything, mock.Anything)
+	})
+}
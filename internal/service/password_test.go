@@ -0,0 +1,148 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/ahmednurovic/task-manager-api/internal/mail"
+	"github.com/ahmednurovic/task-manager-api/internal/model"
+	"github.com/ahmednurovic/task-manager-api/internal/password"
+	"github.com/ahmednurovic/task-manager-api/internal/service"
+)
+
+// cheapArgon2id keeps the rehash tests fast.
+var cheapArgon2id = password.Argon2id{Memory: 64, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32}
+
+func TestAuthServiceLoginRehash(t *testing.T) {
+	newService := func(userRepo *MockUserRepository, hasher password.Hasher) service.AuthServicer {
+		mockRefreshRepo := new(MockRefreshTokenRepository)
+		mockRefreshRepo.On("Create", mock.Anything, mock.Anything).Return(nil)
+		return service.NewAuthService(userRepo, mockRefreshRepo, unrevokedStore(), nil, nil, testKeys, service.AuthPolicy{PasswordHasher: hasher})
+	}
+	userWithHash := func(t *testing.T, hasher password.Hasher) *model.User {
+		hash, err := hasher.Hash("password123")
+		require.NoError(t, err)
+		return &model.User{ID: 1, Email: "user@example.com", Password: hash}
+	}
+
+	t.Run("Upgrades Bcrypt To Argon2id", func(t *testing.T) {
+		user := userWithHash(t, password.Bcrypt{Cost: bcrypt.MinCost})
+		oldHash := user.Password
+		mockUserRepo := new(MockUserRepository)
+		mockUserRepo.On("GetUserByEmail", mock.Anything, "user@example.com").Return(user, nil)
+		mockUserRepo.On("RehashPassword", mock.Anything, uint(1), oldHash, mock.MatchedBy(func(hash string) bool {
+			return strings.HasPrefix(hash, "$argon2id$v=19$m=64,t=1,p=1$") && cheapArgon2id.Verify("password123", hash) == nil
+		})).Return(nil)
+
+		hasher := password.Chain(cheapArgon2id, password.Bcrypt{Cost: bcrypt.MinCost})
+		tokens, err := newService(mockUserRepo, hasher).Login(context.Background(), "user@example.com", "password123", "192.0.2.1")
+
+		assert.NoError(t, err)
+		assert.NotEmpty(t, tokens.AccessToken)
+		mockUserRepo.AssertExpectations(t)
+	})
+
+	t.Run("Upgrades Outdated Parameters", func(t *testing.T) {
+		user := userWithHash(t, cheapArgon2id)
+		stronger := cheapArgon2id
+		stronger.Iterations = 2
+		mockUserRepo := new(MockUserRepository)
+		mockUserRepo.On("GetUserByEmail", mock.Anything, "user@example.com").Return(user, nil)
+		mockUserRepo.On("RehashPassword", mock.Anything, uint(1), user.Password, mock.MatchedBy(func(hash string) bool {
+			return strings.HasPrefix(hash, "$argon2id$v=19$m=64,t=2,p=1$")
+		})).Return(nil)
+
+		_, err := newService(mockUserRepo, password.Chain(stronger)).Login(context.Background(), "user@example.com", "password123", "192.0.2.1")
+
+		assert.NoError(t, err)
+		mockUserRepo.AssertExpectations(t)
+	})
+
+	t.Run("Current Hash Is Kept", func(t *testing.T) {
+		user := userWithHash(t, cheapArgon2id)
+		mockUserRepo := new(MockUserRepository)
+		mockUserRepo.On("GetUserByEmail", mock.Anything, "user@example.com").Return(user, nil)
+
+		_, err := newService(mockUserRepo, cheapArgon2id).Login(context.Background(), "user@example.com", "password123", "192.0.2.1")
+
+		assert.NoError(t, err)
+		mockUserRepo.AssertNotCalled(t, "RehashPassword", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Wrong Password Is Not Rehashed", func(t *testing.T) {
+		user := userWithHash(t, password.Bcrypt{Cost: bcrypt.MinCost})
+		mockUserRepo := new(MockUserRepository)
+		mockUserRepo.On("GetUserByEmail", mock.Anything, "user@example.com").Return(user, nil)
+
+		_, err := newService(mockUserRepo, password.Chain(cheapArgon2id, password.Bcrypt{Cost: bcrypt.MinCost})).
+			Login(context.Background(), "user@example.com", "wrong-password", "192.0.2.1")
+
+		assert.ErrorIs(t, err, service.ErrInvalidCredentials)
+		mockUserRepo.AssertNotCalled(t, "RehashPassword", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Failed Rehash Does Not Fail Login", func(t *testing.T) {
+		user := userWithHash(t, password.Bcrypt{Cost: bcrypt.MinCost})
+		mockUserRepo := new(MockUserRepository)
+		mockUserRepo.On("GetUserByEmail", mock.Anything, "user@example.com").Return(user, nil)
+		mockUserRepo.On("RehashPassword", mock.Anything, uint(1), mock.Anything, mock.Anything).Return(errors.New("database is down"))
+
+		tokens, err := newService(mockUserRepo, password.Chain(cheapArgon2id, password.Bcrypt{Cost: bcrypt.MinCost})).
+			Login(context.Background(), "user@example.com", "password123", "192.0.2.1")
+
+		assert.NoError(t, err)
+		assert.NotEmpty(t, tokens.AccessToken)
+	})
+}
+
+func TestAuthServiceRegisterPasswordPolicy(t *testing.T) {
+	policy := service.AuthPolicy{
+		PasswordHasher: cheapArgon2id,
+		PasswordPolicy: password.Policy{MinLength: 10, Common: map[string]struct{}{"password1234": {}}},
+	}
+
+	tests := []struct {
+		name     string
+		password string
+		wantErr  error
+	}{
+		{"Too Short", "secret123", service.ErrPasswordTooShort},
+		{"Too Long", strings.Repeat("a", 129), service.ErrPasswordTooLong},
+		{"Common", "password1234", service.ErrPasswordTooCommon},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockUserRepo := new(MockUserRepository)
+
+			authService := service.NewAuthService(mockUserRepo, nil, nil, nil, nil, testKeys, policy)
+			_, err := authService.Register(context.Background(), "user@example.com", tt.password)
+
+			assert.ErrorIs(t, err, tt.wantErr)
+			mockUserRepo.AssertNotCalled(t, "CreateUser", mock.Anything, mock.Anything)
+		})
+	}
+
+	t.Run("Stores PHC String", func(t *testing.T) {
+		mockUserRepo := new(MockUserRepository)
+		mockUserRepo.On("GetUserByEmail", mock.Anything, "user@example.com").Return(nil, nil)
+		mockUserRepo.On("CreateUser", mock.Anything, mock.MatchedBy(func(user *model.User) bool {
+			return strings.HasPrefix(user.Password, "$argon2id$v=19$m=64,t=1,p=1$") && cheapArgon2id.Verify("correct horse", user.Password) == nil
+		})).Return(nil)
+		mockUserRepo.On("CreateEmailVerification", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+		authService := service.NewAuthService(mockUserRepo, nil, nil, nil, mail.NewLogMailer(io.Discard), testKeys, policy)
+		_, err := authService.Register(context.Background(), "user@example.com", "correct horse")
+
+		assert.NoError(t, err)
+		mockUserRepo.AssertExpectations(t)
+	})
+}
@@ -0,0 +1,165 @@
+package service
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+
+	"github.com/ahmednurovic/task-manager-api/internal/authz"
+	"github.com/ahmednurovic/task-manager-api/internal/model"
+	"github.com/ahmednurovic/task-manager-api/internal/repository"
+	"github.com/gin-gonic/gin"
+)
+
+const maxProjectNameLength = 100
+
+type ProjectService struct {
+	projectRepo repository.ProjectRepository
+	authorizer  *authz.Authorizer
+}
+
+func NewProjectService(projectRepo repository.ProjectRepository, authorizer *authz.Authorizer) *ProjectService {
+	return &ProjectService{projectRepo: projectRepo, authorizer: authorizer}
+}
+
+// CreateProject adds a project to the request's space. A nil position
+// appends the project after the existing ones. In a workspace, managing
+// projects takes an admin.
+func (s *ProjectService) CreateProject(ctx *gin.Context, project *model.Project, position *int, userID int64) error {
+	workspaceID, err := authorize(ctx, s.authorizer, userID, authz.WriteProjects)
+	if err != nil {
+		return err
+	}
+
+	if err := normalizeProject(project); err != nil {
+		return err
+	}
+
+	project.Position = -1
+	if position != nil {
+		if *position < 0 {
+			return ErrInvalidPosition
+		}
+		project.Position = *position
+	}
+
+	project.ID = 0
+	project.UserID = uint(userID)
+	project.WorkspaceID = workspaceID
+	project.ProjectCounts = model.ProjectCounts{}
+
+	return s.projectRepo.Create(ctx, project)
+}
+
+// GetProjects returns the projects in the request's space.
+func (s *ProjectService) GetProjects(ctx *gin.Context, userID int64, includeArchived bool) (*model.ProjectList, error) {
+	workspaceID, err := authorize(ctx, s.authorizer, userID, authz.ReadProjects)
+	if err != nil {
+		return nil, err
+	}
+
+	projects, err := s.projectRepo.List(ctx, userID, workspaceID, includeArchived)
+	if err != nil {
+		return nil, err
+	}
+
+	inbox, err := s.projectRepo.InboxCounts(ctx, userID, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.ProjectList{Inbox: inbox, Items: projects}, nil
+}
+
+func (s *ProjectService) GetProject(ctx *gin.Context, projectID int64, userID int64) (*model.Project, error) {
+	return getProject(ctx, s.projectRepo, s.authorizer, projectID, userID, authz.ReadProjects)
+}
+
+// UpdateProject replaces the client-writable fields of a project. A nil
+// position keeps the current one.
+func (s *ProjectService) UpdateProject(ctx *gin.Context, project *model.Project, position *int, userID int64) error {
+	if err := normalizeProject(project); err != nil {
+		return err
+	}
+
+	existingProject, err := getProject(ctx, s.projectRepo, s.authorizer, int64(project.ID), userID, authz.WriteProjects)
+	if err != nil {
+		return err
+	}
+
+	if position != nil {
+		if *position < 0 {
+			return ErrInvalidPosition
+		}
+		existingProject.Position = *position
+	}
+
+	existingProject.Name = project.Name
+	existingProject.Description = project.Description
+	existingProject.Color = project.Color
+	existingProject.Archived = project.Archived
+
+	if err := s.projectRepo.Update(ctx, existingProject); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrProjectNotFound
+		}
+		return err
+	}
+
+	*project = *existingProject
+	return nil
+}
+
+// DeleteProject removes a project. Its tasks move to the inbox.
+func (s *ProjectService) DeleteProject(ctx *gin.Context, projectID int64, userID int64) error {
+	project, err := getProject(ctx, s.projectRepo, s.authorizer, projectID, userID, authz.WriteProjects)
+	if err != nil {
+		return err
+	}
+
+	if err := s.projectRepo.Delete(ctx, projectID, int64(project.UserID)); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrProjectNotFound
+		}
+		return err
+	}
+
+	return nil
+}
+
+// getProject loads a project the user may perform action on. Projects
+// outside the request's space are hidden behind ErrProjectNotFound.
+func getProject(ctx *gin.Context, projectRepo repository.ProjectRepository, authorizer *authz.Authorizer, projectID int64, userID int64, action authz.Action) (*model.Project, error) {
+	project, err := projectRepo.GetByID(ctx, projectID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrProjectNotFound
+		}
+		return nil, err
+	}
+
+	resource := authz.Resource{Type: "project", ID: project.ID, OwnerID: project.UserID, WorkspaceID: project.WorkspaceID}
+	if err := authorizeResource(ctx, authorizer, userID, authz.ReadProjects, action, resource, ErrProjectNotFound); err != nil {
+		return nil, err
+	}
+
+	return project, nil
+}
+
+func normalizeProject(project *model.Project) error {
+	project.Name = strings.TrimSpace(project.Name)
+	if project.Name == "" {
+		return ErrProjectNameRequired
+	}
+	if len([]rune(project.Name)) > maxProjectNameLength {
+		return ErrProjectNameTooLong
+	}
+
+	color, ok := normalizeColor(project.Color)
+	if !ok {
+		return ErrInvalidProjectColor
+	}
+	project.Color = color
+
+	return nil
+}
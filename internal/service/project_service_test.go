@@ -0,0 +1,171 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/ahmednurovic/task-manager-api/internal/model"
+	"github.com/ahmednurovic/task-manager-api/internal/service"
+)
+
+type MockProjectRepository struct {
+	mock.Mock
+}
+
+func (m *MockProjectRepository) Create(ctx context.Context, project *model.Project) error {
+	args := m.Called(ctx, project)
+	return args.Error(0)
+}
+
+func (m *MockProjectRepository) GetByID(ctx context.Context, projectID int64) (*model.Project, error) {
+	args := m.Called(ctx, projectID)
+	project, _ := args.Get(0).(*model.Project)
+	return project, args.Error(1)
+}
+
+func (m *MockProjectRepository) List(ctx context.Context, userID int64, workspaceID *uint, includeArchived bool) ([]*model.Project, error) {
+	args := m.Called(ctx, userID, workspaceID, includeArchived)
+	projects, _ := args.Get(0).([]*model.Project)
+	return projects, args.Error(1)
+}
+
+func (m *MockProjectRepository) InboxCounts(ctx context.Context, userID int64, workspaceID *uint) (model.ProjectCounts, error) {
+	args := m.Called(ctx, userID, workspaceID)
+	return args.Get(0).(model.ProjectCounts), args.Error(1)
+}
+
+func (m *MockProjectRepository) Update(ctx context.Context, project *model.Project) error {
+	args := m.Called(ctx, project)
+	return args.Error(0)
+}
+
+func (m *MockProjectRepository) Delete(ctx context.Context, projectID int64, userID int64) error {
+	args := m.Called(ctx, projectID, userID)
+	return args.Error(0)
+}
+
+func TestProjectServiceCreateProject(t *testing.T) {
+	t.Run("Appends By Default", func(t *testing.T) {
+		mockRepo := new(MockProjectRepository)
+		mockRepo.On("Create", mock.Anything, mock.MatchedBy(func(project *model.Project) bool {
+			return project.UserID == 1 && project.Position == -1 && project.Color == "#9e9e9e"
+		})).Return(nil)
+
+		projectService := service.NewProjectService(mockRepo, testAuthorizer)
+		err := projectService.CreateProject(newTestContext(), &model.Project{Name: "Website"}, nil, 1)
+
+		assert.NoError(t, err)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Negative Position", func(t *testing.T) {
+		mockRepo := new(MockProjectRepository)
+		position := -2
+
+		projectService := service.NewProjectService(mockRepo, testAuthorizer)
+		err := projectService.CreateProject(newTestContext(), &model.Project{Name: "Website"}, &position, 1)
+
+		assert.ErrorIs(t, err, service.ErrInvalidPosition)
+		mockRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Name Required", func(t *testing.T) {
+		projectService := service.NewProjectService(new(MockProjectRepository), testAuthorizer)
+		err := projectService.CreateProject(newTestContext(), &model.Project{Name: "  "}, nil, 1)
+
+		assert.ErrorIs(t, err, service.ErrProjectNameRequired)
+	})
+}
+
+func TestProjectServiceGetProjects(t *testing.T) {
+	mockRepo := new(MockProjectRepository)
+	mockRepo.On("List", mock.Anything, int64(1), (*uint)(nil), false).
+		Return([]*model.Project{{ID: 3, UserID: 1, Name: "Website"}}, nil)
+	mockRepo.On("InboxCounts", mock.Anything, int64(1), (*uint)(nil)).
+		Return(model.ProjectCounts{OpenCount: 4, DoneCount: 2}, nil)
+
+	projectService := service.NewProjectService(mockRepo, testAuthorizer)
+	projects, err := projectService.GetProjects(newTestContext(), 1, false)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 4, projects.Inbox.OpenCount)
+	assert.Len(t, projects.Items, 1)
+}
+
+func TestProjectServiceUpdateProject(t *testing.T) {
+	t.Run("Keeps Position", func(t *testing.T) {
+		mockRepo := new(MockProjectRepository)
+		mockRepo.On("GetByID", mock.Anything, int64(3)).
+			Return(&model.Project{ID: 3, UserID: 1, Name: "Website", Position: 5}, nil)
+		mockRepo.On("Update", mock.Anything, mock.MatchedBy(func(project *model.Project) bool {
+			return project.Name == "Relaunch" && project.Position == 5 && project.Archived
+		})).Return(nil)
+
+		projectService := service.NewProjectService(mockRepo, testAuthorizer)
+		project := &model.Project{ID: 3, Name: "Relaunch", Archived: true}
+		err := projectService.UpdateProject(newTestContext(), project, nil, 1)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 5, project.Position)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Other User's Project", func(t *testing.T) {
+		mockRepo := new(MockProjectRepository)
+		mockRepo.On("GetByID", mock.Anything, int64(3)).
+			Return(&model.Project{ID: 3, UserID: 2, Name: "Website"}, nil)
+
+		projectService := service.NewProjectService(mockRepo, testAuthorizer)
+		err := projectService.UpdateProject(newTestContext(), &model.Project{ID: 3, Name: "Mine now"}, nil, 1)
+
+		assert.ErrorIs(t, err, service.ErrProjectNotFound)
+		mockRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+	})
+}
+
+func TestTaskServiceProjectOwnership(t *testing.T) {
+	projectID := uint(3)
+
+	t.Run("Create In Other User's Project", func(t *testing.T) {
+		mockTaskRepo := new(MockTaskRepository)
+		mockProjectRepo := new(MockProjectRepository)
+		mockProjectRepo.On("GetByID", mock.Anything, int64(3)).
+			Return(&model.Project{ID: 3, UserID: 2}, nil)
+
+		taskService := service.NewTaskService(mockTaskRepo, mockProjectRepo, unblockedDependencyRepo(), nil, nil, nil, testAuthorizer, service.TaskPolicy{})
+		err := taskService.CreateTask(newTestContext(), &model.Task{Title: "Task", ProjectID: &projectID}, 1)
+
+		assert.ErrorIs(t, err, service.ErrProjectNotFound)
+		mockTaskRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+
+	t.Run("List Other User's Project", func(t *testing.T) {
+		mockTaskRepo := new(MockTaskRepository)
+		mockProjectRepo := new(MockProjectRepository)
+		mockProjectRepo.On("GetByID", mock.Anything, int64(3)).
+			Return(&model.Project{ID: 3, UserID: 2}, nil)
+
+		taskService := service.NewTaskService(mockTaskRepo, mockProjectRepo, unblockedDependencyRepo(), nil, nil, nil, testAuthorizer, service.TaskPolicy{})
+		_, err := taskService.GetTasks(newTestContext(), 1, model.TaskFilter{ProjectID: &projectID})
+
+		assert.ErrorIs(t, err, service.ErrProjectNotFound)
+		mockTaskRepo.AssertNotCalled(t, "List", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("List Inbox", func(t *testing.T) {
+		mockTaskRepo := new(MockTaskRepository)
+		mockTaskRepo.On("List", mock.Anything, int64(1), mock.MatchedBy(func(filter model.TaskFilter) bool {
+			return filter.InboxOnly
+		})).Return(&model.TaskPage{Items: []*model.Task{}}, nil)
+		mockProjectRepo := new(MockProjectRepository)
+
+		taskService := service.NewTaskService(mockTaskRepo, mockProjectRepo, unblockedDependencyRepo(), nil, nil, nil, testAuthorizer, service.TaskPolicy{})
+		_, err := taskService.GetTasks(newTestContext(), 1, model.TaskFilter{InboxOnly: true})
+
+		assert.NoError(t, err)
+		mockProjectRepo.AssertNotCalled(t, "GetByID", mock.Anything, mock.Anything)
+	})
+}
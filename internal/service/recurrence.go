@@ -0,0 +1,151 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ahmednurovic/task-manager-api/internal/model"
+	"github.com/ahmednurovic/task-manager-api/internal/recurrence"
+	"github.com/ahmednurovic/task-manager-api/internal/repository"
+	"github.com/gin-gonic/gin"
+)
+
+const defaultRecurrenceTZ = "UTC"
+
+// normalizeRecurrence validates the recurrence settings of a task and
+// stores its rule in canonical form. A task without a rule gets the default
+// time zone and mode.
+func normalizeRecurrence(task *model.Task) error {
+	task.RecurrenceRule = strings.TrimSpace(task.RecurrenceRule)
+	task.RecurrenceTZ = strings.TrimSpace(task.RecurrenceTZ)
+	if task.RecurrenceTZ == "" {
+		task.RecurrenceTZ = defaultRecurrenceTZ
+	}
+	if task.RecurrenceMode == "" {
+		task.RecurrenceMode = model.RecurFromSchedule
+	}
+
+	if task.RecurrenceRule == "" {
+		return nil
+	}
+
+	rule, err := recurrence.Parse(task.RecurrenceRule)
+	if err != nil {
+		return err
+	}
+	task.RecurrenceRule = rule.String()
+
+	// "Local" would tie the schedule to the server's time zone.
+	if _, err := time.LoadLocation(task.RecurrenceTZ); err != nil || task.RecurrenceTZ == "Local" {
+		return fmt.Errorf("%w: unknown time zone %q", ErrInvalidRecurrence, task.RecurrenceTZ)
+	}
+
+	if !task.RecurrenceMode.Valid() {
+		return fmt.Errorf("%w: mode must be schedule or completion", ErrInvalidRecurrence)
+	}
+
+	if task.DueAt == nil {
+		return fmt.Errorf("%w: recurring tasks need a due date", ErrInvalidRecurrence)
+	}
+
+	return nil
+}
+
+// scheduleNextOccurrence creates the task for the next occurrence of a
+// recurring task that has just been completed, carrying over its assignee,
+// labels and an unticked copy of its checklist, and links it from task. Nothing
+// happens if the series has ended or the next occurrence already exists.
+// Every write goes through taskRepo so that it can share a transaction with
+// the completing update.
+func (s *TaskService) scheduleNextOccurrence(ctx *gin.Context, taskRepo repository.TaskRepository, task *model.Task) error {
+	if task.RecurrenceRule == "" || task.DueAt == nil || task.NextOccurrenceID != nil {
+		return nil
+	}
+
+	nextDueAt, ok, err := nextOccurrence(task)
+	if err != nil || !ok {
+		return err
+	}
+
+	next := &model.Task{
+		UserID:          task.UserID,
+		WorkspaceID:     task.WorkspaceID,
+		AssigneeID:      task.AssigneeID,
+		ProjectID:       task.ProjectID,
+		ParentID:        task.ParentID,
+		Title:           task.Title,
+		Description:     task.Description,
+		Status:          model.StatusPending,
+		Priority:        task.Priority,
+		DueAt:           &nextDueAt,
+		Labels:          []model.Label{},
+		RecurrenceRule:  task.RecurrenceRule,
+		RecurrenceTZ:    task.RecurrenceTZ,
+		RecurrenceMode:  task.RecurrenceMode,
+		RecurrenceStart: task.RecurrenceStart,
+		Occurrence:      task.Occurrence + 1,
+	}
+	if err := taskRepo.Create(ctx, next); err != nil {
+		return err
+	}
+
+	if err := taskRepo.CopyLabelsAndChecklist(ctx, int64(task.ID), int64(next.ID)); err != nil {
+		return err
+	}
+
+	task.NextOccurrenceID = &next.ID
+	return taskRepo.Update(ctx, task)
+}
+
+// nextOccurrence works out the due date that follows a completed task.
+//
+// From the schedule, it is the first occurrence of the original series
+// after the task's due date. From completion, the series restarts on the
+// completion day at the task's usual time of day, and any COUNT limit is
+// reduced by the occurrences already done.
+func nextOccurrence(task *model.Task) (time.Time, bool, error) {
+	rule, err := recurrence.Parse(task.RecurrenceRule)
+	if err != nil {
+		return time.Time{}, false, err
+	}
+
+	loc, err := time.LoadLocation(task.RecurrenceTZ)
+	if err != nil {
+		return time.Time{}, false, err
+	}
+
+	var next time.Time
+	var ok bool
+	switch task.RecurrenceMode {
+	case model.RecurFromCompletion:
+		completedAt := time.Now()
+		if task.CompletedAt != nil {
+			completedAt = *task.CompletedAt
+		}
+
+		if rule.Count > 0 {
+			rule.Count -= task.Occurrence - 1
+			if rule.Count < 2 {
+				return time.Time{}, false, nil
+			}
+		}
+
+		anchor := recurrence.AtWallClock(completedAt, *task.DueAt, loc)
+		next, ok = rule.Next(anchor, anchor, loc)
+
+	default:
+		start := *task.DueAt
+		if task.RecurrenceStart != nil {
+			start = *task.RecurrenceStart
+		}
+
+		after := *task.DueAt
+		if start.After(after) {
+			after = start
+		}
+		next, ok = rule.Next(start, after, loc)
+	}
+
+	return next.UTC(), ok, nil
+}
@@ -0,0 +1,280 @@
+package service_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/ahmednurovic/task-manager-api/internal/model"
+	"github.com/ahmednurovic/task-manager-api/internal/recurrence"
+	"github.com/ahmednurovic/task-manager-api/internal/service"
+)
+
+func TestTaskServiceCreateRecurringTask(t *testing.T) {
+	dueAt := time.Date(2025, 1, 6, 8, 0, 0, 0, time.UTC)
+
+	t.Run("Canonical Rule", func(t *testing.T) {
+		mockRepo := new(MockTaskRepository)
+		mockRepo.On("Create", mock.Anything, mock.Anything).Return(nil)
+
+		taskService := service.NewTaskService(mockRepo, new(MockProjectRepository), unblockedDependencyRepo(), nil, nil, nil, testAuthorizer, service.TaskPolicy{})
+		task := &model.Task{Title: "Standup notes", DueAt: &dueAt, RecurrenceRule: "rrule:freq=weekly;byday=th,mo;interval=1"}
+		err := taskService.CreateTask(newTestContext(), task, 1)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "FREQ=WEEKLY;BYDAY=MO,TH", task.RecurrenceRule)
+		assert.Equal(t, "UTC", task.RecurrenceTZ)
+		assert.Equal(t, model.RecurFromSchedule, task.RecurrenceMode)
+		assert.Equal(t, &dueAt, task.RecurrenceStart)
+		assert.Equal(t, 1, task.Occurrence)
+	})
+
+	t.Run("Invalid Settings", func(t *testing.T) {
+		tests := []struct {
+			name string
+			task model.Task
+		}{
+			{"Invalid Rule", model.Task{DueAt: &dueAt, RecurrenceRule: "FREQ=HOURLY"}},
+			{"Unknown Time Zone", model.Task{DueAt: &dueAt, RecurrenceRule: "FREQ=DAILY", RecurrenceTZ: "Mars/Olympus"}},
+			{"Server Time Zone", model.Task{DueAt: &dueAt, RecurrenceRule: "FREQ=DAILY", RecurrenceTZ: "Local"}},
+			{"Invalid Mode", model.Task{DueAt: &dueAt, RecurrenceRule: "FREQ=DAILY", RecurrenceMode: "whenever"}},
+			{"Missing Due Date", model.Task{RecurrenceRule: "FREQ=DAILY"}},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				mockRepo := new(MockTaskRepository)
+
+				taskService := service.NewTaskService(mockRepo, new(MockProjectRepository), unblockedDependencyRepo(), nil, nil, nil, testAuthorizer, service.TaskPolicy{})
+				task := tt.task
+				task.Title = "Standup notes"
+				err := taskService.CreateTask(newTestContext(), &task, 1)
+
+				assert.ErrorIs(t, err, service.ErrInvalidRecurrence)
+				mockRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+			})
+		}
+	})
+}
+
+func TestTaskServiceCompleteRecurringTask(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	assert.NoError(t, err)
+
+	// Mondays at 09:00 in Berlin, starting on 6 January 2025.
+	start := time.Date(2025, 1, 6, 9, 0, 0, 0, berlin).UTC()
+	weekly := func() *model.Task {
+		return &model.Task{
+			ID: 10, UserID: 1, Title: "Standup notes", Status: model.StatusInProgress, Priority: model.PriorityMedium,
+			DueAt: &start, RecurrenceRule: "FREQ=WEEKLY;BYDAY=MO", RecurrenceTZ: "Europe/Berlin",
+			RecurrenceMode: model.RecurFromSchedule, RecurrenceStart: &start, Occurrence: 1,
+		}
+	}
+	request := func(existing *model.Task) *model.Task {
+		return &model.Task{
+			ID: existing.ID, Title: existing.Title, Status: model.StatusDone, DueAt: existing.DueAt,
+			RecurrenceRule: existing.RecurrenceRule, RecurrenceTZ: existing.RecurrenceTZ, RecurrenceMode: existing.RecurrenceMode,
+		}
+	}
+
+	t.Run("From Schedule", func(t *testing.T) {
+		existing := weekly()
+		mockRepo := new(MockTaskRepository)
+		mockRepo.On("GetByID", mock.Anything, int64(10)).Return(existing, nil)
+		mockRepo.On("Create", mock.Anything, mock.MatchedBy(func(task *model.Task) bool {
+			return task.DueAt.Equal(time.Date(2025, 1, 13, 8, 0, 0, 0, time.UTC)) &&
+				task.Status == model.StatusPending && task.Occurrence == 2 && task.RecurrenceStart.Equal(start) &&
+				task.RecurrenceRule == "FREQ=WEEKLY;BYDAY=MO" && task.UserID == 1
+		})).
+			Run(func(args mock.Arguments) {
+				args.Get(1).(*model.Task).ID = 11
+			}).
+			Return(nil)
+		mockRepo.On("CopyLabelsAndChecklist", mock.Anything, int64(10), int64(11)).Return(nil)
+		// The completion is saved first, then again to link the new task.
+		var links []*uint
+		mockRepo.On("Update", mock.Anything, mock.Anything).
+			Run(func(args mock.Arguments) {
+				links = append(links, args.Get(1).(*model.Task).NextOccurrenceID)
+			}).
+			Return(nil)
+
+		taskService := service.NewTaskService(mockRepo, new(MockProjectRepository), unblockedDependencyRepo(), nil, nil, nil, testAuthorizer, service.TaskPolicy{})
+		task := request(existing)
+		err := taskService.UpdateTask(newTestContext(), task, 1)
+
+		assert.NoError(t, err)
+		assert.Equal(t, uint(11), *task.NextOccurrenceID)
+		if assert.Len(t, links, 2) {
+			assert.Nil(t, links[0])
+			assert.Equal(t, uint(11), *links[1])
+		}
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Update Fails", func(t *testing.T) {
+		existing := weekly()
+		mockRepo := new(MockTaskRepository)
+		mockRepo.On("GetByID", mock.Anything, int64(10)).Return(existing, nil)
+		mockRepo.On("Update", mock.Anything, mock.Anything).Return(errors.New("connection reset"))
+
+		taskService := service.NewTaskService(mockRepo, new(MockProjectRepository), unblockedDependencyRepo(), nil, nil, nil, testAuthorizer, service.TaskPolicy{})
+		task := request(existing)
+		err := taskService.UpdateTask(newTestContext(), task, 1)
+
+		assert.EqualError(t, err, "connection reset")
+		mockRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+		mockRepo.AssertNotCalled(t, "CopyLabelsAndChecklist", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("From Schedule Across Daylight Saving", func(t *testing.T) {
+		// The last Monday before Berlin moves to summer time on 30 March.
+		dueAt := time.Date(2025, 3, 24, 9, 0, 0, 0, berlin).UTC()
+		existing := weekly()
+		existing.DueAt = &dueAt
+		existing.Occurrence = 12
+
+		mockRepo := new(MockTaskRepository)
+		mockRepo.On("GetByID", mock.Anything, int64(10)).Return(existing, nil)
+		mockRepo.On("Create", mock.Anything, mock.MatchedBy(func(task *model.Task) bool {
+			return task.DueAt.Equal(time.Date(2025, 3, 31, 7, 0, 0, 0, time.UTC)) && task.Occurrence == 13
+		})).Return(nil)
+		mockRepo.On("CopyLabelsAndChecklist", mock.Anything, int64(10), mock.Anything).Return(nil)
+		mockRepo.On("Update", mock.Anything, mock.Anything).Return(nil)
+
+		taskService := service.NewTaskService(mockRepo, new(MockProjectRepository), unblockedDependencyRepo(), nil, nil, nil, testAuthorizer, service.TaskPolicy{})
+		err := taskService.UpdateTask(newTestContext(), request(existing), 1)
+
+		assert.NoError(t, err)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("From Completion", func(t *testing.T) {
+		existing := weekly()
+		existing.RecurrenceRule = "FREQ=DAILY;INTERVAL=3"
+		existing.RecurrenceMode = model.RecurFromCompletion
+
+		var created *model.Task
+		mockRepo := new(MockTaskRepository)
+		mockRepo.On("GetByID", mock.Anything, int64(10)).Return(existing, nil)
+		mockRepo.On("Create", mock.Anything, mock.Anything).
+			Run(func(args mock.Arguments) {
+				created = args.Get(1).(*model.Task)
+			}).
+			Return(nil)
+		mockRepo.On("CopyLabelsAndChecklist", mock.Anything, int64(10), mock.Anything).Return(nil)
+		mockRepo.On("Update", mock.Anything, mock.Anything).Return(nil)
+
+		taskService := service.NewTaskService(mockRepo, new(MockProjectRepository), unblockedDependencyRepo(), nil, nil, nil, testAuthorizer, service.TaskPolicy{})
+		task := request(existing)
+		err := taskService.UpdateTask(newTestContext(), task, 1)
+
+		assert.NoError(t, err)
+		if assert.NotNil(t, created) {
+			// Three days after completion, at the usual 09:00 in Berlin.
+			completedOn := recurrence.AtWallClock(*task.CompletedAt, start, berlin)
+			want := time.Date(completedOn.Year(), completedOn.Month(), completedOn.Day()+3, 9, 0, 0, 0, berlin)
+			assert.True(t, want.Equal(*created.DueAt), "got %v, want %v", created.DueAt, want)
+			assert.Equal(t, 2, created.Occurrence)
+		}
+	})
+
+	t.Run("Series Ended By Count", func(t *testing.T) {
+		for _, mode := range []model.RecurrenceMode{model.RecurFromSchedule, model.RecurFromCompletion} {
+			existing := weekly()
+			existing.RecurrenceRule = "FREQ=WEEKLY;BYDAY=MO;COUNT=3"
+			existing.RecurrenceMode = mode
+			existing.Occurrence = 3
+			dueAt := time.Date(2025, 1, 20, 9, 0, 0, 0, berlin).UTC()
+			existing.DueAt = &dueAt
+
+			mockRepo := new(MockTaskRepository)
+			mockRepo.On("GetByID", mock.Anything, int64(10)).Return(existing, nil)
+			mockRepo.On("Update", mock.Anything, mock.Anything).Return(nil)
+
+			taskService := service.NewTaskService(mockRepo, new(MockProjectRepository), unblockedDependencyRepo(), nil, nil, nil, testAuthorizer, service.TaskPolicy{})
+			task := request(existing)
+			err := taskService.UpdateTask(newTestContext(), task, 1)
+
+			assert.NoError(t, err)
+			assert.Nil(t, task.NextOccurrenceID)
+			mockRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+		}
+	})
+
+	t.Run("Series Ended By Until", func(t *testing.T) {
+		existing := weekly()
+		existing.RecurrenceRule = "FREQ=WEEKLY;BYDAY=MO;UNTIL=20250112"
+
+		mockRepo := new(MockTaskRepository)
+		mockRepo.On("GetByID", mock.Anything, int64(10)).Return(existing, nil)
+		mockRepo.On("Update", mock.Anything, mock.Anything).Return(nil)
+
+		taskService := service.NewTaskService(mockRepo, new(MockProjectRepository), unblockedDependencyRepo(), nil, nil, nil, testAuthorizer, service.TaskPolicy{})
+		err := taskService.UpdateTask(newTestContext(), request(existing), 1)
+
+		assert.NoError(t, err)
+		mockRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Next Occurrence Already Exists", func(t *testing.T) {
+		nextID := uint(11)
+		existing := weekly()
+		existing.Status = model.StatusPending
+		existing.NextOccurrenceID = &nextID
+
+		mockRepo := new(MockTaskRepository)
+		mockRepo.On("GetByID", mock.Anything, int64(10)).Return(existing, nil)
+		mockRepo.On("Update", mock.Anything, mock.Anything).Return(nil)
+
+		taskService := service.NewTaskService(mockRepo, new(MockProjectRepository), unblockedDependencyRepo(), nil, nil, nil, testAuthorizer, service.TaskPolicy{})
+		task := request(existing)
+		err := taskService.UpdateTask(newTestContext(), task, 1)
+
+		assert.NoError(t, err)
+		assert.Equal(t, nextID, *task.NextOccurrenceID)
+		mockRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+
+	t.Run("New Rule Restarts Series", func(t *testing.T) {
+		dueAt := time.Date(2025, 2, 3, 9, 0, 0, 0, berlin).UTC()
+		existing := weekly()
+		existing.DueAt = &dueAt
+		existing.Occurrence = 5
+
+		mockRepo := new(MockTaskRepository)
+		mockRepo.On("GetByID", mock.Anything, int64(10)).Return(existing, nil)
+		mockRepo.On("Update", mock.Anything, mock.Anything).Return(nil)
+
+		taskService := service.NewTaskService(mockRepo, new(MockProjectRepository), unblockedDependencyRepo(), nil, nil, nil, testAuthorizer, service.TaskPolicy{})
+		task := request(existing)
+		task.Status = model.StatusInProgress
+		task.RecurrenceRule = "FREQ=MONTHLY;BYDAY=1MO"
+		err := taskService.UpdateTask(newTestContext(), task, 1)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "FREQ=MONTHLY;BYDAY=1MO", task.RecurrenceRule)
+		assert.Equal(t, &dueAt, task.RecurrenceStart)
+		assert.Equal(t, 1, task.Occurrence)
+	})
+
+	t.Run("Removing Rule", func(t *testing.T) {
+		existing := weekly()
+
+		mockRepo := new(MockTaskRepository)
+		mockRepo.On("GetByID", mock.Anything, int64(10)).Return(existing, nil)
+		mockRepo.On("Update", mock.Anything, mock.Anything).Return(nil)
+
+		taskService := service.NewTaskService(mockRepo, new(MockProjectRepository), unblockedDependencyRepo(), nil, nil, nil, testAuthorizer, service.TaskPolicy{})
+		task := request(existing)
+		task.RecurrenceRule = ""
+		err := taskService.UpdateTask(newTestContext(), task, 1)
+
+		assert.NoError(t, err)
+		assert.Nil(t, task.RecurrenceStart)
+		mockRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+}
@@ -0,0 +1,183 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/ahmednurovic/task-manager-api/internal/repository"
+)
+
+const DefaultRevocationCacheTTL = 30 * time.Second
+
+// TokenRevocationStore answers whether an access token has been revoked,
+// caching answers from Postgres in process.
+//
+// A revoked token stays revoked, so revocations are cached until the token
+// expires. "Not revoked" and token versions can change under another
+// instance, so they are only trusted for cacheTTL; a revocation made by
+// this process is seen immediately.
+type TokenRevocationStore struct {
+	repo     repository.RevocationRepository
+	cacheTTL time.Duration
+
+	mu       sync.Mutex
+	revoked  map[string]time.Time
+	checked  map[string]time.Time
+	versions map[uint]cachedTokenVersion
+}
+
+type cachedTokenVersion struct {
+	version  int
+	loadedAt time.Time
+}
+
+func NewTokenRevocationStore(repo repository.RevocationRepository, cacheTTL time.Duration) *TokenRevocationStore {
+	if cacheTTL <= 0 {
+		cacheTTL = DefaultRevocationCacheTTL
+	}
+
+	return &TokenRevocationStore{
+		repo:     repo,
+		cacheTTL: cacheTTL,
+		revoked:  make(map[string]time.Time),
+		checked:  make(map[string]time.Time),
+		versions: make(map[uint]cachedTokenVersion),
+	}
+}
+
+// Revoke rejects the token with the given jti until it expires.
+func (s *TokenRevocationStore) Revoke(ctx context.Context, jti string, userID uint, expiresAt time.Time) error {
+	if err := s.repo.Revoke(ctx, jti, userID, expiresAt); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.revoked[jti] = expiresAt
+	delete(s.checked, jti)
+	return nil
+}
+
+func (s *TokenRevocationStore) IsRevoked(ctx context.Context, jti string) (bool, error) {
+	now := time.Now()
+
+	s.mu.Lock()
+	if _, ok := s.revoked[jti]; ok {
+		s.mu.Unlock()
+		return true, nil
+	}
+	if checkedAt, ok := s.checked[jti]; ok && now.Sub(checkedAt) < s.cacheTTL {
+		s.mu.Unlock()
+		return false, nil
+	}
+	s.mu.Unlock()
+
+	revoked, err := s.repo.IsRevoked(ctx, jti)
+	if err != nil {
+		return false, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if revoked {
+		// The expiry is unknown here; cleanup evicts the entry once the
+		// row is gone from the database.
+		s.revoked[jti] = now.Add(s.cacheTTL)
+	} else {
+		s.checked[jti] = now
+	}
+	return revoked, nil
+}
+
+// TokenVersion returns the user's current token version. Tokens carrying
+// an older version are rejected.
+func (s *TokenRevocationStore) TokenVersion(ctx context.Context, userID uint) (int, error) {
+	now := time.Now()
+
+	s.mu.Lock()
+	cached, ok := s.versions[userID]
+	s.mu.Unlock()
+	if ok && now.Sub(cached.loadedAt) < s.cacheTTL {
+		return cached.version, nil
+	}
+
+	return s.loadTokenVersion(ctx, userID)
+}
+
+func (s *TokenRevocationStore) loadTokenVersion(ctx context.Context, userID uint) (int, error) {
+	version, err := s.repo.GetTokenVersion(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.versions[userID] = cachedTokenVersion{version: version, loadedAt: time.Now()}
+	return version, nil
+}
+
+// BumpTokenVersion rejects every token issued to the user so far.
+func (s *TokenRevocationStore) BumpTokenVersion(ctx context.Context, userID uint) (int, error) {
+	version, err := s.repo.IncrementTokenVersion(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+
+	s.cacheTokenVersion(userID, version)
+	return version, nil
+}
+
+// cacheTokenVersion records a token version the user was just moved to,
+// for bumps made in a transaction outside the store.
+func (s *TokenRevocationStore) cacheTokenVersion(userID uint, version int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.versions[userID] = cachedTokenVersion{version: version, loadedAt: time.Now()}
+}
+
+// Cleanup deletes revocations of tokens that have expired and drops stale
+// cache entries.
+func (s *TokenRevocationStore) Cleanup(ctx context.Context) error {
+	if _, err := s.repo.DeleteExpired(ctx); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for jti, expiresAt := range s.revoked {
+		if !now.Before(expiresAt) {
+			delete(s.revoked, jti)
+		}
+	}
+	for jti, checkedAt := range s.checked {
+		if now.Sub(checkedAt) >= s.cacheTTL {
+			delete(s.checked, jti)
+		}
+	}
+	for userID, cached := range s.versions {
+		if now.Sub(cached.loadedAt) >= s.cacheTTL {
+			delete(s.versions, userID)
+		}
+	}
+	return nil
+}
+
+// RunCleanup calls Cleanup every interval until ctx is done. Errors are
+// passed to onError, which may be nil.
+func (s *TokenRevocationStore) RunCleanup(ctx context.Context, interval time.Duration, onError func(error)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.Cleanup(ctx); err != nil && onError != nil {
+				onError(err)
+			}
+		}
+	}
+}
@@ -0,0 +1,145 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/ahmednurovic/task-manager-api/internal/service"
+)
+
+type MockRevocationRepository struct {
+	mock.Mock
+}
+
+func (m *MockRevocationRepository) Revoke(ctx context.Context, jti string, userID uint, expiresAt time.Time) error {
+	args := m.Called(ctx, jti, userID, expiresAt)
+	return args.Error(0)
+}
+
+func (m *MockRevocationRepository) IsRevoked(ctx context.Context, jti string) (bool, error) {
+	args := m.Called(ctx, jti)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockRevocationRepository) DeleteExpired(ctx context.Context) (int64, error) {
+	args := m.Called(ctx)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockRevocationRepository) GetTokenVersion(ctx context.Context, userID uint) (int, error) {
+	args := m.Called(ctx, userID)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockRevocationRepository) IncrementTokenVersion(ctx context.Context, userID uint) (int, error) {
+	args := m.Called(ctx, userID)
+	return args.Int(0), args.Error(1)
+}
+
+func TestTokenRevocationStore(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Caches Unrevoked Tokens For TTL", func(t *testing.T) {
+		mockRepo := new(MockRevocationRepository)
+		mockRepo.On("IsRevoked", mock.Anything, "token-id").Return(false, nil).Twice()
+
+		store := service.NewTokenRevocationStore(mockRepo, 50*time.Millisecond)
+		for i := 0; i < 3; i++ {
+			revoked, err := store.IsRevoked(ctx, "token-id")
+			assert.NoError(t, err)
+			assert.False(t, revoked)
+		}
+		mockRepo.AssertNumberOfCalls(t, "IsRevoked", 1)
+
+		time.Sleep(60 * time.Millisecond)
+		revoked, err := store.IsRevoked(ctx, "token-id")
+		assert.NoError(t, err)
+		assert.False(t, revoked)
+		mockRepo.AssertNumberOfCalls(t, "IsRevoked", 2)
+	})
+
+	t.Run("Caches Revoked Tokens", func(t *testing.T) {
+		mockRepo := new(MockRevocationRepository)
+		mockRepo.On("IsRevoked", mock.Anything, "token-id").Return(true, nil).Once()
+
+		store := service.NewTokenRevocationStore(mockRepo, time.Minute)
+		for i := 0; i < 3; i++ {
+			revoked, err := store.IsRevoked(ctx, "token-id")
+			assert.NoError(t, err)
+			assert.True(t, revoked)
+		}
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Revoke Overrides Cached Answer", func(t *testing.T) {
+		expiresAt := time.Now().Add(time.Hour)
+		mockRepo := new(MockRevocationRepository)
+		mockRepo.On("IsRevoked", mock.Anything, "token-id").Return(false, nil).Once()
+		mockRepo.On("Revoke", mock.Anything, "token-id", uint(1), expiresAt).Return(nil)
+
+		store := service.NewTokenRevocationStore(mockRepo, time.Minute)
+		revoked, _ := store.IsRevoked(ctx, "token-id")
+		assert.False(t, revoked)
+
+		assert.NoError(t, store.Revoke(ctx, "token-id", 1, expiresAt))
+		revoked, err := store.IsRevoked(ctx, "token-id")
+		assert.NoError(t, err)
+		assert.True(t, revoked)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Lookup Error", func(t *testing.T) {
+		mockRepo := new(MockRevocationRepository)
+		mockRepo.On("IsRevoked", mock.Anything, "token-id").Return(false, errors.New("connection refused"))
+
+		store := service.NewTokenRevocationStore(mockRepo, time.Minute)
+		_, err := store.IsRevoked(ctx, "token-id")
+
+		assert.Error(t, err)
+	})
+
+	t.Run("Token Versions", func(t *testing.T) {
+		mockRepo := new(MockRevocationRepository)
+		mockRepo.On("GetTokenVersion", mock.Anything, uint(1)).Return(2, nil).Once()
+		mockRepo.On("IncrementTokenVersion", mock.Anything, uint(1)).Return(3, nil).Once()
+
+		store := service.NewTokenRevocationStore(mockRepo, time.Minute)
+		version, err := store.TokenVersion(ctx, 1)
+		assert.NoError(t, err)
+		assert.Equal(t, 2, version)
+		version, _ = store.TokenVersion(ctx, 1)
+		assert.Equal(t, 2, version)
+
+		version, err = store.BumpTokenVersion(ctx, 1)
+		assert.NoError(t, err)
+		assert.Equal(t, 3, version)
+		version, _ = store.TokenVersion(ctx, 1)
+		assert.Equal(t, 3, version)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Cleanup", func(t *testing.T) {
+		mockRepo := new(MockRevocationRepository)
+		mockRepo.On("Revoke", mock.Anything, mock.Anything, uint(1), mock.Anything).Return(nil)
+		mockRepo.On("DeleteExpired", mock.Anything).Return(int64(1), nil)
+		mockRepo.On("IsRevoked", mock.Anything, "expired").Return(false, nil)
+
+		store := service.NewTokenRevocationStore(mockRepo, time.Minute)
+		assert.NoError(t, store.Revoke(ctx, "expired", 1, time.Now().Add(-time.Second)))
+		assert.NoError(t, store.Revoke(ctx, "live", 1, time.Now().Add(time.Hour)))
+
+		assert.NoError(t, store.Cleanup(ctx))
+		mockRepo.AssertCalled(t, "DeleteExpired", mock.Anything)
+
+		revoked, _ := store.IsRevoked(ctx, "live")
+		assert.True(t, revoked)
+		revoked, _ = store.IsRevoked(ctx, "expired")
+		assert.False(t, revoked)
+		mockRepo.AssertCalled(t, "IsRevoked", mock.Anything, "expired")
+	})
+}
@@ -1,24 +1,115 @@
 package service
 
 import (
+	"database/sql"
 	"errors"
+	"fmt"
+	"strings"
+	"time"
 
+	"github.com/ahmednurovic/task-manager-api/internal/authz"
 	"github.com/ahmednurovic/task-manager-api/internal/model"
 	"github.com/ahmednurovic/task-manager-api/internal/repository"
 	"github.com/gin-gonic/gin"
 )
 
+// DefaultMaxSubtaskDepth is used when a TaskPolicy leaves MaxSubtaskDepth
+// unset.
+const DefaultMaxSubtaskDepth = 5
+
+// TaskPolicy holds the configurable rules for task hierarchies.
+type TaskPolicy struct {
+	// RequireSubtasksClosed stops a task from being marked done while any
+	// subtask below it is still open.
+	RequireSubtasksClosed bool
+	// MaxSubtaskDepth is how deeply subtasks may nest: 1 allows subtasks
+	// but no sub-subtasks.
+	MaxSubtaskDepth int
+}
+
 type TaskService struct {
-	taskRepo repository.TaskRepository
+	taskRepo       repository.TaskRepository
+	projectRepo    repository.ProjectRepository
+	dependencyRepo repository.DependencyRepository
+	workspaceRepo  repository.WorkspaceRepository
+	activityRepo   repository.ActivityRepository
+	labelRepo      repository.LabelRepository
+	authorizer     *authz.Authorizer
+	policy         TaskPolicy
 }
 
-func NewTaskService(taskRepo repository.TaskRepository) *TaskService {
-	return &TaskService{taskRepo: taskRepo}
+func NewTaskService(
+	taskRepo repository.TaskRepository,
+	projectRepo repository.ProjectRepository,
+	dependencyRepo repository.DependencyRepository,
+	workspaceRepo repository.WorkspaceRepository,
+	activityRepo repository.ActivityRepository,
+	labelRepo repository.LabelRepository,
+	authorizer *authz.Authorizer,
+	policy TaskPolicy,
+) *TaskService {
+	if policy.MaxSubtaskDepth <= 0 {
+		policy.MaxSubtaskDepth = DefaultMaxSubtaskDepth
+	}
+	return &TaskService{
+		taskRepo:       taskRepo,
+		projectRepo:    projectRepo,
+		dependencyRepo: dependencyRepo,
+		workspaceRepo:  workspaceRepo,
+		activityRepo:   activityRepo,
+		labelRepo:      labelRepo,
+		authorizer:     authorizer,
+		policy:         policy,
+	}
 }
 
-func (s *TaskService) CreateTask(ctx *gin.Context, task *model.Task) error {
-	if task.Title == "" {
-		return errors.New("title is required")
+// CreateTask adds a task to the request's space: the user's personal
+// space, or a workspace where the user is at least a member.
+func (s *TaskService) CreateTask(ctx *gin.Context, task *model.Task, userID int64) error {
+	workspaceID, err := authorize(ctx, s.authorizer, userID, authz.WriteTasks)
+	if err != nil {
+		return err
+	}
+
+	if task.Status == "" {
+		task.Status = model.StatusPending
+	}
+	if task.Priority == "" {
+		task.Priority = model.PriorityMedium
+	}
+
+	if err := validateTask(task); err != nil {
+		return err
+	}
+
+	if err := normalizeRecurrence(task); err != nil {
+		return err
+	}
+
+	if err := s.checkProject(ctx, task.ProjectID, userID); err != nil {
+		return err
+	}
+
+	task.ID = 0
+	if err := s.checkParent(ctx, task, userID); err != nil {
+		return err
+	}
+
+	task.UserID = uint(userID)
+	task.WorkspaceID = workspaceID
+	task.Labels = []model.Label{}
+	task.Progress = model.TaskProgress{}
+	task.CompletedAt = nil
+	if task.Status == model.StatusDone {
+		now := time.Now().UTC()
+		task.CompletedAt = &now
+	}
+
+	task.RecurrenceStart = nil
+	task.Occurrence = 1
+	task.NextOccurrenceID = nil
+	if task.RecurrenceRule != "" {
+		task.RecurrenceStart = task.DueAt
 	}
 
 	if err := s.taskRepo.Create(ctx, task); err != nil {
@@ -28,43 +119,567 @@ func (s *TaskService) CreateTask(ctx *gin.Context, task *model.Task) error {
 	return nil
 }
 
-func (s *TaskService) GetTasks(ctx *gin.Context, userID int64) ([]*model.Task, error) {
-	tasks, err := s.taskRepo.GetAllForUser(ctx, userID)
+// GetTask returns a task in the request's space with its subtask and
+// checklist progress.
+func (s *TaskService) GetTask(ctx *gin.Context, taskID int64, userID int64) (*model.Task, error) {
+	return getTask(ctx, s.taskRepo, s.authorizer, taskID, userID, authz.ReadTasks)
+}
+
+const (
+	defaultTaskPageSize = 50
+	maxTaskPageSize     = 200
+)
+
+// GetTasks returns one page of the tasks in the request's space. Unset sort
+// options default to the newest tasks first.
+func (s *TaskService) GetTasks(ctx *gin.Context, userID int64, filter model.TaskFilter) (*model.TaskPage, error) {
+	if filter.Sort == "" {
+		filter.Sort = model.SortByCreatedAt
+	}
+	if filter.Order == "" {
+		filter.Order = model.SortDesc
+	}
+	if filter.Limit == 0 {
+		filter.Limit = defaultTaskPageSize
+	}
+	if filter.LabelMatch == "" {
+		filter.LabelMatch = model.LabelMatchAny
+	}
+
+	if err := validateTaskFilter(filter); err != nil {
+		return nil, err
+	}
+
+	workspaceID, err := authorize(ctx, s.authorizer, userID, authz.ReadTasks)
+	if err != nil {
+		return nil, err
+	}
+	filter.WorkspaceID = workspaceID
+
+	if err := s.checkLabelFilter(ctx, filter.LabelIDs, userID); err != nil {
+		return nil, err
+	}
+
+	if !filter.InboxOnly {
+		if err := s.checkProject(ctx, filter.ProjectID, userID); err != nil {
+			return nil, err
+		}
+	}
+
+	page, err := s.taskRepo.List(ctx, userID, filter)
+	if err != nil {
+		if errors.Is(err, repository.ErrInvalidCursor) {
+			return nil, fmt.Errorf("%w: invalid cursor", ErrInvalidFilter)
+		}
+		return nil, err
+	}
+	keepOwnLabels(userID, page.Items...)
+	return page, nil
+}
+
+const (
+	defaultSearchLimit = 20
+	maxSearchLimit     = 100
+)
+
+// SearchTasks runs a full-text search over the titles and descriptions of
+// the tasks in the request's space, best matches first.
+func (s *TaskService) SearchTasks(ctx *gin.Context, userID int64, query string, limit int) (*model.TaskSearchResults, error) {
+	if strings.TrimSpace(query) == "" {
+		return nil, ErrSearchQueryRequired
+	}
+
+	if limit == 0 {
+		limit = defaultSearchLimit
+	}
+	if limit < 1 || limit > maxSearchLimit {
+		return nil, fmt.Errorf("%w: limit must be between 1 and %d", ErrInvalidFilter, maxSearchLimit)
+	}
+
+	workspaceID, err := authorize(ctx, s.authorizer, userID, authz.ReadTasks)
+	if err != nil {
+		return nil, err
+	}
+
+	results, err := s.taskRepo.Search(ctx, userID, workspaceID, query, limit)
 	if err != nil {
 		return nil, err
 	}
-	return tasks, nil
+	for _, result := range results {
+		keepOwnLabels(userID, result.Task)
+	}
+
+	return &model.TaskSearchResults{Items: results}, nil
 }
 
-func (s *TaskService) UpdateTask(ctx *gin.Context, task *model.Task) error {
-	existingTask, err := s.taskRepo.GetByID(ctx, int64(task.ID))
+// UpdateTask replaces the client-writable fields of a task. An empty status
+// or priority keeps the current value; a status change must be allowed by
+// the workflow, and a task cannot be started or finished while any of its
+// blockers are still open.
+//
+// Completing a recurring task creates its next occurrence.
+//
+// Cancelling a task also cancels its open subtasks at every depth. Marking
+// it done leaves subtasks alone unless the policy requires them to be
+// closed first.
+func (s *TaskService) UpdateTask(ctx *gin.Context, task *model.Task, userID int64) error {
+	existingTask, err := getTask(ctx, s.taskRepo, s.authorizer, int64(task.ID), userID, authz.WriteTasks)
 	if err != nil {
-		return ErrTaskNotFound
+		return err
+	}
+
+	if task.Status == "" {
+		task.Status = existingTask.Status
+	}
+	if task.Priority == "" {
+		task.Priority = existingTask.Priority
+	}
+
+	if err := validateTask(task); err != nil {
+		return err
 	}
 
+	if err := normalizeRecurrence(task); err != nil {
+		return err
+	}
+
+	if !existingTask.Status.CanTransitionTo(task.Status) {
+		return ErrInvalidTransition
+	}
+
+	if err := s.checkProject(ctx, task.ProjectID, userID); err != nil {
+		return err
+	}
+
+	if !sameID(existingTask.ParentID, task.ParentID) {
+		if err := s.checkParent(ctx, task, userID); err != nil {
+			return err
+		}
+	}
+
+	if task.Status != existingTask.Status &&
+		(task.Status == model.StatusInProgress || task.Status == model.StatusDone) {
+		openCount, err := s.dependencyRepo.CountOpenBlockers(ctx, int64(existingTask.ID))
+		if err != nil {
+			return err
+		}
+		if openCount > 0 {
+			return ErrBlockedByOpenTasks
+		}
+	}
+
+	wasDone := existingTask.Status == model.StatusDone
+	cancelling := task.Status == model.StatusCancelled && existingTask.Status != model.StatusCancelled
+
+	if task.Status == model.StatusDone && !wasDone && s.policy.RequireSubtasksClosed {
+		openCount, err := s.taskRepo.CountOpenDescendants(ctx, int64(existingTask.ID))
+		if err != nil {
+			return err
+		}
+		if openCount > 0 {
+			return ErrOpenSubtasks
+		}
+	}
+
+	existingTask.ProjectID = task.ProjectID
+	existingTask.ParentID = task.ParentID
 	existingTask.Title = task.Title
+	existingTask.Description = task.Description
 	existingTask.Status = task.Status
+	existingTask.Priority = task.Priority
+	existingTask.DueAt = task.DueAt
+
+	// A new rule or time zone starts a new series from the current due date.
+	if task.RecurrenceRule != existingTask.RecurrenceRule || task.RecurrenceTZ != existingTask.RecurrenceTZ ||
+		(task.RecurrenceRule != "" && existingTask.RecurrenceStart == nil) {
+		existingTask.RecurrenceStart = nil
+		existingTask.Occurrence = 1
+		if task.RecurrenceRule != "" {
+			existingTask.RecurrenceStart = task.DueAt
+		}
+	}
+	existingTask.RecurrenceRule = task.RecurrenceRule
+	existingTask.RecurrenceTZ = task.RecurrenceTZ
+	existingTask.RecurrenceMode = task.RecurrenceMode
+
+	completing := task.Status == model.StatusDone && !wasDone
+	switch {
+	case completing:
+		now := time.Now().UTC()
+		existingTask.CompletedAt = &now
+	case task.Status != model.StatusDone:
+		existingTask.CompletedAt = nil
+	}
 
-	if err := s.taskRepo.Update(ctx, existingTask); err != nil {
+	// The next occurrence and the cancelled subtasks only exist if the task
+	// itself was saved.
+	err = s.taskRepo.InTx(ctx, func(taskRepo repository.TaskRepository) error {
+		if err := taskRepo.Update(ctx, existingTask); err != nil {
+			return err
+		}
+		if completing {
+			if err := s.scheduleNextOccurrence(ctx, taskRepo, existingTask); err != nil {
+				return err
+			}
+		}
+		if cancelling {
+			return taskRepo.CancelOpenDescendants(ctx, int64(existingTask.ID))
+		}
+		return nil
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrTaskNotFound
+		}
 		return err
 	}
 
+	*task = *existingTask
 	return nil
 }
 
+// DeleteTask removes a task together with all of its subtasks and their
+// checklists.
 func (s *TaskService) DeleteTask(ctx *gin.Context, taskID int64, userID int64) error {
-	task, err := s.taskRepo.GetByID(ctx, taskID)
+	task, err := getTask(ctx, s.taskRepo, s.authorizer, taskID, userID, authz.WriteTasks)
+	if err != nil {
+		return err
+	}
+
+	if err := s.taskRepo.Delete(ctx, taskID, int64(task.UserID)); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrTaskNotFound
+		}
+		return err
+	}
+
+	return nil
+}
+
+// GetTransitions reports the statuses the task can move to from its current
+// status.
+func (s *TaskService) GetTransitions(ctx *gin.Context, taskID int64, userID int64) (*model.TaskTransitions, error) {
+	task, err := getTask(ctx, s.taskRepo, s.authorizer, taskID, userID, authz.ReadTasks)
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.TaskTransitions{
+		TaskID:  task.ID,
+		Status:  task.Status,
+		Allowed: task.Status.AllowedTransitions(),
+	}, nil
+}
+
+// GetDependencies lists the tasks that block the task and the tasks it
+// blocks.
+func (s *TaskService) GetDependencies(ctx *gin.Context, taskID int64, userID int64) (*model.TaskDependencies, error) {
+	if _, err := getTask(ctx, s.taskRepo, s.authorizer, taskID, userID, authz.ReadTasks); err != nil {
+		return nil, err
+	}
+
+	blockedBy, err := s.dependencyRepo.ListBlockers(ctx, taskID)
+	if err != nil {
+		return nil, err
+	}
+
+	blocks, err := s.dependencyRepo.ListBlocked(ctx, taskID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.TaskDependencies{TaskID: uint(taskID), BlockedBy: blockedBy, Blocks: blocks}, nil
+}
+
+// AddDependency records that the task is blocked by blockerID. Both tasks
+// must be in the request's space, and the new edge must not close a cycle: the
+// blocker may not itself depend, directly or transitively, on the task.
+func (s *TaskService) AddDependency(ctx *gin.Context, taskID int64, blockerID int64, userID int64) error {
+	if _, err := getTask(ctx, s.taskRepo, s.authorizer, taskID, userID, authz.WriteTasks); err != nil {
+		return err
+	}
+
+	if _, err := getTask(ctx, s.taskRepo, s.authorizer, blockerID, userID, authz.ReadTasks); err != nil {
+		if errors.Is(err, ErrTaskNotFound) {
+			return ErrBlockerNotFound
+		}
+		return err
+	}
+
+	if taskID == blockerID {
+		return ErrDependencyCycle
+	}
+
+	cycle, err := s.dependencyRepo.DependsOn(ctx, blockerID, taskID)
+	if err != nil {
+		return err
+	}
+	if cycle {
+		return ErrDependencyCycle
+	}
+
+	return s.dependencyRepo.Add(ctx, taskID, blockerID)
+}
+
+func (s *TaskService) RemoveDependency(ctx *gin.Context, taskID int64, blockerID int64, userID int64) error {
+	if _, err := getTask(ctx, s.taskRepo, s.authorizer, taskID, userID, authz.WriteTasks); err != nil {
+		return err
+	}
+
+	return s.dependencyRepo.Remove(ctx, taskID, blockerID)
+}
+
+// GetDependencyGraph returns the task and every task it transitively
+// depends on.
+func (s *TaskService) GetDependencyGraph(ctx *gin.Context, taskID int64, userID int64) (*model.TaskGraph, error) {
+	if _, err := getTask(ctx, s.taskRepo, s.authorizer, taskID, userID, authz.ReadTasks); err != nil {
+		return nil, err
+	}
+
+	nodes, edges, err := s.dependencyRepo.Graph(ctx, taskID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.TaskGraph{RootID: uint(taskID), Nodes: nodes, Edges: edges}, nil
+}
+
+// AssignTask makes assigneeID responsible for the task and records the
+// change in the task's activity. The assignee must be a member, in any
+// role, of the task's workspace; a personal task can only be assigned to
+// its owner.
+func (s *TaskService) AssignTask(ctx *gin.Context, taskID int64, assigneeID int64, userID int64) error {
+	task, err := getTask(ctx, s.taskRepo, s.authorizer, taskID, userID, authz.WriteTasks)
+	if err != nil {
+		return err
+	}
+
+	if err := s.checkAssignee(ctx, task, assigneeID); err != nil {
+		return err
+	}
+
+	id := uint(assigneeID)
+	return s.taskRepo.SetAssignee(ctx, taskID, &id, userID)
+}
+
+// UnassignTask leaves the task without an assignee.
+func (s *TaskService) UnassignTask(ctx *gin.Context, taskID int64, userID int64) error {
+	if _, err := getTask(ctx, s.taskRepo, s.authorizer, taskID, userID, authz.WriteTasks); err != nil {
+		return err
+	}
+
+	return s.taskRepo.SetAssignee(ctx, taskID, nil, userID)
+}
+
+// GetActivity returns the task's history, oldest first.
+func (s *TaskService) GetActivity(ctx *gin.Context, taskID int64, userID int64) ([]*model.TaskActivity, error) {
+	if _, err := getTask(ctx, s.taskRepo, s.authorizer, taskID, userID, authz.ReadTasks); err != nil {
+		return nil, err
+	}
+
+	return s.activityRepo.ListForTask(ctx, taskID)
+}
+
+// checkAssignee verifies that the user can be assigned the task, which
+// they can only if they can see it.
+func (s *TaskService) checkAssignee(ctx *gin.Context, task *model.Task, assigneeID int64) error {
+	if task.WorkspaceID == nil {
+		if assigneeID != int64(task.UserID) {
+			return ErrInvalidAssignee
+		}
+		return nil
+	}
+
+	if _, err := s.workspaceRepo.MemberRole(ctx, int64(*task.WorkspaceID), assigneeID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrInvalidAssignee
+		}
+		return err
+	}
+	return nil
+}
+
+// checkProject verifies that a task may be placed in the project, which
+// must be in the request's space. A nil project is the inbox.
+func (s *TaskService) checkProject(ctx *gin.Context, projectID *uint, userID int64) error {
+	if projectID == nil {
+		return nil
+	}
+
+	_, err := getProject(ctx, s.projectRepo, s.authorizer, int64(*projectID), userID, authz.ReadProjects)
+	return err
+}
+
+// checkParent verifies that the task may be placed under its parent: the
+// parent must be in the request's space, must not be the task itself or one of its
+// subtasks, and the move must keep the whole subtree within the depth limit.
+// A new task has no ID yet and so no subtree.
+func (s *TaskService) checkParent(ctx *gin.Context, task *model.Task, userID int64) error {
+	if task.ParentID == nil {
+		return nil
+	}
+
+	parentID := int64(*task.ParentID)
+	if task.ID != 0 && parentID == int64(task.ID) {
+		return ErrSubtaskCycle
+	}
+
+	if _, err := getTask(ctx, s.taskRepo, s.authorizer, parentID, userID, authz.ReadTasks); err != nil {
+		if errors.Is(err, ErrTaskNotFound) {
+			return ErrParentTaskNotFound
+		}
+		return err
+	}
+
+	ancestorIDs, err := s.taskRepo.GetAncestorIDs(ctx, parentID)
 	if err != nil {
-		return ErrTaskNotFound
+		return err
+	}
+
+	height := 1
+	if task.ID != 0 {
+		for _, ancestorID := range ancestorIDs {
+			if ancestorID == task.ID {
+				return ErrSubtaskCycle
+			}
+		}
+
+		if height, err = s.taskRepo.SubtreeHeight(ctx, int64(task.ID)); err != nil {
+			return err
+		}
+	}
+
+	// The parent sits at depth len(ancestorIDs), so the task lands one
+	// level below it and its deepest subtask height-1 levels further down.
+	if len(ancestorIDs)+height > s.policy.MaxSubtaskDepth {
+		return ErrSubtaskTooDeep
 	}
 
-	if task.UserID != uint(userID) {
-		return errors.New("unauthorized to delete this task")
+	return nil
+}
+
+// getTask loads a task the user may perform action on. Tasks outside the
+// request's space are hidden behind ErrTaskNotFound, so callers cannot
+// probe which task IDs exist; a task the user can see but not change gives
+// ErrInsufficientRole.
+func getTask(ctx *gin.Context, taskRepo repository.TaskRepository, authorizer *authz.Authorizer, taskID int64, userID int64, action authz.Action) (*model.Task, error) {
+	task, err := taskRepo.GetByID(ctx, taskID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrTaskNotFound
+		}
+		return nil, err
 	}
 
-	if err := s.taskRepo.Delete(ctx, taskID, userID); err != nil {
+	resource := authz.Resource{Type: "task", ID: task.ID, OwnerID: task.UserID, WorkspaceID: task.WorkspaceID}
+	if err := authorizeResource(ctx, authorizer, userID, authz.ReadTasks, action, resource, ErrTaskNotFound); err != nil {
+		return nil, err
+	}
+
+	keepOwnLabels(userID, task)
+	return task, nil
+}
+
+// keepOwnLabels drops the labels other users put on tasks. Labels stay
+// personal inside workspaces, so members only see their own.
+func keepOwnLabels(userID int64, tasks ...*model.Task) {
+	for _, task := range tasks {
+		own := make([]model.Label, 0, len(task.Labels))
+		for _, label := range task.Labels {
+			if label.UserID == uint(userID) {
+				own = append(own, label)
+			}
+		}
+		task.Labels = own
+	}
+}
+
+// checkLabelFilter rejects label IDs the user does not own, so that
+// filtering by them cannot reveal which tasks carry other users' labels.
+func (s *TaskService) checkLabelFilter(ctx *gin.Context, labelIDs []uint, userID int64) error {
+	if len(labelIDs) == 0 {
+		return nil
+	}
+
+	labels, err := s.labelRepo.ListForUser(ctx, userID)
+	if err != nil {
 		return err
 	}
+	owned := make(map[uint]bool, len(labels))
+	for _, label := range labels {
+		owned[label.ID] = true
+	}
+
+	for _, labelID := range labelIDs {
+		if !owned[labelID] {
+			return fmt.Errorf("%w: label %d not found", ErrInvalidFilter, labelID)
+		}
+	}
+	return nil
+}
+
+// validateTask checks the values a client is allowed to set and normalises
+// the due date to UTC.
+func validateTask(task *model.Task) error {
+	if task.Title == "" {
+		return ErrTitleRequired
+	}
+
+	if !task.Status.Valid() {
+		return ErrInvalidStatus
+	}
+
+	if !task.Priority.Valid() {
+		return ErrInvalidPriority
+	}
+
+	if task.DueAt != nil {
+		dueAt := task.DueAt.UTC()
+		task.DueAt = &dueAt
+	}
+
+	return nil
+}
+
+func sameID(a, b *uint) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+func validateTaskFilter(filter model.TaskFilter) error {
+	for _, status := range filter.Statuses {
+		if !status.Valid() {
+			return fmt.Errorf("%w: unknown status %q", ErrInvalidFilter, status)
+		}
+	}
+
+	for _, priority := range filter.Priorities {
+		if !priority.Valid() {
+			return fmt.Errorf("%w: unknown priority %q", ErrInvalidFilter, priority)
+		}
+	}
+
+	if filter.LabelMatch != model.LabelMatchAny && filter.LabelMatch != model.LabelMatchAll {
+		return fmt.Errorf("%w: label_match must be any or all", ErrInvalidFilter)
+	}
+
+	if filter.DueAfter != nil && filter.DueBefore != nil && !filter.DueAfter.Before(*filter.DueBefore) {
+		return fmt.Errorf("%w: due_after must be before due_before", ErrInvalidFilter)
+	}
+
+	if !filter.Sort.Valid() {
+		return fmt.Errorf("%w: cannot sort by %q", ErrInvalidFilter, filter.Sort)
+	}
+
+	if filter.Order != model.SortAsc && filter.Order != model.SortDesc {
+		return fmt.Errorf("%w: order must be asc or desc", ErrInvalidFilter)
+	}
+
+	if filter.Limit < 1 || filter.Limit > maxTaskPageSize {
+		return fmt.Errorf("%w: limit must be between 1 and %d", ErrInvalidFilter, maxTaskPageSize)
+	}
 
 	return nil
 }
@@ -0,0 +1,908 @@
+package service_test
+
+import (
+	"context"
+	"database/sql"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+
+	"github.com/ahmednurovic/task-manager-api/internal/authz"
+	"github.com/ahmednurovic/task-manager-api/internal/model"
+	"github.com/ahmednurovic/task-manager-api/internal/repository"
+	"github.com/ahmednurovic/task-manager-api/internal/service"
+)
+
+type MockTaskRepository struct {
+	mock.Mock
+}
+
+func (m *MockTaskRepository) Create(ctx context.Context, task *model.Task) error {
+	args := m.Called(ctx, task)
+	return args.Error(0)
+}
+
+func (m *MockTaskRepository) List(ctx context.Context, userID int64, filter model.TaskFilter) (*model.TaskPage, error) {
+	args := m.Called(ctx, userID, filter)
+	page, _ := args.Get(0).(*model.TaskPage)
+	return page, args.Error(1)
+}
+
+func (m *MockTaskRepository) Search(ctx context.Context, userID int64, workspaceID *uint, query string, limit int) ([]*model.TaskSearchResult, error) {
+	args := m.Called(ctx, userID, workspaceID, query, limit)
+	results, _ := args.Get(0).([]*model.TaskSearchResult)
+	return results, args.Error(1)
+}
+
+func (m *MockTaskRepository) GetByID(ctx context.Context, taskID int64) (*model.Task, error) {
+	args := m.Called(ctx, taskID)
+	task, _ := args.Get(0).(*model.Task)
+	return task, args.Error(1)
+}
+
+func (m *MockTaskRepository) Update(ctx context.Context, task *model.Task) error {
+	args := m.Called(ctx, task)
+	return args.Error(0)
+}
+
+func (m *MockTaskRepository) Delete(ctx context.Context, taskID int64, userID int64) error {
+	args := m.Called(ctx, taskID, userID)
+	return args.Error(0)
+}
+
+func (m *MockTaskRepository) GetAncestorIDs(ctx context.Context, taskID int64) ([]uint, error) {
+	args := m.Called(ctx, taskID)
+	ancestorIDs, _ := args.Get(0).([]uint)
+	return ancestorIDs, args.Error(1)
+}
+
+func (m *MockTaskRepository) SubtreeHeight(ctx context.Context, taskID int64) (int, error) {
+	args := m.Called(ctx, taskID)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockTaskRepository) CountOpenDescendants(ctx context.Context, taskID int64) (int, error) {
+	args := m.Called(ctx, taskID)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockTaskRepository) CancelOpenDescendants(ctx context.Context, taskID int64) error {
+	args := m.Called(ctx, taskID)
+	return args.Error(0)
+}
+
+func (m *MockTaskRepository) CopyLabelsAndChecklist(ctx context.Context, fromTaskID int64, toTaskID int64) error {
+	args := m.Called(ctx, fromTaskID, toTaskID)
+	return args.Error(0)
+}
+
+func (m *MockTaskRepository) SetAssignee(ctx context.Context, taskID int64, assigneeID *uint, actorID int64) error {
+	args := m.Called(ctx, taskID, assigneeID, actorID)
+	return args.Error(0)
+}
+
+// InTx runs fn against the mock itself; tests assert on the calls made inside.
+func (m *MockTaskRepository) InTx(ctx context.Context, fn func(repository.TaskRepository) error) error {
+	return fn(m)
+}
+
+type MockDependencyRepository struct {
+	mock.Mock
+}
+
+func (m *MockDependencyRepository) Add(ctx context.Context, taskID int64, blockerID int64) error {
+	args := m.Called(ctx, taskID, blockerID)
+	return args.Error(0)
+}
+
+func (m *MockDependencyRepository) Remove(ctx context.Context, taskID int64, blockerID int64) error {
+	args := m.Called(ctx, taskID, blockerID)
+	return args.Error(0)
+}
+
+func (m *MockDependencyRepository) ListBlockers(ctx context.Context, taskID int64) ([]model.TaskRef, error) {
+	args := m.Called(ctx, taskID)
+	refs, _ := args.Get(0).([]model.TaskRef)
+	return refs, args.Error(1)
+}
+
+func (m *MockDependencyRepository) ListBlocked(ctx context.Context, taskID int64) ([]model.TaskRef, error) {
+	args := m.Called(ctx, taskID)
+	refs, _ := args.Get(0).([]model.TaskRef)
+	return refs, args.Error(1)
+}
+
+func (m *MockDependencyRepository) DependsOn(ctx context.Context, taskID int64, otherID int64) (bool, error) {
+	args := m.Called(ctx, taskID, otherID)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockDependencyRepository) CountOpenBlockers(ctx context.Context, taskID int64) (int, error) {
+	args := m.Called(ctx, taskID)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockDependencyRepository) Graph(ctx context.Context, taskID int64) ([]model.TaskRef, []model.DependencyEdge, error) {
+	args := m.Called(ctx, taskID)
+	nodes, _ := args.Get(0).([]model.TaskRef)
+	edges, _ := args.Get(1).([]model.DependencyEdge)
+	return nodes, edges, args.Error(2)
+}
+
+// unblockedDependencyRepo reports no open blockers for any task, for tests
+// that are not about dependencies.
+func unblockedDependencyRepo() *MockDependencyRepository {
+	mockDependencyRepo := new(MockDependencyRepository)
+	mockDependencyRepo.On("CountOpenBlockers", mock.Anything, mock.Anything).Return(0, nil).Maybe()
+	return mockDependencyRepo
+}
+
+var testAuthorizer = authz.New(authz.DefaultPolicy, zap.NewNop())
+
+func newTestContext() *gin.Context {
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	return c
+}
+
+func TestTaskServiceCreateTask(t *testing.T) {
+	t.Run("Owner Is Server Assigned", func(t *testing.T) {
+		mockRepo := new(MockTaskRepository)
+		mockRepo.On("Create", mock.Anything, mock.MatchedBy(func(task *model.Task) bool {
+			return task.UserID == 1 && task.ID == 0
+		})).Return(nil)
+
+		taskService := service.NewTaskService(mockRepo, new(MockProjectRepository), unblockedDependencyRepo(), nil, nil, nil, testAuthorizer, service.TaskPolicy{})
+		task := &model.Task{ID: 99, UserID: 2, Title: "Write report"}
+		err := taskService.CreateTask(newTestContext(), task, 1)
+
+		assert.NoError(t, err)
+		assert.Equal(t, uint(1), task.UserID)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Defaults", func(t *testing.T) {
+		mockRepo := new(MockTaskRepository)
+		mockRepo.On("Create", mock.Anything, mock.Anything).Return(nil)
+
+		taskService := service.NewTaskService(mockRepo, new(MockProjectRepository), unblockedDependencyRepo(), nil, nil, nil, testAuthorizer, service.TaskPolicy{})
+		task := &model.Task{Title: "Write report"}
+		err := taskService.CreateTask(newTestContext(), task, 1)
+
+		assert.NoError(t, err)
+		assert.Equal(t, model.StatusPending, task.Status)
+		assert.Equal(t, model.PriorityMedium, task.Priority)
+		assert.Nil(t, task.CompletedAt)
+	})
+
+	t.Run("Created Done", func(t *testing.T) {
+		mockRepo := new(MockTaskRepository)
+		mockRepo.On("Create", mock.Anything, mock.Anything).Return(nil)
+
+		taskService := service.NewTaskService(mockRepo, new(MockProjectRepository), unblockedDependencyRepo(), nil, nil, nil, testAuthorizer, service.TaskPolicy{})
+		task := &model.Task{Title: "Write report", Status: model.StatusDone}
+		err := taskService.CreateTask(newTestContext(), task, 1)
+
+		assert.NoError(t, err)
+		assert.NotNil(t, task.CompletedAt)
+	})
+
+	t.Run("Title Required", func(t *testing.T) {
+		mockRepo := new(MockTaskRepository)
+
+		taskService := service.NewTaskService(mockRepo, new(MockProjectRepository), unblockedDependencyRepo(), nil, nil, nil, testAuthorizer, service.TaskPolicy{})
+		err := taskService.CreateTask(newTestContext(), &model.Task{}, 1)
+
+		assert.ErrorIs(t, err, service.ErrTitleRequired)
+		mockRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Invalid Priority", func(t *testing.T) {
+		mockRepo := new(MockTaskRepository)
+
+		taskService := service.NewTaskService(mockRepo, new(MockProjectRepository), unblockedDependencyRepo(), nil, nil, nil, testAuthorizer, service.TaskPolicy{})
+		err := taskService.CreateTask(newTestContext(), &model.Task{Title: "Write report", Priority: "critical"}, 1)
+
+		assert.ErrorIs(t, err, service.ErrInvalidPriority)
+		mockRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+}
+
+func TestTaskServiceGetTask(t *testing.T) {
+	t.Run("Only Own Labels Shown", func(t *testing.T) {
+		mockRepo := new(MockTaskRepository)
+		workspaceID := uint(3)
+		mockRepo.On("GetByID", mock.Anything, int64(10)).
+			Return(&model.Task{ID: 10, UserID: 1, WorkspaceID: &workspaceID, Labels: []model.Label{
+				{ID: 1, UserID: 1, Name: "salary review"},
+				{ID: 2, UserID: 2, Name: "urgent"},
+			}}, nil)
+
+		taskService := service.NewTaskService(mockRepo, new(MockProjectRepository), unblockedDependencyRepo(), nil, nil, nil, testAuthorizer, service.TaskPolicy{})
+
+		task, err := taskService.GetTask(workspaceContext(3, model.RoleViewer), 10, 2)
+		assert.NoError(t, err)
+		assert.Equal(t, []model.Label{{ID: 2, UserID: 2, Name: "urgent"}}, task.Labels)
+	})
+}
+
+func TestTaskServiceGetTasks(t *testing.T) {
+	t.Run("Defaults", func(t *testing.T) {
+		mockRepo := new(MockTaskRepository)
+		mockRepo.On("List", mock.Anything, int64(1), model.TaskFilter{
+			Sort:       model.SortByCreatedAt,
+			Order:      model.SortDesc,
+			Limit:      50,
+			LabelMatch: model.LabelMatchAny,
+		}).
+			Return(&model.TaskPage{Items: []*model.Task{{ID: 10, UserID: 1, Title: "Mine"}}}, nil)
+
+		taskService := service.NewTaskService(mockRepo, new(MockProjectRepository), unblockedDependencyRepo(), nil, nil, nil, testAuthorizer, service.TaskPolicy{})
+		page, err := taskService.GetTasks(newTestContext(), 1, model.TaskFilter{})
+
+		assert.NoError(t, err)
+		assert.Len(t, page.Items, 1)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Invalid Filters", func(t *testing.T) {
+		dueAt := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+		filters := map[string]model.TaskFilter{
+			"Status":      {Statuses: []model.TaskStatus{"archived"}},
+			"Priority":    {Priorities: []model.TaskPriority{"critical"}},
+			"Due Range":   {DueAfter: &dueAt, DueBefore: &dueAt},
+			"Sort":        {Sort: "password"},
+			"Order":       {Order: "sideways"},
+			"Limit":       {Limit: 1000},
+			"Label Match": {LabelIDs: []uint{1}, LabelMatch: "some"},
+		}
+
+		for name, filter := range filters {
+			t.Run(name, func(t *testing.T) {
+				mockRepo := new(MockTaskRepository)
+
+				taskService := service.NewTaskService(mockRepo, new(MockProjectRepository), unblockedDependencyRepo(), nil, nil, nil, testAuthorizer, service.TaskPolicy{})
+				_, err := taskService.GetTasks(newTestContext(), 1, filter)
+
+				assert.ErrorIs(t, err, service.ErrInvalidFilter)
+				mockRepo.AssertNotCalled(t, "List", mock.Anything, mock.Anything, mock.Anything)
+			})
+		}
+	})
+
+	t.Run("Only Own Labels Shown", func(t *testing.T) {
+		workspaceID := uint(3)
+		mockRepo := new(MockTaskRepository)
+		mockRepo.On("List", mock.Anything, int64(2), mock.Anything).
+			Return(&model.TaskPage{Items: []*model.Task{{ID: 10, UserID: 1, WorkspaceID: &workspaceID, Labels: []model.Label{
+				{ID: 1, UserID: 1, Name: "salary review"},
+				{ID: 2, UserID: 2, Name: "urgent"},
+			}}}}, nil)
+
+		taskService := service.NewTaskService(mockRepo, new(MockProjectRepository), unblockedDependencyRepo(), nil, nil, nil, testAuthorizer, service.TaskPolicy{})
+		page, err := taskService.GetTasks(workspaceContext(3, model.RoleMember), 2, model.TaskFilter{})
+
+		assert.NoError(t, err)
+		assert.Equal(t, []model.Label{{ID: 2, UserID: 2, Name: "urgent"}}, page.Items[0].Labels)
+	})
+
+	t.Run("Label Filter Must Be Own Labels", func(t *testing.T) {
+		mockLabelRepo := new(MockLabelRepository)
+		mockLabelRepo.On("ListForUser", mock.Anything, int64(2)).Return([]*model.Label{{ID: 2, UserID: 2}}, nil)
+		mockRepo := new(MockTaskRepository)
+		mockRepo.On("List", mock.Anything, int64(2), mock.MatchedBy(func(filter model.TaskFilter) bool {
+			return len(filter.LabelIDs) == 1
+		})).Return(&model.TaskPage{Items: []*model.Task{}}, nil)
+
+		taskService := service.NewTaskService(mockRepo, new(MockProjectRepository), unblockedDependencyRepo(), nil, nil, mockLabelRepo, testAuthorizer, service.TaskPolicy{})
+
+		_, err := taskService.GetTasks(workspaceContext(3, model.RoleMember), 2, model.TaskFilter{LabelIDs: []uint{2}})
+		assert.NoError(t, err)
+
+		// Label 1 belongs to another member of the workspace.
+		_, err = taskService.GetTasks(workspaceContext(3, model.RoleMember), 2, model.TaskFilter{LabelIDs: []uint{2, 1}})
+		assert.ErrorIs(t, err, service.ErrInvalidFilter)
+		mockRepo.AssertNumberOfCalls(t, "List", 1)
+	})
+
+	t.Run("Invalid Cursor", func(t *testing.T) {
+		mockRepo := new(MockTaskRepository)
+		mockRepo.On("List", mock.Anything, int64(1), mock.Anything).Return(nil, repository.ErrInvalidCursor)
+
+		taskService := service.NewTaskService(mockRepo, new(MockProjectRepository), unblockedDependencyRepo(), nil, nil, nil, testAuthorizer, service.TaskPolicy{})
+		_, err := taskService.GetTasks(newTestContext(), 1, model.TaskFilter{Cursor: "garbage"})
+
+		assert.ErrorIs(t, err, service.ErrInvalidFilter)
+	})
+}
+
+func TestTaskServiceSearchTasks(t *testing.T) {
+	t.Run("Default Limit", func(t *testing.T) {
+		mockRepo := new(MockTaskRepository)
+		mockRepo.On("Search", mock.Anything, int64(1), (*uint)(nil), "report", 20).
+			Return([]*model.TaskSearchResult{}, nil)
+
+		taskService := service.NewTaskService(mockRepo, new(MockProjectRepository), unblockedDependencyRepo(), nil, nil, nil, testAuthorizer, service.TaskPolicy{})
+		results, err := taskService.SearchTasks(newTestContext(), 1, "report", 0)
+
+		assert.NoError(t, err)
+		assert.NotNil(t, results.Items)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Blank Query", func(t *testing.T) {
+		mockRepo := new(MockTaskRepository)
+
+		taskService := service.NewTaskService(mockRepo, new(MockProjectRepository), unblockedDependencyRepo(), nil, nil, nil, testAuthorizer, service.TaskPolicy{})
+		_, err := taskService.SearchTasks(newTestContext(), 1, "  ", 0)
+
+		assert.ErrorIs(t, err, service.ErrSearchQueryRequired)
+		mockRepo.AssertNotCalled(t, "Search", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestTaskServiceUpdateTask(t *testing.T) {
+	t.Run("Own Task", func(t *testing.T) {
+		mockRepo := new(MockTaskRepository)
+		mockRepo.On("GetByID", mock.Anything, int64(10)).
+			Return(&model.Task{ID: 10, UserID: 1, Title: "Old", Status: model.StatusPending, Priority: model.PriorityMedium}, nil)
+		mockRepo.On("Update", mock.Anything, mock.MatchedBy(func(task *model.Task) bool {
+			return task.ID == 10 && task.UserID == 1 && task.Title == "New"
+		})).Return(nil)
+
+		taskService := service.NewTaskService(mockRepo, new(MockProjectRepository), unblockedDependencyRepo(), nil, nil, nil, testAuthorizer, service.TaskPolicy{})
+		err := taskService.UpdateTask(newTestContext(), &model.Task{ID: 10, UserID: 2, Title: "New"}, 1)
+
+		assert.NoError(t, err)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Other User's Task", func(t *testing.T) {
+		mockRepo := new(MockTaskRepository)
+		mockRepo.On("GetByID", mock.Anything, int64(10)).
+			Return(&model.Task{ID: 10, UserID: 1, Title: "Old"}, nil)
+
+		taskService := service.NewTaskService(mockRepo, new(MockProjectRepository), unblockedDependencyRepo(), nil, nil, nil, testAuthorizer, service.TaskPolicy{})
+		err := taskService.UpdateTask(newTestContext(), &model.Task{ID: 10, Title: "New"}, 2)
+
+		assert.ErrorIs(t, err, service.ErrTaskNotFound)
+		mockRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Completion Timestamp", func(t *testing.T) {
+		mockRepo := new(MockTaskRepository)
+		mockRepo.On("GetByID", mock.Anything, int64(10)).
+			Return(&model.Task{ID: 10, UserID: 1, Title: "Old", Status: model.StatusPending, Priority: model.PriorityMedium}, nil).Once()
+		mockRepo.On("Update", mock.Anything, mock.Anything).Return(nil)
+
+		taskService := service.NewTaskService(mockRepo, new(MockProjectRepository), unblockedDependencyRepo(), nil, nil, nil, testAuthorizer, service.TaskPolicy{})
+		task := &model.Task{ID: 10, Title: "Old", Status: model.StatusDone}
+		err := taskService.UpdateTask(newTestContext(), task, 1)
+
+		assert.NoError(t, err)
+		assert.NotNil(t, task.CompletedAt)
+		completedAt := *task.CompletedAt
+
+		mockRepo.On("GetByID", mock.Anything, int64(10)).
+			Return(&model.Task{ID: 10, UserID: 1, Title: "Old", Status: model.StatusDone, Priority: model.PriorityMedium, CompletedAt: &completedAt}, nil).Once()
+		task = &model.Task{ID: 10, Title: "Renamed", Status: model.StatusDone}
+		err = taskService.UpdateTask(newTestContext(), task, 1)
+
+		assert.NoError(t, err)
+		assert.Equal(t, completedAt, *task.CompletedAt)
+
+		mockRepo.On("GetByID", mock.Anything, int64(10)).
+			Return(&model.Task{ID: 10, UserID: 1, Title: "Old", Status: model.StatusDone, Priority: model.PriorityMedium, CompletedAt: &completedAt}, nil).Once()
+		task = &model.Task{ID: 10, Title: "Old", Status: model.StatusPending}
+		err = taskService.UpdateTask(newTestContext(), task, 1)
+
+		assert.NoError(t, err)
+		assert.Nil(t, task.CompletedAt)
+	})
+
+	t.Run("Missing Task", func(t *testing.T) {
+		mockRepo := new(MockTaskRepository)
+		mockRepo.On("GetByID", mock.Anything, int64(10)).Return(nil, sql.ErrNoRows)
+
+		taskService := service.NewTaskService(mockRepo, new(MockProjectRepository), unblockedDependencyRepo(), nil, nil, nil, testAuthorizer, service.TaskPolicy{})
+		err := taskService.UpdateTask(newTestContext(), &model.Task{ID: 10, Title: "New"}, 1)
+
+		assert.ErrorIs(t, err, service.ErrTaskNotFound)
+	})
+}
+
+func TestTaskServiceUpdateTaskTransitions(t *testing.T) {
+	tests := []struct {
+		name    string
+		from    model.TaskStatus
+		to      model.TaskStatus
+		wantErr error
+	}{
+		{"Keep Status", model.StatusBlocked, "", nil},
+		{"Start Work", model.StatusPending, model.StatusInProgress, nil},
+		{"Reopen", model.StatusDone, model.StatusPending, nil},
+		{"Blocked To Done", model.StatusBlocked, model.StatusDone, service.ErrInvalidTransition},
+		{"Cancelled To Done", model.StatusCancelled, model.StatusDone, service.ErrInvalidTransition},
+		{"Done To Cancelled", model.StatusDone, model.StatusCancelled, service.ErrInvalidTransition},
+		{"Unknown Status", model.StatusPending, "archived", service.ErrInvalidStatus},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(MockTaskRepository)
+			mockRepo.On("GetByID", mock.Anything, int64(10)).
+				Return(&model.Task{ID: 10, UserID: 1, Title: "Task", Status: tt.from, Priority: model.PriorityMedium}, nil)
+			mockRepo.On("Update", mock.Anything, mock.Anything).Return(nil)
+
+			taskService := service.NewTaskService(mockRepo, new(MockProjectRepository), unblockedDependencyRepo(), nil, nil, nil, testAuthorizer, service.TaskPolicy{})
+			err := taskService.UpdateTask(newTestContext(), &model.Task{ID: 10, Title: "Task", Status: tt.to}, 1)
+
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				mockRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+				return
+			}
+			assert.NoError(t, err)
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestTaskServiceGetTransitions(t *testing.T) {
+	t.Run("Own Task", func(t *testing.T) {
+		mockRepo := new(MockTaskRepository)
+		mockRepo.On("GetByID", mock.Anything, int64(10)).
+			Return(&model.Task{ID: 10, UserID: 1, Status: model.StatusBlocked}, nil)
+
+		taskService := service.NewTaskService(mockRepo, new(MockProjectRepository), unblockedDependencyRepo(), nil, nil, nil, testAuthorizer, service.TaskPolicy{})
+		transitions, err := taskService.GetTransitions(newTestContext(), 10, 1)
+
+		assert.NoError(t, err)
+		assert.Equal(t, model.StatusBlocked, transitions.Status)
+		assert.ElementsMatch(t, []model.TaskStatus{model.StatusPending, model.StatusInProgress, model.StatusCancelled}, transitions.Allowed)
+	})
+
+	t.Run("Other User's Task", func(t *testing.T) {
+		mockRepo := new(MockTaskRepository)
+		mockRepo.On("GetByID", mock.Anything, int64(10)).
+			Return(&model.Task{ID: 10, UserID: 1, Status: model.StatusBlocked}, nil)
+
+		taskService := service.NewTaskService(mockRepo, new(MockProjectRepository), unblockedDependencyRepo(), nil, nil, nil, testAuthorizer, service.TaskPolicy{})
+		_, err := taskService.GetTransitions(newTestContext(), 10, 2)
+
+		assert.ErrorIs(t, err, service.ErrTaskNotFound)
+	})
+}
+
+func TestTaskServiceDeleteTask(t *testing.T) {
+	t.Run("Own Task", func(t *testing.T) {
+		mockRepo := new(MockTaskRepository)
+		mockRepo.On("GetByID", mock.Anything, int64(10)).
+			Return(&model.Task{ID: 10, UserID: 1}, nil)
+		mockRepo.On("Delete", mock.Anything, int64(10), int64(1)).Return(nil)
+
+		taskService := service.NewTaskService(mockRepo, new(MockProjectRepository), unblockedDependencyRepo(), nil, nil, nil, testAuthorizer, service.TaskPolicy{})
+		err := taskService.DeleteTask(newTestContext(), 10, 1)
+
+		assert.NoError(t, err)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Other User's Task", func(t *testing.T) {
+		mockRepo := new(MockTaskRepository)
+		mockRepo.On("GetByID", mock.Anything, int64(10)).
+			Return(&model.Task{ID: 10, UserID: 1}, nil)
+
+		taskService := service.NewTaskService(mockRepo, new(MockProjectRepository), unblockedDependencyRepo(), nil, nil, nil, testAuthorizer, service.TaskPolicy{})
+		err := taskService.DeleteTask(newTestContext(), 10, 2)
+
+		assert.ErrorIs(t, err, service.ErrTaskNotFound)
+		mockRepo.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestTaskServiceSubtasks(t *testing.T) {
+	parentID := uint(5)
+
+	t.Run("Create Under Own Parent", func(t *testing.T) {
+		mockRepo := new(MockTaskRepository)
+		mockRepo.On("GetByID", mock.Anything, int64(5)).Return(&model.Task{ID: 5, UserID: 1}, nil)
+		mockRepo.On("GetAncestorIDs", mock.Anything, int64(5)).Return([]uint{2, 1}, nil)
+		mockRepo.On("Create", mock.Anything, mock.MatchedBy(func(task *model.Task) bool {
+			return task.ParentID != nil && *task.ParentID == 5
+		})).Return(nil)
+
+		taskService := service.NewTaskService(mockRepo, new(MockProjectRepository), unblockedDependencyRepo(), nil, nil, nil, testAuthorizer, service.TaskPolicy{})
+		err := taskService.CreateTask(newTestContext(), &model.Task{Title: "Subtask", ParentID: &parentID}, 1)
+
+		assert.NoError(t, err)
+		mockRepo.AssertExpectations(t)
+		mockRepo.AssertNotCalled(t, "SubtreeHeight", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Other User's Parent", func(t *testing.T) {
+		mockRepo := new(MockTaskRepository)
+		mockRepo.On("GetByID", mock.Anything, int64(5)).Return(&model.Task{ID: 5, UserID: 2}, nil)
+
+		taskService := service.NewTaskService(mockRepo, new(MockProjectRepository), unblockedDependencyRepo(), nil, nil, nil, testAuthorizer, service.TaskPolicy{})
+		err := taskService.CreateTask(newTestContext(), &model.Task{Title: "Subtask", ParentID: &parentID}, 1)
+
+		assert.ErrorIs(t, err, service.ErrParentTaskNotFound)
+		mockRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Create Too Deep", func(t *testing.T) {
+		mockRepo := new(MockTaskRepository)
+		mockRepo.On("GetByID", mock.Anything, int64(5)).Return(&model.Task{ID: 5, UserID: 1}, nil)
+		mockRepo.On("GetAncestorIDs", mock.Anything, int64(5)).Return([]uint{2}, nil)
+
+		taskService := service.NewTaskService(mockRepo, new(MockProjectRepository), unblockedDependencyRepo(), nil, nil, nil, testAuthorizer, service.TaskPolicy{MaxSubtaskDepth: 1})
+		err := taskService.CreateTask(newTestContext(), &model.Task{Title: "Subtask", ParentID: &parentID}, 1)
+
+		assert.ErrorIs(t, err, service.ErrSubtaskTooDeep)
+		mockRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Own Parent", func(t *testing.T) {
+		mockRepo := new(MockTaskRepository)
+		mockRepo.On("GetByID", mock.Anything, int64(5)).
+			Return(&model.Task{ID: 5, UserID: 1, Title: "Task", Status: model.StatusPending, Priority: model.PriorityMedium}, nil)
+
+		taskService := service.NewTaskService(mockRepo, new(MockProjectRepository), unblockedDependencyRepo(), nil, nil, nil, testAuthorizer, service.TaskPolicy{})
+		err := taskService.UpdateTask(newTestContext(), &model.Task{ID: 5, Title: "Task", ParentID: &parentID}, 1)
+
+		assert.ErrorIs(t, err, service.ErrSubtaskCycle)
+		mockRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Under Own Subtask", func(t *testing.T) {
+		mockRepo := new(MockTaskRepository)
+		mockRepo.On("GetByID", mock.Anything, int64(10)).
+			Return(&model.Task{ID: 10, UserID: 1, Title: "Task", Status: model.StatusPending, Priority: model.PriorityMedium}, nil)
+		mockRepo.On("GetByID", mock.Anything, int64(5)).Return(&model.Task{ID: 5, UserID: 1}, nil)
+		mockRepo.On("GetAncestorIDs", mock.Anything, int64(5)).Return([]uint{10}, nil)
+
+		taskService := service.NewTaskService(mockRepo, new(MockProjectRepository), unblockedDependencyRepo(), nil, nil, nil, testAuthorizer, service.TaskPolicy{})
+		err := taskService.UpdateTask(newTestContext(), &model.Task{ID: 10, Title: "Task", ParentID: &parentID}, 1)
+
+		assert.ErrorIs(t, err, service.ErrSubtaskCycle)
+		mockRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Move Subtree Too Deep", func(t *testing.T) {
+		mockRepo := new(MockTaskRepository)
+		mockRepo.On("GetByID", mock.Anything, int64(10)).
+			Return(&model.Task{ID: 10, UserID: 1, Title: "Task", Status: model.StatusPending, Priority: model.PriorityMedium}, nil)
+		mockRepo.On("GetByID", mock.Anything, int64(5)).Return(&model.Task{ID: 5, UserID: 1}, nil)
+		mockRepo.On("GetAncestorIDs", mock.Anything, int64(5)).Return([]uint{}, nil)
+		mockRepo.On("SubtreeHeight", mock.Anything, int64(10)).Return(3, nil)
+
+		taskService := service.NewTaskService(mockRepo, new(MockProjectRepository), unblockedDependencyRepo(), nil, nil, nil, testAuthorizer, service.TaskPolicy{MaxSubtaskDepth: 2})
+		err := taskService.UpdateTask(newTestContext(), &model.Task{ID: 10, Title: "Task", ParentID: &parentID}, 1)
+
+		assert.ErrorIs(t, err, service.ErrSubtaskTooDeep)
+		mockRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Unchanged Parent Is Not Rechecked", func(t *testing.T) {
+		mockRepo := new(MockTaskRepository)
+		mockRepo.On("GetByID", mock.Anything, int64(10)).
+			Return(&model.Task{ID: 10, UserID: 1, ParentID: &parentID, Title: "Task", Status: model.StatusPending, Priority: model.PriorityMedium}, nil)
+		mockRepo.On("Update", mock.Anything, mock.Anything).Return(nil)
+
+		sameParentID := parentID
+		taskService := service.NewTaskService(mockRepo, new(MockProjectRepository), unblockedDependencyRepo(), nil, nil, nil, testAuthorizer, service.TaskPolicy{})
+		err := taskService.UpdateTask(newTestContext(), &model.Task{ID: 10, Title: "Renamed", ParentID: &sameParentID}, 1)
+
+		assert.NoError(t, err)
+		mockRepo.AssertNotCalled(t, "GetAncestorIDs", mock.Anything, mock.Anything)
+	})
+}
+
+func TestTaskServiceSubtaskCascade(t *testing.T) {
+	t.Run("Done With Open Subtasks", func(t *testing.T) {
+		mockRepo := new(MockTaskRepository)
+		mockRepo.On("GetByID", mock.Anything, int64(10)).
+			Return(&model.Task{ID: 10, UserID: 1, Title: "Task", Status: model.StatusInProgress, Priority: model.PriorityMedium}, nil)
+		mockRepo.On("CountOpenDescendants", mock.Anything, int64(10)).Return(2, nil)
+
+		taskService := service.NewTaskService(mockRepo, new(MockProjectRepository), unblockedDependencyRepo(), nil, nil, nil, testAuthorizer, service.TaskPolicy{RequireSubtasksClosed: true})
+		err := taskService.UpdateTask(newTestContext(), &model.Task{ID: 10, Title: "Task", Status: model.StatusDone}, 1)
+
+		assert.ErrorIs(t, err, service.ErrOpenSubtasks)
+		mockRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Done With Closed Subtasks", func(t *testing.T) {
+		mockRepo := new(MockTaskRepository)
+		mockRepo.On("GetByID", mock.Anything, int64(10)).
+			Return(&model.Task{ID: 10, UserID: 1, Title: "Task", Status: model.StatusInProgress, Priority: model.PriorityMedium}, nil)
+		mockRepo.On("CountOpenDescendants", mock.Anything, int64(10)).Return(0, nil)
+		mockRepo.On("Update", mock.Anything, mock.Anything).Return(nil)
+
+		taskService := service.NewTaskService(mockRepo, new(MockProjectRepository), unblockedDependencyRepo(), nil, nil, nil, testAuthorizer, service.TaskPolicy{RequireSubtasksClosed: true})
+		err := taskService.UpdateTask(newTestContext(), &model.Task{ID: 10, Title: "Task", Status: model.StatusDone}, 1)
+
+		assert.NoError(t, err)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Done Without Policy", func(t *testing.T) {
+		mockRepo := new(MockTaskRepository)
+		mockRepo.On("GetByID", mock.Anything, int64(10)).
+			Return(&model.Task{ID: 10, UserID: 1, Title: "Task", Status: model.StatusInProgress, Priority: model.PriorityMedium}, nil)
+		mockRepo.On("Update", mock.Anything, mock.Anything).Return(nil)
+
+		taskService := service.NewTaskService(mockRepo, new(MockProjectRepository), unblockedDependencyRepo(), nil, nil, nil, testAuthorizer, service.TaskPolicy{})
+		err := taskService.UpdateTask(newTestContext(), &model.Task{ID: 10, Title: "Task", Status: model.StatusDone}, 1)
+
+		assert.NoError(t, err)
+		mockRepo.AssertNotCalled(t, "CountOpenDescendants", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Cancel Cascades", func(t *testing.T) {
+		mockRepo := new(MockTaskRepository)
+		mockRepo.On("GetByID", mock.Anything, int64(10)).
+			Return(&model.Task{ID: 10, UserID: 1, Title: "Task", Status: model.StatusPending, Priority: model.PriorityMedium}, nil)
+		mockRepo.On("Update", mock.Anything, mock.Anything).Return(nil)
+		mockRepo.On("CancelOpenDescendants", mock.Anything, int64(10)).Return(nil)
+
+		taskService := service.NewTaskService(mockRepo, new(MockProjectRepository), unblockedDependencyRepo(), nil, nil, nil, testAuthorizer, service.TaskPolicy{})
+		err := taskService.UpdateTask(newTestContext(), &model.Task{ID: 10, Title: "Task", Status: model.StatusCancelled}, 1)
+
+		assert.NoError(t, err)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Other Updates Leave Subtasks Alone", func(t *testing.T) {
+		mockRepo := new(MockTaskRepository)
+		mockRepo.On("GetByID", mock.Anything, int64(10)).
+			Return(&model.Task{ID: 10, UserID: 1, Title: "Task", Status: model.StatusCancelled, Priority: model.PriorityMedium}, nil)
+		mockRepo.On("Update", mock.Anything, mock.Anything).Return(nil)
+
+		taskService := service.NewTaskService(mockRepo, new(MockProjectRepository), unblockedDependencyRepo(), nil, nil, nil, testAuthorizer, service.TaskPolicy{})
+		err := taskService.UpdateTask(newTestContext(), &model.Task{ID: 10, Title: "Renamed"}, 1)
+
+		assert.NoError(t, err)
+		mockRepo.AssertNotCalled(t, "CancelOpenDescendants", mock.Anything, mock.Anything)
+	})
+}
+
+func TestTaskServiceAddDependency(t *testing.T) {
+	ownTasks := func() *MockTaskRepository {
+		mockRepo := new(MockTaskRepository)
+		mockRepo.On("GetByID", mock.Anything, int64(10)).Return(&model.Task{ID: 10, UserID: 1}, nil)
+		mockRepo.On("GetByID", mock.Anything, int64(11)).Return(&model.Task{ID: 11, UserID: 1}, nil)
+		mockRepo.On("GetByID", mock.Anything, int64(12)).Return(&model.Task{ID: 12, UserID: 2}, nil)
+		return mockRepo
+	}
+
+	t.Run("Own Tasks", func(t *testing.T) {
+		mockDependencyRepo := new(MockDependencyRepository)
+		mockDependencyRepo.On("DependsOn", mock.Anything, int64(11), int64(10)).Return(false, nil)
+		mockDependencyRepo.On("Add", mock.Anything, int64(10), int64(11)).Return(nil)
+
+		taskService := service.NewTaskService(ownTasks(), new(MockProjectRepository), mockDependencyRepo, nil, nil, nil, testAuthorizer, service.TaskPolicy{})
+		err := taskService.AddDependency(newTestContext(), 10, 11, 1)
+
+		assert.NoError(t, err)
+		mockDependencyRepo.AssertExpectations(t)
+	})
+
+	t.Run("Other User's Blocker", func(t *testing.T) {
+		mockDependencyRepo := new(MockDependencyRepository)
+
+		taskService := service.NewTaskService(ownTasks(), new(MockProjectRepository), mockDependencyRepo, nil, nil, nil, testAuthorizer, service.TaskPolicy{})
+		err := taskService.AddDependency(newTestContext(), 10, 12, 1)
+
+		assert.ErrorIs(t, err, service.ErrBlockerNotFound)
+		mockDependencyRepo.AssertNotCalled(t, "Add", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Self", func(t *testing.T) {
+		mockDependencyRepo := new(MockDependencyRepository)
+
+		taskService := service.NewTaskService(ownTasks(), new(MockProjectRepository), mockDependencyRepo, nil, nil, nil, testAuthorizer, service.TaskPolicy{})
+		err := taskService.AddDependency(newTestContext(), 10, 10, 1)
+
+		assert.ErrorIs(t, err, service.ErrDependencyCycle)
+		mockDependencyRepo.AssertNotCalled(t, "Add", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Cycle", func(t *testing.T) {
+		mockDependencyRepo := new(MockDependencyRepository)
+		mockDependencyRepo.On("DependsOn", mock.Anything, int64(11), int64(10)).Return(true, nil)
+
+		taskService := service.NewTaskService(ownTasks(), new(MockProjectRepository), mockDependencyRepo, nil, nil, nil, testAuthorizer, service.TaskPolicy{})
+		err := taskService.AddDependency(newTestContext(), 10, 11, 1)
+
+		assert.ErrorIs(t, err, service.ErrDependencyCycle)
+		mockDependencyRepo.AssertNotCalled(t, "Add", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestTaskServiceOpenBlockers(t *testing.T) {
+	tests := []struct {
+		name         string
+		from         model.TaskStatus
+		to           model.TaskStatus
+		openBlockers int
+		wantErr      error
+	}{
+		{"Start While Blocked", model.StatusPending, model.StatusInProgress, 1, service.ErrBlockedByOpenTasks},
+		{"Finish While Blocked", model.StatusInProgress, model.StatusDone, 2, service.ErrBlockedByOpenTasks},
+		{"Start When Unblocked", model.StatusPending, model.StatusInProgress, 0, nil},
+		{"Mark Blocked", model.StatusPending, model.StatusBlocked, 1, nil},
+		{"Cancel While Blocked", model.StatusPending, model.StatusCancelled, 1, nil},
+		{"Stay In Progress", model.StatusInProgress, model.StatusInProgress, 1, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(MockTaskRepository)
+			mockRepo.On("GetByID", mock.Anything, int64(10)).
+				Return(&model.Task{ID: 10, UserID: 1, Title: "Task", Status: tt.from, Priority: model.PriorityMedium}, nil)
+			mockRepo.On("Update", mock.Anything, mock.Anything).Return(nil)
+			mockRepo.On("CancelOpenDescendants", mock.Anything, int64(10)).Return(nil)
+			mockDependencyRepo := new(MockDependencyRepository)
+			mockDependencyRepo.On("CountOpenBlockers", mock.Anything, int64(10)).Return(tt.openBlockers, nil).Maybe()
+
+			taskService := service.NewTaskService(mockRepo, new(MockProjectRepository), mockDependencyRepo, nil, nil, nil, testAuthorizer, service.TaskPolicy{})
+			err := taskService.UpdateTask(newTestContext(), &model.Task{ID: 10, Title: "Task", Status: tt.to}, 1)
+
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				mockRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+				return
+			}
+			assert.NoError(t, err)
+			mockRepo.AssertCalled(t, "Update", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestTaskServiceGetDependencyGraph(t *testing.T) {
+	t.Run("Own Task", func(t *testing.T) {
+		mockRepo := new(MockTaskRepository)
+		mockRepo.On("GetByID", mock.Anything, int64(10)).Return(&model.Task{ID: 10, UserID: 1}, nil)
+		mockDependencyRepo := new(MockDependencyRepository)
+		mockDependencyRepo.On("Graph", mock.Anything, int64(10)).Return(
+			[]model.TaskRef{{ID: 10}, {ID: 11}, {ID: 12}},
+			[]model.DependencyEdge{{TaskID: 10, BlockerID: 11}, {TaskID: 11, BlockerID: 12}},
+			nil,
+		)
+
+		taskService := service.NewTaskService(mockRepo, new(MockProjectRepository), mockDependencyRepo, nil, nil, nil, testAuthorizer, service.TaskPolicy{})
+		graph, err := taskService.GetDependencyGraph(newTestContext(), 10, 1)
+
+		assert.NoError(t, err)
+		assert.Equal(t, uint(10), graph.RootID)
+		assert.Len(t, graph.Nodes, 3)
+		assert.Len(t, graph.Edges, 2)
+	})
+
+	t.Run("Other User's Task", func(t *testing.T) {
+		mockRepo := new(MockTaskRepository)
+		mockRepo.On("GetByID", mock.Anything, int64(10)).Return(&model.Task{ID: 10, UserID: 1}, nil)
+		mockDependencyRepo := new(MockDependencyRepository)
+
+		taskService := service.NewTaskService(mockRepo, new(MockProjectRepository), mockDependencyRepo, nil, nil, nil, testAuthorizer, service.TaskPolicy{})
+		_, err := taskService.GetDependencyGraph(newTestContext(), 10, 2)
+
+		assert.ErrorIs(t, err, service.ErrTaskNotFound)
+		mockDependencyRepo.AssertNotCalled(t, "Graph", mock.Anything, mock.Anything)
+	})
+}
+
+type MockActivityRepository struct {
+	mock.Mock
+}
+
+func (m *MockActivityRepository) ListForTask(ctx context.Context, taskID int64) ([]*model.TaskActivity, error) {
+	args := m.Called(ctx, taskID)
+	activity, _ := args.Get(0).([]*model.TaskActivity)
+	return activity, args.Error(1)
+}
+
+func TestTaskServiceAssignTask(t *testing.T) {
+	workspaceID := uint(3)
+	sharedTask := &model.Task{ID: 10, UserID: 1, WorkspaceID: &workspaceID}
+
+	t.Run("Workspace Member", func(t *testing.T) {
+		mockRepo := new(MockTaskRepository)
+		mockRepo.On("GetByID", mock.Anything, int64(10)).Return(sharedTask, nil)
+		mockRepo.On("SetAssignee", mock.Anything, int64(10), mock.MatchedBy(func(assigneeID *uint) bool {
+			return assigneeID != nil && *assigneeID == 5
+		}), int64(2)).Return(nil)
+		mockWorkspaceRepo := new(MockWorkspaceRepository)
+		mockWorkspaceRepo.On("MemberRole", mock.Anything, int64(3), int64(5)).Return(model.RoleViewer, nil)
+
+		taskService := service.NewTaskService(mockRepo, new(MockProjectRepository), unblockedDependencyRepo(), mockWorkspaceRepo, nil, nil, testAuthorizer, service.TaskPolicy{})
+		err := taskService.AssignTask(workspaceContext(3, model.RoleMember), 10, 5, 2)
+
+		assert.NoError(t, err)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Not A Member", func(t *testing.T) {
+		mockRepo := new(MockTaskRepository)
+		mockRepo.On("GetByID", mock.Anything, int64(10)).Return(sharedTask, nil)
+		mockWorkspaceRepo := new(MockWorkspaceRepository)
+		mockWorkspaceRepo.On("MemberRole", mock.Anything, int64(3), int64(9)).Return(model.WorkspaceRole(""), sql.ErrNoRows)
+
+		taskService := service.NewTaskService(mockRepo, new(MockProjectRepository), unblockedDependencyRepo(), mockWorkspaceRepo, nil, nil, testAuthorizer, service.TaskPolicy{})
+		err := taskService.AssignTask(workspaceContext(3, model.RoleMember), 10, 9, 2)
+
+		assert.ErrorIs(t, err, service.ErrInvalidAssignee)
+		mockRepo.AssertNotCalled(t, "SetAssignee", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Viewer Cannot Assign", func(t *testing.T) {
+		mockRepo := new(MockTaskRepository)
+		mockRepo.On("GetByID", mock.Anything, int64(10)).Return(sharedTask, nil)
+
+		taskService := service.NewTaskService(mockRepo, new(MockProjectRepository), unblockedDependencyRepo(), new(MockWorkspaceRepository), nil, nil, testAuthorizer, service.TaskPolicy{})
+		err := taskService.AssignTask(workspaceContext(3, model.RoleViewer), 10, 2, 2)
+
+		assert.ErrorIs(t, err, service.ErrInsufficientRole)
+		mockRepo.AssertNotCalled(t, "SetAssignee", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Personal Task", func(t *testing.T) {
+		mockRepo := new(MockTaskRepository)
+		mockRepo.On("GetByID", mock.Anything, int64(11)).Return(&model.Task{ID: 11, UserID: 1}, nil)
+		mockRepo.On("SetAssignee", mock.Anything, int64(11), mock.Anything, int64(1)).Return(nil)
+
+		taskService := service.NewTaskService(mockRepo, new(MockProjectRepository), unblockedDependencyRepo(), nil, nil, nil, testAuthorizer, service.TaskPolicy{})
+
+		assert.ErrorIs(t, taskService.AssignTask(newTestContext(), 11, 5, 1), service.ErrInvalidAssignee)
+		assert.NoError(t, taskService.AssignTask(newTestContext(), 11, 1, 1))
+	})
+}
+
+func TestTaskServiceUnassignTask(t *testing.T) {
+	mockRepo := new(MockTaskRepository)
+	mockRepo.On("GetByID", mock.Anything, int64(10)).Return(&model.Task{ID: 10, UserID: 1}, nil)
+	mockRepo.On("SetAssignee", mock.Anything, int64(10), (*uint)(nil), int64(1)).Return(nil)
+
+	taskService := service.NewTaskService(mockRepo, new(MockProjectRepository), unblockedDependencyRepo(), nil, nil, nil, testAuthorizer, service.TaskPolicy{})
+	err := taskService.UnassignTask(newTestContext(), 10, 1)
+
+	assert.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestTaskServiceGetActivity(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		mockRepo := new(MockTaskRepository)
+		mockRepo.On("GetByID", mock.Anything, int64(10)).Return(&model.Task{ID: 10, UserID: 1}, nil)
+		assigneeID := uint(1)
+		mockActivityRepo := new(MockActivityRepository)
+		mockActivityRepo.On("ListForTask", mock.Anything, int64(10)).Return([]*model.TaskActivity{
+			{ID: 1, TaskID: 10, ActorID: &assigneeID, Action: model.ActivityAssigned, SubjectID: &assigneeID},
+		}, nil)
+
+		taskService := service.NewTaskService(mockRepo, new(MockProjectRepository), unblockedDependencyRepo(), nil, mockActivityRepo, nil, testAuthorizer, service.TaskPolicy{})
+		activity, err := taskService.GetActivity(newTestContext(), 10, 1)
+
+		assert.NoError(t, err)
+		assert.Len(t, activity, 1)
+	})
+
+	t.Run("Other User's Task", func(t *testing.T) {
+		mockRepo := new(MockTaskRepository)
+		mockRepo.On("GetByID", mock.Anything, int64(10)).Return(&model.Task{ID: 10, UserID: 2}, nil)
+		mockActivityRepo := new(MockActivityRepository)
+
+		taskService := service.NewTaskService(mockRepo, new(MockProjectRepository), unblockedDependencyRepo(), nil, mockActivityRepo, nil, testAuthorizer, service.TaskPolicy{})
+		_, err := taskService.GetActivity(newTestContext(), 10, 1)
+
+		assert.ErrorIs(t, err, service.ErrTaskNotFound)
+		mockActivityRepo.AssertNotCalled(t, "ListForTask", mock.Anything, mock.Anything)
+	})
+}
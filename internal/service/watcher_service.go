@@ -0,0 +1,44 @@
+package service
+
+import (
+	"github.com/ahmednurovic/task-manager-api/internal/authz"
+	"github.com/ahmednurovic/task-manager-api/internal/model"
+	"github.com/ahmednurovic/task-manager-api/internal/repository"
+	"github.com/gin-gonic/gin"
+)
+
+type WatcherService struct {
+	watcherRepo repository.WatcherRepository
+	taskRepo    repository.TaskRepository
+	authorizer  *authz.Authorizer
+}
+
+func NewWatcherService(watcherRepo repository.WatcherRepository, taskRepo repository.TaskRepository, authorizer *authz.Authorizer) *WatcherService {
+	return &WatcherService{watcherRepo: watcherRepo, taskRepo: taskRepo, authorizer: authorizer}
+}
+
+func (s *WatcherService) GetWatchers(ctx *gin.Context, taskID int64, userID int64) ([]*model.TaskWatcher, error) {
+	if _, err := getTask(ctx, s.taskRepo, s.authorizer, taskID, userID, authz.ReadTasks); err != nil {
+		return nil, err
+	}
+
+	return s.watcherRepo.ListForTask(ctx, taskID)
+}
+
+// WatchTask makes the user follow a task they can see. Watching a task
+// twice is a no-op.
+func (s *WatcherService) WatchTask(ctx *gin.Context, taskID int64, userID int64) error {
+	if _, err := getTask(ctx, s.taskRepo, s.authorizer, taskID, userID, authz.WatchTasks); err != nil {
+		return err
+	}
+
+	return s.watcherRepo.Add(ctx, taskID, userID)
+}
+
+func (s *WatcherService) UnwatchTask(ctx *gin.Context, taskID int64, userID int64) error {
+	if _, err := getTask(ctx, s.taskRepo, s.authorizer, taskID, userID, authz.WatchTasks); err != nil {
+		return err
+	}
+
+	return s.watcherRepo.Remove(ctx, taskID, userID)
+}
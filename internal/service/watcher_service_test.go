@@ -0,0 +1,88 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/ahmednurovic/task-manager-api/internal/model"
+	"github.com/ahmednurovic/task-manager-api/internal/service"
+)
+
+type MockWatcherRepository struct {
+	mock.Mock
+}
+
+func (m *MockWatcherRepository) Add(ctx context.Context, taskID int64, userID int64) error {
+	args := m.Called(ctx, taskID, userID)
+	return args.Error(0)
+}
+
+func (m *MockWatcherRepository) Remove(ctx context.Context, taskID int64, userID int64) error {
+	args := m.Called(ctx, taskID, userID)
+	return args.Error(0)
+}
+
+func (m *MockWatcherRepository) ListForTask(ctx context.Context, taskID int64) ([]*model.TaskWatcher, error) {
+	args := m.Called(ctx, taskID)
+	watchers, _ := args.Get(0).([]*model.TaskWatcher)
+	return watchers, args.Error(1)
+}
+
+func TestWatcherServiceWatchTask(t *testing.T) {
+	workspaceID := uint(3)
+
+	t.Run("Viewer Watches", func(t *testing.T) {
+		mockTaskRepo := new(MockTaskRepository)
+		mockTaskRepo.On("GetByID", mock.Anything, int64(10)).Return(&model.Task{ID: 10, UserID: 1, WorkspaceID: &workspaceID}, nil)
+		mockWatcherRepo := new(MockWatcherRepository)
+		mockWatcherRepo.On("Add", mock.Anything, int64(10), int64(2)).Return(nil)
+
+		watcherService := service.NewWatcherService(mockWatcherRepo, mockTaskRepo, testAuthorizer)
+		err := watcherService.WatchTask(workspaceContext(3, model.RoleViewer), 10, 2)
+
+		assert.NoError(t, err)
+		mockWatcherRepo.AssertExpectations(t)
+	})
+
+	t.Run("Task In Other Space", func(t *testing.T) {
+		mockTaskRepo := new(MockTaskRepository)
+		mockTaskRepo.On("GetByID", mock.Anything, int64(10)).Return(&model.Task{ID: 10, UserID: 1, WorkspaceID: &workspaceID}, nil)
+		mockWatcherRepo := new(MockWatcherRepository)
+
+		watcherService := service.NewWatcherService(mockWatcherRepo, mockTaskRepo, testAuthorizer)
+		err := watcherService.WatchTask(newTestContext(), 10, 2)
+
+		assert.ErrorIs(t, err, service.ErrTaskNotFound)
+		mockWatcherRepo.AssertNotCalled(t, "Add", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestWatcherServiceUnwatchTask(t *testing.T) {
+	mockTaskRepo := new(MockTaskRepository)
+	mockTaskRepo.On("GetByID", mock.Anything, int64(10)).Return(&model.Task{ID: 10, UserID: 2}, nil)
+	mockWatcherRepo := new(MockWatcherRepository)
+	mockWatcherRepo.On("Remove", mock.Anything, int64(10), int64(2)).Return(nil)
+
+	watcherService := service.NewWatcherService(mockWatcherRepo, mockTaskRepo, testAuthorizer)
+	err := watcherService.UnwatchTask(newTestContext(), 10, 2)
+
+	assert.NoError(t, err)
+	mockWatcherRepo.AssertExpectations(t)
+}
+
+func TestWatcherServiceGetWatchers(t *testing.T) {
+	mockTaskRepo := new(MockTaskRepository)
+	mockTaskRepo.On("GetByID", mock.Anything, int64(10)).Return(&model.Task{ID: 10, UserID: 2}, nil)
+	mockWatcherRepo := new(MockWatcherRepository)
+	mockWatcherRepo.On("ListForTask", mock.Anything, int64(10)).
+		Return([]*model.TaskWatcher{{TaskID: 10, UserID: 2, Email: "me@example.com"}}, nil)
+
+	watcherService := service.NewWatcherService(mockWatcherRepo, mockTaskRepo, testAuthorizer)
+	watchers, err := watcherService.GetWatchers(newTestContext(), 10, 2)
+
+	assert.NoError(t, err)
+	assert.Len(t, watchers, 1)
+}
@@ -0,0 +1,399 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ahmednurovic/task-manager-api/internal/authz"
+	"github.com/ahmednurovic/task-manager-api/internal/mail"
+	"github.com/ahmednurovic/task-manager-api/internal/model"
+	"github.com/ahmednurovic/task-manager-api/internal/repository"
+	"github.com/gin-gonic/gin"
+)
+
+const maxWorkspaceNameLength = 100
+
+// DefaultWorkspaceInvitationTTL is used when a WorkspacePolicy leaves
+// InvitationTTL unset.
+const DefaultWorkspaceInvitationTTL = 7 * 24 * time.Hour
+
+// WorkspacePolicy holds the settings for workspace invitations.
+type WorkspacePolicy struct {
+	InvitationTTL time.Duration
+	// InvitationURL is the page that accepts or declines an invitation,
+	// with the token added as the token query parameter.
+	InvitationURL string
+}
+
+type WorkspaceService struct {
+	workspaceRepo repository.WorkspaceRepository
+	userRepo      repository.UserRepository
+	mailer        mail.Mailer
+	authorizer    *authz.Authorizer
+	policy        WorkspacePolicy
+}
+
+func NewWorkspaceService(
+	workspaceRepo repository.WorkspaceRepository,
+	userRepo repository.UserRepository,
+	mailer mail.Mailer,
+	authorizer *authz.Authorizer,
+	policy WorkspacePolicy,
+) *WorkspaceService {
+	if policy.InvitationTTL <= 0 {
+		policy.InvitationTTL = DefaultWorkspaceInvitationTTL
+	}
+	return &WorkspaceService{
+		workspaceRepo: workspaceRepo,
+		userRepo:      userRepo,
+		mailer:        mailer,
+		authorizer:    authorizer,
+		policy:        policy,
+	}
+}
+
+// CreateWorkspace adds a workspace with the user as its owner.
+func (s *WorkspaceService) CreateWorkspace(ctx *gin.Context, workspace *model.Workspace, userID int64) error {
+	if err := normalizeWorkspace(workspace); err != nil {
+		return err
+	}
+
+	workspace.ID = 0
+	return s.workspaceRepo.Create(ctx, workspace, userID)
+}
+
+// GetWorkspaces returns the workspaces the user is a member of.
+func (s *WorkspaceService) GetWorkspaces(ctx *gin.Context, userID int64) ([]*model.Workspace, error) {
+	return s.workspaceRepo.ListForUser(ctx, userID)
+}
+
+func (s *WorkspaceService) GetWorkspace(ctx *gin.Context, workspaceID int64, userID int64) (*model.Workspace, error) {
+	return s.getWorkspace(ctx, workspaceID, userID, authz.ReadWorkspace)
+}
+
+// UpdateWorkspace renames a workspace. Only owners may do so.
+func (s *WorkspaceService) UpdateWorkspace(ctx *gin.Context, workspace *model.Workspace, userID int64) error {
+	if err := normalizeWorkspace(workspace); err != nil {
+		return err
+	}
+
+	existing, err := s.getWorkspace(ctx, int64(workspace.ID), userID, authz.ManageWorkspace)
+	if err != nil {
+		return err
+	}
+
+	existing.Name = workspace.Name
+	if err := s.workspaceRepo.Update(ctx, existing); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrWorkspaceNotFound
+		}
+		return err
+	}
+
+	*workspace = *existing
+	return nil
+}
+
+// DeleteWorkspace removes a workspace together with its projects and
+// tasks. Only owners may do so.
+func (s *WorkspaceService) DeleteWorkspace(ctx *gin.Context, workspaceID int64, userID int64) error {
+	if _, err := s.getWorkspace(ctx, workspaceID, userID, authz.ManageWorkspace); err != nil {
+		return err
+	}
+
+	if err := s.workspaceRepo.Delete(ctx, workspaceID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrWorkspaceNotFound
+		}
+		return err
+	}
+
+	return nil
+}
+
+func (s *WorkspaceService) GetMembers(ctx *gin.Context, workspaceID int64, userID int64) ([]*model.WorkspaceMember, error) {
+	if _, err := s.getWorkspace(ctx, workspaceID, userID, authz.ReadWorkspace); err != nil {
+		return nil, err
+	}
+
+	return s.workspaceRepo.ListMembers(ctx, workspaceID)
+}
+
+// UpdateMemberRole changes a member's role. Admins manage everyone below
+// owner; only owners may appoint or demote owners, and the last owner
+// cannot be demoted.
+func (s *WorkspaceService) UpdateMemberRole(ctx *gin.Context, workspaceID int64, memberID int64, role model.WorkspaceRole, userID int64) error {
+	if !role.Valid() {
+		return ErrInvalidWorkspaceRole
+	}
+
+	workspace, err := s.getWorkspace(ctx, workspaceID, userID, authz.ManageMembers)
+	if err != nil {
+		return err
+	}
+
+	current, err := s.memberRole(ctx, workspaceID, memberID)
+	if err != nil {
+		return err
+	}
+	if (role == model.RoleOwner || current == model.RoleOwner) && !s.can(workspace, userID, authz.ManageWorkspace) {
+		return ErrInsufficientRole
+	}
+
+	if err := s.workspaceRepo.UpdateMemberRole(ctx, workspaceID, memberID, role); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrLastOwner
+		}
+		return err
+	}
+
+	return nil
+}
+
+// RemoveMember takes a user out of a workspace. Members may always leave
+// themselves; removing others takes an admin, and removing an owner takes
+// an owner. The last owner cannot leave.
+func (s *WorkspaceService) RemoveMember(ctx *gin.Context, workspaceID int64, memberID int64, userID int64) error {
+	action := authz.ManageMembers
+	if memberID == userID {
+		action = authz.ReadWorkspace
+	}
+
+	workspace, err := s.getWorkspace(ctx, workspaceID, userID, action)
+	if err != nil {
+		return err
+	}
+
+	current, err := s.memberRole(ctx, workspaceID, memberID)
+	if err != nil {
+		return err
+	}
+	if current == model.RoleOwner && !s.can(workspace, userID, authz.ManageWorkspace) {
+		return ErrInsufficientRole
+	}
+
+	if err := s.workspaceRepo.RemoveMember(ctx, workspaceID, memberID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrLastOwner
+		}
+		return err
+	}
+
+	return nil
+}
+
+// InviteMember emails an invitation to join the workspace with the given
+// role. Inviting takes an admin, and inviting an owner takes an owner.
+func (s *WorkspaceService) InviteMember(ctx *gin.Context, invitation *model.WorkspaceInvitation, userID int64) error {
+	invitation.Email = strings.TrimSpace(invitation.Email)
+	if invitation.Email == "" {
+		return ErrInvitationEmailRequired
+	}
+	if !invitation.Role.Valid() {
+		return ErrInvalidWorkspaceRole
+	}
+
+	workspace, err := s.getWorkspace(ctx, int64(invitation.WorkspaceID), userID, authz.ManageMembers)
+	if err != nil {
+		return err
+	}
+	if !workspace.Role.AtLeast(invitation.Role) {
+		return ErrInsufficientRole
+	}
+
+	token, err := randomToken(32, base64.RawURLEncoding.EncodeToString)
+	if err != nil {
+		return ErrTokenGeneration
+	}
+
+	invitation.ID = 0
+	invitation.TokenHash = hashToken(token)
+	invitation.InvitedBy = uint(userID)
+	invitation.ExpiresAt = time.Now().Add(s.policy.InvitationTTL).UTC()
+	invitation.AcceptedAt = nil
+	invitation.DeclinedAt = nil
+	if err := s.workspaceRepo.CreateInvitation(ctx, invitation); err != nil {
+		return err
+	}
+
+	link, err := tokenLink(s.policy.InvitationURL, token)
+	if err != nil {
+		return err
+	}
+
+	return s.mailer.Send(ctx, mail.Message{
+		To:      invitation.Email,
+		Subject: fmt.Sprintf("You're invited to %s", workspace.Name),
+		Body: fmt.Sprintf("You have been invited to join the workspace %q as %s.\n\n"+
+			"To accept or decline, open this link within %s:\n\n%s\n\n"+
+			"If you weren't expecting this, you can ignore this email.\n",
+			workspace.Name, invitation.Role, s.policy.InvitationTTL, link),
+	})
+}
+
+// AcceptInvitation adds the user to the workspace of an invitation sent to
+// their email address, and returns the workspace.
+func (s *WorkspaceService) AcceptInvitation(ctx *gin.Context, token string, userID int64) (*model.Workspace, error) {
+	invitation, err := s.getPendingInvitation(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.userRepo.GetUserByID(ctx, uint(userID))
+	if err != nil {
+		return nil, err
+	}
+	if user == nil || !strings.EqualFold(user.Email, invitation.Email) {
+		return nil, ErrInvitationEmailMismatch
+	}
+
+	if err := s.workspaceRepo.AcceptInvitation(ctx, int64(invitation.ID), userID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrInvalidInvitation
+		}
+		return nil, err
+	}
+
+	return s.getWorkspace(ctx, int64(invitation.WorkspaceID), userID, authz.ReadWorkspace)
+}
+
+// DeclineInvitation turns an invitation down. The token is proof enough
+// that the recipient declined, so no login is needed.
+func (s *WorkspaceService) DeclineInvitation(ctx *gin.Context, token string) error {
+	invitation, err := s.getPendingInvitation(ctx, token)
+	if err != nil {
+		return err
+	}
+
+	if err := s.workspaceRepo.DeclineInvitation(ctx, int64(invitation.ID)); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrInvalidInvitation
+		}
+		return err
+	}
+
+	return nil
+}
+
+// MemberRole returns the user's role in a workspace, or "" if the user is
+// not a member.
+func (s *WorkspaceService) MemberRole(ctx context.Context, workspaceID uint, userID uint) (model.WorkspaceRole, error) {
+	role, err := s.workspaceRepo.MemberRole(ctx, int64(workspaceID), int64(userID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+	return role, err
+}
+
+// getWorkspace loads a workspace in which the user may perform action.
+// Workspaces the user is not a member of are hidden behind
+// ErrWorkspaceNotFound.
+func (s *WorkspaceService) getWorkspace(ctx *gin.Context, workspaceID int64, userID int64, action authz.Action) (*model.Workspace, error) {
+	workspace, err := s.workspaceRepo.GetForMember(ctx, workspaceID, userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrWorkspaceNotFound
+		}
+		return nil, err
+	}
+
+	if !s.can(workspace, userID, action) {
+		return nil, ErrInsufficientRole
+	}
+
+	return workspace, nil
+}
+
+// can reports whether the user may perform action in a workspace loaded
+// for them. Workspace endpoints name the workspace in the path, so the
+// user's role comes from the workspace rather than the request.
+func (s *WorkspaceService) can(workspace *model.Workspace, userID int64, action authz.Action) bool {
+	p := authz.Principal{UserID: uint(userID), WorkspaceID: &workspace.ID, Role: workspace.Role}
+	return s.authorizer.Can(p, action, authz.Resource{Type: "workspace", ID: workspace.ID, WorkspaceID: &workspace.ID})
+}
+
+func (s *WorkspaceService) memberRole(ctx *gin.Context, workspaceID int64, memberID int64) (model.WorkspaceRole, error) {
+	role, err := s.workspaceRepo.MemberRole(ctx, workspaceID, memberID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", ErrMemberNotFound
+		}
+		return "", err
+	}
+	return role, nil
+}
+
+func (s *WorkspaceService) getPendingInvitation(ctx *gin.Context, token string) (*model.WorkspaceInvitation, error) {
+	invitation, err := s.workspaceRepo.GetInvitationByHash(ctx, hashToken(token))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrInvalidInvitation
+		}
+		return nil, err
+	}
+
+	if !invitation.Pending(time.Now()) {
+		return nil, ErrInvalidInvitation
+	}
+
+	return invitation, nil
+}
+
+// principal returns who the request acts for: the user, in the workspace
+// AuthMiddleware resolved for the request with their role in it, or else
+// in their personal space.
+func principal(ctx *gin.Context, userID int64) authz.Principal {
+	p := authz.Principal{UserID: uint(userID)}
+	value, exists := ctx.Get("workspaceID")
+	workspaceID, ok := value.(uint)
+	if !exists || !ok {
+		return p
+	}
+
+	value, _ = ctx.Get("workspaceRole")
+	p.WorkspaceID = &workspaceID
+	p.Role, _ = value.(model.WorkspaceRole)
+	return p
+}
+
+// authorize checks that the request may perform action in the space it
+// acts in, and returns the space's workspace, or nil for the personal
+// space.
+func authorize(ctx *gin.Context, authorizer *authz.Authorizer, userID int64, action authz.Action) (*uint, error) {
+	p := principal(ctx, userID)
+	if !authorizer.Can(p, action, authz.Space(p)) {
+		return nil, ErrInsufficientRole
+	}
+	return p.WorkspaceID, nil
+}
+
+// authorizeResource checks that the request may perform action on a
+// resource it loaded. A resource the user may not even read, such as one in
+// another space, gives notFound so that callers cannot probe which IDs
+// exist; one they may read but not change gives ErrInsufficientRole.
+func authorizeResource(ctx *gin.Context, authorizer *authz.Authorizer, userID int64, read authz.Action, action authz.Action, resource authz.Resource, notFound error) error {
+	p := principal(ctx, userID)
+	if !authorizer.Can(p, read, resource) {
+		return notFound
+	}
+	if action != read && !authorizer.Can(p, action, resource) {
+		return ErrInsufficientRole
+	}
+	return nil
+}
+
+func normalizeWorkspace(workspace *model.Workspace) error {
+	workspace.Name = strings.TrimSpace(workspace.Name)
+	if workspace.Name == "" {
+		return ErrWorkspaceNameRequired
+	}
+	if len([]rune(workspace.Name)) > maxWorkspaceNameLength {
+		return ErrWorkspaceNameTooLong
+	}
+	return nil
+}
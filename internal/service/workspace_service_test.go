@@ -0,0 +1,585 @@
+package service_test
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/ahmednurovic/task-manager-api/internal/mail"
+	"github.com/ahmednurovic/task-manager-api/internal/model"
+	"github.com/ahmednurovic/task-manager-api/internal/service"
+)
+
+type MockWorkspaceRepository struct {
+	mock.Mock
+}
+
+func (m *MockWorkspaceRepository) Create(ctx context.Context, workspace *model.Workspace, ownerID int64) error {
+	args := m.Called(ctx, workspace, ownerID)
+	return args.Error(0)
+}
+
+func (m *MockWorkspaceRepository) GetForMember(ctx context.Context, workspaceID int64, userID int64) (*model.Workspace, error) {
+	args := m.Called(ctx, workspaceID, userID)
+	workspace, _ := args.Get(0).(*model.Workspace)
+	return workspace, args.Error(1)
+}
+
+func (m *MockWorkspaceRepository) ListForUser(ctx context.Context, userID int64) ([]*model.Workspace, error) {
+	args := m.Called(ctx, userID)
+	workspaces, _ := args.Get(0).([]*model.Workspace)
+	return workspaces, args.Error(1)
+}
+
+func (m *MockWorkspaceRepository) Update(ctx context.Context, workspace *model.Workspace) error {
+	args := m.Called(ctx, workspace)
+	return args.Error(0)
+}
+
+func (m *MockWorkspaceRepository) Delete(ctx context.Context, workspaceID int64) error {
+	args := m.Called(ctx, workspaceID)
+	return args.Error(0)
+}
+
+func (m *MockWorkspaceRepository) MemberRole(ctx context.Context, workspaceID int64, userID int64) (model.WorkspaceRole, error) {
+	args := m.Called(ctx, workspaceID, userID)
+	return args.Get(0).(model.WorkspaceRole), args.Error(1)
+}
+
+func (m *MockWorkspaceRepository) ListMembers(ctx context.Context, workspaceID int64) ([]*model.WorkspaceMember, error) {
+	args := m.Called(ctx, workspaceID)
+	members, _ := args.Get(0).([]*model.WorkspaceMember)
+	return members, args.Error(1)
+}
+
+func (m *MockWorkspaceRepository) UpdateMemberRole(ctx context.Context, workspaceID int64, userID int64, role model.WorkspaceRole) error {
+	args := m.Called(ctx, workspaceID, userID, role)
+	return args.Error(0)
+}
+
+func (m *MockWorkspaceRepository) RemoveMember(ctx context.Context, workspaceID int64, userID int64) error {
+	args := m.Called(ctx, workspaceID, userID)
+	return args.Error(0)
+}
+
+func (m *MockWorkspaceRepository) CreateInvitation(ctx context.Context, invitation *model.WorkspaceInvitation) error {
+	args := m.Called(ctx, invitation)
+	return args.Error(0)
+}
+
+func (m *MockWorkspaceRepository) GetInvitationByHash(ctx context.Context, tokenHash string) (*model.WorkspaceInvitation, error) {
+	args := m.Called(ctx, tokenHash)
+	invitation, _ := args.Get(0).(*model.WorkspaceInvitation)
+	return invitation, args.Error(1)
+}
+
+func (m *MockWorkspaceRepository) AcceptInvitation(ctx context.Context, invitationID int64, userID int64) error {
+	args := m.Called(ctx, invitationID, userID)
+	return args.Error(0)
+}
+
+func (m *MockWorkspaceRepository) DeclineInvitation(ctx context.Context, invitationID int64) error {
+	args := m.Called(ctx, invitationID)
+	return args.Error(0)
+}
+
+// workspaceContext is a request that AuthMiddleware switched to a
+// workspace.
+func workspaceContext(workspaceID uint, role model.WorkspaceRole) *gin.Context {
+	c := newTestContext()
+	c.Set("workspaceID", workspaceID)
+	c.Set("workspaceRole", role)
+	return c
+}
+
+// memberOf makes user a member of workspace 3 with the given role.
+func memberOf(repo *MockWorkspaceRepository, userID int64, role model.WorkspaceRole) {
+	repo.On("GetForMember", mock.Anything, int64(3), userID).
+		Return(&model.Workspace{ID: 3, Name: "Platform", Role: role}, nil)
+}
+
+func TestWorkspaceServiceCreateWorkspace(t *testing.T) {
+	t.Run("Creator Owns It", func(t *testing.T) {
+		mockRepo := new(MockWorkspaceRepository)
+		mockRepo.On("Create", mock.Anything, mock.MatchedBy(func(workspace *model.Workspace) bool {
+			return workspace.Name == "Platform" && workspace.ID == 0
+		}), int64(1)).Return(nil)
+
+		workspaceService := service.NewWorkspaceService(mockRepo, nil, nil, testAuthorizer, service.WorkspacePolicy{})
+		err := workspaceService.CreateWorkspace(newTestContext(), &model.Workspace{ID: 9, Name: "  Platform "}, 1)
+
+		assert.NoError(t, err)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Name Required", func(t *testing.T) {
+		mockRepo := new(MockWorkspaceRepository)
+
+		workspaceService := service.NewWorkspaceService(mockRepo, nil, nil, testAuthorizer, service.WorkspacePolicy{})
+		err := workspaceService.CreateWorkspace(newTestContext(), &model.Workspace{Name: " "}, 1)
+
+		assert.ErrorIs(t, err, service.ErrWorkspaceNameRequired)
+		mockRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestWorkspaceServiceRoles(t *testing.T) {
+	t.Run("Non Member Sees Nothing", func(t *testing.T) {
+		mockRepo := new(MockWorkspaceRepository)
+		mockRepo.On("GetForMember", mock.Anything, int64(3), int64(2)).Return(nil, sql.ErrNoRows)
+
+		workspaceService := service.NewWorkspaceService(mockRepo, nil, nil, testAuthorizer, service.WorkspacePolicy{})
+		_, err := workspaceService.GetMembers(newTestContext(), 3, 2)
+
+		assert.ErrorIs(t, err, service.ErrWorkspaceNotFound)
+	})
+
+	t.Run("Only Owners Delete", func(t *testing.T) {
+		mockRepo := new(MockWorkspaceRepository)
+		memberOf(mockRepo, 2, model.RoleAdmin)
+
+		workspaceService := service.NewWorkspaceService(mockRepo, nil, nil, testAuthorizer, service.WorkspacePolicy{})
+		err := workspaceService.DeleteWorkspace(newTestContext(), 3, 2)
+
+		assert.ErrorIs(t, err, service.ErrInsufficientRole)
+		mockRepo.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Admin Changes Member Role", func(t *testing.T) {
+		mockRepo := new(MockWorkspaceRepository)
+		memberOf(mockRepo, 2, model.RoleAdmin)
+		mockRepo.On("MemberRole", mock.Anything, int64(3), int64(5)).Return(model.RoleViewer, nil)
+		mockRepo.On("UpdateMemberRole", mock.Anything, int64(3), int64(5), model.RoleMember).Return(nil)
+
+		workspaceService := service.NewWorkspaceService(mockRepo, nil, nil, testAuthorizer, service.WorkspacePolicy{})
+		err := workspaceService.UpdateMemberRole(newTestContext(), 3, 5, model.RoleMember, 2)
+
+		assert.NoError(t, err)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Admin Cannot Appoint Owner", func(t *testing.T) {
+		mockRepo := new(MockWorkspaceRepository)
+		memberOf(mockRepo, 2, model.RoleAdmin)
+		mockRepo.On("MemberRole", mock.Anything, int64(3), int64(5)).Return(model.RoleMember, nil)
+
+		workspaceService := service.NewWorkspaceService(mockRepo, nil, nil, testAuthorizer, service.WorkspacePolicy{})
+		err := workspaceService.UpdateMemberRole(newTestContext(), 3, 5, model.RoleOwner, 2)
+
+		assert.ErrorIs(t, err, service.ErrInsufficientRole)
+		mockRepo.AssertNotCalled(t, "UpdateMemberRole", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Member Cannot Change Roles", func(t *testing.T) {
+		mockRepo := new(MockWorkspaceRepository)
+		memberOf(mockRepo, 2, model.RoleMember)
+
+		workspaceService := service.NewWorkspaceService(mockRepo, nil, nil, testAuthorizer, service.WorkspacePolicy{})
+		err := workspaceService.UpdateMemberRole(newTestContext(), 3, 5, model.RoleViewer, 2)
+
+		assert.ErrorIs(t, err, service.ErrInsufficientRole)
+	})
+
+	t.Run("Last Owner Cannot Step Down", func(t *testing.T) {
+		mockRepo := new(MockWorkspaceRepository)
+		memberOf(mockRepo, 1, model.RoleOwner)
+		mockRepo.On("MemberRole", mock.Anything, int64(3), int64(1)).Return(model.RoleOwner, nil)
+		mockRepo.On("UpdateMemberRole", mock.Anything, int64(3), int64(1), model.RoleAdmin).Return(sql.ErrNoRows)
+
+		workspaceService := service.NewWorkspaceService(mockRepo, nil, nil, testAuthorizer, service.WorkspacePolicy{})
+		err := workspaceService.UpdateMemberRole(newTestContext(), 3, 1, model.RoleAdmin, 1)
+
+		assert.ErrorIs(t, err, service.ErrLastOwner)
+	})
+
+	t.Run("Unknown Role", func(t *testing.T) {
+		workspaceService := service.NewWorkspaceService(new(MockWorkspaceRepository), nil, nil, testAuthorizer, service.WorkspacePolicy{})
+		err := workspaceService.UpdateMemberRole(newTestContext(), 3, 5, "superuser", 1)
+
+		assert.ErrorIs(t, err, service.ErrInvalidWorkspaceRole)
+	})
+
+	t.Run("Viewer Leaves", func(t *testing.T) {
+		mockRepo := new(MockWorkspaceRepository)
+		memberOf(mockRepo, 5, model.RoleViewer)
+		mockRepo.On("MemberRole", mock.Anything, int64(3), int64(5)).Return(model.RoleViewer, nil)
+		mockRepo.On("RemoveMember", mock.Anything, int64(3), int64(5)).Return(nil)
+
+		workspaceService := service.NewWorkspaceService(mockRepo, nil, nil, testAuthorizer, service.WorkspacePolicy{})
+		err := workspaceService.RemoveMember(newTestContext(), 3, 5, 5)
+
+		assert.NoError(t, err)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Admin Cannot Remove Owner", func(t *testing.T) {
+		mockRepo := new(MockWorkspaceRepository)
+		memberOf(mockRepo, 2, model.RoleAdmin)
+		mockRepo.On("MemberRole", mock.Anything, int64(3), int64(1)).Return(model.RoleOwner, nil)
+
+		workspaceService := service.NewWorkspaceService(mockRepo, nil, nil, testAuthorizer, service.WorkspacePolicy{})
+		err := workspaceService.RemoveMember(newTestContext(), 3, 1, 2)
+
+		assert.ErrorIs(t, err, service.ErrInsufficientRole)
+		mockRepo.AssertNotCalled(t, "RemoveMember", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Removing Non Member", func(t *testing.T) {
+		mockRepo := new(MockWorkspaceRepository)
+		memberOf(mockRepo, 2, model.RoleAdmin)
+		mockRepo.On("MemberRole", mock.Anything, int64(3), int64(9)).Return(model.WorkspaceRole(""), sql.ErrNoRows)
+
+		workspaceService := service.NewWorkspaceService(mockRepo, nil, nil, testAuthorizer, service.WorkspacePolicy{})
+		err := workspaceService.RemoveMember(newTestContext(), 3, 9, 2)
+
+		assert.ErrorIs(t, err, service.ErrMemberNotFound)
+	})
+}
+
+// TestWorkspaceServiceRouteRoles checks the role each /workspaces/:id route
+// needs. Those routes have no Authorize middleware, since the workspace they
+// name need not be the one the request acts in, so these are the only
+// checks guarding them.
+func TestWorkspaceServiceRouteRoles(t *testing.T) {
+	routes := []struct {
+		route   string
+		minimum model.WorkspaceRole
+		call    func(workspaceService *service.WorkspaceService) error
+	}{
+		{"GET /workspaces/:id", model.RoleViewer, func(workspaceService *service.WorkspaceService) error {
+			_, err := workspaceService.GetWorkspace(newTestContext(), 3, 2)
+			return err
+		}},
+		{"PUT /workspaces/:id", model.RoleOwner, func(workspaceService *service.WorkspaceService) error {
+			return workspaceService.UpdateWorkspace(newTestContext(), &model.Workspace{ID: 3, Name: "Renamed"}, 2)
+		}},
+		{"DELETE /workspaces/:id", model.RoleOwner, func(workspaceService *service.WorkspaceService) error {
+			return workspaceService.DeleteWorkspace(newTestContext(), 3, 2)
+		}},
+		{"GET /workspaces/:id/members", model.RoleViewer, func(workspaceService *service.WorkspaceService) error {
+			_, err := workspaceService.GetMembers(newTestContext(), 3, 2)
+			return err
+		}},
+		{"PUT /workspaces/:id/members/:user_id", model.RoleAdmin, func(workspaceService *service.WorkspaceService) error {
+			return workspaceService.UpdateMemberRole(newTestContext(), 3, 5, model.RoleMember, 2)
+		}},
+		{"DELETE /workspaces/:id/members/:user_id", model.RoleAdmin, func(workspaceService *service.WorkspaceService) error {
+			return workspaceService.RemoveMember(newTestContext(), 3, 5, 2)
+		}},
+		{"POST /workspaces/:id/invitations", model.RoleAdmin, func(workspaceService *service.WorkspaceService) error {
+			invitation := &model.WorkspaceInvitation{WorkspaceID: 3, Email: "colleague@example.com", Role: model.RoleViewer}
+			return workspaceService.InviteMember(newTestContext(), invitation, 2)
+		}},
+	}
+	policy := service.WorkspacePolicy{InvitationTTL: time.Hour, InvitationURL: "https://app.example.com/invitations"}
+
+	for _, route := range routes {
+		for _, role := range []model.WorkspaceRole{model.RoleViewer, model.RoleMember, model.RoleAdmin, model.RoleOwner} {
+			t.Run(route.route+" As "+string(role), func(t *testing.T) {
+				mockRepo := new(MockWorkspaceRepository)
+				memberOf(mockRepo, 2, role)
+				mockRepo.On("Update", mock.Anything, mock.Anything).Return(nil)
+				mockRepo.On("Delete", mock.Anything, int64(3)).Return(nil)
+				mockRepo.On("ListMembers", mock.Anything, int64(3)).Return([]*model.WorkspaceMember{}, nil)
+				mockRepo.On("MemberRole", mock.Anything, int64(3), int64(5)).Return(model.RoleViewer, nil)
+				mockRepo.On("UpdateMemberRole", mock.Anything, int64(3), int64(5), model.RoleMember).Return(nil)
+				mockRepo.On("RemoveMember", mock.Anything, int64(3), int64(5)).Return(nil)
+				mockRepo.On("CreateInvitation", mock.Anything, mock.Anything).Return(nil)
+
+				workspaceService := service.NewWorkspaceService(mockRepo, nil, mail.NewLogMailer(io.Discard), testAuthorizer, policy)
+				err := route.call(workspaceService)
+
+				if role.AtLeast(route.minimum) {
+					assert.NoError(t, err)
+				} else {
+					assert.ErrorIs(t, err, service.ErrInsufficientRole)
+				}
+			})
+		}
+	}
+}
+
+func TestWorkspaceServiceInvitations(t *testing.T) {
+	policy := service.WorkspacePolicy{InvitationTTL: time.Hour, InvitationURL: "https://app.example.com/invitations"}
+	pending := func() *model.WorkspaceInvitation {
+		return &model.WorkspaceInvitation{
+			ID: 4, WorkspaceID: 3, Email: "Colleague@example.com", Role: model.RoleMember,
+			ExpiresAt: time.Now().Add(time.Hour),
+		}
+	}
+
+	t.Run("Invite Emails Token", func(t *testing.T) {
+		mockRepo := new(MockWorkspaceRepository)
+		memberOf(mockRepo, 2, model.RoleAdmin)
+		var stored *model.WorkspaceInvitation
+		mockRepo.On("CreateInvitation", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+			stored = args.Get(1).(*model.WorkspaceInvitation)
+		}).Return(nil)
+
+		var sent bytes.Buffer
+		workspaceService := service.NewWorkspaceService(mockRepo, nil, mail.NewLogMailer(&sent), testAuthorizer, policy)
+		invitation := &model.WorkspaceInvitation{WorkspaceID: 3, Email: " colleague@example.com ", Role: model.RoleMember}
+		err := workspaceService.InviteMember(newTestContext(), invitation, 2)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "colleague@example.com", stored.Email)
+		assert.Equal(t, uint(2), stored.InvitedBy)
+		assert.Len(t, stored.TokenHash, 64)
+		assert.WithinDuration(t, time.Now().Add(time.Hour), stored.ExpiresAt, time.Minute)
+		assert.Contains(t, sent.String(), "To: colleague@example.com")
+		assert.Contains(t, sent.String(), "https://app.example.com/invitations?token=")
+		assert.NotContains(t, sent.String(), stored.TokenHash)
+	})
+
+	t.Run("Admin Cannot Invite Owner", func(t *testing.T) {
+		mockRepo := new(MockWorkspaceRepository)
+		memberOf(mockRepo, 2, model.RoleAdmin)
+
+		workspaceService := service.NewWorkspaceService(mockRepo, nil, nil, testAuthorizer, policy)
+		err := workspaceService.InviteMember(newTestContext(), &model.WorkspaceInvitation{WorkspaceID: 3, Email: "a@example.com", Role: model.RoleOwner}, 2)
+
+		assert.ErrorIs(t, err, service.ErrInsufficientRole)
+		mockRepo.AssertNotCalled(t, "CreateInvitation", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Accept", func(t *testing.T) {
+		mockRepo := new(MockWorkspaceRepository)
+		mockRepo.On("GetInvitationByHash", mock.Anything, sha256Hex("invite-token")).Return(pending(), nil)
+		mockRepo.On("AcceptInvitation", mock.Anything, int64(4), int64(5)).Return(nil)
+		memberOf(mockRepo, 5, model.RoleMember)
+		mockUserRepo := new(MockUserRepository)
+		mockUserRepo.On("GetUserByID", mock.Anything, uint(5)).Return(&model.User{ID: 5, Email: "colleague@example.com"}, nil)
+
+		workspaceService := service.NewWorkspaceService(mockRepo, mockUserRepo, nil, testAuthorizer, policy)
+		workspace, err := workspaceService.AcceptInvitation(newTestContext(), "invite-token", 5)
+
+		assert.NoError(t, err)
+		assert.Equal(t, model.RoleMember, workspace.Role)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Accept With Other Email", func(t *testing.T) {
+		mockRepo := new(MockWorkspaceRepository)
+		mockRepo.On("GetInvitationByHash", mock.Anything, sha256Hex("invite-token")).Return(pending(), nil)
+		mockUserRepo := new(MockUserRepository)
+		mockUserRepo.On("GetUserByID", mock.Anything, uint(6)).Return(&model.User{ID: 6, Email: "someone@example.com"}, nil)
+
+		workspaceService := service.NewWorkspaceService(mockRepo, mockUserRepo, nil, testAuthorizer, policy)
+		_, err := workspaceService.AcceptInvitation(newTestContext(), "invite-token", 6)
+
+		assert.ErrorIs(t, err, service.ErrInvitationEmailMismatch)
+		mockRepo.AssertNotCalled(t, "AcceptInvitation", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Decline", func(t *testing.T) {
+		mockRepo := new(MockWorkspaceRepository)
+		mockRepo.On("GetInvitationByHash", mock.Anything, sha256Hex("invite-token")).Return(pending(), nil)
+		mockRepo.On("DeclineInvitation", mock.Anything, int64(4)).Return(nil)
+
+		workspaceService := service.NewWorkspaceService(mockRepo, nil, nil, testAuthorizer, policy)
+		err := workspaceService.DeclineInvitation(newTestContext(), "invite-token")
+
+		assert.NoError(t, err)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Used Or Expired", func(t *testing.T) {
+		now := time.Now()
+		expired := pending()
+		expired.ExpiresAt = now.Add(-time.Minute)
+		declined := pending()
+		declined.DeclinedAt = &now
+
+		for name, invitation := range map[string]*model.WorkspaceInvitation{"Expired": expired, "Declined": declined} {
+			t.Run(name, func(t *testing.T) {
+				mockRepo := new(MockWorkspaceRepository)
+				mockRepo.On("GetInvitationByHash", mock.Anything, sha256Hex("invite-token")).Return(invitation, nil)
+
+				workspaceService := service.NewWorkspaceService(mockRepo, nil, nil, testAuthorizer, policy)
+				_, err := workspaceService.AcceptInvitation(newTestContext(), "invite-token", 5)
+
+				assert.ErrorIs(t, err, service.ErrInvalidInvitation)
+			})
+		}
+	})
+
+	t.Run("Unknown Token", func(t *testing.T) {
+		mockRepo := new(MockWorkspaceRepository)
+		mockRepo.On("GetInvitationByHash", mock.Anything, mock.Anything).Return(nil, sql.ErrNoRows)
+
+		workspaceService := service.NewWorkspaceService(mockRepo, nil, nil, testAuthorizer, policy)
+		err := workspaceService.DeclineInvitation(newTestContext(), "nope")
+
+		assert.ErrorIs(t, err, service.ErrInvalidInvitation)
+	})
+}
+
+func TestWorkspaceServiceMemberRole(t *testing.T) {
+	mockRepo := new(MockWorkspaceRepository)
+	mockRepo.On("MemberRole", mock.Anything, int64(3), int64(5)).Return(model.RoleViewer, nil)
+	mockRepo.On("MemberRole", mock.Anything, int64(3), int64(6)).Return(model.WorkspaceRole(""), sql.ErrNoRows)
+
+	workspaceService := service.NewWorkspaceService(mockRepo, nil, nil, testAuthorizer, service.WorkspacePolicy{})
+
+	role, err := workspaceService.MemberRole(context.Background(), 3, 5)
+	assert.NoError(t, err)
+	assert.Equal(t, model.RoleViewer, role)
+
+	role, err = workspaceService.MemberRole(context.Background(), 3, 6)
+	assert.NoError(t, err)
+	assert.Empty(t, role)
+}
+
+func TestTaskServiceWorkspaceAccess(t *testing.T) {
+	workspaceID := uint(3)
+	otherWorkspaceID := uint(4)
+	// Task 10 was created in workspace 3 by user 1.
+	sharedTask := func() *model.Task {
+		return &model.Task{ID: 10, UserID: 1, WorkspaceID: &workspaceID, Title: "Shared", Status: model.StatusPending, Priority: model.PriorityMedium}
+	}
+
+	t.Run("Viewer Reads", func(t *testing.T) {
+		mockRepo := new(MockTaskRepository)
+		mockRepo.On("GetByID", mock.Anything, int64(10)).Return(sharedTask(), nil)
+
+		taskService := service.NewTaskService(mockRepo, new(MockProjectRepository), unblockedDependencyRepo(), nil, nil, nil, testAuthorizer, service.TaskPolicy{})
+		task, err := taskService.GetTask(workspaceContext(3, model.RoleViewer), 10, 2)
+
+		assert.NoError(t, err)
+		assert.Equal(t, uint(10), task.ID)
+	})
+
+	t.Run("Viewer Cannot Update", func(t *testing.T) {
+		mockRepo := new(MockTaskRepository)
+		mockRepo.On("GetByID", mock.Anything, int64(10)).Return(sharedTask(), nil)
+
+		taskService := service.NewTaskService(mockRepo, new(MockProjectRepository), unblockedDependencyRepo(), nil, nil, nil, testAuthorizer, service.TaskPolicy{})
+		err := taskService.UpdateTask(workspaceContext(3, model.RoleViewer), &model.Task{ID: 10, Title: "Mine now"}, 2)
+
+		assert.ErrorIs(t, err, service.ErrInsufficientRole)
+		mockRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Viewer Cannot Create", func(t *testing.T) {
+		mockRepo := new(MockTaskRepository)
+
+		taskService := service.NewTaskService(mockRepo, new(MockProjectRepository), unblockedDependencyRepo(), nil, nil, nil, testAuthorizer, service.TaskPolicy{})
+		err := taskService.CreateTask(workspaceContext(3, model.RoleViewer), &model.Task{Title: "New"}, 2)
+
+		assert.ErrorIs(t, err, service.ErrInsufficientRole)
+		mockRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Member Creates In Workspace", func(t *testing.T) {
+		mockRepo := new(MockTaskRepository)
+		mockRepo.On("Create", mock.Anything, mock.MatchedBy(func(task *model.Task) bool {
+			return task.UserID == 2 && task.WorkspaceID != nil && *task.WorkspaceID == 3
+		})).Return(nil)
+
+		taskService := service.NewTaskService(mockRepo, new(MockProjectRepository), unblockedDependencyRepo(), nil, nil, nil, testAuthorizer, service.TaskPolicy{})
+		err := taskService.CreateTask(workspaceContext(3, model.RoleMember), &model.Task{Title: "New"}, 2)
+
+		assert.NoError(t, err)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Member Deletes Another Member's Task", func(t *testing.T) {
+		mockRepo := new(MockTaskRepository)
+		mockRepo.On("GetByID", mock.Anything, int64(10)).Return(sharedTask(), nil)
+		mockRepo.On("Delete", mock.Anything, int64(10), int64(1)).Return(nil)
+
+		taskService := service.NewTaskService(mockRepo, new(MockProjectRepository), unblockedDependencyRepo(), nil, nil, nil, testAuthorizer, service.TaskPolicy{})
+		err := taskService.DeleteTask(workspaceContext(3, model.RoleMember), 10, 2)
+
+		assert.NoError(t, err)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Other Workspace", func(t *testing.T) {
+		mockRepo := new(MockTaskRepository)
+		mockRepo.On("GetByID", mock.Anything, int64(10)).Return(sharedTask(), nil)
+
+		taskService := service.NewTaskService(mockRepo, new(MockProjectRepository), unblockedDependencyRepo(), nil, nil, nil, testAuthorizer, service.TaskPolicy{})
+		_, err := taskService.GetTask(workspaceContext(otherWorkspaceID, model.RoleOwner), 10, 1)
+
+		assert.ErrorIs(t, err, service.ErrTaskNotFound)
+	})
+
+	t.Run("Workspace Task Outside Workspace", func(t *testing.T) {
+		mockRepo := new(MockTaskRepository)
+		mockRepo.On("GetByID", mock.Anything, int64(10)).Return(sharedTask(), nil)
+
+		taskService := service.NewTaskService(mockRepo, new(MockProjectRepository), unblockedDependencyRepo(), nil, nil, nil, testAuthorizer, service.TaskPolicy{})
+		_, err := taskService.GetTask(newTestContext(), 10, 1)
+
+		assert.ErrorIs(t, err, service.ErrTaskNotFound)
+	})
+
+	t.Run("Personal Task Inside Workspace", func(t *testing.T) {
+		mockRepo := new(MockTaskRepository)
+		mockRepo.On("GetByID", mock.Anything, int64(11)).Return(&model.Task{ID: 11, UserID: 2}, nil)
+
+		taskService := service.NewTaskService(mockRepo, new(MockProjectRepository), unblockedDependencyRepo(), nil, nil, nil, testAuthorizer, service.TaskPolicy{})
+		_, err := taskService.GetTask(workspaceContext(3, model.RoleOwner), 11, 2)
+
+		assert.ErrorIs(t, err, service.ErrTaskNotFound)
+	})
+
+	t.Run("Listing Is Scoped", func(t *testing.T) {
+		mockRepo := new(MockTaskRepository)
+		mockRepo.On("List", mock.Anything, int64(2), mock.MatchedBy(func(filter model.TaskFilter) bool {
+			return filter.WorkspaceID != nil && *filter.WorkspaceID == 3
+		})).Return(&model.TaskPage{Items: []*model.Task{}}, nil)
+
+		taskService := service.NewTaskService(mockRepo, new(MockProjectRepository), unblockedDependencyRepo(), nil, nil, nil, testAuthorizer, service.TaskPolicy{})
+		_, err := taskService.GetTasks(workspaceContext(3, model.RoleViewer), 2, model.TaskFilter{})
+
+		assert.NoError(t, err)
+		mockRepo.AssertExpectations(t)
+	})
+}
+
+func TestProjectServiceWorkspaceAccess(t *testing.T) {
+	workspaceID := uint(3)
+
+	t.Run("Member Cannot Create", func(t *testing.T) {
+		mockRepo := new(MockProjectRepository)
+
+		projectService := service.NewProjectService(mockRepo, testAuthorizer)
+		err := projectService.CreateProject(workspaceContext(3, model.RoleMember), &model.Project{Name: "Launch"}, nil, 2)
+
+		assert.ErrorIs(t, err, service.ErrInsufficientRole)
+		mockRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Admin Deletes Another Admin's Project", func(t *testing.T) {
+		mockRepo := new(MockProjectRepository)
+		mockRepo.On("GetByID", mock.Anything, int64(7)).Return(&model.Project{ID: 7, UserID: 1, WorkspaceID: &workspaceID}, nil)
+		mockRepo.On("Delete", mock.Anything, int64(7), int64(1)).Return(nil)
+
+		projectService := service.NewProjectService(mockRepo, testAuthorizer)
+		err := projectService.DeleteProject(workspaceContext(3, model.RoleAdmin), 7, 2)
+
+		assert.NoError(t, err)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Listing Is Scoped", func(t *testing.T) {
+		mockRepo := new(MockProjectRepository)
+		mockRepo.On("List", mock.Anything, int64(2), &workspaceID, false).Return([]*model.Project{}, nil)
+		mockRepo.On("InboxCounts", mock.Anything, int64(2), &workspaceID).Return(model.ProjectCounts{OpenCount: 4}, nil)
+
+		projectService := service.NewProjectService(mockRepo, testAuthorizer)
+		projects, err := projectService.GetProjects(workspaceContext(3, model.RoleViewer), 2, false)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 4, projects.Inbox.OpenCount)
+	})
+}
@@ -0,0 +1,118 @@
+package signing
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+)
+
+// JWK is a public key in JSON Web Key form (RFC 7517).
+type JWK struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid,omitempty"`
+	Use       string `json:"use,omitempty"`
+	Algorithm string `json:"alg,omitempty"`
+	// RSA keys.
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+	// Ed25519 keys.
+	Curve string `json:"crv,omitempty"`
+	X     string `json:"x,omitempty"`
+}
+
+// JWKS is the document published at /.well-known/jwks.json.
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS returns every key tokens are verified with, signing key first. It
+// is empty in HMAC mode, since the secret must not be published.
+func (k *KeySet) JWKS() JWKS {
+	jwks := JWKS{Keys: []JWK{}}
+	for _, id := range k.order {
+		key := k.verifying[id]
+		jwk := key.jwk()
+		jwk.KeyID = key.ID
+		jwk.Use = "sig"
+		jwk.Algorithm = key.Algorithm
+		jwks.Keys = append(jwks.Keys, jwk)
+	}
+	return jwks
+}
+
+// jwk returns just the members RFC 7638 uses for the thumbprint.
+func (key *Key) jwk() JWK {
+	switch public := key.public.(type) {
+	case *rsa.PublicKey:
+		return JWK{
+			KeyType: "RSA",
+			N:       encode(public.N.Bytes()),
+			E:       encode(big.NewInt(int64(public.E)).Bytes()),
+		}
+	case ed25519.PublicKey:
+		return JWK{KeyType: "OKP", Curve: "Ed25519", X: encode(public)}
+	}
+	return JWK{}
+}
+
+// PublicKey decodes the key, for verifying tokens signed by someone else,
+// such as an OpenID Connect provider. RSA keys are held to the same minimum
+// size as keys of our own.
+func (jwk JWK) PublicKey() (crypto.PublicKey, error) {
+	switch jwk.KeyType {
+	case "RSA":
+		n, err := base64.RawURLEncoding.DecodeString(jwk.N)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidKey, err)
+		}
+		e, err := base64.RawURLEncoding.DecodeString(jwk.E)
+		if err != nil || len(e) == 0 || len(e) > 4 {
+			return nil, fmt.Errorf("%w: invalid RSA exponent", ErrInvalidKey)
+		}
+		public := &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+		if public.N.BitLen() < minRSABits {
+			return nil, fmt.Errorf("%w: RSA keys must be at least %d bits", ErrInvalidKey, minRSABits)
+		}
+		return public, nil
+	case "OKP":
+		x, err := base64.RawURLEncoding.DecodeString(jwk.X)
+		if jwk.Curve != "Ed25519" || err != nil || len(x) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("%w: invalid Ed25519 key", ErrInvalidKey)
+		}
+		return ed25519.PublicKey(x), nil
+	}
+	return nil, fmt.Errorf("%w: unsupported key type %q", ErrInvalidKey, jwk.KeyType)
+}
+
+// thumbprint computes the RFC 7638 thumbprint: the SHA-256 of the required
+// members, in lexicographic order, with no whitespace. encoding/json
+// writes struct fields in declaration order, hence the separate structs.
+func thumbprint(jwk JWK) string {
+	var canonical []byte
+	switch jwk.KeyType {
+	case "RSA":
+		canonical, _ = json.Marshal(struct {
+			E   string `json:"e"`
+			Kty string `json:"kty"`
+			N   string `json:"n"`
+		}{jwk.E, jwk.KeyType, jwk.N})
+	case "OKP":
+		canonical, _ = json.Marshal(struct {
+			Crv string `json:"crv"`
+			Kty string `json:"kty"`
+			X   string `json:"x"`
+		}{jwk.Curve, jwk.KeyType, jwk.X})
+	}
+
+	sum := sha256.Sum256(canonical)
+	return encode(sum[:])
+}
+
+func encode(data []byte) string {
+	return base64.RawURLEncoding.EncodeToString(data)
+}
@@ -0,0 +1,281 @@
+// Package signing holds the keys access tokens are signed and verified
+// with. Tokens are signed with HMAC-SHA256 using a shared secret, or with
+// RS256 or EdDSA using a private key whose public half, along with any
+// older keys still accepted, is published as a JSON Web Key Set.
+package signing
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	HS256 = "HS256"
+	RS256 = "RS256"
+	EdDSA = "EdDSA"
+)
+
+// minRSABits is the smallest RSA key accepted, as recommended by NIST.
+const minRSABits = 2048
+
+var (
+	ErrInvalidKey = errors.New("invalid signing key")
+	ErrUnknownKey = errors.New("token signed with an unknown key")
+)
+
+// Key is one asymmetric key. Only the signing key has a private half.
+type Key struct {
+	// ID is the key's RFC 7638 thumbprint, sent as the kid header.
+	ID        string
+	Algorithm string
+	private   crypto.Signer
+	public    crypto.PublicKey
+}
+
+// KeySet signs tokens with one key and verifies them with any of several.
+type KeySet struct {
+	algorithm string
+	// secret signs and verifies HS256 tokens in HMAC mode. With a signing
+	// key it only verifies them, and only if AcceptHMAC set it.
+	secret    []byte
+	signing   *Key
+	verifying map[string]*Key
+	// order keeps the JWKS output stable: the signing key first, then the
+	// others in the order they were configured.
+	order []string
+}
+
+// NewHMAC returns a key set that signs and verifies HS256 tokens with a
+// shared secret. Tokens carry no kid, and there is nothing to publish.
+func NewHMAC(secret string) *KeySet {
+	return &KeySet{algorithm: HS256, secret: []byte(secret)}
+}
+
+// New returns a key set that signs with the private key in signingKeyPEM,
+// whose type must suit algorithm, and also accepts tokens signed by any of
+// verificationKeyPEMs. Those may hold public or private keys of either
+// supported type, which is what lets the algorithm change during rotation.
+func New(algorithm string, signingKeyPEM []byte, verificationKeyPEMs ...[]byte) (*KeySet, error) {
+	signer, err := parsePrivateKey(signingKeyPEM)
+	if err != nil {
+		return nil, err
+	}
+
+	signing, err := newKey(signer.Public())
+	if err != nil {
+		return nil, err
+	}
+	if signing.Algorithm != algorithm {
+		return nil, fmt.Errorf("%w: signing key is not a %s key", ErrInvalidKey, algorithm)
+	}
+	signing.private = signer
+
+	keys := &KeySet{algorithm: algorithm, signing: signing, verifying: make(map[string]*Key)}
+	keys.add(signing)
+
+	for _, data := range verificationKeyPEMs {
+		public, err := parsePublicKey(data)
+		if err != nil {
+			return nil, err
+		}
+		key, err := newKey(public)
+		if err != nil {
+			return nil, err
+		}
+		keys.add(key)
+	}
+
+	return keys, nil
+}
+
+// LoadFiles reads the keys for New from PEM files.
+func LoadFiles(algorithm, signingKeyFile string, verificationKeyFiles []string) (*KeySet, error) {
+	signingKeyPEM, err := os.ReadFile(signingKeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read signing key: %w", err)
+	}
+
+	verificationKeyPEMs := make([][]byte, 0, len(verificationKeyFiles))
+	for _, file := range verificationKeyFiles {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read verification key: %w", err)
+		}
+		verificationKeyPEMs = append(verificationKeyPEMs, data)
+	}
+
+	return New(algorithm, signingKeyPEM, verificationKeyPEMs...)
+}
+
+// AcceptHMAC makes an asymmetric key set also accept HS256 tokens signed
+// with secret, so that switching away from HS256 does not log everyone
+// out. New tokens are still signed with the signing key. It is meant for
+// the migration only, until the last HS256 token has expired.
+func (k *KeySet) AcceptHMAC(secret string) {
+	if k.signing != nil {
+		k.secret = []byte(secret)
+	}
+}
+
+func (k *KeySet) add(key *Key) {
+	if _, exists := k.verifying[key.ID]; exists {
+		return
+	}
+	k.verifying[key.ID] = key
+	k.order = append(k.order, key.ID)
+}
+
+// Algorithm is the algorithm new tokens are signed with.
+func (k *KeySet) Algorithm() string {
+	return k.algorithm
+}
+
+// Sign signs claims with the signing key, naming it in the kid header.
+func (k *KeySet) Sign(claims jwt.Claims) (string, error) {
+	if k.signing == nil {
+		return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(k.secret)
+	}
+
+	token := jwt.NewWithClaims(jwt.GetSigningMethod(k.algorithm), claims)
+	token.Header["kid"] = k.signing.ID
+	return token.SignedString(k.signing.private)
+}
+
+// Parse verifies a token and reads its claims. In HMAC mode only HS256
+// tokens are accepted. Otherwise the token must name a known key in its
+// kid header and use that key's algorithm, so a public key can never be
+// used as an HMAC secret; HS256 tokens are only accepted after AcceptHMAC,
+// and only with its secret.
+func (k *KeySet) Parse(tokenString string, claims jwt.Claims) (*jwt.Token, error) {
+	return jwt.ParseWithClaims(tokenString, claims, k.keyFor, jwt.WithValidMethods(k.methods()))
+}
+
+func (k *KeySet) methods() []string {
+	if k.signing == nil {
+		return []string{HS256}
+	}
+	if len(k.secret) > 0 {
+		return []string{RS256, EdDSA, HS256}
+	}
+	return []string{RS256, EdDSA}
+}
+
+func (k *KeySet) keyFor(token *jwt.Token) (interface{}, error) {
+	if k.signing == nil || (token.Method.Alg() == HS256 && len(k.secret) > 0) {
+		return k.secret, nil
+	}
+
+	kid, _ := token.Header["kid"].(string)
+	key, ok := k.verifying[kid]
+	if !ok || key.Algorithm != token.Method.Alg() {
+		return nil, ErrUnknownKey
+	}
+	return key.public, nil
+}
+
+// Derive returns a key for a server-internal purpose, such as signing
+// tokens that are never meant to leave this service. It is derived from
+// the secret or the signing key, so it needs no configuration of its own
+// and changes when they do.
+func (k *KeySet) Derive(purpose string) []byte {
+	material := k.secret
+	if k.signing != nil {
+		material, _ = x509.MarshalPKCS8PrivateKey(k.signing.private)
+	}
+
+	mac := hmac.New(sha256.New, material)
+	mac.Write([]byte(purpose))
+	return mac.Sum(nil)
+}
+
+func newKey(public crypto.PublicKey) (*Key, error) {
+	key := &Key{public: public}
+	switch public := public.(type) {
+	case *rsa.PublicKey:
+		if public.N.BitLen() < minRSABits {
+			return nil, fmt.Errorf("%w: RSA keys must be at least %d bits", ErrInvalidKey, minRSABits)
+		}
+		key.Algorithm = RS256
+	case ed25519.PublicKey:
+		key.Algorithm = EdDSA
+	default:
+		return nil, fmt.Errorf("%w: only RSA and Ed25519 keys are supported", ErrInvalidKey)
+	}
+
+	key.ID = thumbprint(key.jwk())
+	return key, nil
+}
+
+func decodePEM(data []byte) (*pem.Block, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("%w: no PEM data found", ErrInvalidKey)
+	}
+	return block, nil
+}
+
+func parsePrivateKey(data []byte) (crypto.Signer, error) {
+	block, err := decodePEM(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var parsed interface{}
+	switch block.Type {
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("%w: expected a private key, found %s", ErrInvalidKey, block.Type)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidKey, err)
+	}
+
+	signer, ok := parsed.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("%w: only RSA and Ed25519 keys are supported", ErrInvalidKey)
+	}
+	return signer, nil
+}
+
+// parsePublicKey accepts a public key, or a private key whose public half
+// is taken, so an old signing key file can be listed as it is.
+func parsePublicKey(data []byte) (crypto.PublicKey, error) {
+	block, err := decodePEM(data)
+	if err != nil {
+		return nil, err
+	}
+
+	switch block.Type {
+	case "PUBLIC KEY":
+		public, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidKey, err)
+		}
+		return public, nil
+	case "RSA PUBLIC KEY":
+		public, err := x509.ParsePKCS1PublicKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidKey, err)
+		}
+		return public, nil
+	}
+
+	signer, err := parsePrivateKey(data)
+	if err != nil {
+		return nil, err
+	}
+	return signer.Public(), nil
+}
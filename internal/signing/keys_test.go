@@ -0,0 +1,254 @@
+package signing
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+func privateKeyPEM(t *testing.T, key interface{}) []byte {
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	assert.NoError(t, err)
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+}
+
+func publicKeyPEM(t *testing.T, key interface{}) []byte {
+	der, err := x509.MarshalPKIXPublicKey(key)
+	assert.NoError(t, err)
+	return pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})
+}
+
+func newRSAKey(t *testing.T, bits int) *rsa.PrivateKey {
+	key, err := rsa.GenerateKey(rand.Reader, bits)
+	assert.NoError(t, err)
+	return key
+}
+
+func newEd25519Key(t *testing.T) ed25519.PrivateKey {
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	assert.NoError(t, err)
+	return key
+}
+
+func testClaims() jwt.MapClaims {
+	return jwt.MapClaims{"user_id": 1, "exp": jwt.NewNumericDate(time.Now().Add(time.Hour))}
+}
+
+func TestThumbprint(t *testing.T) {
+	// RFC 7638 section 3.1.
+	rsaKey := JWK{
+		KeyType: "RSA",
+		N:       "0vx7agoebGcQSuuPiLJXZptN9nndrQmbXEps2aiAFbWhM78LhWx4cbbfAAtVT86zwu1RK7aPFFxuhDR1L6tSoc_BJECPebWKRXjBZCiFV4n3oknjhMstn64tZ_2W-5JsGY4Hc5n9yBXArwl93lqt7_RN5w6Cf0h4QyQ5v-65YGjQR0_FDW2QvzqY368QQMicAtaSqzs8KJZgnYb9c7d0zgdAZHzu6qMQvRL5hajrn1n91CbOpbISD08qNLyrdkt-bFTWhAI4vMQFh6WeZu0fM4lFd2NcRwr3XPksINHaQ-G_xBniIqbw0Ls1jF44-csFCur-kEgU8awapJzKnqDKgw",
+		E:       "AQAB",
+	}
+	assert.Equal(t, "NzbLsXh8uDCcd-6MNwXF4W_7noWXFZAfHkxZsRGC9Xs", thumbprint(rsaKey))
+
+	// RFC 8037 appendix A.3.
+	okpKey := JWK{KeyType: "OKP", Curve: "Ed25519", X: "11qYAYKxCrfVS_7TyWQHOg7hcvPapiMlrwIaaPcHURo"}
+	assert.Equal(t, "kPrK_qmxVWaYVA9wwBF6Iuo3vVzz7TxHCTwXBygrS4k", thumbprint(okpKey))
+}
+
+func TestKeySetRoundTrip(t *testing.T) {
+	tests := []struct {
+		name      string
+		algorithm string
+		key       interface{}
+	}{
+		{"RS256", RS256, newRSAKey(t, 2048)},
+		{"EdDSA", EdDSA, newEd25519Key(t)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			keys, err := New(tt.algorithm, privateKeyPEM(t, tt.key))
+			assert.NoError(t, err)
+
+			signed, err := keys.Sign(testClaims())
+			assert.NoError(t, err)
+
+			claims := jwt.MapClaims{}
+			token, err := keys.Parse(signed, claims)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.algorithm, token.Method.Alg())
+			assert.Equal(t, keys.JWKS().Keys[0].KeyID, token.Header["kid"])
+			assert.Equal(t, float64(1), claims["user_id"])
+		})
+	}
+}
+
+func TestKeySetHMAC(t *testing.T) {
+	keys := NewHMAC("test-secret")
+
+	signed, err := keys.Sign(testClaims())
+	assert.NoError(t, err)
+
+	// Tokens are plain HS256 tokens, readable with the secret alone.
+	token, err := jwt.Parse(signed, func(*jwt.Token) (interface{}, error) {
+		return []byte("test-secret"), nil
+	})
+	assert.NoError(t, err)
+	assert.NotContains(t, token.Header, "kid")
+
+	_, err = keys.Parse(signed, jwt.MapClaims{})
+	assert.NoError(t, err)
+	assert.Empty(t, keys.JWKS().Keys)
+
+	_, err = NewHMAC("other-secret").Parse(signed, jwt.MapClaims{})
+	assert.ErrorIs(t, err, jwt.ErrTokenSignatureInvalid)
+}
+
+func TestKeySetRotation(t *testing.T) {
+	oldKey := newRSAKey(t, 2048)
+	newKey := newEd25519Key(t)
+
+	before, err := New(RS256, privateKeyPEM(t, oldKey))
+	assert.NoError(t, err)
+	oldToken, err := before.Sign(testClaims())
+	assert.NoError(t, err)
+
+	// The new key signs; the old one, given as a public key or as the old
+	// private key file, still verifies until its tokens have expired.
+	for _, oldKeyPEM := range [][]byte{publicKeyPEM(t, &oldKey.PublicKey), privateKeyPEM(t, oldKey)} {
+		after, err := New(EdDSA, privateKeyPEM(t, newKey), oldKeyPEM)
+		assert.NoError(t, err)
+
+		_, err = after.Parse(oldToken, jwt.MapClaims{})
+		assert.NoError(t, err)
+
+		newToken, err := after.Sign(testClaims())
+		assert.NoError(t, err)
+		_, err = after.Parse(newToken, jwt.MapClaims{})
+		assert.NoError(t, err)
+
+		jwks := after.JWKS()
+		assert.Len(t, jwks.Keys, 2)
+		assert.Equal(t, "OKP", jwks.Keys[0].KeyType)
+		assert.Equal(t, EdDSA, jwks.Keys[0].Algorithm)
+		assert.Equal(t, "RSA", jwks.Keys[1].KeyType)
+		assert.Equal(t, RS256, jwks.Keys[1].Algorithm)
+		assert.Equal(t, before.JWKS().Keys[0], jwks.Keys[1])
+	}
+
+	// Once the old key is removed, its tokens stop verifying.
+	removed, err := New(EdDSA, privateKeyPEM(t, newKey))
+	assert.NoError(t, err)
+	_, err = removed.Parse(oldToken, jwt.MapClaims{})
+	assert.True(t, errors.Is(err, ErrUnknownKey), "got %v", err)
+}
+
+func TestKeySetAcceptHMAC(t *testing.T) {
+	oldToken, err := NewHMAC("test-secret").Sign(testClaims())
+	assert.NoError(t, err)
+
+	keys, err := New(EdDSA, privateKeyPEM(t, newEd25519Key(t)))
+	assert.NoError(t, err)
+
+	// Without AcceptHMAC, switching algorithm rejects the old tokens.
+	_, err = keys.Parse(oldToken, jwt.MapClaims{})
+	assert.Error(t, err)
+
+	keys.AcceptHMAC("test-secret")
+	_, err = keys.Parse(oldToken, jwt.MapClaims{})
+	assert.NoError(t, err)
+
+	// New tokens are still signed with the signing key, and the secret is
+	// not published.
+	newToken, err := keys.Sign(testClaims())
+	assert.NoError(t, err)
+	token, err := keys.Parse(newToken, jwt.MapClaims{})
+	assert.NoError(t, err)
+	assert.Equal(t, EdDSA, token.Method.Alg())
+	assert.Len(t, keys.JWKS().Keys, 1)
+
+	otherToken, err := NewHMAC("other-secret").Sign(testClaims())
+	assert.NoError(t, err)
+	_, err = keys.Parse(otherToken, jwt.MapClaims{})
+	assert.ErrorIs(t, err, jwt.ErrTokenSignatureInvalid)
+}
+
+func TestKeySetRejects(t *testing.T) {
+	rsaKey := newRSAKey(t, 2048)
+	keys, err := New(RS256, privateKeyPEM(t, rsaKey))
+	assert.NoError(t, err)
+	kid := keys.JWKS().Keys[0].KeyID
+
+	t.Run("HMAC Signed With Public Key", func(t *testing.T) {
+		token := jwt.NewWithClaims(jwt.SigningMethodHS256, testClaims())
+		token.Header["kid"] = kid
+		signed, err := token.SignedString(publicKeyPEM(t, &rsaKey.PublicKey))
+		assert.NoError(t, err)
+
+		_, err = keys.Parse(signed, jwt.MapClaims{})
+		assert.Error(t, err)
+	})
+
+	t.Run("Missing Kid", func(t *testing.T) {
+		signed, err := jwt.NewWithClaims(jwt.SigningMethodRS256, testClaims()).SignedString(rsaKey)
+		assert.NoError(t, err)
+
+		_, err = keys.Parse(signed, jwt.MapClaims{})
+		assert.True(t, errors.Is(err, ErrUnknownKey), "got %v", err)
+	})
+
+	t.Run("Wrong Key Type For Algorithm", func(t *testing.T) {
+		_, err := New(EdDSA, privateKeyPEM(t, rsaKey))
+		assert.ErrorIs(t, err, ErrInvalidKey)
+	})
+
+	t.Run("Short RSA Key", func(t *testing.T) {
+		_, err := New(RS256, privateKeyPEM(t, newRSAKey(t, 1024)))
+		assert.ErrorIs(t, err, ErrInvalidKey)
+	})
+
+	t.Run("Public Key As Signing Key", func(t *testing.T) {
+		_, err := New(RS256, publicKeyPEM(t, &rsaKey.PublicKey))
+		assert.ErrorIs(t, err, ErrInvalidKey)
+	})
+
+	t.Run("Not PEM", func(t *testing.T) {
+		_, err := New(RS256, []byte("not a key"))
+		assert.ErrorIs(t, err, ErrInvalidKey)
+	})
+}
+
+func TestKeySetDerive(t *testing.T) {
+	assert.Equal(t, NewHMAC("secret").Derive("mfa"), NewHMAC("secret").Derive("mfa"))
+	assert.NotEqual(t, NewHMAC("secret").Derive("mfa"), NewHMAC("secret").Derive("other"))
+	assert.NotEqual(t, NewHMAC("secret").Derive("mfa"), NewHMAC("other-secret").Derive("mfa"))
+
+	keys, err := New(EdDSA, privateKeyPEM(t, newEd25519Key(t)))
+	assert.NoError(t, err)
+	assert.Len(t, keys.Derive("mfa"), 32)
+	assert.NotEqual(t, NewHMAC("").Derive("mfa"), keys.Derive("mfa"))
+}
+
+func TestJWKPublicKey(t *testing.T) {
+	for _, algorithm := range []string{RS256, EdDSA} {
+		t.Run(algorithm, func(t *testing.T) {
+			var private interface{} = newEd25519Key(t)
+			if algorithm == RS256 {
+				private = newRSAKey(t, 2048)
+			}
+			keys, err := New(algorithm, privateKeyPEM(t, private))
+			assert.NoError(t, err)
+
+			public, err := keys.JWKS().Keys[0].PublicKey()
+			assert.NoError(t, err)
+			assert.True(t, public.(interface{ Equal(crypto.PublicKey) bool }).Equal(keys.signing.public))
+		})
+	}
+
+	_, err := JWK{KeyType: "EC", Curve: "P-256"}.PublicKey()
+	assert.ErrorIs(t, err, ErrInvalidKey)
+	_, err = JWK{KeyType: "OKP", Curve: "Ed25519", X: "short"}.PublicKey()
+	assert.ErrorIs(t, err, ErrInvalidKey)
+}
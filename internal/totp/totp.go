@@ -0,0 +1,193 @@
+// Package totp implements time-based one-time passwords as described in
+// RFC 6238, on top of the HMAC-based one-time passwords of RFC 4226.
+package totp
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrInvalidSecret is returned by DecodeSecret for secrets that are not
+// valid base32.
+var ErrInvalidSecret = errors.New("invalid TOTP secret")
+
+type Algorithm string
+
+const (
+	SHA1   Algorithm = "SHA1"
+	SHA256 Algorithm = "SHA256"
+	SHA512 Algorithm = "SHA512"
+)
+
+func (a Algorithm) hash() func() hash.Hash {
+	switch a {
+	case SHA256:
+		return sha256.New
+	case SHA512:
+		return sha512.New
+	default:
+		return sha1.New
+	}
+}
+
+// Options are the parameters shared by the server and the authenticator
+// app. The zero value of each field means the RFC 6238 default.
+type Options struct {
+	Period    time.Duration
+	Digits    int
+	Algorithm Algorithm
+}
+
+// Defaults are the parameters every common authenticator app supports:
+// six digits every 30 seconds, using HMAC-SHA1.
+var Defaults = Options{Period: 30 * time.Second, Digits: 6, Algorithm: SHA1}
+
+func (o Options) withDefaults() Options {
+	if o.Period <= 0 {
+		o.Period = Defaults.Period
+	}
+	if o.Digits <= 0 {
+		o.Digits = Defaults.Digits
+	}
+	if o.Algorithm == "" {
+		o.Algorithm = Defaults.Algorithm
+	}
+	return o
+}
+
+// SecretSize is the length in bytes of secrets from GenerateSecret, the
+// 160 bits RFC 4226 recommends.
+const SecretSize = 20
+
+// GenerateSecret returns a new random shared secret.
+func GenerateSecret() ([]byte, error) {
+	secret := make([]byte, SecretSize)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, err
+	}
+	return secret, nil
+}
+
+var encoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// EncodeSecret formats a secret as unpadded base32, the form authenticator
+// apps expect.
+func EncodeSecret(secret []byte) string {
+	return encoding.EncodeToString(secret)
+}
+
+// DecodeSecret reads a base32 secret. Case, spaces and padding are
+// ignored, since people copy secrets by hand.
+func DecodeSecret(value string) ([]byte, error) {
+	value = strings.ToUpper(strings.ReplaceAll(value, " ", ""))
+	secret, err := encoding.DecodeString(strings.TrimRight(value, "="))
+	if err != nil || len(secret) == 0 {
+		return nil, ErrInvalidSecret
+	}
+	return secret, nil
+}
+
+// HOTP returns the RFC 4226 one-time password for a counter value.
+func HOTP(secret []byte, counter uint64, digits int, algorithm Algorithm) string {
+	var message [8]byte
+	binary.BigEndian.PutUint64(message[:], counter)
+
+	mac := hmac.New(algorithm.hash(), secret)
+	mac.Write(message[:])
+	sum := mac.Sum(nil)
+
+	// Dynamic truncation: the low four bits of the last byte pick where to
+	// read a 31-bit number from.
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	modulus := uint32(1)
+	for i := 0; i < digits; i++ {
+		modulus *= 10
+	}
+	return fmt.Sprintf("%0*d", digits, value%modulus)
+}
+
+// Counter returns the number of whole periods between the Unix epoch and t.
+func Counter(t time.Time, opts Options) uint64 {
+	opts = opts.withDefaults()
+	return uint64(t.Unix()) / uint64(opts.Period/time.Second)
+}
+
+// Generate returns the code for the period containing t.
+func Generate(secret []byte, t time.Time, opts Options) string {
+	opts = opts.withDefaults()
+	return HOTP(secret, Counter(t, opts), opts.Digits, opts.Algorithm)
+}
+
+// Validate checks a code against the period containing t and up to skew
+// periods either side of it, to allow for clock drift. It returns the
+// counter of the matching period, which callers should remember so that a
+// code cannot be used twice.
+func Validate(secret []byte, code string, t time.Time, skew int, opts Options) (uint64, bool) {
+	opts = opts.withDefaults()
+	if len(code) != opts.Digits {
+		return 0, false
+	}
+
+	current := Counter(t, opts)
+	for delta := -skew; delta <= skew; delta++ {
+		if delta < 0 && uint64(-delta) > current {
+			continue
+		}
+		counter := uint64(int64(current) + int64(delta))
+		expected := HOTP(secret, counter, opts.Digits, opts.Algorithm)
+		if subtle.ConstantTimeCompare([]byte(expected), []byte(code)) == 1 {
+			return counter, true
+		}
+	}
+	return 0, false
+}
+
+// URI returns the otpauth:// URI authenticator apps read from a QR code.
+// Parameters at their defaults are left out, since some apps reject the
+// rest even when they are set to the default.
+func URI(issuer, account string, secret []byte, opts Options) string {
+	opts = opts.withDefaults()
+
+	query := url.Values{}
+	query.Set("secret", EncodeSecret(secret))
+	if issuer != "" {
+		query.Set("issuer", issuer)
+	}
+	if opts.Algorithm != Defaults.Algorithm {
+		query.Set("algorithm", string(opts.Algorithm))
+	}
+	if opts.Digits != Defaults.Digits {
+		query.Set("digits", strconv.Itoa(opts.Digits))
+	}
+	if opts.Period != Defaults.Period {
+		query.Set("period", strconv.Itoa(int(opts.Period/time.Second)))
+	}
+
+	label := account
+	if issuer != "" {
+		label = issuer + ":" + account
+	}
+
+	uri := url.URL{
+		Scheme:   "otpauth",
+		Host:     "totp",
+		Path:     "/" + label,
+		RawQuery: strings.ReplaceAll(query.Encode(), "+", "%20"),
+	}
+	return uri.String()
+}
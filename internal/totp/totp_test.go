@@ -0,0 +1,108 @@
+package totp
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// The secrets and expected values below are the test vectors from RFC 4226
+// appendix D and RFC 6238 appendix B.
+var (
+	sha1Secret   = []byte("12345678901234567890")
+	sha256Secret = []byte("12345678901234567890123456789012")
+	sha512Secret = []byte("1234567890123456789012345678901234567890123456789012345678901234")
+)
+
+func TestHOTP(t *testing.T) {
+	want := []string{
+		"755224", "287082", "359152", "969429", "338314",
+		"254676", "287922", "162583", "399871", "520489",
+	}
+
+	for counter, code := range want {
+		assert.Equal(t, code, HOTP(sha1Secret, uint64(counter), 6, SHA1), "counter %d", counter)
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	tests := []struct {
+		unix   int64
+		sha1   string
+		sha256 string
+		sha512 string
+	}{
+		{59, "94287082", "46119246", "90693936"},
+		{1111111109, "07081804", "68084774", "25091201"},
+		{1111111111, "14050471", "67062674", "99943326"},
+		{1234567890, "89005924", "91819424", "93441116"},
+		{2000000000, "69279037", "90698825", "38618901"},
+		{20000000000, "65353130", "77737706", "47863826"},
+	}
+
+	for _, tt := range tests {
+		at := time.Unix(tt.unix, 0)
+		assert.Equal(t, tt.sha1, Generate(sha1Secret, at, Options{Digits: 8, Algorithm: SHA1}), "SHA1 at %d", tt.unix)
+		assert.Equal(t, tt.sha256, Generate(sha256Secret, at, Options{Digits: 8, Algorithm: SHA256}), "SHA256 at %d", tt.unix)
+		assert.Equal(t, tt.sha512, Generate(sha512Secret, at, Options{Digits: 8, Algorithm: SHA512}), "SHA512 at %d", tt.unix)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	current := Counter(now, Defaults)
+
+	tests := []struct {
+		name        string
+		code        string
+		wantCounter uint64
+		wantOK      bool
+	}{
+		{"Current Period", Generate(sha1Secret, now, Defaults), current, true},
+		{"Previous Period", Generate(sha1Secret, now.Add(-30*time.Second), Defaults), current - 1, true},
+		{"Next Period", Generate(sha1Secret, now.Add(30*time.Second), Defaults), current + 1, true},
+		{"Outside Skew", Generate(sha1Secret, now.Add(-time.Minute), Defaults), 0, false},
+		{"Wrong Length", "12345", 0, false},
+		{"Wrong Code", "000000", 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			counter, ok := Validate(sha1Secret, tt.code, now, 1, Defaults)
+
+			assert.Equal(t, tt.wantOK, ok)
+			assert.Equal(t, tt.wantCounter, counter)
+		})
+	}
+}
+
+func TestSecretRoundTrip(t *testing.T) {
+	secret, err := GenerateSecret()
+	assert.NoError(t, err)
+	assert.Len(t, secret, SecretSize)
+
+	encoded := EncodeSecret(secret)
+	assert.NotContains(t, encoded, "=")
+
+	decoded, err := DecodeSecret(encoded)
+	assert.NoError(t, err)
+	assert.Equal(t, secret, decoded)
+
+	decoded, err = DecodeSecret("gezd gnbv gy3t qojq gezd gnbv gy3t qojq")
+	assert.NoError(t, err)
+	assert.Equal(t, sha1Secret, decoded)
+
+	_, err = DecodeSecret("not base32!")
+	assert.ErrorIs(t, err, ErrInvalidSecret)
+}
+
+func TestURI(t *testing.T) {
+	assert.Equal(t,
+		"otpauth://totp/Task%20Manager:user@example.com?issuer=Task%20Manager&secret=GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ",
+		URI("Task Manager", "user@example.com", sha1Secret, Defaults))
+
+	assert.Equal(t,
+		"otpauth://totp/user@example.com?algorithm=SHA256&digits=8&period=60&secret=GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ",
+		URI("", "user@example.com", sha1Secret, Options{Period: time.Minute, Digits: 8, Algorithm: SHA256}))
+}
@@ -0,0 +1,24 @@
+-- +goose Up
+ALTER TABLE tasks
+    ADD COLUMN description TEXT NOT NULL DEFAULT '',
+    ADD COLUMN priority VARCHAR(20) NOT NULL DEFAULT 'medium'
+        CHECK (priority IN ('low', 'medium', 'high', 'urgent')),
+    ADD COLUMN due_at TIMESTAMPTZ,
+    ADD COLUMN created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    ADD COLUMN updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    ADD COLUMN completed_at TIMESTAMPTZ;
+
+UPDATE tasks SET completed_at = NOW() WHERE status = 'done';
+
+CREATE INDEX idx_tasks_user_id ON tasks (user_id);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_tasks_user_id;
+
+ALTER TABLE tasks
+    DROP COLUMN IF EXISTS completed_at,
+    DROP COLUMN IF EXISTS updated_at,
+    DROP COLUMN IF EXISTS created_at,
+    DROP COLUMN IF EXISTS due_at,
+    DROP COLUMN IF EXISTS priority,
+    DROP COLUMN IF EXISTS description;
@@ -0,0 +1,16 @@
+-- +goose Up
+UPDATE tasks SET status = 'done' WHERE status IN ('completed', 'complete');
+UPDATE tasks SET status = 'in_progress' WHERE status IN ('in-progress', 'in progress', 'started');
+UPDATE tasks SET status = 'pending'
+    WHERE status IS NULL OR status NOT IN ('pending', 'in_progress', 'blocked', 'done', 'cancelled');
+UPDATE tasks SET completed_at = NOW() WHERE status = 'done' AND completed_at IS NULL;
+
+ALTER TABLE tasks
+    ALTER COLUMN status SET NOT NULL,
+    ADD CONSTRAINT tasks_status_check
+        CHECK (status IN ('pending', 'in_progress', 'blocked', 'done', 'cancelled'));
+
+-- +goose Down
+ALTER TABLE tasks
+    DROP CONSTRAINT IF EXISTS tasks_status_check,
+    ALTER COLUMN status DROP NOT NULL;
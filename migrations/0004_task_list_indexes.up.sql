@@ -0,0 +1,9 @@
+-- +goose Up
+CREATE INDEX idx_tasks_user_created ON tasks (user_id, created_at, id);
+CREATE INDEX idx_tasks_user_updated ON tasks (user_id, updated_at, id);
+CREATE INDEX idx_tasks_user_due ON tasks (user_id, (COALESCE(due_at, 'infinity'::timestamptz)), id);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_tasks_user_due;
+DROP INDEX IF EXISTS idx_tasks_user_updated;
+DROP INDEX IF EXISTS idx_tasks_user_created;
@@ -0,0 +1,22 @@
+-- +goose Up
+CREATE TABLE labels (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name VARCHAR(50) NOT NULL,
+    color VARCHAR(7) NOT NULL DEFAULT '#9e9e9e' CHECK (color ~ '^#[0-9a-f]{6}$'),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX idx_labels_user_name ON labels (user_id, lower(name));
+
+CREATE TABLE task_labels (
+    task_id INT NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    label_id INT NOT NULL REFERENCES labels(id) ON DELETE CASCADE,
+    PRIMARY KEY (task_id, label_id)
+);
+
+CREATE INDEX idx_task_labels_label_id ON task_labels (label_id);
+
+-- +goose Down
+DROP TABLE IF EXISTS task_labels;
+DROP TABLE IF EXISTS labels;
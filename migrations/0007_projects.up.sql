@@ -0,0 +1,26 @@
+-- +goose Up
+CREATE TABLE projects (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    color VARCHAR(7) NOT NULL DEFAULT '#9e9e9e' CHECK (color ~ '^#[0-9a-f]{6}$'),
+    archived BOOLEAN NOT NULL DEFAULT FALSE,
+    position INT NOT NULL DEFAULT 0 CHECK (position >= 0),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_projects_user_position ON projects (user_id, position, id);
+
+-- Deleting a project moves its tasks back to the inbox.
+ALTER TABLE tasks ADD COLUMN project_id INT REFERENCES projects(id) ON DELETE SET NULL;
+
+CREATE INDEX idx_tasks_project_id ON tasks (project_id);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_tasks_project_id;
+
+ALTER TABLE tasks DROP COLUMN IF EXISTS project_id;
+
+DROP TABLE IF EXISTS projects;
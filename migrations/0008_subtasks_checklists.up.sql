@@ -0,0 +1,28 @@
+-- +goose Up
+-- Deleting a task deletes its subtasks.
+ALTER TABLE tasks
+    ADD COLUMN parent_id INT REFERENCES tasks(id) ON DELETE CASCADE,
+    ADD CONSTRAINT tasks_parent_not_self CHECK (parent_id <> id);
+
+CREATE INDEX idx_tasks_parent_id ON tasks (parent_id);
+
+CREATE TABLE task_checklist_items (
+    id SERIAL PRIMARY KEY,
+    task_id INT NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    title VARCHAR(255) NOT NULL,
+    done BOOLEAN NOT NULL DEFAULT FALSE,
+    position INT NOT NULL DEFAULT 0 CHECK (position >= 0),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_task_checklist_items_task ON task_checklist_items (task_id, position, id);
+
+-- +goose Down
+DROP TABLE IF EXISTS task_checklist_items;
+
+DROP INDEX IF EXISTS idx_tasks_parent_id;
+
+ALTER TABLE tasks
+    DROP CONSTRAINT IF EXISTS tasks_parent_not_self,
+    DROP COLUMN IF EXISTS parent_id;
@@ -0,0 +1,14 @@
+-- +goose Up
+-- A row means task_id cannot start until blocker_id is finished.
+CREATE TABLE task_dependencies (
+    task_id INT NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    blocker_id INT NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (task_id, blocker_id),
+    CHECK (task_id <> blocker_id)
+);
+
+CREATE INDEX idx_task_dependencies_blocker_id ON task_dependencies (blocker_id);
+
+-- +goose Down
+DROP TABLE IF EXISTS task_dependencies;
@@ -0,0 +1,21 @@
+-- +goose Up
+-- recurrence_start is the first due date of the series and occurrence counts
+-- from 1 within it; next_occurrence_id points at the task generated when
+-- this one was completed.
+ALTER TABLE tasks
+    ADD COLUMN recurrence_rule TEXT NOT NULL DEFAULT '',
+    ADD COLUMN recurrence_tz TEXT NOT NULL DEFAULT 'UTC',
+    ADD COLUMN recurrence_mode VARCHAR(20) NOT NULL DEFAULT 'schedule'
+        CHECK (recurrence_mode IN ('schedule', 'completion')),
+    ADD COLUMN recurrence_start TIMESTAMPTZ,
+    ADD COLUMN occurrence INT NOT NULL DEFAULT 1 CHECK (occurrence >= 1),
+    ADD COLUMN next_occurrence_id INT REFERENCES tasks(id) ON DELETE SET NULL;
+
+-- +goose Down
+ALTER TABLE tasks
+    DROP COLUMN IF EXISTS next_occurrence_id,
+    DROP COLUMN IF EXISTS occurrence,
+    DROP COLUMN IF EXISTS recurrence_start,
+    DROP COLUMN IF EXISTS recurrence_mode,
+    DROP COLUMN IF EXISTS recurrence_tz,
+    DROP COLUMN IF EXISTS recurrence_rule;
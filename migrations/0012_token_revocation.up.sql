@@ -0,0 +1,21 @@
+-- +goose Up
+-- Access tokens revoked before they expire, by their jti claim. Rows can be
+-- deleted once expires_at has passed, since the token is then rejected
+-- anyway.
+CREATE TABLE revoked_tokens (
+    jti VARCHAR(64) PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    expires_at TIMESTAMPTZ NOT NULL,
+    revoked_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_revoked_tokens_expires_at ON revoked_tokens (expires_at);
+
+-- Access tokens carry the version current when they were issued; bumping it
+-- rejects every token issued before.
+ALTER TABLE users ADD COLUMN token_version INT NOT NULL DEFAULT 0;
+
+-- +goose Down
+ALTER TABLE users DROP COLUMN IF EXISTS token_version;
+
+DROP TABLE IF EXISTS revoked_tokens;
@@ -0,0 +1,25 @@
+-- +goose Up
+-- mfa_secret is set when enrolment starts; two-factor authentication is on
+-- once mfa_enabled_at is set. mfa_last_counter is the time step of the last
+-- accepted code, so a code cannot be used twice.
+ALTER TABLE users
+    ADD COLUMN mfa_secret TEXT,
+    ADD COLUMN mfa_enabled_at TIMESTAMPTZ,
+    ADD COLUMN mfa_last_counter BIGINT;
+
+CREATE TABLE mfa_recovery_codes (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    code_hash CHAR(64) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    used_at TIMESTAMPTZ,
+    UNIQUE (user_id, code_hash)
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS mfa_recovery_codes;
+
+ALTER TABLE users
+    DROP COLUMN IF EXISTS mfa_last_counter,
+    DROP COLUMN IF EXISTS mfa_enabled_at,
+    DROP COLUMN IF EXISTS mfa_secret;
@@ -0,0 +1,20 @@
+-- +goose Up
+ALTER TABLE users ADD COLUMN is_admin BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- login_failures counts failed logins per account (scope 'account', keyed
+-- by email, whether or not the account exists) and per client IP address
+-- (scope 'ip').
+CREATE TABLE login_failures (
+    scope VARCHAR(10) NOT NULL,
+    identifier VARCHAR(255) NOT NULL,
+    failures INT NOT NULL,
+    window_started_at TIMESTAMPTZ NOT NULL,
+    locked_until TIMESTAMPTZ,
+    PRIMARY KEY (scope, identifier)
+);
+
+CREATE INDEX idx_login_failures_window_started_at ON login_failures (window_started_at);
+
+-- +goose Down
+DROP TABLE IF EXISTS login_failures;
+ALTER TABLE users DROP COLUMN IF EXISTS is_admin;
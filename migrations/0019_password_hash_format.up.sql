@@ -0,0 +1,7 @@
+-- +goose Up
+-- Password hashes are PHC strings, whose length depends on the hashing
+-- parameters.
+ALTER TABLE users ALTER COLUMN password TYPE TEXT;
+
+-- +goose Down
+ALTER TABLE users ALTER COLUMN password TYPE VARCHAR(255);
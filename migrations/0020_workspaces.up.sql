@@ -0,0 +1,49 @@
+-- +goose Up
+CREATE TABLE workspaces (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE workspace_members (
+    workspace_id INT NOT NULL REFERENCES workspaces(id) ON DELETE CASCADE,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    role VARCHAR(20) NOT NULL CHECK (role IN ('owner', 'admin', 'member', 'viewer')),
+    joined_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (workspace_id, user_id)
+);
+
+CREATE INDEX idx_workspace_members_user_id ON workspace_members (user_id);
+
+CREATE TABLE workspace_invitations (
+    id SERIAL PRIMARY KEY,
+    workspace_id INT NOT NULL REFERENCES workspaces(id) ON DELETE CASCADE,
+    email VARCHAR(255) NOT NULL,
+    role VARCHAR(20) NOT NULL CHECK (role IN ('owner', 'admin', 'member', 'viewer')),
+    token_hash CHAR(64) NOT NULL UNIQUE,
+    invited_by INT REFERENCES users(id) ON DELETE SET NULL,
+    expires_at TIMESTAMPTZ NOT NULL,
+    accepted_at TIMESTAMPTZ,
+    declined_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_workspace_invitations_workspace_id ON workspace_invitations (workspace_id);
+
+-- Projects and tasks without a workspace stay in their owner's personal
+-- space, as before.
+ALTER TABLE projects ADD COLUMN workspace_id INT REFERENCES workspaces(id) ON DELETE CASCADE;
+ALTER TABLE tasks ADD COLUMN workspace_id INT REFERENCES workspaces(id) ON DELETE CASCADE;
+
+CREATE INDEX idx_projects_workspace_id ON projects (workspace_id, position) WHERE workspace_id IS NOT NULL;
+CREATE INDEX idx_tasks_workspace_id ON tasks (workspace_id, created_at) WHERE workspace_id IS NOT NULL;
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_tasks_workspace_id;
+DROP INDEX IF EXISTS idx_projects_workspace_id;
+ALTER TABLE tasks DROP COLUMN IF EXISTS workspace_id;
+ALTER TABLE projects DROP COLUMN IF EXISTS workspace_id;
+DROP TABLE IF EXISTS workspace_invitations;
+DROP TABLE IF EXISTS workspace_members;
+DROP TABLE IF EXISTS workspaces;
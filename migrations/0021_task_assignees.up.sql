@@ -0,0 +1,30 @@
+-- +goose Up
+ALTER TABLE tasks ADD COLUMN assignee_id INT REFERENCES users(id) ON DELETE SET NULL;
+
+CREATE INDEX idx_tasks_assignee_id ON tasks (assignee_id) WHERE assignee_id IS NOT NULL;
+
+CREATE TABLE task_watchers (
+    task_id INT NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (task_id, user_id)
+);
+
+CREATE INDEX idx_task_watchers_user_id ON task_watchers (user_id);
+
+CREATE TABLE task_activity (
+    id SERIAL PRIMARY KEY,
+    task_id INT NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    actor_id INT REFERENCES users(id) ON DELETE SET NULL,
+    action VARCHAR(30) NOT NULL,
+    subject_id INT REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_task_activity_task_id ON task_activity (task_id, created_at);
+
+-- +goose Down
+DROP TABLE IF EXISTS task_activity;
+DROP TABLE IF EXISTS task_watchers;
+DROP INDEX IF EXISTS idx_tasks_assignee_id;
+ALTER TABLE tasks DROP COLUMN IF EXISTS assignee_id;
@@ -0,0 +1,38 @@
+-- +goose Up
+CREATE TABLE task_comments (
+    id SERIAL PRIMARY KEY,
+    task_id INT NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    author_id INT REFERENCES users(id) ON DELETE SET NULL,
+    body TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    edited_at TIMESTAMPTZ,
+    deleted_at TIMESTAMPTZ,
+    deleted_by INT REFERENCES users(id) ON DELETE SET NULL
+);
+
+CREATE INDEX idx_task_comments_task_id ON task_comments (task_id, id) WHERE deleted_at IS NULL;
+
+-- Every edit keeps the body it replaced.
+CREATE TABLE task_comment_revisions (
+    id SERIAL PRIMARY KEY,
+    comment_id INT NOT NULL REFERENCES task_comments(id) ON DELETE CASCADE,
+    body TEXT NOT NULL,
+    editor_id INT REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_task_comment_revisions_comment_id ON task_comment_revisions (comment_id, id);
+
+CREATE TABLE task_comment_mentions (
+    comment_id INT NOT NULL REFERENCES task_comments(id) ON DELETE CASCADE,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    PRIMARY KEY (comment_id, user_id)
+);
+
+CREATE INDEX idx_task_comment_mentions_user_id ON task_comment_mentions (user_id);
+
+-- +goose Down
+DROP TABLE IF EXISTS task_comment_mentions;
+DROP TABLE IF EXISTS task_comment_revisions;
+DROP TABLE IF EXISTS task_comments;
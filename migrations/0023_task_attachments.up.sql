@@ -0,0 +1,20 @@
+-- +goose Up
+-- A NULL task_id marks a blob waiting to be garbage-collected: its task,
+-- or the attachment itself, has been deleted.
+CREATE TABLE task_attachments (
+    id SERIAL PRIMARY KEY,
+    task_id INT REFERENCES tasks(id) ON DELETE SET NULL,
+    uploader_id INT REFERENCES users(id) ON DELETE SET NULL,
+    filename VARCHAR(255) NOT NULL,
+    content_type VARCHAR(100) NOT NULL,
+    size BIGINT NOT NULL,
+    sha256 CHAR(64) NOT NULL,
+    storage_key VARCHAR(255) NOT NULL UNIQUE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_task_attachments_task_id ON task_attachments (task_id, id);
+CREATE INDEX idx_task_attachments_orphaned ON task_attachments (id) WHERE task_id IS NULL;
+
+-- +goose Down
+DROP TABLE IF EXISTS task_attachments;
@@ -0,0 +1,13 @@
+-- +goose Up
+-- Personal access tokens remember the user's token version when they were
+-- made, so signing out everywhere or resetting the password rejects them
+-- like any other token. Existing tokens keep working.
+ALTER TABLE personal_access_tokens ADD COLUMN token_version INT NOT NULL DEFAULT 0;
+
+UPDATE personal_access_tokens
+SET token_version = users.token_version
+FROM users
+WHERE users.id = personal_access_tokens.user_id;
+
+-- +goose Down
+ALTER TABLE personal_access_tokens DROP COLUMN IF EXISTS token_version;